@@ -2,9 +2,13 @@ package main
 
 import (
 	"github.com/agentpkg/agentpkg/pkg/cmd"
+	_ "github.com/agentpkg/agentpkg/pkg/projector/aider"
 	_ "github.com/agentpkg/agentpkg/pkg/projector/claudecode"
+	_ "github.com/agentpkg/agentpkg/pkg/projector/continuedev"
+	_ "github.com/agentpkg/agentpkg/pkg/projector/copilot"
 	_ "github.com/agentpkg/agentpkg/pkg/projector/cursor"
 	_ "github.com/agentpkg/agentpkg/pkg/projector/gemini"
+	_ "github.com/agentpkg/agentpkg/pkg/projector/goose"
 )
 
 func main() {
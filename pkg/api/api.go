@@ -0,0 +1,277 @@
+// Package api serves apkg operations over a localhost REST API so IDE
+// extensions and GUIs can list, install, and remove packages without
+// invoking the CLI and parsing its output.
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/agentpkg/agentpkg/pkg/installer"
+	"github.com/agentpkg/agentpkg/pkg/workspace"
+)
+
+const (
+	// DefaultPort is the default port for `apkg api`.
+	DefaultPort = 19514
+
+	// TokenFileName is the file in the apkg config dir holding the bearer
+	// token clients must send.
+	TokenFileName = "api.token"
+
+	// maxBodySize limits request bodies, which are small JSON objects.
+	maxBodySize = 1 << 20
+)
+
+// Server handles API requests for the workspaces returned by Open.
+type Server struct {
+	Port int
+	// Token is the bearer token every request must carry.
+	Token string
+	// Open returns the project workspace, or the global one when global is
+	// set (requests with ?global=true).
+	Open func(global bool) (*workspace.Workspace, error)
+
+	// mu serializes operations so concurrent requests don't interleave
+	// manifest and lockfile writes.
+	mu sync.Mutex
+}
+
+// Handler returns the API routes:
+//
+//	GET    /v1/packages            list manifest entries
+//	GET    /v1/status              install state of each manifest entry
+//	POST   /v1/install             install everything in the manifest
+//	POST   /v1/skills              install a skill: {"ref": "...", "scope": "..."}
+//	DELETE /v1/skills/{name}       remove a skill
+//	DELETE /v1/mcp-servers/{name}  remove an MCP server
+//
+// Removals with ?purge=true also purge the store and stop containers (see
+// workspace.Workspace.Remove).
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/packages", s.handle(s.listPackages))
+	mux.HandleFunc("GET /v1/status", s.handle(s.status))
+	mux.HandleFunc("POST /v1/install", s.handle(s.installAll))
+	mux.HandleFunc("POST /v1/skills", s.handle(s.installSkill))
+	mux.HandleFunc("DELETE /v1/skills/{name}", s.handle(s.remove(installer.KindSkill)))
+	mux.HandleFunc("DELETE /v1/mcp-servers/{name}", s.handle(s.remove(installer.KindMCP)))
+	return mux
+}
+
+// ListenAndServe serves the API on 127.0.0.1 and blocks until a shutdown
+// signal is received or ctx is cancelled. It returns nil on clean shutdown.
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	if s.Token == "" {
+		return fmt.Errorf("no API token configured")
+	}
+
+	addr := fmt.Sprintf("127.0.0.1:%d", s.Port)
+	srv := &http.Server{
+		Addr:    addr,
+		Handler: s.Handler(),
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	errCh := make(chan error, 1)
+	go func() {
+		log.Printf("apkg api listening on %s", addr)
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		if err == http.ErrServerClosed {
+			return nil
+		}
+		return err
+	case sig := <-sigCh:
+		log.Printf("received %v, shutting down", sig)
+	case <-ctx.Done():
+		log.Printf("context cancelled, shutting down")
+	}
+
+	if err := srv.Shutdown(context.Background()); err != nil {
+		log.Printf("HTTP server shutdown error: %v", err)
+	}
+	return nil
+}
+
+// LoadOrCreateToken returns the token stored in dir/TokenFileName,
+// generating one (readable only by the user) if the file doesn't exist.
+func LoadOrCreateToken(dir string) (string, error) {
+	path := filepath.Join(dir, TokenFileName)
+	data, err := os.ReadFile(path)
+	if err == nil && len(strings.TrimSpace(string(data))) > 0 {
+		return strings.TrimSpace(string(data)), nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generating API token: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("creating %s: %w", dir, err)
+	}
+	if err := os.WriteFile(path, []byte(token+"\n"), 0o600); err != nil {
+		return "", fmt.Errorf("writing %s: %w", path, err)
+	}
+	return token, nil
+}
+
+// httpError is an error with the HTTP status to respond with.
+type httpError struct {
+	status int
+	err    error
+}
+
+func (e *httpError) Error() string { return e.err.Error() }
+
+// handlerFunc handles a request for ws and returns the status and JSON
+// body of the response (no body if nil).
+type handlerFunc func(r *http.Request, ws *workspace.Workspace) (int, any, error)
+
+// handle authenticates the request, opens its workspace, and runs h with
+// other requests held off.
+func (s *Server) handle(h handlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorized(r) {
+			writeError(w, &httpError{status: http.StatusUnauthorized, err: errors.New("missing or invalid bearer token")})
+			return
+		}
+
+		ws, err := s.Open(r.URL.Query().Get("global") == "true")
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		ws.Command = "apkg api: " + r.Method + " " + r.URL.Path
+		ws.Warn = func(err error) { log.Printf("warning: %v", err) }
+
+		s.mu.Lock()
+		status, body, err := h(r, ws)
+		s.mu.Unlock()
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		if body == nil {
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(body)
+	}
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.Token)) == 1
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var he *httpError
+	switch {
+	case errors.As(err, &he):
+		status = he.status
+	case errors.Is(err, workspace.ErrNotFound):
+		status = http.StatusNotFound
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+func (s *Server) listPackages(r *http.Request, ws *workspace.Workspace) (int, any, error) {
+	nodes, err := ws.Packages()
+	if err != nil {
+		return 0, nil, err
+	}
+	if nodes == nil {
+		nodes = []installer.TreeNode{}
+	}
+	return http.StatusOK, map[string]any{"packages": nodes}, nil
+}
+
+func (s *Server) status(r *http.Request, ws *workspace.Workspace) (int, any, error) {
+	status, err := ws.Status()
+	if err != nil {
+		return 0, nil, err
+	}
+	if status.Packages == nil {
+		status.Packages = []installer.PackageStatus{}
+	}
+	return http.StatusOK, status, nil
+}
+
+func (s *Server) installAll(r *http.Request, ws *workspace.Workspace) (int, any, error) {
+	lf, err := ws.InstallAll(r.Context(), workspace.InstallOptions{})
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, map[string]int{"skills": len(lf.Skills), "mcpServers": len(lf.MCPServers)}, nil
+}
+
+// installSkillRequest is the body of POST /v1/skills.
+type installSkillRequest struct {
+	// Ref is a skill reference as accepted by `apkg install skill`.
+	Ref string `json:"ref"`
+	// Scope is "project" (default) or "user".
+	Scope string `json:"scope,omitempty"`
+}
+
+func (s *Server) installSkill(r *http.Request, ws *workspace.Workspace) (int, any, error) {
+	var req installSkillRequest
+	if err := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxBodySize)).Decode(&req); err != nil {
+		return 0, nil, &httpError{status: http.StatusBadRequest, err: fmt.Errorf("decoding request: %w", err)}
+	}
+	if req.Ref == "" {
+		return 0, nil, &httpError{status: http.StatusBadRequest, err: errors.New(`"ref" is required`)}
+	}
+
+	name, err := ws.InstallSkill(r.Context(), req.Ref, workspace.SkillOptions{Scope: req.Scope})
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusCreated, map[string]string{"name": name}, nil
+}
+
+func (s *Server) remove(kind string) handlerFunc {
+	return func(r *http.Request, ws *workspace.Workspace) (int, any, error) {
+		var skills, mcpServers []string
+		if kind == installer.KindSkill {
+			skills = []string{r.PathValue("name")}
+		} else {
+			mcpServers = []string{r.PathValue("name")}
+		}
+		if _, err := ws.Remove(r.Context(), skills, mcpServers, r.URL.Query().Get("purge") == "true"); err != nil {
+			return 0, nil, err
+		}
+		return http.StatusNoContent, nil, nil
+	}
+}
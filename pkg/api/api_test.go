@@ -0,0 +1,184 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/installer"
+	"github.com/agentpkg/agentpkg/pkg/journal"
+	"github.com/agentpkg/agentpkg/pkg/store"
+	"github.com/agentpkg/agentpkg/pkg/workspace"
+)
+
+const testToken = "test-token"
+
+func newTestServer(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := config.SaveFile(filepath.Join(dir, "apkg.toml"), &config.Config{}); err != nil {
+		t.Fatal(err)
+	}
+
+	skillDir := filepath.Join(dir, "skills", "pdf")
+	if err := os.MkdirAll(skillDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	content := "---\nname: pdf\ndescription: test skill\n---\n# pdf\n"
+	if err := os.WriteFile(filepath.Join(skillDir, "SKILL.md"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Server{
+		Token: testToken,
+		Open: func(global bool) (*workspace.Workspace, error) {
+			return &workspace.Workspace{
+				Dir:          dir,
+				ManifestPath: filepath.Join(dir, "apkg.toml"),
+				LockPath:     filepath.Join(dir, config.LockFileName),
+				Store:        store.New(filepath.Join(dir, "store")),
+				Journal:      &journal.Journal{Path: filepath.Join(dir, "journal.log")},
+			}, nil
+		},
+	}
+	srv := httptest.NewServer(s.Handler())
+	t.Cleanup(srv.Close)
+	return srv, dir
+}
+
+func do(t *testing.T, srv *httptest.Server, method, path, token, body string) (int, map[string]any) {
+	t.Helper()
+	req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var out map[string]any
+	json.NewDecoder(resp.Body).Decode(&out)
+	return resp.StatusCode, out
+}
+
+func TestAuthorization(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	tests := map[string]struct {
+		token      string
+		wantStatus int
+	}{
+		"valid token":   {token: testToken, wantStatus: http.StatusOK},
+		"missing token": {wantStatus: http.StatusUnauthorized},
+		"wrong token":   {token: "guess", wantStatus: http.StatusUnauthorized},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if status, _ := do(t, srv, http.MethodGet, "/v1/packages", tc.token, ""); status != tc.wantStatus {
+				t.Errorf("status = %d, want %d", status, tc.wantStatus)
+			}
+		})
+	}
+}
+
+func TestSkillLifecycle(t *testing.T) {
+	srv, dir := newTestServer(t)
+
+	status, body := do(t, srv, http.MethodPost, "/v1/skills", testToken, `{"ref": "./skills/pdf"}`)
+	if status != http.StatusCreated || body["name"] != "pdf" {
+		t.Fatalf("POST /v1/skills = %d %v, want 201 with name pdf", status, body)
+	}
+
+	cfg, err := config.LoadFile(filepath.Join(dir, "apkg.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Skills["pdf"].Path; got != "./skills/pdf" {
+		t.Errorf("manifest path = %q, want %q", got, "./skills/pdf")
+	}
+
+	status, body = do(t, srv, http.MethodGet, "/v1/packages", testToken, "")
+	packages, _ := body["packages"].([]any)
+	if status != http.StatusOK || len(packages) != 1 {
+		t.Fatalf("GET /v1/packages = %d %v, want one package", status, body)
+	}
+
+	status, body = do(t, srv, http.MethodGet, "/v1/status", testToken, "")
+	entries, _ := body["packages"].([]any)
+	if status != http.StatusOK || len(entries) != 1 || entries[0].(map[string]any)["state"] != installer.StateInstalled {
+		t.Fatalf("GET /v1/status = %d %v, want pdf installed", status, body)
+	}
+
+	if status, body = do(t, srv, http.MethodDelete, "/v1/skills/pdf", testToken, ""); status != http.StatusNoContent {
+		t.Fatalf("DELETE /v1/skills/pdf = %d %v, want 204", status, body)
+	}
+	if status, _ = do(t, srv, http.MethodDelete, "/v1/skills/pdf", testToken, ""); status != http.StatusNotFound {
+		t.Errorf("second DELETE /v1/skills/pdf = %d, want 404", status)
+	}
+
+	events, err := (&journal.Journal{Path: filepath.Join(dir, "journal.log")}).Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Action != journal.ActionInstall || events[1].Action != journal.ActionRemove {
+		t.Errorf("journal = %+v, want install then remove", events)
+	}
+}
+
+func TestInstallSkillBadRequest(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	tests := map[string]struct {
+		body string
+	}{
+		"invalid json": {body: `{"ref":`},
+		"missing ref":  {body: `{}`},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if status, _ := do(t, srv, http.MethodPost, "/v1/skills", testToken, tc.body); status != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", status, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestLoadOrCreateToken(t *testing.T) {
+	dir := t.TempDir()
+
+	token, err := LoadOrCreateToken(dir)
+	if err != nil {
+		t.Fatalf("LoadOrCreateToken() error = %v", err)
+	}
+	if len(token) != 64 {
+		t.Errorf("token length = %d, want 64", len(token))
+	}
+
+	info, err := os.Stat(filepath.Join(dir, TokenFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("token file mode = %o, want 600", perm)
+	}
+
+	again, err := LoadOrCreateToken(dir)
+	if err != nil {
+		t.Fatalf("second LoadOrCreateToken() error = %v", err)
+	}
+	if again != token {
+		t.Errorf("second LoadOrCreateToken() = %q, want the stored token %q", again, token)
+	}
+}
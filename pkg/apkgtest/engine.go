@@ -0,0 +1,21 @@
+package apkgtest
+
+import (
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/container"
+)
+
+// NewEngine returns a container engine that runs nothing (see
+// container.Fake), with images listed in images already pulled, so tests
+// can use it wherever apkg takes a container.Engine.
+func NewEngine(t testing.TB, images ...string) *container.Fake {
+	t.Helper()
+	engine := &container.Fake{}
+	for _, image := range images {
+		if err := engine.Pull(t.Context(), image); err != nil {
+			t.Fatalf("pulling %s: %v", image, err)
+		}
+	}
+	return engine
+}
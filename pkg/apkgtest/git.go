@@ -0,0 +1,128 @@
+// Package apkgtest provides local stand-ins for the services apkg fetches
+// packages from, so source integrations can be tested without network
+// access: a git server, fake npm and PyPI registries, and a fake
+// container engine.
+package apkgtest
+
+import (
+	"net/http/cgi"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// RequireGit skips the test if git is not available.
+func RequireGit(t testing.TB) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found in PATH")
+	}
+}
+
+// GitServer serves bare repositories over git's smart HTTP protocol, as
+// git hosts do, through git http-backend. Tests using it need git, and
+// skip without it.
+type GitServer struct {
+	// URL is the base URL of the server's repositories.
+	URL  string
+	root string
+}
+
+// NewGitServer starts a git server, stopped when the test ends.
+func NewGitServer(t testing.TB) *GitServer {
+	t.Helper()
+	RequireGit(t)
+	git, _ := exec.LookPath("git")
+	root := t.TempDir()
+	srv := httptest.NewServer(&cgi.Handler{
+		Path: git,
+		Args: []string{"http-backend"},
+		Env: []string{
+			"GIT_PROJECT_ROOT=" + root,
+			"GIT_HTTP_EXPORT_ALL=1",
+		},
+	})
+	t.Cleanup(srv.Close)
+	return &GitServer{URL: srv.URL, root: root}
+}
+
+// GitRepo is a repository on a GitServer, with a work tree commits are
+// made in and pushed from.
+type GitRepo struct {
+	// URL clones the repository, e.g. "http://127.0.0.1:1234/skills.git".
+	URL  string
+	work string
+}
+
+// NewRepo creates an empty repository name (e.g. "org/skills") on the
+// server. Its default branch is main.
+func (s *GitServer) NewRepo(t testing.TB, name string) *GitRepo {
+	t.Helper()
+	bare := filepath.Join(s.root, name+".git")
+	work := t.TempDir()
+	runGit(t, "", "init", "--bare", "--initial-branch=main", bare)
+	// Pushes over http-backend need receive-pack enabled; these are
+	// pushed to on disk, but enable it so tests can push over HTTP too.
+	runGit(t, bare, "config", "http.receivepack", "true")
+	runGit(t, "", "init", "--initial-branch=main", work)
+	runGit(t, work, "config", "user.email", "apkgtest@example.com")
+	runGit(t, work, "config", "user.name", "apkgtest")
+	runGit(t, work, "remote", "add", "origin", bare)
+	return &GitRepo{URL: s.URL + "/" + name + ".git", work: work}
+}
+
+// Commit writes files (paths relative to the repository root, with
+// forward slashes) over the work tree, commits them to main, pushes, and
+// returns the commit hash.
+func (r *GitRepo) Commit(t testing.TB, files map[string]string, message string) string {
+	t.Helper()
+	writeFiles(t, r.work, files)
+	runGit(t, r.work, "add", "-A")
+	runGit(t, r.work, "commit", "--allow-empty", "-m", message)
+	runGit(t, r.work, "push", "origin", "main")
+	return runGit(t, r.work, "rev-parse", "HEAD")
+}
+
+// Tag tags the last commit name, annotated with message if it isn't
+// empty, and pushes the tag.
+func (r *GitRepo) Tag(t testing.TB, name, message string) {
+	t.Helper()
+	if message == "" {
+		runGit(t, r.work, "tag", name)
+	} else {
+		runGit(t, r.work, "tag", "-a", name, "-m", message)
+	}
+	runGit(t, r.work, "push", "origin", name)
+}
+
+// runGit runs git with args in dir and returns its trimmed output,
+// failing the test if it fails.
+func runGit(t testing.TB, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	// Keep the user's git config out of the fixtures.
+	cmd.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL="+os.DevNull, "GIT_CONFIG_NOSYSTEM=1")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// writeFiles writes files, by slash-separated path, under dir.
+func writeFiles(t testing.TB, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package apkgtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// MCPServer is a fake MCP server on the streamable HTTP transport at URL,
+// offering tools by name and description. It answers initialize with JSON
+// and tools/list with an event stream, as servers may do either, and
+// records the requests it got.
+type MCPServer struct {
+	URL string
+
+	tools map[string]string
+
+	mu      sync.Mutex
+	methods []string
+	headers []http.Header
+}
+
+// NewMCPServer starts a server offering tools (names to descriptions),
+// stopped when the test ends.
+func NewMCPServer(t testing.TB, tools map[string]string) *MCPServer {
+	t.Helper()
+	s := &MCPServer{tools: tools}
+	srv := httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(srv.Close)
+	s.URL = srv.URL + "/mcp"
+	return s
+}
+
+// Methods returns the methods of the messages the server got, in order.
+func (s *MCPServer) Methods() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.methods...)
+}
+
+// Headers returns the headers of the requests the server got, in order.
+func (s *MCPServer) Headers() []http.Header {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]http.Header(nil), s.headers...)
+}
+
+func (s *MCPServer) serve(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost || req.URL.Path != "/mcp" {
+		http.NotFound(w, req)
+		return
+	}
+	var msg struct {
+		ID     *int   `json:"id"`
+		Method string `json:"method"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&msg); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	s.methods = append(s.methods, msg.Method)
+	s.headers = append(s.headers, req.Header.Clone())
+	s.mu.Unlock()
+
+	if msg.ID == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	switch msg.Method {
+	case "initialize":
+		w.Header().Set("Mcp-Session-Id", "apkgtest")
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      *msg.ID,
+			"result": map[string]any{
+				"protocolVersion": "2025-06-18",
+				"capabilities":    map[string]any{"tools": map[string]any{}},
+				"serverInfo":      map[string]any{"name": "apkgtest", "version": "1"},
+			},
+		})
+	case "tools/list":
+		if req.Header.Get("Mcp-Session-Id") != "apkgtest" {
+			http.Error(w, "missing session", http.StatusBadRequest)
+			return
+		}
+		names := make([]string, 0, len(s.tools))
+		for name := range s.tools {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		tools := []map[string]any{}
+		for _, name := range names {
+			tools = append(tools, map[string]any{"name": name, "description": s.tools[name], "inputSchema": map[string]any{"type": "object"}})
+		}
+		data, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": *msg.ID, "result": map[string]any{"tools": tools}})
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "event: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/message\",\"params\":{}}\n\n")
+		fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"jsonrpc": "2.0",
+			"id":      *msg.ID,
+			"error":   map[string]any{"code": -32601, "message": "method not found"},
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package apkgtest
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// NPMRegistry is a fake npm registry serving the packages published to
+// it: packuments at /<name>, version documents at /<name>/<version-or-tag>
+// (as the registry API apkg queries directly), and tarballs at
+// /<name>/-/<file>.tgz. Point npm at it with Setenv.
+type NPMRegistry struct {
+	URL string
+
+	mu       sync.Mutex
+	packages map[string]*npmPackage
+}
+
+type npmPackage struct {
+	versions map[string]map[string]any
+	tarballs map[string][]byte
+	latest   string
+}
+
+// NewNPMRegistry starts a registry, stopped when the test ends.
+func NewNPMRegistry(t testing.TB) *NPMRegistry {
+	t.Helper()
+	r := &NPMRegistry{packages: make(map[string]*npmPackage)}
+	srv := httptest.NewServer(http.HandlerFunc(r.serve))
+	t.Cleanup(srv.Close)
+	r.URL = srv.URL
+	return r
+}
+
+// Setenv points npm at the registry for the rest of the test, and gives it
+// a cache of its own so packages of other tests don't leak in.
+func (r *NPMRegistry) Setenv(t testing.TB) {
+	t.Helper()
+	t.Setenv("npm_config_registry", r.URL+"/")
+	t.Setenv("npm_config_cache", t.TempDir())
+	t.Setenv("npm_config_audit", "false")
+	t.Setenv("npm_config_fund", "false")
+	t.Setenv("npm_config_update_notifier", "false")
+}
+
+// Publish adds version of the package name with files (paths relative to
+// the package root), tagging it latest, and returns its integrity as the
+// registry publishes it (dist.integrity). A package.json naming the
+// package is added unless files has one; its fields, such as bin, also go
+// into the version's document.
+func (r *NPMRegistry) Publish(t testing.TB, name, version string, files map[string]string) string {
+	t.Helper()
+	manifest := map[string]any{}
+	if data, ok := files["package.json"]; ok {
+		if err := json.Unmarshal([]byte(data), &manifest); err != nil {
+			t.Fatalf("parsing package.json of %s@%s: %v", name, version, err)
+		}
+	}
+	manifest["name"], manifest["version"] = name, version
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := map[string]string{"package.json": string(data)}
+	for name, content := range files {
+		if name != "package.json" {
+			all[name] = content
+		}
+	}
+
+	tarball := npmTarball(t, all)
+	sum512 := sha512.Sum512(tarball)
+	sum1 := sha1.Sum(tarball)
+	integrity := "sha512-" + base64.StdEncoding.EncodeToString(sum512[:])
+	file := path.Base(name) + "-" + version + ".tgz"
+	manifest["dist"] = map[string]any{
+		"tarball":   r.URL + "/" + name + "/-/" + file,
+		"integrity": integrity,
+		"shasum":    hex.EncodeToString(sum1[:]),
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pkg := r.packages[name]
+	if pkg == nil {
+		pkg = &npmPackage{versions: make(map[string]map[string]any), tarballs: make(map[string][]byte)}
+		r.packages[name] = pkg
+	}
+	pkg.versions[version] = manifest
+	pkg.tarballs[file] = tarball
+	pkg.latest = version
+	return integrity
+}
+
+// Deprecate marks version of the package name deprecated with message.
+func (r *NPMRegistry) Deprecate(name, version, message string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if pkg := r.packages[name]; pkg != nil && pkg.versions[version] != nil {
+		pkg.versions[version]["deprecated"] = message
+	}
+}
+
+func (r *NPMRegistry) serve(w http.ResponseWriter, req *http.Request) {
+	// Scoped names come escaped ("@scope%2fname") from npm and unescaped
+	// from other clients.
+	p, err := url.PathUnescape(req.URL.EscapedPath())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	p = strings.TrimPrefix(p, "/")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if name, file, ok := strings.Cut(p, "/-/"); ok {
+		if pkg := r.packages[name]; pkg != nil && pkg.tarballs[file] != nil {
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write(pkg.tarballs[file])
+			return
+		}
+		http.NotFound(w, req)
+		return
+	}
+
+	name, rest := p, ""
+	segs := strings.SplitN(p, "/", 3)
+	if strings.HasPrefix(p, "@") && len(segs) == 3 {
+		name, rest = segs[0]+"/"+segs[1], segs[2]
+	} else if !strings.HasPrefix(p, "@") && len(segs) == 2 {
+		name, rest = segs[0], segs[1]
+	}
+	pkg := r.packages[name]
+	if pkg == nil {
+		http.NotFound(w, req)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if rest == "" {
+		json.NewEncoder(w).Encode(pkg.packument(name))
+		return
+	}
+	version := rest
+	if version == "latest" {
+		version = pkg.latest
+	}
+	if doc := pkg.versions[version]; doc != nil {
+		json.NewEncoder(w).Encode(doc)
+		return
+	}
+	http.NotFound(w, req)
+}
+
+// packument returns the registry document of the package name.
+func (pkg *npmPackage) packument(name string) map[string]any {
+	versions := make([]string, 0, len(pkg.versions))
+	for v := range pkg.versions {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+	docs := make(map[string]any, len(versions))
+	for _, v := range versions {
+		docs[v] = pkg.versions[v]
+	}
+	return map[string]any{
+		"name":      name,
+		"dist-tags": map[string]string{"latest": pkg.latest},
+		"versions":  docs,
+	}
+}
+
+// npmTarball returns a package tarball of files, under package/ as npm
+// packs them.
+func npmTarball(t testing.TB, files map[string]string) []byte {
+	t.Helper()
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, name := range names {
+		content := files[name]
+		mode := int64(0o644)
+		if strings.HasPrefix(content, "#!") {
+			mode = 0o755
+		}
+		hdr := &tar.Header{Name: "package/" + name, Mode: mode, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,54 @@
+package apkgtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestNPMRegistry(t *testing.T) {
+	r := NewNPMRegistry(t)
+	integrity := r.Publish(t, "@scope/server", "1.0.0", map[string]string{
+		"package.json": `{"bin": {"server": "cli.js"}}`,
+		"cli.js":       "#!/usr/bin/env node\n",
+	})
+
+	get := func(path string, v any) int {
+		t.Helper()
+		resp, err := http.Get(r.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if v != nil && resp.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+				t.Fatalf("GET %s: %v", path, err)
+			}
+		}
+		return resp.StatusCode
+	}
+
+	var packument struct {
+		DistTags map[string]string         `json:"dist-tags"`
+		Versions map[string]map[string]any `json:"versions"`
+	}
+	if code := get("/@scope%2fserver", &packument); code != http.StatusOK || packument.DistTags["latest"] != "1.0.0" || packument.Versions["1.0.0"]["bin"] == nil {
+		t.Errorf("packument = %d %+v", code, packument)
+	}
+
+	var version struct {
+		Dist struct {
+			Integrity string `json:"integrity"`
+			Tarball   string `json:"tarball"`
+		} `json:"dist"`
+	}
+	if code := get("/@scope/server/latest", &version); code != http.StatusOK || version.Dist.Integrity != integrity {
+		t.Errorf("latest = %d %+v, want integrity %s", code, version, integrity)
+	}
+	if code := get(version.Dist.Tarball[len(r.URL):], nil); code != http.StatusOK {
+		t.Errorf("tarball status = %d", code)
+	}
+	if code := get("/missing", nil); code != http.StatusNotFound {
+		t.Errorf("missing package status = %d, want 404", code)
+	}
+}
@@ -0,0 +1,178 @@
+package apkgtest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// PyPIRegistry is a fake PyPI serving the releases published to it: the
+// JSON API at /pypi/<name>/json and /pypi/<name>/<version>/json, the
+// simple index installers use at /simple/<name>/, and the release files at
+// /files/<filename>. Point uv at it with Setenv.
+type PyPIRegistry struct {
+	URL string
+
+	mu       sync.Mutex
+	packages map[string]*pypiPackage
+}
+
+type pypiPackage struct {
+	name     string
+	releases map[string][]pypiFile
+	yanked   map[string]string
+	latest   string
+}
+
+type pypiFile struct {
+	filename string
+	data     []byte
+	sha256   string
+}
+
+// pypiNonAlnum matches what PEP 503 normalizes to "-" in package names.
+var pypiNonAlnum = regexp.MustCompile(`[-_.]+`)
+
+// NewPyPIRegistry starts a registry, stopped when the test ends.
+func NewPyPIRegistry(t testing.TB) *PyPIRegistry {
+	t.Helper()
+	r := &PyPIRegistry{packages: make(map[string]*pypiPackage)}
+	srv := httptest.NewServer(http.HandlerFunc(r.serve))
+	t.Cleanup(srv.Close)
+	r.URL = srv.URL
+	return r
+}
+
+// Setenv points uv at the registry's simple index for the rest of the
+// test, instead of PyPI.
+func (r *PyPIRegistry) Setenv(t testing.TB) {
+	t.Helper()
+	t.Setenv("UV_INDEX_URL", r.URL+"/simple/")
+	t.Setenv("UV_DEFAULT_INDEX", r.URL+"/simple/")
+	t.Setenv("UV_CACHE_DIR", t.TempDir())
+}
+
+// Publish adds the release file filename (e.g. a wheel) with data to
+// version of the package name, making it the latest version, and returns
+// the file's sha256 digest as PyPI publishes it.
+func (r *PyPIRegistry) Publish(name, version, filename string, data []byte) string {
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:])
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := normalizePyPIName(name)
+	pkg := r.packages[key]
+	if pkg == nil {
+		pkg = &pypiPackage{name: name, releases: make(map[string][]pypiFile), yanked: make(map[string]string)}
+		r.packages[key] = pkg
+	}
+	pkg.releases[version] = append(pkg.releases[version], pypiFile{filename: filename, data: data, sha256: digest})
+	pkg.latest = version
+	return digest
+}
+
+// Yank marks version of the package name yanked for reason.
+func (r *PyPIRegistry) Yank(name, version, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if pkg := r.packages[normalizePyPIName(name)]; pkg != nil {
+		pkg.yanked[version] = reason
+	}
+}
+
+func (r *PyPIRegistry) serve(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	segs := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	switch {
+	case len(segs) == 2 && segs[0] == "files":
+		for _, pkg := range r.packages {
+			for _, files := range pkg.releases {
+				for _, f := range files {
+					if f.filename == segs[1] {
+						w.Header().Set("Content-Type", "application/octet-stream")
+						w.Write(f.data)
+						return
+					}
+				}
+			}
+		}
+	case len(segs) == 2 && segs[0] == "simple":
+		if pkg := r.packages[normalizePyPIName(segs[1])]; pkg != nil {
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprintf(w, "<!DOCTYPE html>\n<html><body>\n")
+			for _, version := range pkg.versions() {
+				for _, f := range pkg.releases[version] {
+					yanked := ""
+					if reason, ok := pkg.yanked[version]; ok {
+						yanked = fmt.Sprintf(` data-yanked="%s"`, html.EscapeString(reason))
+					}
+					fmt.Fprintf(w, "<a href=\"%s/files/%s#sha256=%s\"%s>%s</a>\n", r.URL, f.filename, f.sha256, yanked, html.EscapeString(f.filename))
+				}
+			}
+			fmt.Fprintf(w, "</body></html>\n")
+			return
+		}
+	case len(segs) == 3 && segs[0] == "pypi" && segs[2] == "json":
+		if pkg := r.packages[normalizePyPIName(segs[1])]; pkg != nil {
+			r.writeRelease(w, pkg, pkg.latest)
+			return
+		}
+	case len(segs) == 4 && segs[0] == "pypi" && segs[3] == "json":
+		if pkg := r.packages[normalizePyPIName(segs[1])]; pkg != nil && pkg.releases[segs[2]] != nil {
+			r.writeRelease(w, pkg, segs[2])
+			return
+		}
+	}
+	http.NotFound(w, req)
+}
+
+// writeRelease writes the JSON API document of version of pkg.
+func (r *PyPIRegistry) writeRelease(w http.ResponseWriter, pkg *pypiPackage, version string) {
+	type file struct {
+		Filename string            `json:"filename"`
+		URL      string            `json:"url"`
+		Digests  map[string]string `json:"digests"`
+	}
+	urls := []file{}
+	for _, f := range pkg.releases[version] {
+		urls = append(urls, file{Filename: f.filename, URL: r.URL + "/files/" + f.filename, Digests: map[string]string{"sha256": f.sha256}})
+	}
+	reason, yanked := pkg.yanked[version]
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"info": map[string]any{
+			"name":          pkg.name,
+			"version":       version,
+			"yanked":        yanked,
+			"yanked_reason": reason,
+		},
+		"urls": urls,
+	})
+}
+
+// versions returns the versions of pkg, sorted.
+func (pkg *pypiPackage) versions() []string {
+	versions := make([]string, 0, len(pkg.releases))
+	for v := range pkg.releases {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// normalizePyPIName normalizes a package name as PEP 503 does.
+func normalizePyPIName(name string) string {
+	return strings.ToLower(pypiNonAlnum.ReplaceAllString(name, "-"))
+}
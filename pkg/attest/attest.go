@@ -0,0 +1,242 @@
+// Package attest signs lockfiles and verifies their signatures, so CI can
+// check that the packages it installs are the ones a team approved.
+//
+// A lockfile is signed either with an ed25519 team key, whose signature is
+// kept next to it in "<lockfile>.sig", or keylessly with sigstore through
+// the cosign CLI, whose bundle is kept in "<lockfile>.sigstore.json". Both
+// sign the lockfile's bytes, so any later change to it breaks the
+// signature.
+package attest
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Suffixes of the files holding a lockfile's signature.
+const (
+	SigSuffix    = ".sig"
+	BundleSuffix = ".sigstore.json"
+)
+
+// sigAlgorithm is the first field of a signature file.
+const sigAlgorithm = "ed25519"
+
+// ErrUnsigned is returned when a lockfile has no signature to verify.
+var ErrUnsigned = errors.New("lockfile is not signed")
+
+// SignatureError reports a lockfile whose signature doesn't verify, e.g.
+// because it changed after it was signed.
+type SignatureError struct {
+	Path   string
+	Reason string
+}
+
+func (e *SignatureError) Error() string {
+	return fmt.Sprintf("signature of %s doesn't verify: %s", e.Path, e.Reason)
+}
+
+// GenerateKey returns a new ed25519 key pair, PEM-encoded.
+func GenerateKey() (privPEM, pubPEM []byte, err error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generating key: %w", err)
+	}
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encoding private key: %w", err)
+	}
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encoding public key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), nil
+}
+
+// Sign returns the signature file content for data signed with the
+// PEM-encoded ed25519 private key.
+func Sign(data, privPEM []byte) ([]byte, error) {
+	key, err := parsePrivateKey(privPEM)
+	if err != nil {
+		return nil, err
+	}
+	sig := ed25519.Sign(key, data)
+	line := fmt.Sprintf("%s %s %s\n", sigAlgorithm, KeyID(key.Public().(ed25519.PublicKey)), base64.StdEncoding.EncodeToString(sig))
+	return []byte(line), nil
+}
+
+// Verify checks that sig, the content of a signature file, signs data with
+// the private key of the PEM-encoded ed25519 public key. path names the
+// signed file in errors.
+func Verify(path string, data, sig, pubPEM []byte) error {
+	key, err := parsePublicKey(pubPEM)
+	if err != nil {
+		return err
+	}
+	fields := strings.Fields(string(sig))
+	if len(fields) != 3 || fields[0] != sigAlgorithm {
+		return &SignatureError{Path: path, Reason: "malformed signature file"}
+	}
+	if id := KeyID(key); fields[1] != id {
+		return &SignatureError{Path: path, Reason: fmt.Sprintf("signed with key %s, not %s", fields[1], id)}
+	}
+	raw, err := base64.StdEncoding.DecodeString(fields[2])
+	if err != nil {
+		return &SignatureError{Path: path, Reason: "malformed signature file"}
+	}
+	if !ed25519.Verify(key, data, raw) {
+		return &SignatureError{Path: path, Reason: "it changed after it was signed"}
+	}
+	return nil
+}
+
+// KeyID returns a short fingerprint of key, to tell keys apart in messages.
+func KeyID(key ed25519.PublicKey) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+// SignFile signs the file at path with the private key at keyPath, and
+// writes the signature to path+SigSuffix.
+func SignFile(path, keyPath string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+	privPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return fmt.Errorf("reading key: %w", err)
+	}
+	sig, err := Sign(data, privPEM)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path+SigSuffix, sig, 0o644)
+}
+
+// VerifyFile checks the signature in path+SigSuffix against the file at
+// path and the public key at keyPath, returning ErrUnsigned if there is no
+// signature.
+func VerifyFile(path, keyPath string) error {
+	sig, err := os.ReadFile(path + SigSuffix)
+	if os.IsNotExist(err) {
+		return ErrUnsigned
+	}
+	if err != nil {
+		return fmt.Errorf("reading signature: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+	pubPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return fmt.Errorf("reading key: %w", err)
+	}
+	return Verify(path, data, sig, pubPEM)
+}
+
+// Signed reports whether the file at path has a signature of either kind.
+func Signed(path string) bool {
+	for _, suffix := range []string{SigSuffix, BundleSuffix} {
+		if _, err := os.Stat(path + suffix); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// lookPath finds cosign; tests replace it.
+var lookPath = exec.LookPath
+
+// cosign returns the path of the cosign binary keyless signing runs.
+func cosign() (string, error) {
+	bin, err := lookPath("cosign")
+	if err != nil {
+		return "", errors.New("keyless signing needs cosign; install it from https://docs.sigstore.dev/cosign/system_config/installation/")
+	}
+	return bin, nil
+}
+
+// SignKeyless signs the file at path with a short-lived sigstore
+// certificate for the identity cosign authenticates (interactively, or
+// from the CI's OIDC token), and writes the bundle to path+BundleSuffix.
+func SignKeyless(ctx context.Context, path string) error {
+	bin, err := cosign()
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, bin, "sign-blob", "--yes", "--bundle", path+BundleSuffix, path)
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("cosign sign-blob: %w", err)
+	}
+	return nil
+}
+
+// VerifyKeyless checks the sigstore bundle in path+BundleSuffix against the
+// file at path, requiring its certificate to be issued to identity by the
+// OIDC issuer, and returns ErrUnsigned if there is no bundle.
+func VerifyKeyless(ctx context.Context, path, identity, issuer string) error {
+	if _, err := os.Stat(path + BundleSuffix); os.IsNotExist(err) {
+		return ErrUnsigned
+	}
+	bin, err := cosign()
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, bin, "verify-blob",
+		"--bundle", path+BundleSuffix,
+		"--certificate-identity", identity,
+		"--certificate-oidc-issuer", issuer,
+		path)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return &SignatureError{Path: path, Reason: strings.TrimSpace(string(out))}
+	}
+	return nil
+}
+
+func parsePrivateKey(data []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("key is not PEM-encoded")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing private key: %w", err)
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("key is not an ed25519 private key")
+	}
+	return priv, nil
+}
+
+func parsePublicKey(data []byte) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("key is not PEM-encoded")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing public key: %w", err)
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.New("key is not an ed25519 public key")
+	}
+	return pub, nil
+}
@@ -0,0 +1,118 @@
+package attest
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifyFile(t *testing.T) {
+	const lock = "version = 1\n"
+
+	tests := map[string]struct {
+		modify       func(t *testing.T, lockPath, otherPub string) string // returns the key to verify with
+		wantUnsigned bool
+		wantBadSig   bool
+	}{
+		"signed": {},
+		"lockfile changed": {
+			modify: func(t *testing.T, lockPath, _ string) string {
+				writeFile(t, lockPath, lock+"\n[[skills]]\n")
+				return ""
+			},
+			wantBadSig: true,
+		},
+		"other key": {
+			modify:     func(_ *testing.T, _, otherPub string) string { return otherPub },
+			wantBadSig: true,
+		},
+		"unsigned": {
+			modify: func(t *testing.T, lockPath, _ string) string {
+				os.Remove(lockPath + SigSuffix)
+				return ""
+			},
+			wantUnsigned: true,
+		},
+		"malformed signature": {
+			modify: func(t *testing.T, lockPath, _ string) string {
+				writeFile(t, lockPath+SigSuffix, "garbage")
+				return ""
+			},
+			wantBadSig: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			lockPath := filepath.Join(dir, "apkg-lock.toml")
+			writeFile(t, lockPath, lock)
+			privPath, pubPath := writeKeys(t, dir, "team")
+			_, otherPub := writeKeys(t, dir, "other")
+
+			if err := SignFile(lockPath, privPath); err != nil {
+				t.Fatalf("SignFile() error = %v", err)
+			}
+			if !Signed(lockPath) {
+				t.Errorf("Signed() = false after SignFile")
+			}
+			if tc.modify != nil {
+				if key := tc.modify(t, lockPath, otherPub); key != "" {
+					pubPath = key
+				}
+			}
+
+			err := VerifyFile(lockPath, pubPath)
+			var sigErr *SignatureError
+			switch {
+			case tc.wantUnsigned:
+				if !errors.Is(err, ErrUnsigned) {
+					t.Errorf("VerifyFile() error = %v, want ErrUnsigned", err)
+				}
+			case tc.wantBadSig:
+				if !errors.As(err, &sigErr) {
+					t.Errorf("VerifyFile() error = %v, want *SignatureError", err)
+				}
+			case err != nil:
+				t.Errorf("VerifyFile() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestSignKeylessWithoutCosign(t *testing.T) {
+	lookPath = func(string) (string, error) { return "", exec.ErrNotFound }
+	t.Cleanup(func() { lookPath = exec.LookPath })
+
+	lockPath := filepath.Join(t.TempDir(), "apkg-lock.toml")
+	writeFile(t, lockPath, "version = 1\n")
+	if err := SignKeyless(context.Background(), lockPath); err == nil {
+		t.Error("SignKeyless() error = nil, want missing cosign")
+	}
+	if err := VerifyKeyless(context.Background(), lockPath, "me@example.com", "https://accounts.example.com"); !errors.Is(err, ErrUnsigned) {
+		t.Errorf("VerifyKeyless() error = %v, want ErrUnsigned", err)
+	}
+}
+
+func writeKeys(t *testing.T, dir, name string) (privPath, pubPath string) {
+	t.Helper()
+	priv, pub, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	privPath = filepath.Join(dir, name+".key")
+	pubPath = privPath + ".pub"
+	writeFile(t, privPath, string(priv))
+	writeFile(t, pubPath, string(pub))
+	return privPath, pubPath
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/agentpkg/agentpkg/pkg/project"
+	"github.com/spf13/cobra"
+)
+
+func newAgentsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "agents",
+		Short: "List supported agents and where they project packages",
+		Long: `Lists every agent apkg can project into, whether it supports skills and
+MCP servers, and the skills directory and MCP config file it writes for the
+current project and for global installs. Paths that don't exist yet are
+marked as missing. Configured agents are marked with *.`,
+		Args: cobra.NoArgs,
+		RunE: runAgents,
+	}
+}
+
+func runAgents(cmd *cobra.Command, args []string) error {
+	infos, err := project.DescribeAgents(ProjectDir)
+	if err != nil {
+		return err
+	}
+
+	w := cmd.OutOrStdout()
+	for i, info := range infos {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+
+		marker := " "
+		if slices.Contains(DevCfg.Agents, info.Name) {
+			marker = "*"
+		}
+		var supports []string
+		if info.SupportsSkills {
+			supports = append(supports, "skills")
+		}
+		if info.SupportsMCPServers {
+			supports = append(supports, "mcp")
+		}
+		fmt.Fprintf(w, "%s %s (%s)\n", marker, info.Name, strings.Join(supports, ", "))
+
+		printTargets(w, "project", info.Project)
+		printTargets(w, "global", info.Global)
+	}
+	return nil
+}
+
+func printTargets(w io.Writer, scope string, targets []project.Target) {
+	for _, t := range targets {
+		status := ""
+		if !t.Exists {
+			status = " (missing)"
+		}
+		fmt.Fprintf(w, "    %-7s %-6s %s%s\n", scope, t.Kind, t.Path, status)
+	}
+}
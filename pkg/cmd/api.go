@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/agentpkg/agentpkg/pkg/api"
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+func newAPICmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "api",
+		Short: "Serve apkg operations over a localhost REST API",
+		Long: `Starts an HTTP server on 127.0.0.1 that lists, installs, and removes
+packages of the current project (or the global scope, with ?global=true) for
+IDE extensions and GUIs:
+
+  GET    /v1/packages            list manifest entries
+  GET    /v1/status              install state of each manifest entry
+  POST   /v1/install             install everything in apkg.toml
+  POST   /v1/skills              install a skill: {"ref": "...", "scope": "..."}
+  DELETE /v1/skills/{name}       remove a skill
+  DELETE /v1/mcp-servers/{name}  remove an MCP server
+
+Removals with ?purge=true also delete the store entries no project uses any
+more and stop the removed container servers, like apkg remove --purge.
+
+Requests must send "Authorization: Bearer <token>", where the token is read
+from ~/.apkg/api.token (created on first start). Responses are JSON.`,
+		Args: cobra.NoArgs,
+		RunE: runAPI,
+	}
+
+	cmd.Flags().Int("port", api.DefaultPort, "Port to listen on")
+
+	return cmd
+}
+
+func runAPI(cmd *cobra.Command, args []string) error {
+	port, err := cmd.Flags().GetInt("port")
+	if err != nil {
+		return err
+	}
+
+	dir, err := config.GlobalConfigDir()
+	if err != nil {
+		return err
+	}
+	token, err := api.LoadOrCreateToken(dir)
+	if err != nil {
+		return err
+	}
+
+	projectDir := ProjectDir
+	srv := &api.Server{
+		Port:  port,
+		Token: token,
+		Open: func(global bool) (*workspace.Workspace, error) {
+			return workspace.Open(projectDir, global)
+		},
+	}
+
+	fmt.Fprintf(progressOut(cmd), "Serving %s on http://127.0.0.1:%d (token in %s)\n", projectDir, port, filepath.Join(dir, api.TokenFileName))
+	return srv.ListenAndServe(cmd.Context())
+}
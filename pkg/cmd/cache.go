@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/agentpkg/agentpkg/pkg/installer"
+	"github.com/agentpkg/agentpkg/pkg/journal"
+	"github.com/agentpkg/agentpkg/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+func newCacheCmd() *cobra.Command {
+	cacheCmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the package store",
+	}
+
+	gcCmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Delete store entries no lockfile references",
+		Long: `Deletes the fetched skill repos and MCP server packages (npm, uv, go,
+oci, and static entries) in the store that no known lockfile references,
+and prints the space reclaimed.
+
+The known lockfiles are the global lockfile, the current project's, and
+those of every project in apkg history or apkg list --all-projects that
+still exists. A project apkg
+hasn't recorded re-fetches deleted entries on its next install.`,
+		Args: cobra.NoArgs,
+		RunE: runCacheGC,
+	}
+	gcCmd.Flags().Bool("dry-run", false, "Print what would be deleted without deleting it")
+
+	lsCmd := &cobra.Command{
+		Use:   "ls [path]",
+		Short: "List the packages in the store",
+		Long: `Lists the skill repos and MCP server packages in the store, or those under
+path (e.g. npm or repos/github.com/org), with their size and the last time
+an install used them. Entries no install has recorded using show when
+they were fetched.
+
+With --json, the entries are printed as a JSON array.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runCacheLs,
+	}
+	lsCmd.Flags().Bool("json", false, "Print the entries as JSON")
+
+	pathCmd := &cobra.Command{
+		Use:   "path [path]",
+		Short: "Print the location of the store",
+		Long: `Prints the store directory, or the location of path within it, e.g.
+apkg cache path npm/@modelcontextprotocol/server-filesystem.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runCachePath,
+	}
+
+	rmCmd := &cobra.Command{
+		Use:   "rm <path>...",
+		Short: "Delete packages from the store",
+		Long: `Deletes store entries by the paths apkg cache ls prints, or directories of
+them, e.g. npm/@modelcontextprotocol/server-filesystem for every cached
+version. Projects using a deleted entry re-fetch it on their next install.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: runCacheRm,
+	}
+
+	cacheCmd.AddCommand(gcCmd, lsCmd, pathCmd, rmCmd)
+	return cacheCmd
+}
+
+func runCacheLs(cmd *cobra.Command, args []string) error {
+	asJSON, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		return err
+	}
+	var prefix string
+	if len(args) > 0 {
+		prefix = args[0]
+	}
+
+	ws, err := commandWorkspace(cmd)
+	if err != nil {
+		return err
+	}
+	entries, err := ws.Installer().CacheEntries(prefix)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		if entries == nil {
+			entries = []installer.CacheEntry{}
+		}
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+
+	if len(entries) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "No packages in the store")
+		return nil
+	}
+
+	var total int64
+	tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PATH\tSIZE\tLAST USED")
+	for _, e := range entries {
+		total += e.Size
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", e.Path, formatBytes(e.Size), e.LastUsed.Local().Format(time.DateTime))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "%d package(s), %s\n", len(entries), formatBytes(total))
+	return nil
+}
+
+func runCachePath(cmd *cobra.Command, args []string) error {
+	s, err := openStore()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), s.Path(args...))
+	return nil
+}
+
+func runCacheRm(cmd *cobra.Command, args []string) error {
+	ws, err := commandWorkspace(cmd)
+	if err != nil {
+		return err
+	}
+	inst, s := ws.Installer(), ws.Store
+
+	w := cmd.OutOrStdout()
+	var events []journal.Event
+	var errs []error
+	for _, rel := range args {
+		g, err := inst.RemoveCacheEntry(rel)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		fmt.Fprintf(w, "Deleted %s (%s)\n", g.Path, formatBytes(g.Size))
+		name, relErr := filepath.Rel(s.Path(), g.Path)
+		if relErr != nil {
+			name = g.Path
+		}
+		events = append(events, journal.Event{Time: time.Now(), Action: journal.ActionPurge, Name: name, Source: g.Path})
+	}
+	// The store isn't part of any one project.
+	recordEvents(cmd, "", events)
+	return errors.Join(errs...)
+}
+
+func runCacheGC(cmd *cobra.Command, args []string) error {
+	dryRun, err := cmd.Flags().GetBool("dry-run")
+	if err != nil {
+		return err
+	}
+
+	ws, err := commandWorkspace(cmd)
+	if err != nil {
+		return err
+	}
+	lockfiles, err := workspace.KnownLockFiles(ws.Dir)
+	if err != nil {
+		return err
+	}
+
+	inst, s := ws.Installer(), ws.Store
+	garbage, err := inst.CollectGarbage(dryRun, lockfiles...)
+
+	w := cmd.OutOrStdout()
+	var total int64
+	var events []journal.Event
+	for _, g := range garbage {
+		total += g.Size
+		if dryRun {
+			fmt.Fprintf(w, "Would delete %s (%s)\n", g.Path, formatBytes(g.Size))
+			continue
+		}
+		fmt.Fprintf(w, "Deleted %s (%s)\n", g.Path, formatBytes(g.Size))
+		name, relErr := filepath.Rel(s.Path(), g.Path)
+		if relErr != nil {
+			name = g.Path
+		}
+		events = append(events, journal.Event{Time: time.Now(), Action: journal.ActionPurge, Name: name, Source: g.Path})
+	}
+	// The store isn't part of any one project.
+	recordEvents(cmd, "", events)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case len(garbage) == 0:
+		fmt.Fprintln(w, "No unreferenced store entries")
+	case dryRun:
+		fmt.Fprintf(w, "Would reclaim %s\n", formatBytes(total))
+	default:
+		fmt.Fprintf(w, "Reclaimed %s\n", formatBytes(total))
+	}
+	return nil
+}
+
+// formatBytes formats n bytes in binary units, e.g. "1.5 MiB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,453 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/project"
+	_ "github.com/agentpkg/agentpkg/pkg/projector/claudecode"
+	"github.com/agentpkg/agentpkg/pkg/prompt"
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+// step runs apkg with args, answering its prompts with answers.
+type step struct {
+	args    []string
+	answers []prompt.Answer
+	wantErr bool
+}
+
+// runApkg runs apkg in projectDir with p answering its prompts, returning
+// its output.
+func runApkg(t *testing.T, projectDir string, p prompt.Prompter, args ...string) (string, error) {
+	t.Helper()
+	root := newRootCmd(p)
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetErr(&out)
+	root.SetArgs(append([]string{"--project-dir", projectDir}, args...))
+	err := root.Execute()
+	return out.String(), err
+}
+
+func TestPromptFlows(t *testing.T) {
+	tests := map[string]struct {
+		setup func(t *testing.T, projectDir string)
+		steps []step
+		check func(t *testing.T, projectDir string)
+	}{
+		"install prompts for agents and saves them": {
+			steps: []step{{
+				args: []string{"install", "skill", "{skill}"},
+				answers: []prompt.Answer{
+					prompt.Choose("claude-code"),
+					prompt.Choose("Yes, for this project"),
+					prompt.Choose(),
+				},
+			}},
+			check: func(t *testing.T, projectDir string) {
+				devCfg, err := config.LoadDevConfig(nil, false, projectDir)
+				if err != nil {
+					t.Fatalf("LoadDevConfig() error = %v", err)
+				}
+				if !slices.Equal(devCfg.Agents, []string{"claude-code"}) {
+					t.Errorf("saved agents = %v, want [claude-code]", devCfg.Agents)
+				}
+				if _, err := os.Lstat(filepath.Join(projectDir, ".claude", "skills", "greet")); err != nil {
+					t.Errorf("skill not projected: %v", err)
+				}
+			},
+		},
+		"remove removes the selected packages": {
+			steps: []step{
+				{args: []string{"install", "skill", "{skill}", "--agents", "claude-code", "--save-agents", "project", "--gitignore", "none"}},
+				{args: []string{"remove"}, answers: []prompt.Answer{prompt.Choose("skill: greet")}},
+			},
+			check: func(t *testing.T, projectDir string) {
+				cfg, err := config.LoadFile(filepath.Join(projectDir, config.ManifestFileName))
+				if err != nil {
+					t.Fatalf("LoadFile() error = %v", err)
+				}
+				if _, ok := cfg.Skills["greet"]; ok {
+					t.Error("greet is still in the manifest")
+				}
+				if _, err := os.Lstat(filepath.Join(projectDir, ".claude", "skills", "greet")); !os.IsNotExist(err) {
+					t.Errorf("greet is still projected: %v", err)
+				}
+			},
+		},
+		"duplicate server defers to the chosen global definition": {
+			steps: []step{
+				{args: []string{"install", "mcp", "echo", "-t", "stdio", "--command", "echo", "-g", "--agents", "claude-code", "--save-agents", "no", "--gitignore", "none"}},
+				{
+					args:    []string{"install", "mcp", "echo", "-t", "stdio", "--command", "echo", "--agents", "claude-code", "--save-agents", "no", "--gitignore", "none"},
+					answers: []prompt.Answer{prompt.Choose("Global (~/.apkg/apkg.toml)")},
+				},
+				// The saved answer is used without asking again.
+				{args: []string{"install", "--agents", "claude-code", "--save-agents", "no", "--gitignore", "none"}},
+			},
+			check: func(t *testing.T, projectDir string) {
+				devCfg, err := config.LoadDevConfig(nil, false, projectDir)
+				if err != nil {
+					t.Fatalf("LoadDevConfig() error = %v", err)
+				}
+				if got := devCfg.ServerScopes["echo"]; got != config.ServerScopeGlobal {
+					t.Errorf("saved scope = %q, want %q", got, config.ServerScopeGlobal)
+				}
+
+				data, err := os.ReadFile(filepath.Join(os.Getenv("HOME"), ".claude.json"))
+				if err != nil {
+					t.Fatalf("reading .claude.json: %v", err)
+				}
+				var claude struct {
+					MCPServers map[string]any `json:"mcpServers"`
+					Projects   map[string]struct {
+						MCPServers map[string]any `json:"mcpServers"`
+					} `json:"projects"`
+				}
+				if err := json.Unmarshal(data, &claude); err != nil {
+					t.Fatalf("parsing .claude.json: %v", err)
+				}
+				if _, ok := claude.MCPServers["echo"]; !ok {
+					t.Error("echo is not projected globally")
+				}
+				if _, ok := claude.Projects[projectDir].MCPServers["echo"]; ok {
+					t.Error("echo is projected into the project as well")
+				}
+			},
+		},
+		"install mcp infers the server from a short-form ref": {
+			steps: []step{
+				{args: []string{"install", "mcp", "https://mcp.linear.app/sse", "--headers", "X-Team=core", "--agents", "claude-code", "--save-agents", "no", "--gitignore", "none"}},
+				{args: []string{"install", "mcp", "https://example.com/mcp", "-t", "stdio", "--url", "https://example.com/other"}, wantErr: true},
+			},
+			check: func(t *testing.T, projectDir string) {
+				cfg, err := config.LoadFile(filepath.Join(projectDir, config.ManifestFileName))
+				if err != nil {
+					t.Fatalf("LoadFile() error = %v", err)
+				}
+				ms, ok := cfg.MCPServers["linear"]
+				if !ok {
+					t.Fatalf("servers = %v, want linear", cfg.MCPServers)
+				}
+				if ms.Transport != "http" || ms.ExternalHttpMCPConfig == nil || ms.URL != "https://mcp.linear.app/sse" {
+					t.Errorf("linear = %+v, want http server at https://mcp.linear.app/sse", ms)
+				}
+				if ms.HttpMCPConfig == nil || ms.Headers["X-Team"] != "core" {
+					t.Errorf("linear headers = %+v, want X-Team from --headers", ms.HttpMCPConfig)
+				}
+			},
+		},
+		"install mcp asks before replacing an entry apkg didn't create": {
+			setup: func(t *testing.T, projectDir string) {
+				data, err := json.Marshal(map[string]any{"projects": map[string]any{
+					projectDir: map[string]any{"mcpServers": map[string]any{"linear": map[string]any{"url": "https://hand.example/mcp"}}},
+				}})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(os.Getenv("HOME"), ".claude.json"), data, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			steps: []step{
+				{
+					args:    []string{"install", "mcp", "https://mcp.linear.app/sse", "--agents", "claude-code", "--save-agents", "no", "--gitignore", "none"},
+					answers: []prompt.Answer{prompt.Choose("Adopt it as is, and manage it from now on")},
+				},
+				// Adopted entries are apkg's, so the next install replaces
+				// them without asking.
+				{args: []string{"install", "--agents", "claude-code", "--save-agents", "no", "--gitignore", "none"}},
+			},
+			check: func(t *testing.T, projectDir string) {
+				data, err := os.ReadFile(filepath.Join(os.Getenv("HOME"), ".claude.json"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !strings.Contains(string(data), "https://mcp.linear.app/sse") || strings.Contains(string(data), "https://hand.example/mcp") {
+					t.Errorf("claude config = %s, want apkg's linear entry", data)
+				}
+			},
+		},
+		"remove without an answer fails": {
+			steps: []step{
+				{args: []string{"install", "skill", "{skill}", "--agents", "claude-code", "--save-agents", "project", "--gitignore", "none"}},
+				{args: []string{"remove"}, wantErr: true},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			projectDir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(projectDir, config.ManifestFileName), nil, 0o644); err != nil {
+				t.Fatalf("writing manifest: %v", err)
+			}
+			if tc.setup != nil {
+				tc.setup(t, projectDir)
+			}
+
+			skillDir := filepath.Join(t.TempDir(), "greet")
+			os.MkdirAll(skillDir, 0o755)
+			content := "---\nname: greet\ndescription: test skill\n---\n# greet\n"
+			if err := os.WriteFile(filepath.Join(skillDir, "SKILL.md"), []byte(content), 0o644); err != nil {
+				t.Fatalf("writing SKILL.md: %v", err)
+			}
+
+			for _, s := range tc.steps {
+				args := slices.Clone(s.args)
+				if i := slices.Index(args, "{skill}"); i >= 0 {
+					args[i] = skillDir
+				}
+
+				script := &prompt.Script{Answers: s.answers}
+				out, err := runApkg(t, projectDir, script, args...)
+				if (err != nil) != s.wantErr {
+					t.Fatalf("apkg %v error = %v, wantErr %v\n%s", args, err, s.wantErr, out)
+				}
+				if len(script.Answers) > 0 {
+					t.Fatalf("apkg %v left answers unused %v; asked %q", args, script.Answers, script.Asked)
+				}
+			}
+
+			if tc.check != nil {
+				tc.check(t, projectDir)
+			}
+		})
+	}
+}
+
+func TestFrozenInstall(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	projectDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(projectDir, config.ManifestFileName), nil, 0o644); err != nil {
+		t.Fatalf("writing manifest: %v", err)
+	}
+	key := filepath.Join(t.TempDir(), "team.key")
+	flags := []string{"--agents", "claude-code", "--save-agents", "no", "--gitignore", "none"}
+
+	run := func(wantErr bool, args ...string) string {
+		t.Helper()
+		out, err := runApkg(t, projectDir, &prompt.Script{}, args...)
+		if (err != nil) != wantErr {
+			t.Fatalf("apkg %v error = %v, wantErr %v\n%s", args, err, wantErr, out)
+		}
+		if err != nil {
+			return err.Error()
+		}
+		return out
+	}
+
+	run(false, append([]string{"install", "mcp", "echo", "-t", "stdio", "--command", "echo"}, flags...)...)
+	run(false, "lock", "keygen", key)
+
+	// An unsigned lockfile is refused once a key is required.
+	run(true, append([]string{"install", "--frozen", "--lock-key", key + ".pub"}, flags...)...)
+
+	run(false, "lock", "sign", "--key", key)
+	run(false, "lock", "verify", "--key", key+".pub")
+	run(false, append([]string{"install", "--frozen", "--lock-key", key + ".pub"}, flags...)...)
+
+	// Changing the lockfile invalidates its signature.
+	run(false, append([]string{"install", "mcp", "cat", "-t", "stdio", "--command", "cat"}, flags...)...)
+	if out := run(true, "lock", "verify", "--key", key+".pub"); !strings.Contains(out, "changed after it was signed") {
+		t.Errorf("lock verify = %q, want the lockfile reported as changed", out)
+	}
+
+	// A frozen install doesn't drop servers removed from the manifest
+	// after review.
+	run(false, "lock", "sign", "--key", key)
+	manifest := filepath.Join(projectDir, config.ManifestFileName)
+	cfg, err := config.LoadFile(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	delete(cfg.MCPServers, "cat")
+	if err := config.SaveFile(manifest, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if out := run(true, append([]string{"install", "--frozen", "--lock-key", key + ".pub"}, flags...)...); !strings.Contains(out, "not in apkg.toml") {
+		t.Errorf("frozen install error = %q, want the orphaned lock entry reported", out)
+	}
+
+	// Nor one whose config changed since it was locked.
+	echo := cfg.MCPServers["echo"]
+	echo.UnmanagedStdioMCPConfig = &config.UnmanagedStdioMCPConfig{Command: "printf"}
+	cfg.MCPServers["echo"] = echo
+	if err := config.SaveFile(manifest, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if out := run(true, append([]string{"install", "--frozen", "--lock-key", key + ".pub"}, flags...)...); !strings.Contains(out, `"echo" changed since it was locked`) {
+		t.Errorf("frozen install error = %q, want the changed server reported", out)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	projectDir := t.TempDir()
+	manifest := filepath.Join(projectDir, config.ManifestFileName)
+	lockPath := filepath.Join(projectDir, config.LockFileName)
+	if err := os.WriteFile(manifest, []byte("[skills.docs]\npath = \"./docs\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	oldLock := []byte("version = 1\n\n[[skills]]\npath = \"./docs\"\n")
+	if err := os.WriteFile(lockPath, oldLock, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Commands refuse files in an older format, saying what would change.
+	out, err := runApkg(t, projectDir, &prompt.Script{}, "verify")
+	if err == nil || !strings.Contains(err.Error(), `name the locked skill ./docs "docs"`) {
+		t.Fatalf("verify error = %v, want the pending migration listed\n%s", err, out)
+	}
+	// Commands that only run or show what is installed read them as is.
+	if out, err := runApkg(t, projectDir, &prompt.Script{}, "list"); err != nil {
+		t.Fatalf("list error = %v\n%s", err, out)
+	}
+	if out, err := runApkg(t, projectDir, &prompt.Script{}, "migrate", "--dry-run"); err != nil || !strings.Contains(out, "Would migrate") {
+		t.Fatalf("migrate --dry-run = %q, %v", out, err)
+	}
+	if data, _ := os.ReadFile(lockPath); !bytes.Equal(data, oldLock) {
+		t.Errorf("lockfile changed before migrating:\n%s", data)
+	}
+
+	if out, err := runApkg(t, projectDir, &prompt.Script{}, "migrate"); err != nil {
+		t.Fatalf("migrate error = %v\n%s", err, out)
+	}
+	lf, err := config.LoadLockFile(lockPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lf.Version != config.LockFileVersion || len(lf.Skills) != 1 || lf.Skills[0].Name != "docs" {
+		t.Errorf("migrated lockfile = %+v", lf)
+	}
+	if out, err := runApkg(t, projectDir, &prompt.Script{}, "migrate"); err != nil || !strings.Contains(out, "Nothing to migrate") {
+		t.Errorf("second migrate = %q, %v", out, err)
+	}
+
+	// A lockfile that only needs its version bumped doesn't stop commands.
+	if err := os.WriteFile(lockPath, []byte("version = 1\n\n[[skills]]\nname = \"docs\"\npath = \"./docs\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := runApkg(t, projectDir, &prompt.Script{}, "verify"); err != nil && strings.Contains(err.Error(), "apkg migrate") {
+		t.Errorf("verify of a version 1 lockfile with named skills error = %v\n%s", err, out)
+	}
+}
+
+func TestRemovePurgeKeepsEntriesOfKnownProjects(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	storeDir := t.TempDir()
+	t.Setenv(store.EnvRoot, storeDir)
+
+	const repo = "https://github.com/org/skills"
+	lock := &config.LockFile{
+		Version: config.LockFileVersion,
+		Skills:  []config.SkillLockEntry{{Name: "pdf", Git: repo, Path: "pdf", Commit: "c1"}},
+	}
+	manifest := []byte("[skills.pdf]\ngit = \"" + repo + "\"\npath = \"pdf\"\n")
+	projectDir, otherDir := t.TempDir(), t.TempDir()
+	for _, dir := range []string{projectDir, otherDir} {
+		if err := os.WriteFile(filepath.Join(dir, config.ManifestFileName), manifest, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := config.SaveLockFile(filepath.Join(dir, config.LockFileName), lock); err != nil {
+			t.Fatal(err)
+		}
+	}
+	knownPath, err := project.KnownProjectsPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := project.RecordKnownProject(knownPath, otherDir, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	entry := filepath.Join(storeDir, "repos", "github.com", "org", "skills", "c1")
+	if err := os.MkdirAll(entry, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if out, err := runApkg(t, projectDir, &prompt.Script{}, "remove", "skill", "pdf", "--purge", "--agents", "claude-code"); err != nil {
+		t.Fatalf("remove error = %v\n%s", err, out)
+	}
+	if _, err := os.Stat(entry); err != nil {
+		t.Errorf("store entry another project locks was purged: %v", err)
+	}
+}
+
+func TestListAllProjects(t *testing.T) {
+	tests := map[string]struct {
+		args        []string
+		wantOut     []string
+		wantMissing bool // whether the deleted project is still known after
+		wantErr     bool
+	}{
+		"text": {
+			args:        []string{"list", "--all-projects"},
+			wantOut:     []string{"global (", "pdf", "skill", "missing"},
+			wantMissing: true,
+		},
+		"json": {
+			args:        []string{"list", "--all-projects", "--json"},
+			wantOut:     []string{`"global": true`, `"name": "pdf"`, `"missing": true`},
+			wantMissing: true,
+		},
+		"prune": {
+			args:    []string{"list", "--all-projects", "--prune"},
+			wantOut: []string{"Forgot ", "pdf"},
+		},
+		"prune without all projects": {
+			args:        []string{"list", "--prune"},
+			wantMissing: true,
+			wantErr:     true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			t.Setenv(store.EnvRoot, t.TempDir())
+			projectDir := t.TempDir()
+			manifest := []byte("[skills.pdf]\ngit = \"https://github.com/org/skills\"\npath = \"pdf\"\nref = \"main\"\n")
+			if err := os.WriteFile(filepath.Join(projectDir, config.ManifestFileName), manifest, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			deletedDir := filepath.Join(t.TempDir(), "deleted")
+			knownPath, err := project.KnownProjectsPath()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, dir := range []string{projectDir, deletedDir} {
+				if err := project.RecordKnownProject(knownPath, dir, time.Now()); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			out, err := runApkg(t, projectDir, &prompt.Script{}, tc.args...)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("apkg %v error = %v, wantErr %v\n%s", tc.args, err, tc.wantErr, out)
+			}
+			for _, want := range tc.wantOut {
+				if !strings.Contains(out, want) {
+					t.Errorf("apkg %v output missing %q:\n%s", tc.args, want, out)
+				}
+			}
+
+			known, err := project.LoadKnownProjects(knownPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			stillKnown := slices.ContainsFunc(known, func(p project.KnownProject) bool { return p.Dir == deletedDir })
+			if stillKnown != tc.wantMissing {
+				t.Errorf("deleted project known = %v, want %v", stillKnown, tc.wantMissing)
+			}
+		})
+	}
+}
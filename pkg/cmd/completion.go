@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/agentpkg/agentpkg/pkg/completion"
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/installer"
+	"github.com/agentpkg/agentpkg/pkg/project"
+	"github.com/agentpkg/agentpkg/pkg/projector"
+	"github.com/spf13/cobra"
+)
+
+// addCompletionCmds adds `completion install` and `completion docs` to
+// cobra's default completion command.
+func addCompletionCmds(root *cobra.Command) {
+	root.InitDefaultCompletionCmd()
+
+	completionCmd, _, err := root.Find([]string{"completion"})
+	if err != nil || completionCmd == root {
+		return
+	}
+
+	completionCmd.AddCommand(&cobra.Command{
+		Use:   "install [shell]",
+		Short: "Install the autocompletion script into your shell configuration",
+		Long: `Writes the autocompletion script for the given shell (default: the
+shell in $SHELL) and makes the shell load it: bash and zsh scripts are
+written to ~/.apkg/completions and sourced from ~/.bashrc or ~/.zshrc, fish
+scripts go to ~/.config/fish/completions. Re-run it after upgrading apkg to
+refresh the script.`,
+		Args:      cobra.MaximumNArgs(1),
+		ValidArgs: completion.Shells,
+		RunE:      runCompletionInstall,
+	})
+
+	docsCmd := &cobra.Command{
+		Use:   "docs",
+		Short: "Write an overview of the project's skills and MCP servers",
+		Long: `Writes a markdown overview of the skills (with their descriptions) and MCP
+servers (with the tools they offer) in apkg.toml to .apkg/OVERVIEW.md, so
+people and agents can discover what tooling the project ships. The tools are
+listed by starting each installed server, or connecting to it for HTTP
+servers, and asking it; pass --no-inspect to leave them out. Nothing is
+fetched: skills that aren't installed yet are listed without a description.
+
+With --agents-md, the overview is also written into AGENTS.md, in a section
+between apkg's markers that later runs replace. The rest of the file is left
+alone.`,
+		Args: cobra.NoArgs,
+		RunE: runCompletionDocs,
+	}
+	docsCmd.Flags().StringP("output", "o", "", "File to write the overview to, or - for stdout (default .apkg/OVERVIEW.md)")
+	docsCmd.Flags().Bool("agents-md", false, "Also write the overview into AGENTS.md")
+	docsCmd.Flags().Bool("no-inspect", false, "Don't start MCP servers to list their tools")
+	completionCmd.AddCommand(docsCmd)
+}
+
+func runCompletionInstall(cmd *cobra.Command, args []string) error {
+	shell := completion.DetectShell(os.Getenv("SHELL"))
+	if len(args) > 0 {
+		shell = args[0]
+	}
+	if shell == "" {
+		return fmt.Errorf("could not detect the shell from $SHELL, pass one of: %s", strings.Join(completion.Shells, ", "))
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return fmt.Errorf("determining home directory: %w", err)
+	}
+	configDir, err := config.GlobalConfigDir()
+	if err != nil {
+		return err
+	}
+
+	root := cmd.Root()
+	gen := map[string]func(io.Writer) error{
+		"bash": func(w io.Writer) error { return root.GenBashCompletionV2(w, true) },
+		"zsh":  root.GenZshCompletion,
+		"fish": func(w io.Writer) error { return root.GenFishCompletion(w, true) },
+	}[shell]
+	if gen == nil {
+		return fmt.Errorf("unsupported shell %q (want one of %s)", shell, strings.Join(completion.Shells, ", "))
+	}
+
+	result, err := completion.Install(shell, home, configDir, gen)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Wrote %s completion to %s\n", shell, result.ScriptPath)
+	switch {
+	case result.RCUpdated:
+		fmt.Fprintf(out, "Added it to %s; restart your shell or run: source %s\n", result.RCFile, result.RCFile)
+	case result.RCFile != "":
+		fmt.Fprintf(out, "%s already loads it\n", result.RCFile)
+	}
+	return nil
+}
+
+func runCompletionDocs(cmd *cobra.Command, args []string) error {
+	global, err := cmd.Flags().GetBool("global")
+	if err != nil {
+		return err
+	}
+	if global {
+		return fmt.Errorf("completion docs describes a project, not the global install")
+	}
+	output, _ := cmd.Flags().GetString("output")
+	agentsMD, _ := cmd.Flags().GetBool("agents-md")
+	noInspect, _ := cmd.Flags().GetBool("no-inspect")
+
+	ws, err := commandWorkspace(cmd)
+	if err != nil {
+		return err
+	}
+	projectDir := ws.Dir
+	cfg, lf, err := ws.Load()
+	if err != nil {
+		return err
+	}
+
+	inst := ws.Installer()
+	overview, err := inst.Overview(cmd.Context(), cfg, lf, !noInspect)
+	if err != nil {
+		return err
+	}
+	for _, server := range overview.MCPServers {
+		if server.ToolsError != "" {
+			warnf(cmd, "listing the tools of %s: %s", server.Name, server.ToolsError)
+		}
+	}
+	md := overview.Markdown()
+
+	out := cmd.OutOrStdout()
+	if output == "-" {
+		_, err := out.Write(md)
+		return err
+	}
+	if output == "" {
+		output = filepath.Join(projectDir, projector.ContentDir, installer.OverviewFileName)
+	}
+	if err := os.MkdirAll(filepath.Dir(output), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(output, md, 0o644); err != nil {
+		return fmt.Errorf("writing %s: %w", output, err)
+	}
+	fmt.Fprintf(out, "Wrote %s (%d skills, %d MCP servers)\n", output, len(overview.Skills), len(overview.MCPServers))
+
+	if agentsMD {
+		changed, err := project.InjectAgentsSection(projectDir, md)
+		if err != nil {
+			return err
+		}
+		if changed {
+			fmt.Fprintf(out, "Updated %s\n", project.AgentsFile)
+		} else {
+			fmt.Fprintf(out, "%s is up to date\n", project.AgentsFile)
+		}
+	}
+	return nil
+}
+
+// completeSkillRefs completes the ref of `install skill` from recently
+// installed refs, falling back to file completion for local paths.
+func completeSkillRefs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	if strings.HasPrefix(toComplete, ".") || strings.HasPrefix(toComplete, "/") {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+
+	if err := loadCompletionDevConfig(); err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	s, err := openStore()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	recent, err := completion.RecentRefs(s)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completion.CompleteRefs(recent, toComplete), cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
+
+// completeRegistries completes the names of the registries in the dev
+// config.
+func completeRegistries(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if err := loadCompletionDevConfig(); err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var names []string
+	for _, name := range sortedKeys(DevCfg.Registries) {
+		if strings.HasPrefix(name, toComplete) {
+			names = append(names, name)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// loadCompletionDevConfig sets DevCfg to the project's dev config, which
+// PersistentPreRunE would have loaded if it ran for completions.
+func loadCompletionDevConfig() error {
+	dir, err := resolveProjectDir()
+	if err != nil {
+		return err
+	}
+	if DevCfg, err = config.LoadDevConfig(nil, false, dir); err != nil {
+		return err
+	}
+	return projector.RegisterCustomProjectors(DevCfg.Projectors)
+}
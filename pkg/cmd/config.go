@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/projector"
+	"github.com/spf13/cobra"
+)
+
+const configKeysHelp = `Keys:
+  agents                         agents to project for (comma-separated)
+  env_set                        env set applied to MCP servers that define it
+  store_path                     store location (default ~/.apkg)
+  fetch_timeout                  time limit for fetching each package (default 10m)
+  projection                     skill links: absolute, relative, or project (copy into .apkg/)
+  serve_access.<server>          project directories allowed to reach a server via apkg serve
+  serve_socket_agents            agents that reach apkg serve over its Unix socket (see apkg serve --socket)
+  server_scopes.<server>         project or global: which definition of a server installed in both agents get
+  mcp_scopes.<agent>             project or global: where project installs register the agent's MCP servers
+  mcp_types.<agent>.<transport>  "type" the agent's MCP config gives servers of a transport (stdio, http, sse)
+  mcp_commands.<agent>           absolute, path (npx/uvx/go run), exec (apkg exec), or run (apkg run mcp): how the agent launches stdio servers
+  policy_hook                    URL to POST install plans to, or command to run with them, that approves packages before they're installed
+  registries.<name>.type         registry type: git or oci
+  registries.<name>.url          registry index URL or OCI repository prefix
+  registries.<name>.username     username for OCI registries (default apkg)
+  registries.<name>.token_env    environment variable holding the token`
+
+func newConfigCmd() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage developer configuration",
+		Long: `Reads and changes the developer configuration in apkg.local.toml, or in
+~/.apkg/config.toml with --global.
+
+` + configKeysHelp,
+	}
+
+	getCmd := &cobra.Command{
+		Use:   "get [key]",
+		Short: "Print a developer configuration key, or all keys that are set",
+		Long: `Prints the effective value of a key: apkg.local.toml merged over
+~/.apkg/config.toml, or only the latter with --global. Without a key, prints
+every key that is set.
+
+` + configKeysHelp,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeConfigKeys,
+		RunE:              runConfigGet,
+	}
+
+	setCmd := &cobra.Command{
+		Use:   "set <key> <value>...",
+		Short: "Set a developer configuration key",
+		Long: `Validates and sets a key in apkg.local.toml, or ~/.apkg/config.toml with
+--global, e.g.
+
+  apkg config set agents claude-code cursor
+  apkg config set registries.team.type oci --global
+  apkg config set serve_access.postgres ~/work/billing ~/work/reports --global
+
+` + configKeysHelp,
+		Args:              cobra.MinimumNArgs(2),
+		ValidArgsFunction: completeConfigSet,
+		RunE:              runConfigSet,
+	}
+
+	unsetCmd := &cobra.Command{
+		Use:   "unset <key>",
+		Short: "Remove a developer configuration key",
+		Long: `Removes a key from apkg.local.toml, or ~/.apkg/config.toml with --global.
+"registries.<name>" removes the whole registry.`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeConfigKeys,
+		RunE:              runConfigUnset,
+	}
+
+	configCmd.AddCommand(getCmd, setCmd, unsetCmd)
+	return configCmd
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	out := cmd.OutOrStdout()
+	if len(args) == 0 {
+		for _, key := range DevCfg.Keys() {
+			value, _ := DevCfg.Get(key)
+			fmt.Fprintf(out, "%s = %s\n", key, value)
+		}
+		return nil
+	}
+
+	value, err := DevCfg.Get(args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(out, value)
+	return nil
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	key, value := args[0], strings.Join(args[1:], ",")
+	if key == config.KeyPolicyHook {
+		// A command's arguments are separate values.
+		value = strings.Join(args[1:], " ")
+	}
+	multi := key == config.KeyAgents || key == config.KeyServeSocketAgents || key == config.KeyPolicyHook || strings.HasPrefix(key, config.KeyServeAccess+".")
+	if !multi && len(args) > 2 {
+		return fmt.Errorf("%s takes a single value", key)
+	}
+
+	path, err := devConfigPath(cmd)
+	if err != nil {
+		return err
+	}
+
+	err = config.UpdateDevConfigFile(path, func(cfg *config.DevConfig) error {
+		if err := cfg.Set(key, value); err != nil {
+			return err
+		}
+		switch key {
+		case config.KeyAgents:
+			return projector.ValidateAgents(cfg.Agents)
+		case config.KeyServeSocketAgents:
+			return projector.ValidateAgents(cfg.ServeSocketAgents)
+		}
+		if agent, ok := strings.CutPrefix(key, config.KeyProjectionModes+"."); ok {
+			return projector.ValidateAgents([]string{agent})
+		}
+		if agent, ok := strings.CutPrefix(key, config.KeyMCPScopes+"."); ok {
+			return projector.ValidateAgents([]string{agent})
+		}
+		if agent, ok := strings.CutPrefix(key, config.KeyMCPCommands+"."); ok {
+			return projector.ValidateAgents([]string{agent})
+		}
+		if rest, ok := strings.CutPrefix(key, config.KeyMCPTypes+"."); ok {
+			agent, _, _ := strings.Cut(rest, ".")
+			return projector.ValidateAgents([]string{agent})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Set %s in %s\n", key, path)
+	return nil
+}
+
+func runConfigUnset(cmd *cobra.Command, args []string) error {
+	path, err := devConfigPath(cmd)
+	if err != nil {
+		return err
+	}
+
+	err = config.UpdateDevConfigFile(path, func(cfg *config.DevConfig) error {
+		return cfg.Unset(args[0])
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Unset %s in %s\n", args[0], path)
+	return nil
+}
+
+// devConfigPath returns the dev config file that config set and unset
+// modify: apkg.local.toml, or ~/.apkg/config.toml with --global.
+func devConfigPath(cmd *cobra.Command) (string, error) {
+	global, err := cmd.Flags().GetBool("global")
+	if err != nil {
+		return "", err
+	}
+	if global {
+		return config.GlobalDevConfigPath()
+	}
+	return filepath.Join(ProjectDir, config.LocalConfigFile), nil
+}
+
+func completeConfigKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	keys := []string{config.KeyAgents, config.KeyEnvSet, config.KeyStorePath, config.KeyFetchTimeout, config.KeyProjection, config.KeyProjectionMode, config.KeyProjectionModes + ".", config.KeyServeAccess + ".", config.KeyServeSocketAgents, config.KeyServerScopes + ".", config.KeyMCPScopes + ".", config.KeyMCPTypes + ".", config.KeyMCPCommands + ".", config.KeyPolicyHook, config.KeyRegistries + "."}
+	return keys, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
+
+func completeConfigSet(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch {
+	case len(args) == 0:
+		return completeConfigKeys(cmd, args, toComplete)
+	case args[0] == config.KeyAgents, args[0] == config.KeyServeSocketAgents:
+		return completeAgents(cmd, args, toComplete)
+	case args[0] == config.KeyStorePath, strings.HasPrefix(args[0], config.KeyServeAccess+"."):
+		return nil, cobra.ShellCompDirectiveFilterDirs
+	case args[0] == config.KeyProjection && len(args) == 1:
+		return config.ProjectionStrategies, cobra.ShellCompDirectiveNoFileComp
+	case args[0] == config.KeyProjectionMode && len(args) == 1,
+		strings.HasPrefix(args[0], config.KeyProjectionModes+".") && len(args) == 1:
+		return config.ProjectionModes, cobra.ShellCompDirectiveNoFileComp
+	case strings.HasPrefix(args[0], config.KeyServerScopes+".") && len(args) == 1,
+		strings.HasPrefix(args[0], config.KeyMCPScopes+".") && len(args) == 1:
+		return []string{config.ServerScopeProject, config.ServerScopeGlobal}, cobra.ShellCompDirectiveNoFileComp
+	case strings.HasPrefix(args[0], config.KeyMCPCommands+".") && len(args) == 1:
+		return config.MCPCommandStyles, cobra.ShellCompDirectiveNoFileComp
+	case strings.HasSuffix(args[0], ".type") && len(args) == 1:
+		return config.RegistryTypes, cobra.ShellCompDirectiveNoFileComp
+	default:
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+}
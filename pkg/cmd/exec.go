@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/agentpkg/agentpkg/pkg/mcp"
+	"github.com/spf13/cobra"
+)
+
+func newExecCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "exec <server> [-- args...]",
+		Short: "Run a managed MCP server's binary with the given arguments",
+		Long: `Runs the binary of an installed npm, uv, or go MCP server with the given
+arguments instead of the ones in apkg.toml, and with the env apkg.toml
+configures for it (with the dev config's env_set applied as apkg install
+does, and "${VAR}" references expanded from apkg's environment), for the
+package's own CLI subcommands:
+
+  apkg exec github -- --version
+  apkg exec db -- migrate
+
+The binary runs on the same runtime agents use. Everything after the server
+name is passed through, so "--" is only needed before arguments that look
+like apkg flags. The command exits with the binary's exit code.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: runExec,
+	}
+	// Stop parsing apkg flags at the server name, so the binary's flags
+	// pass through without "--".
+	cmd.Flags().SetInterspersed(false)
+	return cmd
+}
+
+func runExec(cmd *cobra.Command, args []string) error {
+	ws, err := commandWorkspace(cmd)
+	if err != nil {
+		return err
+	}
+	cfg, lf, err := ws.Load()
+	if err != nil {
+		return err
+	}
+
+	name, passthrough := args[0], args[1:]
+	if len(passthrough) > 0 && passthrough[0] == "--" {
+		passthrough = passthrough[1:]
+	}
+
+	server, err := ws.Installer().ExecServer(cfg, lf, name, passthrough)
+	if err != nil {
+		return err
+	}
+
+	return runServerProcess(cmd, name, server)
+}
+
+// runServerProcess runs server's command attached to cmd's stdio, with its
+// env added to apkg's (see mcp.LaunchEnv), and exits with its exit code.
+func runServerProcess(cmd *cobra.Command, name string, server mcp.MCPServer) error {
+	c := exec.CommandContext(cmd.Context(), server.Command(), server.Args()...)
+	c.Stdin, c.Stdout, c.Stderr = cmd.InOrStdin(), cmd.OutOrStdout(), cmd.ErrOrStderr()
+	c.Env = mcp.LaunchEnv(server, os.Environ())
+
+	if err := c.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+			// The binary reported its own failure on stderr.
+			cmd.SilenceErrors = true
+			return &exitCodeError{code: exitErr.ExitCode()}
+		}
+		return fmt.Errorf("running %s: %w", name, err)
+	}
+	return nil
+}
+
+// exitCodeError makes apkg exit with code, without printing an error.
+type exitCodeError struct {
+	code int
+}
+
+func (e *exitCodeError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/agentpkg/agentpkg/pkg/project"
+	"github.com/spf13/cobra"
+)
+
+func newGitignoreCmd() *cobra.Command {
+	gitignoreCmd := &cobra.Command{
+		Use:   "gitignore",
+		Short: "Manage agent config entries in .gitignore",
+	}
+
+	syncCmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Reconcile .gitignore with the configured agents",
+		Long: `Appends the .gitignore entries of every configured agent (and
+apkg.local.toml) that are missing. Entries belonging only to agents that are
+no longer configured are reported, and removed with --prune.`,
+		Args: cobra.NoArgs,
+		RunE: runGitignoreSync,
+	}
+	syncCmd.Flags().Bool("prune", false, "Remove entries of agents that are no longer configured")
+
+	gitignoreCmd.AddCommand(syncCmd)
+	return gitignoreCmd
+}
+
+func runGitignoreSync(cmd *cobra.Command, args []string) error {
+	prune, _ := cmd.Flags().GetBool("prune")
+
+	result, err := project.SyncGitignore(ProjectDir, DevCfg.Agents, prune)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	for _, entry := range result.Added {
+		fmt.Fprintf(out, "Added %s to .gitignore\n", entry)
+	}
+	for _, entry := range result.Removed {
+		fmt.Fprintf(out, "Removed %s from .gitignore\n", entry)
+	}
+	for _, entry := range result.Stale {
+		fmt.Fprintf(out, "%s belongs to an agent that is not configured (remove with --prune)\n", entry)
+	}
+	if len(result.Added)+len(result.Removed)+len(result.Stale) == 0 {
+		fmt.Fprintln(out, ".gitignore is up to date")
+	}
+	return nil
+}
+
+// offerGitignoreEntries asks whether to gitignore the config files of agents
+// being projected into the project for the first time, as init does. With
+// --gitignore, the named agents' entries are added without asking. It is
+// skipped for global installs and when stdin is not a terminal.
+func offerGitignoreEntries(w io.Writer, projectDir string, agents []string, global bool) error {
+	if global {
+		return nil
+	}
+
+	candidates := agents
+	if flagGitignore == nil {
+		var err error
+		candidates, err = project.UnprojectedAgents(projectDir, agents)
+		if err != nil {
+			return err
+		}
+	}
+
+	entries, err := selectGitignoreEntries(candidates, "Add config files of newly added agents to .gitignore?")
+	if err != nil {
+		return err
+	}
+
+	added, err := project.EnsureGitignore(projectDir, entries)
+	if err != nil {
+		return err
+	}
+	for _, entry := range added {
+		fmt.Fprintf(w, "Added %s to .gitignore\n", entry)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/agentpkg/agentpkg/pkg/journal"
+	"github.com/agentpkg/agentpkg/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+func newHistoryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show the journal of installs, updates, and removals",
+		Long: `Shows the entries of ~/.apkg/journal.log, newest last: every package
+installed, updated, or removed (and every store entry purged), with the
+project it happened in and the apkg command that caused it.`,
+		Args: cobra.NoArgs,
+		RunE: runHistory,
+	}
+
+	cmd.Flags().IntP("limit", "n", 50, "Show at most this many of the most recent entries (0 for all)")
+	cmd.Flags().Bool("all-projects", false, "Show entries from every project, not just the current one")
+
+	return cmd
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	limit, err := cmd.Flags().GetInt("limit")
+	if err != nil {
+		return err
+	}
+	allProjects, err := cmd.Flags().GetBool("all-projects")
+	if err != nil {
+		return err
+	}
+	global, err := cmd.Flags().GetBool("global")
+	if err != nil {
+		return err
+	}
+
+	j, err := journal.Default()
+	if err != nil {
+		return err
+	}
+	events, err := j.Read()
+	if err != nil {
+		return err
+	}
+
+	if !allProjects {
+		projectDir, _, _, err := resolveInstallPaths(global)
+		if err != nil {
+			return err
+		}
+		var filtered []journal.Event
+		for _, e := range events {
+			if e.Project == projectDir {
+				filtered = append(filtered, e)
+			}
+		}
+		events = filtered
+	}
+
+	if limit > 0 && len(events) > limit {
+		events = events[len(events)-limit:]
+	}
+
+	if len(events) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "No history recorded")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	for _, e := range events {
+		pkg := e.Name
+		if e.Kind != "" {
+			pkg = e.Kind + " " + e.Name
+		}
+		line := []string{e.Time.Local().Format(time.DateTime), e.Action, pkg, shortVersion(e.Version), e.Command}
+		if allProjects {
+			line = append(line, e.Project)
+		}
+		fmt.Fprintln(tw, strings.Join(line, "\t"))
+	}
+	return tw.Flush()
+}
+
+// recordEvents appends events to the journal, stamped with the project and
+// the running command. Journal failures are reported as warnings: they
+// never fail the command that made the change.
+func recordEvents(cmd *cobra.Command, projectDir string, events []journal.Event) {
+	journalWorkspace(cmd, projectDir).Record(events)
+}
+
+// journalWorkspace returns a workspace that journals changes to projectDir
+// as caused by the running command.
+func journalWorkspace(cmd *cobra.Command, projectDir string) *workspace.Workspace {
+	return &workspace.Workspace{
+		Dir:     projectDir,
+		Command: commandLine(),
+		Warn:    warnFunc(cmd),
+	}
+}
+
+// commandLine is the running command as journal events record it.
+func commandLine() string {
+	return "apkg " + strings.Join(os.Args[1:], " ")
+}
@@ -3,31 +3,75 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/installer"
 	"github.com/agentpkg/agentpkg/pkg/project"
 	"github.com/agentpkg/agentpkg/pkg/projector"
-	"github.com/charmbracelet/huh"
+	"github.com/agentpkg/agentpkg/pkg/workspace"
 	"github.com/spf13/cobra"
 )
 
+// Values of init --import.
+const (
+	importAll  = "all"
+	importNone = "none"
+)
+
 func newInitCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "init",
 		Short: "Initialize a new apkg project",
-		Long:  "Creates an apkg.toml manifest and configures .gitignore entries.",
-		RunE:  runInit,
+		Long: `Creates an apkg.toml manifest, configures .gitignore entries, and asks
+which agents to project for by default (unless already configured), saving
+the choice to apkg.local.toml or ~/.apkg/config.toml so later installs don't
+prompt for it.
+
+In a project whose agent configs already have skills or MCP servers (e.g.
+.mcp.json, .cursor/mcp.json, or .claude/skills/), init offers to import
+them into apkg.toml so apkg manages them instead of projecting next to
+them. Imported MCP servers keep their config; imported skill directories
+are moved into skills/ and declared as local skills, which the next
+install links back into the agents' skills directories. Pass --import all
+or --import none to answer without prompting.`,
+		RunE: runInit,
 		// init does not need dev config resolution; skip the root PersistentPreRunE.
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
 	}
+	cmd.Flags().String("import", "", `Import existing agent config entries without prompting: "all" or "none"`)
+	return cmd
 }
 
 func runInit(cmd *cobra.Command, args []string) error {
+	if err := projector.ValidateAgents(flagAgents); err != nil {
+		return fmt.Errorf("--agents: %w", err)
+	}
+	if err := validatePromptFlags(); err != nil {
+		return err
+	}
+	importChoice, err := cmd.Flags().GetString("import")
+	if err != nil {
+		return err
+	}
+	switch importChoice {
+	case "", importAll, importNone:
+	default:
+		return fmt.Errorf("--import must be %q or %q", importAll, importNone)
+	}
+
+	// init creates the manifest in the working directory (or --project-dir)
+	// rather than walking up to an enclosing project.
 	wd, err := os.Getwd()
 	if err != nil {
 		return fmt.Errorf("getting working directory: %w", err)
 	}
+	if flagProjectDir != "" {
+		if wd, err = filepath.Abs(flagProjectDir); err != nil {
+			return fmt.Errorf("resolving --project-dir: %w", err)
+		}
+	}
 
 	name := project.InferName(wd)
 
@@ -36,8 +80,12 @@ func runInit(cmd *cobra.Command, args []string) error {
 	}
 	fmt.Fprintf(cmd.OutOrStdout(), "Created %s\n", project.ManifestFile)
 
-	// Prompt for agent config directories to gitignore.
-	selectedEntries, err := promptGitignoreEntries()
+	if err := importExisting(cmd, wd, importChoice); err != nil {
+		return err
+	}
+
+	// Prompt for agent config directories to gitignore (or take --gitignore).
+	selectedEntries, err := selectGitignoreEntries(projector.RegisteredAgents(), "Add agent config files to .gitignore?")
 	if err != nil {
 		return err
 	}
@@ -48,16 +96,121 @@ func runInit(cmd *cobra.Command, args []string) error {
 		return err
 	}
 	for _, entry := range added {
-		fmt.Fprintf(cmd.OutOrStdout(), "Added %s to .gitignore\n", entry)
+		fmt.Fprintf(progressOut(cmd), "Added %s to .gitignore\n", entry)
+	}
+
+	ProjectDir = wd
+	return offerDefaultAgents(wd)
+}
+
+// importExisting finds the skills and MCP servers in the project's agent
+// configs that apkg doesn't manage and imports those chosen with --import
+// or at a prompt into the manifest in dir. Without --import and a
+// terminal, nothing is imported.
+func importExisting(cmd *cobra.Command, dir, choice string) error {
+	if choice == importNone {
+		return nil
+	}
+
+	ws, err := workspace.New(dir, false, DevCfg)
+	if err != nil {
+		return err
+	}
+	manifestPath := ws.ManifestPath
+	cfg, err := config.LoadFile(manifestPath)
+	if err != nil {
+		return fmt.Errorf("loading %s: %w", manifestPath, err)
+	}
+	// Entries of every agent are found, not just the configured ones.
+	ws.Agents = projector.RegisteredAgents()
+	ws.Warn = warnFunc(cmd)
+	inst := ws.Installer()
+
+	unmanaged, err := inst.Unmanaged(cfg)
+	if err != nil {
+		return err
+	}
+	// Skills in the agents' global directories aren't the project's.
+	var found []installer.Unmanaged
+	for _, u := range unmanaged {
+		if rel, err := filepath.Rel(dir, u.Path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			found = append(found, u)
+		}
+	}
+	if len(found) == 0 {
+		return nil
 	}
 
+	selected := found
+	if choice == "" {
+		if !prompter.Interactive() {
+			fmt.Fprintf(progressOut(cmd), "Found %d agent config entries apkg doesn't manage; pass --import all to import them, or see apkg status --agents\n", len(found))
+			return nil
+		}
+		labels := make([]string, len(found))
+		for i, u := range found {
+			rel, _ := filepath.Rel(dir, u.Path)
+			labels[i] = fmt.Sprintf("%s %s (%s, %s)", u.Kind, u.Name, rel, u.Agent)
+		}
+		idxs, err := prompter.MultiSelect("Import existing skills and MCP servers into apkg.toml?", labels)
+		if err != nil {
+			return fmt.Errorf("prompt failed: %w", err)
+		}
+		selected = nil
+		for _, i := range idxs {
+			selected = append(selected, found[i])
+		}
+		if len(selected) == 0 {
+			return nil
+		}
+	}
+
+	imported, err := inst.Import(cfg, selected, func() error {
+		if err := config.SaveFile(manifestPath, cfg); err != nil {
+			return fmt.Errorf("writing %s: %w", manifestPath, err)
+		}
+		return nil
+	})
+	for _, u := range imported {
+		fmt.Fprintf(cmd.OutOrStdout(), "Imported %s %q from %s\n", u.Kind, u.Name, u.Agent)
+	}
+	if err != nil {
+		return err
+	}
+	if len(imported) > 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "Run apkg install to manage them with apkg")
+	}
 	return nil
 }
 
-// promptGitignoreEntries uses huh to present a multi-select of agent config
-// entries to gitignore, built from the registered projectors.
-func promptGitignoreEntries() ([]string, error) {
-	agents := projector.RegisteredAgents()
+// offerDefaultAgents saves the agents later installs project for: those
+// passed with --agents (to this project, unless --save-agents says
+// otherwise), or those chosen at the agent prompt. Nothing is asked if
+// agents are already configured or stdin is not a terminal.
+func offerDefaultAgents(dir string) error {
+	if len(flagAgents) > 0 {
+		choice := flagSaveAgents
+		if choice == "" {
+			choice = saveAgentsProject
+		}
+		return saveAgents(flagAgents, choice, false)
+	}
+
+	devCfg, err := config.LoadDevConfig(nil, false, dir)
+	if err != nil {
+		return err
+	}
+	if len(devCfg.Agents) > 0 || !prompter.Interactive() {
+		return nil
+	}
+
+	_, err = promptAgents(false)
+	return err
+}
+
+// promptGitignoreEntries presents a multi-select of agent config entries to
+// gitignore, built from the given agents' projectors.
+func promptGitignoreEntries(agents []string, title string) ([]string, error) {
 	if len(agents) == 0 {
 		return nil, nil
 	}
@@ -69,7 +222,10 @@ func promptGitignoreEntries() ([]string, error) {
 
 	opts := make([]agentOption, 0, len(agents))
 	for _, agent := range agents {
-		proj, _ := projector.GetProjector(agent)
+		proj, ok := projector.GetProjector(agent)
+		if !ok {
+			continue
+		}
 		entries := proj.GitignoreEntries()
 		if len(entries) == 0 {
 			continue
@@ -82,20 +238,12 @@ func promptGitignoreEntries() ([]string, error) {
 		return nil, nil
 	}
 
-	options := make([]huh.Option[int], len(opts))
+	labels := make([]string, len(opts))
 	for i, opt := range opts {
-		options[i] = huh.NewOption(opt.label, i)
-	}
-
-	var selected []int
-	err := huh.NewForm(
-		huh.NewGroup(
-			huh.NewMultiSelect[int]().
-				Title("Add agent config files to .gitignore?").
-				Options(options...).
-				Value(&selected),
-		),
-	).Run()
+		labels[i] = opt.label
+	}
+
+	selected, err := prompter.MultiSelect(title, labels)
 	if err != nil {
 		return nil, fmt.Errorf("prompt failed: %w", err)
 	}
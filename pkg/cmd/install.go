@@ -1,14 +1,16 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"io"
-	"net"
 	"os"
 	"path/filepath"
 	"strings"
+	"text/tabwriter"
 	"time"
 
+	"github.com/agentpkg/agentpkg/pkg/attest"
 	"github.com/agentpkg/agentpkg/pkg/config"
 	"github.com/agentpkg/agentpkg/pkg/installer"
 	"github.com/agentpkg/agentpkg/pkg/project"
@@ -16,7 +18,7 @@ import (
 	"github.com/agentpkg/agentpkg/pkg/serve"
 	"github.com/agentpkg/agentpkg/pkg/source"
 	"github.com/agentpkg/agentpkg/pkg/store"
-	"github.com/charmbracelet/huh"
+	"github.com/agentpkg/agentpkg/pkg/workspace"
 	"github.com/spf13/cobra"
 )
 
@@ -24,9 +26,31 @@ func newInstallCmd() *cobra.Command {
 	installCmd := &cobra.Command{
 		Use:   "install",
 		Short: "Install packages from apkg.toml",
-		Long:  "Resolves and installs all skills listed in apkg.toml, then projects them into agent configurations.",
-		RunE:  runInstallAll,
+		Long: `Resolves and installs all skills listed in apkg.toml, then projects them into agent configurations.
+
+MCP servers can define named env sets that override their env, args,
+url, or headers per environment:
+
+  [mcpServers.api.env_sets.staging]
+  url = "https://staging.example.com/mcp"
+
+Select one with --env-set or env_set in apkg.local.toml / ~/.apkg/config.toml.
+
+With --frozen, apkg installs exactly what apkg-lock.toml locks and fails
+instead of changing it, e.g. in CI: packages added to apkg.toml, or whose
+ref, checksum, or server config changed since they were locked, fail the
+install before anything is fetched. Add --lock-key, or --lock-identity and
+--lock-issuer, to also require the lockfile to be signed (see apkg lock
+sign) before anything is installed.`,
+		RunE: runInstallAll,
 	}
+	installCmd.Flags().Bool("frozen", false, "Fail instead of changing the lockfile or apkg.toml")
+	addLockTrustFlags(installCmd, "lock-key", "lock-identity", "lock-issuer")
+	installCmd.Flags().String("env-set", "", "Env set to apply to MCP servers that define it (overrides env_set in dev config)")
+	installCmd.Flags().Bool("force", false, "Accept packages whose content no longer matches the integrity in the lockfile")
+	installCmd.Flags().Int("concurrency", installer.DefaultConcurrency, "Number of packages to fetch at once")
+	installCmd.PersistentFlags().Bool("verbose", false, "Stream the output of npm, uv, and go while they install MCP servers")
+	installCmd.PersistentFlags().String("on-conflict", "", `What to do with agent MCP entries apkg didn't create: "overwrite", "adopt", or "skip" (prompts by default)`)
 
 	skillCmd := &cobra.Command{
 		Use:   "skill [ref]",
@@ -34,40 +58,72 @@ func newInstallCmd() *cobra.Command {
 		Long: `Adds a skill to apkg.toml and installs it.
 
 A ref like owner/repo/path@ref installs from git (GitHub).
-A local path starting with ./ or ../ installs from the filesystem.`,
-		Args: cobra.ExactArgs(1),
-		RunE: runInstallSkill,
-	}
+A local path starting with ./ or ../ installs from the filesystem. Agents
+see the directory itself, edits included; with --snapshot, they see a copy
+in the store instead, made anew when the directory has changed on the next
+install, with its integrity in the lockfile.
+An http(s) URL installs a skill published as a .tar.gz or .zip archive.
+The lockfile records the archive's sha256; pass --sha256 to also require
+it in apkg.toml.
+
+With --scope user, the skill is projected into the agents' global skills
+location (e.g. ~/.claude/skills) while apkg.toml still declares it.`,
+		Args:              cobra.ExactArgs(1),
+		RunE:              runInstallSkill,
+		ValidArgsFunction: completeSkillRefs,
+	}
+	skillCmd.Flags().String("scope", "", `Where to project the skill: "project" (default) or "user"`)
+	skillCmd.Flags().Bool("snapshot", false, "Project a copy of a local skill from the store instead of its directory")
+	skillCmd.Flags().String("sha256", "", "Digest the archive of a skill installed from a URL must have")
 
 	mcpCmd := &cobra.Command{
-		Use:   "mcp [name]",
+		Use:   "mcp [name] [ref]",
 		Short: "Add and install an MCP server",
 		Long: `Adds an MCP server to apkg.toml and installs it.
 
-Requires --transport (-t) to specify "stdio" or "http".
+The server is given by a short-form ref, from which the transport and the
+server's name are inferred (pass a name before the ref to choose another):
+
+  npm:@scope/pkg[@version]    managed npm package
+  uv:pkg[==version]           managed Python package
+  uv-tool:pkg[==version]      managed Python package installed as a uv tool
+  go:module[@version]         managed Go module
+  oci:image[:tag]             container image
+  https://host/path           remote HTTP server
+
+Flags override what the ref implies, e.g. -t http for a container serving
+HTTP, and add args, env, headers, or container settings.
+
+Without a ref, the server is described by flags alone, and --transport (-t)
+is required.
 
 Examples:
-  apkg install mcp my-server -t stdio --package npm:@modelcontextprotocol/server-filesystem
-  apkg install mcp my-server -t stdio --command /usr/local/bin/my-server --args flag1,flag2
-  apkg install mcp my-server -t http --url https://example.com/mcp
-  apkg install mcp my-server -t stdio --image my-image:latest`,
-		Args: cobra.ExactArgs(1),
+  apkg install mcp npm:@modelcontextprotocol/server-filesystem@1.2.3
+  apkg install mcp git uv:mcp-server-git
+  apkg install mcp linear https://mcp.linear.app/sse
+  apkg install mcp oci:ghcr.io/org/img:tag -t http --port 3000
+  apkg install mcp my-server -t stdio --command /usr/local/bin/my-server --args flag1,flag2`,
+		Args: cobra.RangeArgs(1, 2),
 		RunE: runInstallMCP,
 	}
 
-	mcpCmd.Flags().StringP("transport", "t", "", "Required. \"stdio\" or \"http\"")
-	mcpCmd.Flags().String("package", "", "Managed package (npm:pkg or uv:pkg)")
+	mcpCmd.Flags().StringP("transport", "t", "", "\"stdio\", \"http\" (streamable HTTP), or \"sse\" (required without a ref)")
+	mcpCmd.Flags().String("package", "", "Managed package (npm:pkg, uv:pkg, or uv-tool:pkg)")
+	mcpCmd.Flags().String("bin", "", "Console script to run for a uv-tool package that has several")
+	mcpCmd.Flags().Bool("in-container", false, "Run the package in a container image apkg builds, so the host needs no node, Python, or Go")
 	mcpCmd.Flags().String("command", "", "Unmanaged command path")
 	mcpCmd.Flags().StringSlice("args", nil, "Arguments for command or container entrypoint")
 	mcpCmd.Flags().String("image", "", "Container image")
 	mcpCmd.Flags().Int("port", 8080, "Container port for http containers")
 	mcpCmd.Flags().String("path", "/mcp", "URL path on the container")
-	mcpCmd.Flags().StringSlice("volume", nil, "Volume mounts for containers (host:container[:ro])")
-	mcpCmd.Flags().String("network", "", "Container network (e.g. \"host\", \"kind\")")
+	mcpCmd.Flags().StringSlice("volume", nil, "Volume mounts for containers (host:container[:ro]); relative host paths are relative to the project")
+	mcpCmd.Flags().Bool("create-volumes", false, "Create missing host directories of --volume mounts")
+	mcpCmd.Flags().String("network", "", "Container network (e.g. \"host\", \"kind\"); user-defined networks are created if missing")
+	mcpCmd.Flags().StringSlice("network-alias", nil, "Extra names for the container on a user-defined network")
+	mcpCmd.Flags().Bool("eager", false, "Start the container with apkg serve instead of on first request")
 	mcpCmd.Flags().String("url", "", "Remote HTTP endpoint URL")
 	mcpCmd.Flags().StringToString("env", nil, "Environment variables (KEY=VALUE)")
 	mcpCmd.Flags().StringToString("headers", nil, "HTTP headers (for external HTTP)")
-	_ = mcpCmd.MarkFlagRequired("transport")
 
 	installCmd.AddCommand(skillCmd)
 	installCmd.AddCommand(mcpCmd)
@@ -78,9 +134,9 @@ Examples:
 // based on whether the install is global or project-local.
 func resolveInstallPaths(global bool) (projectDir, manifestPath, lockPath string, err error) {
 	if global {
-		home, err := os.UserHomeDir()
+		home, err := config.HomeDir()
 		if err != nil {
-			return "", "", "", fmt.Errorf("determining home directory: %w", err)
+			return "", "", "", err
 		}
 		projectDir = home
 
@@ -97,175 +153,274 @@ func resolveInstallPaths(global bool) (projectDir, manifestPath, lockPath string
 		return projectDir, manifestPath, lockPath, nil
 	}
 
-	wd, err := os.Getwd()
-	if err != nil {
-		return "", "", "", fmt.Errorf("getting working directory: %w", err)
-	}
+	return ProjectDir, filepath.Join(ProjectDir, project.ManifestFile), filepath.Join(ProjectDir, config.LockFileName), nil
+}
 
-	return wd, filepath.Join(wd, project.ManifestFile), filepath.Join(wd, config.LockFileName), nil
+// openStore returns the store at $APKG_STORE_DIR, at store_path from the
+// dev config, or the default store.
+func openStore() (store.Store, error) {
+	if os.Getenv(store.EnvRoot) != "" {
+		return store.Default()
+	}
+	if DevCfg != nil && DevCfg.StorePath != "" {
+		return store.New(DevCfg.StorePath), nil
+	}
+	return store.Default()
 }
 
-func runInstallAll(cmd *cobra.Command, args []string) error {
+// commandWorkspace returns the workspace the running command acts on: the
+// current project, or the global scope with --global, configured by DevCfg
+// and --env-set. Its changes are journaled as caused by the command, and
+// its warnings printed.
+func commandWorkspace(cmd *cobra.Command) (*workspace.Workspace, error) {
 	global, err := cmd.Flags().GetBool("global")
 	if err != nil {
-		return err
+		return nil, err
 	}
+	ws, err := workspace.New(ProjectDir, global, DevCfg)
+	if err != nil {
+		return nil, err
+	}
+	ws.EnvSet = selectedEnvSet(cmd)
+	ws.RequireEnvSet = envSetFlagged(cmd)
+	ws.Command = commandLine()
+	ws.Warn = warnFunc(cmd)
+	return ws, nil
+}
 
-	projectDir, manifestPath, lockPath, err := resolveInstallPaths(global)
+func runInstallAll(cmd *cobra.Command, args []string) error {
+	ws, err := commandWorkspace(cmd)
 	if err != nil {
 		return err
 	}
 
-	cfg, err := config.LoadFile(manifestPath)
+	cfg, err := config.LoadFile(ws.ManifestPath)
 	if err != nil {
-		return fmt.Errorf("loading %s: %w", manifestPath, err)
+		return fmt.Errorf("loading %s: %w", ws.ManifestPath, err)
 	}
 
-	s, err := store.Default()
+	agents, err := resolveAgents(ws.Global)
 	if err != nil {
 		return err
 	}
 
-	existingLock, err := config.LoadLockFile(lockPath)
-	if err != nil {
-		return fmt.Errorf("loading lockfile: %w", err)
+	if err := offerGitignoreEntries(progressOut(cmd), ws.Dir, agents, ws.Global); err != nil {
+		return err
 	}
 
-	agents, err := resolveAgents(global)
+	deferred, err := deferredServers(cmd, ws.Global, sortedKeys(cfg.MCPServers))
 	if err != nil {
 		return err
 	}
 
-	inst := &installer.Installer{
-		Store:      s,
-		ProjectDir: projectDir,
-		Agents:     agents,
-		Global:     global,
+	resolve, err := conflictResolver(cmd)
+	if err != nil {
+		return err
 	}
-
-	lf, err := inst.InstallAll(cmd.Context(), cfg, existingLock)
+	// sync runs this without the flags.
+	force, _ := cmd.Flags().GetBool("force")
+	frozen, _ := cmd.Flags().GetBool("frozen")
+	var trust workspace.LockTrust
+	if frozen {
+		if trust, err = lockTrustFlags(cmd, "lock-key", "lock-identity", "lock-issuer"); err != nil {
+			return err
+		}
+		if trust.Empty() && attest.Signed(ws.LockPath) {
+			fmt.Fprintf(progressOut(cmd), "%s is signed, but its signature isn't checked without --lock-key or --lock-identity\n", ws.LockPath)
+		}
+	}
+	concurrency, err := cmd.Flags().GetInt("concurrency")
 	if err != nil {
 		return err
 	}
-
-	if err := config.SaveLockFile(lockPath, lf); err != nil {
-		return fmt.Errorf("writing lockfile: %w", err)
+	if concurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1")
+	}
+
+	// Warnings are printed after the summary, so they don't get lost
+	// among the install's output.
+	warnings := &warningBuffer{}
+	defer warnings.flush(cmd)
+
+	var results []installer.PackageResult
+	ws.Agents = agents
+	ws.Warn = warnings.warn
+	lf, err := ws.InstallAll(cmd.Context(), workspace.InstallOptions{
+		Frozen:          frozen,
+		Trust:           trust,
+		Force:           force,
+		Concurrency:     concurrency,
+		DeferredServers: deferred,
+		Conflict:        resolve,
+		ToolOutput:      toolOutput(cmd),
+		Report: func(r installer.PackageResult) {
+			results = append(results, r)
+		},
+	})
+	var integrityErr *installer.IntegrityError
+	if errors.As(err, &integrityErr) {
+		return fmt.Errorf("%w\nThe cached copy may have been modified or upstream replaced the release; run apkg install --force to accept it", err)
+	}
+	if err != nil {
+		return err
 	}
 
+	if err := printInstallSummary(cmd, results); err != nil {
+		return err
+	}
 	fmt.Fprintf(cmd.OutOrStdout(), "Installed %d skill(s) and %d MCP server(s)\n", len(lf.Skills), len(lf.MCPServers))
 	if len(agents) == 0 {
-		fmt.Fprintln(cmd.OutOrStdout(), "Warning: no agents selected, packages were not projected into any agent configuration")
-	} else {
-		total := len(cfg.Skills) + len(cfg.MCPServers)
-		fmt.Fprintf(cmd.OutOrStdout(), "Projected %d package(s) to %s\n", total, strings.Join(agents, ", "))
+		warnings.warn(errors.New("no agents selected, packages were not projected into any agent configuration"))
 	}
 
-	warnIfServeNotRunning(cmd.OutOrStdout(), containerServerNames(cfg))
+	warnIfServeNotRunning(progressOut(cmd), ws.Store, containerServerNames(cfg))
 	return nil
 }
 
-func runInstallSkill(cmd *cobra.Command, args []string) error {
-	global, err := cmd.Flags().GetBool("global")
-	if err != nil {
-		return err
+// printInstallSummary prints a table of the installed packages: their
+// version, whether it changed, the agents they were projected to, and how
+// long they took. --quiet leaves it out.
+func printInstallSummary(cmd *cobra.Command, results []installer.PackageResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+	tw := tabwriter.NewWriter(progressOut(cmd), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PACKAGE\tKIND\tVERSION\tSTATUS\tAGENTS\tTIME")
+	for _, r := range results {
+		agents := "-"
+		if len(r.Agents) > 0 {
+			agents = strings.Join(r.Agents, ",")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", r.Name, r.Kind, summaryVersion(r.Version), r.Status, agents, summaryDuration(r.Duration))
 	}
+	return tw.Flush()
+}
 
-	projectDir, manifestPath, lockPath, err := resolveInstallPaths(global)
-	if err != nil {
-		return err
+// summaryVersion shortens commits and digests for the install summary.
+func summaryVersion(v string) string {
+	if v == "" {
+		return "-"
 	}
+	if digest, ok := strings.CutPrefix(v, "sha256:"); ok && len(digest) > 12 {
+		return "sha256:" + digest[:12]
+	}
+	return shortVersion(v)
+}
 
-	src, skillSource, err := source.ParseRef(args[0])
-	if err != nil {
-		return err
+func summaryDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
 	}
+	return d.Round(100 * time.Millisecond).String()
+}
 
-	s, err := store.Default()
+// deferredServers returns the servers among names that agents take from
+// the global install, for a project install. Servers installed in both
+// scopes follow the server_scopes dev config, or the answer to
+// promptServerScope. Global installs only warn about them.
+func deferredServers(cmd *cobra.Command, global bool, names []string) ([]string, error) {
+	other, err := otherScopeLockFile(global)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	agents, err := resolveAgents(global)
-	if err != nil {
-		return err
+	var deferred []string
+	for _, name := range installer.DuplicateServers(names, other) {
+		if global {
+			warnf(cmd, "MCP server %q is also installed in %s; agents may see both definitions there", name, ProjectDir)
+			continue
+		}
+
+		scope := DevCfg.ServerScopes[name]
+		if scope == "" {
+			if scope, err = promptServerScope(cmd, name); err != nil {
+				return nil, err
+			}
+		}
+		if scope == config.ServerScopeGlobal {
+			fmt.Fprintf(progressOut(cmd), "Agents use the global definition of MCP server %q here (see %s.%s)\n", name, config.KeyServerScopes, name)
+			deferred = append(deferred, name)
+		}
 	}
+	return deferred, nil
+}
 
-	inst := &installer.Installer{
-		Store:      s,
-		ProjectDir: projectDir,
-		Agents:     agents,
-		Global:     global,
+// selectedEnvSet returns the env set chosen with --env-set, falling back
+// to env_set from the dev config.
+func selectedEnvSet(cmd *cobra.Command) string {
+	if flag := cmd.Flags().Lookup("env-set"); flag != nil && flag.Changed {
+		return flag.Value.String()
 	}
+	return DevCfg.EnvSet
+}
 
-	sk, resolved, err := inst.InstallSkill(cmd.Context(), src)
+// envSetFlagged reports whether the env set was chosen with --env-set,
+// which installs require the manifest to define.
+func envSetFlagged(cmd *cobra.Command) bool {
+	flag := cmd.Flags().Lookup("env-set")
+	return flag != nil && flag.Changed
+}
+
+func runInstallSkill(cmd *cobra.Command, args []string) error {
+	ws, err := commandWorkspace(cmd)
 	if err != nil {
 		return err
 	}
 
-	// Ensure global manifest exists when installing globally.
-	if global {
-		if err := project.InitGlobal(); err != nil {
-			return err
-		}
+	opts := workspace.SkillOptions{}
+	if opts.Scope, err = cmd.Flags().GetString("scope"); err != nil {
+		return err
 	}
-
-	// Update apkg.toml with the new skill.
-	cfg, err := config.LoadFile(manifestPath)
-	if err != nil {
-		return fmt.Errorf("loading %s: %w", manifestPath, err)
+	if opts.Snapshot, err = cmd.Flags().GetBool("snapshot"); err != nil {
+		return err
 	}
-
-	if cfg.Skills == nil {
-		cfg.Skills = make(map[string]config.SkillSource)
+	if opts.SHA256, err = cmd.Flags().GetString("sha256"); err != nil {
+		return err
 	}
-	cfg.Skills[sk.Name()] = skillSource
-
-	if err := config.SaveFile(manifestPath, cfg); err != nil {
-		return fmt.Errorf("saving %s: %w", manifestPath, err)
+	// Local paths are relative to where apkg runs, not the project root.
+	if opts.Dir, err = os.Getwd(); err != nil {
+		return err
 	}
 
-	// Update lockfile.
-	lf, err := config.LoadLockFile(lockPath)
+	agents, err := resolveAgents(ws.Global)
 	if err != nil {
-		return fmt.Errorf("loading lockfile: %w", err)
+		return err
 	}
 
-	lockEntry := config.SkillLockEntry{
-		Git:       skillSource.Git,
-		Path:      skillSource.Path,
-		Ref:       resolved.Ref,
-		Commit:    resolved.Commit,
-		Integrity: resolved.Integrity,
+	if err := offerGitignoreEntries(progressOut(cmd), ws.Dir, agents, ws.Global); err != nil {
+		return err
 	}
 
-	lf.Skills = upsertLockEntry(lf.Skills, lockEntry)
-
-	if err := config.SaveLockFile(lockPath, lf); err != nil {
-		return fmt.Errorf("writing lockfile: %w", err)
+	ws.Agents = agents
+	name, err := ws.InstallSkill(cmd.Context(), args[0], opts)
+	if err != nil {
+		return err
 	}
 
-	fmt.Fprintf(cmd.OutOrStdout(), "Installed skill %q\n", sk.Name())
+	fmt.Fprintf(cmd.OutOrStdout(), "Installed skill %q\n", name)
 	if len(agents) == 0 {
-		fmt.Fprintln(cmd.OutOrStdout(), "Warning: no agents selected, skill was not projected into any agent configuration")
+		warnf(cmd, "no agents selected, skill was not projected into any agent configuration")
 	} else {
-		fmt.Fprintf(cmd.OutOrStdout(), "Projected 1 skill(s) to %s\n", strings.Join(agents, ", "))
+		fmt.Fprintf(progressOut(cmd), "Projected 1 skill(s) to %s\n", strings.Join(agents, ", "))
 	}
 	return nil
 }
 
 func runInstallMCP(cmd *cobra.Command, args []string) error {
-	global, err := cmd.Flags().GetBool("global")
+	ws, err := commandWorkspace(cmd)
 	if err != nil {
 		return err
 	}
+	projectDir, manifestPath, lockPath, global := ws.Dir, ws.ManifestPath, ws.LockPath, ws.Global
 
-	projectDir, manifestPath, lockPath, err := resolveInstallPaths(global)
-	if err != nil {
-		return err
+	name, ref := args[0], ""
+	switch {
+	case len(args) == 2:
+		ref = args[1]
+	case source.IsMCPRef(name):
+		ref, name = name, ""
 	}
-
-	name := args[0]
-	mcpSource, err := mcpSourceFromFlags(cmd, name)
+	mcpSource, name, err := mcpSourceFromFlags(cmd, name, ref)
 	if err != nil {
 		return err
 	}
@@ -275,28 +430,31 @@ func runInstallMCP(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	s, err := store.Default()
+	agents, err := resolveAgents(global)
 	if err != nil {
 		return err
 	}
 
-	agents, err := resolveAgents(global)
-	if err != nil {
+	if err := offerGitignoreEntries(progressOut(cmd), projectDir, agents, global); err != nil {
 		return err
 	}
 
-	inst := &installer.Installer{
-		Store:      s,
-		ProjectDir: projectDir,
-		Agents:     agents,
-		Global:     global,
+	deferred, err := deferredServers(cmd, global, []string{name})
+	if err != nil {
+		return err
 	}
 
-	server, resolved, err := inst.InstallMCP(cmd.Context(), name, src)
+	resolve, err := conflictResolver(cmd)
 	if err != nil {
 		return err
 	}
 
+	ws.Agents = agents
+	inst := ws.Installer()
+	inst.DeferredServers = deferred
+	inst.Conflict = resolve
+	inst.ToolOutput = toolOutput(cmd)
+
 	// Ensure global manifest exists when installing globally.
 	if global {
 		if err := project.InitGlobal(); err != nil {
@@ -304,58 +462,70 @@ func runInstallMCP(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	// Update apkg.toml with the new MCP server.
 	cfg, err := config.LoadFile(manifestPath)
 	if err != nil {
 		return fmt.Errorf("loading %s: %w", manifestPath, err)
 	}
+	lf, err := config.LoadLockFile(lockPath)
+	if err != nil {
+		return fmt.Errorf("loading lockfile: %w", err)
+	}
 
-	if cfg.MCPServers == nil {
-		cfg.MCPServers = make(map[string]config.MCPSource)
+	check := &config.Config{MCPServers: map[string]config.MCPSource{name: mcpSource}, Runtimes: cfg.Runtimes}
+	if err := inst.CheckTools(check, nil); err != nil {
+		return err
 	}
-	cfg.MCPServers[name] = mcpSource
 
-	if err := config.SaveFile(manifestPath, cfg); err != nil {
-		return fmt.Errorf("saving %s: %w", manifestPath, err)
+	// The server runs on the runtimes pinned in the manifest.
+	lf.Runtimes, err = inst.InstallRuntimes(cmd.Context(), cfg.Runtimes, lf.Runtimes)
+	if err != nil {
+		return err
 	}
 
-	// Update lockfile.
-	lf, err := config.LoadLockFile(lockPath)
+	server, resolved, err := inst.InstallMCP(cmd.Context(), name, src)
 	if err != nil {
-		return fmt.Errorf("loading lockfile: %w", err)
+		return err
 	}
 
-	lockEntry := config.MCPLockEntry{
-		Name:      name,
-		Transport: mcpSource.Transport,
-		Integrity: resolved.Integrity,
+	// Update apkg.toml with the new MCP server.
+	if cfg.MCPServers == nil {
+		cfg.MCPServers = make(map[string]config.MCPSource)
 	}
-	if mcpSource.ManagedStdioMCPConfig != nil {
-		lockEntry.Package = mcpSource.Package
+	cfg.MCPServers[name] = mcpSource
+
+	if err := config.SaveFile(manifestPath, cfg); err != nil {
+		return fmt.Errorf("saving %s: %w", manifestPath, err)
 	}
 
+	// Update lockfile.
+	lockEntry := installer.NewMCPLockEntry(name, mcpSource, resolved)
 	lf.MCPServers = upsertMCPLockEntry(lf.MCPServers, lockEntry)
 
-	if err := config.SaveLockFile(lockPath, lf); err != nil {
-		return fmt.Errorf("writing lockfile: %w", err)
+	if err := ws.SaveLock(lf); err != nil {
+		return err
 	}
 
 	fmt.Fprintf(cmd.OutOrStdout(), "Installed MCP server %q\n", server.Name())
 	if len(agents) == 0 {
-		fmt.Fprintln(cmd.OutOrStdout(), "Warning: no agents selected, MCP server was not projected into any agent configuration")
+		warnf(cmd, "no agents selected, MCP server was not projected into any agent configuration")
 	} else {
-		fmt.Fprintf(cmd.OutOrStdout(), "Projected 1 MCP server(s) to %s\n", strings.Join(agents, ", "))
+		fmt.Fprintf(progressOut(cmd), "Projected 1 MCP server(s) to %s\n", strings.Join(agents, ", "))
 	}
 
 	if mcpSource.ContainerMCPConfig != nil && mcpSource.Image != "" {
-		warnIfServeNotRunning(cmd.OutOrStdout(), []string{name})
+		warnIfServeNotRunning(progressOut(cmd), ws.Store, []string{name})
 	}
 	return nil
 }
 
-func mcpSourceFromFlags(cmd *cobra.Command, name string) (config.MCPSource, error) {
+// mcpSourceFromFlags builds the config of an MCP server from its short-form
+// ref, if given, and the flags, and returns it with the server's name: name,
+// or the one inferred from ref when name is empty.
+func mcpSourceFromFlags(cmd *cobra.Command, name, ref string) (config.MCPSource, string, error) {
 	transport, _ := cmd.Flags().GetString("transport")
 	pkg, _ := cmd.Flags().GetString("package")
+	bin, _ := cmd.Flags().GetString("bin")
+	inContainer, _ := cmd.Flags().GetBool("in-container")
 	command, _ := cmd.Flags().GetString("command")
 	args, _ := cmd.Flags().GetStringSlice("args")
 	image, _ := cmd.Flags().GetString("image")
@@ -364,14 +534,47 @@ func mcpSourceFromFlags(cmd *cobra.Command, name string) (config.MCPSource, erro
 	env, _ := cmd.Flags().GetStringToString("env")
 	headers, _ := cmd.Flags().GetStringToString("headers")
 
-	ms := config.MCPSource{
-		Transport: transport,
-		Name:      name,
+	ms := config.MCPSource{Transport: transport}
+	if ref != "" {
+		for _, flag := range []string{"package", "command", "image", "url"} {
+			if cmd.Flags().Changed(flag) {
+				return config.MCPSource{}, "", fmt.Errorf("--%s can't be combined with ref %q", flag, ref)
+			}
+		}
+		parsed, inferred, err := source.ParseMCPRef(ref)
+		if err != nil {
+			return config.MCPSource{}, "", err
+		}
+		if name == "" {
+			name = inferred
+		}
+		if transport != "" {
+			parsed.Transport = transport
+		}
+		ms = parsed
+		if ms.ContainerMCPConfig != nil {
+			image = ms.Image
+		}
+	} else if transport == "" {
+		return config.MCPSource{}, "", errors.New(`required flag(s) "transport" not set`)
 	}
+	ms.Name = name
 
 	if pkg != "" {
 		ms.ManagedStdioMCPConfig = &config.ManagedStdioMCPConfig{Package: pkg}
 	}
+	if bin != "" {
+		if ms.ManagedStdioMCPConfig == nil || !strings.HasPrefix(ms.Package, "uv-tool:") {
+			return config.MCPSource{}, "", errors.New("--bin only applies to uv-tool packages")
+		}
+		ms.Bin = bin
+	}
+	if inContainer {
+		if ms.ManagedStdioMCPConfig == nil {
+			return config.MCPSource{}, "", errors.New("--in-container only applies to packages")
+		}
+		ms.Runtime = config.RuntimeContainer
+	}
 	if command != "" {
 		ms.UnmanagedStdioMCPConfig = &config.UnmanagedStdioMCPConfig{Command: command}
 	}
@@ -379,7 +582,19 @@ func mcpSourceFromFlags(cmd *cobra.Command, name string) (config.MCPSource, erro
 		path, _ := cmd.Flags().GetString("path")
 		volumes, _ := cmd.Flags().GetStringSlice("volume")
 		network, _ := cmd.Flags().GetString("network")
-		ms.ContainerMCPConfig = &config.ContainerMCPConfig{Image: image, Port: &port, Path: path, Volumes: volumes, Network: network}
+		createVolumes, _ := cmd.Flags().GetBool("create-volumes")
+		aliases, _ := cmd.Flags().GetStringSlice("network-alias")
+		eager, _ := cmd.Flags().GetBool("eager")
+		ms.ContainerMCPConfig = &config.ContainerMCPConfig{
+			Image:          image,
+			Port:           &port,
+			Path:           path,
+			Volumes:        volumes,
+			CreateVolumes:  createVolumes,
+			Network:        network,
+			NetworkAliases: aliases,
+			Eager:          eager,
+		}
 	}
 	if url != "" {
 		ms.ExternalHttpMCPConfig = &config.ExternalHttpMCPConfig{URL: url}
@@ -398,7 +613,7 @@ func mcpSourceFromFlags(cmd *cobra.Command, name string) (config.MCPSource, erro
 		ms.HttpMCPConfig = &config.HttpMCPConfig{Headers: headers}
 	}
 
-	return ms, nil
+	return ms, name, nil
 }
 
 func upsertMCPLockEntry(entries []config.MCPLockEntry, entry config.MCPLockEntry) []config.MCPLockEntry {
@@ -411,119 +626,95 @@ func upsertMCPLockEntry(entries []config.MCPLockEntry, entry config.MCPLockEntry
 	return append(entries, entry)
 }
 
-// upsertLockEntry adds or replaces a lock entry, matching on git+path
-// (for git sources) or path alone (for local sources).
-func upsertLockEntry(entries []config.SkillLockEntry, entry config.SkillLockEntry) []config.SkillLockEntry {
-	key := entryKey(entry)
-	for i, e := range entries {
-		if entryKey(e) == key {
-			entries[i] = entry
-			return entries
-		}
-	}
-	return append(entries, entry)
-}
-
-func entryKey(e config.SkillLockEntry) string {
-	if e.Git != "" {
-		return e.Git + "|" + e.Path
-	}
-	return e.Path
-}
-
 // resolveAgents returns the agent list from DevCfg, or prompts the user
 // to select from all registered projector agents if none are configured.
+// Agents passed with --agents are saved when --save-agents is set.
 func resolveAgents(global bool) ([]string, error) {
 	if len(DevCfg.Agents) > 0 {
+		if len(flagAgents) > 0 && flagSaveAgents != "" {
+			if err := saveAgents(flagAgents, flagSaveAgents, global); err != nil {
+				return nil, err
+			}
+		}
 		return DevCfg.Agents, nil
 	}
+	if err := requireTerminal("--agents"); err != nil {
+		return nil, err
+	}
 	return promptAgents(global)
 }
 
-// promptAgents uses huh to present a multi-select of all registered agents,
-// then asks whether to save the choice for future installs.
-// When global is true, the save prompt only offers "globally" (not "for this project").
+// promptAgents presents a multi-select of all registered agents,
+// then asks whether to save the choice for future installs unless
+// --save-agents answered it. When global is true, the save prompt only
+// offers "globally" (not "for this project").
 func promptAgents(global bool) ([]string, error) {
 	agents := projector.RegisteredAgents()
-	options := make([]huh.Option[string], len(agents))
-	for i, a := range agents {
-		options[i] = huh.NewOption(a, a)
-	}
-
-	var selected []string
-	err := huh.NewForm(
-		huh.NewGroup(
-			huh.NewMultiSelect[string]().
-				Title("Select agents to project skills for").
-				Options(options...).
-				Value(&selected),
-		),
-	).Run()
+	idxs, err := prompter.MultiSelect("Select agents to project skills for", agents)
 	if err != nil {
 		return nil, fmt.Errorf("agent selection prompt failed: %w", err)
 	}
 
+	selected := make([]string, len(idxs))
+	for i, idx := range idxs {
+		selected[i] = agents[idx]
+	}
+
 	if len(selected) == 0 {
 		return selected, nil
 	}
 
-	var saveOptions []huh.Option[string]
+	if flagSaveAgents != "" {
+		return selected, saveAgents(selected, flagSaveAgents, global)
+	}
+
+	labels := []string{"Yes, for this project", "Yes, globally", "No"}
+	choices := []string{saveAgentsProject, saveAgentsGlobal, saveAgentsNo}
 	if global {
-		saveOptions = []huh.Option[string]{
-			huh.NewOption("Yes, globally", "global"),
-			huh.NewOption("No", "no"),
-		}
-	} else {
-		saveOptions = []huh.Option[string]{
-			huh.NewOption("Yes, for this project", "project"),
-			huh.NewOption("Yes, globally", "global"),
-			huh.NewOption("No", "no"),
-		}
+		labels, choices = labels[1:], choices[1:]
 	}
 
-	var saveChoice string
-	err = huh.NewForm(
-		huh.NewGroup(
-			huh.NewSelect[string]().
-				Title("Save agent selection for future installs?").
-				Options(saveOptions...).
-				Value(&saveChoice),
-		),
-	).Run()
+	idx, err := prompter.Select("Save agent selection for future installs?", labels)
 	if err != nil {
 		return nil, fmt.Errorf("save preference prompt failed: %w", err)
 	}
 
-	devCfg := &config.DevConfig{Agents: selected}
-	switch saveChoice {
-	case "project":
-		wd, err := os.Getwd()
-		if err != nil {
-			return nil, fmt.Errorf("getting working directory: %w", err)
-		}
-		if err := config.WriteLocalDevConfig(wd, devCfg); err != nil {
-			return nil, err
+	return selected, saveAgents(selected, choices[idx], global)
+}
+
+// saveAgents persists the agent selection per a --save-agents value,
+// keeping the other keys of the config file it is saved to.
+func saveAgents(agents []string, choice string, global bool) error {
+	var path string
+	switch choice {
+	case saveAgentsProject:
+		if global {
+			return fmt.Errorf("--save-agents=%s can't be used with --global", saveAgentsProject)
 		}
-	case "global":
-		if err := config.WriteGlobalDevConfig(devCfg); err != nil {
-			return nil, err
+		path = filepath.Join(ProjectDir, config.LocalConfigFile)
+	case saveAgentsGlobal:
+		var err error
+		if path, err = config.GlobalDevConfigPath(); err != nil {
+			return err
 		}
+	default:
+		return nil
 	}
-
-	return selected, nil
+	return config.UpdateDevConfigFile(path, func(cfg *config.DevConfig) error {
+		cfg.Agents = agents
+		return nil
+	})
 }
 
 // warnIfServeNotRunning prints a warning when containerized MCP servers
-// are installed but the apkg serve proxy isn't reachable.
-func warnIfServeNotRunning(w io.Writer, names []string) {
+// are installed but the apkg serve proxy isn't reachable where it last
+// advertised.
+func warnIfServeNotRunning(w io.Writer, st store.Store, names []string) {
 	if len(names) == 0 {
 		return
 	}
 
-	addr := fmt.Sprintf("127.0.0.1:%d", serve.DefaultPort)
-	conn, err := net.DialTimeout("tcp", addr, 500*time.Millisecond)
-	if err == nil {
-		conn.Close()
+	if serve.Advertised(st).Reachable() {
 		return // proxy is running
 	}
 
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/agentpkg/agentpkg/pkg/installer"
+	"github.com/agentpkg/agentpkg/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+func newListCmd() *cobra.Command {
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List installed skills and MCP servers",
+		Long: `Prints every skill and MCP server in apkg.toml with its source, the commit
+or version it resolved to, its locked integrity hash (abbreviated like
+commits), and the agents it is projected to.
+
+With --json, the packages are printed as a JSON array for scripts and CI
+jobs, with full commits and hashes, and the paths projection created for
+each agent: skill symlinks and rendered files, and the agent config file
+and JSON pointer of each MCP server's entry.
+
+With --all-projects, the packages of the global scope and of every project
+apkg has installed into on this machine are printed, project by project,
+with the agents each projects into; projects that no longer exist are
+marked missing. Useful before apkg cache gc, or to find out which project
+an MCP server comes from. With --prune, missing projects are forgotten
+first, so they're no longer listed and cache gc no longer considers them.`,
+		Args: cobra.NoArgs,
+		RunE: runList,
+	}
+	listCmd.Flags().Bool("json", false, "Print the packages as JSON")
+	listCmd.Flags().Bool("all-projects", false, "List the packages of every project apkg has installed into")
+	listCmd.Flags().Bool("prune", false, "With --all-projects, forget projects that no longer exist")
+	return listCmd
+}
+
+func runList(cmd *cobra.Command, args []string) error {
+	asJSON, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		return err
+	}
+	allProjects, err := cmd.Flags().GetBool("all-projects")
+	if err != nil {
+		return err
+	}
+	prune, err := cmd.Flags().GetBool("prune")
+	if err != nil {
+		return err
+	}
+	if prune && !allProjects {
+		return fmt.Errorf("--prune requires --all-projects")
+	}
+	if allProjects {
+		return listAllProjects(cmd, asJSON, prune)
+	}
+
+	ws, err := commandWorkspace(cmd)
+	if err != nil {
+		return err
+	}
+	cfg, lf, err := ws.Load()
+	if err != nil {
+		return err
+	}
+
+	inst := ws.Installer()
+	nodes := inst.Tree(cfg, lf)
+	if asJSON {
+		if nodes == nil {
+			nodes = []installer.TreeNode{}
+		}
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(nodes)
+	}
+
+	if len(nodes) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "No packages installed")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tKIND\tSOURCE\tVERSION\tINTEGRITY\tAGENTS")
+	for _, node := range nodes {
+		agents := strings.Join(node.Agents, ",")
+		if agents == "" {
+			agents = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", node.Name, node.Kind, node.Source, summaryVersion(node.Resolved), shortIntegrity(node.Integrity), agents)
+	}
+	return tw.Flush()
+}
+
+// shortIntegrity abbreviates an integrity hash for the table like commits
+// are, to the first 7 characters of its digest.
+func shortIntegrity(v string) string {
+	if v == "" {
+		return "-"
+	}
+	if _, digest, ok := strings.Cut(v, ":"); ok {
+		v = digest
+	}
+	if len(v) > 7 {
+		return v[:7]
+	}
+	return v
+}
+
+func listAllProjects(cmd *cobra.Command, asJSON, prune bool) error {
+	if prune {
+		pruned, err := workspace.PruneProjects()
+		if err != nil {
+			return err
+		}
+		for _, p := range pruned {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Forgot %s, which no longer exists\n", p.Dir)
+		}
+	}
+
+	projects, err := workspace.AllProjects()
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(projects)
+	}
+
+	w := cmd.OutOrStdout()
+	for i, p := range projects {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		header := p.Dir
+		if p.Global {
+			header = "global (" + p.Dir + ")"
+		}
+		if !p.LastInstall.IsZero() {
+			header += ", last installed " + p.LastInstall.Local().Format(time.DateTime)
+		}
+		fmt.Fprintln(w, header)
+		switch {
+		case p.Missing:
+			fmt.Fprintln(w, "  missing")
+			continue
+		case p.Error != "":
+			fmt.Fprintf(w, "  error: %s\n", p.Error)
+			continue
+		case len(p.Packages) == 0:
+			fmt.Fprintln(w, "  No packages installed")
+			continue
+		}
+		if len(p.Agents) > 0 {
+			fmt.Fprintf(w, "  agents: %s\n", strings.Join(p.Agents, ", "))
+		}
+
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  NAME\tKIND\tSOURCE\tVERSION\tAGENTS")
+		for _, node := range p.Packages {
+			agents := strings.Join(node.Agents, ",")
+			if agents == "" {
+				agents = "-"
+			}
+			fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\n", node.Name, node.Kind, node.Source, summaryVersion(node.Resolved), agents)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
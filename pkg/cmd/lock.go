@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/agentpkg/agentpkg/pkg/attest"
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+func newLockCmd() *cobra.Command {
+	lockCmd := &cobra.Command{
+		Use:   "lock",
+		Short: "Manage the lockfile",
+	}
+
+	resolveCmd := &cobra.Command{
+		Use:   "resolve",
+		Short: "Resolve git merge conflicts in the lockfile",
+		Long: `Parses a lockfile containing git merge conflict markers, reconciles both
+sides against apkg.toml, and writes a clean lockfile.
+
+Entries no longer in apkg.toml are dropped. When both sides lock a package
+differently, the entry matching apkg.toml wins, then the higher resolved
+version. Entries that can't be reconciled are dropped and re-resolved by
+the next apkg install.`,
+		Args: cobra.NoArgs,
+		RunE: runLockResolve,
+	}
+
+	keygenCmd := &cobra.Command{
+		Use:   "keygen <path>",
+		Short: "Generate a team key pair for signing the lockfile",
+		Long: `Writes a new ed25519 private key to <path> and its public key to
+<path>.pub. Keep the private key with the people who approve lockfile
+changes, and give CI the public key to verify with.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runLockKeygen,
+	}
+
+	signCmd := &cobra.Command{
+		Use:   "sign",
+		Short: "Sign the lockfile",
+		Long: `Signs apkg-lock.toml, recording that its packages were reviewed and
+approved. apkg install --frozen verifies the signature, so CI only installs
+an approved and unmodified lockfile.
+
+With --key, the lockfile is signed with a team key from apkg lock keygen,
+and the signature is written to apkg-lock.toml.sig. With --keyless, it is
+signed with sigstore through the cosign CLI, using your OIDC identity (or
+the CI's), and the bundle is written to apkg-lock.toml.sigstore.json.
+
+Commit the signature next to the lockfile. Changing the lockfile, e.g. by
+running apkg install or apkg update, invalidates it.`,
+		Args: cobra.NoArgs,
+		RunE: runLockSign,
+	}
+	signCmd.Flags().String("key", "", "Private key to sign with")
+	signCmd.Flags().Bool("keyless", false, "Sign with sigstore through cosign instead of a key")
+
+	verifyCmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Verify the lockfile's signature",
+		Long: `Checks that apkg-lock.toml is signed and hasn't changed since, with the
+team's public key (--key) or, for keyless signatures, the identity and OIDC
+issuer that must have signed it (--identity and --issuer).`,
+		Args: cobra.NoArgs,
+		RunE: runLockVerify,
+	}
+	addLockTrustFlags(verifyCmd, "key", "identity", "issuer")
+
+	lockCmd.AddCommand(resolveCmd, keygenCmd, signCmd, verifyCmd)
+	return lockCmd
+}
+
+// addLockTrustFlags adds the flags naming whose lockfile signature to
+// trust, under the given names.
+func addLockTrustFlags(cmd *cobra.Command, key, identity, issuer string) {
+	cmd.Flags().String(key, "", "Public key the lockfile must be signed with")
+	cmd.Flags().String(identity, "", "Identity (e.g. email or CI workflow URL) a keyless signature must be issued to")
+	cmd.Flags().String(issuer, "", "OIDC issuer of a keyless signature's identity")
+}
+
+// lockTrustFlags reads the flags added by addLockTrustFlags.
+func lockTrustFlags(cmd *cobra.Command, key, identity, issuer string) (workspace.LockTrust, error) {
+	var trust workspace.LockTrust
+	var err error
+	if trust.Key, err = cmd.Flags().GetString(key); err != nil {
+		return trust, err
+	}
+	if trust.Identity, err = cmd.Flags().GetString(identity); err != nil {
+		return trust, err
+	}
+	if trust.Issuer, err = cmd.Flags().GetString(issuer); err != nil {
+		return trust, err
+	}
+	switch {
+	case trust.Key != "" && (trust.Identity != "" || trust.Issuer != ""):
+		return trust, fmt.Errorf("--%s can't be combined with --%s or --%s", key, identity, issuer)
+	case (trust.Identity == "") != (trust.Issuer == ""):
+		return trust, fmt.Errorf("--%s and --%s must be given together", identity, issuer)
+	}
+	return trust, nil
+}
+
+func runLockKeygen(cmd *cobra.Command, args []string) error {
+	privPath, pubPath := args[0], args[0]+".pub"
+	for _, path := range []string{privPath, pubPath} {
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("%s already exists", path)
+		}
+	}
+
+	privPEM, pubPEM, err := attest.GenerateKey()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(privPath, privPEM, 0o600); err != nil {
+		return fmt.Errorf("writing private key: %w", err)
+	}
+	if err := os.WriteFile(pubPath, pubPEM, 0o644); err != nil {
+		return fmt.Errorf("writing public key: %w", err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Wrote private key to %s and public key to %s\n", privPath, pubPath)
+	return nil
+}
+
+func runLockSign(cmd *cobra.Command, args []string) error {
+	global, err := cmd.Flags().GetBool("global")
+	if err != nil {
+		return err
+	}
+	key, err := cmd.Flags().GetString("key")
+	if err != nil {
+		return err
+	}
+	keyless, err := cmd.Flags().GetBool("keyless")
+	if err != nil {
+		return err
+	}
+	if (key == "") == !keyless {
+		return errors.New("pass either --key or --keyless")
+	}
+
+	_, _, lockPath, err := resolveInstallPaths(global)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(lockPath); err != nil {
+		return fmt.Errorf("%s not found; run apkg install first", lockPath)
+	}
+
+	if keyless {
+		if err := attest.SignKeyless(cmd.Context(), lockPath); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Signed %s; commit %s with it\n", lockPath, lockPath+attest.BundleSuffix)
+		return nil
+	}
+	if err := attest.SignFile(lockPath, key); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Signed %s; commit %s with it\n", lockPath, lockPath+attest.SigSuffix)
+	return nil
+}
+
+func runLockVerify(cmd *cobra.Command, args []string) error {
+	global, err := cmd.Flags().GetBool("global")
+	if err != nil {
+		return err
+	}
+	trust, err := lockTrustFlags(cmd, "key", "identity", "issuer")
+	if err != nil {
+		return err
+	}
+	if trust.Empty() {
+		return errors.New("pass --key, or --identity and --issuer")
+	}
+
+	_, _, lockPath, err := resolveInstallPaths(global)
+	if err != nil {
+		return err
+	}
+	if err := trust.Verify(cmd.Context(), lockPath); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "%s is signed and unchanged\n", lockPath)
+	return nil
+}
+
+func runLockResolve(cmd *cobra.Command, args []string) error {
+	global, err := cmd.Flags().GetBool("global")
+	if err != nil {
+		return err
+	}
+
+	_, manifestPath, lockPath, err := resolveInstallPaths(global)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadFile(manifestPath)
+	if err != nil {
+		return fmt.Errorf("loading %s: %w", manifestPath, err)
+	}
+
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", lockPath, err)
+	}
+
+	if !config.HasConflictMarkers(data) {
+		fmt.Fprintf(cmd.OutOrStdout(), "No merge conflicts found in %s\n", lockPath)
+		return nil
+	}
+
+	ours, theirs, err := config.SplitConflictedLockFile(data)
+	if err != nil {
+		return fmt.Errorf("parsing %s: %w", lockPath, err)
+	}
+
+	result := config.MergeLockFiles(cfg, ours, theirs)
+
+	if err := config.SaveLockFile(lockPath, result.LockFile); err != nil {
+		return fmt.Errorf("writing lockfile: %w", err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Resolved %s (%d skill(s), %d MCP server(s))\n",
+		lockPath, len(result.LockFile.Skills), len(result.LockFile.MCPServers))
+	if len(result.Unresolved) > 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "The following entries could not be reconciled and will be re-resolved by `apkg install`:")
+		for _, entry := range result.Unresolved {
+			fmt.Fprintf(cmd.OutOrStdout(), "  %s\n", entry)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/agentpkg/agentpkg/pkg/credentials"
+	"github.com/spf13/cobra"
+)
+
+func newLoginCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "login <host>",
+		Short: "Store a token for a git host or registry",
+		Long: `Stores a personal access token for a host (e.g. github.com, gitlab.com,
+ghcr.io, or a private registry). Tokens are kept in the OS keychain when
+available, otherwise in an encrypted file in ~/.apkg.
+
+Stored tokens are used when fetching git skills over HTTPS, pulling
+container images for MCP servers, and publishing to registries.`,
+		Example: `  apkg login github.com
+  echo "$GITLAB_TOKEN" | apkg login gitlab.com --username oauth2 --token-stdin`,
+		Args: cobra.ExactArgs(1),
+		RunE: runLogin,
+	}
+
+	cmd.Flags().StringP("username", "u", "", "Username sent with the token (default: "+credentials.DefaultUsername+")")
+	cmd.Flags().Bool("token-stdin", false, "Read the token from stdin instead of prompting")
+
+	return cmd
+}
+
+func newLogoutCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "logout <host>",
+		Short: "Remove the stored token for a host",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runLogout,
+	}
+}
+
+func runLogin(cmd *cobra.Command, args []string) error {
+	host := credentials.Host(args[0])
+	username, _ := cmd.Flags().GetString("username")
+	fromStdin, _ := cmd.Flags().GetBool("token-stdin")
+
+	var token string
+	if fromStdin {
+		data, err := io.ReadAll(cmd.InOrStdin())
+		if err != nil {
+			return fmt.Errorf("reading token from stdin: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	} else {
+		if err := requireTerminal("--token-stdin"); err != nil {
+			return err
+		}
+		var err error
+		token, err = promptToken(host)
+		if err != nil {
+			return err
+		}
+	}
+
+	store, err := credentials.Default()
+	if err != nil {
+		return err
+	}
+
+	backend, err := store.Set(host, credentials.Credential{Username: username, Token: token})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Stored token for %s in %s\n", host, backend)
+	return nil
+}
+
+func runLogout(cmd *cobra.Command, args []string) error {
+	host := credentials.Host(args[0])
+
+	store, err := credentials.Default()
+	if err != nil {
+		return err
+	}
+
+	if err := store.Delete(host); err != nil {
+		if errors.Is(err, credentials.ErrNotFound) {
+			fmt.Fprintf(cmd.OutOrStdout(), "No token stored for %s\n", host)
+			return nil
+		}
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Removed token for %s\n", host)
+	return nil
+}
+
+func promptToken(host string) (string, error) {
+	token, err := prompter.Password(fmt.Sprintf("Token for %s", host), func(s string) error {
+		if strings.TrimSpace(s) == "" {
+			return fmt.Errorf("token is required")
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(token), nil
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+func newMigrateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Upgrade files written by an older apkg to the current format",
+		Long: `Rewrites the manifest, lockfile, and dev configs of the project (or of the
+global scope with --global) that an older apkg wrote in a format this
+one has changed, printing each change.
+
+Other commands refuse to run on such files, listing what migrate would
+change, rather than silently rewriting them; pass --auto-migrate to them
+to migrate and continue. With --dry-run, migrate only prints the changes.`,
+		Args: cobra.NoArgs,
+		RunE: runMigrate,
+	}
+	cmd.Flags().Bool("dry-run", false, "Print what would be migrated without changing any file")
+	return cmd
+}
+
+func runMigrate(cmd *cobra.Command, args []string) error {
+	global, err := cmd.Flags().GetBool("global")
+	if err != nil {
+		return err
+	}
+	dryRun, err := cmd.Flags().GetBool("dry-run")
+	if err != nil {
+		return err
+	}
+
+	files, err := loadMigrationFiles(global)
+	if err != nil {
+		return err
+	}
+	pending, err := files.Pending()
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "Nothing to migrate")
+		return nil
+	}
+
+	if dryRun {
+		fmt.Fprintf(cmd.OutOrStdout(), "Would migrate:\n%s", config.FormatPendingMigrations(pending))
+		return nil
+	}
+	if err := files.Migrate(pending); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Migrated:\n%s", config.FormatPendingMigrations(pending))
+	return nil
+}
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/agentpkg/agentpkg/pkg/installer"
+	"github.com/agentpkg/agentpkg/pkg/source"
+	"github.com/spf13/cobra"
+)
+
+func newOutdatedCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "outdated",
+		Short: "List packages with newer upstream versions",
+		Long: `Checks installed skills and managed MCP servers against their upstreams,
+the way apkg update does (git ls-remote, npm view, PyPI, go list), and lists
+the ones with newer versions without changing anything. Upstreams that
+can't be reached are reported as warnings.
+
+Upstream answers are cached in the store for 15 minutes, then revalidated
+(with ETags where the registry supports them), and requests to each host
+are spaced out so large manifests don't trip registry rate limits. Pass
+--refresh to revalidate every cached answer now.
+
+With --json, the updates are printed as a JSON array for scripts and CI
+jobs, with full commits.`,
+		Args: cobra.NoArgs,
+		RunE: runOutdated,
+	}
+	cmd.Flags().Bool("json", false, "Print the updates as JSON")
+	cmd.Flags().Bool("refresh", false, "Revalidate cached upstream answers")
+	return cmd
+}
+
+func runOutdated(cmd *cobra.Command, args []string) error {
+	ws, err := commandWorkspace(cmd)
+	if err != nil {
+		return err
+	}
+	asJSON, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		return err
+	}
+	refresh, err := cmd.Flags().GetBool("refresh")
+	if err != nil {
+		return err
+	}
+
+	cfg, lf, err := ws.Load()
+	if err != nil {
+		return err
+	}
+
+	inst := ws.Installer()
+	inst.Metadata = &source.Metadata{Store: ws.Store}
+	if refresh {
+		inst.Metadata.TTL = -1
+	}
+
+	if !asJSON {
+		fmt.Fprintln(progressOut(cmd), "Checking for updates...")
+	}
+	updates, err := inst.Outdated(cmd.Context(), cfg, lf)
+	if err != nil {
+		// Report unreachable upstreams but still list the updates found.
+		// warnf writes to stdout, which must stay valid JSON with --json.
+		if asJSON {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %v\n", err)
+		} else {
+			warnf(cmd, "%v", err)
+		}
+	}
+
+	if asJSON {
+		if updates == nil {
+			updates = []installer.Update{}
+		}
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(updates)
+	}
+
+	if len(updates) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "Everything is up to date")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tKIND\tCURRENT\tLATEST")
+	for _, u := range updates {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", u.Name, u.Kind, shortVersion(u.Current), shortVersion(u.Latest))
+	}
+	return tw.Flush()
+}
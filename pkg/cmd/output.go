@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+)
+
+// flagQuiet suppresses everything but errors and final summaries.
+var flagQuiet bool
+
+// progressOut returns the writer for progress, informational, and warning
+// output, which --quiet discards. Final summaries are written to
+// cmd.OutOrStdout() directly.
+func progressOut(cmd *cobra.Command) io.Writer {
+	if flagQuiet {
+		return io.Discard
+	}
+	return cmd.OutOrStdout()
+}
+
+// toolOutput returns the writer the package managers run by an install
+// stream their output to: stderr with --verbose, otherwise nil, leaving
+// only the logs of failed runs.
+func toolOutput(cmd *cobra.Command) io.Writer {
+	if verbose, _ := cmd.Flags().GetBool("verbose"); verbose {
+		return cmd.ErrOrStderr()
+	}
+	return nil
+}
+
+// warnf writes a warning to progressOut. When stdout is a terminal the
+// warning is wrapped to its width; piped output is left unwrapped so each
+// warning stays on one line for grep and log processors.
+func warnf(cmd *cobra.Command, format string, args ...any) {
+	msg := "Warning: " + fmt.Sprintf(format, args...)
+	if width := terminalWidth(); width > 0 {
+		msg = ansi.Wordwrap(msg, width, "")
+	}
+	fmt.Fprintln(progressOut(cmd), strings.TrimRight(msg, "\n"))
+}
+
+// warnFunc returns a function printing errors with warnf, for packages
+// that report non-fatal problems through a Warn callback.
+func warnFunc(cmd *cobra.Command) func(error) {
+	return func(err error) {
+		warnf(cmd, "%v", err)
+	}
+}
+
+// warningBuffer holds warnings back until flush, so the warnings of a long
+// operation are printed together after its output instead of among it.
+type warningBuffer struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+// warn records err; it is safe for concurrent use.
+func (b *warningBuffer) warn(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.errs = append(b.errs, err)
+}
+
+// flush prints the recorded warnings with warnf and forgets them.
+func (b *warningBuffer) flush(cmd *cobra.Command) {
+	b.mu.Lock()
+	errs := b.errs
+	b.errs = nil
+	b.mu.Unlock()
+	for _, err := range errs {
+		warnf(cmd, "%v", err)
+	}
+}
+
+// terminalWidth returns the width of the terminal on stdout, or 0 when
+// stdout is not a terminal.
+func terminalWidth() int {
+	if !term.IsTerminal(os.Stdout.Fd()) {
+		return 0
+	}
+	width, _, err := term.GetSize(os.Stdout.Fd())
+	if err != nil {
+		return 0
+	}
+	return width
+}
+
+// noColor reports whether styled output should be disabled, following the
+// NO_COLOR convention (https://no-color.org) and TERM=dumb.
+func noColor() bool {
+	return os.Getenv("NO_COLOR") != "" || dumbTerminal()
+}
+
+func dumbTerminal() bool {
+	return os.Getenv("TERM") == "dumb"
+}
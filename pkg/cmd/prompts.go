@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/project"
+	"github.com/agentpkg/agentpkg/pkg/projector"
+	"github.com/spf13/cobra"
+)
+
+// Values of --save-agents.
+const (
+	saveAgentsProject = "project"
+	saveAgentsGlobal  = "global"
+	saveAgentsNo      = "no"
+)
+
+// gitignoreNone is the --gitignore value that selects no agents.
+const gitignoreNone = "none"
+
+// validatePromptFlags checks the flags that answer prompts
+// non-interactively.
+func validatePromptFlags() error {
+	switch flagSaveAgents {
+	case "", saveAgentsProject, saveAgentsGlobal, saveAgentsNo:
+	default:
+		return fmt.Errorf("--save-agents must be %q, %q, or %q", saveAgentsProject, saveAgentsGlobal, saveAgentsNo)
+	}
+
+	if slices.Equal(flagGitignore, []string{gitignoreNone}) {
+		return nil
+	}
+	if err := projector.ValidateAgents(flagGitignore); err != nil {
+		return fmt.Errorf("--gitignore: %w", err)
+	}
+	return nil
+}
+
+// selectGitignoreEntries returns the gitignore entries to add for agents:
+// those of the agents named with --gitignore if it was passed, otherwise
+// the entries chosen at a prompt. Without --gitignore and a terminal,
+// nothing is selected.
+func selectGitignoreEntries(agents []string, title string) ([]string, error) {
+	if flagGitignore != nil {
+		var selected []string
+		for _, agent := range agents {
+			if slices.Contains(flagGitignore, agent) {
+				selected = append(selected, agent)
+			}
+		}
+		return project.AgentGitignoreEntries(selected), nil
+	}
+
+	if !prompter.Interactive() {
+		return nil, nil
+	}
+	return promptGitignoreEntries(agents, title)
+}
+
+// requireTerminal returns an error naming the flag to use instead of a
+// prompt when the prompter can't ask questions (stdin is not a terminal).
+func requireTerminal(alternative string) error {
+	if prompter.Interactive() {
+		return nil
+	}
+	return fmt.Errorf("stdin is not a terminal; pass %s to run non-interactively", alternative)
+}
+
+// conflictResolver returns how to resolve MCP server entries apkg would
+// project over but didn't create: the --on-conflict resolution if it was
+// passed, otherwise a prompt. Without a terminal, such entries are skipped.
+func conflictResolver(cmd *cobra.Command) (func(projector.Conflict) (string, error), error) {
+	resolution, err := cmd.Flags().GetString("on-conflict")
+	if err != nil {
+		return nil, err
+	}
+	if resolution != "" {
+		if !slices.Contains(projector.ConflictResolutions, resolution) {
+			return nil, fmt.Errorf("--on-conflict must be one of %s", strings.Join(projector.ConflictResolutions, ", "))
+		}
+		return func(projector.Conflict) (string, error) { return resolution, nil }, nil
+	}
+
+	return func(c projector.Conflict) (string, error) {
+		if !prompter.Interactive() {
+			warnf(cmd, "%s already has an MCP server %q that apkg didn't create; leaving it alone (pass --on-conflict to choose)", c.Path, c.Server)
+			return projector.ConflictSkip, nil
+		}
+		title := fmt.Sprintf("%s already has an MCP server %q that apkg didn't create. What should apkg do?", c.Path, c.Server)
+		i, err := prompter.Select(title, []string{
+			"Overwrite it with apkg's definition",
+			"Adopt it as is, and manage it from now on",
+			"Skip it, and leave it alone",
+		})
+		if err != nil {
+			return "", err
+		}
+		return projector.ConflictResolutions[i], nil
+	}, nil
+}
+
+// promptServerScope asks which definition agents get of the MCP server
+// name, installed both globally and in the project, and saves the answer
+// to apkg.local.toml. Without a terminal, the project's definition is
+// used for this install.
+func promptServerScope(cmd *cobra.Command, name string) (string, error) {
+	if !prompter.Interactive() {
+		warnf(cmd, "MCP server %q is also installed globally; agents get the project's definition (set %s.%s to choose)", name, config.KeyServerScopes, name)
+		return config.ServerScopeProject, nil
+	}
+
+	scopes := []string{config.ServerScopeProject, config.ServerScopeGlobal}
+	title := fmt.Sprintf("MCP server %q is installed both globally and in this project. Which definition should agents use here?", name)
+	i, err := prompter.Select(title, []string{"Project (apkg.toml)", "Global (~/.apkg/apkg.toml)"})
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(ProjectDir, config.LocalConfigFile)
+	err = config.UpdateDevConfigFile(path, func(c *config.DevConfig) error {
+		return c.Set(config.KeyServerScopes+"."+name, scopes[i])
+	})
+	if err != nil {
+		return "", err
+	}
+	return scopes[i], nil
+}
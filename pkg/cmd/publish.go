@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/registry"
+	"github.com/spf13/cobra"
+)
+
+func newPublishCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "publish [path]",
+		Short: "Publish a skill or MCP server to a registry",
+		Long: `Validates a skill directory (containing SKILL.md) or an MCP server
+definition (mcp.toml), generates package metadata (name, version,
+integrity, source), and pushes it to a configured registry.
+
+Registries are configured in apkg.local.toml or ~/.apkg/config.toml:
+
+  [registries.team]
+  type = "git"        # or "oci" (requires the oras CLI)
+  url = "https://github.com/org/apkg-index.git"
+  token_env = "APKG_REGISTRY_TOKEN"
+
+For skills, the source consumers fetch from defaults to the git origin of
+the skill directory at --version; override it with --git, --ref, and --path.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runPublish,
+	}
+
+	cmd.Flags().String("version", "", "Required. Version to publish")
+	cmd.Flags().String("registry", "", "Name of the configured registry (defaults to the only one configured)")
+	cmd.RegisterFlagCompletionFunc("registry", completeRegistries)
+	cmd.Flags().String("git", "", "Git URL consumers fetch the skill from (default: origin remote)")
+	cmd.Flags().String("ref", "", "Git ref consumers fetch the skill at (default: --version)")
+	cmd.Flags().String("path", "", "Path of the skill within the git repository (default: inferred)")
+	cmd.Flags().Bool("dry-run", false, "Validate and print the metadata without publishing")
+	_ = cmd.MarkFlagRequired("version")
+
+	return cmd
+}
+
+func runPublish(cmd *cobra.Command, args []string) error {
+	target := "."
+	if len(args) > 0 {
+		target = args[0]
+	}
+
+	version, _ := cmd.Flags().GetString("version")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	meta, err := publishMetadata(cmd, target, version)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		data, err := meta.Marshal()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "# %s\n%s", meta.IndexPath(), data)
+		return nil
+	}
+
+	registryName, _ := cmd.Flags().GetString("registry")
+	regCfg, err := selectRegistry(DevCfg.Registries, registryName)
+	if err != nil {
+		return err
+	}
+
+	pub, err := registry.NewPublisher(regCfg)
+	if err != nil {
+		return err
+	}
+
+	location, err := pub.Publish(cmd.Context(), meta)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Published %s %s@%s to %s\n", meta.Kind, meta.Name, meta.Version, location)
+	return nil
+}
+
+// publishMetadata builds metadata for the skill directory or mcp.toml at target.
+func publishMetadata(cmd *cobra.Command, target, version string) (*registry.Metadata, error) {
+	info, err := os.Stat(target)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", target, err)
+	}
+
+	mcpFile := target
+	if info.IsDir() {
+		if _, err := os.Stat(filepath.Join(target, "SKILL.md")); err == nil {
+			src, err := publishSkillSource(cmd, target, version)
+			if err != nil {
+				return nil, err
+			}
+			return registry.SkillMetadata(target, version, src)
+		}
+		mcpFile = filepath.Join(target, "mcp.toml")
+		if _, err := os.Stat(mcpFile); err != nil {
+			return nil, fmt.Errorf("%s contains neither SKILL.md nor mcp.toml", target)
+		}
+	}
+
+	return registry.MCPMetadata(mcpFile, version)
+}
+
+// publishSkillSource returns the source consumers fetch the skill from,
+// inferring unset fields from the skill directory's git repository.
+func publishSkillSource(cmd *cobra.Command, dir, version string) (config.SkillSource, error) {
+	gitURL, _ := cmd.Flags().GetString("git")
+	ref, _ := cmd.Flags().GetString("ref")
+	path, _ := cmd.Flags().GetString("path")
+
+	if ref == "" {
+		ref = version
+	}
+
+	if gitURL == "" || !cmd.Flags().Changed("path") {
+		inferred, err := registry.InferGitSource(cmd.Context(), dir, ref)
+		if err != nil {
+			return config.SkillSource{}, fmt.Errorf("inferring skill source (set --git and --path): %w", err)
+		}
+		if gitURL == "" {
+			gitURL = inferred.Git
+		}
+		if !cmd.Flags().Changed("path") {
+			path = inferred.Path
+		}
+	}
+
+	return config.SkillSource{Git: gitURL, Path: path, Ref: ref}, nil
+}
+
+// selectRegistry returns the named registry, or the only configured one
+// when name is empty.
+func selectRegistry(registries map[string]config.RegistryConfig, name string) (config.RegistryConfig, error) {
+	if name != "" {
+		reg, ok := registries[name]
+		if !ok {
+			return config.RegistryConfig{}, fmt.Errorf("registry %q is not configured", name)
+		}
+		return reg, nil
+	}
+
+	switch len(registries) {
+	case 0:
+		return config.RegistryConfig{}, fmt.Errorf("no registries configured; add a [registries.<name>] table to apkg.local.toml or ~/.apkg/config.toml")
+	case 1:
+		for _, reg := range registries {
+			return reg, nil
+		}
+	}
+
+	names := make([]string, 0, len(registries))
+	for n := range registries {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return config.RegistryConfig{}, fmt.Errorf("multiple registries configured, choose one with --registry (%s)", strings.Join(names, ", "))
+}
@@ -2,12 +2,11 @@ package cmd
 
 import (
 	"fmt"
+	"path/filepath"
 	"sort"
 
 	"github.com/agentpkg/agentpkg/pkg/config"
-	"github.com/agentpkg/agentpkg/pkg/installer"
-	"github.com/agentpkg/agentpkg/pkg/store"
-	"github.com/charmbracelet/huh"
+	"github.com/agentpkg/agentpkg/pkg/workspace"
 	"github.com/spf13/cobra"
 )
 
@@ -20,6 +19,7 @@ func newRemoveCmd() *cobra.Command {
 	}
 
 	removeCmd.Flags().Bool("all", false, "Remove all skills and MCP servers without prompting")
+	removeCmd.PersistentFlags().Bool("purge", false, "Also delete unreferenced store entries and stop the removed servers' containers")
 
 	skillCmd := &cobra.Command{
 		Use:   "skill [name]",
@@ -43,19 +43,14 @@ func newRemoveCmd() *cobra.Command {
 }
 
 func runRemoveAll(cmd *cobra.Command, args []string) error {
-	global, err := cmd.Flags().GetBool("global")
+	ws, err := commandWorkspace(cmd)
 	if err != nil {
 		return err
 	}
 
-	projectDir, manifestPath, lockPath, err := resolveInstallPaths(global)
+	cfg, err := config.LoadFile(ws.ManifestPath)
 	if err != nil {
-		return err
-	}
-
-	cfg, err := config.LoadFile(manifestPath)
-	if err != nil {
-		return fmt.Errorf("loading %s: %w", manifestPath, err)
+		return fmt.Errorf("loading %s: %w", ws.ManifestPath, err)
 	}
 
 	if len(cfg.Skills) == 0 && len(cfg.MCPServers) == 0 {
@@ -78,6 +73,10 @@ func runRemoveAll(cmd *cobra.Command, args []string) error {
 		selectedSkills = skillNames
 		selectedMCPs = mcpNames
 	} else {
+		if err := requireTerminal("--all"); err != nil {
+			return err
+		}
+
 		// Build options with prefixed labels so the user can distinguish types.
 		type entry struct {
 			label string
@@ -93,20 +92,12 @@ func runRemoveAll(cmd *cobra.Command, args []string) error {
 			entries = append(entries, entry{label: "mcp: " + name, kind: "mcp", name: name})
 		}
 
-		options := make([]huh.Option[int], len(entries))
+		labels := make([]string, len(entries))
 		for i, e := range entries {
-			options[i] = huh.NewOption(e.label, i)
+			labels[i] = e.label
 		}
 
-		var selectedIdxs []int
-		err := huh.NewForm(
-			huh.NewGroup(
-				huh.NewMultiSelect[int]().
-					Title("Select packages to remove").
-					Options(options...).
-					Value(&selectedIdxs),
-			),
-		).Run()
+		selectedIdxs, err := prompter.MultiSelect("Select packages to remove", labels)
 		if err != nil {
 			return fmt.Errorf("selection prompt failed: %w", err)
 		}
@@ -126,211 +117,101 @@ func runRemoveAll(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	agents, err := resolveAgents(global)
-	if err != nil {
-		return err
-	}
-
-	s, err := store.Default()
-	if err != nil {
+	if err := removePackages(cmd, ws, selectedSkills, selectedMCPs); err != nil {
 		return err
 	}
 
-	inst := &installer.Installer{
-		Store:      s,
-		ProjectDir: projectDir,
-		Agents:     agents,
-		Global:     global,
-	}
-
-	for _, name := range selectedSkills {
-		if err := inst.RemoveSkill(name); err != nil {
-			return err
-		}
-		delete(cfg.Skills, name)
-	}
-
-	for _, name := range selectedMCPs {
-		if err := inst.RemoveMCP(name); err != nil {
-			return err
-		}
-		delete(cfg.MCPServers, name)
-	}
-
-	if err := config.SaveFile(manifestPath, cfg); err != nil {
-		return fmt.Errorf("saving %s: %w", manifestPath, err)
-	}
-
-	lf, err := config.LoadLockFile(lockPath)
-	if err != nil {
-		return fmt.Errorf("loading lockfile: %w", err)
-	}
-
-	lf.Skills = filterSkillLockEntries(lf.Skills, selectedSkills)
-	lf.MCPServers = filterMCPLockEntries(lf.MCPServers, selectedMCPs)
-
-	if err := config.SaveLockFile(lockPath, lf); err != nil {
-		return fmt.Errorf("writing lockfile: %w", err)
-	}
-
 	fmt.Fprintf(cmd.OutOrStdout(), "Removed %d skill(s) and %d MCP server(s)\n", len(selectedSkills), len(selectedMCPs))
 	return nil
 }
 
 func runRemoveSkill(cmd *cobra.Command, args []string) error {
-	global, err := cmd.Flags().GetBool("global")
-	if err != nil {
-		return err
-	}
-
-	projectDir, manifestPath, lockPath, err := resolveInstallPaths(global)
+	ws, err := commandWorkspace(cmd)
 	if err != nil {
 		return err
 	}
 
 	name := args[0]
-
-	cfg, err := config.LoadFile(manifestPath)
-	if err != nil {
-		return fmt.Errorf("loading %s: %w", manifestPath, err)
-	}
-
-	if _, ok := cfg.Skills[name]; !ok {
-		return fmt.Errorf("skill %q not found in %s", name, manifestPath)
-	}
-
-	agents, err := resolveAgents(global)
-	if err != nil {
-		return err
-	}
-
-	s, err := store.Default()
-	if err != nil {
-		return err
-	}
-
-	inst := &installer.Installer{
-		Store:      s,
-		ProjectDir: projectDir,
-		Agents:     agents,
-		Global:     global,
-	}
-
-	if err := inst.RemoveSkill(name); err != nil {
+	if err := removePackages(cmd, ws, []string{name}, nil); err != nil {
 		return err
 	}
 
-	delete(cfg.Skills, name)
-	if err := config.SaveFile(manifestPath, cfg); err != nil {
-		return fmt.Errorf("saving %s: %w", manifestPath, err)
-	}
-
-	lf, err := config.LoadLockFile(lockPath)
-	if err != nil {
-		return fmt.Errorf("loading lockfile: %w", err)
-	}
-
-	lf.Skills = filterSkillLockEntries(lf.Skills, []string{name})
-
-	if err := config.SaveLockFile(lockPath, lf); err != nil {
-		return fmt.Errorf("writing lockfile: %w", err)
-	}
-
 	fmt.Fprintf(cmd.OutOrStdout(), "Removed skill %q\n", name)
 	return nil
 }
 
 func runRemoveMCP(cmd *cobra.Command, args []string) error {
-	global, err := cmd.Flags().GetBool("global")
-	if err != nil {
-		return err
-	}
-
-	projectDir, manifestPath, lockPath, err := resolveInstallPaths(global)
+	ws, err := commandWorkspace(cmd)
 	if err != nil {
 		return err
 	}
 
 	name := args[0]
-
-	cfg, err := config.LoadFile(manifestPath)
-	if err != nil {
-		return fmt.Errorf("loading %s: %w", manifestPath, err)
+	if err := removePackages(cmd, ws, nil, []string{name}); err != nil {
+		return err
 	}
 
-	if _, ok := cfg.MCPServers[name]; !ok {
-		return fmt.Errorf("MCP server %q not found in %s", name, manifestPath)
-	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Removed MCP server %q\n", name)
+	return nil
+}
 
-	agents, err := resolveAgents(global)
+// removePackages removes the named skills and MCP servers from the
+// workspace (see workspace.Workspace.Remove), purging the store and
+// stopping containers with --purge, and reports what was purged and
+// stopped.
+func removePackages(cmd *cobra.Command, ws *workspace.Workspace, skills, mcpServers []string) error {
+	purge, err := cmd.Flags().GetBool("purge")
 	if err != nil {
 		return err
 	}
 
-	s, err := store.Default()
+	cfg, err := config.LoadFile(ws.ManifestPath)
 	if err != nil {
-		return err
-	}
-
-	inst := &installer.Installer{
-		Store:      s,
-		ProjectDir: projectDir,
-		Agents:     agents,
-		Global:     global,
-	}
-
-	if err := inst.RemoveMCP(name); err != nil {
-		return err
+		return fmt.Errorf("loading %s: %w", ws.ManifestPath, err)
 	}
-
-	delete(cfg.MCPServers, name)
-	if err := config.SaveFile(manifestPath, cfg); err != nil {
-		return fmt.Errorf("saving %s: %w", manifestPath, err)
+	// Check the names before prompting for agents.
+	for _, name := range skills {
+		if _, ok := cfg.Skills[name]; !ok {
+			return fmt.Errorf("skill %q not found in %s", name, ws.ManifestPath)
+		}
 	}
-
-	lf, err := config.LoadLockFile(lockPath)
-	if err != nil {
-		return fmt.Errorf("loading lockfile: %w", err)
+	for _, name := range mcpServers {
+		if _, ok := cfg.MCPServers[name]; !ok {
+			return fmt.Errorf("MCP server %q not found in %s", name, ws.ManifestPath)
+		}
 	}
-
-	lf.MCPServers = filterMCPLockEntries(lf.MCPServers, []string{name})
-
-	if err := config.SaveLockFile(lockPath, lf); err != nil {
-		return fmt.Errorf("writing lockfile: %w", err)
+	if ws.Agents, err = resolveAgents(ws.Global); err != nil {
+		return err
 	}
 
-	fmt.Fprintf(cmd.OutOrStdout(), "Removed MCP server %q\n", name)
-	return nil
-}
-
-// filterSkillLockEntries returns entries with the given names removed.
-func filterSkillLockEntries(entries []config.SkillLockEntry, names []string) []config.SkillLockEntry {
-	remove := make(map[string]bool, len(names))
-	for _, n := range names {
-		remove[n] = true
-	}
-	var kept []config.SkillLockEntry
-	for _, e := range entries {
-		if !remove[e.Name] {
-			kept = append(kept, e)
+	removal, err := ws.Remove(cmd.Context(), skills, mcpServers, purge)
+	if removal != nil {
+		for _, path := range removal.Purged {
+			fmt.Fprintf(progressOut(cmd), "Deleted %s\n", path)
+		}
+		for _, name := range removal.Stopped {
+			fmt.Fprintf(progressOut(cmd), "Stopped container for MCP server %q\n", name)
 		}
 	}
-	return kept
+	return err
 }
 
-// filterMCPLockEntries returns entries with the given names removed.
-func filterMCPLockEntries(entries []config.MCPLockEntry, names []string) []config.MCPLockEntry {
-	remove := make(map[string]bool, len(names))
-	for _, n := range names {
-		remove[n] = true
-	}
-	var kept []config.MCPLockEntry
-	for _, e := range entries {
-		if !remove[e.Name] {
-			kept = append(kept, e)
+// otherScopeLockFile loads the lockfile of the scope not being modified
+// (global when removing from a project, and vice versa) so purging keeps
+// store entries it still references.
+func otherScopeLockFile(global bool) (*config.LockFile, error) {
+	path := filepath.Join(ProjectDir, config.LockFileName)
+	if !global {
+		var err error
+		if path, err = config.GlobalLockFilePath(); err != nil {
+			return nil, err
 		}
 	}
-	return kept
+	lf, err := config.LoadLockFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("loading lockfile: %w", err)
+	}
+	return lf, nil
 }
 
 // sortedKeys returns the keys of a map sorted alphabetically.
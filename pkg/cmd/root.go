@@ -1,50 +1,255 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/project"
+	"github.com/agentpkg/agentpkg/pkg/projector"
+	"github.com/agentpkg/agentpkg/pkg/prompt"
+	"github.com/agentpkg/agentpkg/pkg/store"
 	"github.com/spf13/cobra"
 )
 
 var (
-	flagAgents []string
+	flagAgents     []string
+	flagProjectDir string
+
+	// flagSaveAgents and flagGitignore answer the agent-save and
+	// gitignore prompts non-interactively.
+	flagSaveAgents string
+	flagGitignore  []string
+
+	// flagAutoMigrate applies pending migrations of user files instead of
+	// refusing to run (see checkMigrations).
+	flagAutoMigrate bool
+
+	// ProjectDir is the root of the current project: the --project-dir flag,
+	// or the nearest directory at or above the working directory containing
+	// apkg.toml (falling back to the working directory).
+	ProjectDir string
 
 	// DevCfg holds the resolved developer configuration, available to all
 	// subcommands after PersistentPreRunE completes.
 	DevCfg *config.DevConfig
+
+	// prompter asks every question in apkg; see newRootCmd.
+	prompter prompt.Prompter
 )
 
 func NewRootCmd() *cobra.Command {
+	return newRootCmd(&prompt.Huh{NoColor: noColor(), Accessible: dumbTerminal()})
+}
+
+// newRootCmd builds the root command with commands prompting through p,
+// which tests replace with a prompt.Script.
+func newRootCmd(p prompt.Prompter) *cobra.Command {
+	prompter = p
+
 	root := &cobra.Command{
 		Use:   "apkg",
 		Short: "Agent package manager",
 		Long:  "apkg manages agent-agnostic skill packages and projects them into coding agent configurations.",
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := validatePromptFlags(); err != nil {
+				return err
+			}
+
+			dir, err := resolveProjectDir()
+			if err != nil {
+				return err
+			}
+			ProjectDir = dir
+
 			global, _ := cmd.Flags().GetBool("global")
-			cfg, err := config.LoadDevConfig(flagAgents, global)
+			cfg, err := config.LoadDevConfig(flagAgents, global, ProjectDir)
 			if err != nil {
 				return err
 			}
 			DevCfg = cfg
-			return nil
+			// Custom agents are only known once the config is loaded.
+			if err := projector.RegisterCustomProjectors(cfg.Projectors); err != nil {
+				return err
+			}
+			if err := projector.ValidateAgents(flagAgents); err != nil {
+				return fmt.Errorf("--agents: %w", err)
+			}
+			if err := checkMigrations(cmd, global); err != nil {
+				return err
+			}
+			return upgradeStore(cmd)
 		},
 		SilenceUsage: true,
 	}
 
 	root.PersistentFlags().BoolP("global", "g", false, "Install globally (~/.apkg/) instead of in the current project")
 	root.PersistentFlags().StringSliceVar(&flagAgents, "agents", nil, "coding agents to project for (e.g. claude-code,cursor)")
+	root.RegisterFlagCompletionFunc("agents", completeAgents)
+	root.PersistentFlags().StringVar(&flagSaveAgents, "save-agents", "", `Save the selected agents without prompting: "project", "global", or "no"`)
+	root.PersistentFlags().StringSliceVar(&flagGitignore, "gitignore", nil, `Agents whose config files to add to .gitignore without prompting ("none" for no agents)`)
+	root.RegisterFlagCompletionFunc("gitignore", completeAgents)
+	root.PersistentFlags().BoolVarP(&flagQuiet, "quiet", "q", false, "Only print errors and the final summary")
+	root.PersistentFlags().BoolVar(&flagAutoMigrate, "auto-migrate", false, "Migrate files written by an older apkg instead of stopping (see apkg migrate)")
+	root.PersistentFlags().StringVar(&flagProjectDir, "project-dir", "", "Project root (default: nearest parent directory containing apkg.toml)")
 
 	root.AddCommand(newInitCmd())
 	root.AddCommand(newInstallCmd())
 	root.AddCommand(newRemoveCmd())
 	root.AddCommand(newServeCmd())
+	root.AddCommand(newLockCmd())
+	root.AddCommand(newVendorCmd())
+	root.AddCommand(newPublishCmd())
+	root.AddCommand(newLoginCmd())
+	root.AddCommand(newLogoutCmd())
+	root.AddCommand(newGitignoreCmd())
+	root.AddCommand(newUpdateCmd())
+	root.AddCommand(newOutdatedCmd())
+	root.AddCommand(newTreeCmd())
+	root.AddCommand(newListCmd())
+	root.AddCommand(newSyncCmd())
+	root.AddCommand(newStatusCmd())
+	root.AddCommand(newAgentsCmd())
+	root.AddCommand(newHistoryCmd())
+	root.AddCommand(newCacheCmd())
+	root.AddCommand(newVerifyCmd())
+	root.AddCommand(newAPICmd())
+	root.AddCommand(newRPCCmd())
+	root.AddCommand(newConfigCmd())
+	root.AddCommand(newExecCmd())
+	root.AddCommand(newRunCmd())
+	root.AddCommand(newSelftestCmd())
+	root.AddCommand(newMigrateCmd())
+	addCompletionCmds(root)
 
 	return root
 }
 
+// resolveProjectDir returns the absolute project root (see ProjectDir).
+func resolveProjectDir() (string, error) {
+	if flagProjectDir != "" {
+		dir, err := filepath.Abs(flagProjectDir)
+		if err != nil {
+			return "", fmt.Errorf("resolving --project-dir: %w", err)
+		}
+		return dir, nil
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("getting working directory: %w", err)
+	}
+	if root, ok := project.FindRoot(wd); ok {
+		return root, nil
+	}
+	return wd, nil
+}
+
 func Execute() {
 	if err := NewRootCmd().Execute(); err != nil {
+		var exitErr *exitCodeError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
 		os.Exit(1)
 	}
 }
+
+// completeAgents completes the comma-separated --agents value with the
+// registered agents not already listed.
+func completeAgents(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	prefix := ""
+	if idx := strings.LastIndex(toComplete, ","); idx >= 0 {
+		prefix = toComplete[:idx+1]
+	}
+	listed := strings.Split(prefix, ",")
+
+	var completions []string
+	for _, agent := range projector.RegisteredAgents() {
+		if !slices.Contains(listed, agent) {
+			completions = append(completions, prefix+agent)
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
+
+// upgradeStore migrates a store left by an older apkg to the current layout
+// (see store.Migrate) before any command reads it.
+func upgradeStore(cmd *cobra.Command) error {
+	st, err := openStore()
+	if err != nil {
+		return err
+	}
+	notes, err := store.Migrate(st)
+	for _, note := range notes {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Upgraded store: %s\n", note)
+	}
+	return err
+}
+
+// checkMigrations stops commands from reading the manifest, lockfile, or
+// dev configs while they're in a format an older apkg wrote, listing what
+// apkg migrate changes, rather than rewriting them in the new format
+// behind the user's back. With --auto-migrate it migrates them instead.
+// Migrations that don't change how apkg reads a file, like a bare version
+// bump, don't stop anything. apkg migrate, shell completion, and the
+// commands in skipMigrationCheck skip the check.
+func checkMigrations(cmd *cobra.Command, global bool) error {
+	for c := cmd; c != nil; c = c.Parent() {
+		if c.Name() == "migrate" || c.Name() == "completion" || c.Name() == cobra.ShellCompRequestCmd || skipMigrationCheck[c.Name()] {
+			return nil
+		}
+	}
+
+	files, err := loadMigrationFiles(global)
+	if err != nil {
+		return err
+	}
+	pending, err := files.Pending()
+	if err != nil || !slices.ContainsFunc(pending, func(p config.PendingMigration) bool { return p.IsBlocking(files) }) {
+		return err
+	}
+	if !flagAutoMigrate {
+		return &config.MigrationRequiredError{Pending: pending}
+	}
+	if err := files.Migrate(pending); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "Migrated files written by an older apkg:\n%s", config.FormatPendingMigrations(pending))
+	return nil
+}
+
+// skipMigrationCheck are the commands that run or show what is installed
+// without writing the manifest or lockfile, so files in an older format
+// don't stop them.
+var skipMigrationCheck = map[string]bool{
+	"exec":   true,
+	"run":    true,
+	"serve":  true,
+	"rpc":    true,
+	"list":   true,
+	"tree":   true,
+	"status": true,
+}
+
+// loadMigrationFiles reads the user files of the global scope, or of the
+// project and the global dev config.
+func loadMigrationFiles(global bool) (config.MigrationFiles, error) {
+	_, manifestPath, lockPath, err := resolveInstallPaths(global)
+	if err != nil {
+		return nil, err
+	}
+	globalPath, err := config.GlobalDevConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	devConfigPaths := []string{globalPath}
+	if !global {
+		devConfigPaths = append(devConfigPaths, filepath.Join(ProjectDir, config.LocalConfigFile))
+	}
+	return config.LoadMigrationFiles(manifestPath, lockPath, devConfigPaths...)
+}
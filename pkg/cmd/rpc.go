@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/agentpkg/agentpkg/pkg/rpc"
+	"github.com/agentpkg/agentpkg/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+func newRPCCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rpc",
+		Short: "Serve apkg operations as JSON-RPC over stdio",
+		Long: `Speaks JSON-RPC 2.0 on stdin and stdout, with messages framed by
+Content-Length headers like the Language Server Protocol, for editor
+extensions that run apkg as a child process.
+
+Requests (params may include "global": true for the global scope):
+
+  apkg/packages      list manifest entries
+  apkg/status        install state of each manifest entry
+  apkg/install       install everything in apkg.toml
+  apkg/installSkill  install a skill: {"ref": "...", "scope": "..."}
+  apkg/remove        remove a package: {"kind": "skill"|"mcp", "name": "...",
+                     "purge": true|false}
+  shutdown, exit
+
+Notifications sent to the client:
+
+  apkg/lockfileChanged  the project lockfile was written: {"path": "..."}
+  apkg/projectionDrift  skills missing from agent configurations changed:
+                        {"drift": [{"kind", "name", "agent"}]}`,
+		Args: cobra.NoArgs,
+		RunE: runRPC,
+	}
+}
+
+func runRPC(cmd *cobra.Command, args []string) error {
+	projectDir := ProjectDir
+	srv := &rpc.Server{
+		Open: func(global bool) (*workspace.Workspace, error) {
+			return workspace.Open(projectDir, global)
+		},
+	}
+	return srv.Serve(cmd.Context(), os.Stdin, os.Stdout)
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/installer"
+	"github.com/spf13/cobra"
+)
+
+func newRunCmd() *cobra.Command {
+	runCmd := &cobra.Command{
+		Use:   "run",
+		Short: "Run installed packages",
+	}
+
+	runCmd.AddCommand(&cobra.Command{
+		Use:   "mcp <server>",
+		Short: "Run an installed stdio MCP server",
+		Long: `Runs an installed stdio MCP server the way apkg.toml configures it, with its
+stdio attached, for agents to launch servers through apkg instead of by their
+store paths:
+
+  apkg config set mcp_commands.cursor run
+
+makes the next install project Cursor's stdio servers as "apkg run mcp
+<server>". The server's arguments and env are read from the store when the
+agent starts it, and "${VAR}" references in its env are expanded from the
+agent's environment, so updates and changed secrets apply without projecting
+again. The command exits with the server's exit code.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runRunMCP,
+	})
+	return runCmd
+}
+
+func runRunMCP(cmd *cobra.Command, args []string) error {
+	global, err := cmd.Flags().GetBool("global")
+	if err != nil {
+		return err
+	}
+
+	_, _, lockPath, err := resolveInstallPaths(global)
+	if err != nil {
+		return err
+	}
+
+	lf, err := config.LoadLockFile(lockPath)
+	if err != nil {
+		return fmt.Errorf("loading lockfile: %w", err)
+	}
+
+	server, err := installer.RunServer(lf, args[0])
+	if err != nil {
+		return err
+	}
+	return runServerProcess(cmd, args[0], server)
+}
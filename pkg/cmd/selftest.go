@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/selftest"
+	"github.com/spf13/cobra"
+)
+
+func newSelftestCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "selftest",
+		Short: "Check that apkg works in this environment",
+		Long: `Runs the whole pipeline against a temporary home: creates a local skill and
+a fake MCP server, installs them into a scratch project, verifies their
+projections for a hidden test agent, and removes them again.
+
+Nothing outside the temporary directory is touched. Use --keep to inspect it
+afterwards.`,
+		Hidden: true,
+		Args:   cobra.NoArgs,
+		RunE:   runSelftest,
+	}
+	cmd.Flags().Bool("keep", false, "Keep the temporary directory instead of removing it")
+	return cmd
+}
+
+func runSelftest(cmd *cobra.Command, args []string) error {
+	keep, err := cmd.Flags().GetBool("keep")
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", "apkg-selftest-")
+	if err != nil {
+		return fmt.Errorf("creating temporary directory: %w", err)
+	}
+	if keep {
+		defer fmt.Fprintf(cmd.OutOrStdout(), "Kept %s\n", dir)
+	} else {
+		defer os.RemoveAll(dir)
+	}
+
+	// Anything that looks up the home directory lands in the scratch
+	// directory rather than the user's.
+	if err := os.Setenv(config.EnvHome, dir); err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	err = selftest.Run(cmd.Context(), dir, func(s selftest.Step) {
+		if s.Err != nil {
+			fmt.Fprintf(out, "FAIL %s: %v\n", s.Name, s.Err)
+			return
+		}
+		fmt.Fprintf(out, "ok   %s\n", s.Name)
+	})
+	if err != nil {
+		return fmt.Errorf("selftest failed: %w", err)
+	}
+	fmt.Fprintln(out, "apkg is working")
+	return nil
+}
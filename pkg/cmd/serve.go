@@ -1,9 +1,12 @@
 package cmd
 
 import (
+	"fmt"
+	"path/filepath"
+
 	"github.com/agentpkg/agentpkg/pkg/container"
 	"github.com/agentpkg/agentpkg/pkg/serve"
-	"github.com/agentpkg/agentpkg/pkg/store"
+	"github.com/agentpkg/agentpkg/pkg/workspace"
 	"github.com/spf13/cobra"
 )
 
@@ -15,18 +18,118 @@ func newServeCmd() *cobra.Command {
 
 The proxy discovers installed container images by scanning ~/.apkg/oci/
 and lazily starts them on first request. Containers are stopped after an
-idle timeout and restarted automatically on the next request.
+idle timeout with no open requests (an SSE stream counts as open until it
+ends) and restarted automatically on the next request. Servers with
+eager = true start with the proxy and keep running while idle, avoiding a
+slow first call for heavy images.
+
+GET /healthz reports that the proxy is up. GET /readyz/<server> reports
+whether a server's container is ready (200) or still starting (503), and
+starts it if it is stopped, so scripts can wait for readiness before the
+agent's first call.
 
 Agent configurations point at this proxy using the X-MCP-Server and
-X-MCP-Server-Digest headers for routing.`,
+X-MCP-Server-Digest headers for routing, and X-MCP-Project to name the
+project they belong to. Servers listed in the serve_access config are only
+reachable from the projects given there, e.g.
+
+  apkg config set serve_access.postgres ~/work/billing,global --global
+
+where global stands for the global installs, which name no project.
+
+Requests are routed to the install the requesting project's lockfile
+records, so projects installing different servers of the same name each
+reach their own, with the volumes they mount. Only projects apkg has
+installed into (see apkg list --all-projects) are looked up; requests
+naming any other project are routed by X-MCP-Server-Digest.
+
+Requests are bounded so a wedged container can't hold connections open
+forever: connecting to a container, waiting for its response, and the whole
+request (except SSE streams) each time out, and request bodies above
+--max-request-body bytes are rejected. A zero value disables the limit.
+
+The port is recorded in the store, and installs point agent configs at the
+port serve last listened on. After changing --port, run apkg install again
+in each project.
+
+With --socket the proxy also listens on a Unix domain socket that only the
+current user can connect to (Windows 10 and later support these as well).
+Agents listed in the serve_socket_agents config get http+unix:// URLs for
+the socket instead of localhost ones; they must support such URLs. With
+--tcp=false the socket is the only listener, so nothing is exposed on
+localhost TCP, e.g.
+
+  apkg config set serve_socket_agents claude-code --global
+  apkg serve --socket ~/.apkg/serve.sock --tcp=false`,
 		RunE: runServe,
 	}
 
 	cmd.Flags().Int("port", serve.DefaultPort, "Port to listen on")
+	cmd.Flags().String("socket", "", "Also listen on a Unix domain socket at this path")
+	cmd.Flags().Bool("tcp", true, "Listen on localhost TCP; --tcp=false requires --socket")
+	cmd.Flags().Duration("dial-timeout", serve.DefaultDialTimeout, "Timeout for connecting to a container")
+	cmd.Flags().Duration("response-header-timeout", serve.DefaultResponseHeaderTimeout, "Timeout for a container to start responding")
+	cmd.Flags().Duration("request-timeout", serve.DefaultRequestTimeout, "Timeout for a whole request, excluding SSE streams")
+	cmd.Flags().Int64("max-request-body", serve.DefaultMaxRequestBody, "Largest request body forwarded to a container, in bytes")
+
+	gcCmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Remove stopped apkg containers and unused images",
+		Long: `Removes the stopped apkg- containers left behind when the proxy or an agent
+crashed, the images apkg built for managed packages with runtime =
+"container" that no installed server uses any more, and the untagged
+images rebuilding them left. Running containers and images apkg didn't
+build are left alone.
+
+apkg serve does the same when it shuts down.`,
+		Args: cobra.NoArgs,
+		RunE: runServeGC,
+	}
+	gcCmd.Flags().Bool("dry-run", false, "Print what would be removed without removing it")
+	cmd.AddCommand(gcCmd)
 
 	return cmd
 }
 
+func runServeGC(cmd *cobra.Command, args []string) error {
+	dryRun, err := cmd.Flags().GetBool("dry-run")
+	if err != nil {
+		return err
+	}
+
+	engine, err := container.DetectEngine()
+	if err != nil {
+		return err
+	}
+	st, err := openStore()
+	if err != nil {
+		return err
+	}
+
+	result, gcErr := serve.GC(cmd.Context(), st, engine, dryRun)
+	if result == nil {
+		return gcErr
+	}
+	verb := "Removed"
+	if dryRun {
+		verb = "Would remove"
+	}
+	w := cmd.OutOrStdout()
+	for _, name := range result.Containers {
+		fmt.Fprintf(w, "%s container %s\n", verb, name)
+	}
+	for _, image := range result.Images {
+		fmt.Fprintf(w, "%s image %s\n", verb, image)
+	}
+	if result.Dangling > 0 {
+		fmt.Fprintf(w, "%s %d dangling image(s)\n", verb, result.Dangling)
+	}
+	if len(result.Containers)+len(result.Images)+result.Dangling == 0 {
+		fmt.Fprintln(w, "Nothing to remove")
+	}
+	return gcErr
+}
+
 func runServe(cmd *cobra.Command, args []string) error {
 	port, err := cmd.Flags().GetInt("port")
 	if err != nil {
@@ -38,7 +141,7 @@ func runServe(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	st, err := store.Default()
+	st, err := openStore()
 	if err != nil {
 		return err
 	}
@@ -47,6 +150,49 @@ func runServe(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	if srv.Socket, err = cmd.Flags().GetString("socket"); err != nil {
+		return err
+	}
+	if srv.Socket != "" {
+		if srv.Socket, err = filepath.Abs(srv.Socket); err != nil {
+			return err
+		}
+	}
+	tcp, err := cmd.Flags().GetBool("tcp")
+	if err != nil {
+		return err
+	}
+	srv.NoTCP = !tcp
+	if srv.NoTCP && srv.Socket == "" {
+		return fmt.Errorf("--tcp=false requires --socket")
+	}
+
+	// Record the endpoint once it is bound, so installs point agents at it
+	// and not at a port another process holds.
+	srv.Listening = func(ep serve.Endpoint) error {
+		prev, err := serve.Advertise(st, ep)
+		if err != nil {
+			return err
+		}
+		if prev != ep {
+			warnf(cmd, "apkg serve last listened on %s; run `apkg install` to point agent configs at %s", prev, ep)
+		}
+		return nil
+	}
+	srv.Access = DevCfg.ServeAccess
+	srv.Installs = workspace.ContainerInstall
+	if srv.DialTimeout, err = cmd.Flags().GetDuration("dial-timeout"); err != nil {
+		return err
+	}
+	if srv.ResponseHeaderTimeout, err = cmd.Flags().GetDuration("response-header-timeout"); err != nil {
+		return err
+	}
+	if srv.RequestTimeout, err = cmd.Flags().GetDuration("request-timeout"); err != nil {
+		return err
+	}
+	if srv.MaxRequestBody, err = cmd.Flags().GetInt64("max-request-body"); err != nil {
+		return err
+	}
 
 	return srv.ListenAndServe(cmd.Context())
 }
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/agentpkg/agentpkg/pkg/installer"
+	"github.com/spf13/cobra"
+)
+
+func newStatusCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the install state of the packages in apkg.toml",
+		Long: `Lists the skills and MCP servers in apkg.toml with their install state:
+installed, not-installed (not in the lockfile yet), or missing (locked, but
+deleted from the store), followed by lockfile entries no package refers to.
+
+With --agents, also lists the skills and MCP servers in the configured agents'
+configs that apkg doesn't manage, e.g. servers added by hand or by another
+tool, to move into apkg.toml or to review. Entries apkg projected for other
+projects or the global install count as managed.
+
+Also compares the agents' configs with apkg.toml and what apkg projected,
+listing what drifted out-of-band:
+
+  missing     a skill in apkg.toml absent from an agent's skills directory
+  dangling    a skill link into the store whose target is gone, e.g. after
+              apkg cache gc
+  undeclared  a skill apkg projected that apkg.toml no longer declares
+  deleted     a projected MCP server entry or file removed by hand
+  broken      a projected skill link whose target is gone
+  modified    a projected skill link, file, or MCP server entry changed by
+              hand; apkg remove leaves these in place
+
+apkg sync --repair projects missing and dangling skills again.`,
+		Args: cobra.NoArgs,
+		RunE: runStatus,
+	}
+	cmd.Flags().Bool("agents", false, "Also list agent config entries apkg doesn't manage")
+	cmd.Flags().Bool("json", false, "Print the status as JSON")
+	return cmd
+}
+
+// statusOutput is the JSON form of apkg status.
+type statusOutput struct {
+	*installer.Status
+	Unmanaged []installer.Unmanaged `json:"unmanaged,omitempty"`
+	// Drift is what drifted in the agents' configs.
+	Drift *installer.ProjectionReport `json:"drift"`
+}
+
+func runStatus(cmd *cobra.Command, args []string) error {
+	ws, err := commandWorkspace(cmd)
+	if err != nil {
+		return err
+	}
+	withAgents, err := cmd.Flags().GetBool("agents")
+	if err != nil {
+		return err
+	}
+	asJSON, err := cmd.Flags().GetBool("json")
+	if err != nil {
+		return err
+	}
+
+	cfg, lf, err := ws.Load()
+	if err != nil {
+		return err
+	}
+
+	inst := ws.Installer()
+
+	out := statusOutput{Status: inst.Status(cfg, lf)}
+	out.Drift, err = inst.ProjectionReport(cfg)
+	if err != nil {
+		return err
+	}
+	if withAgents {
+		out.Unmanaged, err = inst.Unmanaged(cfg)
+		if err != nil {
+			return err
+		}
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(out)
+	}
+
+	w := cmd.OutOrStdout()
+	if len(out.Packages) == 0 {
+		fmt.Fprintln(w, "No packages in apkg.toml")
+	} else {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tKIND\tSTATE")
+		for _, p := range out.Packages {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", p.Name, p.Kind, p.State)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	if len(out.Orphaned) > 0 {
+		fmt.Fprintln(w, "\nLockfile entries no package refers to:")
+		for _, entry := range out.Orphaned {
+			fmt.Fprintf(w, "  %s\n", entry)
+		}
+	}
+	if !out.Drift.InSync() {
+		fmt.Fprintln(w, "\nDrift in agent configs:")
+		if err := printDrift(w, out.Drift); err != nil {
+			return err
+		}
+	}
+
+	if !withAgents {
+		return nil
+	}
+	if len(out.Unmanaged) == 0 {
+		fmt.Fprintln(w, "\nAgent configs have no entries apkg doesn't manage")
+		return nil
+	}
+	fmt.Fprintln(w, "\nNot managed by apkg:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "AGENT\tKIND\tNAME\tPATH")
+	for _, u := range out.Unmanaged {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", u.Agent, u.Kind, u.Name, u.Path)
+	}
+	return tw.Flush()
+}
+
+// printDrift prints report as a table.
+func printDrift(w io.Writer, report *installer.ProjectionReport) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "AGENT\tKIND\tNAME\tSTATE\tPATH")
+	for _, d := range report.Missing {
+		fmt.Fprintf(tw, "%s\t%s\t%s\tmissing\t-\n", d.Agent, d.Kind, d.Name)
+	}
+	for _, link := range report.Dangling {
+		fmt.Fprintf(tw, "%s\t%s\t%s\tdangling\t%s -> %s\n", link.Agent, installer.KindSkill, link.Name, link.Path, link.Target)
+	}
+	for _, u := range report.Undeclared {
+		fmt.Fprintf(tw, "%s\t%s\t%s\tundeclared\t%s\n", u.Agent, installer.KindSkill, u.Name, u.Path)
+	}
+	for _, d := range report.Changed {
+		state := d.State
+		if state == installer.DriftMissing {
+			state = "deleted"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", d.Agent, d.Kind, d.Name, state, d.Path)
+	}
+	return tw.Flush()
+}
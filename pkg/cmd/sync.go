@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/installer"
+	"github.com/spf13/cobra"
+)
+
+func newSyncCmd() *cobra.Command {
+	syncCmd := &cobra.Command{
+		Use:   "sync",
+		Short: "Check agent configurations against apkg.toml",
+		Long: `Reports skill links in agent configurations whose target is gone (e.g.
+after the store was cleaned up) and skills missing from an agent's skills
+directory.
+
+With --repair, removes the dangling links and installs again: declared
+skills are fetched again and their links recreated, links of skills no
+longer in apkg.toml stay removed.`,
+		Args: cobra.NoArgs,
+		RunE: runSync,
+	}
+	syncCmd.Flags().Bool("repair", false, "Remove dangling links and reinstall to fix them")
+	syncCmd.Flags().Int("concurrency", installer.DefaultConcurrency, "Number of packages to fetch at once")
+	return syncCmd
+}
+
+func runSync(cmd *cobra.Command, args []string) error {
+	repair, err := cmd.Flags().GetBool("repair")
+	if err != nil {
+		return err
+	}
+	ws, err := commandWorkspace(cmd)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadFile(ws.ManifestPath)
+	if err != nil {
+		return fmt.Errorf("loading %s: %w", ws.ManifestPath, err)
+	}
+
+	inst := ws.Installer()
+
+	out := cmd.OutOrStdout()
+	if repair {
+		removed, err := inst.RemoveDanglingLinks(cfg)
+		if err != nil {
+			return err
+		}
+		for _, link := range removed {
+			fmt.Fprintf(out, "Removed dangling link %s\n", link.Path)
+		}
+		return runInstallAll(cmd, args)
+	}
+
+	dangling, err := inst.DanglingLinks(cfg)
+	if err != nil {
+		return err
+	}
+	drift, err := inst.Drift(cfg)
+	if err != nil {
+		return err
+	}
+
+	if len(dangling) == 0 && len(drift) == 0 {
+		fmt.Fprintln(out, "Agent configurations are in sync")
+		return nil
+	}
+
+	if len(dangling) > 0 {
+		fmt.Fprintln(out, "Dangling links:")
+		for _, link := range dangling {
+			fix := "remove"
+			if link.Declared {
+				fix = "refetch"
+			}
+			fmt.Fprintf(out, "  %s -> %s (%s, will %s)\n", link.Path, link.Target, link.Agent, fix)
+		}
+	}
+	if len(drift) > 0 {
+		fmt.Fprintln(out, "Missing projections:")
+		for _, d := range drift {
+			fmt.Fprintf(out, "  %s %s (%s)\n", d.Kind, d.Name, d.Agent)
+		}
+	}
+	fmt.Fprintln(out, "Run `apkg sync --repair` to fix them")
+	return nil
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/agentpkg/agentpkg/pkg/installer"
+	"github.com/spf13/cobra"
+)
+
+func newTreeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "tree",
+		Short: "Show what each manifest entry installs and where it is projected",
+		Long: `Prints every skill and MCP server in apkg.toml with the commit or version
+it resolved to, the store entry backing it, the agents it is projected to,
+and other entries sharing the same store entry (such as skills from the same
+repository commit).`,
+		Args: cobra.NoArgs,
+		RunE: runTree,
+	}
+}
+
+func runTree(cmd *cobra.Command, args []string) error {
+	ws, err := commandWorkspace(cmd)
+	if err != nil {
+		return err
+	}
+	cfg, lf, err := ws.Load()
+	if err != nil {
+		return err
+	}
+
+	inst := ws.Installer()
+	nodes := inst.Tree(cfg, lf)
+	if len(nodes) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "No packages installed")
+		return nil
+	}
+
+	var skills, servers []installer.TreeNode
+	for _, node := range nodes {
+		if node.Kind == installer.KindSkill {
+			skills = append(skills, node)
+		} else {
+			servers = append(servers, node)
+		}
+	}
+
+	printTreeSection(cmd.OutOrStdout(), "Skills", skills)
+	printTreeSection(cmd.OutOrStdout(), "MCP servers", servers)
+	return nil
+}
+
+func printTreeSection(w io.Writer, title string, nodes []installer.TreeNode) {
+	if len(nodes) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, title)
+	for i, node := range nodes {
+		branch, indent := "├── ", "│   "
+		if i == len(nodes)-1 {
+			branch, indent = "└── ", "    "
+		}
+
+		line := node.Name + "  " + node.Source
+		if node.Resolved != "" {
+			line += " (" + shortVersion(node.Resolved) + ")"
+		}
+		fmt.Fprintln(w, branch+line)
+
+		var details []string
+		if node.StorePath != "" {
+			details = append(details, "store: "+node.StorePath)
+		}
+		if len(node.Agents) > 0 {
+			details = append(details, "agents: "+strings.Join(node.Agents, ", "))
+		} else {
+			details = append(details, "agents: (none)")
+		}
+		if len(node.SharedWith) > 0 {
+			details = append(details, "shared with: "+strings.Join(node.SharedWith, ", "))
+		}
+		for j, detail := range details {
+			if j == len(details)-1 {
+				fmt.Fprintln(w, indent+"└── "+detail)
+			} else {
+				fmt.Fprintln(w, indent+"├── "+detail)
+			}
+		}
+	}
+}
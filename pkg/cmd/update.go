@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/installer"
+	"github.com/agentpkg/agentpkg/pkg/source"
+	"github.com/spf13/cobra"
+)
+
+func newUpdateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "update [name...]",
+		Short: "Update packages to their latest upstream versions",
+		Long: `Checks installed skills and managed MCP servers for newer upstream
+versions and installs them. Skills tracking a branch move to the branch's
+latest commit, skills on a version tag move to the newest release tag, and
+managed npm/uv/go servers move to the latest published version. Skills pinned
+to a commit are left alone, unless they were pinned by pin_refs and track a
+branch or tag: those follow the tracked ref and are pinned to its new commit.
+Skills from archived GitHub repositories and servers whose installed version
+was deprecated, yanked, or retracted upstream are reported as warnings.
+
+Pass names to update only those packages, or use -i to pick updates from a
+list.`,
+		RunE: runUpdate,
+	}
+
+	cmd.Flags().BoolP("interactive", "i", false, "Choose which updates to apply")
+	cmd.Flags().String("env-set", "", "Env set to apply to MCP servers that define it (overrides env_set in dev config)")
+
+	return cmd
+}
+
+func runUpdate(cmd *cobra.Command, args []string) error {
+	ws, err := commandWorkspace(cmd)
+	if err != nil {
+		return err
+	}
+	manifestPath, lockPath, global := ws.ManifestPath, ws.LockPath, ws.Global
+	interactive, err := cmd.Flags().GetBool("interactive")
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadFile(manifestPath)
+	if err != nil {
+		return fmt.Errorf("loading %s: %w", manifestPath, err)
+	}
+
+	for _, name := range args {
+		_, isSkill := cfg.Skills[name]
+		_, isMCP := cfg.MCPServers[name]
+		if !isSkill && !isMCP {
+			return fmt.Errorf("%q is not in %s", name, manifestPath)
+		}
+	}
+
+	existingLock, err := config.LoadLockFile(lockPath)
+	if err != nil {
+		return fmt.Errorf("loading lockfile: %w", err)
+	}
+
+	// Updates act on what upstream has now, so cached answers are
+	// revalidated, which stays cheap where ETags match.
+	inst := ws.Installer()
+	inst.Metadata = &source.Metadata{Store: ws.Store, TTL: -1}
+
+	fmt.Fprintln(progressOut(cmd), "Checking for updates...")
+	updates, err := inst.Outdated(cmd.Context(), cfg, existingLock)
+	if err != nil {
+		// Report unreachable upstreams but still offer the updates found.
+		warnf(cmd, "%v", err)
+	}
+
+	if len(args) > 0 {
+		updates = slices.DeleteFunc(updates, func(u installer.Update) bool {
+			return !slices.Contains(args, u.Name)
+		})
+	}
+
+	if len(updates) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "Everything is up to date")
+		return nil
+	}
+
+	if interactive {
+		updates, err = promptUpdates(updates)
+		if err != nil {
+			return err
+		}
+		if len(updates) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "Nothing selected")
+			return nil
+		}
+	}
+
+	agents, err := resolveAgents(global)
+	if err != nil {
+		return err
+	}
+	inst.Agents = agents
+
+	var names []string
+	for _, u := range updates {
+		if u.Kind == installer.KindMCP {
+			names = append(names, u.Name)
+		}
+	}
+	if inst.DeferredServers, err = deferredServers(cmd, global, names); err != nil {
+		return err
+	}
+
+	lf, err := inst.ApplyUpdates(cmd.Context(), cfg, existingLock, updates)
+	if err != nil {
+		return err
+	}
+	if cfg.Project.PinRefs {
+		installer.PinRefs(cfg, lf)
+	}
+
+	if err := config.SaveFile(manifestPath, cfg); err != nil {
+		return fmt.Errorf("writing %s: %w", manifestPath, err)
+	}
+	if err := ws.SaveLock(lf); err != nil {
+		return err
+	}
+
+	for _, u := range updates {
+		fmt.Fprintf(cmd.OutOrStdout(), "Updated %s %q: %s → %s\n", u.Kind, u.Name, shortVersion(u.Current), shortVersion(u.Latest))
+	}
+	return nil
+}
+
+// promptUpdates presents the available updates in a multi-select and
+// returns the chosen ones.
+func promptUpdates(updates []installer.Update) ([]installer.Update, error) {
+	labels := make([]string, len(updates))
+	for i, u := range updates {
+		labels[i] = fmt.Sprintf("%s: %s  %s → %s", u.Kind, u.Name, shortVersion(u.Current), shortVersion(u.Latest))
+	}
+
+	selectedIdxs, err := prompter.MultiSelect("Select updates to apply", labels)
+	if err != nil {
+		return nil, fmt.Errorf("selection prompt failed: %w", err)
+	}
+
+	selected := make([]installer.Update, 0, len(selectedIdxs))
+	for _, idx := range selectedIdxs {
+		selected = append(selected, updates[idx])
+	}
+	return selected, nil
+}
+
+// shortVersion abbreviates commit hashes for display and leaves versions
+// and tags as they are.
+func shortVersion(v string) string {
+	if len(v) == 40 {
+		return v[:7]
+	}
+	return v
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/installer"
+	"github.com/spf13/cobra"
+)
+
+func newVendorCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "vendor",
+		Short: "Copy installed packages into the project",
+		Long: `Copies the resolved content of every skill into vendor/apkg/ in the
+project and re-projects agents to point at the vendored copies, so all
+agent content can be reviewed in-repo and installed offline.
+
+With --mcp, MCP server definitions (mcp.toml) are vendored as well.
+Managed npm/uv/go servers are skipped since they depend on installed
+package content.
+
+Once vendor/apkg/ exists, apkg install loads packages from it instead of
+fetching them. Re-run apkg vendor after changing apkg.toml: installs fail
+while a vendored skill's source or ref differs from the manifest's.`,
+		Args: cobra.NoArgs,
+		RunE: runVendor,
+	}
+
+	cmd.Flags().Bool("mcp", false, "Also vendor MCP server definitions")
+
+	return cmd
+}
+
+func runVendor(cmd *cobra.Command, args []string) error {
+	ws, err := commandWorkspace(cmd)
+	if err != nil {
+		return err
+	}
+	if ws.Global {
+		return fmt.Errorf("vendoring is only supported for projects, not --global")
+	}
+	projectDir, manifestPath, lockPath := ws.Dir, ws.ManifestPath, ws.LockPath
+
+	withMCP, err := cmd.Flags().GetBool("mcp")
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadFile(manifestPath)
+	if err != nil {
+		return fmt.Errorf("loading %s: %w", manifestPath, err)
+	}
+
+	existingLock, err := config.LoadLockFile(lockPath)
+	if err != nil {
+		return fmt.Errorf("loading lockfile: %w", err)
+	}
+
+	agents, err := resolveAgents(false)
+	if err != nil {
+		return err
+	}
+
+	if err := offerGitignoreEntries(progressOut(cmd), projectDir, agents, false); err != nil {
+		return err
+	}
+
+	ws.Agents = agents
+	inst := ws.Installer()
+	inst.VendorDir = filepath.Join(projectDir, installer.VendorDirName)
+
+	result, err := inst.Vendor(cmd.Context(), cfg, existingLock, withMCP)
+	if err != nil {
+		return err
+	}
+
+	// Re-run the install so projections and the lockfile point at the
+	// vendored copies.
+	lf, err := inst.InstallAll(cmd.Context(), cfg, existingLock)
+	if err != nil {
+		return err
+	}
+
+	if err := ws.SaveLock(lf); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Vendored %d skill(s) and %d MCP server(s) into %s\n",
+		len(result.Skills), len(result.MCPServers), installer.VendorDirName)
+	for _, name := range result.Skipped {
+		fmt.Fprintf(progressOut(cmd), "Skipped MCP server %q: managed packages can't be vendored\n", name)
+	}
+	if len(agents) > 0 {
+		fmt.Fprintf(progressOut(cmd), "Projected vendored packages to %s\n", strings.Join(agents, ", "))
+	}
+	return nil
+}
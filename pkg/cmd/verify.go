@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+func newVerifyCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "verify",
+		Short: "Check the store against the lockfile's integrity hashes",
+		Long: `Rehashes the store content of every locked skill and static or container
+MCP server and compares it with the integrity recorded in the lockfile.
+Installs reuse cached hashes of store directories whose files haven't
+changed; verify always reads every file, so it also catches content
+modified without changing modification times.
+
+Managed MCP servers (npm, uv, go) are locked to their publisher's
+integrity, which installs check against the registry instead.`,
+		Args: cobra.NoArgs,
+		RunE: runVerify,
+	}
+}
+
+func runVerify(cmd *cobra.Command, args []string) error {
+	ws, err := commandWorkspace(cmd)
+	if err != nil {
+		return err
+	}
+	lf, err := config.LoadLockFile(ws.LockPath)
+	if err != nil {
+		return fmt.Errorf("loading lockfile: %w", err)
+	}
+
+	inst := ws.Installer()
+	mismatches, err := inst.Verify(lf)
+	if err != nil {
+		return err
+	}
+
+	if len(mismatches) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "All store content matches the lockfile")
+		return nil
+	}
+	for _, m := range mismatches {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Error: %v\n", m)
+	}
+	return fmt.Errorf("%d package(s) in the store don't match the lockfile", len(mismatches))
+}
@@ -0,0 +1,158 @@
+package completion
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/store/storetest"
+)
+
+func TestInstall(t *testing.T) {
+	tests := map[string]struct {
+		wantScript string // relative to home
+		wantRC     string // relative to home, "" for none
+	}{
+		"bash": {wantScript: ".apkg/completions/apkg.bash", wantRC: ".bashrc"},
+		"zsh":  {wantScript: ".apkg/completions/_apkg", wantRC: ".zshrc"},
+		"fish": {wantScript: ".config/fish/completions/apkg.fish"},
+	}
+
+	gen := func(w io.Writer) error {
+		_, err := io.WriteString(w, "# completion script\n")
+		return err
+	}
+
+	for shell, tc := range tests {
+		t.Run(shell, func(t *testing.T) {
+			home := t.TempDir()
+			if tc.wantRC != "" {
+				if err := os.WriteFile(filepath.Join(home, tc.wantRC), []byte("export EDITOR=vi"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for i, wantUpdated := range []bool{tc.wantRC != "", false} {
+				result, err := Install(shell, home, filepath.Join(home, ".apkg"), gen)
+				if err != nil {
+					t.Fatalf("Install() #%d error = %v", i+1, err)
+				}
+				if want := filepath.Join(home, tc.wantScript); result.ScriptPath != want {
+					t.Errorf("ScriptPath = %q, want %q", result.ScriptPath, want)
+				}
+				if result.RCUpdated != wantUpdated {
+					t.Errorf("Install() #%d RCUpdated = %v, want %v", i+1, result.RCUpdated, wantUpdated)
+				}
+			}
+
+			if _, err := os.Stat(filepath.Join(home, tc.wantScript)); err != nil {
+				t.Errorf("script not written: %v", err)
+			}
+			if tc.wantRC == "" {
+				return
+			}
+			data, err := os.ReadFile(filepath.Join(home, tc.wantRC))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rc := string(data)
+			if !strings.HasPrefix(rc, "export EDITOR=vi\n") {
+				t.Errorf("rc file lost its content:\n%s", rc)
+			}
+			if n := strings.Count(rc, rcComment); n != 1 {
+				t.Errorf("rc file has %d completion blocks, want 1:\n%s", n, rc)
+			}
+		})
+	}
+}
+
+func TestInstallUnsupportedShell(t *testing.T) {
+	home := t.TempDir()
+	gen := func(w io.Writer) error { return nil }
+	if _, err := Install("tcsh", home, filepath.Join(home, ".apkg"), gen); err == nil {
+		t.Error("Install(tcsh) error = nil, want unsupported shell error")
+	}
+}
+
+func TestDetectShell(t *testing.T) {
+	tests := map[string]struct {
+		shellPath string
+		want      string
+	}{
+		"bash":        {shellPath: "/bin/bash", want: "bash"},
+		"zsh":         {shellPath: "/usr/local/bin/zsh", want: "zsh"},
+		"unsupported": {shellPath: "/bin/tcsh"},
+		"unset":       {},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := DetectShell(tc.shellPath); got != tc.want {
+				t.Errorf("DetectShell(%q) = %q, want %q", tc.shellPath, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRecordRef(t *testing.T) {
+	s := storetest.NewMemory()
+
+	for _, ref := range []string{"org/a/pdf@main", "org/b/docx@v1", "org/a/pdf@main"} {
+		if err := RecordRef(s, ref); err != nil {
+			t.Fatalf("RecordRef(%q) error = %v", ref, err)
+		}
+	}
+
+	got, err := RecentRefs(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"org/a/pdf@main", "org/b/docx@v1"}; !slices.Equal(got, want) {
+		t.Errorf("RecentRefs() = %v, want %v", got, want)
+	}
+
+	for i := range maxRecentRefs + 5 {
+		if err := RecordRef(s, "org/repo/skill-"+strconv.Itoa(i)+"@main"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, _ := RecentRefs(s); len(got) != maxRecentRefs {
+		t.Errorf("len(RecentRefs()) = %d, want %d", len(got), maxRecentRefs)
+	}
+}
+
+func TestCompleteRefs(t *testing.T) {
+	recent := []string{"org/skills/pdf@main", "org/skills/docx@v1.2.0", "other/tool@main"}
+
+	tests := map[string]struct {
+		toComplete string
+		want       []string
+	}{
+		"empty": {
+			want: []string{"org/skills/", "org/skills/pdf@main", "org/skills/docx@v1.2.0", "other/tool@main"},
+		},
+		"owner prefix": {
+			toComplete: "org/",
+			want:       []string{"org/skills/", "org/skills/pdf@main", "org/skills/docx@v1.2.0"},
+		},
+		"skill prefix": {
+			toComplete: "org/skills/d",
+			want:       []string{"org/skills/docx@v1.2.0"},
+		},
+		"no match": {
+			toComplete: "nobody/",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := CompleteRefs(recent, tc.toComplete); !slices.Equal(got, tc.want) {
+				t.Errorf("CompleteRefs(%q) = %v, want %v", tc.toComplete, got, tc.want)
+			}
+		})
+	}
+}
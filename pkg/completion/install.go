@@ -0,0 +1,124 @@
+package completion
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Shells supported by Install.
+var Shells = []string{"bash", "zsh", "fish"}
+
+// rcComment precedes the lines Install appends to a shell's rc file.
+const rcComment = "# apkg shell completion"
+
+// InstallResult describes what Install changed.
+type InstallResult struct {
+	// ScriptPath is where the completion script was written.
+	ScriptPath string
+	// RCFile is the shell rc file that sources the script, or "" if the
+	// shell loads it from ScriptPath on its own (fish).
+	RCFile string
+	// RCUpdated reports whether lines were appended to RCFile; false if it
+	// already sourced the script.
+	RCUpdated bool
+}
+
+// DetectShell returns the name of the shell in the $SHELL path shellPath
+// if it is supported by Install, or "".
+func DetectShell(shellPath string) string {
+	name := filepath.Base(shellPath)
+	for _, shell := range Shells {
+		if name == shell {
+			return shell
+		}
+	}
+	return ""
+}
+
+// Install writes the completion script generated by gen for shell and
+// makes the shell load it: bash and zsh scripts are written to
+// configDir/completions and sourced from ~/.bashrc or ~/.zshrc, fish
+// scripts go to fish's completions directory. Re-running Install
+// refreshes the script without duplicating the rc lines.
+func Install(shell, home, configDir string, gen func(io.Writer) error) (*InstallResult, error) {
+	var buf bytes.Buffer
+	if err := gen(&buf); err != nil {
+		return nil, fmt.Errorf("generating %s completion: %w", shell, err)
+	}
+
+	result := &InstallResult{}
+	var rcLines []string
+	switch shell {
+	case "bash":
+		result.ScriptPath = filepath.Join(configDir, "completions", "apkg.bash")
+		result.RCFile = filepath.Join(home, ".bashrc")
+		rcLines = []string{fmt.Sprintf("[ -f %q ] && source %q", result.ScriptPath, result.ScriptPath)}
+	case "zsh":
+		result.ScriptPath = filepath.Join(configDir, "completions", "_apkg")
+		result.RCFile = filepath.Join(home, ".zshrc")
+		rcLines = []string{
+			"(( $+functions[compdef] )) || { autoload -U compinit && compinit; }",
+			fmt.Sprintf("[ -f %q ] && source %q", result.ScriptPath, result.ScriptPath),
+		}
+	case "fish":
+		result.ScriptPath = filepath.Join(home, ".config", "fish", "completions", "apkg.fish")
+	default:
+		return nil, fmt.Errorf("unsupported shell %q (want one of %s)", shell, strings.Join(Shells, ", "))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(result.ScriptPath), 0o755); err != nil {
+		return nil, fmt.Errorf("creating %s: %w", filepath.Dir(result.ScriptPath), err)
+	}
+	if err := os.WriteFile(result.ScriptPath, buf.Bytes(), 0o644); err != nil {
+		return nil, fmt.Errorf("writing %s: %w", result.ScriptPath, err)
+	}
+
+	if result.RCFile == "" {
+		return result, nil
+	}
+	updated, err := appendRCLines(result.RCFile, rcLines)
+	if err != nil {
+		return nil, err
+	}
+	result.RCUpdated = updated
+	return result, nil
+}
+
+// appendRCLines appends lines, preceded by rcComment, to the rc file at
+// path unless its last line is already present. It reports whether the
+// file was changed.
+func appendRCLines(path string, lines []string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("reading %s: %w", path, err)
+	}
+	if bytes.Contains(data, []byte(lines[len(lines)-1])) {
+		return false, nil
+	}
+
+	var b strings.Builder
+	if len(data) > 0 {
+		if !bytes.HasSuffix(data, []byte("\n")) {
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(rcComment + "\n")
+	for _, line := range lines {
+		b.WriteString(line + "\n")
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return false, fmt.Errorf("opening %s: %w", path, err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(b.String()); err != nil {
+		return false, fmt.Errorf("writing %s: %w", path, err)
+	}
+	return true, nil
+}
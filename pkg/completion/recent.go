@@ -0,0 +1,80 @@
+// Package completion installs apkg's shell completion scripts into the
+// user's shell configuration and keeps the cache of recently used refs
+// that dynamic completions suggest.
+package completion
+
+import (
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+const (
+	// RecentRefsFile is the store file holding recently installed refs,
+	// one per line, most recent first.
+	RecentRefsFile = "recent-refs"
+
+	// maxRecentRefs caps the number of refs kept.
+	maxRecentRefs = 50
+)
+
+// RecordRef moves ref to the front of the recent refs in s.
+func RecordRef(s store.Store, ref string) error {
+	refs, err := RecentRefs(s)
+	if err != nil {
+		return err
+	}
+
+	refs = slices.DeleteFunc(refs, func(r string) bool { return r == ref })
+	refs = append([]string{ref}, refs...)
+	if len(refs) > maxRecentRefs {
+		refs = refs[:maxRecentRefs]
+	}
+
+	s.EnsureDir()
+	return s.WriteFile([]byte(strings.Join(refs, "\n")+"\n"), 0o644, RecentRefsFile)
+}
+
+// RecentRefs returns the recent refs in s, most recent first.
+func RecentRefs(s store.Store) ([]string, error) {
+	data, err := s.ReadFile(RecentRefsFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			refs = append(refs, line)
+		}
+	}
+	return refs, nil
+}
+
+// CompleteRefs returns the completions of toComplete from recent refs
+// (owner/repo/path@ref): the refs themselves, and their owner/repo/
+// prefixes so other skills from a known repository are quick to type.
+func CompleteRefs(recent []string, toComplete string) []string {
+	var completions []string
+	add := func(c string) {
+		if strings.HasPrefix(c, toComplete) && !slices.Contains(completions, c) {
+			completions = append(completions, c)
+		}
+	}
+
+	for _, ref := range recent {
+		path, _, _ := strings.Cut(ref, "@")
+		if segments := strings.Split(path, "/"); len(segments) > 2 {
+			add(segments[0] + "/" + segments[1] + "/")
+		}
+	}
+	for _, ref := range recent {
+		add(ref)
+	}
+	return completions
+}
@@ -16,10 +16,42 @@ type Config struct {
 	Project    ProjectConfig          `toml:"project"`
 	Skills     map[string]SkillSource `toml:"skills,omitempty"`
 	MCPServers map[string]MCPSource   `toml:"mcpServers,omitempty"`
+	Runtimes   *RuntimesConfig        `toml:"runtimes,omitempty"`
 }
 
 type ProjectConfig struct {
 	Name string `toml:"name"`
+
+	// PinRefs makes install and update rewrite the ref of each git skill
+	// to the commit it resolved to, keeping the original ref in Track, so
+	// the committed manifest itself is immutable.
+	PinRefs bool `toml:"pin_refs,omitempty"`
+}
+
+// RuntimesConfig pins the runtimes managed MCP servers run on. apkg
+// installs each pinned runtime into the store and uses it instead of the
+// system's for managed npm (Node) or uv (Python) servers. A version is
+// either concrete ("22.11.0") or a prefix of one ("22"), which resolves
+// to the newest matching release and is locked like package versions.
+type RuntimesConfig struct {
+	Node   string `toml:"node,omitempty"`
+	Python string `toml:"python,omitempty"`
+}
+
+// Versions returns the pinned version of each runtime by name, leaving
+// out runtimes that aren't pinned.
+func (rc *RuntimesConfig) Versions() map[string]string {
+	versions := make(map[string]string)
+	if rc == nil {
+		return versions
+	}
+	if rc.Node != "" {
+		versions["node"] = rc.Node
+	}
+	if rc.Python != "" {
+		versions["python"] = rc.Python
+	}
+	return versions
 }
 
 type SkillSource struct {
@@ -29,15 +61,77 @@ type SkillSource struct {
 	Git  string `toml:"git,omitempty"`
 	Path string `toml:"path,omitempty"`
 	Ref  string `toml:"ref,omitempty"`
+
+	// URL is an HTTP(S) URL of a .tar.gz or .zip archive of the skill, for
+	// skills published as release artifacts rather than git repositories.
+	// Path is then the skill's directory within the archive.
+	URL string `toml:"url,omitempty"`
+	// SHA256 is the hex digest the archive at URL must have.
+	SHA256 string `toml:"sha256,omitempty"`
+
+	// Track is the branch or tag a commit Ref was pinned from (see
+	// ProjectConfig.PinRefs). Updates follow Track instead of Ref.
+	Track string `toml:"track,omitempty"`
+
+	// Scope is where the skill is projected: SkillScopeProject (the
+	// default) or SkillScopeUser for the agents' global skills location.
+	Scope string `toml:"scope,omitempty"`
+
+	// Timeout overrides the fetch timeout for this skill (see
+	// ParseTimeout).
+	Timeout string `toml:"timeout,omitempty"`
+
+	// Snapshot copies a local skill (one without Git or URL) into the store,
+	// keyed by the hash of its content, instead of projecting its
+	// directory in place. The lockfile then records the content's
+	// integrity, and agents keep the snapshot if the directory is deleted.
+	Snapshot bool `toml:"snapshot,omitempty"`
+}
+
+// Remote returns the git repository or archive URL the skill is fetched
+// from, or "" for a local skill.
+func (ss SkillSource) Remote() string {
+	if ss.Git != "" {
+		return ss.Git
+	}
+	return ss.URL
 }
 
+const (
+	// SkillScopeProject projects a skill into the project's agent
+	// directories.
+	SkillScopeProject = "project"
+	// SkillScopeUser projects a skill declared by a project manifest into
+	// the agents' global skills location, so it follows the developer
+	// across projects.
+	SkillScopeUser = "user"
+)
+
+// MCP server transports.
+const (
+	TransportStdio = "stdio"
+	// TransportHTTP is the streamable HTTP transport.
+	TransportHTTP = "http"
+	// TransportSSE is the older HTTP+SSE transport, for remote servers
+	// that don't serve streamable HTTP yet.
+	TransportSSE = "sse"
+)
+
+// Transports are the accepted values of MCPSource.Transport.
+var Transports = []string{TransportStdio, TransportHTTP, TransportSSE}
+
 type MCPSource struct {
-	// Transport is required: "stdio" or "http"
+	// Transport is required, one of Transports. Remote servers may use
+	// "sse"; apkg serve routes container servers over "http".
 	Transport string `toml:"transport"`
 
 	// Name of the server, overrides the key in the table of mcp servers
 	Name string `toml:"name,omitempty"`
 
+	// Timeout overrides the fetch timeout for this server's package or
+	// image (see ParseTimeout).
+	Timeout string `toml:"timeout,omitempty"`
+
 	// container config
 	*ContainerMCPConfig `toml:",omitempty"`
 	// external http server config
@@ -52,6 +146,11 @@ type MCPSource struct {
 
 	// common config for any locally-run mcp server (stdio or container)
 	*LocalMCPConfig `toml:",omitempty"`
+
+	// EnvSets are named overlays (e.g. "dev", "staging", "prod") applied on
+	// top of the server config when selected with --env-set or the env_set
+	// dev config key.
+	EnvSets map[string]EnvSet `toml:"env_sets,omitempty"`
 }
 
 type ContainerMCPConfig struct {
@@ -60,7 +159,20 @@ type ContainerMCPConfig struct {
 	Path    string   `toml:"path,omitempty"`    // URL path on the container (default "mcp")
 	Digest  string   `toml:"digest,omitempty"`  // resolved image digest, populated at install time
 	Volumes []string `toml:"volumes,omitempty"` // bind mounts (host:container[:ro])
-	Network string   `toml:"network,omitempty"` // container network (e.g. "host", "kind")
+	// ManifestDigest is the registry manifest digest ("sha256:...") the
+	// image was pulled by, populated at install time. Containers run the
+	// image pinned to it, so a tag moved upstream doesn't change what runs.
+	ManifestDigest string `toml:"manifest_digest,omitempty"`
+	// CreateVolumes creates missing host directories of Volumes at
+	// install time.
+	CreateVolumes bool   `toml:"create_volumes,omitempty"`
+	Network       string `toml:"network,omitempty"` // container network (e.g. "host", "kind")
+	// NetworkAliases are extra names the container is reachable by on
+	// Network, which must then be a user-defined network.
+	NetworkAliases []string `toml:"network_aliases,omitempty"`
+	// Eager makes apkg serve start the container when it starts instead of
+	// on the first request, and keeps it running while idle.
+	Eager bool `toml:"eager,omitempty"`
 }
 
 // config for any http transport mcp server
@@ -76,16 +188,37 @@ type ExternalHttpMCPConfig struct {
 // config for managed stdio mcp server
 type ManagedStdioMCPConfig struct {
 	// managed package - apkg installs + pins locally
-	// Format: "npm:<package>[@version]", "uv:<package>[==version]", or "go:<module>[@version]"
+	// Format: "npm:<package>[@version]", "uv:<package>[==version]",
+	// "uv-tool:<package>[==version]", or "go:<module>[@version]"
 	Package string `toml:"package,omitempty"`
 
+	// Bin is the console script to run for a uv-tool package that
+	// installs several. By default it is the one named like the package,
+	// or the package's only one.
+	Bin string `toml:"bin,omitempty"`
+
 	// Runtime is the resolved absolute path to the interpreter needed to
-	// run the package (e.g. /usr/local/bin/node for npm packages). It is
-	// populated at install time so that agents which do not source the
-	// shell environment (e.g. Cursor) can locate the runtime.
+	// run the package (e.g. /usr/local/bin/node for npm packages), so
+	// that agents which do not source the shell environment (e.g. Cursor)
+	// can locate the runtime. It depends on the project, so installs
+	// record it in the lockfile (see MCPLockEntry.Runtime).
+	//
+	// In the manifest, RuntimeContainer runs the package in a container
+	// instead.
 	Runtime string `toml:"runtime,omitempty"`
 }
 
+// RuntimeContainer is the ManagedStdioMCPConfig.Runtime of packages that
+// run in a container image apkg builds with the package installed on a
+// base image of its ecosystem, so the host needs no node, Python, or Go.
+const RuntimeContainer = "container"
+
+// InContainer reports whether the managed package runs in a container
+// (see RuntimeContainer).
+func (c *ManagedStdioMCPConfig) InContainer() bool {
+	return c != nil && c.Runtime == RuntimeContainer
+}
+
 // config for unmanaged stdio mcp server
 type UnmanagedStdioMCPConfig struct {
 	Command string `toml:"command,omitempty"`
@@ -96,6 +229,16 @@ type LocalMCPConfig struct {
 	Args []string          `toml:"args,omitempty"`
 }
 
+// EnvSet overrides parts of an MCP server's config for one environment.
+// Env and Headers are merged into the base maps; Args and URL replace the
+// base values when set.
+type EnvSet struct {
+	Env     map[string]string `toml:"env,omitempty"`
+	Args    []string          `toml:"args,omitempty"`
+	URL     string            `toml:"url,omitempty"`
+	Headers map[string]string `toml:"headers,omitempty"`
+}
+
 func UnmarshalConfig(data []byte) (*Config, error) {
 	cfg := &Config{}
 	err := toml.Unmarshal(data, cfg)
@@ -123,9 +266,20 @@ func SaveFile(path string, cfg *Config) error {
 	return os.WriteFile(path, data, 0o644)
 }
 
-// GlobalManifestPath returns the path to the global manifest (~/.apkg/apkg.toml),
-// ensuring the directory exists.
+// GlobalManifestPath returns the path to the global manifest (~/.apkg/apkg.toml,
+// or $APKG_GLOBAL_MANIFEST), ensuring the directory exists.
 func GlobalManifestPath() (string, error) {
+	if path := os.Getenv(EnvGlobalManifest); path != "" {
+		path, err := filepath.Abs(path)
+		if err != nil {
+			return "", err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return "", fmt.Errorf("creating %s: %w", filepath.Dir(path), err)
+		}
+		return path, nil
+	}
+
 	dir, err := GlobalConfigDir()
 	if err != nil {
 		return "", err
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/pelletier/go-toml/v2"
 	"github.com/spf13/viper"
@@ -16,7 +19,170 @@ const LocalConfigFile = "apkg.local.toml"
 // to version control. It is resolved with Viper precedence:
 // CLI flags > apkg.local.toml (project-local) > ~/.apkg/config.toml (global).
 type DevConfig struct {
-	Agents []string `toml:"agents" mapstructure:"agents"`
+	Agents     []string                  `toml:"agents" mapstructure:"agents"`
+	Registries map[string]RegistryConfig `toml:"registries,omitempty" mapstructure:"registries"`
+	// EnvSet selects the named env set of each MCP server that defines
+	// one (see MCPSource.EnvSets). Overridden by `apkg install --env-set`.
+	EnvSet string `toml:"env_set,omitempty" mapstructure:"env_set"`
+	// StorePath overrides the store location (default ~/.apkg).
+	StorePath string `toml:"store_path,omitempty" mapstructure:"store_path"`
+	// FetchTimeout bounds each package fetch (see ParseTimeout). Packages
+	// can override it with their own timeout in the manifest.
+	FetchTimeout string `toml:"fetch_timeout,omitempty" mapstructure:"fetch_timeout"`
+	// Projection is the projection strategy for skills, one of
+	// ProjectionStrategies (default ProjectionAbsolute).
+	Projection string `toml:"projection,omitempty" mapstructure:"projection"`
+	// ProjectionMode is how skills are projected, one of ProjectionModes
+	// (default ProjectionModeSymlink), e.g. ProjectionModeCopy where
+	// symlinks don't work.
+	ProjectionMode string `toml:"projection_mode,omitempty" mapstructure:"projection_mode"`
+	// AgentProjectionModes maps agents to the projection mode of their
+	// skills, overriding ProjectionMode.
+	AgentProjectionModes map[string]string `toml:"projection_modes,omitempty" mapstructure:"projection_modes"`
+	// ServeAccess restricts which projects may reach a server through
+	// `apkg serve`: it maps server names to the absolute directories of
+	// the projects allowed to use them, and ServerScopeGlobal for the
+	// global installs. Servers not listed are reachable by every project.
+	ServeAccess map[string][]string `toml:"serve_access,omitempty" mapstructure:"serve_access"`
+	// ServeSocketAgents are the agents that reach `apkg serve` over its
+	// Unix domain socket, when it listens on one, instead of localhost TCP.
+	// Only agents able to use http+unix:// MCP URLs should be listed.
+	ServeSocketAgents []string `toml:"serve_socket_agents,omitempty" mapstructure:"serve_socket_agents"`
+	// ServerScopes maps MCP servers installed both globally and in the
+	// project to the scope whose definition agents get in the project,
+	// ServerScopeProject or ServerScopeGlobal. Install asks for servers
+	// not listed and records the answer in apkg.local.toml.
+	ServerScopes map[string]string `toml:"server_scopes,omitempty" mapstructure:"server_scopes"`
+	// MCPScopes maps agents to the scope a project install projects their
+	// MCP servers into, ServerScopeProject (the default) or
+	// ServerScopeGlobal, e.g. to register servers with Claude Code for
+	// every project while keeping Cursor's project-scoped. Global installs
+	// always project globally. The global entries of a project install are
+	// named after the server and the project, e.g. "fs-billing-1a2b3c",
+	// since they run that project's install.
+	MCPScopes map[string]string `toml:"mcp_scopes,omitempty" mapstructure:"mcp_scopes"`
+	// MCPTypes maps agents to the "type" their MCP config gives servers of
+	// each transport (see Transports), overriding the agent's projector,
+	// e.g. {cursor = {http = "streamable-http"}} for an agent version that
+	// expects another name.
+	MCPTypes map[string]map[string]string `toml:"mcp_types,omitempty" mapstructure:"mcp_types"`
+	// MCPCommands maps agents to how their configs launch managed stdio
+	// MCP servers, one of MCPCommandStyles (MCPCommandAbsolute by
+	// default), e.g. absolute store paths for GUI agents that don't
+	// inherit the shell's PATH and portable npx/uvx commands for the rest.
+	MCPCommands map[string]string `toml:"mcp_commands,omitempty" mapstructure:"mcp_commands"`
+	// PolicyHook approves the packages of every install before they are
+	// projected: an http(s) URL the install plan is POSTed to, or a
+	// command run with the plan on stdin (see policy.New).
+	PolicyHook string `toml:"policy_hook,omitempty" mapstructure:"policy_hook"`
+	// Projectors define agents apkg has no projector for by where their
+	// config files are, so they can be listed in Agents like built-in
+	// ones.
+	Projectors map[string]ProjectorConfig `toml:"projectors,omitempty" mapstructure:"projectors"`
+}
+
+// ProjectorConfig describes where an agent without a built-in projector
+// reads MCP servers and skills from (see DevConfig.Projectors). Paths are
+// relative to the project, or to the home directory for global installs;
+// paths starting with "~/" are always in the home directory.
+type ProjectorConfig struct {
+	// MCPConfig is the config file MCP servers are written to, if the
+	// agent supports them.
+	MCPConfig string `toml:"mcp_config,omitempty" mapstructure:"mcp_config"`
+	// Format is the format of MCPConfig, one of ProjectorFormats. It
+	// defaults to the one of the file's extension, and JSON for others.
+	Format string `toml:"format,omitempty" mapstructure:"format"`
+	// MCPKey is the dotted path of the table of MCPConfig holding the
+	// servers by name, e.g. "mcp.servers" (default "mcpServers").
+	MCPKey string `toml:"mcp_key,omitempty" mapstructure:"mcp_key"`
+	// SkillsDir is the directory skills are symlinked into, if the agent
+	// supports them.
+	SkillsDir string `toml:"skills_dir,omitempty" mapstructure:"skills_dir"`
+}
+
+// ProjectorFormats are the accepted values of ProjectorConfig.Format.
+var ProjectorFormats = []string{"json", "toml", "yaml"}
+
+// Scopes an MCP server installed in both the project and globally can be
+// taken from (see DevConfig.ServerScopes), and scopes an agent's MCP
+// servers can be projected into (see DevConfig.MCPScopes).
+const (
+	ServerScopeProject = "project"
+	ServerScopeGlobal  = "global"
+)
+
+// Skill projection strategies, selecting what the symlinks in an agent's
+// skills directory point at.
+const (
+	// ProjectionAbsolute links to the skill's store entry by absolute path.
+	ProjectionAbsolute = "absolute"
+	// ProjectionRelative links to the store entry by a path relative to
+	// the skills directory, so the links survive moving the home directory
+	// together with the store.
+	ProjectionRelative = "relative"
+	// ProjectionProject copies the skill into the project's .apkg/
+	// directory and links to the copy by relative path, so the project
+	// works without the store, e.g. when synced to another machine.
+	ProjectionProject = "project"
+)
+
+// ProjectionStrategies are the accepted values of DevConfig.Projection.
+var ProjectionStrategies = []string{ProjectionAbsolute, ProjectionRelative, ProjectionProject}
+
+// Skill projection modes, selecting what apkg puts in an agent's skills
+// directory.
+const (
+	// ProjectionModeSymlink links to the skill, as the projection strategy
+	// says.
+	ProjectionModeSymlink = "symlink"
+	// ProjectionModeCopy copies the skill's directory, for systems where
+	// symlinks need privileges (Windows without developer mode) or don't
+	// work (some network filesystems). Every install refreshes the copy.
+	ProjectionModeCopy = "copy"
+	// ProjectionModeHardlink is ProjectionModeCopy with the files
+	// hardlinked to the store where the filesystem allows it, saving the
+	// space. Edits to the files change the store entry too.
+	ProjectionModeHardlink = "hardlink"
+)
+
+// ProjectionModes are the accepted values of DevConfig.ProjectionMode.
+var ProjectionModes = []string{ProjectionModeSymlink, ProjectionModeCopy, ProjectionModeHardlink}
+
+// Ways an agent's config can launch managed stdio MCP servers (see
+// DevConfig.MCPCommands).
+const (
+	// MCPCommandAbsolute runs the package's binary by its absolute path in
+	// the store.
+	MCPCommandAbsolute = "absolute"
+	// MCPCommandPath runs the locked package version through its package
+	// manager's runner found on PATH: npx, uvx, or go run.
+	MCPCommandPath = "path"
+	// MCPCommandExec runs the server through apkg exec, which looks the
+	// package up in the lockfile when the agent starts it.
+	MCPCommandExec = "exec"
+	// MCPCommandRun runs every stdio server through apkg run mcp, which
+	// reads the server's arguments and env from the store and resolves
+	// env references when the agent starts it, so store updates and
+	// changed secrets apply without projecting again.
+	MCPCommandRun = "run"
+)
+
+// MCPCommandStyles are the accepted values of DevConfig.MCPCommands.
+var MCPCommandStyles = []string{MCPCommandAbsolute, MCPCommandPath, MCPCommandExec, MCPCommandRun}
+
+// RegistryConfig describes a package registry that apkg can publish to.
+type RegistryConfig struct {
+	// Type is "git" (a git repository used as a package index) or "oci".
+	Type string `toml:"type" mapstructure:"type"`
+	// URL is the git clone URL of the index, or the OCI repository prefix
+	// (e.g. "ghcr.io/org/apkg").
+	URL string `toml:"url" mapstructure:"url"`
+	// Username is used together with the token for registries that require
+	// basic auth (OCI). Defaults to "apkg".
+	Username string `toml:"username,omitempty" mapstructure:"username"`
+	// TokenEnv optionally names an environment variable holding the token
+	// used to authenticate to the registry.
+	TokenEnv string `toml:"token_env,omitempty" mapstructure:"token_env"`
 }
 
 // LoadDevConfig resolves developer configuration using Viper's merge semantics.
@@ -24,13 +190,14 @@ type DevConfig struct {
 // skipping the project-local apkg.local.toml. This ensures that global installs
 // use global agent preferences rather than project-scoped ones.
 // flagAgents, if non-empty, takes highest precedence (set via --agents flag).
-func LoadDevConfig(flagAgents []string, global bool) (*DevConfig, error) {
-	home, err := os.UserHomeDir()
+// projectDir is the project root containing apkg.local.toml.
+func LoadDevConfig(flagAgents []string, global bool, projectDir string) (*DevConfig, error) {
+	dir, err := globalConfigDir()
 	if err != nil {
-		return nil, fmt.Errorf("determining home directory: %w", err)
+		return nil, err
 	}
-	globalPath := filepath.Join(home, ".apkg", "config.toml")
-	return loadDevConfig(flagAgents, global, globalPath, LocalConfigFile)
+	globalPath := filepath.Join(dir, "config.toml")
+	return loadDevConfig(flagAgents, global, globalPath, filepath.Join(projectDir, LocalConfigFile))
 }
 
 // loadDevConfig is the internal implementation that accepts explicit paths,
@@ -63,17 +230,68 @@ func loadDevConfig(flagAgents []string, global bool, globalPath, localPath strin
 	if err := v.Unmarshal(cfg); err != nil {
 		return nil, fmt.Errorf("unmarshaling dev config: %w", err)
 	}
+	if _, err := ParseTimeout(cfg.FetchTimeout); err != nil {
+		return nil, fmt.Errorf("fetch_timeout: %w", err)
+	}
+	if err := validateProjection(cfg.Projection); err != nil {
+		return nil, fmt.Errorf("projection: %w", err)
+	}
+	if err := validateProjectionMode(cfg.ProjectionMode); err != nil {
+		return nil, fmt.Errorf("%s: %w", KeyProjectionMode, err)
+	}
+	for _, agent := range sortedKeys(cfg.AgentProjectionModes) {
+		if err := validateProjectionMode(cfg.AgentProjectionModes[agent]); err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", KeyProjectionModes, agent, err)
+		}
+	}
+	for _, name := range sortedKeys(cfg.Projectors) {
+		if err := cfg.Projectors[name].validate(); err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", KeyProjectors, name, err)
+		}
+	}
 
 	return cfg, nil
 }
 
-// GlobalConfigDir returns the path to ~/.apkg, creating it if necessary.
+// FetchTimeoutDuration returns the parsed FetchTimeout, or 0 if it is
+// unset. LoadDevConfig rejects invalid values.
+func (c *DevConfig) FetchTimeoutDuration() time.Duration {
+	d, _ := ParseTimeout(c.FetchTimeout)
+	return d
+}
+
+func validateProjection(strategy string) error {
+	if strategy != "" && !slices.Contains(ProjectionStrategies, strategy) {
+		return fmt.Errorf("unknown strategy %q (want one of %s)", strategy, strings.Join(ProjectionStrategies, ", "))
+	}
+	return nil
+}
+
+func validateProjectionMode(mode string) error {
+	if mode != "" && !slices.Contains(ProjectionModes, mode) {
+		return fmt.Errorf("unknown mode %q (want one of %s)", mode, strings.Join(ProjectionModes, ", "))
+	}
+	return nil
+}
+
+// validate checks that pc projects something and names a known format.
+func (pc ProjectorConfig) validate() error {
+	if pc.MCPConfig == "" && pc.SkillsDir == "" {
+		return fmt.Errorf("mcp_config or skills_dir is required")
+	}
+	if pc.Format != "" && !slices.Contains(ProjectorFormats, pc.Format) {
+		return fmt.Errorf("unknown format %q (want one of %s)", pc.Format, strings.Join(ProjectorFormats, ", "))
+	}
+	return nil
+}
+
+// GlobalConfigDir returns the path to ~/.apkg (or $APKG_CONFIG_DIR),
+// creating it if necessary.
 func GlobalConfigDir() (string, error) {
-	home, err := os.UserHomeDir()
+	dir, err := globalConfigDir()
 	if err != nil {
-		return "", fmt.Errorf("determining home directory: %w", err)
+		return "", err
 	}
-	dir := filepath.Join(home, ".apkg")
 	if err := os.MkdirAll(dir, 0o755); err != nil {
 		return "", fmt.Errorf("creating %s: %w", dir, err)
 	}
@@ -115,3 +333,40 @@ func WriteGlobalDevConfig(cfg *DevConfig) error {
 
 	return nil
 }
+
+// GlobalDevConfigPath returns the path to ~/.apkg/config.toml.
+func GlobalDevConfigPath() (string, error) {
+	dir, err := GlobalConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.toml"), nil
+}
+
+// UpdateDevConfigFile applies update to the developer config file at path
+// (starting from an empty config if it doesn't exist) and writes it back.
+// Unlike WriteLocalDevConfig and WriteGlobalDevConfig, keys update doesn't
+// touch are preserved. Nothing is written if update returns an error.
+func UpdateDevConfigFile(path string, update func(*DevConfig) error) error {
+	cfg := &DevConfig{}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+	if err := toml.Unmarshal(data, cfg); err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	if err := update(cfg); err != nil {
+		return err
+	}
+
+	data, err = toml.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("marshaling dev config: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,585 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Developer config keys accepted by DevConfig.Get, Set, and Unset. Registry
+// fields are addressed as "registries.<name>.<field>", with field one of
+// RegistryFields, the projects allowed to reach a served MCP server as
+// "serve_access.<server>", the scope a duplicated MCP server is taken
+// from as "server_scopes.<server>", the projection mode of an agent's
+// skills as "projection_modes.<agent>", the scope an agent's MCP servers
+// are projected into as "mcp_scopes.<agent>", the type an agent's
+// config gives a transport as "mcp_types.<agent>.<transport>", how
+// an agent's config launches managed servers as "mcp_commands.<agent>",
+// and the fields of a custom agent's projector as
+// "projectors.<agent>.<field>", with field one of ProjectorFields.
+const (
+	KeyAgents            = "agents"
+	KeyEnvSet            = "env_set"
+	KeyStorePath         = "store_path"
+	KeyFetchTimeout      = "fetch_timeout"
+	KeyProjection        = "projection"
+	KeyProjectionMode    = "projection_mode"
+	KeyProjectionModes   = "projection_modes"
+	KeyRegistries        = "registries"
+	KeyServeAccess       = "serve_access"
+	KeyServeSocketAgents = "serve_socket_agents"
+	KeyServerScopes      = "server_scopes"
+	KeyMCPScopes         = "mcp_scopes"
+	KeyMCPTypes          = "mcp_types"
+	KeyMCPCommands       = "mcp_commands"
+	KeyPolicyHook        = "policy_hook"
+	KeyProjectors        = "projectors"
+)
+
+// ProjectorFields are the settable fields of a custom projector.
+var ProjectorFields = []string{"mcp_config", "format", "mcp_key", "skills_dir"}
+
+// RegistryFields are the settable fields of a registry.
+var RegistryFields = []string{"type", "url", "username", "token_env"}
+
+// RegistryTypes are the accepted values of a registry's type.
+var RegistryTypes = []string{"git", "oci"}
+
+// Keys returns the keys set in c, sorted, with registries expanded to
+// their set fields.
+func (c *DevConfig) Keys() []string {
+	var keys []string
+	if len(c.Agents) > 0 {
+		keys = append(keys, KeyAgents)
+	}
+	if c.EnvSet != "" {
+		keys = append(keys, KeyEnvSet)
+	}
+	if c.StorePath != "" {
+		keys = append(keys, KeyStorePath)
+	}
+	if c.FetchTimeout != "" {
+		keys = append(keys, KeyFetchTimeout)
+	}
+	if c.Projection != "" {
+		keys = append(keys, KeyProjection)
+	}
+	if c.ProjectionMode != "" {
+		keys = append(keys, KeyProjectionMode)
+	}
+	if len(c.ServeSocketAgents) > 0 {
+		keys = append(keys, KeyServeSocketAgents)
+	}
+	if c.PolicyHook != "" {
+		keys = append(keys, KeyPolicyHook)
+	}
+
+	for _, name := range sortedKeys(c.Registries) {
+		for _, field := range RegistryFields {
+			key := KeyRegistries + "." + name + "." + field
+			if v, _ := c.Get(key); v != "" {
+				keys = append(keys, key)
+			}
+		}
+	}
+	for _, name := range sortedKeys(c.ServeAccess) {
+		keys = append(keys, KeyServeAccess+"."+name)
+	}
+	for _, name := range sortedKeys(c.ServerScopes) {
+		keys = append(keys, KeyServerScopes+"."+name)
+	}
+	for _, agent := range sortedKeys(c.AgentProjectionModes) {
+		keys = append(keys, KeyProjectionModes+"."+agent)
+	}
+	for _, agent := range sortedKeys(c.MCPScopes) {
+		keys = append(keys, KeyMCPScopes+"."+agent)
+	}
+	for _, agent := range sortedKeys(c.MCPTypes) {
+		for _, transport := range sortedKeys(c.MCPTypes[agent]) {
+			keys = append(keys, KeyMCPTypes+"."+agent+"."+transport)
+		}
+	}
+	for _, agent := range sortedKeys(c.MCPCommands) {
+		keys = append(keys, KeyMCPCommands+"."+agent)
+	}
+	for _, agent := range sortedKeys(c.Projectors) {
+		for _, field := range ProjectorFields {
+			key := KeyProjectors + "." + agent + "." + field
+			if v, _ := c.Get(key); v != "" {
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
+
+// Get returns the value of key, with agents joined by commas. Unset keys
+// return "".
+func (c *DevConfig) Get(key string) (string, error) {
+	switch key {
+	case KeyAgents:
+		return strings.Join(c.Agents, ","), nil
+	case KeyEnvSet:
+		return c.EnvSet, nil
+	case KeyStorePath:
+		return c.StorePath, nil
+	case KeyFetchTimeout:
+		return c.FetchTimeout, nil
+	case KeyProjection:
+		return c.Projection, nil
+	case KeyProjectionMode:
+		return c.ProjectionMode, nil
+	case KeyServeSocketAgents:
+		return strings.Join(c.ServeSocketAgents, ","), nil
+	case KeyPolicyHook:
+		return c.PolicyHook, nil
+	}
+	if server, ok := parseServeAccessKey(key); ok {
+		return strings.Join(c.ServeAccess[server], ","), nil
+	}
+	if server, ok := parseServerScopeKey(key); ok {
+		return c.ServerScopes[server], nil
+	}
+	if agent, ok := parseProjectionModeKey(key); ok {
+		return c.AgentProjectionModes[agent], nil
+	}
+	if agent, ok := parseMCPScopeKey(key); ok {
+		return c.MCPScopes[agent], nil
+	}
+	if agent, transport, ok := parseMCPTypeKey(key); ok {
+		return c.MCPTypes[agent][transport], nil
+	}
+	if agent, ok := parseMCPCommandKey(key); ok {
+		return c.MCPCommands[agent], nil
+	}
+	if agent, field, ok := parseProjectorKey(key); ok {
+		pc := c.Projectors[agent]
+		switch field {
+		case "mcp_config":
+			return pc.MCPConfig, nil
+		case "format":
+			return pc.Format, nil
+		case "mcp_key":
+			return pc.MCPKey, nil
+		default:
+			return pc.SkillsDir, nil
+		}
+	}
+
+	name, field, err := parseRegistryKey(key)
+	if err != nil {
+		return "", err
+	}
+	reg := c.Registries[name]
+	switch field {
+	case "type":
+		return reg.Type, nil
+	case "url":
+		return reg.URL, nil
+	case "username":
+		return reg.Username, nil
+	default:
+		return reg.TokenEnv, nil
+	}
+}
+
+// Set validates value and assigns it to key. Agents are given as a comma-
+// separated list (their names are not checked here, see
+// projector.ValidateAgents), and store_path is made absolute.
+func (c *DevConfig) Set(key, value string) error {
+	switch key {
+	case KeyAgents:
+		agents, err := parseAgentList(key, value)
+		if err != nil {
+			return err
+		}
+		c.Agents = agents
+		return nil
+	case KeyServeSocketAgents:
+		agents, err := parseAgentList(key, value)
+		if err != nil {
+			return err
+		}
+		c.ServeSocketAgents = agents
+		return nil
+	case KeyEnvSet:
+		c.EnvSet = value
+		return nil
+	case KeyStorePath:
+		if value == "" {
+			return fmt.Errorf("%s: path is required", key)
+		}
+		abs, err := filepath.Abs(value)
+		if err != nil {
+			return fmt.Errorf("%s: resolving %s: %w", key, value, err)
+		}
+		c.StorePath = abs
+		return nil
+	case KeyFetchTimeout:
+		if value == "" {
+			return fmt.Errorf("%s: duration is required", key)
+		}
+		if _, err := ParseTimeout(value); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		c.FetchTimeout = value
+		return nil
+	case KeyProjection:
+		if value == "" {
+			return fmt.Errorf("%s: strategy is required", key)
+		}
+		if err := validateProjection(value); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		c.Projection = value
+		return nil
+	case KeyProjectionMode:
+		if value == "" {
+			return fmt.Errorf("%s: mode is required", key)
+		}
+		if err := validateProjectionMode(value); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		c.ProjectionMode = value
+		return nil
+	case KeyPolicyHook:
+		if strings.TrimSpace(value) == "" {
+			return fmt.Errorf("%s: URL or command is required", key)
+		}
+		c.PolicyHook = value
+		return nil
+	}
+	if server, ok := parseServeAccessKey(key); ok {
+		var projects []string
+		for _, project := range strings.Split(value, ",") {
+			if project = strings.TrimSpace(project); project == "" {
+				continue
+			}
+			if project == ServerScopeGlobal {
+				// The global installs, which name no project.
+				if !slices.Contains(projects, project) {
+					projects = append(projects, project)
+				}
+				continue
+			}
+			abs, err := filepath.Abs(project)
+			if err != nil {
+				return fmt.Errorf("%s: resolving %s: %w", key, project, err)
+			}
+			if !slices.Contains(projects, abs) {
+				projects = append(projects, abs)
+			}
+		}
+		if len(projects) == 0 {
+			return fmt.Errorf("%s: at least one project directory is required", key)
+		}
+		if c.ServeAccess == nil {
+			c.ServeAccess = make(map[string][]string)
+		}
+		c.ServeAccess[server] = projects
+		return nil
+	}
+	if server, ok := parseServerScopeKey(key); ok {
+		if value != ServerScopeProject && value != ServerScopeGlobal {
+			return fmt.Errorf("%s: must be %q or %q", key, ServerScopeProject, ServerScopeGlobal)
+		}
+		if c.ServerScopes == nil {
+			c.ServerScopes = make(map[string]string)
+		}
+		c.ServerScopes[server] = value
+		return nil
+	}
+	if agent, ok := parseProjectionModeKey(key); ok {
+		if !slices.Contains(ProjectionModes, value) {
+			return fmt.Errorf("%s: must be one of %s", key, strings.Join(ProjectionModes, ", "))
+		}
+		if c.AgentProjectionModes == nil {
+			c.AgentProjectionModes = make(map[string]string)
+		}
+		c.AgentProjectionModes[agent] = value
+		return nil
+	}
+	if agent, ok := parseMCPScopeKey(key); ok {
+		if value != ServerScopeProject && value != ServerScopeGlobal {
+			return fmt.Errorf("%s: must be %q or %q", key, ServerScopeProject, ServerScopeGlobal)
+		}
+		if c.MCPScopes == nil {
+			c.MCPScopes = make(map[string]string)
+		}
+		c.MCPScopes[agent] = value
+		return nil
+	}
+	if agent, transport, ok := parseMCPTypeKey(key); ok {
+		if value == "" {
+			return fmt.Errorf("%s: type is required", key)
+		}
+		if c.MCPTypes == nil {
+			c.MCPTypes = make(map[string]map[string]string)
+		}
+		if c.MCPTypes[agent] == nil {
+			c.MCPTypes[agent] = make(map[string]string)
+		}
+		c.MCPTypes[agent][transport] = value
+		return nil
+	}
+	if agent, ok := parseMCPCommandKey(key); ok {
+		if !slices.Contains(MCPCommandStyles, value) {
+			return fmt.Errorf("%s: must be one of %s", key, strings.Join(MCPCommandStyles, ", "))
+		}
+		if c.MCPCommands == nil {
+			c.MCPCommands = make(map[string]string)
+		}
+		c.MCPCommands[agent] = value
+		return nil
+	}
+	if agent, field, ok := parseProjectorKey(key); ok {
+		if value == "" {
+			return fmt.Errorf("%s: value is required", key)
+		}
+		pc := c.Projectors[agent]
+		switch field {
+		case "mcp_config":
+			pc.MCPConfig = value
+		case "format":
+			if !slices.Contains(ProjectorFormats, value) {
+				return fmt.Errorf("%s: must be one of %s", key, strings.Join(ProjectorFormats, ", "))
+			}
+			pc.Format = value
+		case "mcp_key":
+			pc.MCPKey = value
+		default:
+			pc.SkillsDir = value
+		}
+		if c.Projectors == nil {
+			c.Projectors = make(map[string]ProjectorConfig)
+		}
+		c.Projectors[agent] = pc
+		return nil
+	}
+
+	name, field, err := parseRegistryKey(key)
+	if err != nil {
+		return err
+	}
+	reg := c.Registries[name]
+	switch field {
+	case "type":
+		if !slices.Contains(RegistryTypes, value) {
+			return fmt.Errorf("%s: must be one of %s", key, strings.Join(RegistryTypes, ", "))
+		}
+		reg.Type = value
+	case "url":
+		if value == "" {
+			return fmt.Errorf("%s: URL is required", key)
+		}
+		reg.URL = value
+	case "username":
+		reg.Username = value
+	default:
+		reg.TokenEnv = value
+	}
+
+	if c.Registries == nil {
+		c.Registries = make(map[string]RegistryConfig)
+	}
+	c.Registries[name] = reg
+	return nil
+}
+
+// Unset clears key. "registries.<name>" removes the whole registry.
+func (c *DevConfig) Unset(key string) error {
+	switch key {
+	case KeyAgents:
+		c.Agents = nil
+		return nil
+	case KeyEnvSet:
+		c.EnvSet = ""
+		return nil
+	case KeyStorePath:
+		c.StorePath = ""
+		return nil
+	case KeyFetchTimeout:
+		c.FetchTimeout = ""
+		return nil
+	case KeyProjection:
+		c.Projection = ""
+		return nil
+	case KeyProjectionMode:
+		c.ProjectionMode = ""
+		return nil
+	case KeyServeSocketAgents:
+		c.ServeSocketAgents = nil
+		return nil
+	case KeyPolicyHook:
+		c.PolicyHook = ""
+		return nil
+	}
+	if server, ok := parseServeAccessKey(key); ok {
+		delete(c.ServeAccess, server)
+		return nil
+	}
+	if server, ok := parseServerScopeKey(key); ok {
+		delete(c.ServerScopes, server)
+		return nil
+	}
+	if agent, ok := parseProjectionModeKey(key); ok {
+		delete(c.AgentProjectionModes, agent)
+		return nil
+	}
+	if agent, ok := parseMCPScopeKey(key); ok {
+		delete(c.MCPScopes, agent)
+		return nil
+	}
+	if agent, transport, ok := parseMCPTypeKey(key); ok {
+		delete(c.MCPTypes[agent], transport)
+		if len(c.MCPTypes[agent]) == 0 {
+			delete(c.MCPTypes, agent)
+		}
+		return nil
+	}
+	if agent, ok := parseMCPCommandKey(key); ok {
+		delete(c.MCPCommands, agent)
+		return nil
+	}
+	if agent, ok := strings.CutPrefix(key, KeyProjectors+"."); ok && agent != "" && !strings.Contains(agent, ".") {
+		delete(c.Projectors, agent)
+		return nil
+	}
+	if agent, field, ok := parseProjectorKey(key); ok {
+		pc, ok := c.Projectors[agent]
+		if !ok {
+			return nil
+		}
+		switch field {
+		case "mcp_config":
+			pc.MCPConfig = ""
+		case "format":
+			pc.Format = ""
+		case "mcp_key":
+			pc.MCPKey = ""
+		default:
+			pc.SkillsDir = ""
+		}
+		c.Projectors[agent] = pc
+		return nil
+	}
+
+	if name, ok := strings.CutPrefix(key, KeyRegistries+"."); ok && name != "" && !strings.Contains(name, ".") {
+		delete(c.Registries, name)
+		return nil
+	}
+
+	name, field, err := parseRegistryKey(key)
+	if err != nil {
+		return err
+	}
+	reg, ok := c.Registries[name]
+	if !ok {
+		return nil
+	}
+	switch field {
+	case "type":
+		reg.Type = ""
+	case "url":
+		reg.URL = ""
+	case "username":
+		reg.Username = ""
+	default:
+		reg.TokenEnv = ""
+	}
+	c.Registries[name] = reg
+	return nil
+}
+
+// parseAgentList splits a comma-separated list of agents, dropping blanks
+// and duplicates.
+func parseAgentList(key, value string) ([]string, error) {
+	var agents []string
+	for _, agent := range strings.Split(value, ",") {
+		if agent = strings.TrimSpace(agent); agent != "" && !slices.Contains(agents, agent) {
+			agents = append(agents, agent)
+		}
+	}
+	if len(agents) == 0 {
+		return nil, fmt.Errorf("%s: at least one agent is required", key)
+	}
+	return agents, nil
+}
+
+// parseRegistryKey splits "registries.<name>.<field>".
+func parseRegistryKey(key string) (name, field string, err error) {
+	rest, ok := strings.CutPrefix(key, KeyRegistries+".")
+	if ok {
+		if idx := strings.LastIndex(rest, "."); idx > 0 {
+			name, field = rest[:idx], rest[idx+1:]
+		}
+	}
+	if name == "" || !slices.Contains(RegistryFields, field) {
+		return "", "", fmt.Errorf("unknown config key %q (want %s, %s, %s, %s, %s, %s, %s, %s, %s.<server>, %s.<server>, %s.<agent>, %s.<agent>, %s.<agent>.<%s>, %s.<agent>, %s.<agent>.<%s>, or %s.<name>.<%s>)",
+			key, KeyAgents, KeyEnvSet, KeyStorePath, KeyFetchTimeout, KeyProjection, KeyProjectionMode, KeyServeSocketAgents, KeyPolicyHook, KeyServeAccess, KeyServerScopes, KeyProjectionModes, KeyMCPScopes, KeyMCPTypes, strings.Join(Transports, "|"), KeyMCPCommands, KeyProjectors, strings.Join(ProjectorFields, "|"), KeyRegistries, strings.Join(RegistryFields, "|"))
+	}
+	return name, field, nil
+}
+
+// parseServeAccessKey returns the server of "serve_access.<server>".
+func parseServeAccessKey(key string) (string, bool) {
+	server, ok := strings.CutPrefix(key, KeyServeAccess+".")
+	return server, ok && server != ""
+}
+
+// parseServerScopeKey returns the server of "server_scopes.<server>".
+func parseServerScopeKey(key string) (string, bool) {
+	server, ok := strings.CutPrefix(key, KeyServerScopes+".")
+	return server, ok && server != ""
+}
+
+// parseProjectionModeKey returns the agent of "projection_modes.<agent>".
+func parseProjectionModeKey(key string) (string, bool) {
+	agent, ok := strings.CutPrefix(key, KeyProjectionModes+".")
+	return agent, ok && agent != ""
+}
+
+// parseMCPScopeKey returns the agent of "mcp_scopes.<agent>".
+func parseMCPScopeKey(key string) (string, bool) {
+	agent, ok := strings.CutPrefix(key, KeyMCPScopes+".")
+	return agent, ok && agent != ""
+}
+
+// parseMCPTypeKey returns the agent and transport of
+// "mcp_types.<agent>.<transport>".
+func parseMCPTypeKey(key string) (agent, transport string, ok bool) {
+	rest, ok := strings.CutPrefix(key, KeyMCPTypes+".")
+	if !ok {
+		return "", "", false
+	}
+	agent, transport, _ = strings.Cut(rest, ".")
+	return agent, transport, agent != "" && slices.Contains(Transports, transport)
+}
+
+// parseMCPCommandKey returns the agent of "mcp_commands.<agent>".
+func parseMCPCommandKey(key string) (string, bool) {
+	agent, ok := strings.CutPrefix(key, KeyMCPCommands+".")
+	return agent, ok && agent != ""
+}
+
+// parseProjectorKey returns the agent and field of
+// "projectors.<agent>.<field>".
+func parseProjectorKey(key string) (agent, field string, ok bool) {
+	rest, ok := strings.CutPrefix(key, KeyProjectors+".")
+	if !ok {
+		return "", "", false
+	}
+	agent, field, _ = strings.Cut(rest, ".")
+	return agent, field, agent != "" && slices.Contains(ProjectorFields, field)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
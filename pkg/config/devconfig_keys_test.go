@@ -0,0 +1,105 @@
+package config
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestDevConfigSet(t *testing.T) {
+	tests := map[string]struct {
+		key     string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		"agents":               {key: KeyAgents, value: "cursor, claude-code,cursor", want: "cursor,claude-code"},
+		"empty agents":         {key: KeyAgents, value: " , ", wantErr: true},
+		"env set":              {key: KeyEnvSet, value: "prod", want: "prod"},
+		"absolute store path":  {key: KeyStorePath, value: "/data/apkg", want: filepath.FromSlash("/data/apkg")},
+		"fetch timeout":        {key: KeyFetchTimeout, value: "90s", want: "90s"},
+		"bad fetch timeout":    {key: KeyFetchTimeout, value: "soon", wantErr: true},
+		"zero fetch timeout":   {key: KeyFetchTimeout, value: "0s", wantErr: true},
+		"projection":           {key: KeyProjection, value: "relative", want: "relative"},
+		"bad projection":       {key: KeyProjection, value: "hardlink", wantErr: true},
+		"projection mode":      {key: KeyProjectionMode, value: "copy", want: "copy"},
+		"bad projection mode":  {key: KeyProjectionMode, value: "junction", wantErr: true},
+		"per-agent mode":       {key: "projection_modes.cursor", value: "hardlink", want: "hardlink"},
+		"registry type":        {key: "registries.team.type", value: "oci", want: "oci"},
+		"bad registry type":    {key: "registries.team.type", value: "s3", wantErr: true},
+		"registry url":         {key: "registries.team.url", value: "ghcr.io/org/apkg", want: "ghcr.io/org/apkg"},
+		"empty registry url":   {key: "registries.team.url", wantErr: true},
+		"registry token env":   {key: "registries.team.token_env", value: "TEAM_TOKEN", want: "TEAM_TOKEN"},
+		"unknown field":        {key: "registries.team.password", value: "x", wantErr: true},
+		"serve socket agents":  {key: KeyServeSocketAgents, value: "claude-code, claude-code", want: "claude-code"},
+		"serve access":         {key: "serve_access.db", value: "/work/a, /work/b,/work/a", want: filepath.FromSlash("/work/a") + "," + filepath.FromSlash("/work/b")},
+		"global serve access":  {key: "serve_access.db", value: "/work/a,global", want: filepath.FromSlash("/work/a") + ",global"},
+		"empty serve access":   {key: "serve_access.db", value: " ", wantErr: true},
+		"server scope":         {key: "server_scopes.github", value: "global", want: "global"},
+		"bad server scope":     {key: "server_scopes.github", value: "user", wantErr: true},
+		"mcp scope":            {key: "mcp_scopes.claude-code", value: "global", want: "global"},
+		"bad mcp scope":        {key: "mcp_scopes.cursor", value: "local", wantErr: true},
+		"mcp command":          {key: "mcp_commands.cursor", value: "path", want: "path"},
+		"bad mcp command":      {key: "mcp_commands.cursor", value: "npx", wantErr: true},
+		"mcp type":             {key: "mcp_types.cursor.http", value: "streamable-http", want: "streamable-http"},
+		"empty mcp type":       {key: "mcp_types.cursor.sse", wantErr: true},
+		"bad mcp transport":    {key: "mcp_types.cursor.websocket", value: "ws", wantErr: true},
+		"projector mcp config": {key: "projectors.zed.mcp_config", value: ".zed/settings.json", want: ".zed/settings.json"},
+		"projector format":     {key: "projectors.zed.format", value: "toml", want: "toml"},
+		"bad projector format": {key: "projectors.zed.format", value: "ini", wantErr: true},
+		"empty projector key":  {key: "projectors.zed.mcp_key", wantErr: true},
+		"unknown key":          {key: "telemetry", value: "off", wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := &DevConfig{}
+			err := cfg.Set(tc.key, tc.value)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Set(%q, %q) error = %v, wantErr %v", tc.key, tc.value, err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+
+			got, err := cfg.Get(tc.key)
+			if err != nil {
+				t.Fatalf("Get(%q) error = %v", tc.key, err)
+			}
+			if got != tc.want {
+				t.Errorf("Get(%q) = %q, want %q", tc.key, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDevConfigKeysAndUnset(t *testing.T) {
+	cfg := &DevConfig{
+		Agents: []string{"cursor"},
+		Registries: map[string]RegistryConfig{
+			"team": {Type: "git", URL: "https://example.com/index.git"},
+			"oci":  {Type: "oci", URL: "ghcr.io/org/apkg", Username: "bot"},
+		},
+		ServeAccess: map[string][]string{"db": {"/work/a"}},
+		MCPTypes:    map[string]map[string]string{"gemini": {"sse": "sse", "http": "http"}},
+	}
+
+	want := []string{"agents", "registries.oci.type", "registries.oci.url", "registries.oci.username", "registries.team.type", "registries.team.url", "serve_access.db", "mcp_types.gemini.http", "mcp_types.gemini.sse"}
+	if got := cfg.Keys(); !slices.Equal(got, want) {
+		t.Errorf("Keys() = %v, want %v", got, want)
+	}
+
+	for _, key := range []string{"agents", "registries.oci.username", "registries.team", "serve_access.db", "mcp_types.gemini.http", "mcp_types.gemini.sse"} {
+		if err := cfg.Unset(key); err != nil {
+			t.Fatalf("Unset(%q) error = %v", key, err)
+		}
+	}
+
+	want = []string{"registries.oci.type", "registries.oci.url"}
+	if got := cfg.Keys(); !slices.Equal(got, want) {
+		t.Errorf("Keys() after Unset = %v, want %v", got, want)
+	}
+	if cfg.MCPTypes["gemini"] != nil {
+		t.Errorf("MCPTypes = %v, want gemini removed with its last type", cfg.MCPTypes)
+	}
+}
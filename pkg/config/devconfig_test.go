@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -82,6 +83,33 @@ func TestLoadDevConfig(t *testing.T) {
 	}
 }
 
+func TestLoadDevConfigProjectors(t *testing.T) {
+	dir := t.TempDir()
+	globalPath := filepath.Join(dir, "global-config.toml")
+	localPath := filepath.Join(dir, "apkg.local.toml")
+	os.WriteFile(globalPath, []byte(`
+[projectors.zed]
+mcp_config = ".zed/settings.json"
+mcp_key = "context_servers"
+skills_dir = ".zed/skills"
+`), 0o644)
+
+	cfg, err := loadDevConfig(nil, false, globalPath, localPath)
+	if err != nil {
+		t.Fatalf("loadDevConfig() error = %v", err)
+	}
+	want := ProjectorConfig{MCPConfig: ".zed/settings.json", MCPKey: "context_servers", SkillsDir: ".zed/skills"}
+	if got := cfg.Projectors["zed"]; got != want {
+		t.Errorf("Projectors[zed] = %+v, want %+v", got, want)
+	}
+
+	// A projector must project something.
+	os.WriteFile(localPath, []byte("[projectors.empty]\nformat = \"yaml\"\n"), 0o644)
+	if _, err := loadDevConfig(nil, false, globalPath, localPath); err == nil {
+		t.Error("loadDevConfig() accepted a projector without mcp_config or skills_dir")
+	}
+}
+
 func writeTestConfig(t *testing.T, path string, agents []string) {
 	t.Helper()
 	f, err := os.Create(path)
@@ -114,3 +142,52 @@ func slicesEqual(a, b []string) bool {
 	}
 	return true
 }
+
+func TestUpdateDevConfigFile(t *testing.T) {
+	tests := map[string]struct {
+		existing string
+		agents   []string
+		want     DevConfig
+	}{
+		"missing file": {
+			agents: []string{"cursor"},
+			want:   DevConfig{Agents: []string{"cursor"}},
+		},
+		"preserves other keys": {
+			existing: "agents = [\"claude-code\"]\nenv_set = \"dev\"\n\n[registries.team]\ntype = \"git\"\nurl = \"https://example.com/index.git\"\n",
+			agents:   []string{"cursor", "gemini"},
+			want: DevConfig{
+				Agents:     []string{"cursor", "gemini"},
+				EnvSet:     "dev",
+				Registries: map[string]RegistryConfig{"team": {Type: "git", URL: "https://example.com/index.git"}},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), LocalConfigFile)
+			if tc.existing != "" {
+				if err := os.WriteFile(path, []byte(tc.existing), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := UpdateDevConfigFile(path, func(cfg *DevConfig) error {
+				cfg.Agents = tc.agents
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("UpdateDevConfigFile() error = %v", err)
+			}
+
+			got, err := loadDevConfig(nil, false, filepath.Join(t.TempDir(), "none.toml"), path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*got, tc.want) {
+				t.Errorf("config = %+v, want %+v", *got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// WithEnvSet returns a copy of the server config with the named env set
+// applied. The returned config has no EnvSets of its own. ok is false (and
+// the base config is returned) when the server doesn't define the set.
+func (m MCPSource) WithEnvSet(name string) (resolved MCPSource, ok bool, err error) {
+	set, ok := m.EnvSets[name]
+	resolved = m.clone()
+	resolved.EnvSets = nil
+	if name == "" || !ok {
+		return resolved, false, nil
+	}
+
+	if set.URL != "" {
+		if resolved.ExternalHttpMCPConfig == nil {
+			return MCPSource{}, false, fmt.Errorf("env set %q sets url, but the server is not a remote http server", name)
+		}
+		resolved.URL = set.URL
+	}
+
+	if len(set.Env) > 0 || len(set.Args) > 0 {
+		if resolved.ExternalHttpMCPConfig != nil {
+			return MCPSource{}, false, fmt.Errorf("env set %q sets env or args, but the server is a remote http server", name)
+		}
+		if resolved.LocalMCPConfig == nil {
+			resolved.LocalMCPConfig = &LocalMCPConfig{}
+		}
+		if len(set.Env) > 0 {
+			if resolved.Env == nil {
+				resolved.Env = map[string]string{}
+			}
+			maps.Copy(resolved.Env, set.Env)
+		}
+		if len(set.Args) > 0 {
+			resolved.Args = slices.Clone(set.Args)
+		}
+	}
+
+	if len(set.Headers) > 0 {
+		if resolved.Transport != "http" {
+			return MCPSource{}, false, fmt.Errorf("env set %q sets headers, but the server uses %q transport", name, resolved.Transport)
+		}
+		if resolved.HttpMCPConfig == nil {
+			resolved.HttpMCPConfig = &HttpMCPConfig{}
+		}
+		if resolved.Headers == nil {
+			resolved.Headers = map[string]string{}
+		}
+		maps.Copy(resolved.Headers, set.Headers)
+	}
+
+	return resolved, true, nil
+}
+
+// clone returns a copy of m whose overridable sections don't share memory
+// with m, so applying an env set never mutates the loaded manifest.
+func (m MCPSource) clone() MCPSource {
+	c := m
+	if m.ExternalHttpMCPConfig != nil {
+		ext := *m.ExternalHttpMCPConfig
+		c.ExternalHttpMCPConfig = &ext
+	}
+	if m.LocalMCPConfig != nil {
+		local := LocalMCPConfig{Env: maps.Clone(m.Env), Args: slices.Clone(m.Args)}
+		c.LocalMCPConfig = &local
+	}
+	if m.HttpMCPConfig != nil {
+		c.HttpMCPConfig = &HttpMCPConfig{Headers: maps.Clone(m.Headers)}
+	}
+	return c
+}
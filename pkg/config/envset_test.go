@@ -0,0 +1,119 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+func TestWithEnvSet(t *testing.T) {
+	manifest := `
+[mcpServers.api]
+transport = "http"
+url = "https://api.example.com/mcp"
+headers = { "X-Team" = "core" }
+
+[mcpServers.api.env_sets.staging]
+url = "https://staging.example.com/mcp"
+headers = { "X-Env" = "staging" }
+
+[mcpServers.tool]
+transport = "stdio"
+command = "/usr/bin/tool"
+env = { LOG_LEVEL = "info", BACKEND = "prod" }
+
+[mcpServers.tool.env_sets.staging]
+env = { BACKEND = "staging" }
+args = ["--verbose"]
+
+[mcpServers.tool.env_sets.broken]
+url = "https://example.com"
+`
+	cfg, err := UnmarshalConfig([]byte(manifest))
+	if err != nil {
+		t.Fatalf("UnmarshalConfig() error = %v", err)
+	}
+
+	tests := map[string]struct {
+		server      string
+		set         string
+		wantApplied bool
+		wantErr     bool
+		check       func(t *testing.T, ms MCPSource)
+	}{
+		"no env set keeps base config": {
+			server: "tool",
+			check: func(t *testing.T, ms MCPSource) {
+				if ms.Env["BACKEND"] != "prod" {
+					t.Errorf("BACKEND = %q, want %q", ms.Env["BACKEND"], "prod")
+				}
+			},
+		},
+		"undefined env set keeps base config": {
+			server: "api",
+			set:    "prod",
+			check: func(t *testing.T, ms MCPSource) {
+				if ms.URL != "https://api.example.com/mcp" {
+					t.Errorf("URL = %q, want base url", ms.URL)
+				}
+			},
+		},
+		"http overlay replaces url and merges headers": {
+			server:      "api",
+			set:         "staging",
+			wantApplied: true,
+			check: func(t *testing.T, ms MCPSource) {
+				if ms.URL != "https://staging.example.com/mcp" {
+					t.Errorf("URL = %q, want staging url", ms.URL)
+				}
+				if ms.Headers["X-Team"] != "core" || ms.Headers["X-Env"] != "staging" {
+					t.Errorf("Headers = %v, want merged headers", ms.Headers)
+				}
+			},
+		},
+		"stdio overlay merges env and replaces args": {
+			server:      "tool",
+			set:         "staging",
+			wantApplied: true,
+			check: func(t *testing.T, ms MCPSource) {
+				if ms.Env["BACKEND"] != "staging" || ms.Env["LOG_LEVEL"] != "info" {
+					t.Errorf("Env = %v, want merged env", ms.Env)
+				}
+				if len(ms.Args) != 1 || ms.Args[0] != "--verbose" {
+					t.Errorf("Args = %v, want [--verbose]", ms.Args)
+				}
+			},
+		},
+		"url on local server": {
+			server:  "tool",
+			set:     "broken",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			base := cfg.MCPServers[tc.server]
+			before, _ := toml.Marshal(base)
+
+			got, applied, err := base.WithEnvSet(tc.set)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("WithEnvSet() error = %v, wantErr = %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if applied != tc.wantApplied {
+				t.Errorf("WithEnvSet() applied = %v, want %v", applied, tc.wantApplied)
+			}
+			if got.EnvSets != nil {
+				t.Error("resolved config still has env sets")
+			}
+			tc.check(t, got)
+
+			if after, _ := toml.Marshal(base); string(after) != string(before) {
+				t.Errorf("WithEnvSet() mutated the base config:\n%s\nwant:\n%s", after, before)
+			}
+		})
+	}
+}
@@ -11,9 +11,14 @@ import (
 const LockFileName = "apkg-lock.toml"
 
 type LockFile struct {
-	Version    int              `toml:"version" comment:"Auto-generated by apkg. Do not edit."`
-	Skills     []SkillLockEntry `toml:"skills"`
-	MCPServers []MCPLockEntry   `toml:"mcp_servers,omitempty"`
+	Version    int                `toml:"version" comment:"Auto-generated by apkg. Do not edit."`
+	Skills     []SkillLockEntry   `toml:"skills"`
+	MCPServers []MCPLockEntry     `toml:"mcp_servers,omitempty"`
+	Runtimes   []RuntimeLockEntry `toml:"runtimes,omitempty"`
+
+	// Dir is the directory the lockfile was loaded from, which relative
+	// local skill paths are relative to; empty if it wasn't loaded.
+	Dir string `toml:"-"`
 }
 
 type SkillLockEntry struct {
@@ -23,6 +28,20 @@ type SkillLockEntry struct {
 	Ref       string `toml:"ref,omitempty"`
 	Commit    string `toml:"commit,omitempty"`
 	Integrity string `toml:"integrity,omitempty"`
+
+	// URL and SHA256 are the archive of a skill downloaded from a URL and
+	// its digest (see SkillSource.URL).
+	URL    string `toml:"url,omitempty"`
+	SHA256 string `toml:"sha256,omitempty"`
+}
+
+// Remote returns the git repository or archive URL the skill was fetched
+// from, or "" for a local skill.
+func (e SkillLockEntry) Remote() string {
+	if e.Git != "" {
+		return e.Git
+	}
+	return e.URL
 }
 
 type MCPLockEntry struct {
@@ -38,12 +57,33 @@ type MCPLockEntry struct {
 	Args       []string `toml:"args,omitempty"`
 	EnvKeys    []string `toml:"env_keys,omitempty"`    // keys only, not values (security)
 	HeaderKeys []string `toml:"header_keys,omitempty"` // keys only
+	EnvSet     string   `toml:"env_set,omitempty"`     // selected env set, if the server defines it
+	// ConfigDigest is the SHA256 of the env and header values, which
+	// aren't locked verbatim since they may hold secrets.
+	ConfigDigest string `toml:"config_digest,omitempty"`
 
 	// Resolved fields (for reproducibility)
 	ResolvedVersion string `toml:"resolved_version,omitempty"` // npm/uv resolved version
 	InstallPath     string `toml:"install_path,omitempty"`     // relative to store root
 	Digest          string `toml:"digest,omitempty"`           // container image digest
+	ManifestDigest  string `toml:"manifest_digest,omitempty"`  // registry manifest digest of the container image
 	Integrity       string `toml:"integrity,omitempty"`        // SHA256 of installed content
+
+	// Runtime and Volumes are the node binary of an npm package and the
+	// volumes of a container, with absolute host paths, as this project
+	// runs them. Projects share store entries, so these are kept here.
+	Runtime string   `toml:"runtime,omitempty"`
+	Volumes []string `toml:"volumes,omitempty"`
+}
+
+// RuntimeLockEntry locks a runtime pinned in the manifest (see
+// RuntimesConfig) to the release it resolved to.
+type RuntimeLockEntry struct {
+	Name    string `toml:"name"`
+	Version string `toml:"version"` // config mirror: the version pinned in the manifest
+
+	ResolvedVersion string `toml:"resolved_version"`
+	Integrity       string `toml:"integrity,omitempty"` // checksum of the downloaded build
 }
 
 func ReadLockFile(data []byte) (*LockFile, error) {
@@ -61,11 +101,16 @@ func LoadLockFile(path string) (*LockFile, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return &LockFile{Version: 1}, nil
+			return &LockFile{Version: LockFileVersion, Dir: filepath.Dir(path)}, nil
 		}
 		return nil, fmt.Errorf("reading %s: %w", path, err)
 	}
-	return ReadLockFile(data)
+	lf, err := ReadLockFile(data)
+	if err != nil {
+		return nil, err
+	}
+	lf.Dir = filepath.Dir(path)
+	return lf, nil
 }
 
 func SaveLockFile(path string, lf *LockFile) error {
@@ -76,12 +121,13 @@ func SaveLockFile(path string, lf *LockFile) error {
 	return os.WriteFile(path, data, 0o644)
 }
 
-// GlobalLockFilePath returns the path to the global lockfile (~/.apkg/apkg-lock.toml),
-// ensuring the directory exists.
+// GlobalLockFilePath returns the path to the global lockfile, next to the
+// global manifest (~/.apkg/apkg-lock.toml by default), ensuring the
+// directory exists.
 func GlobalLockFilePath() (string, error) {
-	dir, err := GlobalConfigDir()
+	manifest, err := GlobalManifestPath()
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(dir, LockFileName), nil
+	return filepath.Join(filepath.Dir(manifest), LockFileName), nil
 }
@@ -0,0 +1,372 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	conflictOursMarker   = "<<<<<<<"
+	conflictBaseMarker   = "|||||||"
+	conflictSepMarker    = "======="
+	conflictTheirsMarker = ">>>>>>>"
+)
+
+// HasConflictMarkers reports whether data contains git merge conflict markers.
+func HasConflictMarkers(data []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if strings.HasPrefix(scanner.Text(), conflictOursMarker) {
+			return true
+		}
+	}
+	return false
+}
+
+// SplitConflictedLockFile splits a lockfile containing git merge conflict
+// markers into the "ours" and "theirs" versions and parses both. Lines
+// outside conflict blocks are shared by both sides; the merge base section
+// of diff3-style conflicts is discarded.
+func SplitConflictedLockFile(data []byte) (ours, theirs *LockFile, err error) {
+	const (
+		stateShared = iota
+		stateOurs
+		stateBase
+		stateTheirs
+	)
+
+	var oursBuf, theirsBuf bytes.Buffer
+	state := stateShared
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+
+		switch {
+		case strings.HasPrefix(line, conflictOursMarker):
+			if state != stateShared {
+				return nil, nil, fmt.Errorf("line %d: nested conflict marker", lineNo)
+			}
+			state = stateOurs
+			continue
+		case strings.HasPrefix(line, conflictBaseMarker) && state == stateOurs:
+			state = stateBase
+			continue
+		case line == conflictSepMarker && (state == stateOurs || state == stateBase):
+			state = stateTheirs
+			continue
+		case strings.HasPrefix(line, conflictTheirsMarker) && state == stateTheirs:
+			state = stateShared
+			continue
+		}
+
+		switch state {
+		case stateShared:
+			oursBuf.WriteString(line + "\n")
+			theirsBuf.WriteString(line + "\n")
+		case stateOurs:
+			oursBuf.WriteString(line + "\n")
+		case stateTheirs:
+			theirsBuf.WriteString(line + "\n")
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("reading lockfile: %w", err)
+	}
+	if state != stateShared {
+		return nil, nil, fmt.Errorf("unterminated conflict block")
+	}
+
+	ours, err = ReadLockFile(oursBuf.Bytes())
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing our side of the conflict: %w", err)
+	}
+	theirs, err = ReadLockFile(theirsBuf.Bytes())
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing their side of the conflict: %w", err)
+	}
+	return ours, theirs, nil
+}
+
+// LockMergeResult is the outcome of reconciling two lockfiles against a
+// manifest.
+type LockMergeResult struct {
+	LockFile *LockFile
+	// Unresolved lists the skills (by source key) and MCP servers (by name)
+	// that could not be reconciled and were dropped from the lockfile so
+	// the next install re-resolves them.
+	Unresolved []string
+}
+
+// MergeLockFiles reconciles two lockfiles against the manifest. Entries no
+// longer declared in the manifest are dropped. When both sides lock the
+// same package differently, the entry matching the manifest is preferred,
+// then the higher resolved version; entries that still can't be decided
+// are dropped so the next install re-resolves them.
+func MergeLockFiles(cfg *Config, ours, theirs *LockFile) *LockMergeResult {
+	result := &LockMergeResult{LockFile: &LockFile{Version: max(ours.Version, theirs.Version, 1)}}
+
+	wantSkills := make(map[string]SkillSource, len(cfg.Skills))
+	for _, ss := range cfg.Skills {
+		wantSkills[skillSourceKey(ss)] = ss
+	}
+
+	oursSkills := indexSkillLockEntries(ours.Skills)
+	theirsSkills := indexSkillLockEntries(theirs.Skills)
+	for _, key := range sortedUnion(oursSkills, theirsSkills) {
+		ss, ok := wantSkills[key]
+		if !ok {
+			continue
+		}
+		entry, ok := pickSkillLockEntry(ss, oursSkills[key], theirsSkills[key])
+		if !ok {
+			result.Unresolved = append(result.Unresolved, "skill "+key)
+			continue
+		}
+		result.LockFile.Skills = append(result.LockFile.Skills, entry)
+	}
+
+	oursMCP := indexMCPLockEntries(ours.MCPServers)
+	theirsMCP := indexMCPLockEntries(theirs.MCPServers)
+	for _, name := range sortedUnion(oursMCP, theirsMCP) {
+		ms, ok := cfg.MCPServers[name]
+		if !ok {
+			continue
+		}
+		entry, ok := pickMCPLockEntry(ms, oursMCP[name], theirsMCP[name])
+		if !ok {
+			result.Unresolved = append(result.Unresolved, "mcp "+name)
+			continue
+		}
+		result.LockFile.MCPServers = append(result.LockFile.MCPServers, entry)
+	}
+
+	wantRuntimes := cfg.Runtimes.Versions()
+	oursRuntimes := indexRuntimeLockEntries(ours.Runtimes)
+	theirsRuntimes := indexRuntimeLockEntries(theirs.Runtimes)
+	for _, name := range sortedUnion(oursRuntimes, theirsRuntimes) {
+		version, ok := wantRuntimes[name]
+		if !ok {
+			continue
+		}
+		entry, ok := pickRuntimeLockEntry(version, oursRuntimes[name], theirsRuntimes[name])
+		if !ok {
+			result.Unresolved = append(result.Unresolved, "runtime "+name)
+			continue
+		}
+		result.LockFile.Runtimes = append(result.LockFile.Runtimes, entry)
+	}
+
+	return result
+}
+
+func pickSkillLockEntry(ss SkillSource, a, b *SkillLockEntry) (SkillLockEntry, bool) {
+	switch {
+	case a == nil && b == nil:
+		return SkillLockEntry{}, false
+	case b == nil:
+		return *a, true
+	case a == nil:
+		return *b, true
+	case *a == *b:
+		return *a, true
+	}
+
+	aMatches, bMatches := a.Ref == ss.Ref, b.Ref == ss.Ref
+	switch {
+	case aMatches && !bMatches:
+		return *a, true
+	case bMatches && !aMatches:
+		return *b, true
+	}
+
+	// Either neither side locks the manifest ref, or both do at different
+	// commits. Compare the locked refs: prefer the higher one when both are
+	// version tags. Equal refs can't be decided.
+	if c, ok := CompareVersions(a.Ref, b.Ref); ok && c != 0 {
+		if c > 0 {
+			return *a, true
+		}
+		return *b, true
+	}
+	return SkillLockEntry{}, false
+}
+
+func pickMCPLockEntry(ms MCPSource, a, b *MCPLockEntry) (MCPLockEntry, bool) {
+	switch {
+	case a == nil && b == nil:
+		return MCPLockEntry{}, false
+	case b == nil:
+		return *a, true
+	case a == nil:
+		return *b, true
+	}
+
+	if ms.ManagedStdioMCPConfig != nil {
+		aMatches, bMatches := a.Package == ms.Package, b.Package == ms.Package
+		switch {
+		case aMatches && !bMatches:
+			return *a, true
+		case bMatches && !aMatches:
+			return *b, true
+		}
+	}
+
+	if a.ResolvedVersion != b.ResolvedVersion {
+		if c, ok := CompareVersions(a.ResolvedVersion, b.ResolvedVersion); ok && c != 0 {
+			if c > 0 {
+				return *a, true
+			}
+			return *b, true
+		}
+		return MCPLockEntry{}, false
+	}
+
+	if a.Integrity != b.Integrity || a.Digest != b.Digest {
+		return MCPLockEntry{}, false
+	}
+	return *a, true
+}
+
+func pickRuntimeLockEntry(version string, a, b *RuntimeLockEntry) (RuntimeLockEntry, bool) {
+	switch {
+	case a == nil && b == nil:
+		return RuntimeLockEntry{}, false
+	case b == nil:
+		return *a, true
+	case a == nil:
+		return *b, true
+	case *a == *b:
+		return *a, true
+	}
+
+	aMatches, bMatches := a.Version == version, b.Version == version
+	switch {
+	case aMatches && !bMatches:
+		return *a, true
+	case bMatches && !aMatches:
+		return *b, true
+	}
+
+	if c, ok := CompareVersions(a.ResolvedVersion, b.ResolvedVersion); ok && c != 0 {
+		if c > 0 {
+			return *a, true
+		}
+		return *b, true
+	}
+	return RuntimeLockEntry{}, false
+}
+
+// CompareVersions compares two dotted numeric versions (an optional leading
+// "v" and any pre-release/build suffix are ignored). It returns -1, 0, or 1
+// and ok=false when either version is not numeric.
+func CompareVersions(a, b string) (int, bool) {
+	pa, ok := parseVersion(a)
+	if !ok {
+		return 0, false
+	}
+	pb, ok := parseVersion(b)
+	if !ok {
+		return 0, false
+	}
+
+	for i := 0; i < max(len(pa), len(pb)); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+	}
+	return 0, true
+}
+
+func parseVersion(v string) ([]int, bool) {
+	v = strings.TrimPrefix(v, "v")
+	if idx := strings.IndexAny(v, "-+"); idx >= 0 {
+		v = v[:idx]
+	}
+	if v == "" {
+		return nil, false
+	}
+
+	parts := strings.Split(v, ".")
+	nums := make([]int, len(parts))
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, false
+		}
+		nums[i] = n
+	}
+	return nums, true
+}
+
+// skillSourceKey identifies a skill by git or archive URL + path (for
+// remote sources) or path alone (for local sources), matching how lock
+// entries are keyed.
+func skillSourceKey(ss SkillSource) string {
+	if remote := ss.Remote(); remote != "" {
+		return remote + "|" + ss.Path
+	}
+	return ss.Path
+}
+
+func skillLockEntryKey(e SkillLockEntry) string {
+	if remote := e.Remote(); remote != "" {
+		return remote + "|" + e.Path
+	}
+	return e.Path
+}
+
+func indexSkillLockEntries(entries []SkillLockEntry) map[string]*SkillLockEntry {
+	idx := make(map[string]*SkillLockEntry, len(entries))
+	for i := range entries {
+		idx[skillLockEntryKey(entries[i])] = &entries[i]
+	}
+	return idx
+}
+
+func indexMCPLockEntries(entries []MCPLockEntry) map[string]*MCPLockEntry {
+	idx := make(map[string]*MCPLockEntry, len(entries))
+	for i := range entries {
+		idx[entries[i].Name] = &entries[i]
+	}
+	return idx
+}
+
+func indexRuntimeLockEntries(entries []RuntimeLockEntry) map[string]*RuntimeLockEntry {
+	idx := make(map[string]*RuntimeLockEntry, len(entries))
+	for i := range entries {
+		idx[entries[i].Name] = &entries[i]
+	}
+	return idx
+}
+
+func sortedUnion[V any](a, b map[string]V) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	var keys []string
+	for _, m := range []map[string]V{a, b} {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
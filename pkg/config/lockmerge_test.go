@@ -0,0 +1,187 @@
+package config
+
+import (
+	"testing"
+)
+
+const conflictedLock = `version = 1
+
+[[skills]]
+name = 'pdf'
+git = 'https://github.com/org/skills.git'
+path = 'pdf'
+<<<<<<< HEAD
+ref = 'v1.2.0'
+commit = 'aaaa'
+||||||| base
+ref = 'v1.0.0'
+commit = 'base'
+=======
+ref = 'v1.1.0'
+commit = 'bbbb'
+>>>>>>> feature
+`
+
+func TestSplitConflictedLockFile(t *testing.T) {
+	tests := map[string]struct {
+		data       string
+		wantOurs   string
+		wantTheirs string
+		wantErr    bool
+	}{
+		"diff3 conflict": {
+			data:       conflictedLock,
+			wantOurs:   "aaaa",
+			wantTheirs: "bbbb",
+		},
+		"no conflict": {
+			data:       "version = 1\n[[skills]]\nname = 'x'\npath = './x'\ncommit = 'cccc'\n",
+			wantOurs:   "cccc",
+			wantTheirs: "cccc",
+		},
+		"unterminated conflict": {
+			data:    "version = 1\n<<<<<<< HEAD\n",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ours, theirs, err := SplitConflictedLockFile([]byte(tc.data))
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("SplitConflictedLockFile() error = %v, wantErr = %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if got := ours.Skills[0].Commit; got != tc.wantOurs {
+				t.Errorf("ours commit = %q, want %q", got, tc.wantOurs)
+			}
+			if got := theirs.Skills[0].Commit; got != tc.wantTheirs {
+				t.Errorf("theirs commit = %q, want %q", got, tc.wantTheirs)
+			}
+		})
+	}
+}
+
+func TestMergeLockFiles(t *testing.T) {
+	const gitURL = "https://github.com/org/skills.git"
+
+	tests := map[string]struct {
+		cfg            *Config
+		ours           *LockFile
+		theirs         *LockFile
+		wantCommit     string
+		wantVersion    string
+		wantRuntime    string
+		wantUnresolved int
+	}{
+		"prefers entry matching manifest ref": {
+			cfg: &Config{Skills: map[string]SkillSource{"pdf": {Git: gitURL, Path: "pdf", Ref: "v1.1.0"}}},
+			ours: &LockFile{Skills: []SkillLockEntry{
+				{Git: gitURL, Path: "pdf", Ref: "v1.2.0", Commit: "aaaa"},
+			}},
+			theirs: &LockFile{Skills: []SkillLockEntry{
+				{Git: gitURL, Path: "pdf", Ref: "v1.1.0", Commit: "bbbb"},
+			}},
+			wantCommit: "bbbb",
+		},
+		"same ref different commit is unresolved": {
+			cfg: &Config{Skills: map[string]SkillSource{"pdf": {Git: gitURL, Path: "pdf", Ref: "main"}}},
+			ours: &LockFile{Skills: []SkillLockEntry{
+				{Git: gitURL, Path: "pdf", Ref: "main", Commit: "aaaa"},
+			}},
+			theirs: &LockFile{Skills: []SkillLockEntry{
+				{Git: gitURL, Path: "pdf", Ref: "main", Commit: "bbbb"},
+			}},
+			wantUnresolved: 1,
+		},
+		"drops entries removed from manifest": {
+			cfg: &Config{},
+			ours: &LockFile{Skills: []SkillLockEntry{
+				{Git: gitURL, Path: "pdf", Ref: "main", Commit: "aaaa"},
+			}},
+			theirs: &LockFile{},
+		},
+		"prefers higher mcp resolved version": {
+			cfg: &Config{MCPServers: map[string]MCPSource{"fs": {
+				Transport:             "stdio",
+				ManagedStdioMCPConfig: &ManagedStdioMCPConfig{Package: "npm:server-fs"},
+			}}},
+			ours:        &LockFile{MCPServers: []MCPLockEntry{{Name: "fs", Package: "npm:server-fs", ResolvedVersion: "1.10.0"}}},
+			theirs:      &LockFile{MCPServers: []MCPLockEntry{{Name: "fs", Package: "npm:server-fs", ResolvedVersion: "1.9.3"}}},
+			wantVersion: "1.10.0",
+		},
+		"prefers runtime matching manifest version": {
+			cfg:         &Config{Runtimes: &RuntimesConfig{Node: "22"}},
+			ours:        &LockFile{Runtimes: []RuntimeLockEntry{{Name: "node", Version: "20", ResolvedVersion: "20.18.0"}}},
+			theirs:      &LockFile{Runtimes: []RuntimeLockEntry{{Name: "node", Version: "22", ResolvedVersion: "22.11.0"}}},
+			wantRuntime: "22.11.0",
+		},
+		"drops runtimes no longer pinned": {
+			cfg:    &Config{},
+			ours:   &LockFile{Runtimes: []RuntimeLockEntry{{Name: "node", Version: "22", ResolvedVersion: "22.11.0"}}},
+			theirs: &LockFile{},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			result := MergeLockFiles(tc.cfg, tc.ours, tc.theirs)
+
+			if len(result.Unresolved) != tc.wantUnresolved {
+				t.Errorf("Unresolved = %v, want %d entries", result.Unresolved, tc.wantUnresolved)
+			}
+
+			var gotCommit string
+			if len(result.LockFile.Skills) > 0 {
+				gotCommit = result.LockFile.Skills[0].Commit
+			}
+			if gotCommit != tc.wantCommit {
+				t.Errorf("skill commit = %q, want %q", gotCommit, tc.wantCommit)
+			}
+
+			var gotVersion string
+			if len(result.LockFile.MCPServers) > 0 {
+				gotVersion = result.LockFile.MCPServers[0].ResolvedVersion
+			}
+			if gotVersion != tc.wantVersion {
+				t.Errorf("mcp version = %q, want %q", gotVersion, tc.wantVersion)
+			}
+
+			var gotRuntime string
+			if len(result.LockFile.Runtimes) > 0 {
+				gotRuntime = result.LockFile.Runtimes[0].ResolvedVersion
+			}
+			if gotRuntime != tc.wantRuntime {
+				t.Errorf("runtime version = %q, want %q", gotRuntime, tc.wantRuntime)
+			}
+		})
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := map[string]struct {
+		a, b   string
+		want   int
+		wantOK bool
+	}{
+		"equal":               {a: "1.2.3", b: "1.2.3", want: 0, wantOK: true},
+		"numeric not lexical": {a: "1.10.0", b: "1.9.0", want: 1, wantOK: true},
+		"v prefix":            {a: "v1.0", b: "v1.0.1", want: -1, wantOK: true},
+		"prerelease ignored":  {a: "2.0.0-rc1", b: "1.9.9", want: 1, wantOK: true},
+		"branch names":        {a: "main", b: "develop", wantOK: false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, ok := CompareVersions(tc.a, tc.b)
+			if ok != tc.wantOK {
+				t.Fatalf("CompareVersions(%q, %q) ok = %v, want %v", tc.a, tc.b, ok, tc.wantOK)
+			}
+			if got != tc.want {
+				t.Errorf("CompareVersions(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,366 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// LockFileVersion is the lockfile format this apkg writes. Lockfiles of
+// older versions are upgraded by apkg migrate (see Migrations), and newer
+// ones are refused rather than rewritten in an older shape.
+const LockFileVersion = 2
+
+// Kinds of user files migrations upgrade.
+const (
+	FileManifest  = "manifest"
+	FileLock      = "lockfile"
+	FileDevConfig = "dev config"
+)
+
+// MigrationFile is a user file parsed as plain TOML, so migrations can
+// read shapes the current structs no longer have.
+type MigrationFile struct {
+	Kind string
+	Path string
+	Doc  map[string]any
+}
+
+// MigrationFiles are the user files of a project, or of the global scope,
+// that migrations apply to. Files that don't exist are left out.
+type MigrationFiles []*MigrationFile
+
+// Migration is a change to the format of one kind of user file. Unlike
+// store migrations (see store.Migrate), which apkg applies on its own,
+// migrations of files users edit and commit are only applied by apkg
+// migrate, once the user has seen what they change.
+type Migration struct {
+	File        string
+	Description string
+	// Plan returns what the migration would change in f, one note per
+	// change, or nil if f is in the new format already. files are all the
+	// files being migrated, for migrations that need e.g. the manifest to
+	// upgrade the lockfile.
+	Plan func(f *MigrationFile, files MigrationFiles) []string
+	// Apply makes the changes Plan describes to f.Doc.
+	Apply func(f *MigrationFile, files MigrationFiles) error
+	// Blocking reports whether f must be migrated before apkg reads it,
+	// or nil if it always must. A migration that only bumps the format
+	// version of a file this apkg reads as is doesn't stop commands; the
+	// file takes the new version the next time apkg writes it.
+	Blocking func(f *MigrationFile, files MigrationFiles) bool
+}
+
+// Migrations are the format changes of user files, in order.
+var Migrations = []Migration{
+	{
+		File:        FileLock,
+		Description: "lockfile version 2: record the name of every locked skill",
+		Plan:        planLockV2,
+		Apply:       applyLockV2,
+		Blocking:    blockingLockV2,
+	},
+}
+
+// PendingMigration is a migration a file needs, with what it changes.
+type PendingMigration struct {
+	Migration
+	File  *MigrationFile
+	Notes []string
+}
+
+// IsBlocking reports whether p must be applied before apkg reads its file
+// (see Migration.Blocking).
+func (p PendingMigration) IsBlocking(files MigrationFiles) bool {
+	return p.Blocking == nil || p.Blocking(p.File, files)
+}
+
+// MigrationRequiredError is returned when user files are in an older
+// format, instead of silently rewriting them in the new one.
+type MigrationRequiredError struct {
+	Pending []PendingMigration
+}
+
+func (e *MigrationRequiredError) Error() string {
+	var b strings.Builder
+	b.WriteString("files written by an older apkg need migrating; apkg migrate will:\n")
+	b.WriteString(FormatPendingMigrations(e.Pending))
+	b.WriteString("run `apkg migrate`, or pass --auto-migrate, to continue")
+	return b.String()
+}
+
+// FormatPendingMigrations lists what pending changes, by file, one line
+// per change.
+func FormatPendingMigrations(pending []PendingMigration) string {
+	var b strings.Builder
+	for _, p := range pending {
+		fmt.Fprintf(&b, "  %s (%s):\n", p.File.Path, p.Description)
+		for _, note := range p.Notes {
+			fmt.Fprintf(&b, "    - %s\n", note)
+		}
+	}
+	return b.String()
+}
+
+// LoadMigrationFiles reads the manifest, lockfile, and developer configs
+// at the given paths, skipping those that don't exist. An empty path is
+// skipped too.
+func LoadMigrationFiles(manifestPath, lockPath string, devConfigPaths ...string) (MigrationFiles, error) {
+	var files MigrationFiles
+	add := func(kind, path string) error {
+		if path == "" {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", path, err)
+		}
+		doc := make(map[string]any)
+		if err := toml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("parsing %s: %w", path, err)
+		}
+		files = append(files, &MigrationFile{Kind: kind, Path: path, Doc: doc})
+		return nil
+	}
+
+	if err := add(FileManifest, manifestPath); err != nil {
+		return nil, err
+	}
+	if err := add(FileLock, lockPath); err != nil {
+		return nil, err
+	}
+	for _, path := range devConfigPaths {
+		if err := add(FileDevConfig, path); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// Pending returns the migrations files need, in order. A lockfile newer
+// than this apkg supports is an error.
+func (files MigrationFiles) Pending() ([]PendingMigration, error) {
+	if lock := files.get(FileLock); lock != nil {
+		if v := lockVersion(lock.Doc); v > LockFileVersion {
+			return nil, fmt.Errorf("%s has format version %d, newer than this apkg supports (%d); upgrade apkg", lock.Path, v, LockFileVersion)
+		}
+	}
+
+	var pending []PendingMigration
+	for _, m := range Migrations {
+		for _, f := range files {
+			if f.Kind != m.File {
+				continue
+			}
+			if notes := m.Plan(f, files); len(notes) > 0 {
+				pending = append(pending, PendingMigration{Migration: m, File: f, Notes: notes})
+			}
+		}
+	}
+	return pending, nil
+}
+
+// Migrate applies pending, as returned by Pending, and writes back each
+// file it changed in the current format.
+func (files MigrationFiles) Migrate(pending []PendingMigration) error {
+	var changed []*MigrationFile
+	for _, p := range pending {
+		if err := p.Apply(p.File, files); err != nil {
+			return fmt.Errorf("migrating %s (%s): %w", p.File.Path, p.Description, err)
+		}
+		if !containsFile(changed, p.File) {
+			changed = append(changed, p.File)
+		}
+	}
+	for _, f := range changed {
+		if err := f.save(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// get returns the first file of kind, or nil.
+func (files MigrationFiles) get(kind string) *MigrationFile {
+	for _, f := range files {
+		if f.Kind == kind {
+			return f
+		}
+	}
+	return nil
+}
+
+func containsFile(files []*MigrationFile, f *MigrationFile) bool {
+	for _, g := range files {
+		if g == f {
+			return true
+		}
+	}
+	return false
+}
+
+// save writes f back through the struct of its kind, so it comes out as
+// apkg writes files of that kind.
+func (f *MigrationFile) save() error {
+	data, err := toml.Marshal(f.Doc)
+	if err != nil {
+		return fmt.Errorf("marshaling %s: %w", f.Path, err)
+	}
+	switch f.Kind {
+	case FileManifest:
+		cfg, err := UnmarshalConfig(data)
+		if err != nil {
+			return fmt.Errorf("parsing migrated %s: %w", f.Path, err)
+		}
+		return SaveFile(f.Path, cfg)
+	case FileLock:
+		lf, err := ReadLockFile(data)
+		if err != nil {
+			return fmt.Errorf("parsing migrated %s: %w", f.Path, err)
+		}
+		return SaveLockFile(f.Path, lf)
+	default:
+		cfg := &DevConfig{}
+		if err := toml.Unmarshal(data, cfg); err != nil {
+			return fmt.Errorf("parsing migrated %s: %w", f.Path, err)
+		}
+		if data, err = toml.Marshal(cfg); err != nil {
+			return fmt.Errorf("marshaling dev config: %w", err)
+		}
+		return os.WriteFile(f.Path, data, 0o644)
+	}
+}
+
+// lockVersion returns the format version of the lockfile doc; lockfiles
+// without one are version 1.
+func lockVersion(doc map[string]any) int64 {
+	if v, ok := doc["version"].(int64); ok {
+		return v
+	}
+	return 1
+}
+
+// lockV2Name is the name version 2 gives the unnamed skill entry at
+// index, or "" to drop an entry the manifest no longer declares.
+type lockV2Name struct {
+	index  int
+	source string
+	name   string
+}
+
+// lockV2Names returns the names of the skill entries of the version 1
+// lockfile f that have none, taken from the manifest skill with the same
+// source that no other entry is named after.
+func lockV2Names(f *MigrationFile, files MigrationFiles) []lockV2Name {
+	entries, _ := f.Doc["skills"].([]any)
+
+	named := make(map[string]bool)
+	for _, e := range entries {
+		if entry, ok := e.(map[string]any); ok {
+			if name, _ := entry["name"].(string); name != "" {
+				named[name] = true
+			}
+		}
+	}
+
+	// Manifest skills by source, e.g. "https://github.com/org/skills|pdf".
+	bySource := make(map[string][]string)
+	if manifest := files.get(FileManifest); manifest != nil {
+		skills, _ := manifest.Doc["skills"].(map[string]any)
+		for name, s := range skills {
+			if src, ok := s.(map[string]any); ok {
+				key := lockSourceKey(src)
+				bySource[key] = append(bySource[key], name)
+			}
+		}
+	}
+	for _, names := range bySource {
+		sort.Strings(names)
+	}
+
+	var result []lockV2Name
+	for i, e := range entries {
+		entry, ok := e.(map[string]any)
+		if !ok {
+			continue
+		}
+		if name, _ := entry["name"].(string); name != "" {
+			continue
+		}
+		key := lockSourceKey(entry)
+		n := lockV2Name{index: i, source: strings.TrimPrefix(strings.ReplaceAll(key, "|", " "), " ")}
+		for _, name := range bySource[key] {
+			if !named[name] {
+				n.name = name
+				named[name] = true
+				break
+			}
+		}
+		result = append(result, n)
+	}
+	return result
+}
+
+// lockSourceKey returns the git URL and path of a skill source or lock
+// entry, like the installer matches them.
+func lockSourceKey(m map[string]any) string {
+	git, _ := m["git"].(string)
+	path, _ := m["path"].(string)
+	if git != "" {
+		return git + "|" + path
+	}
+	return path
+}
+
+func planLockV2(f *MigrationFile, files MigrationFiles) []string {
+	if lockVersion(f.Doc) >= 2 {
+		return nil
+	}
+	notes := []string{"set the format version to 2"}
+	for _, n := range lockV2Names(f, files) {
+		if n.name == "" {
+			notes = append(notes, fmt.Sprintf("drop the locked skill %s, which the manifest doesn't declare; apkg install locks it again if needed", n.source))
+			continue
+		}
+		notes = append(notes, fmt.Sprintf("name the locked skill %s %q, after its manifest entry", n.source, n.name))
+	}
+	return notes
+}
+
+// blockingLockV2 reports whether the version 1 lockfile f has unnamed
+// skill entries; one whose entries are all named reads as version 2.
+func blockingLockV2(f *MigrationFile, files MigrationFiles) bool {
+	return len(lockV2Names(f, files)) > 0
+}
+
+func applyLockV2(f *MigrationFile, files MigrationFiles) error {
+	entries, _ := f.Doc["skills"].([]any)
+	names := lockV2Names(f, files)
+	drop := make(map[int]bool)
+	for _, n := range names {
+		if n.name == "" {
+			drop[n.index] = true
+			continue
+		}
+		entries[n.index].(map[string]any)["name"] = n.name
+	}
+	if len(drop) > 0 {
+		kept := entries[:0]
+		for i, e := range entries {
+			if !drop[i] {
+				kept = append(kept, e)
+			}
+		}
+		f.Doc["skills"] = kept
+	}
+	f.Doc["version"] = int64(2)
+	return nil
+}
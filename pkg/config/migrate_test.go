@@ -0,0 +1,142 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMigrateLockFile(t *testing.T) {
+	tests := map[string]struct {
+		manifest string // none if empty
+		lock     string
+		// wantErr is in the error of planning the migrations.
+		wantErr      string
+		wantBlocking bool
+		wantNotes    []string
+		// wantSkills are the names of the migrated lockfile's skills.
+		wantSkills []string
+	}{
+		"unnamed skills": {
+			manifest: `
+[skills.pdf]
+git = "https://github.com/org/skills"
+path = "pdf"
+
+[skills.docs]
+path = "./skills/docs"
+`,
+			lock: `version = 1
+
+[[skills]]
+git = "https://github.com/org/skills"
+path = "pdf"
+commit = "abc123"
+
+[[skills]]
+path = "./skills/docs"
+
+[[skills]]
+git = "https://github.com/org/removed"
+commit = "def456"
+`,
+			wantBlocking: true,
+			wantNotes:    []string{`"pdf"`, `"docs"`, "drop the locked skill https://github.com/org/removed"},
+			wantSkills:   []string{"pdf", "docs"},
+		},
+		"version only": {
+			lock: `version = 1
+
+[[skills]]
+name = "docs"
+path = "./skills/docs"
+`,
+			wantSkills: []string{"docs"},
+		},
+		"newer lockfile": {
+			lock:    "version = 99\n",
+			wantErr: "upgrade apkg",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			lockPath := filepath.Join(dir, LockFileName)
+			if err := os.WriteFile(lockPath, []byte(tc.lock), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			manifestPath := ""
+			if tc.manifest != "" {
+				manifestPath = filepath.Join(dir, "apkg.toml")
+				if err := os.WriteFile(manifestPath, []byte(strings.TrimLeft(tc.manifest, "\n")), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// Missing files are skipped.
+			files, err := LoadMigrationFiles(manifestPath, lockPath, filepath.Join(dir, "missing.toml"))
+			if err != nil {
+				t.Fatalf("LoadMigrationFiles() error = %v", err)
+			}
+			pending, err := files.Pending()
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("Pending() error = %v, want %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Pending() error = %v", err)
+			}
+			if len(pending) != 1 || pending[0].File.Path != lockPath {
+				t.Fatalf("Pending() = %+v, want the lockfile migration", pending)
+			}
+			notes := strings.Join(pending[0].Notes, "\n")
+			for _, want := range tc.wantNotes {
+				if !strings.Contains(notes, want) {
+					t.Errorf("notes missing %s:\n%s", want, notes)
+				}
+			}
+			if got := pending[0].IsBlocking(files); got != tc.wantBlocking {
+				t.Errorf("IsBlocking() = %v, want %v", got, tc.wantBlocking)
+			}
+
+			// Planning doesn't touch the files.
+			if err := (&MigrationRequiredError{Pending: pending}).Error(); !strings.Contains(err, "apkg migrate") {
+				t.Errorf("MigrationRequiredError = %s", err)
+			}
+			if after, _ := os.ReadFile(lockPath); string(after) != tc.lock {
+				t.Error("planning migrations changed the lockfile")
+			}
+
+			if err := files.Migrate(pending); err != nil {
+				t.Fatalf("Migrate() error = %v", err)
+			}
+			lf, err := LoadLockFile(lockPath)
+			if err != nil {
+				t.Fatalf("LoadLockFile() error = %v", err)
+			}
+			if lf.Version != LockFileVersion {
+				t.Errorf("Version = %d, want %d", lf.Version, LockFileVersion)
+			}
+			var names []string
+			for _, entry := range lf.Skills {
+				names = append(names, entry.Name)
+			}
+			if !slices.Equal(names, tc.wantSkills) {
+				t.Errorf("Skills = %v, want %v", names, tc.wantSkills)
+			}
+
+			files, err = LoadMigrationFiles(manifestPath, lockPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pending, err := files.Pending(); err != nil || len(pending) != 0 {
+				t.Errorf("Pending() after Migrate() = %+v, %v, want nothing", pending, err)
+			}
+		})
+	}
+}
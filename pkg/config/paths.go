@@ -0,0 +1,49 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Environment variables that relocate apkg's files, so CI and integration
+// tests can isolate apkg from the real home directory. Relative paths are
+// made absolute.
+const (
+	// EnvConfigDir replaces ~/.apkg as the directory of the global
+	// manifest and lockfile, the developer config, credentials, and
+	// history.
+	EnvConfigDir = "APKG_CONFIG_DIR"
+	// EnvGlobalManifest is the path of the global manifest. The global
+	// lockfile is kept next to it.
+	EnvGlobalManifest = "APKG_GLOBAL_MANIFEST"
+	// EnvHome replaces the home directory global installs project into,
+	// e.g. ~/.claude.json and ~/.claude/skills.
+	EnvHome = "APKG_HOME"
+)
+
+// HomeDir returns the directory global installs project into: $APKG_HOME,
+// or the user's home directory.
+func HomeDir() (string, error) {
+	if dir := os.Getenv(EnvHome); dir != "" {
+		return filepath.Abs(dir)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("determining home directory: %w", err)
+	}
+	return home, nil
+}
+
+// globalConfigDir returns $APKG_CONFIG_DIR, or ~/.apkg, without creating
+// it.
+func globalConfigDir() (string, error) {
+	if dir := os.Getenv(EnvConfigDir); dir != "" {
+		return filepath.Abs(dir)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("determining home directory: %w", err)
+	}
+	return filepath.Join(home, ".apkg"), nil
+}
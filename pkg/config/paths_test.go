@@ -0,0 +1,76 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGlobalPathOverrides(t *testing.T) {
+	tests := map[string]struct {
+		env          map[string]string
+		wantConfig   string
+		wantManifest string
+		wantLock     string
+		wantHome     string
+	}{
+		"defaults": {
+			wantConfig:   "{home}/.apkg",
+			wantManifest: "{home}/.apkg/apkg.toml",
+			wantLock:     "{home}/.apkg/apkg-lock.toml",
+			wantHome:     "{home}",
+		},
+		"config dir": {
+			env:          map[string]string{EnvConfigDir: "{tmp}/cfg"},
+			wantConfig:   "{tmp}/cfg",
+			wantManifest: "{tmp}/cfg/apkg.toml",
+			wantLock:     "{tmp}/cfg/apkg-lock.toml",
+			wantHome:     "{home}",
+		},
+		"global manifest keeps the lockfile next to it": {
+			env:          map[string]string{EnvConfigDir: "{tmp}/cfg", EnvGlobalManifest: "{tmp}/ci/global.toml"},
+			wantConfig:   "{tmp}/cfg",
+			wantManifest: "{tmp}/ci/global.toml",
+			wantLock:     "{tmp}/ci/apkg-lock.toml",
+			wantHome:     "{home}",
+		},
+		"home": {
+			env:          map[string]string{EnvHome: "{tmp}/agents"},
+			wantConfig:   "{home}/.apkg",
+			wantManifest: "{home}/.apkg/apkg.toml",
+			wantLock:     "{home}/.apkg/apkg-lock.toml",
+			wantHome:     "{tmp}/agents",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			home, tmp := t.TempDir(), t.TempDir()
+			replacer := strings.NewReplacer("{home}", home, "{tmp}", tmp)
+			expand := func(s string) string { return filepath.FromSlash(replacer.Replace(s)) }
+
+			t.Setenv("HOME", home)
+			for _, key := range []string{EnvConfigDir, EnvGlobalManifest, EnvHome} {
+				t.Setenv(key, expand(tc.env[key]))
+			}
+
+			for label, tt := range map[string]struct {
+				get  func() (string, error)
+				want string
+			}{
+				"GlobalConfigDir":    {GlobalConfigDir, tc.wantConfig},
+				"GlobalManifestPath": {GlobalManifestPath, tc.wantManifest},
+				"GlobalLockFilePath": {GlobalLockFilePath, tc.wantLock},
+				"HomeDir":            {HomeDir, tc.wantHome},
+			} {
+				got, err := tt.get()
+				if err != nil {
+					t.Fatalf("%s() error = %v", label, err)
+				}
+				if want := expand(tt.want); got != want {
+					t.Errorf("%s() = %q, want %q", label, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// ParseTimeout parses a fetch timeout such as "90s" or "5m", as set by the
+// fetch_timeout dev config key or a package's timeout in the manifest. An
+// empty string parses to 0, meaning no override.
+func ParseTimeout(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout %q: want a duration such as 90s or 5m", s)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid timeout %q: must be positive", s)
+	}
+	return d, nil
+}
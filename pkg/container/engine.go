@@ -1,22 +1,76 @@
 package container
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"strings"
 )
 
-// Engine represents a detected container runtime (docker or podman).
-type Engine struct {
+// Engine runs containers. CLI drives docker or podman; Fake simulates
+// them for tests and dry runs.
+type Engine interface {
+	// Command returns the binary agents run stdio containers with, e.g.
+	// "/usr/bin/docker".
+	Command() string
+	// Pull pulls an image if it isn't already present locally.
+	Pull(ctx context.Context, image string) error
+	// Build builds an image tagged tag from a Dockerfile that needs no
+	// build context.
+	Build(ctx context.Context, tag string, dockerfile []byte) error
+	// Login authenticates the engine against a registry host.
+	Login(ctx context.Context, host, username, token string) error
+	// Run starts a detached container with the given name, mapping
+	// hostPort to containerPort, and returns the container ID.
+	Run(ctx context.Context, name, image string, hostPort, containerPort int, opts *RunOpts) (string, error)
+	// Stop stops and removes a container by name. It is not an error if
+	// the container does not exist.
+	Stop(ctx context.Context, name string) error
+	// ImageDigest returns the bare hex image ID of a locally available
+	// image.
+	ImageDigest(ctx context.Context, image string) (string, error)
+	// ManifestDigest returns the registry manifest digest
+	// ("sha256:...") a locally available image was pulled by, or "" for
+	// images only ever built locally.
+	ManifestDigest(ctx context.Context, image string) (string, error)
+	// IsRunning reports whether a container with the given name is
+	// running.
+	IsRunning(ctx context.Context, name string) (bool, error)
+	// EnsureNetwork creates the named user-defined network if it doesn't
+	// exist. Built-in networks such as "host" and "bridge" are left alone.
+	EnsureNetwork(ctx context.Context, network string) error
+	// StoppedContainers returns the names of the containers whose name
+	// starts with prefix and that aren't running, e.g. ones left behind
+	// by a crash.
+	StoppedContainers(ctx context.Context, prefix string) ([]string, error)
+	// Images returns the references ("repository:tag") of the local
+	// images whose repository starts with prefix.
+	Images(ctx context.Context, prefix string) ([]string, error)
+	// RemoveImage removes a local image by reference.
+	RemoveImage(ctx context.Context, image string) error
+	// PruneImages removes the dangling images Build left behind when it
+	// moved a tag to a new image, and returns how many it removed.
+	PruneImages(ctx context.Context) (int, error)
+}
+
+// BuildLabel marks the images Build creates, so PruneImages only touches
+// apkg's own dangling images.
+const BuildLabel = "io.apkg.built"
+
+// CLI is a container Engine backed by the docker or podman binary.
+type CLI struct {
 	Path string // absolute path to the binary
 	Name string // "docker" or "podman"
 }
 
+var _ Engine = &CLI{}
+
 // DetectEngine finds a container engine by first checking the
 // APKG_CONTAINER_ENGINE env var, then searching PATH for docker and podman.
-func DetectEngine() (*Engine, error) {
+func DetectEngine() (Engine, error) {
 	if override := os.Getenv("APKG_CONTAINER_ENGINE"); override != "" {
 		path, err := exec.LookPath(override)
 		if err != nil {
@@ -27,28 +81,35 @@ func DetectEngine() (*Engine, error) {
 		if idx := strings.LastIndex(name, "/"); idx >= 0 {
 			name = name[idx+1:]
 		}
-		return &Engine{Path: path, Name: name}, nil
+		return &CLI{Path: path, Name: name}, nil
 	}
 
 	for _, candidate := range []string{"docker", "podman"} {
 		path, err := exec.LookPath(candidate)
 		if err == nil {
-			return &Engine{Path: path, Name: candidate}, nil
+			return &CLI{Path: path, Name: candidate}, nil
 		}
 	}
 
 	return nil, fmt.Errorf("no container engine found: install docker or podman, or set APKG_CONTAINER_ENGINE")
 }
 
-// Pull pulls an image if it isn't already present locally.
-func (e *Engine) Pull(ctx context.Context, image string) error {
+// Command returns the path of the docker or podman binary.
+func (e *CLI) Command() string {
+	return e.Path
+}
+
+// Pull pulls an image if it isn't already present locally. Its progress
+// goes to stderr, so it doesn't mix with the output of commands like
+// apkg rpc that answer on stdout.
+func (e *CLI) Pull(ctx context.Context, image string) error {
 	cmd := exec.CommandContext(ctx, e.Path, "image", "inspect", image)
 	if err := cmd.Run(); err == nil {
 		return nil // image already present
 	}
 
 	cmd = exec.CommandContext(ctx, e.Path, "pull", image)
-	cmd.Stdout = os.Stdout
+	cmd.Stdout = os.Stderr
 	cmd.Stderr = os.Stderr
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("pulling image %q: %w", image, err)
@@ -56,17 +117,73 @@ func (e *Engine) Pull(ctx context.Context, image string) error {
 	return nil
 }
 
+// Build builds an image tagged tag from dockerfile, passed on stdin
+// without a build context. Its output goes to stderr, as pulls' does.
+func (e *CLI) Build(ctx context.Context, tag string, dockerfile []byte) error {
+	cmd := exec.CommandContext(ctx, e.Path, "build", "-t", tag, "--label", BuildLabel+"=true", "-")
+	cmd.Stdin = bytes.NewReader(dockerfile)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("building image %q: %w", tag, err)
+	}
+	return nil
+}
+
+// Login authenticates the engine against a registry host, passing the
+// token on stdin.
+func (e *CLI) Login(ctx context.Context, host, username, token string) error {
+	cmd := exec.CommandContext(ctx, e.Path, "login", host, "--username", username, "--password-stdin")
+	cmd.Stdin = strings.NewReader(token)
+	if _, err := cmd.Output(); err != nil {
+		return fmt.Errorf("logging in to %s: %w", host, execError(err))
+	}
+	return nil
+}
+
+// RegistryHost returns the registry host of an image reference, e.g.
+// "ghcr.io" for "ghcr.io/org/image:tag" and "docker.io" for "nginx".
+func RegistryHost(image string) string {
+	first, _, found := strings.Cut(image, "/")
+	if found && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		return first
+	}
+	return "docker.io"
+}
+
+// PinnedImage returns the reference of image pinned to its registry
+// manifest digest, e.g. "ghcr.io/org/image@sha256:..." for
+// "ghcr.io/org/image:tag", or image itself if digest is empty.
+func PinnedImage(image, digest string) string {
+	if digest == "" {
+		return image
+	}
+	return imageRepository(image) + "@" + digest
+}
+
+// imageRepository returns image without its tag or digest.
+func imageRepository(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
+
 // RunOpts holds optional parameters for running a container.
 type RunOpts struct {
 	Env     map[string]string // environment variables passed via -e
 	Args    []string          // arguments appended after the image (entrypoint args)
 	Volumes []string          // bind mounts passed via -v (host:container[:ro])
 	Network string            // container network (--network); "host" skips port mapping
+	// NetworkAliases are extra names the container is reachable by on a
+	// user-defined network (--network-alias).
+	NetworkAliases []string
 }
 
 // Run starts a detached container with the given name, mapping hostPort to
 // containerPort, and returns the container ID.
-func (e *Engine) Run(ctx context.Context, name, image string, hostPort, containerPort int, opts *RunOpts) (string, error) {
+func (e *CLI) Run(ctx context.Context, name, image string, hostPort, containerPort int, opts *RunOpts) (string, error) {
 	args := []string{
 		"run", "-d",
 		"--name", name,
@@ -91,6 +208,9 @@ func (e *Engine) Run(ctx context.Context, name, image string, hostPort, containe
 		for _, vol := range opts.Volumes {
 			args = append(args, "-v", expandVolumeTilde(vol))
 		}
+		for _, alias := range opts.NetworkAliases {
+			args = append(args, "--network-alias", alias)
+		}
 	}
 
 	args = append(args, image)
@@ -109,7 +229,7 @@ func (e *Engine) Run(ctx context.Context, name, image string, hostPort, containe
 
 // Stop stops and removes a container by name. It is not an error if the
 // container does not exist.
-func (e *Engine) Stop(ctx context.Context, name string) error {
+func (e *CLI) Stop(ctx context.Context, name string) error {
 	// Stop, then remove. Ignore errors from stop (container may not be running).
 	stop := exec.CommandContext(ctx, e.Path, "stop", name)
 	_ = stop.Run()
@@ -122,7 +242,7 @@ func (e *Engine) Stop(ctx context.Context, name string) error {
 }
 
 // ImageDigest returns the image ID (sha256 digest) for a locally available image.
-func (e *Engine) ImageDigest(ctx context.Context, image string) (string, error) {
+func (e *CLI) ImageDigest(ctx context.Context, image string) (string, error) {
 	cmd := exec.CommandContext(ctx, e.Path, "image", "inspect", "--format", "{{.Id}}", image)
 	out, err := cmd.Output()
 	if err != nil {
@@ -134,8 +254,44 @@ func (e *Engine) ImageDigest(ctx context.Context, image string) (string, error)
 	return digest, nil
 }
 
+// ManifestDigest returns the registry manifest digest of a locally
+// available image from its RepoDigests, preferring the entry of the
+// image's own repository.
+func (e *CLI) ManifestDigest(ctx context.Context, image string) (string, error) {
+	cmd := exec.CommandContext(ctx, e.Path, "image", "inspect", "--format", "{{json .RepoDigests}}", image)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("inspecting image %q: %w", image, execError(err))
+	}
+	var repoDigests []string
+	if err := json.Unmarshal(out, &repoDigests); err != nil {
+		return "", fmt.Errorf("parsing repo digests of image %q: %w", image, err)
+	}
+	return repoManifestDigest(image, repoDigests), nil
+}
+
+// repoManifestDigest picks the digest of image's repository from
+// repoDigests ("repository@sha256:..."), which engines may spell with the
+// registry host (docker.io/library/nginx for nginx), falling back to the
+// first entry.
+func repoManifestDigest(image string, repoDigests []string) string {
+	repo := imageRepository(image)
+	for _, rd := range repoDigests {
+		name, digest, ok := strings.Cut(rd, "@")
+		if ok && (name == repo || strings.HasSuffix(name, "/"+repo)) {
+			return digest
+		}
+	}
+	if len(repoDigests) > 0 {
+		if _, digest, ok := strings.Cut(repoDigests[0], "@"); ok {
+			return digest
+		}
+	}
+	return ""
+}
+
 // IsRunning checks whether a container with the given name is currently running.
-func (e *Engine) IsRunning(ctx context.Context, name string) (bool, error) {
+func (e *CLI) IsRunning(ctx context.Context, name string) (bool, error) {
 	cmd := exec.CommandContext(ctx, e.Path,
 		"container", "inspect", "-f", "{{.State.Running}}", name)
 	out, err := cmd.Output()
@@ -145,6 +301,73 @@ func (e *Engine) IsRunning(ctx context.Context, name string) (bool, error) {
 	return strings.TrimSpace(string(out)) == "true", nil
 }
 
+// StoppedContainers lists the containers named with prefix that are
+// created, exited, or dead.
+func (e *CLI) StoppedContainers(ctx context.Context, prefix string) ([]string, error) {
+	cmd := exec.CommandContext(ctx, e.Path, "ps", "-a",
+		"--filter", "name=^"+prefix,
+		"--filter", "status=created", "--filter", "status=exited", "--filter", "status=dead",
+		"--format", "{{.Names}}")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("listing containers: %w", execError(err))
+	}
+	// The name filter is a regexp for docker but a substring for some
+	// podman versions, so check the prefix again.
+	var names []string
+	for _, name := range strings.Fields(string(out)) {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// Images lists the tagged local images whose repository starts with
+// prefix.
+func (e *CLI) Images(ctx context.Context, prefix string) ([]string, error) {
+	cmd := exec.CommandContext(ctx, e.Path, "images", "--format", "{{.Repository}}:{{.Tag}}")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("listing images: %w", execError(err))
+	}
+	var images []string
+	for _, image := range strings.Fields(string(out)) {
+		if strings.HasPrefix(image, prefix) && !strings.HasSuffix(image, ":<none>") {
+			images = append(images, image)
+		}
+	}
+	return images, nil
+}
+
+// RemoveImage removes a local image by reference.
+func (e *CLI) RemoveImage(ctx context.Context, image string) error {
+	cmd := exec.CommandContext(ctx, e.Path, "image", "rm", image)
+	if _, err := cmd.Output(); err != nil {
+		return fmt.Errorf("removing image %q: %w", image, execError(err))
+	}
+	return nil
+}
+
+// PruneImages removes the dangling images labeled BuildLabel.
+func (e *CLI) PruneImages(ctx context.Context) (int, error) {
+	cmd := exec.CommandContext(ctx, e.Path, "image", "prune", "-f", "--filter", "label="+BuildLabel)
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("pruning images: %w", execError(err))
+	}
+	// Both engines list each removed image on a line of its own: docker
+	// as "deleted: sha256:..." and podman as a bare ID.
+	n := 0
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "deleted: ") || (line != "" && !strings.Contains(line, " ")) {
+			n++
+		}
+	}
+	return n, nil
+}
+
 // expandVolumeTilde expands a leading "~/" in the host-path portion of a
 // volume mount string (host:container[:opts]).  exec.Command bypasses the
 // shell, so tilde expansion doesn't happen automatically.
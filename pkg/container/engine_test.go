@@ -39,7 +39,7 @@ func TestDetectEngine(t *testing.T) {
 				t.Setenv("APKG_CONTAINER_ENGINE", tc.envVar)
 			}
 
-			eng, err := DetectEngine()
+			engine, err := DetectEngine()
 			if tc.wantErr {
 				if err == nil {
 					t.Fatal("expected error, got nil")
@@ -49,6 +49,7 @@ func TestDetectEngine(t *testing.T) {
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
+			eng := engine.(*CLI)
 
 			if tc.wantName != "" && eng.Name != tc.wantName {
 				t.Errorf("Name = %q, want %q", eng.Name, tc.wantName)
@@ -79,10 +80,11 @@ func TestDetectEngineFromPath(t *testing.T) {
 		t.Skip("neither docker nor podman in PATH")
 	}
 
-	eng, err := DetectEngine()
+	engine, err := DetectEngine()
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
+	eng := engine.(*CLI)
 
 	if eng.Name != "docker" && eng.Name != "podman" {
 		t.Errorf("Name = %q, want docker or podman", eng.Name)
@@ -177,3 +179,67 @@ func TestExecError(t *testing.T) {
 		})
 	}
 }
+
+func TestRegistryHost(t *testing.T) {
+	tests := map[string]struct {
+		image string
+		want  string
+	}{
+		"docker hub library image": {image: "nginx:latest", want: "docker.io"},
+		"docker hub user image":    {image: "org/server:1.0", want: "docker.io"},
+		"ghcr":                     {image: "ghcr.io/org/server:1.0", want: "ghcr.io"},
+		"registry with port":       {image: "localhost:5000/server", want: "localhost:5000"},
+		"localhost":                {image: "localhost/server", want: "localhost"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := RegistryHost(tc.image); got != tc.want {
+				t.Errorf("RegistryHost(%q) = %q, want %q", tc.image, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPinnedImage(t *testing.T) {
+	const digest = "sha256:0123abcd"
+	tests := map[string]struct {
+		image  string
+		digest string
+		want   string
+	}{
+		"tagged":             {image: "ghcr.io/org/server:1.0", digest: digest, want: "ghcr.io/org/server@" + digest},
+		"registry with port": {image: "localhost:5000/server", digest: digest, want: "localhost:5000/server@" + digest},
+		"already pinned":     {image: "nginx@sha256:ffff", digest: digest, want: "nginx@" + digest},
+		"no digest":          {image: "nginx:latest", want: "nginx:latest"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := PinnedImage(tc.image, tc.digest); got != tc.want {
+				t.Errorf("PinnedImage(%q, %q) = %q, want %q", tc.image, tc.digest, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRepoManifestDigest(t *testing.T) {
+	tests := map[string]struct {
+		image       string
+		repoDigests []string
+		want        string
+	}{
+		"own repository":         {image: "nginx:latest", repoDigests: []string{"mirror.example/web@sha256:aa", "docker.io/library/nginx@sha256:bb"}, want: "sha256:bb"},
+		"falls back to first":    {image: "nginx:latest", repoDigests: []string{"mirror.example/web@sha256:aa"}, want: "sha256:aa"},
+		"locally built image":    {image: "my-server:dev", want: ""},
+		"exact repository match": {image: "ghcr.io/org/server:1.0", repoDigests: []string{"ghcr.io/org/server@sha256:cc"}, want: "sha256:cc"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := repoManifestDigest(tc.image, tc.repoDigests); got != tc.want {
+				t.Errorf("repoManifestDigest() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package container
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Fake is an Engine that runs nothing, for tests. It tracks
+// pulled images and running containers in memory and records every
+// operation in Ops.
+type Fake struct {
+	// Binary is returned by Command (default "docker").
+	Binary string
+	// Digests maps images to the digest ImageDigest returns once they are
+	// pulled. Other images get a digest derived from their reference.
+	Digests map[string]string
+	// Manifests maps images to the manifest digest ManifestDigest returns
+	// once they are pulled. Other images get one derived from their
+	// reference.
+	Manifests map[string]string
+	// Exited maps the names of stopped containers, e.g. ones a crash
+	// left behind, to their images. Stop removes them.
+	Exited map[string]string
+	// Dangling is the number of dangling images PruneImages removes.
+	Dangling int
+	// Errors makes the operation of the same name ("pull", "build",
+	// "login", "run", "stop", "digest", "manifest", "running", "network",
+	// "containers", "images", "rmi", "prune") fail with the given error.
+	Errors map[string]error
+
+	mu      sync.Mutex
+	ops     []string
+	images  map[string]bool
+	running map[string]string // container name → image
+	// networks are the user-defined networks created so far.
+	networks map[string]bool
+}
+
+var _ Engine = &Fake{}
+
+func (f *Fake) Command() string {
+	if f.Binary == "" {
+		return "docker"
+	}
+	return f.Binary
+}
+
+// Ops returns the operations performed so far, e.g. "pull nginx" or
+// "run apkg-mcp-fetch nginx 8080:80".
+func (f *Fake) Ops() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.ops)
+}
+
+// Running returns the names of the running containers, sorted.
+func (f *Fake) Running() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	names := make([]string, 0, len(f.running))
+	for name := range f.running {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func (f *Fake) Pull(ctx context.Context, image string) error {
+	if err := f.record("pull", image); err != nil {
+		return fmt.Errorf("pulling image %q: %w", image, err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.images == nil {
+		f.images = make(map[string]bool)
+	}
+	f.images[image] = true
+	return nil
+}
+
+func (f *Fake) Build(ctx context.Context, tag string, dockerfile []byte) error {
+	if err := f.record("build", tag); err != nil {
+		return fmt.Errorf("building image %q: %w", tag, err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.images == nil {
+		f.images = make(map[string]bool)
+	}
+	f.images[tag] = true
+	return nil
+}
+
+func (f *Fake) Login(ctx context.Context, host, username, token string) error {
+	if err := f.record("login", host+" "+username); err != nil {
+		return fmt.Errorf("logging in to %s: %w", host, err)
+	}
+	return nil
+}
+
+func (f *Fake) Run(ctx context.Context, name, image string, hostPort, containerPort int, opts *RunOpts) (string, error) {
+	if err := f.record("run", fmt.Sprintf("%s %s %d:%d", name, image, hostPort, containerPort)); err != nil {
+		return "", fmt.Errorf("starting container %q: %w", name, err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.running[name]; ok {
+		return "", fmt.Errorf("starting container %q: name is already in use", name)
+	}
+	if f.running == nil {
+		f.running = make(map[string]string)
+	}
+	f.running[name] = image
+	return "fake-" + name, nil
+}
+
+func (f *Fake) Stop(ctx context.Context, name string) error {
+	if err := f.record("stop", name); err != nil {
+		return fmt.Errorf("removing container %q: %w", name, err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.running, name)
+	delete(f.Exited, name)
+	return nil
+}
+
+func (f *Fake) ImageDigest(ctx context.Context, image string) (string, error) {
+	if err := f.record("digest", image); err != nil {
+		return "", fmt.Errorf("inspecting image %q: %w", image, err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.images[image] {
+		return "", fmt.Errorf("inspecting image %q: no such image", image)
+	}
+	if digest, ok := f.Digests[image]; ok {
+		return digest, nil
+	}
+	sum := sha256.Sum256([]byte(image))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func (f *Fake) ManifestDigest(ctx context.Context, image string) (string, error) {
+	if err := f.record("manifest", image); err != nil {
+		return "", fmt.Errorf("inspecting image %q: %w", image, err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.images[image] {
+		return "", fmt.Errorf("inspecting image %q: no such image", image)
+	}
+	if digest, ok := f.Manifests[image]; ok {
+		return digest, nil
+	}
+	sum := sha256.Sum256([]byte("manifest " + image))
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+func (f *Fake) IsRunning(ctx context.Context, name string) (bool, error) {
+	if err := f.record("running", name); err != nil {
+		return false, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.running[name]
+	return ok, nil
+}
+
+func (f *Fake) EnsureNetwork(ctx context.Context, network string) error {
+	if !IsUserNetwork(network) {
+		return nil
+	}
+	f.mu.Lock()
+	exists := f.networks[network]
+	f.mu.Unlock()
+	if exists {
+		return nil
+	}
+
+	if err := f.record("network", network); err != nil {
+		return fmt.Errorf("creating network %q: %w", network, err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.networks == nil {
+		f.networks = make(map[string]bool)
+	}
+	f.networks[network] = true
+	return nil
+}
+
+func (f *Fake) StoppedContainers(ctx context.Context, prefix string) ([]string, error) {
+	if err := f.record("containers", prefix); err != nil {
+		return nil, fmt.Errorf("listing containers: %w", err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var names []string
+	for name := range f.Exited {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+func (f *Fake) Images(ctx context.Context, prefix string) ([]string, error) {
+	if err := f.record("images", prefix); err != nil {
+		return nil, fmt.Errorf("listing images: %w", err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var images []string
+	for image := range f.images {
+		if strings.HasPrefix(image, prefix) {
+			images = append(images, image)
+		}
+	}
+	slices.Sort(images)
+	return images, nil
+}
+
+func (f *Fake) RemoveImage(ctx context.Context, image string) error {
+	if err := f.record("rmi", image); err != nil {
+		return fmt.Errorf("removing image %q: %w", image, err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.images[image] {
+		return fmt.Errorf("removing image %q: no such image", image)
+	}
+	delete(f.images, image)
+	return nil
+}
+
+func (f *Fake) PruneImages(ctx context.Context) (int, error) {
+	if err := f.record("prune", "images"); err != nil {
+		return 0, fmt.Errorf("pruning images: %w", err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := f.Dangling
+	f.Dangling = 0
+	return n, nil
+}
+
+// record appends the operation op on subject to Ops and returns the error
+// configured for op, if any.
+func (f *Fake) record(op, subject string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ops = append(f.ops, op+" "+subject)
+	return f.Errors[op]
+}
@@ -0,0 +1,63 @@
+package container
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestFake(t *testing.T) {
+	tests := map[string]struct {
+		run         func(ctx context.Context, f *Fake) error
+		wantRunning []string
+		wantErr     bool
+	}{
+		"run and stop": {
+			run: func(ctx context.Context, f *Fake) error {
+				if _, err := f.Run(ctx, "a", "img", 8080, 80, nil); err != nil {
+					return err
+				}
+				if _, err := f.Run(ctx, "b", "img", 8081, 80, nil); err != nil {
+					return err
+				}
+				return f.Stop(ctx, "a")
+			},
+			wantRunning: []string{"b"},
+		},
+		"name conflict": {
+			run: func(ctx context.Context, f *Fake) error {
+				f.Run(ctx, "a", "img", 8080, 80, nil)
+				_, err := f.Run(ctx, "a", "img", 8080, 80, nil)
+				return err
+			},
+			wantRunning: []string{"a"},
+			wantErr:     true,
+		},
+		"digest of image not pulled": {
+			run: func(ctx context.Context, f *Fake) error {
+				_, err := f.ImageDigest(ctx, "img")
+				return err
+			},
+			wantRunning: []string{},
+			wantErr:     true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			f := &Fake{}
+			err := tc.run(context.Background(), f)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if got := f.Running(); !slices.Equal(got, tc.wantRunning) {
+				t.Errorf("Running() = %v, want %v", got, tc.wantRunning)
+			}
+			for _, name := range tc.wantRunning {
+				if ok, _ := f.IsRunning(context.Background(), name); !ok {
+					t.Errorf("IsRunning(%q) = false, want true", name)
+				}
+			}
+		})
+	}
+}
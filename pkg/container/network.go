@@ -0,0 +1,59 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// IsUserNetwork reports whether network names a user-defined network, which
+// may need creating, rather than one of the engine's built-in modes ("host",
+// "bridge", "none", "default", or "container:<name>"). The empty string is
+// the engine's default network.
+func IsUserNetwork(network string) bool {
+	switch network {
+	case "", "host", "bridge", "none", "default":
+		return false
+	}
+	return !strings.HasPrefix(network, "container:")
+}
+
+// CheckNetwork reports whether a container can run on network with the
+// given aliases. publishesPort is set for containers reached over a
+// published port (http servers), which can't run without networking.
+// Problems the engine would only report when the container starts are
+// caught here, at install time.
+func CheckNetwork(network string, aliases []string, publishesPort bool) error {
+	switch {
+	case network == "none" && publishesPort:
+		return fmt.Errorf("network %q has no networking, so the container's port can't be reached; use the default network or a named one", network)
+	case strings.HasPrefix(network, "container:") && publishesPort:
+		return fmt.Errorf("network %q shares another container's network, so the container's port can't be published; use the default network or a named one", network)
+	case len(aliases) > 0 && !IsUserNetwork(network):
+		if network == "" {
+			return fmt.Errorf("network aliases %v need a named network; set network too", aliases)
+		}
+		return fmt.Errorf("network aliases %v are only supported on user-defined networks, not %q", aliases, network)
+	}
+	return nil
+}
+
+// EnsureNetwork creates the named user-defined network if it doesn't
+// exist. Built-in networks are left alone.
+func (e *CLI) EnsureNetwork(ctx context.Context, network string) error {
+	if !IsUserNetwork(network) {
+		return nil
+	}
+
+	cmd := exec.CommandContext(ctx, e.Path, "network", "inspect", network)
+	if err := cmd.Run(); err == nil {
+		return nil // network already exists
+	}
+
+	cmd = exec.CommandContext(ctx, e.Path, "network", "create", network)
+	if _, err := cmd.Output(); err != nil {
+		return fmt.Errorf("creating network %q: %w", network, execError(err))
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package container
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCheckNetwork(t *testing.T) {
+	tests := map[string]struct {
+		network       string
+		aliases       []string
+		publishesPort bool
+		wantErr       bool
+	}{
+		"default network": {
+			publishesPort: true,
+		},
+		"host network": {
+			network:       "host",
+			publishesPort: true,
+		},
+		"user network with aliases": {
+			network:       "kind",
+			aliases:       []string{"mcp"},
+			publishesPort: true,
+		},
+		"no networking for stdio": {
+			network: "none",
+		},
+		"no networking for http": {
+			network:       "none",
+			publishesPort: true,
+			wantErr:       true,
+		},
+		"shared container network for http": {
+			network:       "container:db",
+			publishesPort: true,
+			wantErr:       true,
+		},
+		"aliases on host network": {
+			network: "host",
+			aliases: []string{"mcp"},
+			wantErr: true,
+		},
+		"aliases on default bridge": {
+			network: "bridge",
+			aliases: []string{"mcp"},
+			wantErr: true,
+		},
+		"aliases without network": {
+			aliases: []string{"mcp"},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := CheckNetwork(tc.network, tc.aliases, tc.publishesPort)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("CheckNetwork() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestFakeEnsureNetwork(t *testing.T) {
+	f := &Fake{}
+	ctx := context.Background()
+	for _, network := range []string{"", "host", "kind", "kind"} {
+		if err := f.EnsureNetwork(ctx, network); err != nil {
+			t.Fatalf("EnsureNetwork(%q) error: %v", network, err)
+		}
+	}
+
+	ops := f.Ops()
+	if len(ops) != 1 || ops[0] != "network kind" {
+		t.Errorf("Ops() = %v, want only the creation of kind", ops)
+	}
+}
@@ -0,0 +1,154 @@
+package container
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// volumeOptions are the mount options accepted after a volume's container
+// path. docker and podman both understand them.
+var volumeOptions = map[string]bool{"ro": true, "rw": true, "z": true, "Z": true}
+
+// ResolveVolume checks a volume spec (host:container[:opts]) and returns it
+// with the host path made absolute: "~" expands to the home directory and
+// relative paths are joined to baseDir. A host path that doesn't exist is
+// created when create is set; otherwise it must at least be creatable.
+// Named volumes (a host part that isn't a path, e.g. "pgdata") are returned
+// as-is. On Windows, host paths may start with a drive letter, as in
+// C:\data:/data.
+//
+// Problems are reported here, at install time, rather than by the engine
+// when apkg serve starts the container.
+func ResolveVolume(spec, baseDir string, create bool) (string, error) {
+	parts := splitVolume(spec, runtime.GOOS)
+	if len(parts) < 2 || len(parts) > 3 {
+		return "", fmt.Errorf("volume %q: want host:container[:options]", spec)
+	}
+	host, target := parts[0], parts[1]
+
+	if host == "" {
+		return "", fmt.Errorf("volume %q: host path is empty", spec)
+	}
+	if !strings.HasPrefix(target, "/") {
+		return "", fmt.Errorf("volume %q: container path %q must be absolute", spec, target)
+	}
+	if len(parts) == 3 {
+		for _, opt := range strings.Split(parts[2], ",") {
+			if !volumeOptions[opt] {
+				return "", fmt.Errorf("volume %q: unknown option %q (want ro, rw, z, or Z)", spec, opt)
+			}
+		}
+	}
+
+	if !isHostPath(host) {
+		return spec, nil
+	}
+
+	host, err := absHostPath(host, baseDir)
+	if err != nil {
+		return "", fmt.Errorf("volume %q: %w", spec, err)
+	}
+	if err := ensureHostPath(host, create); err != nil {
+		return "", fmt.Errorf("volume %q: %w", spec, err)
+	}
+
+	parts[0] = host
+	return strings.Join(parts, ":"), nil
+}
+
+// ResolveVolumes resolves each of specs (see ResolveVolume).
+func ResolveVolumes(specs []string, baseDir string, create bool) ([]string, error) {
+	if len(specs) == 0 {
+		return specs, nil
+	}
+	resolved := make([]string, len(specs))
+	for i, spec := range specs {
+		var err error
+		if resolved[i], err = ResolveVolume(spec, baseDir, create); err != nil {
+			return nil, err
+		}
+	}
+	return resolved, nil
+}
+
+// splitVolume splits a volume spec into its host, container, and options
+// parts. On Windows, the colon of a drive letter starting the host path
+// doesn't separate parts.
+func splitVolume(spec, goos string) []string {
+	drive := ""
+	if goos == "windows" && len(spec) > 2 && spec[1] == ':' && (spec[2] == '\\' || spec[2] == '/') {
+		drive, spec = spec[:2], spec[2:]
+	}
+	parts := strings.Split(spec, ":")
+	parts[0] = drive + parts[0]
+	return parts
+}
+
+// isHostPath reports whether a volume's host part is a path rather than
+// the name of a volume managed by the engine.
+func isHostPath(host string) bool {
+	return strings.HasPrefix(host, "~") || strings.HasPrefix(host, ".") || strings.ContainsAny(host, `/\`)
+}
+
+// absHostPath expands "~" and makes host absolute relative to baseDir, or
+// the working directory if baseDir is empty.
+func absHostPath(host, baseDir string) (string, error) {
+	if host == "~" || strings.HasPrefix(host, "~/") || strings.HasPrefix(host, "~"+string(filepath.Separator)) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("expanding ~: %w", err)
+		}
+		return filepath.Join(home, host[1:]), nil
+	}
+	if strings.HasPrefix(host, "~") {
+		return "", fmt.Errorf("host path %q: only ~/ is expanded", host)
+	}
+	if filepath.IsAbs(host) {
+		return filepath.Clean(host), nil
+	}
+	if baseDir != "" {
+		return filepath.Join(baseDir, host), nil
+	}
+	return filepath.Abs(host)
+}
+
+// ensureHostPath checks that host exists, creating it as a directory if
+// create is set. Without create, a missing host path must have a directory
+// as its nearest existing ancestor so the engine can create it.
+func ensureHostPath(host string, create bool) error {
+	_, err := os.Stat(host)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("checking host path: %w", err)
+	}
+
+	if create {
+		if err := os.MkdirAll(host, 0o755); err != nil {
+			return fmt.Errorf("creating host directory: %w", err)
+		}
+		return nil
+	}
+
+	for dir := filepath.Dir(host); ; dir = filepath.Dir(dir) {
+		info, err := os.Stat(dir)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("host path %s doesn't exist and can't be created: %s is not a directory", host, dir)
+			}
+			return nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("checking host path: %w", err)
+		}
+		if dir == filepath.Dir(dir) {
+			return fmt.Errorf("host path %s doesn't exist", host)
+		}
+	}
+}
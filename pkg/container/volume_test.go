@@ -0,0 +1,127 @@
+package container
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestResolveVolume(t *testing.T) {
+	base := t.TempDir()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	if err := os.WriteFile(filepath.Join(base, "file"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		spec    string
+		create  bool
+		want    string
+		wantDir string // created host directory, if any
+		wantErr bool
+	}{
+		"absolute path": {
+			spec: base + ":/data:ro",
+			want: base + ":/data:ro",
+		},
+		"relative path": {
+			spec: "./data:/data",
+			want: filepath.Join(base, "data") + ":/data",
+		},
+		"tilde": {
+			spec: "~/cache:/cache:rw",
+			want: filepath.Join(home, "cache") + ":/cache:rw",
+		},
+		"named volume": {
+			spec: "pgdata:/var/lib/postgresql",
+			want: "pgdata:/var/lib/postgresql",
+		},
+		"selinux label": {
+			spec: base + ":/data:ro,Z",
+			want: base + ":/data:ro,Z",
+		},
+		"creates missing directory": {
+			spec:    "out/logs:/logs",
+			create:  true,
+			want:    filepath.Join(base, "out", "logs") + ":/logs",
+			wantDir: filepath.Join(base, "out", "logs"),
+		},
+		"missing path under a file": {
+			spec:    "file/sub:/data",
+			wantErr: true,
+		},
+		"unknown option": {
+			spec:    base + ":/data:readonly",
+			wantErr: true,
+		},
+		"relative container path": {
+			spec:    base + ":data",
+			wantErr: true,
+		},
+		"no container path": {
+			spec:    base,
+			wantErr: true,
+		},
+		"other user's home": {
+			spec:    "~bob/data:/data",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ResolveVolume(tc.spec, base, tc.create)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ResolveVolume() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("ResolveVolume() = %q, want %q", got, tc.want)
+			}
+			if tc.wantDir != "" {
+				if info, err := os.Stat(tc.wantDir); err != nil || !info.IsDir() {
+					t.Errorf("host directory %s wasn't created", tc.wantDir)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitVolume(t *testing.T) {
+	tests := map[string]struct {
+		spec string
+		goos string
+		want []string
+	}{
+		"unix path": {
+			spec: "/data:/data:ro",
+			goos: "linux",
+			want: []string{"/data", "/data", "ro"},
+		},
+		"drive letter": {
+			spec: `C:\Users\me\data:/data:ro`,
+			goos: "windows",
+			want: []string{`C:\Users\me\data`, "/data", "ro"},
+		},
+		"drive letter with slashes": {
+			spec: "D:/cache:/cache",
+			goos: "windows",
+			want: []string{"D:/cache", "/cache"},
+		},
+		"one-letter named volume": {
+			spec: "c:/data",
+			goos: "linux",
+			want: []string{"c", "/data"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := splitVolume(tc.spec, tc.goos); !slices.Equal(got, tc.want) {
+				t.Errorf("splitVolume(%q, %q) = %q, want %q", tc.spec, tc.goos, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+// Package credentials stores per-host tokens used to authenticate against
+// git hosts, container registries, and package registries. Tokens are kept
+// in the OS keychain when one is available, falling back to an encrypted
+// file in ~/.apkg.
+package credentials
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+)
+
+// DefaultUsername is used for basic auth when a credential has no
+// username. GitHub accepts any username with a token; GitLab and most
+// registries accept any non-empty one.
+const DefaultUsername = "x-access-token"
+
+// ErrNotFound is returned when no credential is stored for a host.
+var ErrNotFound = errors.New("no credentials stored")
+
+// Credential is a token for a single host.
+type Credential struct {
+	Username string `json:"username,omitempty"`
+	Token    string `json:"token"`
+}
+
+// User returns the credential's username, or DefaultUsername if unset.
+func (c Credential) User() string {
+	if c.Username == "" {
+		return DefaultUsername
+	}
+	return c.Username
+}
+
+// BasicAuth returns the value of an HTTP Authorization header carrying the
+// credential as basic auth.
+func (c Credential) BasicAuth() string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(c.User()+":"+c.Token))
+}
+
+// Backend persists credentials keyed by host.
+type Backend interface {
+	// Name describes where credentials are stored, for user-facing output.
+	Name() string
+	Get(host string) (Credential, error)
+	Set(host string, cred Credential) error
+	Delete(host string) error
+}
+
+// Store reads and writes credentials across backends in order of
+// preference.
+type Store struct {
+	backends []Backend
+}
+
+// New returns a Store using the given backends, most preferred first.
+func New(backends ...Backend) *Store {
+	return &Store{backends: backends}
+}
+
+// Default returns a Store backed by the OS keychain (when available) with
+// the encrypted file in ~/.apkg as a fallback.
+func Default() (*Store, error) {
+	dir, err := config.GlobalConfigDir()
+	if err != nil {
+		return nil, err
+	}
+
+	var backends []Backend
+	if kc := DetectKeychain(); kc != nil {
+		backends = append(backends, kc)
+	}
+	backends = append(backends, &File{Dir: dir})
+	return New(backends...), nil
+}
+
+// Get returns the credential for host from the first backend holding one.
+func (s *Store) Get(host string) (Credential, error) {
+	host = Host(host)
+	var errs []error
+	for _, b := range s.backends {
+		cred, err := b.Get(host)
+		if err == nil {
+			return cred, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			errs = append(errs, fmt.Errorf("%s: %w", b.Name(), err))
+		}
+	}
+	if len(errs) > 0 {
+		return Credential{}, errors.Join(errs...)
+	}
+	return Credential{}, fmt.Errorf("%w for %s", ErrNotFound, host)
+}
+
+// Set stores the credential for host in the first backend that accepts it
+// and returns that backend's name.
+func (s *Store) Set(host string, cred Credential) (string, error) {
+	host = Host(host)
+	if host == "" {
+		return "", fmt.Errorf("host is required")
+	}
+	if cred.Token == "" {
+		return "", fmt.Errorf("token is required")
+	}
+
+	defer forget(host)
+
+	var errs []error
+	for _, b := range s.backends {
+		if err := b.Set(host, cred); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", b.Name(), err))
+			continue
+		}
+		return b.Name(), nil
+	}
+	return "", fmt.Errorf("storing credentials for %s: %w", host, errors.Join(errs...))
+}
+
+// Delete removes the credential for host from every backend. It returns
+// ErrNotFound if no backend held one.
+func (s *Store) Delete(host string) error {
+	host = Host(host)
+	defer forget(host)
+
+	found := false
+	var errs []error
+	for _, b := range s.backends {
+		err := b.Delete(host)
+		switch {
+		case err == nil:
+			found = true
+		case !errors.Is(err, ErrNotFound):
+			errs = append(errs, fmt.Errorf("%s: %w", b.Name(), err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if !found {
+		return fmt.Errorf("%w for %s", ErrNotFound, host)
+	}
+	return nil
+}
+
+// lookupTTL is how long Lookup remembers what is stored for a host, so
+// fetching many packages from one host reads the keychain once, while a
+// long-running process still picks up logins made meanwhile.
+var lookupTTL = time.Minute
+
+// lookupStore returns the store Lookup reads.
+var lookupStore = Default
+
+var (
+	lookupMu sync.Mutex
+	// lookups caches the results of Lookup by host.
+	lookups = make(map[string]lookup)
+)
+
+// lookup is a result of Lookup, and when it was looked up.
+type lookup struct {
+	cred Credential
+	ok   bool
+	at   time.Time
+}
+
+// Lookup returns the stored credential for host from the default store.
+// Lookup failures are treated as "no credential" so callers fall back to
+// their own authentication (git credential helpers, docker login, ...).
+// Results are cached for lookupTTL; Store.Set and Store.Delete clear them.
+func Lookup(host string) (Credential, bool) {
+	host = Host(host)
+
+	lookupMu.Lock()
+	defer lookupMu.Unlock()
+	if l, found := lookups[host]; found && time.Since(l.at) < lookupTTL {
+		return l.cred, l.ok
+	}
+
+	l := lookup{at: time.Now()}
+	if s, err := lookupStore(); err == nil {
+		if cred, err := s.Get(host); err == nil {
+			l.cred, l.ok = cred, true
+		}
+	}
+	lookups[host] = l
+	return l.cred, l.ok
+}
+
+// forget clears the cached Lookup of host, whose credential changed.
+func forget(host string) {
+	lookupMu.Lock()
+	defer lookupMu.Unlock()
+	delete(lookups, host)
+}
+
+// Host normalizes a host, URL, git SSH address, or image reference to the
+// lowercase host (with port) credentials are keyed by. e.g.
+//
+//	"https://github.com/org/repo.git" → "github.com"
+//	"git@gitlab.com:org/repo.git"     → "gitlab.com"
+//	"ghcr.io/org/image:tag"           → "ghcr.io"
+func Host(s string) string {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "://") {
+		if u, err := url.Parse(s); err == nil {
+			return strings.ToLower(u.Host)
+		}
+	}
+
+	// SSH shorthand: git@github.com:owner/repo.git
+	if at := strings.Index(s, "@"); at >= 0 {
+		if colon := strings.Index(s, ":"); colon > at && !strings.Contains(s[:colon], "/") {
+			return strings.ToLower(s[at+1 : colon])
+		}
+	}
+
+	if slash := strings.Index(s, "/"); slash >= 0 {
+		s = s[:slash]
+	}
+	return strings.ToLower(s)
+}
@@ -0,0 +1,199 @@
+package credentials
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHost(t *testing.T) {
+	tests := map[string]struct {
+		input string
+		want  string
+	}{
+		"bare host":          {input: "GitHub.com", want: "github.com"},
+		"https url":          {input: "https://github.com/org/repo.git", want: "github.com"},
+		"url with port":      {input: "https://git.example.com:8443/org/repo", want: "git.example.com:8443"},
+		"ssh shorthand":      {input: "git@gitlab.com:org/repo.git", want: "gitlab.com"},
+		"image reference":    {input: "ghcr.io/org/image:1.0", want: "ghcr.io"},
+		"registry with port": {input: "localhost:5000/org/image", want: "localhost:5000"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := Host(tc.input); got != tc.want {
+				t.Errorf("Host(%q) = %q, want %q", tc.input, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFile(t *testing.T) {
+	dir := t.TempDir()
+	f := &File{Dir: dir}
+
+	if _, err := f.Get("github.com"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get() on empty store error = %v, want ErrNotFound", err)
+	}
+
+	cred := Credential{Username: "me", Token: "s3cret-token"}
+	if err := f.Set("github.com", cred); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	got, err := f.Get("github.com")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got != cred {
+		t.Errorf("Get() = %+v, want %+v", got, cred)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, FileName))
+	if err != nil {
+		t.Fatalf("reading credentials file: %v", err)
+	}
+	if bytes.Contains(data, []byte(cred.Token)) {
+		t.Error("credentials file contains the plain-text token")
+	}
+	for _, name := range []string{FileName, KeyFileName} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("stat %s: %v", name, err)
+		}
+		if perm := info.Mode().Perm(); perm != 0o600 {
+			t.Errorf("%s mode = %o, want 600", name, perm)
+		}
+	}
+
+	if err := f.Delete("github.com"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := f.Delete("github.com"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second Delete() error = %v, want ErrNotFound", err)
+	}
+}
+
+// fakeBackend is an in-memory Backend that can be made to fail writes.
+type fakeBackend struct {
+	creds   map[string]Credential
+	failSet bool
+	gets    int
+}
+
+func (f *fakeBackend) Name() string { return "fake" }
+
+func (f *fakeBackend) Get(host string) (Credential, error) {
+	f.gets++
+	cred, ok := f.creds[host]
+	if !ok {
+		return Credential{}, ErrNotFound
+	}
+	return cred, nil
+}
+
+func (f *fakeBackend) Set(host string, cred Credential) error {
+	if f.failSet {
+		return errors.New("keychain locked")
+	}
+	f.creds[host] = cred
+	return nil
+}
+
+func (f *fakeBackend) Delete(host string) error {
+	if _, ok := f.creds[host]; !ok {
+		return ErrNotFound
+	}
+	delete(f.creds, host)
+	return nil
+}
+
+func TestStore(t *testing.T) {
+	tests := map[string]struct {
+		keychainFails bool
+		wantBackend   string
+	}{
+		"keychain preferred": {
+			wantBackend: "fake",
+		},
+		"falls back to file": {
+			keychainFails: true,
+			wantBackend:   "file",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			kc := &fakeBackend{creds: map[string]Credential{}, failSet: tc.keychainFails}
+			file := &File{Dir: t.TempDir()}
+			s := New(kc, file)
+
+			backend, err := s.Set("https://GitHub.com/org/repo", Credential{Token: "tok"})
+			if err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+			wantName := kc.Name()
+			if tc.wantBackend == "file" {
+				wantName = file.Name()
+			}
+			if backend != wantName {
+				t.Errorf("Set() backend = %q, want %q", backend, wantName)
+			}
+
+			cred, err := s.Get("github.com")
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if cred.Token != "tok" || cred.User() != DefaultUsername {
+				t.Errorf("Get() = %+v, want token %q with default username", cred, "tok")
+			}
+
+			if err := s.Delete("github.com"); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if _, err := s.Get("github.com"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get() after Delete() error = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	kc := &fakeBackend{creds: map[string]Credential{"github.com": {Token: "tok"}}}
+	s := New(kc)
+	lookupStore = func() (*Store, error) { return s, nil }
+	t.Cleanup(func() {
+		lookupStore = Default
+		lookups = make(map[string]lookup)
+	})
+
+	for range 3 {
+		if cred, ok := Lookup("github.com"); !ok || cred.Token != "tok" {
+			t.Fatalf("Lookup() = %+v, %v, want token %q", cred, ok, "tok")
+		}
+		if _, ok := Lookup("gitlab.com"); ok {
+			t.Fatal("Lookup() of a host without credentials succeeded")
+		}
+	}
+	if kc.gets != 2 {
+		t.Errorf("backend read %d times, want once per host", kc.gets)
+	}
+
+	// Logging out forgets the cached credential.
+	if err := s.Delete("github.com"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, ok := Lookup("github.com"); ok {
+		t.Error("Lookup() after Delete() succeeded")
+	}
+
+	// Logins made by other processes are seen once the cache expires.
+	kc.creds["gitlab.com"] = Credential{Token: "other"}
+	lookups["gitlab.com"] = lookup{at: time.Now().Add(-lookupTTL)}
+	if cred, ok := Lookup("gitlab.com"); !ok || cred.Token != "other" {
+		t.Errorf("Lookup() after expiry = %+v, %v, want token %q", cred, ok, "other")
+	}
+}
@@ -0,0 +1,158 @@
+package credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// FileName is the encrypted credentials file within the apkg config dir.
+	FileName = "credentials.enc"
+	// KeyFileName holds the AES-256 key for FileName. Both files are only
+	// readable by the current user; the encryption keeps tokens out of
+	// plain-text backups and accidental dotfile commits.
+	KeyFileName = "credentials.key"
+)
+
+// File stores credentials in an AES-GCM encrypted file. It is the fallback
+// when no OS keychain is available (headless Linux, containers, CI).
+type File struct {
+	Dir string
+}
+
+var _ Backend = &File{}
+
+func (f *File) Name() string {
+	return filepath.Join(f.Dir, FileName)
+}
+
+func (f *File) Get(host string) (Credential, error) {
+	creds, err := f.load()
+	if err != nil {
+		return Credential{}, err
+	}
+	cred, ok := creds[host]
+	if !ok {
+		return Credential{}, ErrNotFound
+	}
+	return cred, nil
+}
+
+func (f *File) Set(host string, cred Credential) error {
+	creds, err := f.load()
+	if err != nil {
+		return err
+	}
+	creds[host] = cred
+	return f.save(creds)
+}
+
+func (f *File) Delete(host string) error {
+	creds, err := f.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := creds[host]; !ok {
+		return ErrNotFound
+	}
+	delete(creds, host)
+	return f.save(creds)
+}
+
+// load decrypts the credentials file. A missing file yields an empty map.
+func (f *File) load() (map[string]Credential, error) {
+	data, err := os.ReadFile(filepath.Join(f.Dir, FileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]Credential{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading credentials: %w", err)
+	}
+
+	gcm, err := f.cipher(false)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("credentials file is corrupt")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting credentials: %w", err)
+	}
+
+	creds := map[string]Credential{}
+	if err := json.Unmarshal(plaintext, &creds); err != nil {
+		return nil, fmt.Errorf("decoding credentials: %w", err)
+	}
+	return creds, nil
+}
+
+// save encrypts creds with a fresh nonce and writes them atomically.
+func (f *File) save(creds map[string]Credential) error {
+	plaintext, err := json.Marshal(creds)
+	if err != nil {
+		return fmt.Errorf("encoding credentials: %w", err)
+	}
+
+	gcm, err := f.cipher(true)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("generating nonce: %w", err)
+	}
+	data := gcm.Seal(nonce, nonce, plaintext, nil)
+
+	if err := os.MkdirAll(f.Dir, 0o755); err != nil {
+		return fmt.Errorf("creating %s: %w", f.Dir, err)
+	}
+	path := filepath.Join(f.Dir, FileName)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("writing credentials: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("writing credentials: %w", err)
+	}
+	return nil
+}
+
+// cipher returns the AES-GCM cipher for the key file, generating the key
+// first when create is set and none exists yet.
+func (f *File) cipher(create bool) (cipher.AEAD, error) {
+	keyPath := filepath.Join(f.Dir, KeyFileName)
+	key, err := os.ReadFile(keyPath)
+	if errors.Is(err, fs.ErrNotExist) && create {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("generating credentials key: %w", err)
+		}
+		if err := os.MkdirAll(f.Dir, 0o755); err != nil {
+			return nil, fmt.Errorf("creating %s: %w", f.Dir, err)
+		}
+		if err := os.WriteFile(keyPath, key, 0o600); err != nil {
+			return nil, fmt.Errorf("writing credentials key: %w", err)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("reading credentials key: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("loading credentials key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
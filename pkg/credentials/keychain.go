@@ -0,0 +1,140 @@
+package credentials
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// keychainService is the service name credentials are stored under.
+const keychainService = "apkg"
+
+// keychainTimeout bounds each keychain call so a locked keyring waiting on
+// an unlock prompt that never appears doesn't hang installs.
+const keychainTimeout = 10 * time.Second
+
+// Keychain stores credentials in the OS keychain via its CLI: security(1)
+// on macOS and secret-tool(1) (libsecret) on Linux. Secrets are passed on
+// stdin so they don't show up in process listings.
+type Keychain struct {
+	Path string // absolute path to the binary
+	Tool string // "security" or "secret-tool"
+}
+
+var _ Backend = &Keychain{}
+
+// DetectKeychain returns the OS keychain backend, or nil if none is
+// available on this platform.
+func DetectKeychain() *Keychain {
+	tool := ""
+	switch runtime.GOOS {
+	case "darwin":
+		tool = "security"
+	case "linux", "freebsd", "openbsd", "netbsd":
+		tool = "secret-tool"
+	default:
+		return nil
+	}
+
+	path, err := exec.LookPath(tool)
+	if err != nil {
+		return nil
+	}
+	return &Keychain{Path: path, Tool: tool}
+}
+
+func (k *Keychain) Name() string {
+	return "OS keychain (" + k.Tool + ")"
+}
+
+func (k *Keychain) Get(host string) (Credential, error) {
+	var args []string
+	switch k.Tool {
+	case "security":
+		args = []string{"find-generic-password", "-s", keychainService, "-a", host, "-w"}
+	default:
+		args = []string{"lookup", "service", keychainService, "host", host}
+	}
+
+	out, err := k.run("", args...)
+	if err != nil {
+		return Credential{}, err
+	}
+
+	secret := strings.TrimSpace(string(out))
+	if secret == "" {
+		return Credential{}, ErrNotFound
+	}
+
+	var cred Credential
+	if err := json.Unmarshal([]byte(secret), &cred); err != nil {
+		return Credential{}, fmt.Errorf("decoding keychain entry for %s: %w", host, err)
+	}
+	return cred, nil
+}
+
+func (k *Keychain) Set(host string, cred Credential) error {
+	secret, err := json.Marshal(cred)
+	if err != nil {
+		return err
+	}
+
+	switch k.Tool {
+	case "security":
+		// security -i reads commands from stdin; -X takes the password as
+		// hex so it needs no quoting.
+		line := fmt.Sprintf("add-generic-password -U -s %s -a %s -l %q -X %s\n",
+			keychainService, host, "apkg: "+host, hex.EncodeToString(secret))
+		_, err = k.run(line, "-i")
+	default:
+		_, err = k.run(string(secret), "store", "--label=apkg: "+host, "service", keychainService, "host", host)
+	}
+	return err
+}
+
+func (k *Keychain) Delete(host string) error {
+	if _, err := k.Get(host); err != nil {
+		return err
+	}
+
+	var args []string
+	switch k.Tool {
+	case "security":
+		args = []string{"delete-generic-password", "-s", keychainService, "-a", host}
+	default:
+		args = []string{"clear", "service", keychainService, "host", host}
+	}
+	_, err := k.run("", args...)
+	return err
+}
+
+// run executes the keychain CLI with stdin as input. A non-zero exit
+// without an error message is reported as ErrNotFound, which is how both
+// tools signal a missing entry.
+func (k *Keychain) run(stdin string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), keychainTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, k.Path, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	out, err := cmd.Output()
+	if err == nil {
+		return out, nil
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		stderr := strings.TrimSpace(string(exitErr.Stderr))
+		if stderr == "" || strings.Contains(stderr, "could not be found") {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("%w: %s", err, stderr)
+	}
+	return nil, err
+}
@@ -0,0 +1,197 @@
+package fsutil
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ExtractTarGz extracts the gzipped tar stream r into dest, dropping the
+// first strip path components of every entry. Entries and symlinks that
+// would land outside dest are rejected, as are entries under a symlink,
+// which could otherwise chain out of dest.
+func ExtractTarGz(r io.Reader, dest string, strip int) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("reading archive: %w", err)
+	}
+	defer gz.Close()
+
+	if err := os.MkdirAll(dest, dirPerm); err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading archive: %w", err)
+		}
+
+		name, path, err := archivePath(dest, hdr.Name, strip)
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			continue
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, dirPerm); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeFile(path, tr, hdr.FileInfo().Mode().Perm()); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := archiveSymlink(hdr.Name, hdr.Linkname, name, path); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ExtractZip extracts the zip archive at src into dest like ExtractTarGz.
+func ExtractZip(src, dest string, strip int) error {
+	zr, err := zip.OpenReader(src)
+	if err != nil {
+		return fmt.Errorf("reading archive: %w", err)
+	}
+	defer zr.Close()
+
+	if err := os.MkdirAll(dest, dirPerm); err != nil {
+		return err
+	}
+
+	for _, f := range zr.File {
+		name, path, err := archivePath(dest, f.Name, strip)
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			continue
+		}
+
+		mode := f.Mode()
+		switch {
+		case mode.IsDir():
+			if err := os.MkdirAll(path, dirPerm); err != nil {
+				return err
+			}
+		case mode&os.ModeSymlink != 0:
+			target, err := readZipFile(f)
+			if err != nil {
+				return err
+			}
+			if err := archiveSymlink(f.Name, target, name, path); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			rc, err := f.Open()
+			if err != nil {
+				return fmt.Errorf("reading archive: %w", err)
+			}
+			// Archives made on Windows carry no permissions.
+			perm := mode.Perm()
+			if perm == 0 {
+				perm = 0o644
+			}
+			err = writeFile(path, rc, perm)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// archivePath returns the name of the archive entry entry without its
+// first strip components, or "" if that leaves nothing, and its path in
+// dest. Entries whose parent directories in dest include a symlink are
+// rejected.
+func archivePath(dest, entry string, strip int) (name, path string, err error) {
+	parts := strings.Split(strings.Trim(filepath.ToSlash(entry), "/"), "/")
+	if len(parts) <= strip {
+		return "", "", nil
+	}
+	name = filepath.Join(parts[strip:]...)
+	if !filepath.IsLocal(name) {
+		return "", "", fmt.Errorf("archive entry %q escapes the install directory", entry)
+	}
+	dir := dest
+	for _, part := range parts[strip : len(parts)-1] {
+		dir = filepath.Join(dir, part)
+		if fi, err := os.Lstat(dir); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+			return "", "", fmt.Errorf("archive entry %q is under a symlink", entry)
+		}
+	}
+	return name, filepath.Join(dest, name), nil
+}
+
+// archiveSymlink creates the symlink entry at path, named name in the
+// archive, to target, which must stay inside the archive. Only leading
+// ".." components are allowed, so the target climbs real directories
+// (archivePath keeps symlinks out of an entry's parents) and every
+// symlink it descends through was checked the same way.
+func archiveSymlink(entry, target, name, path string) error {
+	rest := filepath.ToSlash(target)
+	for strings.HasPrefix(rest, "../") {
+		rest = rest[len("../"):]
+	}
+	escapes := filepath.IsAbs(target) || !filepath.IsLocal(filepath.Join(filepath.Dir(name), target))
+	if escapes || rest == ".." || slices.Contains(strings.Split(rest, "/"), "..") {
+		return fmt.Errorf("archive symlink %q points outside the install directory", entry)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), dirPerm); err != nil {
+		return err
+	}
+	return os.Symlink(target, path)
+}
+
+func readZipFile(f *zip.File) (string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return "", fmt.Errorf("reading archive: %w", err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return "", fmt.Errorf("reading archive: %w", err)
+	}
+	return string(data), nil
+}
+
+// writeFile writes r to path, replacing a symlink an earlier entry put
+// there rather than writing through it.
+func writeFile(path string, r io.Reader, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), dirPerm); err != nil {
+		return err
+	}
+	if fi, err := os.Lstat(path); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,163 @@
+package fsutil
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type archiveEntry struct {
+	name     string
+	body     string
+	linkname string
+}
+
+func TestExtract(t *testing.T) {
+	tests := map[string]struct {
+		entries []archiveEntry
+		// want maps files to their contents, c = "b" if nil.
+		want    map[string]string
+		wantErr bool
+	}{
+		"nested files and relative symlink": {
+			entries: []archiveEntry{
+				{name: "top/a/b.txt", body: "b"},
+				{name: "top/c", linkname: "a/b.txt"},
+			},
+		},
+		"path traversal": {
+			entries: []archiveEntry{{name: "top/../../evil", body: "x"}},
+			wantErr: true,
+		},
+		"symlink out of the tree": {
+			entries: []archiveEntry{{name: "top/link", linkname: "../../etc/passwd"}},
+			wantErr: true,
+		},
+		"symlink chain out of the tree": {
+			entries: []archiveEntry{
+				{name: "top/a/b", linkname: ".."},
+				{name: "top/a/b/d", linkname: ".."},
+				{name: "top/a/b/d/escaped.txt", body: "x"},
+			},
+			wantErr: true,
+		},
+		"symlink climbing through a name": {
+			entries: []archiveEntry{
+				{name: "top/a/b.txt", body: "b"},
+				{name: "top/c", linkname: "a/../a/b.txt"},
+			},
+			wantErr: true,
+		},
+		"file replacing a symlink": {
+			entries: []archiveEntry{
+				{name: "top/a/b.txt", body: "a"},
+				{name: "top/c", linkname: "a/b.txt"},
+				{name: "top/c", body: "b"},
+			},
+			want: map[string]string{"c": "b", "a/b.txt": "a"},
+		},
+		"absolute symlink": {
+			entries: []archiveEntry{{name: "top/link", linkname: "/etc/passwd"}},
+			wantErr: true,
+		},
+	}
+
+	formats := map[string]func(t *testing.T, entries []archiveEntry, dest string) error{
+		"tar.gz": func(t *testing.T, entries []archiveEntry, dest string) error {
+			return ExtractTarGz(bytes.NewReader(makeTarGz(t, entries)), dest, 1)
+		},
+		"zip": func(t *testing.T, entries []archiveEntry, dest string) error {
+			src := filepath.Join(t.TempDir(), "archive.zip")
+			if err := os.WriteFile(src, makeZip(t, entries), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			return ExtractZip(src, dest, 1)
+		},
+	}
+
+	for name, tc := range tests {
+		for format, extract := range formats {
+			t.Run(name+"/"+format, func(t *testing.T) {
+				dest := filepath.Join(t.TempDir(), "out")
+				err := extract(t, tc.entries, dest)
+				if (err != nil) != tc.wantErr {
+					t.Fatalf("extracting error = %v, wantErr %v", err, tc.wantErr)
+				}
+				if tc.wantErr {
+					return
+				}
+				want := tc.want
+				if want == nil {
+					want = map[string]string{"c": "b"}
+				}
+				for file, body := range want {
+					data, err := os.ReadFile(filepath.Join(dest, file))
+					if err != nil || string(data) != body {
+						t.Errorf("%s = %q, %v; want %q", file, data, err, body)
+					}
+				}
+				if _, err := os.Stat(filepath.Join(filepath.Dir(dest), "escaped.txt")); err == nil {
+					t.Error("extracting wrote a file outside dest")
+				}
+			})
+		}
+	}
+}
+
+func makeTarGz(t *testing.T, entries []archiveEntry) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Mode: 0o755, Size: int64(len(e.body)), Typeflag: tar.TypeReg}
+		if e.linkname != "" {
+			hdr.Typeflag, hdr.Linkname, hdr.Size = tar.TypeSymlink, e.linkname, 0
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func makeZip(t *testing.T, entries []archiveEntry) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		hdr := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		body := e.body
+		hdr.SetMode(0o644)
+		if e.linkname != "" {
+			hdr.SetMode(os.ModeSymlink | 0o777)
+			body = e.linkname
+		}
+		w, err := zw.CreateHeader(hdr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
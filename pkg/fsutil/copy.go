@@ -0,0 +1,115 @@
+package fsutil
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+const dirPerm = 0o755
+
+// SkipFunc reports whether the entry at rel (relative to the copy root)
+// should be left out of a copy. Returning true for a directory skips its
+// whole subtree.
+type SkipFunc func(rel string, d fs.DirEntry) bool
+
+// SkipGitDir is a SkipFunc that leaves out .git directories.
+func SkipGitDir(rel string, d fs.DirEntry) bool {
+	return d.IsDir() && d.Name() == ".git"
+}
+
+// CopyDir recursively copies the directory tree at src into dst, replacing
+// anything already at dst. File modes are preserved and symlinks are
+// recreated as symlinks. skip may be nil.
+func CopyDir(src, dst string, skip SkipFunc) error {
+	return copyTree(src, dst, skip, copyFile)
+}
+
+// LinkDir is CopyDir hardlinking files instead of copying them, so the
+// copy takes no space but shares its files' content with src. Files that
+// can't be hardlinked, e.g. because dst is on another filesystem, are
+// copied.
+func LinkDir(src, dst string, skip SkipFunc) error {
+	return copyTree(src, dst, skip, linkFile)
+}
+
+// copyTree is CopyDir creating the files of dst with create.
+func copyTree(src, dst string, skip SkipFunc, create func(src, dst string) error) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", src, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", src)
+	}
+
+	if err := os.RemoveAll(dst); err != nil {
+		return fmt.Errorf("removing %s: %w", dst, err)
+	}
+
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if rel != "." && skip != nil && skip(rel, d) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, dirPerm)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			return create(path, target)
+		}
+	})
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// linkFile hardlinks dst to src, copying src where that fails.
+func linkFile(src, dst string) error {
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+	return copyFile(src, dst)
+}
@@ -0,0 +1,91 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestCopyDir(t *testing.T) {
+	tests := map[string]struct {
+		skip      SkipFunc
+		wantFiles []string
+		wantGone  []string
+	}{
+		"copies everything": {
+			wantFiles: []string{"SKILL.md", "scripts/run.sh", ".git/HEAD"},
+		},
+		"skips git metadata": {
+			skip:      SkipGitDir,
+			wantFiles: []string{"SKILL.md", "scripts/run.sh"},
+			wantGone:  []string{".git"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			src := t.TempDir()
+			os.MkdirAll(filepath.Join(src, "scripts"), 0o755)
+			os.MkdirAll(filepath.Join(src, ".git"), 0o755)
+			os.WriteFile(filepath.Join(src, "SKILL.md"), []byte("skill"), 0o644)
+			os.WriteFile(filepath.Join(src, "scripts", "run.sh"), []byte("#!/bin/sh\n"), 0o755)
+			os.WriteFile(filepath.Join(src, ".git", "HEAD"), []byte("ref"), 0o644)
+
+			dst := filepath.Join(t.TempDir(), "out")
+			// Pre-existing content at dst must be replaced.
+			os.MkdirAll(dst, 0o755)
+			os.WriteFile(filepath.Join(dst, "stale.txt"), []byte("old"), 0o644)
+
+			if err := CopyDir(src, dst, tc.skip); err != nil {
+				t.Fatalf("CopyDir() error: %v", err)
+			}
+
+			for _, f := range tc.wantFiles {
+				if _, err := os.Stat(filepath.Join(dst, f)); err != nil {
+					t.Errorf("expected %s to be copied: %v", f, err)
+				}
+			}
+			for _, f := range append(tc.wantGone, "stale.txt") {
+				if _, err := os.Stat(filepath.Join(dst, f)); !os.IsNotExist(err) {
+					t.Errorf("expected %s to be absent", f)
+				}
+			}
+
+			info, err := os.Stat(filepath.Join(dst, "scripts", "run.sh"))
+			if err != nil {
+				t.Fatalf("stat run.sh: %v", err)
+			}
+			// Windows has no executable bit to keep.
+			if runtime.GOOS != "windows" && info.Mode().Perm() != 0o755 {
+				t.Errorf("run.sh mode = %v, want 0755", info.Mode().Perm())
+			}
+		})
+	}
+}
+
+func TestLinkDir(t *testing.T) {
+	src := t.TempDir()
+	os.MkdirAll(filepath.Join(src, "scripts"), 0o755)
+	os.WriteFile(filepath.Join(src, "SKILL.md"), []byte("skill"), 0o644)
+	os.WriteFile(filepath.Join(src, "scripts", "run.sh"), []byte("#!/bin/sh\n"), 0o755)
+
+	dst := filepath.Join(t.TempDir(), "out")
+	if err := LinkDir(src, dst, nil); err != nil {
+		t.Fatalf("LinkDir() error: %v", err)
+	}
+
+	for _, f := range []string{"SKILL.md", "scripts/run.sh"} {
+		want, err := os.Stat(filepath.Join(src, f))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := os.Stat(filepath.Join(dst, f))
+		if err != nil {
+			t.Fatalf("expected %s to be linked: %v", f, err)
+		}
+		if !os.SameFile(got, want) {
+			t.Errorf("%s is not a hardlink to the source", f)
+		}
+	}
+}
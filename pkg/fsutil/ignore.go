@@ -0,0 +1,193 @@
+package fsutil
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFile lists, in gitignore syntax, the files in a local package
+// directory that are not part of the package, e.g. editor droppings,
+// virtualenvs, or test fixtures. apkg leaves them out of the copies it
+// makes and the skills it projects.
+const IgnoreFile = ".apkgignore"
+
+// Ignore is a parsed IgnoreFile.
+type Ignore struct {
+	rules []ignoreRule
+}
+
+type ignoreRule struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// LoadIgnore reads the IgnoreFile in dir, returning nil if there is none.
+func LoadIgnore(dir string) (*Ignore, error) {
+	path := filepath.Join(dir, IgnoreFile)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	ig, err := ParseIgnore(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return ig, nil
+}
+
+// ParseIgnore parses ignore patterns in gitignore syntax: one per line,
+// with blank lines and lines starting with # skipped, ! negating a
+// pattern, a trailing / matching only directories, a leading or inner /
+// anchoring a pattern to the directory's root, and *, ?, [...], and **
+// globbing.
+func ParseIgnore(data []byte) (*Ignore, error) {
+	ig := &Ignore{}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; sc.Scan(); line++ {
+		pattern := trimIgnoreLine(sc.Text())
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+
+		var rule ignoreRule
+		if strings.HasPrefix(pattern, "!") {
+			rule.negate = true
+			pattern = pattern[1:]
+		} else if strings.HasPrefix(pattern, `\!`) || strings.HasPrefix(pattern, `\#`) {
+			pattern = pattern[1:]
+		}
+		if strings.HasSuffix(pattern, "/") {
+			rule.dirOnly = true
+			pattern = strings.TrimRight(pattern, "/")
+		}
+		if pattern == "" {
+			continue
+		}
+
+		// Patterns without a slash match at any depth.
+		anchored := strings.Contains(pattern, "/")
+		pattern = strings.TrimPrefix(pattern, "/")
+		if !anchored && !strings.HasPrefix(pattern, "**") {
+			pattern = "**/" + pattern
+		}
+
+		re, err := regexp.Compile("^" + globToRegexp(pattern) + "$")
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid pattern %q: %w", line, sc.Text(), err)
+		}
+		rule.re = re
+		ig.rules = append(ig.rules, rule)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return ig, nil
+}
+
+// Match reports whether the entry at rel, a slash-separated path relative
+// to the directory of the IgnoreFile, is ignored, either itself or by an
+// ignored parent directory. A nil Ignore ignores nothing.
+func (ig *Ignore) Match(rel string, isDir bool) bool {
+	if ig == nil {
+		return false
+	}
+	// As in git, files in an ignored directory can't be re-included.
+	for i := strings.Index(rel, "/"); i >= 0; i = indexFrom(rel, "/", i+1) {
+		if ig.match(rel[:i], true) {
+			return true
+		}
+	}
+	return ig.match(rel, isDir)
+}
+
+// match reports whether rel itself is ignored: the last rule matching it
+// decides.
+func (ig *Ignore) match(rel string, isDir bool) bool {
+	ignored := false
+	for _, rule := range ig.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.re.MatchString(rel) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// Skip is a SkipFunc that leaves out what ig ignores, along with VCS
+// metadata and JunkFiles (see SkipJunk).
+func (ig *Ignore) Skip(rel string, d fs.DirEntry) bool {
+	return IsJunk(d) || ig.Match(filepath.ToSlash(rel), d.IsDir())
+}
+
+// trimIgnoreLine removes a line's trailing spaces unless they are escaped
+// with a backslash.
+func trimIgnoreLine(line string) string {
+	trimmed := strings.TrimRight(line, " \t\r")
+	if strings.HasSuffix(trimmed, `\`) && len(trimmed) < len(line) {
+		trimmed = trimmed[:len(trimmed)-1] + " "
+	}
+	return trimmed
+}
+
+// globToRegexp translates a gitignore glob into a regular expression: **
+// spans directories, while * and ? don't match a slash.
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			b.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// indexFrom returns the index of sep in s at or after from, or -1.
+func indexFrom(s, sep string, from int) int {
+	if i := strings.Index(s[from:], sep); i >= 0 {
+		return from + i
+	}
+	return -1
+}
@@ -0,0 +1,70 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIgnoreMatch(t *testing.T) {
+	tests := map[string]struct {
+		patterns string
+		rel      string
+		isDir    bool
+		want     bool
+	}{
+		"basename at any depth":        {patterns: "*.pyc", rel: "scripts/lib/x.pyc", want: true},
+		"basename not matching":        {patterns: "*.pyc", rel: "scripts/x.py", want: false},
+		"star doesn't cross dirs":      {patterns: "/*.md", rel: "docs/x.md", want: false},
+		"anchored at root":             {patterns: "/build", rel: "build", isDir: true, want: true},
+		"anchored not nested":          {patterns: "/build", rel: "src/build", isDir: true, want: false},
+		"inner slash anchors":          {patterns: "tests/fixtures", rel: "tests/fixtures", isDir: true, want: true},
+		"file in ignored dir":          {patterns: ".venv/", rel: ".venv/lib/site.py", want: true},
+		"dir-only skips files":         {patterns: "cache/", rel: "cache", want: false},
+		"double star dirs":             {patterns: "docs/**/*.tmp", rel: "docs/a/b/c.tmp", want: true},
+		"double star zero dirs":        {patterns: "docs/**/*.tmp", rel: "docs/c.tmp", want: true},
+		"trailing double star":         {patterns: "tests/**", rel: "tests/a/b", want: true},
+		"negation re-includes":         {patterns: "*.md\n!SKILL.md", rel: "SKILL.md", want: false},
+		"negation order matters":       {patterns: "!SKILL.md\n*.md", rel: "SKILL.md", want: true},
+		"no re-include in ignored dir": {patterns: "notes/\n!notes/keep.md", rel: "notes/keep.md", want: true},
+		"comments and blanks":          {patterns: "# *.md\n\n", rel: "x.md", want: false},
+		"escaped hash":                 {patterns: `\#notes`, rel: "#notes", want: true},
+		"character class":              {patterns: "*.sw[op]", rel: ".x.swp", want: true},
+		"negated character class":      {patterns: "file[!0-9]", rel: "file1", want: false},
+		"question mark":                {patterns: "?.log", rel: "a.log", want: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ig, err := ParseIgnore([]byte(tc.patterns))
+			if err != nil {
+				t.Fatalf("ParseIgnore() error = %v", err)
+			}
+			if got := ig.Match(tc.rel, tc.isDir); got != tc.want {
+				t.Errorf("Match(%q, %v) = %v, want %v", tc.rel, tc.isDir, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLoadIgnore(t *testing.T) {
+	dir := t.TempDir()
+	ig, err := LoadIgnore(dir)
+	if err != nil || ig != nil {
+		t.Fatalf("LoadIgnore() without %s = %v, %v, want nil", IgnoreFile, ig, err)
+	}
+	if ig.Match("anything", false) {
+		t.Error("nil Ignore matched")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, IgnoreFile), []byte("*.log\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ig, err = LoadIgnore(dir)
+	if err != nil {
+		t.Fatalf("LoadIgnore() error = %v", err)
+	}
+	if !ig.Match("debug.log", false) {
+		t.Error("Match(debug.log) = false, want true")
+	}
+}
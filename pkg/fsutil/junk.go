@@ -0,0 +1,53 @@
+package fsutil
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// JunkFiles are files that operating systems and file managers leave
+// behind, which are never part of a package's content.
+var JunkFiles = []string{".DS_Store", "Thumbs.db", "desktop.ini"}
+
+// IsJunk reports whether the entry d is VCS metadata (a .git directory or
+// file) or one of JunkFiles.
+func IsJunk(d fs.DirEntry) bool {
+	return d.Name() == ".git" || (!d.IsDir() && slices.Contains(JunkFiles, d.Name()))
+}
+
+// SkipJunk is a SkipFunc that leaves out VCS metadata and JunkFiles.
+func SkipJunk(rel string, d fs.DirEntry) bool {
+	return IsJunk(d)
+}
+
+// RemoveJunk deletes VCS metadata and JunkFiles under dir, so content
+// materialized into the store holds only the package itself.
+func RemoveJunk(dir string) error {
+	var junk []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir || !IsJunk(d) {
+			return nil
+		}
+		junk = append(junk, path)
+		if d.IsDir() {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("walking %s: %w", dir, err)
+	}
+
+	for _, path := range junk {
+		if err := os.RemoveAll(path); err != nil {
+			return fmt.Errorf("removing %s: %w", path, err)
+		}
+	}
+	return nil
+}
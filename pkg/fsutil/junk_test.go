@@ -0,0 +1,42 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRemoveJunk(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]bool{
+		"SKILL.md":                            true,
+		filepath.Join("scripts", "run.sh"):    true,
+		filepath.Join("docs", "Thumbs.db.md"): true,
+		".DS_Store":                           false,
+		filepath.Join("scripts", ".DS_Store"): false,
+		filepath.Join("docs", "Thumbs.db"):    false,
+		filepath.Join(".git", "HEAD"):         false,
+		filepath.Join("vendor", ".git"):       false, // submodule gitfile
+	}
+	for f := range files {
+		os.MkdirAll(filepath.Join(dir, filepath.Dir(f)), 0o755)
+		os.WriteFile(filepath.Join(dir, f), []byte("x"), 0o644)
+	}
+
+	if err := RemoveJunk(dir); err != nil {
+		t.Fatalf("RemoveJunk() error: %v", err)
+	}
+
+	for f, keep := range files {
+		_, err := os.Stat(filepath.Join(dir, f))
+		if keep && err != nil {
+			t.Errorf("expected %s to be kept: %v", f, err)
+		}
+		if !keep && !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed", f)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".git")); !os.IsNotExist(err) {
+		t.Errorf("expected .git to be removed")
+	}
+}
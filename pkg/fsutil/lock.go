@@ -0,0 +1,44 @@
+package fsutil
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// staleLock is how old a lock file must be to be taken for one left
+// behind by a process that died holding it.
+var staleLock = 10 * time.Minute
+
+// lockPoll is how often Lock checks whether a held lock was released.
+const lockPoll = 50 * time.Millisecond
+
+// Lock takes the lock at path, a file that exists while some process
+// holds it, waiting up to timeout for the process holding it to release
+// it. A lock file older than staleLock is removed and taken over. The
+// returned func releases the lock.
+func Lock(path string, timeout time.Duration) (func(), error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("taking lock %s: %w", path, err)
+		}
+
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > staleLock {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s is held by another apkg process; remove it if none is running", path)
+		}
+		time.Sleep(lockPoll)
+	}
+}
@@ -0,0 +1,68 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLock(t *testing.T) {
+	tests := map[string]struct {
+		setup   func(t *testing.T, path string)
+		wantErr bool
+	}{
+		"free": {},
+		"held": {
+			setup: func(t *testing.T, path string) {
+				unlock, err := Lock(path, 0)
+				if err != nil {
+					t.Fatal(err)
+				}
+				t.Cleanup(unlock)
+			},
+			wantErr: true,
+		},
+		"released": {
+			setup: func(t *testing.T, path string) {
+				unlock, err := Lock(path, 0)
+				if err != nil {
+					t.Fatal(err)
+				}
+				time.AfterFunc(100*time.Millisecond, unlock)
+			},
+		},
+		"stale": {
+			setup: func(t *testing.T, path string) {
+				if err := os.WriteFile(path, []byte("1\n"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				old := time.Now().Add(-2 * staleLock)
+				if err := os.Chtimes(path, old, old); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.lock")
+			if tc.setup != nil {
+				tc.setup(t, path)
+			}
+
+			unlock, err := Lock(path, time.Second)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Lock() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			unlock()
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("lock file left after unlock: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package fsutil
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// SymlinkDir creates link as a symlink to the directory target. Creating
+// symlinks on Windows needs Developer Mode or an elevated shell, so there
+// it falls back to a directory junction, which doesn't. Junctions can only
+// point to absolute paths, so a relative target is resolved from link's
+// directory first.
+func SymlinkDir(target, link string) error {
+	err := os.Symlink(target, link)
+	if err == nil || runtime.GOOS != "windows" {
+		return err
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(link), target)
+	}
+	if jerr := junction(target, link); jerr != nil {
+		return fmt.Errorf("%w (creating a junction instead failed: %v)", err, jerr)
+	}
+	return nil
+}
+
+// ReplaceSymlinkDir points the symlink or junction at link to target (see
+// SymlinkDir), creating it next to link first and renaming it into place,
+// so agents never see it missing. Windows can't rename over a directory
+// link, so there the old link is removed first.
+func ReplaceSymlinkDir(target, link string) error {
+	tmp := link + ".tmp"
+	if err := os.Remove(tmp); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove existing temporary link dir: %w", err)
+	}
+	if err := SymlinkDir(target, tmp); err != nil {
+		return fmt.Errorf("failed to create temporary symlink: %w", err)
+	}
+	if runtime.GOOS == "windows" {
+		if err := os.Remove(link); err != nil && !os.IsNotExist(err) {
+			os.Remove(tmp)
+			return fmt.Errorf("failed to remove symlink: %w", err)
+		}
+	}
+	if err := os.Rename(tmp, link); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to rename temporary symlink: %w", err)
+	}
+	return nil
+}
+
+// junction creates link as a directory junction to target with mklink,
+// which Go has no API for.
+func junction(target, link string) error {
+	out, err := exec.Command("cmd", "/c", "mklink", "/J", link, target).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("mklink: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
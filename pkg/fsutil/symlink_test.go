@@ -0,0 +1,65 @@
+package fsutil
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestSymlinkDir(t *testing.T) {
+	tests := map[string]struct {
+		link    func(target, link string) error
+		windows bool // only on Windows
+	}{
+		"symlink":  {link: SymlinkDir},
+		"junction": {link: junction, windows: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if tc.windows && runtime.GOOS != "windows" {
+				t.Skip("junctions are Windows only")
+			}
+			dir := t.TempDir()
+			for _, d := range []string{"v1", "v2"} {
+				os.MkdirAll(filepath.Join(dir, d), 0o755)
+				os.WriteFile(filepath.Join(dir, d, "SKILL.md"), []byte(d), 0o644)
+			}
+			link := filepath.Join(dir, "skill")
+
+			if err := tc.link(filepath.Join(dir, "v1"), link); err != nil {
+				t.Fatalf("creating link: %v", err)
+			}
+			info, err := os.Lstat(link)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode()&os.ModeSymlink == 0 {
+				t.Errorf("link mode = %v, want a symlink", info.Mode())
+			}
+			assertContent(t, filepath.Join(link, "SKILL.md"), "v1")
+
+			if err := ReplaceSymlinkDir(filepath.Join(dir, "v2"), link); err != nil {
+				t.Fatalf("ReplaceSymlinkDir() error: %v", err)
+			}
+			assertContent(t, filepath.Join(link, "SKILL.md"), "v2")
+
+			if err := os.Remove(link); err != nil {
+				t.Fatalf("removing link: %v", err)
+			}
+			assertContent(t, filepath.Join(dir, "v2", "SKILL.md"), "v2")
+		})
+	}
+}
+
+func assertContent(t *testing.T, path, want string) {
+	t.Helper()
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %s: %v", path, err)
+	}
+	if string(got) != want {
+		t.Errorf("%s = %q, want %q", path, got, want)
+	}
+}
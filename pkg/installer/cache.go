@@ -0,0 +1,154 @@
+package installer
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// CacheEntry is a package fetched into the store: a skill repo clone at a
+// commit, or an MCP server package at a version or config.
+type CacheEntry struct {
+	// Path is the entry's location relative to the store root, slash
+	// separated, e.g. "npm/@modelcontextprotocol/server-fs/1.0.0".
+	Path string `json:"path"`
+	Size int64  `json:"size"` // bytes of the files under Path
+	// LastUsed is the last time an install used the entry, or when it was
+	// fetched if no install has recorded using it.
+	LastUsed time.Time `json:"last_used"`
+}
+
+// CacheEntries returns the package store entries under prefix, a store
+// path like "npm" or "repos/github.com/org", sorted by path; every entry
+// if prefix is empty. Entries are the commit directories of repo clones
+// and, in the other package directories (see packageDirs), the first
+// directory on each path that holds files, such as an MCP package's
+// mcp.toml.
+func (inst *Installer) CacheEntries(prefix string) ([]CacheEntry, error) {
+	roots := packageDirs
+	var prefixSegs []string
+	if prefix != "" {
+		var err error
+		prefixSegs, err = cacheSegments(prefix)
+		if err != nil {
+			return nil, err
+		}
+		roots = prefixSegs[:1]
+	}
+
+	var entries []CacheEntry
+	var list func(segs []string) error
+	list = func(segs []string) error {
+		dir := inst.Store.Path(segs...)
+		children, err := os.ReadDir(dir)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", dir, err)
+		}
+
+		var isEntry bool
+		switch {
+		case len(segs) < 2:
+		case segs[0] == "repos":
+			isEntry = isCommitDir(segs[len(segs)-1])
+		default:
+			isEntry = slices.ContainsFunc(children, func(e os.DirEntry) bool { return !e.IsDir() })
+		}
+		if !isEntry && len(segs) < len(prefixSegs) {
+			// Above the prefix: only descend towards it.
+			return list(prefixSegs[:len(segs)+1])
+		}
+		if !isEntry {
+			for _, e := range children {
+				if e.IsDir() {
+					if err := list(append(slices.Clip(segs), e.Name())); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		}
+
+		size, err := treeSize(dir)
+		if err != nil {
+			return fmt.Errorf("measuring %s: %w", dir, err)
+		}
+		lastUsed := inst.Store.LastUsed(segs...)
+		if lastUsed.IsZero() {
+			if info, err := os.Stat(dir); err == nil {
+				lastUsed = info.ModTime()
+			}
+		}
+		entries = append(entries, CacheEntry{Path: path.Join(segs...), Size: size, LastUsed: lastUsed})
+		return nil
+	}
+
+	for _, dir := range roots {
+		if err := list([]string{dir}); err != nil {
+			return entries, err
+		}
+	}
+	slices.SortFunc(entries, func(a, b CacheEntry) int { return strings.Compare(a.Path, b.Path) })
+	return entries, nil
+}
+
+// RemoveCacheEntry deletes the store path rel, a package store entry or a
+// directory of entries (e.g. "npm/@mcp/fs" for every cached version), and
+// returns what was deleted. Paths outside the package directories are
+// rejected: the store root also holds runtimes, logs, and apkg's own
+// configuration. Projects using a deleted entry re-fetch it on their next
+// install.
+func (inst *Installer) RemoveCacheEntry(rel string) (Garbage, error) {
+	segs, err := cacheSegments(rel)
+	if err != nil {
+		return Garbage{}, err
+	}
+
+	unlock, err := inst.Store.Lock(storeLockTimeout)
+	if err != nil {
+		return Garbage{}, err
+	}
+	defer unlock()
+
+	p := inst.Store.Path(segs...)
+	exists, err := inst.Store.Exists(segs...)
+	if err != nil {
+		return Garbage{}, fmt.Errorf("checking %s: %w", p, err)
+	}
+	if !exists {
+		return Garbage{}, fmt.Errorf("%s is not in the store", rel)
+	}
+	size, err := treeSize(p)
+	if err != nil {
+		return Garbage{}, fmt.Errorf("measuring %s: %w", p, err)
+	}
+	inst.Store.Remove(segs...)
+	return Garbage{Path: p, Size: size}, nil
+}
+
+// cacheSegments splits rel, a store path, into segments, and checks it
+// lies within a package directory.
+func cacheSegments(rel string) ([]string, error) {
+	clean := path.Clean(filepath.ToSlash(rel))
+	segs := strings.Split(clean, "/")
+	if clean == "." || path.IsAbs(clean) || slices.Contains(segs, "..") || !slices.Contains(packageDirs, segs[0]) {
+		return nil, fmt.Errorf("%q is not a package store path; it must be under one of %s", rel, strings.Join(packageDirs, ", "))
+	}
+	return segs, nil
+}
+
+// isCommitDir reports whether name is the directory of a repo clone at a
+// commit: a full commit hash, with a ".partial" suffix while the clone is
+// in progress.
+func isCommitDir(name string) bool {
+	commit := strings.TrimSuffix(name, ".partial")
+	_, err := hex.DecodeString(commit)
+	return len(commit) == 40 && err == nil
+}
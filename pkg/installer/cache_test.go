@@ -0,0 +1,129 @@
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+func TestCacheEntries(t *testing.T) {
+	root := t.TempDir()
+	s := store.New(root)
+	inst := &Installer{Store: s}
+
+	// A skill's clone may hold only directories.
+	const commit = "0123456789abcdef0123456789abcdef01234567"
+	for _, rel := range []string{
+		"repos/github.com/org/skills/" + commit + "/pdf",
+		"npm/@mcp/fs/1.0.0",
+		"npm/@mcp/fs/1.0.0/node_modules/fs",
+		"npm/@mcp/fs/1.1.0",
+		"uv/mcp-server-git/0.6.2",
+		// Only package directories are listed.
+		"runtimes/node/22.1.0",
+	} {
+		os.MkdirAll(filepath.Join(root, rel), 0o755)
+		os.WriteFile(filepath.Join(root, rel, "data"), []byte("12345"), 0o644)
+	}
+	fetched := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	os.Chtimes(filepath.Join(root, "npm", "@mcp", "fs", "1.1.0"), fetched, fetched)
+	if _, err := s.HashDir("npm", "@mcp", "fs", "1.0.0"); err != nil {
+		t.Fatalf("HashDir() error = %v", err)
+	}
+
+	tests := map[string]struct {
+		prefix string
+		want   map[string]int64
+	}{
+		"all": {
+			want: map[string]int64{
+				"repos/github.com/org/skills/" + commit: 5,
+				"npm/@mcp/fs/1.0.0":                     10,
+				"npm/@mcp/fs/1.1.0":                     5,
+				"uv/mcp-server-git/0.6.2":               5,
+			},
+		},
+		"prefix": {
+			prefix: "npm/@mcp",
+			want:   map[string]int64{"npm/@mcp/fs/1.0.0": 10, "npm/@mcp/fs/1.1.0": 5},
+		},
+		"entry": {
+			prefix: "npm/@mcp/fs/1.1.0",
+			want:   map[string]int64{"npm/@mcp/fs/1.1.0": 5},
+		},
+		"missing": {
+			prefix: "go/example.com",
+			want:   map[string]int64{},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			entries, err := inst.CacheEntries(tc.prefix)
+			if err != nil {
+				t.Fatalf("CacheEntries(%q) error = %v", tc.prefix, err)
+			}
+
+			got := make(map[string]int64)
+			for i, e := range entries {
+				got[e.Path] = e.Size
+				if i > 0 && entries[i-1].Path > e.Path {
+					t.Errorf("CacheEntries() not sorted: %q before %q", entries[i-1].Path, e.Path)
+				}
+				switch e.Path {
+				case "npm/@mcp/fs/1.0.0":
+					if time.Since(e.LastUsed) > time.Minute {
+						t.Errorf("LastUsed of hashed entry = %v, want the time it was hashed", e.LastUsed)
+					}
+				case "npm/@mcp/fs/1.1.0":
+					if !e.LastUsed.Equal(fetched) {
+						t.Errorf("LastUsed of unhashed entry = %v, want its modification time %v", e.LastUsed, fetched)
+					}
+				}
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("CacheEntries(%q) = %v, want %v", tc.prefix, got, tc.want)
+			}
+			for path, size := range tc.want {
+				if got[path] != size {
+					t.Errorf("CacheEntries(%q)[%q] size = %d, want %d", tc.prefix, path, got[path], size)
+				}
+			}
+		})
+	}
+}
+
+func TestRemoveCacheEntry(t *testing.T) {
+	root := t.TempDir()
+	inst := &Installer{Store: store.New(root)}
+	for _, rel := range []string{"npm/@mcp/fs/1.0.0", "npm/@mcp/fs/1.1.0", "npm/@mcp/db/2.0.0", "runtimes/node/22.1.0"} {
+		os.MkdirAll(filepath.Join(root, rel), 0o755)
+		os.WriteFile(filepath.Join(root, rel, "data"), []byte("12345"), 0o644)
+	}
+
+	g, err := inst.RemoveCacheEntry("npm/@mcp/fs")
+	if err != nil {
+		t.Fatalf("RemoveCacheEntry() error = %v", err)
+	}
+	if want := filepath.Join(root, "npm", "@mcp", "fs"); g.Path != want || g.Size != 10 {
+		t.Errorf("RemoveCacheEntry() = %+v, want path %s and size 10", g, want)
+	}
+	if _, err := os.Stat(filepath.Join(root, "npm", "@mcp", "fs")); !os.IsNotExist(err) {
+		t.Errorf("npm/@mcp/fs still exists after RemoveCacheEntry()")
+	}
+	if _, err := os.Stat(filepath.Join(root, "npm", "@mcp", "db", "2.0.0")); err != nil {
+		t.Errorf("npm/@mcp/db/2.0.0 removed: %v", err)
+	}
+
+	for _, rel := range []string{"npm/@mcp/fs", "runtimes/node", "npm/../runtimes", "/npm", ".", ""} {
+		if _, err := inst.RemoveCacheEntry(rel); err == nil {
+			t.Errorf("RemoveCacheEntry(%q) error = nil, want error", rel)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "runtimes", "node", "22.1.0")); err != nil {
+		t.Errorf("runtimes removed: %v", err)
+	}
+}
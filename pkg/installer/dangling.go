@@ -0,0 +1,179 @@
+package installer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/projector"
+)
+
+// DanglingLink is a symlink in an agent's skills directory that points into
+// the store, the vendor directory, or the project's package copies at an
+// entry that no longer exists, e.g. after the store was cleaned up.
+type DanglingLink struct {
+	Agent  string `json:"agent"`
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	Target string `json:"target"`
+	// Declared reports whether the manifest declares the skill, in which
+	// case install fetches it again and recreates the link. Links of
+	// undeclared skills are only removed.
+	Declared bool `json:"declared"`
+}
+
+// DanglingLinks returns the dangling symlinks in the skills directories of
+// the configured agents, for both skill scopes, sorted by path. Symlinks
+// that point outside apkg's directories are left alone, since apkg didn't
+// create them.
+func (inst *Installer) DanglingLinks(cfg *config.Config) ([]DanglingLink, error) {
+	declared := make(map[string]bool)
+	for name := range cfg.Skills {
+		declared[name] = true
+	}
+
+	owned := inst.ownedDirs()
+	seen := make(map[string]bool)
+	var links []DanglingLink
+	for _, scope := range []string{config.SkillScopeProject, config.SkillScopeUser} {
+		opts, err := inst.skillProjectionOpts(scope)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, agent := range inst.Agents {
+			proj, ok := projector.GetProjector(agent)
+			if !ok || !proj.SupportsSkills() {
+				continue
+			}
+			targets, err := proj.Targets(opts)
+			if err != nil {
+				return nil, fmt.Errorf("resolving targets of %s: %w", agent, err)
+			}
+			if targets.SkillsDir == "" || seen[targets.SkillsDir] {
+				continue
+			}
+			seen[targets.SkillsDir] = true
+
+			found, err := danglingLinksIn(targets.SkillsDir, owned)
+			if err != nil {
+				return nil, err
+			}
+			for _, link := range found {
+				link.Agent = agent
+				link.Declared = declared[link.Name]
+				links = append(links, link)
+			}
+		}
+	}
+
+	sort.Slice(links, func(i, j int) bool { return links[i].Path < links[j].Path })
+	return links, nil
+}
+
+// RemoveDanglingLinks removes the links returned by DanglingLinks and
+// returns them. Projecting the declared skills afterwards (InstallAll)
+// fetches their missing store entries and recreates the links.
+func (inst *Installer) RemoveDanglingLinks(cfg *config.Config) ([]DanglingLink, error) {
+	links, err := inst.DanglingLinks(cfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, link := range links {
+		if err := os.Remove(link.Path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("removing dangling link %s: %w", link.Path, err)
+		}
+	}
+	return links, nil
+}
+
+// ownedDirs returns the directories apkg links skills to: the store, the
+// vendor directory, and the project's package copies.
+func (inst *Installer) ownedDirs() []string {
+	var owned []string
+	if inst.Store != nil {
+		owned = append(owned, inst.Store.Path())
+	}
+	if inst.VendorDir != "" {
+		owned = append(owned, inst.VendorDir)
+	}
+	if !inst.Global && inst.ProjectDir != "" {
+		owned = append(owned, filepath.Join(inst.ProjectDir, projector.ContentDir))
+	}
+	// Absolute links use the paths as given, relative ones resolve to
+	// physical paths, so match both.
+	for _, dir := range owned {
+		if resolved := resolvePath(dir); resolved != dir {
+			owned = append(owned, resolved)
+		}
+	}
+	return owned
+}
+
+// linkTarget returns the target of the symlink at path in dir, with
+// relative targets resolved.
+func linkTarget(dir, path string) (string, error) {
+	target, err := os.Readlink(path)
+	if err != nil {
+		return "", fmt.Errorf("reading link %s: %w", path, err)
+	}
+	if !filepath.IsAbs(target) {
+		// Relative links are resolved from the physical directory
+		// (see projector.SkillProjector).
+		target = filepath.Join(resolvePath(dir), target)
+	}
+	return target, nil
+}
+
+// danglingLinksIn returns the symlinks in dir whose target is missing and
+// lies under one of the owned directories.
+func danglingLinksIn(dir string, owned []string) ([]DanglingLink, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", dir, err)
+	}
+
+	var links []DanglingLink
+	for _, e := range entries {
+		if e.Type()&os.ModeSymlink == 0 {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		target, err := linkTarget(dir, path)
+		if err != nil {
+			return nil, err
+		}
+		if exists(target) || !underAny(target, owned) {
+			continue
+		}
+		links = append(links, DanglingLink{Name: e.Name(), Path: path, Target: target})
+	}
+	return links, nil
+}
+
+// underAny reports whether path is inside one of dirs. Paths are compared
+// lexically since the link targets no longer exist.
+func underAny(path string, dirs []string) bool {
+	for _, dir := range dirs {
+		rel, err := filepath.Rel(dir, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolvePath returns path with symlinks resolved, or path itself if it
+// can't be resolved (e.g. because it doesn't exist).
+func resolvePath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return path
+}
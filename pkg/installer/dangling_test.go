@@ -0,0 +1,105 @@
+package installer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+func TestDanglingLinks(t *testing.T) {
+	skillsDir := t.TempDir()
+	writeSkill(t, filepath.Join(skillsDir, "pdf"), "pdf")
+
+	cfg := &config.Config{
+		Skills: map[string]config.SkillSource{
+			"pdf": {Path: filepath.Join(skillsDir, "pdf")},
+		},
+	}
+
+	tests := map[string]struct {
+		name     string
+		target   func(storeRoot string) string
+		wantLink bool
+		declared bool
+	}{
+		"undeclared skill in store": {
+			name:     "docx",
+			target:   func(storeRoot string) string { return filepath.Join(storeRoot, "skills", "docx") },
+			wantLink: true,
+		},
+		"declared skill in store": {
+			name:     "pdf",
+			target:   func(storeRoot string) string { return filepath.Join(storeRoot, "skills", "pdf") },
+			wantLink: true,
+			declared: true,
+		},
+		"relative link into store": {
+			name: "docx",
+			target: func(storeRoot string) string {
+				return filepath.Join("..", "..", "..", filepath.Base(storeRoot), "skills", "docx")
+			},
+			wantLink: true,
+		},
+		"link outside apkg directories": {
+			name:   "notes",
+			target: func(storeRoot string) string { return filepath.Join(t.TempDir(), "gone") },
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			root := t.TempDir()
+			projectDir := filepath.Join(root, "project")
+			storeRoot := filepath.Join(root, "store")
+			inst := &Installer{
+				Store:      store.New(storeRoot),
+				ProjectDir: projectDir,
+				Agents:     []string{"test-skills-only"},
+			}
+			if _, err := inst.InstallAll(context.Background(), cfg, nil); err != nil {
+				t.Fatalf("InstallAll() error = %v", err)
+			}
+
+			link := filepath.Join(projectDir, ".test", "skills", tc.name)
+			if err := os.RemoveAll(link); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Symlink(tc.target(storeRoot), link); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := inst.DanglingLinks(cfg)
+			if err != nil {
+				t.Fatalf("DanglingLinks() error = %v", err)
+			}
+			if !tc.wantLink {
+				if len(got) != 0 {
+					t.Errorf("DanglingLinks() = %v, want none", got)
+				}
+				return
+			}
+			if len(got) != 1 || got[0].Path != link || got[0].Declared != tc.declared {
+				t.Fatalf("DanglingLinks() = %v, want %s with declared = %v", got, link, tc.declared)
+			}
+
+			// Install removes the link and recreates it for declared skills.
+			if _, err := inst.InstallAll(context.Background(), cfg, nil); err != nil {
+				t.Fatalf("InstallAll() error = %v", err)
+			}
+			_, err = os.Stat(link)
+			if tc.declared && err != nil {
+				t.Errorf("link of declared skill not recreated: %v", err)
+			}
+			if _, lerr := os.Lstat(link); !tc.declared && !os.IsNotExist(lerr) {
+				t.Errorf("link of undeclared skill not removed: %v", lerr)
+			}
+			if got, _ := inst.DanglingLinks(cfg); len(got) != 0 {
+				t.Errorf("DanglingLinks() after install = %v, want none", got)
+			}
+		})
+	}
+}
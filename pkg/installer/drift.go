@@ -0,0 +1,229 @@
+package installer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/projector"
+)
+
+// Drift is a manifest entry missing from an agent's configuration, e.g.
+// because its projection was deleted by hand or by the agent.
+type Drift struct {
+	Kind  string `json:"kind"` // KindSkill
+	Name  string `json:"name"`
+	Agent string `json:"agent"`
+}
+
+// Drift returns the skills in cfg missing from the skills directory of a
+// configured agent, sorted by name and agent. A skill counts as projected
+// if the directory has an entry named after it, with or without an
+// extension (symlinked skill directories and rendered rule files alike).
+func (inst *Installer) Drift(cfg *config.Config) ([]Drift, error) {
+	var drift []Drift
+	for _, name := range sortedNames(cfg.Skills) {
+		opts, err := inst.skillProjectionOpts(cfg.Skills[name].Scope)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, agent := range inst.Agents {
+			proj, ok := projector.GetProjector(agent)
+			if !ok || !proj.SupportsSkills() {
+				continue
+			}
+			targets, err := proj.Targets(opts)
+			if err != nil {
+				return nil, fmt.Errorf("resolving targets of %s: %w", agent, err)
+			}
+			if targets.SkillsDir == "" {
+				continue
+			}
+
+			projected, err := dirHasEntry(targets.SkillsDir, name)
+			if err != nil {
+				return nil, err
+			}
+			if !projected {
+				drift = append(drift, Drift{Kind: KindSkill, Name: name, Agent: agent})
+			}
+		}
+	}
+	return drift, nil
+}
+
+// dirHasEntry reports whether dir contains name or name.<ext>.
+func dirHasEntry(dir, name string) (bool, error) {
+	path, err := dirEntry(dir, name)
+	return path != "", err
+}
+
+// Undeclared is a skill apkg projected for an agent that the manifest no
+// longer declares, e.g. because it was edited by hand.
+type Undeclared struct {
+	Agent string `json:"agent"`
+	Name  string `json:"name"`
+	Path  string `json:"path"`
+}
+
+// Undeclared returns the skills apkg projected into the configured agents'
+// skills directories that cfg doesn't declare, sorted by path: links into
+// apkg's directories, rendered files, and the skills recorded in
+// StatePath. Only the directories of the install's own scope are
+// looked at, as the agents' global directories also hold the skills of
+// other projects, and dangling links are left to DanglingLinks.
+func (inst *Installer) Undeclared(cfg *config.Config) ([]Undeclared, error) {
+	var records []ProjectionRecord
+	if inst.StatePath != "" {
+		var err error
+		if records, err = LoadProjectionRecords(inst.StatePath, inst.projectID()); err != nil {
+			return nil, err
+		}
+	}
+
+	owned := inst.ownedDirs()
+	opts := inst.projectionOpts()
+	seen := make(map[string]bool)
+	var found []Undeclared
+	for _, agent := range inst.Agents {
+		proj, ok := projector.GetProjector(agent)
+		if !ok || !proj.SupportsSkills() {
+			continue
+		}
+		targets, err := proj.Targets(opts)
+		if err != nil {
+			return nil, fmt.Errorf("resolving targets of %s: %w", agent, err)
+		}
+		if targets.SkillsDir == "" {
+			continue
+		}
+
+		entries, err := os.ReadDir(targets.SkillsDir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", targets.SkillsDir, err)
+		}
+		for _, e := range entries {
+			path := filepath.Join(targets.SkillsDir, e.Name())
+			if strings.HasPrefix(e.Name(), ".") || seen[path] || declaresEntry(cfg, e.Name()) {
+				continue
+			}
+			var projected bool
+			if e.Type()&os.ModeSymlink != 0 {
+				target, err := linkTarget(targets.SkillsDir, path)
+				if err != nil {
+					return nil, err
+				}
+				projected = exists(target) && underAny(target, owned)
+			} else if e.IsDir() {
+				projected = projector.IsSkillCopy(path)
+			} else {
+				projected = projector.IsGenerated(path)
+			}
+			if projected {
+				seen[path] = true
+				found = append(found, Undeclared{Agent: agent, Name: skillName(e.Name()), Path: path})
+			}
+		}
+	}
+
+	for _, r := range records {
+		if r.Kind != KindSkill || seen[r.Path] || declaresEntry(cfg, r.Name) {
+			continue
+		}
+		if _, err := os.Stat(r.Path); err != nil {
+			continue
+		}
+		seen[r.Path] = true
+		found = append(found, Undeclared{Agent: r.Agent, Name: r.Name, Path: r.Path})
+	}
+
+	sort.Slice(found, func(i, j int) bool { return found[i].Path < found[j].Path })
+	return found, nil
+}
+
+// declaresEntry reports whether cfg declares the skill of the skills
+// directory entry named entry: name or name.<ext>.
+func declaresEntry(cfg *config.Config, entry string) bool {
+	for name := range cfg.Skills {
+		if entry == name || strings.HasPrefix(entry, name+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// skillName returns the name of the skill of the skills directory entry
+// named entry, without the extension of a rendered file.
+func skillName(entry string) string {
+	if ext := filepath.Ext(entry); ext != "" && ext != entry {
+		return strings.TrimSuffix(entry, ext)
+	}
+	return entry
+}
+
+// ProjectionReport compares the configured agents' configurations with
+// the manifest and the projections recorded in StatePath.
+type ProjectionReport struct {
+	// Missing are the skills of the manifest missing from an agent's
+	// skills directory (see Drift).
+	Missing []Drift `json:"missing,omitempty"`
+	// Dangling are the skill links whose target is gone, e.g. a store
+	// entry that was garbage collected (see DanglingLinks).
+	Dangling []DanglingLink `json:"dangling,omitempty"`
+	// Undeclared are the skills apkg projected that the manifest no
+	// longer declares (see Undeclared).
+	Undeclared []Undeclared `json:"undeclared,omitempty"`
+	// Changed are the other recorded projections changed out-of-band,
+	// such as MCP server entries edited or removed by hand (see
+	// ProjectionDrift).
+	Changed []ProjectionDrift `json:"changed,omitempty"`
+}
+
+// InSync reports whether r found nothing out of sync.
+func (r *ProjectionReport) InSync() bool {
+	return len(r.Missing) == 0 && len(r.Dangling) == 0 && len(r.Undeclared) == 0 && len(r.Changed) == 0
+}
+
+// ProjectionReport compares the configured agents' configurations with
+// cfg and the recorded projections. Each problem is reported once: a
+// recorded skill link that is gone or dangling is only in Missing or
+// Dangling.
+func (inst *Installer) ProjectionReport(cfg *config.Config) (*ProjectionReport, error) {
+	report := &ProjectionReport{}
+	var err error
+	if report.Missing, err = inst.Drift(cfg); err != nil {
+		return nil, err
+	}
+	if report.Dangling, err = inst.DanglingLinks(cfg); err != nil {
+		return nil, err
+	}
+	if report.Undeclared, err = inst.Undeclared(cfg); err != nil {
+		return nil, err
+	}
+	changed, err := inst.ProjectionDrift()
+	if err != nil {
+		return nil, err
+	}
+
+	reported := make(map[string]bool)
+	for _, d := range report.Missing {
+		reported[d.Kind+"\x00"+d.Name+"\x00"+d.Agent] = true
+	}
+	for _, link := range report.Dangling {
+		reported[link.Path] = true
+	}
+	for _, d := range changed {
+		if d.Kind == KindSkill && (reported[d.Path] || reported[d.Kind+"\x00"+d.Name+"\x00"+d.Agent]) {
+			continue
+		}
+		report.Changed = append(report.Changed, d)
+	}
+	return report, nil
+}
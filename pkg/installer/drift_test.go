@@ -0,0 +1,159 @@
+package installer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/projector"
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+func TestDrift(t *testing.T) {
+	skillsDir := t.TempDir()
+	writeSkill(t, filepath.Join(skillsDir, "pdf"), "pdf")
+	writeSkill(t, filepath.Join(skillsDir, "docx"), "docx")
+
+	cfg := &config.Config{
+		Skills: map[string]config.SkillSource{
+			"pdf":  {Path: filepath.Join(skillsDir, "pdf")},
+			"docx": {Path: filepath.Join(skillsDir, "docx")},
+		},
+	}
+
+	tests := map[string]struct {
+		remove string // path relative to the agent's skills dir
+		want   []Drift
+	}{
+		"all projected": {},
+		"projection deleted": {
+			remove: "docx",
+			want:   []Drift{{Kind: KindSkill, Name: "docx", Agent: "test-skills-only"}},
+		},
+		"skills dir deleted": {
+			remove: ".",
+			want: []Drift{
+				{Kind: KindSkill, Name: "docx", Agent: "test-skills-only"},
+				{Kind: KindSkill, Name: "pdf", Agent: "test-skills-only"},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			projectDir := t.TempDir()
+			inst := &Installer{
+				Store:      store.New(t.TempDir()),
+				ProjectDir: projectDir,
+				Agents:     []string{"test-skills-only"},
+			}
+			if _, err := inst.InstallAll(context.Background(), cfg, nil); err != nil {
+				t.Fatalf("InstallAll() error = %v", err)
+			}
+			if tc.remove != "" {
+				if err := os.RemoveAll(filepath.Join(projectDir, ".test", "skills", tc.remove)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := inst.Drift(cfg)
+			if err != nil {
+				t.Fatalf("Drift() error = %v", err)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("Drift() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestProjectionReport(t *testing.T) {
+	link := func(projectDir string) string {
+		return filepath.Join(projectDir, ".cursor", "skills", "my-skill")
+	}
+
+	tests := map[string]struct {
+		change         func(t *testing.T, projectDir string, cfg *config.Config)
+		wantMissing    int
+		wantDangling   int
+		wantUndeclared int
+		wantChanged    []string // kind/name/state
+	}{
+		"in sync": {},
+		"skill link deleted": {
+			change: func(t *testing.T, projectDir string, cfg *config.Config) {
+				os.Remove(link(projectDir))
+			},
+			wantMissing: 1,
+		},
+		"store entry garbage collected": {
+			change: func(t *testing.T, projectDir string, cfg *config.Config) {
+				target, err := os.Readlink(link(projectDir))
+				if err != nil {
+					t.Fatal(err)
+				}
+				os.RemoveAll(target)
+			},
+			wantDangling: 1,
+		},
+		"skill removed from manifest": {
+			change: func(t *testing.T, projectDir string, cfg *config.Config) {
+				delete(cfg.Skills, "my-skill")
+			},
+			wantUndeclared: 1,
+		},
+		"MCP entry removed by hand": {
+			change: func(t *testing.T, projectDir string, cfg *config.Config) {
+				editMCPConfig(t, projectDir, func(servers map[string]any) {
+					delete(servers, "tool")
+				})
+			},
+			wantChanged: []string{"mcp/tool/" + DriftMissing},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			projectDir := t.TempDir()
+			cfg := recordConfig(t)
+			ss := cfg.Skills["my-skill"]
+			ss.Snapshot = true
+			cfg.Skills["my-skill"] = ss
+
+			inst := &Installer{
+				Store:      store.New(t.TempDir()),
+				ProjectDir: projectDir,
+				Agents:     []string{"cursor"},
+				StatePath:  filepath.Join(t.TempDir(), projector.StateFileName),
+			}
+			if _, err := inst.InstallAll(context.Background(), cfg, nil); err != nil {
+				t.Fatalf("InstallAll() error = %v", err)
+			}
+			if tc.change != nil {
+				tc.change(t, projectDir, cfg)
+			}
+
+			report, err := inst.ProjectionReport(cfg)
+			if err != nil {
+				t.Fatalf("ProjectionReport() error = %v", err)
+			}
+			if len(report.Missing) != tc.wantMissing || len(report.Dangling) != tc.wantDangling || len(report.Undeclared) != tc.wantUndeclared {
+				t.Errorf("ProjectionReport() = %d missing, %d dangling, %d undeclared, want %d, %d, %d",
+					len(report.Missing), len(report.Dangling), len(report.Undeclared), tc.wantMissing, tc.wantDangling, tc.wantUndeclared)
+			}
+			var changed []string
+			for _, d := range report.Changed {
+				changed = append(changed, d.Kind+"/"+d.Name+"/"+d.State)
+			}
+			if !slices.Equal(changed, tc.wantChanged) {
+				t.Errorf("ProjectionReport().Changed = %v, want %v", changed, tc.wantChanged)
+			}
+			if inSync := tc.wantMissing+tc.wantDangling+tc.wantUndeclared+len(tc.wantChanged) == 0; report.InSync() != inSync {
+				t.Errorf("InSync() = %v, want %v", report.InSync(), inSync)
+			}
+		})
+	}
+}
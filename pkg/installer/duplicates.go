@@ -0,0 +1,39 @@
+package installer
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/mcp"
+)
+
+// DuplicateServers returns the MCP servers among names that the other
+// scope's lockfile installs as well, sorted. Agents reading the configs
+// of both scopes would see two definitions of each.
+func DuplicateServers(names []string, other *config.LockFile) []string {
+	var dups []string
+	for _, entry := range other.MCPServers {
+		if slices.Contains(names, entry.Name) && !slices.Contains(dups, entry.Name) {
+			dups = append(dups, entry.Name)
+		}
+	}
+	sort.Strings(dups)
+	return dups
+}
+
+// splitDeferred separates the servers agents take from the global install
+// (see Installer.DeferredServers) from the ones to project.
+func (inst *Installer) splitDeferred(servers []mcp.MCPServer) (project []mcp.MCPServer, deferred []string) {
+	if inst.Global || len(inst.DeferredServers) == 0 {
+		return servers, nil
+	}
+	for _, server := range servers {
+		if slices.Contains(inst.DeferredServers, server.Name()) {
+			deferred = append(deferred, server.Name())
+		} else {
+			project = append(project, server)
+		}
+	}
+	return project, deferred
+}
@@ -0,0 +1,31 @@
+package installer
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+)
+
+func TestDuplicateServers(t *testing.T) {
+	other := &config.LockFile{MCPServers: []config.MCPLockEntry{
+		{Name: "github"}, {Name: "fs"}, {Name: "db"},
+	}}
+
+	tests := map[string]struct {
+		names []string
+		want  []string
+	}{
+		"sorted duplicates": {names: []string{"github", "db", "search"}, want: []string{"db", "github"}},
+		"no duplicates":     {names: []string{"search"}},
+		"no servers":        {},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := DuplicateServers(tc.names, other); !slices.Equal(got, tc.want) {
+				t.Errorf("DuplicateServers() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
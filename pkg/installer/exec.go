@@ -0,0 +1,81 @@
+package installer
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/mcp"
+)
+
+// ExecServer loads the managed MCP server name from the store entry its
+// lock entry points at, set up to run the package's binary with args
+// instead of the configured arguments (see mcp.LoadExec). Its env is
+// resolved from cfg with inst.EnvSet applied, as installs project it.
+func (inst *Installer) ExecServer(cfg *config.Config, lf *config.LockFile, name string, args []string) (mcp.MCPServer, error) {
+	entry, err := lockedServer(lf, name)
+	if err != nil {
+		return nil, err
+	}
+	if entry.Package == "" {
+		return nil, fmt.Errorf("MCP server %q is not a managed package", name)
+	}
+	if !isDir(entry.InstallPath) {
+		return nil, errMissingFromStore(name)
+	}
+
+	opts := lockedOptions(entry)
+	if ms, ok := cfg.MCPServers[name]; ok {
+		resolved, _, err := ms.WithEnvSet(inst.EnvSet)
+		if err != nil {
+			return nil, fmt.Errorf("MCP server %q: %w", name, err)
+		}
+		opts.Env = map[string]string{}
+		if resolved.LocalMCPConfig != nil {
+			maps.Copy(opts.Env, resolved.Env)
+		}
+	}
+	return mcp.LoadExec(entry.InstallPath, args, opts)
+}
+
+// RunServer loads the stdio MCP server name from the store entry its lock
+// entry points at, with the arguments and env it was installed with, for
+// agents that launch it through apkg run mcp (see
+// config.MCPCommandRun).
+func RunServer(lf *config.LockFile, name string) (mcp.MCPServer, error) {
+	entry, err := lockedServer(lf, name)
+	if err != nil {
+		return nil, err
+	}
+	if !isDir(entry.InstallPath) {
+		return nil, errMissingFromStore(name)
+	}
+	server, err := mcp.LoadWith(entry.InstallPath, lockedOptions(entry))
+	if err != nil {
+		return nil, fmt.Errorf("loading MCP server %q: %w", name, err)
+	}
+	if server.Transport() != config.TransportStdio {
+		return nil, fmt.Errorf("MCP server %q is not a stdio server", name)
+	}
+	return server, nil
+}
+
+// lockedServer returns the lock entry of the MCP server name.
+func lockedServer(lf *config.LockFile, name string) (config.MCPLockEntry, error) {
+	for _, entry := range lf.MCPServers {
+		if entry.Name == name {
+			return entry, nil
+		}
+	}
+	return config.MCPLockEntry{}, fmt.Errorf("MCP server %q is not installed (run apkg install)", name)
+}
+
+// lockedOptions returns how the server locked as entry runs for the
+// project (see mcp.Options).
+func lockedOptions(entry config.MCPLockEntry) mcp.Options {
+	return mcp.Options{Runtime: entry.Runtime, Volumes: entry.Volumes}
+}
+
+func errMissingFromStore(name string) error {
+	return fmt.Errorf("MCP server %q is missing from the store (run apkg install)", name)
+}
@@ -0,0 +1,137 @@
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+)
+
+func TestExecServer(t *testing.T) {
+	dir := t.TempDir()
+	toolDir := filepath.Join(dir, "go", "tool")
+	if err := os.MkdirAll(filepath.Join(toolDir, "bin"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		// Another project installed the shared entry with its own env.
+		"mcp.toml": "name = \"tool\"\npackage = \"go:github.com/example/tool\"\nargs = [\"serve\"]\nenv = { TOKEN = \"other\" }\n",
+		"bin/tool": "executable content",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(toolDir, name), []byte(content), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lf := &config.LockFile{MCPServers: []config.MCPLockEntry{
+		{Name: "tool", Package: "go:github.com/example/tool", InstallPath: toolDir},
+		{Name: "gone", Package: "npm:gone", InstallPath: filepath.Join(dir, "npm", "gone")},
+		{Name: "remote", URL: "https://example.com/mcp"},
+	}}
+
+	cfg := &config.Config{MCPServers: map[string]config.MCPSource{
+		"tool": {
+			Transport:             config.TransportStdio,
+			ManagedStdioMCPConfig: &config.ManagedStdioMCPConfig{Package: "go:github.com/example/tool"},
+			LocalMCPConfig:        &config.LocalMCPConfig{Env: map[string]string{"TOKEN": "${TOKEN}"}},
+			EnvSets:               map[string]config.EnvSet{"staging": {Env: map[string]string{"TOKEN": "staging"}}},
+		},
+	}}
+
+	tests := map[string]struct {
+		name    string
+		envSet  string
+		wantEnv map[string]string
+		wantErr string
+	}{
+		"managed server":       {name: "tool", wantEnv: map[string]string{"TOKEN": "${TOKEN}"}},
+		"env set":              {name: "tool", envSet: "staging", wantEnv: map[string]string{"TOKEN": "staging"}},
+		"missing store entry":  {name: "gone", wantErr: "missing from the store"},
+		"not a managed server": {name: "remote", wantErr: "not a managed package"},
+		"not installed":        {name: "other", wantErr: "not installed"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			inst := &Installer{EnvSet: tc.envSet}
+			server, err := inst.ExecServer(cfg, lf, tc.name, []string{"--version"})
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("ExecServer() error = %v, want containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExecServer() error = %v", err)
+			}
+			if want := filepath.Join(toolDir, "bin", "tool"); server.Command() != want {
+				t.Errorf("Command() = %q, want %q", server.Command(), want)
+			}
+			if want := []string{"--version"}; !reflect.DeepEqual(server.Args(), want) {
+				t.Errorf("Args() = %v, want %v", server.Args(), want)
+			}
+			if !reflect.DeepEqual(server.Env(), tc.wantEnv) {
+				t.Errorf("Env() = %v, want %v", server.Env(), tc.wantEnv)
+			}
+		})
+	}
+}
+
+func TestRunServer(t *testing.T) {
+	dir := t.TempDir()
+	localDir := filepath.Join(dir, "local")
+	remoteDir := filepath.Join(dir, "remote")
+	files := map[string]string{
+		filepath.Join(localDir, "mcp.toml"):  "name = \"local\"\ncommand = \"my-server\"\nargs = [\"--stdio\"]\nenv = { TOKEN = \"${TOKEN}\" }\n",
+		filepath.Join(remoteDir, "mcp.toml"): "name = \"remote\"\ntransport = \"http\"\nurl = \"https://example.com/mcp\"\n",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lf := &config.LockFile{MCPServers: []config.MCPLockEntry{
+		{Name: "local", Command: "my-server", InstallPath: localDir},
+		{Name: "remote", URL: "https://example.com/mcp", InstallPath: remoteDir},
+		{Name: "gone", Command: "gone", InstallPath: filepath.Join(dir, "gone")},
+	}}
+
+	tests := map[string]struct {
+		name    string
+		wantErr string
+	}{
+		"stdio server":        {name: "local"},
+		"http server":         {name: "remote", wantErr: "not a stdio server"},
+		"missing store entry": {name: "gone", wantErr: "missing from the store"},
+		"not installed":       {name: "other", wantErr: "not installed"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			server, err := RunServer(lf, tc.name)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("RunServer() error = %v, want containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RunServer() error = %v", err)
+			}
+			if server.Command() != "my-server" || !reflect.DeepEqual(server.Args(), []string{"--stdio"}) {
+				t.Errorf("server runs %q %v, want my-server [--stdio]", server.Command(), server.Args())
+			}
+			if want := map[string]string{"TOKEN": "${TOKEN}"}; !reflect.DeepEqual(server.Env(), want) {
+				t.Errorf("Env() = %v, want %v", server.Env(), want)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package installer
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+)
+
+// packageDirs are the store directories packages are fetched into, one per
+// source kind. Garbage collection only looks inside these: the store root
+// also holds runtimes, logs, and (by default) apkg's own configuration.
+var packageDirs = []string{"repos", "npm", "uv", "uv-tool", "go", "oci", "static", "snapshots", "archives", "local"}
+
+// Garbage is a store entry no lockfile references.
+type Garbage struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"` // bytes of the files under Path
+}
+
+// CollectGarbage returns the package store entries that no entry in the
+// referenced lockfiles uses, including leftovers of interrupted fetches,
+// and deletes them unless dryRun is set. Projects whose lockfiles aren't
+// passed re-fetch deleted entries on their next install. Unless dryRun is
+// set, it holds the store lock, so entries an install is fetching aren't
+// deleted.
+func (inst *Installer) CollectGarbage(dryRun bool, referenced ...*config.LockFile) ([]Garbage, error) {
+	if !dryRun {
+		unlock, err := inst.Store.Lock(storeLockTimeout)
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+	}
+
+	inUse := make(map[string]bool)
+	// parents holds the directories above entries in use, which are
+	// descended into rather than deleted.
+	parents := make(map[string]bool)
+	for _, lf := range referenced {
+		if lf == nil {
+			continue
+		}
+		for _, segs := range inst.storeEntries(lf) {
+			inUse[filepath.Join(segs...)] = true
+			for i := 1; i < len(segs); i++ {
+				parents[filepath.Join(segs[:i]...)] = true
+			}
+		}
+	}
+
+	var garbage []Garbage
+	var collect func(rel string) error
+	collect = func(rel string) error {
+		entries, err := os.ReadDir(inst.Store.Path(rel))
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", inst.Store.Path(rel), err)
+		}
+		for _, e := range entries {
+			child := filepath.Join(rel, e.Name())
+			if inUse[child] {
+				continue
+			}
+			if parents[child] && e.IsDir() {
+				if err := collect(child); err != nil {
+					return err
+				}
+				continue
+			}
+
+			path := inst.Store.Path(child)
+			size, err := treeSize(path)
+			if err != nil {
+				return fmt.Errorf("measuring %s: %w", path, err)
+			}
+			if !dryRun {
+				inst.Store.Remove(child)
+			}
+			garbage = append(garbage, Garbage{Path: path, Size: size})
+		}
+		return nil
+	}
+
+	for _, dir := range packageDirs {
+		if err := collect(dir); err != nil {
+			return garbage, err
+		}
+	}
+	return garbage, nil
+}
+
+// treeSize returns the total size of the regular files under path, without
+// following symlinks.
+func treeSize(path string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
@@ -0,0 +1,126 @@
+package installer
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/source"
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+func TestCollectGarbage(t *testing.T) {
+	tests := map[string]struct {
+		dryRun bool
+	}{
+		"delete":  {},
+		"dry run": {dryRun: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			root := t.TempDir()
+			inst := &Installer{Store: store.New(root)}
+			// InstallPath is recorded as an absolute store path.
+			project := filepath.Join(root, "project")
+			referenced := &config.LockFile{
+				Skills: []config.SkillLockEntry{
+					{Git: "https://github.com/org/skills.git", Path: "pdf", Commit: "c1"},
+					{Name: "local", Path: "skills/local"},
+				},
+				MCPServers: []config.MCPLockEntry{{Name: "fs", InstallPath: filepath.Join(root, "npm", "@mcp", "fs", "1.0.0")}},
+				Dir:        project,
+			}
+			localCopy := path.Join(source.LocalStoreSegments(filepath.Join(project, "skills", "local"))...)
+			deletedCopy := path.Join(source.LocalStoreSegments(filepath.Join(project, "skills", "deleted"))...)
+			global := &config.LockFile{
+				MCPServers: []config.MCPLockEntry{{Name: "git", InstallPath: filepath.Join(root, "uv", "mcp-server-git", "0.6.2")}},
+			}
+
+			kept := []string{
+				"repos/github.com/org/skills/c1",
+				"npm/@mcp/fs/1.0.0",
+				"uv/mcp-server-git/0.6.2",
+				localCopy,
+				// Only package directories are collected.
+				"runtimes/node/22.1.0",
+				"logs",
+			}
+			unreferenced := []string{
+				"repos/github.com/org/skills/c0",
+				"repos/github.com/org/skills/c2.partial",
+				"repos/github.com/other",
+				"npm/@mcp/fs/0.9.0",
+				"npm/@mcp/db",
+				"static/local",
+				deletedCopy,
+			}
+			for _, rel := range append(kept, unreferenced...) {
+				os.MkdirAll(filepath.Join(root, rel), 0o755)
+				os.WriteFile(filepath.Join(root, rel, "data"), []byte("12345"), 0o644)
+			}
+
+			garbage, err := inst.CollectGarbage(tc.dryRun, referenced, global, nil)
+			if err != nil {
+				t.Fatalf("CollectGarbage() error = %v", err)
+			}
+
+			got := make(map[string]int64)
+			for _, g := range garbage {
+				rel, _ := filepath.Rel(root, g.Path)
+				got[filepath.ToSlash(rel)] = g.Size
+			}
+			if len(got) != len(unreferenced) {
+				t.Errorf("CollectGarbage() = %v, want %v", got, unreferenced)
+			}
+			for _, rel := range unreferenced {
+				if got[rel] != 5 {
+					t.Errorf("size of %s = %d, want 5", rel, got[rel])
+				}
+				_, err := os.Stat(filepath.Join(root, rel))
+				if deleted := os.IsNotExist(err); deleted == tc.dryRun {
+					t.Errorf("%s deleted = %v, want %v", rel, deleted, !tc.dryRun)
+				}
+			}
+			for _, rel := range kept {
+				if _, err := os.Stat(filepath.Join(root, rel)); err != nil {
+					t.Errorf("%s was deleted: %v", rel, err)
+				}
+			}
+		})
+	}
+}
+
+func TestCollectGarbageWaitsForStoreLock(t *testing.T) {
+	root := t.TempDir()
+	s := store.New(root)
+	os.MkdirAll(filepath.Join(root, "npm", "@mcp", "fs", "1.0.0"), 0o755)
+
+	// An install holding the store lock keeps gc from deleting what it
+	// fetches.
+	unlock, err := s.Lock(time.Second)
+	if err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := (&Installer{Store: s}).CollectGarbage(false)
+		done <- err
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	if _, err := os.Stat(filepath.Join(root, "npm", "@mcp", "fs", "1.0.0")); err != nil {
+		t.Fatalf("entry deleted while the store was locked: %v", err)
+	}
+	unlock()
+
+	if err := <-done; err != nil {
+		t.Fatalf("CollectGarbage() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "npm", "@mcp", "fs", "1.0.0")); !os.IsNotExist(err) {
+		t.Errorf("entry still present after the lock was released, err = %v", err)
+	}
+}
@@ -0,0 +1,258 @@
+package installer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/projector"
+)
+
+// ImportSkillsDir is the project directory Import moves the skills it
+// finds in agent skills directories into, so apkg can project them back.
+const ImportSkillsDir = "skills"
+
+// Import adds the skills and MCP servers found in agent configs (see
+// Unmanaged) to cfg, so apkg manages them from then on, and returns the
+// ones it added. Entries cfg already declares, repeats of a name imported
+// for another agent, and skills without a SKILL.md are skipped.
+//
+// MCP servers are declared with the config read from their agent's config
+// file, and the entries matching it are recorded as owned in StatePath so
+// the next install takes them over without asking. Env vars and headers
+// that look like secrets are declared as "${VAR}" references instead of
+// their values (see redactSecrets), with a warning naming the variables
+// to set. Skill directories are moved into ImportSkillsDir of the project
+// and declared as local skills, as projecting links to them would
+// otherwise collide with them; linked skills are declared by their link
+// target.
+//
+// Once cfg holds the imports, save writes it. If anything fails before
+// save returns, the imports are taken out of cfg again, the skill
+// directories moved back, and nothing is recorded as owned, so a failed
+// import leaves the agent configs as they were.
+func (inst *Installer) Import(cfg *config.Config, found []Unmanaged, save func() error) (_ []Unmanaged, err error) {
+	if cfg.Skills == nil {
+		cfg.Skills = make(map[string]config.SkillSource)
+	}
+	if cfg.MCPServers == nil {
+		cfg.MCPServers = make(map[string]config.MCPSource)
+	}
+
+	var ownership *projector.Ownership
+	if inst.StatePath != "" {
+		ownership, err = projector.LoadOwnership(inst.StatePath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var imported []Unmanaged
+	// moves are the skill directories moved so far, as original and new
+	// path.
+	var moves [][2]string
+	saved := false
+	defer func() {
+		if err == nil || saved {
+			return
+		}
+		for _, u := range imported {
+			if u.Kind == KindSkill {
+				delete(cfg.Skills, u.Name)
+			} else {
+				delete(cfg.MCPServers, u.Name)
+			}
+		}
+		for i := len(moves) - 1; i >= 0; i-- {
+			if rerr := os.Rename(moves[i][1], moves[i][0]); rerr != nil {
+				err = errors.Join(err, fmt.Errorf("moving skill back to %s: %w", moves[i][0], rerr))
+			}
+		}
+	}()
+
+	importedMCP := make(map[string]bool)
+	for _, u := range found {
+		switch u.Kind {
+		case KindSkill:
+			if _, declared := cfg.Skills[u.Name]; declared {
+				continue
+			}
+			ss, moved, ok, err := inst.importSkill(u)
+			if err != nil {
+				return nil, err
+			}
+			if moved != "" {
+				moves = append(moves, [2]string{u.Path, moved})
+			}
+			if ok {
+				cfg.Skills[u.Name] = ss
+				imported = append(imported, u)
+			}
+
+		case KindMCP:
+			ms, pointer, err := inst.readMCPEntry(u)
+			if err != nil {
+				return nil, err
+			}
+			refs := redactSecrets(u.Name, &ms)
+			if existing, declared := cfg.MCPServers[u.Name]; declared {
+				// Take over the other agents' entries of a server
+				// imported as they are.
+				if importedMCP[u.Name] && ownership != nil && reflect.DeepEqual(existing, ms) {
+					ownership.Own(u.Path, pointer)
+				}
+				continue
+			}
+			cfg.MCPServers[u.Name] = ms
+			importedMCP[u.Name] = true
+			if ownership != nil {
+				ownership.Own(u.Path, pointer)
+			}
+			imported = append(imported, u)
+			if len(refs) > 0 {
+				inst.warn(fmt.Errorf("imported MCP server %q with references to %s instead of its secrets: set them in the environment its agents start it from", u.Name, strings.Join(refs, ", ")))
+			}
+		}
+	}
+
+	if len(imported) == 0 {
+		return nil, nil
+	}
+	if err := save(); err != nil {
+		return nil, err
+	}
+	saved = true
+	if ownership != nil && len(importedMCP) > 0 {
+		if err := ownership.Save(inst.StatePath); err != nil {
+			return imported, err
+		}
+	}
+	return imported, nil
+}
+
+// secretName matches the names of env vars and headers whose values are
+// likely secrets.
+var secretName = regexp.MustCompile(`(?i)token|secret|passw|api[-_]?key|access[-_]?key|private[-_]?key|credential|auth`)
+
+// redactSecrets replaces the values of the env vars and headers of the MCP
+// server name that look like secrets with "${VAR}" references, which are
+// expanded when the server is launched (see mcp.LaunchEnv), so importing
+// doesn't copy secrets into the manifest, which is usually committed. Env
+// vars are referenced by their own name, headers by the server's and
+// theirs, keeping an auth scheme like "Bearer". It returns the variables
+// referenced, sorted.
+func redactSecrets(name string, ms *config.MCPSource) []string {
+	var refs []string
+	if ms.LocalMCPConfig != nil {
+		for key, value := range ms.Env {
+			if secretName.MatchString(key) && value != "" && !strings.Contains(value, "${") {
+				ms.Env[key] = "${" + key + "}"
+				refs = append(refs, key)
+			}
+		}
+	}
+	if ms.HttpMCPConfig != nil {
+		for key, value := range ms.Headers {
+			if !secretName.MatchString(key) || value == "" || strings.Contains(value, "${") {
+				continue
+			}
+			ref := envVarName(name + "_" + key)
+			scheme := ""
+			if s, credentials, ok := strings.Cut(value, " "); ok && credentials != "" && !strings.Contains(credentials, " ") {
+				scheme = s + " "
+			}
+			ms.Headers[key] = scheme + "${" + ref + "}"
+			refs = append(refs, ref)
+		}
+	}
+	slices.Sort(refs)
+	return refs
+}
+
+// envVarName returns s as an environment variable name: upper case, with
+// anything but letters and digits replaced by underscores.
+func envVarName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// importSkill returns the local skill source of the skill found at u.Path,
+// moving a skill directory into ImportSkillsDir first and returning where
+// to, or false if u.Path holds no skill.
+func (inst *Installer) importSkill(u Unmanaged) (ss config.SkillSource, moved string, ok bool, err error) {
+	info, err := os.Lstat(u.Path)
+	if err != nil {
+		return config.SkillSource{}, "", false, err
+	}
+
+	dir := u.Path
+	if info.Mode()&os.ModeSymlink != 0 {
+		if dir, err = linkTarget(filepath.Dir(u.Path), u.Path); err != nil {
+			return config.SkillSource{}, "", false, err
+		}
+	} else if !info.IsDir() {
+		return config.SkillSource{}, "", false, nil
+	}
+	if _, err := os.Stat(filepath.Join(dir, "SKILL.md")); err != nil {
+		return config.SkillSource{}, "", false, nil
+	}
+
+	if dir == u.Path {
+		moved = filepath.Join(inst.ProjectDir, ImportSkillsDir, u.Name)
+		if _, err := os.Lstat(moved); err == nil {
+			return config.SkillSource{}, "", false, fmt.Errorf("importing skill %q: %s already exists", u.Name, moved)
+		}
+		if err := os.MkdirAll(filepath.Dir(moved), 0o755); err != nil {
+			return config.SkillSource{}, "", false, fmt.Errorf("importing skill %q: %w", u.Name, err)
+		}
+		if err := os.Rename(u.Path, moved); err != nil {
+			return config.SkillSource{}, "", false, fmt.Errorf("importing skill %q: %w", u.Name, err)
+		}
+		dir = moved
+	}
+
+	// Local paths in the manifest are relative to the project.
+	path := dir
+	if rel, err := filepath.Rel(resolvePath(inst.ProjectDir), resolvePath(dir)); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		path = "./" + filepath.ToSlash(rel)
+	}
+	return config.SkillSource{Path: path}, moved, true, nil
+}
+
+// readMCPEntry returns the config of the MCP server found in u.Path by
+// u.Agent, and the JSON pointer of its entry.
+func (inst *Installer) readMCPEntry(u Unmanaged) (config.MCPSource, string, error) {
+	proj, ok := projector.GetProjector(u.Agent)
+	if !ok {
+		return config.MCPSource{}, "", fmt.Errorf("unknown agent %q", u.Agent)
+	}
+	opts := inst.mcpProjectionOpts(u.Agent)
+	targets, err := proj.Targets(opts)
+	if err != nil {
+		return config.MCPSource{}, "", fmt.Errorf("resolving targets of %s: %w", u.Agent, err)
+	}
+
+	entries, err := projector.MCPServerEntries(u.Path, targets.MCPPointer)
+	if err != nil {
+		return config.MCPSource{}, "", err
+	}
+	entry, _ := entries[u.Name].(map[string]any)
+	ms, err := projector.ParseMCPServerJsonConfig(entry, proj.MCPFormat().WithTypes(opts.MCPTypes))
+	if err != nil {
+		return config.MCPSource{}, "", fmt.Errorf("importing MCP server %q from %s: %w", u.Name, u.Path, err)
+	}
+	return ms, projector.JoinPointer(targets.MCPPointer, u.Name), nil
+}
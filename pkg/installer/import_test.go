@@ -0,0 +1,187 @@
+package installer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/projector"
+)
+
+// mcpConfigProjector is a skillsOnlyProjector whose agent also reads MCP
+// servers from <dir>/.test/mcp.json, telling transports apart by the key
+// of their URL like Gemini CLI.
+type mcpConfigProjector struct {
+	skillsOnlyProjector
+}
+
+func (p mcpConfigProjector) Targets(opts projector.ProjectionOpts) (projector.Targets, error) {
+	return projector.Targets{
+		SkillsDir:  p.sp.SkillsDir(opts),
+		MCPConfig:  filepath.Join(opts.ProjectDir, ".test", "mcp.json"),
+		MCPPointer: "/mcpServers",
+	}, nil
+}
+func (mcpConfigProjector) SupportsMCPServers() bool { return true }
+func (mcpConfigProjector) MCPFormat() projector.MCPFormat {
+	return projector.MCPFormat{URLKeys: map[string]string{config.TransportHTTP: "httpUrl", config.TransportSSE: "url"}}
+}
+
+// registerMCPConfigProjector registers the test-mcp-config agent until the
+// test ends.
+func registerMCPConfigProjector(t *testing.T) {
+	t.Helper()
+	if err := projector.RegisterHiddenProjector("test-mcp-config", mcpConfigProjector{skillsOnlyProjector{sp: projector.SkillProjector{AgentDir: ".test"}}}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { projector.UnregisterProjector("test-mcp-config") })
+}
+
+func TestImport(t *testing.T) {
+	tests := map[string]struct {
+		saveErr      error
+		wantImported int
+		// $ROOT in wantSkills' paths is the test's temporary directory.
+		wantSkills   map[string]config.SkillSource
+		wantMCP      map[string]config.MCPSource
+		wantWarnings int
+	}{
+		"skills and servers": {
+			wantImported: 5,
+			wantSkills: map[string]config.SkillSource{
+				"notes":  {Path: "./skills/notes"},
+				"review": {Path: "$ROOT/shared/review"},
+			},
+			wantMCP: map[string]config.MCPSource{
+				"fs": {
+					Transport:               config.TransportStdio,
+					UnmanagedStdioMCPConfig: &config.UnmanagedStdioMCPConfig{Command: "npx"},
+					LocalMCPConfig:          &config.LocalMCPConfig{Args: []string{"-y", "server-fs", "."}, Env: map[string]string{"DEBUG": "1", "GITHUB_TOKEN": "${GITHUB_TOKEN}"}},
+				},
+				"docs": {
+					Transport:             config.TransportHTTP,
+					ExternalHttpMCPConfig: &config.ExternalHttpMCPConfig{URL: "https://docs.example.com/mcp"},
+					HttpMCPConfig:         &config.HttpMCPConfig{Headers: map[string]string{"Authorization": "Bearer ${TOKEN}"}},
+				},
+				"events": {
+					Transport:             config.TransportSSE,
+					ExternalHttpMCPConfig: &config.ExternalHttpMCPConfig{URL: "https://events.example.com/sse"},
+					HttpMCPConfig:         &config.HttpMCPConfig{Headers: map[string]string{"X-Api-Key": "${EVENTS_X_API_KEY}"}},
+				},
+			},
+			// One for each server with secrets.
+			wantWarnings: 2,
+		},
+		"rolls back when the manifest can't be saved": {
+			saveErr:      errors.New("disk full"),
+			wantSkills:   map[string]config.SkillSource{},
+			wantMCP:      map[string]config.MCPSource{},
+			wantWarnings: 2,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			registerMCPConfigProjector(t)
+			root := t.TempDir()
+			projectDir := filepath.Join(root, "project")
+			statePath := filepath.Join(root, "state.toml")
+			skillsDir := filepath.Join(projectDir, ".test", "skills")
+
+			writeSkill(t, filepath.Join(skillsDir, "notes"), "notes")
+			linked := filepath.Join(root, "shared", "review")
+			writeSkill(t, linked, "review")
+			if err := os.Symlink(linked, filepath.Join(skillsDir, "review")); err != nil {
+				t.Fatal(err)
+			}
+			// Not a skill: no SKILL.md.
+			os.MkdirAll(filepath.Join(skillsDir, "scratch"), 0o755)
+
+			mcpConfig := filepath.Join(projectDir, ".test", "mcp.json")
+			os.WriteFile(mcpConfig, []byte(`{"mcpServers": {
+				"fs": {"command": "npx", "args": ["-y", "server-fs", "."], "env": {"DEBUG": "1", "GITHUB_TOKEN": "ghp_secret"}},
+				"docs": {"httpUrl": "https://docs.example.com/mcp", "headers": {"Authorization": "Bearer ${TOKEN}"}},
+				"events": {"url": "https://events.example.com/sse", "headers": {"X-Api-Key": "k3y"}}
+			}}`), 0o644)
+
+			cfg := &config.Config{}
+			var warnings []error
+			inst := &Installer{
+				ProjectDir: projectDir,
+				Agents:     []string{"test-mcp-config"},
+				StatePath:  statePath,
+				Warn:       func(err error) { warnings = append(warnings, err) },
+			}
+			found, err := inst.Unmanaged(cfg)
+			if err != nil {
+				t.Fatalf("Unmanaged() error = %v", err)
+			}
+
+			saved := 0
+			imported, err := inst.Import(cfg, found, func() error { saved++; return tc.saveErr })
+			if !errors.Is(err, tc.saveErr) {
+				t.Fatalf("Import() error = %v, want %v", err, tc.saveErr)
+			}
+			if len(imported) != tc.wantImported {
+				t.Errorf("Import() imported %v, want %d skills and servers", imported, tc.wantImported)
+			}
+			if saved != 1 {
+				t.Errorf("manifest saved %d times, want once", saved)
+			}
+			if len(warnings) != tc.wantWarnings {
+				t.Errorf("warnings = %v, want %d", warnings, tc.wantWarnings)
+			}
+
+			wantSkills := make(map[string]config.SkillSource)
+			for name, ss := range tc.wantSkills {
+				ss.Path = filepath.FromSlash(strings.ReplaceAll(ss.Path, "$ROOT", filepath.ToSlash(root)))
+				wantSkills[name] = ss
+			}
+			if !reflect.DeepEqual(cfg.Skills, wantSkills) {
+				t.Errorf("Import() skills = %v, want %v", cfg.Skills, wantSkills)
+			}
+			if !reflect.DeepEqual(cfg.MCPServers, tc.wantMCP) {
+				t.Errorf("Import() MCP servers = %+v, want %+v", cfg.MCPServers, tc.wantMCP)
+			}
+
+			// Imported skills are moved into the project, and moved back
+			// when the import fails.
+			moved := filepath.Join(projectDir, "skills", "notes", "SKILL.md")
+			left := filepath.Join(skillsDir, "notes", "SKILL.md")
+			if tc.saveErr != nil {
+				moved, left = left, moved
+			}
+			if _, err := os.Stat(moved); err != nil {
+				t.Errorf("notes not at %s: %v", moved, err)
+			}
+			if _, err := os.Stat(left); !os.IsNotExist(err) {
+				t.Errorf("notes left at %s: %v", left, err)
+			}
+
+			if tc.saveErr != nil {
+				if _, err := os.Stat(statePath); !os.IsNotExist(err) {
+					t.Errorf("ownership recorded for a failed import (err = %v)", err)
+				}
+				return
+			}
+			ownership, err := projector.LoadOwnership(statePath)
+			if err != nil {
+				t.Fatalf("LoadOwnership() error = %v", err)
+			}
+			for name := range tc.wantMCP {
+				if !ownership.Owns(mcpConfig, "/mcpServers/"+name) {
+					t.Errorf("imported MCP server %q not owned", name)
+				}
+			}
+
+			// Everything imported is managed now.
+			if found, err := inst.Unmanaged(cfg); err != nil || len(found) != 1 || found[0].Name != "scratch" {
+				t.Errorf("Unmanaged() after Import() = %v, %v, want only scratch", found, err)
+			}
+		})
+	}
+}
@@ -2,15 +2,29 @@ package installer
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
 	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/agentpkg/agentpkg/pkg/config"
 	"github.com/agentpkg/agentpkg/pkg/mcp"
+	"github.com/agentpkg/agentpkg/pkg/policy"
 	"github.com/agentpkg/agentpkg/pkg/projector"
+	"github.com/agentpkg/agentpkg/pkg/runtimes"
+	"github.com/agentpkg/agentpkg/pkg/serve"
 	"github.com/agentpkg/agentpkg/pkg/skill"
 	"github.com/agentpkg/agentpkg/pkg/source"
 	"github.com/agentpkg/agentpkg/pkg/store"
+	"github.com/agentpkg/agentpkg/pkg/sumdb"
 )
 
 type Installer struct {
@@ -18,16 +32,197 @@ type Installer struct {
 	ProjectDir string
 	Agents     []string
 	Global     bool
+
+	// VendorDir, when set, is a directory of vendored package copies (see
+	// Vendor). Packages with a vendored copy are loaded from it instead of
+	// being fetched, and projections point at the vendored copy.
+	VendorDir string
+
+	// EnvSet selects the named env set of every MCP server that defines
+	// it (see config.MCPSource.WithEnvSet).
+	EnvSet string
+
+	// RequireEnvSet makes InstallAll fail if no MCP server defines
+	// EnvSet, as when it was passed with --env-set. Otherwise, as for
+	// env_set in the dev config, which applies to every project, an env
+	// set the manifest doesn't define is only reported to Warn.
+	RequireEnvSet bool
+
+	// FetchTimeout bounds each package fetch and upstream check, unless
+	// the package sets its own timeout in the manifest. Zero means
+	// DefaultFetchTimeout.
+	FetchTimeout time.Duration
+
+	// Projection is the skill projection strategy, one of
+	// config.ProjectionStrategies ("" links to the store by absolute path).
+	Projection string
+
+	// ProjectionMode is how skills are projected, one of
+	// config.ProjectionModes ("" symlinks them), and ProjectionModes
+	// overrides it for the agents it lists (see
+	// config.DevConfig.AgentProjectionModes).
+	ProjectionMode  string
+	ProjectionModes map[string]string
+
+	// SocketAgents are the agents whose configs reach apkg serve over its
+	// Unix domain socket, when it advertises one, rather than TCP.
+	SocketAgents []string
+
+	// MCPScopes maps agents to the scope a project install projects their
+	// MCP servers into (see config.DevConfig.MCPScopes). Agents mapped to
+	// config.ServerScopeGlobal get the project's servers in their global
+	// config; the others follow Global.
+	MCPScopes map[string]string
+
+	// MCPTypes maps agents to the "type" their configs give MCP servers of
+	// each transport, overriding their projectors (see
+	// config.DevConfig.MCPTypes).
+	MCPTypes map[string]map[string]string
+
+	// MCPCommands maps agents to how their configs launch managed stdio
+	// MCP servers, one of config.MCPCommandStyles (see
+	// config.DevConfig.MCPCommands). Agents not listed get the absolute
+	// store path.
+	MCPCommands map[string]string
+
+	// DeferredServers are MCP servers of a project install that agents
+	// take from the global install of the same name instead (see
+	// DuplicateServers). They are still locked, but not projected into
+	// the project, and earlier projections of them there are removed.
+	DeferredServers []string
+
+	// Concurrency bounds the packages InstallAll fetches, or Outdated
+	// checks, at once. Zero means DefaultConcurrency, and one handles them
+	// one after another.
+	Concurrency int
+
+	// Metadata, if set, caches and rate limits the upstream lookups of
+	// Outdated (see source.Metadata). Without it, every check asks
+	// upstream.
+	Metadata *source.Metadata
+
+	// Warn, if set, receives problems that don't fail the install, such
+	// as a package whose content no longer matches the checksum database
+	// (see sumdb.Check). InstallAll calls it from concurrent fetches.
+	Warn func(error)
+
+	// Force makes InstallAll accept packages whose content no longer
+	// matches the integrity locked for them (see IntegrityError),
+	// reporting them to Warn instead of failing.
+	Force bool
+
+	// ToolOutput, if set, receives the output of the package managers
+	// (npm, uv, go) installing managed MCP servers as they run. Either
+	// way, the output of a failed install is kept in the store (see
+	// source.LogsDir).
+	ToolOutput io.Writer
+
+	// Report, if set, receives the outcome of each package InstallAll
+	// installs once it is projected.
+	Report func(PackageResult)
+
+	// StatePath, if set, is the projection state file recording which
+	// agent config entries apkg owns (see projector.Ownership). Entries
+	// apkg doesn't own are only replaced as Conflict decides. It also
+	// records the files, symlinks, and config entries the install
+	// projected, so removing a package cleans up exactly those and
+	// ProjectionDrift can report the ones changed out-of-band.
+	StatePath string
+
+	// Conflict, if set, decides how to resolve an MCP server entry in an
+	// agent config that apkg would project over but doesn't own, returning
+	// one of projector.ConflictResolutions. Without it such entries are
+	// overwritten. Servers in the existing lockfile of InstallAll are
+	// always overwritten, since apkg created them before it recorded
+	// ownership.
+	Conflict func(projector.Conflict) (string, error)
+
+	// Policy, if set, is asked to approve the packages an install resolved
+	// before any of them is projected (see policy.Enforce).
+	Policy policy.Hook
+
+	// Session, if set, collects the changes to agent JSON configs until
+	// the caller commits it. Operations that project many packages open
+	// their own session when there is none, so each config file is
+	// written once.
+	Session *projector.ConfigSession
+
+	// runtimes are the managed runtimes installed by InstallRuntimes and
+	// the toolchains the project pins, by name.
+	runtimes map[string]*runtimes.Runtime
+
+	// ownership is loaded from StatePath for the current batch.
+	ownership *projector.Ownership
+	// records are loaded from StatePath for the current batch.
+	records *[]ProjectionRecord
+	// resolutions are the answers of Conflict in the current batch, by
+	// config path and pointer, so replays of a session don't ask again.
+	resolutions map[string]string
+	// lockedServers are the MCP servers of the existing lockfile.
+	lockedServers map[string]bool
+
+	// fetchLocks serialize concurrent fetches of the same repository or
+	// package (see fetchKey), which share store entries.
+	fetchMu    sync.Mutex
+	fetchLocks map[string]*sync.Mutex
 }
 
+// DefaultFetchTimeout is the fetch timeout used when neither the package
+// nor Installer.FetchTimeout sets one.
+const DefaultFetchTimeout = 10 * time.Minute
+
+// storeLockTimeout bounds how long an operation waits for another apkg
+// process to release the store lock (see store.Store.Lock), which an
+// install holds while it fetches.
+const storeLockTimeout = 10 * time.Minute
+
+// DefaultConcurrency is the number of packages InstallAll fetches at once
+// when Installer.Concurrency is zero.
+const DefaultConcurrency = 4
+
 // InstallAll resolves and installs all skills from the config. It compares
 // the config against the existing lockfile to avoid redundant network calls:
 // if a skill's ref hasn't changed and the lockfile has a resolved commit,
 // the locked commit is used directly so GitSource.Fetch only checks the
-// local cache. Returns a new lockfile capturing the resolved state.
-func (inst *Installer) InstallAll(ctx context.Context, cfg *config.Config, existing *config.LockFile) (*config.LockFile, error) {
-	lockIndex := buildLockIndex(existing)
-	lf := &config.LockFile{Version: 1}
+// local cache. Dangling skill links left behind by store cleanup are removed
+// first (see RemoveDanglingLinks). Returns a new lockfile capturing the
+// resolved state, in the same order however many packages were fetched
+// at once (see Concurrency). Missing tools are reported before anything
+// is fetched (see CheckTools), and packages whose content differs from
+// their locked integrity fail the install unless Force is set (see
+// IntegrityError).
+func (inst *Installer) InstallAll(ctx context.Context, cfg *config.Config, existing *config.LockFile) (lf *config.LockFile, err error) {
+	if err := inst.CheckTools(cfg, existing); err != nil {
+		return nil, err
+	}
+	err = inst.batch(func() error {
+		lf, err = inst.installAll(ctx, cfg, existing)
+		return err
+	})
+	return lf, err
+}
+
+func (inst *Installer) installAll(ctx context.Context, cfg *config.Config, existing *config.LockFile) (*config.LockFile, error) {
+	lockIndex := buildLockIndex(cfg, existing)
+	mcpLockIndex := buildMCPLockIndex(existing)
+	inst.lockedServers = make(map[string]bool, len(mcpLockIndex))
+	for name := range mcpLockIndex {
+		inst.lockedServers[name] = true
+	}
+	defer func() { inst.lockedServers = nil }()
+	lf := &config.LockFile{Version: config.LockFileVersion}
+
+	// Links of declared skills are recreated below, fetching their store
+	// entries again if needed; links of removed skills only go away.
+	dangling, err := inst.RemoveDanglingLinks(cfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, link := range dangling {
+		if !link.Declared {
+			inst.warn(fmt.Errorf("removed dangling link %s (target %s is missing)", link.Path, link.Target))
+		}
+	}
 
 	// Sort skill names for deterministic ordering.
 	names := make([]string, 0, len(cfg.Skills))
@@ -36,91 +231,246 @@ func (inst *Installer) InstallAll(ctx context.Context, cfg *config.Config, exist
 	}
 	sort.Strings(names)
 
-	var skills []skill.Skill
-	for _, name := range names {
+	// Fetch concurrently, then record the skills in name order.
+	fetched := make([]skill.Skill, len(names))
+	resolvedSkills := make([]*source.ResolvedSource, len(names))
+	durations := make([]time.Duration, len(names))
+	err = inst.parallel(ctx, len(names), func(ctx context.Context, i int) error {
+		start := time.Now()
+		name := names[i]
 		ss := cfg.Skills[name]
-		src := source.SourceFromSkillConfig(ss)
-
-		// If the lockfile already has a resolved commit for this skill and
-		// the config ref hasn't changed, substitute the locked commit as
-		// the ref. resolveRef returns full commit hashes as-is (no network
-		// call), and GitSource.Fetch will find the content in the local
-		// cache — making the entire fetch a local-only operation.
-		key := lockKey(ss)
-		if entry, ok := lockIndex[key]; ok && entry.Commit != "" && entry.Ref == ss.Ref {
-			src = source.SourceFromSkillConfig(config.SkillSource{
-				Git:  ss.Git,
-				Path: ss.Path,
-				Ref:  entry.Commit,
-			})
-		}
-
-		resolved, err := src.Fetch(ctx, inst.Store)
+		if err := validateSkillScope(ss.Scope); err != nil {
+			return fmt.Errorf("skill %q: %w", name, err)
+		}
+
+		resolved, err := inst.vendoredSkill(name, ss, lockIndex)
 		if err != nil {
-			return nil, fmt.Errorf("fetching skill %q: %w", name, err)
+			return fmt.Errorf("loading vendored skill %q: %w", name, err)
+		}
+		if resolved == nil {
+			resolved, err = inst.fetchSkill(ctx, name, ss, lockIndex)
+			if err != nil {
+				return fmt.Errorf("fetching skill %q: %w", name, err)
+			}
 		}
 
 		s, err := skill.Load(resolved.Dir)
 		if err != nil {
-			return nil, fmt.Errorf("loading skill %q: %w", name, err)
+			return fmt.Errorf("loading skill %q: %w", name, err)
 		}
 
 		if err := s.Validate(); err != nil {
-			return nil, fmt.Errorf("validating skill %q: %w", name, err)
+			return fmt.Errorf("validating skill %q: %w", name, err)
+		}
+		fetched[i], resolvedSkills[i], durations[i] = s, resolved, time.Since(start)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var results []PackageResult
+	var skills, userSkills []skill.Skill
+	for i, name := range names {
+		ss := cfg.Skills[name]
+		if ss.Scope == config.SkillScopeUser {
+			userSkills = append(userSkills, fetched[i])
+		} else {
+			skills = append(skills, fetched[i])
 		}
 
-		skills = append(skills, s)
+		entry := lockEntryFromResolved(name, ss, resolvedSkills[i])
+		prev, locked := lockIndex[lockKey(name, ss)]
+		if err := inst.verifyIntegrity(skillIntegrity(name, prev, entry)); err != nil {
+			return nil, err
+		}
+		results = append(results, PackageResult{
+			Kind:     KindSkill,
+			Name:     name,
+			Version:  entry.Commit,
+			Status:   skillStatus(prev, locked, entry),
+			Duration: durations[i],
+		})
+		lf.Skills = append(lf.Skills, entry)
+	}
 
-		lf.Skills = append(lf.Skills, lockEntryFromResolved(ss, resolved))
+	if inst.EnvSet != "" && !definesEnvSet(cfg.MCPServers, inst.EnvSet) {
+		err := fmt.Errorf("env set %q is not defined by any MCP server", inst.EnvSet)
+		if inst.RequireEnvSet {
+			return nil, err
+		}
+		inst.warn(err)
 	}
 
-	if err := inst.projectSkills(skills); err != nil {
+	var lockedRuntimes []config.RuntimeLockEntry
+	if existing != nil {
+		lockedRuntimes = existing.Runtimes
+	}
+	lf.Runtimes, err = inst.InstallRuntimes(ctx, cfg.Runtimes, lockedRuntimes)
+	if err != nil {
 		return nil, err
 	}
 
-	// Install MCP servers.
-	var servers []mcp.MCPServer
-	for name, ms := range cfg.MCPServers {
-		src, err := source.SourceFromMCPConfig(name, ms)
+	// Install MCP servers, fetching concurrently like skills.
+	serverNames := make([]string, 0, len(cfg.MCPServers))
+	for name := range cfg.MCPServers {
+		serverNames = append(serverNames, name)
+	}
+	sort.Strings(serverNames)
+
+	servers := make([]mcp.MCPServer, len(serverNames))
+	entries := make([]config.MCPLockEntry, len(serverNames))
+	durations = make([]time.Duration, len(serverNames))
+	err = inst.parallel(ctx, len(serverNames), func(ctx context.Context, i int) error {
+		start := time.Now()
+		name := serverNames[i]
+		ms, applied, err := cfg.MCPServers[name].WithEnvSet(inst.EnvSet)
 		if err != nil {
-			return nil, fmt.Errorf("resolving MCP server %q: %w", name, err)
+			return fmt.Errorf("resolving MCP server %q: %w", name, err)
 		}
 
-		resolved, err := src.Fetch(ctx, inst.Store)
-		if err != nil {
-			return nil, fmt.Errorf("fetching MCP server %q: %w", name, err)
+		// Vendored definitions capture the base config, so servers with
+		// an env set applied are always resolved from the manifest.
+		var resolved *source.ResolvedSource
+		if !applied {
+			resolved, err = inst.vendoredMCP(name, ms)
+			if err != nil {
+				return fmt.Errorf("loading vendored MCP server %q: %w", name, err)
+			}
+		}
+		if resolved == nil {
+			// Managed packages install the version in the lockfile.
+			src, err := source.SourceFromMCPLock(name, ms, mcpLockIndex[name])
+			if err != nil {
+				return fmt.Errorf("resolving MCP server %q: %w", name, err)
+			}
+
+			resolved, err = inst.fetch(ctx, src, ms.Timeout)
+			if err != nil {
+				return fmt.Errorf("fetching MCP server %q: %w", name, err)
+			}
 		}
 
-		server, err := mcp.Load(resolved.Dir)
+		server, err := mcp.LoadWith(resolved.Dir, serverOptions(resolved))
 		if err != nil {
-			return nil, fmt.Errorf("loading MCP server %q: %w", name, err)
+			return fmt.Errorf("loading MCP server %q: %w", name, err)
 		}
 
 		if err := server.Validate(); err != nil {
-			return nil, fmt.Errorf("validating MCP server %q: %w", name, err)
+			return fmt.Errorf("validating MCP server %q: %w", name, err)
 		}
+		servers[i] = mcp.WithVersion(mcp.WithProject(server, inst.projectID()), resolved.Version)
 
-		servers = append(servers, server)
+		entries[i] = NewMCPLockEntry(name, ms, resolved)
+		if applied {
+			entries[i].EnvSet = inst.EnvSet
+		}
+		durations[i] = time.Since(start)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-		lf.MCPServers = append(lf.MCPServers, mcpLockEntryFromResolved(name, ms, resolved))
+	for i, entry := range entries {
+		prev, locked := mcpLockIndex[entry.Name]
+		if err := inst.verifyIntegrity(mcpIntegrity(entry.Name, prev, entry)); err != nil {
+			return nil, err
+		}
+		results = append(results, PackageResult{
+			Kind:     KindMCP,
+			Name:     entry.Name,
+			Version:  mcpVersion(entry),
+			Status:   mcpStatus(prev, locked, entry),
+			Duration: durations[i],
+		})
+		lf.MCPServers = append(lf.MCPServers, entry)
 	}
 
-	sort.Slice(lf.MCPServers, func(i, j int) bool {
-		return lf.MCPServers[i].Name < lf.MCPServers[j].Name
-	})
+	// Nothing is projected until the policy hook approved every package.
+	if err := inst.checkPolicy(ctx, lockPolicyPackages(cfg, lf)); err != nil {
+		return nil, err
+	}
 
+	if err := inst.projectSkills(config.SkillScopeProject, skills); err != nil {
+		return nil, err
+	}
+	if len(userSkills) > 0 {
+		if err := inst.projectSkills(config.SkillScopeUser, userSkills); err != nil {
+			return nil, err
+		}
+	}
 	if err := inst.projectMCPServers(servers); err != nil {
 		return nil, err
 	}
 
+	inst.report(results)
 	return lf, nil
 }
 
+// fetchSkill fetches a skill source into the store. If the lockfile already
+// has a resolved commit for this skill and the config ref hasn't changed,
+// the locked commit is substituted as the ref. resolveRef returns full
+// commit hashes as-is (no network call), and GitSource.Fetch will find the
+// content in the local cache — making the entire fetch a local-only
+// operation.
+func (inst *Installer) fetchSkill(ctx context.Context, name string, ss config.SkillSource, lockIndex map[string]config.SkillLockEntry) (*source.ResolvedSource, error) {
+	src := source.SourceFromSkillConfig(ss)
+
+	// Relative local paths in the manifest are relative to the project
+	// root, not the directory apkg happens to run in.
+	if local, ok := src.(*source.LocalSource); ok && !filepath.IsAbs(local.Path) && inst.ProjectDir != "" {
+		local.Path = filepath.Join(inst.ProjectDir, local.Path)
+	}
+	// A snapshot outlives the directory it was made of.
+	if local, ok := src.(*source.LocalSource); ok && local.Snapshot {
+		local.Locked = lockIndex[lockKey(name, ss)].Integrity
+	}
+
+	// A downloaded archive must keep the digest it was locked with.
+	if archive, ok := src.(*source.ArchiveSource); ok && archive.SHA256 == "" {
+		archive.SHA256 = lockIndex[lockKey(name, ss)].SHA256
+	}
+
+	if entry, ok := lockIndex[lockKey(name, ss)]; ok && entry.Commit != "" && entry.Ref == ss.Ref {
+		src = source.SourceFromSkillConfig(config.SkillSource{
+			Git:  ss.Git,
+			Path: ss.Path,
+			Ref:  entry.Commit,
+		})
+	}
+
+	resolved, err := inst.fetch(ctx, src, ss.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	// Record the manifest ref rather than the substituted commit so the
+	// lock entry keeps matching the config on the next install.
+	if resolved.Ref != "" {
+		resolved.Ref = ss.Ref
+	}
+	return resolved, nil
+}
+
 // InstallSkill fetches a single source, loads and validates the skill, and
-// projects it. Returns the loaded skill and resolved source so the caller can
-// update the config and lockfile.
-func (inst *Installer) InstallSkill(ctx context.Context, src source.Source) (skill.Skill, *source.ResolvedSource, error) {
-	resolved, err := src.Fetch(ctx, inst.Store)
+// projects it with the given manifest scope (see config.SkillSource.Scope).
+// Returns the loaded skill and resolved source so the caller can update the
+// config and lockfile.
+func (inst *Installer) InstallSkill(ctx context.Context, src source.Source, scope string) (s skill.Skill, resolved *source.ResolvedSource, err error) {
+	err = inst.batch(func() error {
+		s, resolved, err = inst.installSkill(ctx, src, scope, "")
+		return err
+	})
+	return s, resolved, err
+}
+
+// installSkill is InstallSkill with the skill's manifest timeout.
+func (inst *Installer) installSkill(ctx context.Context, src source.Source, scope, timeout string) (skill.Skill, *source.ResolvedSource, error) {
+	if err := validateSkillScope(scope); err != nil {
+		return nil, nil, err
+	}
+
+	resolved, err := inst.fetch(ctx, src, timeout)
 	if err != nil {
 		return nil, nil, fmt.Errorf("fetching skill: %w", err)
 	}
@@ -134,23 +484,316 @@ func (inst *Installer) InstallSkill(ctx context.Context, src source.Source) (ski
 		return nil, nil, fmt.Errorf("validating skill: %w", err)
 	}
 
-	if err := inst.projectSkills([]skill.Skill{s}); err != nil {
+	if err := inst.checkPolicy(ctx, []policy.Package{sourcePolicyPackage(KindSkill, s.Name(), src, resolved)}); err != nil {
+		return nil, nil, err
+	}
+	if err := inst.projectSkills(scope, []skill.Skill{s}); err != nil {
 		return nil, nil, err
 	}
 
 	return s, resolved, nil
 }
 
+// fetch fetches src into the store within the fetch timeout (see
+// withTimeout).
+func (inst *Installer) fetch(ctx context.Context, src source.Source, timeout string) (*source.ResolvedSource, error) {
+	switch src := src.(type) {
+	case *source.OCISource:
+		// Relative container volumes in the manifest are relative to the
+		// project root, like local skill paths.
+		if src.BaseDir == "" {
+			src.BaseDir = inst.ProjectDir
+		}
+	case *source.NPMSource:
+		if src.Node == nil {
+			src.Node = inst.runtimes[runtimes.Node]
+		}
+		if src.Output == nil {
+			src.Output = inst.ToolOutput
+		}
+	case *source.UVSource:
+		if src.Python == nil {
+			src.Python = inst.runtimes[runtimes.Python]
+		}
+		if src.Output == nil {
+			src.Output = inst.ToolOutput
+		}
+	case *source.GoSource:
+		if src.Go == nil {
+			src.Go = inst.runtimes[runtimes.Go]
+		}
+		if src.Output == nil {
+			src.Output = inst.ToolOutput
+		}
+	}
+
+	if key := fetchKey(src); key != "" {
+		unlock := inst.lockFetch(key)
+		defer unlock()
+	}
+
+	var resolved *source.ResolvedSource
+	err := inst.withTimeout(ctx, timeout, func(ctx context.Context) error {
+		var err error
+		resolved, err = src.Fetch(ctx, inst.Store)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	inst.checkSums(src, resolved)
+	return resolved, nil
+}
+
+// checkSums checks a fetched package against the checksum database.
+// Managed packages are keyed by their release. Git skills are keyed by
+// commit and, for release tags, by the tag as well, so a tag re-pointed at
+// different content is caught.
+func (inst *Installer) checkSums(src source.Source, resolved *source.ResolvedSource) {
+	if resolved.Integrity == "" {
+		return
+	}
+	if resolved.Package != "" && resolved.Version != "" {
+		if err := sumdb.Check(inst.Store, resolved.Package, resolved.Version, resolved.Integrity); err != nil {
+			inst.warn(err)
+		}
+		return
+	}
+
+	git, ok := src.(*source.GitSource)
+	if !ok || resolved.Commit == "" {
+		return
+	}
+
+	module := git.URL
+	if git.Path != "" {
+		module += "//" + git.Path
+	}
+	versions := []string{resolved.Commit}
+	if _, ok := config.CompareVersions(git.Ref, git.Ref); ok {
+		versions = append(versions, git.Ref)
+	}
+
+	for _, version := range versions {
+		if err := sumdb.Check(inst.Store, module, version, resolved.Integrity); err != nil {
+			inst.warn(err)
+		}
+	}
+}
+
+func (inst *Installer) warn(err error) {
+	if inst.Warn != nil {
+		inst.Warn(err)
+	}
+}
+
+// batch runs fn in a config session that is committed once fn succeeds,
+// unless the caller already set inst.Session and commits it itself. The
+// store lock is held meanwhile, so cache gc doesn't delete what fn fetches.
+func (inst *Installer) batch(fn func() error) error {
+	if inst.Session != nil {
+		return fn()
+	}
+
+	unlock, err := inst.Store.Lock(storeLockTimeout)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if inst.StatePath != "" {
+		ownership, err := projector.LoadOwnership(inst.StatePath)
+		if err != nil {
+			return err
+		}
+		inst.ownership = ownership
+		inst.resolutions = make(map[string]string)
+		defer func() { inst.ownership, inst.resolutions = nil, nil }()
+	}
+
+	if err := inst.loadRecords(); err != nil {
+		return err
+	}
+	defer func() { inst.records = nil }()
+
+	inst.Session = projector.NewConfigSession()
+	inst.Session.Warn = inst.Warn
+	defer func() { inst.Session = nil }()
+
+	if err := fn(); err != nil {
+		return err
+	}
+	if err := inst.Session.Commit(); err != nil {
+		return fmt.Errorf("writing agent configs: %w", err)
+	}
+	if inst.ownership != nil {
+		if err := inst.ownership.Save(inst.StatePath); err != nil {
+			return fmt.Errorf("recording projection state: %w", err)
+		}
+	}
+	if err := inst.saveRecords(); err != nil {
+		return fmt.Errorf("recording projections: %w", err)
+	}
+	return nil
+}
+
+// resolveConflict resolves c with Conflict, remembering the answer for
+// the rest of the batch.
+func (inst *Installer) resolveConflict(c projector.Conflict) (string, error) {
+	key := c.Path + "\x00" + c.Pointer
+	if resolution, ok := inst.resolutions[key]; ok {
+		return resolution, nil
+	}
+
+	resolution := projector.ConflictOverwrite
+	if inst.Conflict != nil && !inst.lockedServers[c.Server] {
+		var err error
+		resolution, err = inst.Conflict(c)
+		if err != nil {
+			return "", err
+		}
+		if !slices.Contains(projector.ConflictResolutions, resolution) {
+			return "", fmt.Errorf("unknown conflict resolution %q for MCP server %q in %s", resolution, c.Server, c.Path)
+		}
+	}
+	inst.resolutions[key] = resolution
+	return resolution, nil
+}
+
+// withTimeout runs op with ctx limited to a package's manifest timeout,
+// or FetchTimeout if the package doesn't set one, so a hung registry or
+// image pull fails instead of stalling the install.
+func (inst *Installer) withTimeout(ctx context.Context, timeout string, op func(context.Context) error) error {
+	d, err := config.ParseTimeout(timeout)
+	if err != nil {
+		return err
+	}
+	if d == 0 {
+		d = inst.FetchTimeout
+	}
+	if d == 0 {
+		d = DefaultFetchTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d)
+	defer cancel()
+	if err := op(ctx); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("timed out after %s (raise the package's timeout or fetch_timeout): %w", d, err)
+		}
+		return err
+	}
+	return nil
+}
+
 func (inst *Installer) projectionOpts() projector.ProjectionOpts {
-	opts := projector.ProjectionOpts{ProjectDir: inst.ProjectDir}
+	opts := projector.ProjectionOpts{ProjectDir: inst.ProjectDir, Strategy: inst.Projection, Session: inst.Session, Warn: inst.Warn}
+	if inst.ownership != nil {
+		opts.Ownership = inst.ownership
+		opts.Conflict = inst.resolveConflict
+	}
 	if inst.Global {
 		opts.Scope = projector.ScopeGlobal
 	}
 	return opts
 }
 
-func (inst *Installer) projectSkills(skills []skill.Skill) error {
+// mcpProjectionOpts returns the projection options for the MCP servers of
+// agent, which MCPScopes may move to the global scope.
+func (inst *Installer) mcpProjectionOpts(agent string) projector.ProjectionOpts {
 	opts := inst.projectionOpts()
+	opts.MCPTypes = inst.MCPTypes[agent]
+	if inst.MCPScopes[agent] == config.ServerScopeGlobal {
+		opts.Scope = projector.ScopeGlobal
+	}
+	return opts
+}
+
+// mcpEntryName returns the name of the entry of the MCP server name in
+// agent's config. A project install that MCPScopes moves to the shared
+// global config, or whose agent has only a shared config (see
+// projector.Targets.SharedMCPConfig), names its entries after the project
+// too: they launch or route to this project's install, so projects
+// mustn't overwrite or remove each other's.
+func (inst *Installer) mcpEntryName(agent, name string) string {
+	project := inst.projectID()
+	if project == "" || inst.MCPScopes[agent] != config.ServerScopeGlobal && !inst.sharedMCPConfig(agent) {
+		return name
+	}
+	sum := sha256.Sum256([]byte(project))
+	base := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '-'
+	}, filepath.Base(project))
+	return name + "-" + base + "-" + hex.EncodeToString(sum[:3])
+}
+
+// sharedMCPConfig reports whether agent writes the MCP servers of every
+// project to the same config.
+func (inst *Installer) sharedMCPConfig(agent string) bool {
+	proj, ok := projector.GetProjector(agent)
+	if !ok {
+		return false
+	}
+	targets, err := proj.Targets(inst.mcpProjectionOpts(agent))
+	return err == nil && targets.SharedMCPConfig
+}
+
+// projectID identifies the project to apkg serve (see mcp.WithProject):
+// its absolute directory, or "" for global installs, which every project
+// uses.
+func (inst *Installer) projectID() string {
+	if inst.Global || inst.ProjectDir == "" {
+		return ""
+	}
+	abs, err := filepath.Abs(inst.ProjectDir)
+	if err != nil {
+		return ""
+	}
+	return abs
+}
+
+// skillProjectionOpts returns the projection options for skills with the
+// given manifest scope. User-scoped skills project into the agents' global
+// skills location regardless of where the manifest lives.
+func (inst *Installer) skillProjectionOpts(scope string) (projector.ProjectionOpts, error) {
+	if scope != config.SkillScopeUser {
+		return inst.projectionOpts(), nil
+	}
+	home, err := config.HomeDir()
+	if err != nil {
+		return projector.ProjectionOpts{}, err
+	}
+	opts := inst.projectionOpts()
+	opts.ProjectDir = home
+	opts.Scope = projector.ScopeGlobal
+	return opts, nil
+}
+
+// projectionMode returns how skills are projected for agent.
+func (inst *Installer) projectionMode(agent string) string {
+	if mode, ok := inst.ProjectionModes[agent]; ok {
+		return mode
+	}
+	return inst.ProjectionMode
+}
+
+func validateSkillScope(scope string) error {
+	switch scope {
+	case "", config.SkillScopeProject, config.SkillScopeUser:
+		return nil
+	default:
+		return fmt.Errorf("unknown scope %q (want %q or %q)", scope, config.SkillScopeProject, config.SkillScopeUser)
+	}
+}
+
+func (inst *Installer) projectSkills(scope string, skills []skill.Skill) error {
+	opts, err := inst.skillProjectionOpts(scope)
+	if err != nil {
+		return err
+	}
 	for _, agent := range inst.Agents {
 		proj, ok := projector.GetProjector(agent)
 		if !ok {
@@ -159,15 +802,26 @@ func (inst *Installer) projectSkills(skills []skill.Skill) error {
 		if !proj.SupportsSkills() {
 			continue
 		}
+		opts.Mode = inst.projectionMode(agent)
 		if err := proj.ProjectSkills(opts, skills); err != nil {
 			return fmt.Errorf("projecting skills for %s: %w", agent, err)
 		}
+		if err := inst.recordSkills(agent, proj, opts, skills); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
 func (inst *Installer) projectMCPServers(servers []mcp.MCPServer) error {
-	opts := inst.projectionOpts()
+	servers, deferred := inst.splitDeferred(servers)
+	for _, name := range deferred {
+		if err := inst.RemoveMCP(name); err != nil {
+			return err
+		}
+	}
+
+	ep := serve.Advertised(inst.Store)
 	for _, agent := range inst.Agents {
 		proj, ok := projector.GetProjector(agent)
 		if !ok {
@@ -176,23 +830,98 @@ func (inst *Installer) projectMCPServers(servers []mcp.MCPServer) error {
 		if !proj.SupportsMCPServers() {
 			continue
 		}
-		if err := proj.ProjectMCPServers(opts, servers); err != nil {
+		opts := inst.mcpProjectionOpts(agent)
+		projected := inst.launched(agent, inst.serveRouted(agent, ep, servers))
+		for i, server := range projected {
+			projected[i] = mcp.WithName(server, inst.mcpEntryName(agent, server.Name()))
+		}
+		if err := proj.ProjectMCPServers(opts, projected); err != nil {
 			return fmt.Errorf("projecting MCP servers for %s: %w", agent, err)
 		}
+		if err := inst.recordMCPServers(agent, proj, opts, servers); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
+// serveRouted points the servers routed through apkg serve at the endpoint
+// it last advertised: its socket for SocketAgents, if it has one, and its
+// TCP port otherwise, which is warned about when it has a socket.
+func (inst *Installer) serveRouted(agent string, ep serve.Endpoint, servers []mcp.MCPServer) []mcp.MCPServer {
+	base := ep.URL()
+	switch {
+	case ep.Socket == "":
+	case slices.Contains(inst.SocketAgents, agent):
+		base = ep.SocketURL()
+	case slices.ContainsFunc(servers, mcp.ServeRouted):
+		err := fmt.Errorf("%s reaches apkg serve over localhost TCP rather than its socket %s; add it to %s if it supports http+unix:// URLs", agent, ep.Socket, config.KeyServeSocketAgents)
+		if ep.Port == 0 {
+			err = fmt.Errorf("%s can't reach apkg serve, which only listens on its socket %s; add it to %s if it supports http+unix:// URLs", agent, ep.Socket, config.KeyServeSocketAgents)
+		}
+		inst.warn(err)
+	}
+
+	routed := make([]mcp.MCPServer, len(servers))
+	for i, server := range servers {
+		routed[i] = mcp.WithServeURL(server, base)
+	}
+	return routed
+}
+
+// launched sets up servers to be launched the way MCPCommands says for
+// agent.
+func (inst *Installer) launched(agent string, servers []mcp.MCPServer) []mcp.MCPServer {
+	style := inst.MCPCommands[agent]
+	if style == "" || style == config.MCPCommandAbsolute {
+		return servers
+	}
+
+	launcher := inst.launcher(style)
+	launched := make([]mcp.MCPServer, len(servers))
+	for i, server := range servers {
+		launched[i] = mcp.WithCommand(server, style, launcher)
+	}
+	return launched
+}
+
+// launcher returns the command that runs apkg exec, or apkg run mcp for
+// config.MCPCommandRun, for this install's project, or for the global
+// install, from any working directory.
+func (inst *Installer) launcher(style string) []string {
+	exe, err := os.Executable()
+	if err != nil {
+		exe = "apkg"
+	}
+	launcher := []string{exe, "exec"}
+	if style == config.MCPCommandRun {
+		launcher = []string{exe, "run", "mcp"}
+	}
+	if inst.Global {
+		return append(launcher, "--global")
+	}
+	return append(launcher, "--project-dir", inst.projectID())
+}
+
 // InstallMCP fetches a single MCP source, loads and validates the server, and
 // projects it. Returns the loaded server and resolved source so the caller can
 // update the config and lockfile.
-func (inst *Installer) InstallMCP(ctx context.Context, name string, src source.Source) (mcp.MCPServer, *source.ResolvedSource, error) {
-	resolved, err := src.Fetch(ctx, inst.Store)
+func (inst *Installer) InstallMCP(ctx context.Context, name string, src source.Source) (server mcp.MCPServer, resolved *source.ResolvedSource, err error) {
+	err = inst.batch(func() error {
+		server, resolved, err = inst.installMCP(ctx, name, src, "")
+		return err
+	})
+	return server, resolved, err
+}
+
+// installMCP is InstallMCP with the server's manifest timeout.
+func (inst *Installer) installMCP(ctx context.Context, name string, src source.Source, timeout string) (mcp.MCPServer, *source.ResolvedSource, error) {
+	resolved, err := inst.fetch(ctx, src, timeout)
 	if err != nil {
 		return nil, nil, fmt.Errorf("fetching MCP server: %w", err)
 	}
 
-	server, err := mcp.Load(resolved.Dir)
+	server, err := mcp.LoadWith(resolved.Dir, serverOptions(resolved))
 	if err != nil {
 		return nil, nil, fmt.Errorf("loading MCP server: %w", err)
 	}
@@ -200,7 +929,11 @@ func (inst *Installer) InstallMCP(ctx context.Context, name string, src source.S
 	if err := server.Validate(); err != nil {
 		return nil, nil, fmt.Errorf("validating MCP server: %w", err)
 	}
+	server = mcp.WithVersion(mcp.WithProject(server, inst.projectID()), resolved.Version)
 
+	if err := inst.checkPolicy(ctx, []policy.Package{sourcePolicyPackage(KindMCP, name, src, resolved)}); err != nil {
+		return nil, nil, err
+	}
 	if err := inst.projectMCPServers([]mcp.MCPServer{server}); err != nil {
 		return nil, nil, err
 	}
@@ -208,9 +941,19 @@ func (inst *Installer) InstallMCP(ctx context.Context, name string, src source.S
 	return server, resolved, nil
 }
 
-// RemoveSkill removes a skill's projections from all registered agents.
-func (inst *Installer) RemoveSkill(name string) error {
-	opts := inst.projectionOpts()
+// RemoveSkill removes a skill's projections from all registered agents,
+// looking for them in the location of the skill's manifest scope.
+func (inst *Installer) RemoveSkill(name, scope string) error {
+	return inst.batch(func() error {
+		return inst.removeSkill(name, scope)
+	})
+}
+
+func (inst *Installer) removeSkill(name, scope string) error {
+	opts, err := inst.skillProjectionOpts(scope)
+	if err != nil {
+		return err
+	}
 	for _, agent := range inst.Agents {
 		proj, ok := projector.GetProjector(agent)
 		if !ok {
@@ -222,13 +965,38 @@ func (inst *Installer) RemoveSkill(name string) error {
 		if err := proj.UnprojectSkills(opts, []string{name}); err != nil {
 			return fmt.Errorf("unprojecting skill %q for %s: %w", name, agent, err)
 		}
+		inst.record(KindSkill, name, agent, nil)
+	}
+	if err := inst.removeRecorded(KindSkill, name); err != nil {
+		return err
+	}
+	if opts.Scope == projector.ScopeLocal {
+		return projector.RemoveSkillContent(opts, name)
 	}
 	return nil
 }
 
+// RemoveMCPServers removes the projections of the named MCP servers from
+// all registered agents, writing each agent config once.
+func (inst *Installer) RemoveMCPServers(names []string) error {
+	return inst.batch(func() error {
+		for _, name := range names {
+			if err := inst.RemoveMCP(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 // RemoveMCP removes an MCP server's projections from all registered agents.
 func (inst *Installer) RemoveMCP(name string) error {
-	opts := inst.projectionOpts()
+	return inst.batch(func() error {
+		return inst.removeMCP(name)
+	})
+}
+
+func (inst *Installer) removeMCP(name string) error {
 	for _, agent := range inst.Agents {
 		proj, ok := projector.GetProjector(agent)
 		if !ok {
@@ -237,19 +1005,59 @@ func (inst *Installer) RemoveMCP(name string) error {
 		if !proj.SupportsMCPServers() {
 			continue
 		}
-		if err := proj.UnprojectMCPServers(opts, []string{name}); err != nil {
+		opts := inst.mcpProjectionOpts(agent)
+		entry := inst.mcpEntryName(agent, name)
+		owned, err := inst.ownsMCPEntry(proj, opts, entry)
+		if err != nil {
 			return fmt.Errorf("unprojecting MCP server %q for %s: %w", name, agent, err)
 		}
+		if owned {
+			if err := proj.UnprojectMCPServers(opts, []string{entry}); err != nil {
+				return fmt.Errorf("unprojecting MCP server %q for %s: %w", name, agent, err)
+			}
+		}
+		inst.record(KindMCP, name, agent, nil)
 	}
-	return nil
+	return inst.removeRecorded(KindMCP, name)
+}
+
+// ownsMCPEntry reports whether removing the MCP server entry named entry
+// from proj's config with opts may delete it: whether apkg created or
+// adopted it, or there's nothing to delete. Entries apkg doesn't own,
+// e.g. another project's or one added by hand, are left alone with a
+// warning. Without ownership, every entry is apkg's.
+func (inst *Installer) ownsMCPEntry(proj projector.Projector, opts projector.ProjectionOpts, entry string) (bool, error) {
+	if opts.Ownership == nil {
+		return true, nil
+	}
+	targets, err := proj.Targets(opts)
+	if err != nil || targets.MCPConfig == "" {
+		return true, err
+	}
+	pointer := projector.JoinPointer(targets.MCPPointer, entry)
+	if opts.Ownership.Owns(targets.MCPConfig, pointer) {
+		return true, nil
+	}
+	if _, ok, err := projector.Entry(targets.MCPConfig, pointer); err != nil || !ok {
+		return true, err
+	}
+	inst.warn(fmt.Errorf("left MCP server %q in %s in place: apkg didn't create it", entry, targets.MCPConfig))
+	return false, nil
 }
 
-func mcpLockEntryFromResolved(name string, ms config.MCPSource, resolved *source.ResolvedSource) config.MCPLockEntry {
+// NewMCPLockEntry returns the lockfile entry of the MCP server name with
+// config ms, resolved to resolved.
+func NewMCPLockEntry(name string, ms config.MCPSource, resolved *source.ResolvedSource) config.MCPLockEntry {
 	entry := config.MCPLockEntry{
-		Name:        name,
-		Transport:   ms.Transport,
-		Integrity:   resolved.Integrity,
-		InstallPath: resolved.Dir,
+		Name:            name,
+		Transport:       ms.Transport,
+		ResolvedVersion: resolved.Version,
+		Integrity:       resolved.Integrity,
+		InstallPath:     resolved.Dir,
+		Digest:          resolved.Digest,
+		ManifestDigest:  resolved.ManifestDigest,
+		Runtime:         resolved.Runtime,
+		Volumes:         resolved.Volumes,
 	}
 	if ms.ManagedStdioMCPConfig != nil {
 		entry.Package = ms.Package
@@ -275,9 +1083,49 @@ func mcpLockEntryFromResolved(name string, ms config.MCPSource, resolved *source
 	if ms.HttpMCPConfig != nil {
 		entry.HeaderKeys = mapKeys(ms.Headers)
 	}
+	entry.ConfigDigest = configDigest(ms)
 	return entry
 }
 
+// serverOptions returns how the server resolved as resolved runs for this
+// project (see mcp.Options).
+func serverOptions(resolved *source.ResolvedSource) mcp.Options {
+	return mcp.Options{Runtime: resolved.Runtime, Volumes: resolved.Volumes}
+}
+
+// configDigest returns the "sha256:<hex>" digest of the env and header
+// values of ms, as written in the manifest, or "" if it has none.
+func configDigest(ms config.MCPSource) string {
+	var env, headers map[string]string
+	if ms.LocalMCPConfig != nil {
+		env = ms.Env
+	}
+	if ms.HttpMCPConfig != nil {
+		headers = ms.Headers
+	}
+	if len(env) == 0 && len(headers) == 0 {
+		return ""
+	}
+	h := sha256.New()
+	for _, k := range mapKeys(env) {
+		fmt.Fprintf(h, "env %q=%q\n", k, env[k])
+	}
+	for _, k := range mapKeys(headers) {
+		fmt.Fprintf(h, "header %q=%q\n", k, headers[k])
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// definesEnvSet reports whether any server defines the named env set.
+func definesEnvSet(servers map[string]config.MCPSource, name string) bool {
+	for _, ms := range servers {
+		if _, ok := ms.EnvSets[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
 func mapKeys(m map[string]string) []string {
 	if len(m) == 0 {
 		return nil
@@ -290,22 +1138,27 @@ func mapKeys(m map[string]string) []string {
 	return keys
 }
 
-func lockEntryFromResolved(ss config.SkillSource, resolved *source.ResolvedSource) config.SkillLockEntry {
+func lockEntryFromResolved(name string, ss config.SkillSource, resolved *source.ResolvedSource) config.SkillLockEntry {
 	return config.SkillLockEntry{
+		Name:      name,
 		Git:       ss.Git,
 		Path:      ss.Path,
 		Ref:       resolved.Ref,
 		Commit:    resolved.Commit,
 		Integrity: resolved.Integrity,
+		URL:       ss.URL,
+		SHA256:    resolved.SHA256,
 	}
 }
 
 // buildLockIndex creates a lookup map from existing lockfile entries,
-// keyed by git URL + path (for git sources) or just path (for local sources).
-func buildLockIndex(lf *config.LockFile) map[string]config.SkillLockEntry {
+// keyed by lockKey. Entries of lockfiles written before skill names were
+// recorded are named after the skills of cfg first.
+func buildLockIndex(cfg *config.Config, lf *config.LockFile) map[string]config.SkillLockEntry {
 	if lf == nil {
 		return nil
 	}
+	NameSkillLockEntries(cfg, lf)
 	idx := make(map[string]config.SkillLockEntry, len(lf.Skills))
 	for _, entry := range lf.Skills {
 		idx[lockKeyFromEntry(entry)] = entry
@@ -313,16 +1166,60 @@ func buildLockIndex(lf *config.LockFile) map[string]config.SkillLockEntry {
 	return idx
 }
 
-func lockKey(ss config.SkillSource) string {
-	if ss.Git != "" {
-		return ss.Git + "|" + ss.Path
+// buildMCPLockIndex maps MCP server names to their lock entries.
+func buildMCPLockIndex(lf *config.LockFile) map[string]config.MCPLockEntry {
+	if lf == nil {
+		return nil
+	}
+	idx := make(map[string]config.MCPLockEntry, len(lf.MCPServers))
+	for _, entry := range lf.MCPServers {
+		idx[entry.Name] = entry
+	}
+	return idx
+}
+
+// NameSkillLockEntries fills in the names of the skill entries of lf
+// written before lockfiles recorded them, from the skills of cfg with the
+// same source. Entries that no unclaimed skill matches stay unnamed.
+func NameSkillLockEntries(cfg *config.Config, lf *config.LockFile) {
+	if cfg == nil || lf == nil {
+		return
+	}
+	claimed := make(map[string]bool, len(lf.Skills))
+	for _, entry := range lf.Skills {
+		if entry.Name != "" {
+			claimed[entry.Name] = true
+		}
+	}
+	for i, entry := range lf.Skills {
+		if entry.Name != "" {
+			continue
+		}
+		for _, name := range sortedNames(cfg.Skills) {
+			if !claimed[name] && sourceKey(cfg.Skills[name].Remote(), cfg.Skills[name].Path) == sourceKey(entry.Remote(), entry.Path) {
+				lf.Skills[i].Name = name
+				claimed[name] = true
+				break
+			}
+		}
 	}
-	return ss.Path
+}
+
+// lockKey identifies a skill across the manifest and the lockfile by its
+// name and source.
+func lockKey(name string, ss config.SkillSource) string {
+	return name + "|" + sourceKey(ss.Remote(), ss.Path)
 }
 
 func lockKeyFromEntry(entry config.SkillLockEntry) string {
-	if entry.Git != "" {
-		return entry.Git + "|" + entry.Path
+	return entry.Name + "|" + sourceKey(entry.Remote(), entry.Path)
+}
+
+// sourceKey is the git or archive URL + path for remote sources, or just
+// path for local sources.
+func sourceKey(remote, path string) string {
+	if remote != "" {
+		return remote + "|" + path
 	}
-	return entry.Path
+	return path
 }
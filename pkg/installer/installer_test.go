@@ -4,11 +4,21 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/agentpkg/agentpkg/pkg/apkgtest"
 	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/mcp"
+	"github.com/agentpkg/agentpkg/pkg/projector"
+	_ "github.com/agentpkg/agentpkg/pkg/projector/goose"
+	"github.com/agentpkg/agentpkg/pkg/serve"
+	"github.com/agentpkg/agentpkg/pkg/skill"
 	"github.com/agentpkg/agentpkg/pkg/source"
 	"github.com/agentpkg/agentpkg/pkg/store"
+	"github.com/agentpkg/agentpkg/pkg/store/storetest"
 )
 
 // writeSkill creates a minimal SKILL.md in dir with the given name.
@@ -21,6 +31,37 @@ func writeSkill(t *testing.T, dir, name string) {
 	}
 }
 
+// skillsOnlyProjector projects skills into <dir>/.test/skills like the
+// real agents do, and doesn't support MCP servers.
+type skillsOnlyProjector struct {
+	sp projector.SkillProjector
+}
+
+func (skillsOnlyProjector) GitignoreEntries() []string { return nil }
+func (skillsOnlyProjector) SupportsSkills() bool       { return true }
+func (p skillsOnlyProjector) Targets(opts projector.ProjectionOpts) (projector.Targets, error) {
+	return projector.Targets{SkillsDir: p.sp.SkillsDir(opts)}, nil
+}
+func (p skillsOnlyProjector) ProjectSkills(opts projector.ProjectionOpts, skills []skill.Skill) error {
+	return p.sp.ProjectSkills(opts, skills)
+}
+func (p skillsOnlyProjector) UnprojectSkills(opts projector.ProjectionOpts, names []string) error {
+	return p.sp.UnprojectSkills(opts, names)
+}
+func (skillsOnlyProjector) SupportsMCPServers() bool       { return false }
+func (skillsOnlyProjector) MCPLimits() projector.Limits    { return projector.Limits{} }
+func (skillsOnlyProjector) MCPFormat() projector.MCPFormat { return projector.MCPFormat{} }
+func (skillsOnlyProjector) ProjectMCPServers(_ projector.ProjectionOpts, _ []mcp.MCPServer) error {
+	return nil
+}
+func (skillsOnlyProjector) UnprojectMCPServers(_ projector.ProjectionOpts, _ []string) error {
+	return nil
+}
+
+func init() {
+	projector.RegisterProjector("test-skills-only", skillsOnlyProjector{sp: projector.SkillProjector{AgentDir: ".test"}})
+}
+
 func TestInstallAll(t *testing.T) {
 	tests := map[string]struct {
 		skills    map[string]config.SkillSource
@@ -98,8 +139,8 @@ func TestInstallAll(t *testing.T) {
 			if len(lf.Skills) != tc.wantCount {
 				t.Errorf("lockfile has %d skills, want %d", len(lf.Skills), tc.wantCount)
 			}
-			if lf.Version != 1 {
-				t.Errorf("lockfile version = %d, want 1", lf.Version)
+			if lf.Version != config.LockFileVersion {
+				t.Errorf("lockfile version = %d, want %d", lf.Version, config.LockFileVersion)
 			}
 		})
 	}
@@ -148,7 +189,7 @@ func TestInstallSkill(t *testing.T) {
 
 			src := &source.LocalSource{Path: dir}
 
-			sk, resolved, err := inst.InstallSkill(context.Background(), src)
+			sk, resolved, err := inst.InstallSkill(context.Background(), src, "")
 			if (err != nil) != tc.wantErr {
 				t.Fatalf("InstallSkill() error = %v, wantErr = %v", err, tc.wantErr)
 			}
@@ -193,7 +234,7 @@ func TestBuildLockIndex(t *testing.T) {
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			idx := buildLockIndex(tc.lockfile)
+			idx := buildLockIndex(nil, tc.lockfile)
 			if tc.wantLen == 0 {
 				if idx != nil && len(idx) != 0 {
 					t.Errorf("buildLockIndex() len = %d, want 0", len(idx))
@@ -207,6 +248,34 @@ func TestBuildLockIndex(t *testing.T) {
 	}
 }
 
+func TestNameSkillLockEntries(t *testing.T) {
+	const repo = "https://github.com/a/b.git"
+	cfg := &config.Config{Skills: map[string]config.SkillSource{
+		"pdf":       {Git: repo, Path: "skills/pdf"},
+		"pdf-fork":  {Git: repo, Path: "skills/pdf"},
+		"docx":      {Git: repo, Path: "skills/docx"},
+		"local-one": {Path: "./local"},
+	}}
+	lf := &config.LockFile{Skills: []config.SkillLockEntry{
+		{Name: "pdf-fork", Git: repo, Path: "skills/pdf"},
+		{Git: repo, Path: "skills/pdf"},
+		{Git: repo, Path: "skills/docx"},
+		{Path: "./local"},
+		{Path: "./gone"},
+	}}
+
+	NameSkillLockEntries(cfg, lf)
+
+	var got []string
+	for _, entry := range lf.Skills {
+		got = append(got, entry.Name)
+	}
+	want := []string{"pdf-fork", "pdf", "docx", "local-one", ""}
+	if !slices.Equal(got, want) {
+		t.Errorf("names = %q, want %q", got, want)
+	}
+}
+
 func TestRemoveSkill(t *testing.T) {
 	tests := map[string]struct {
 		setup   func(t *testing.T, projectDir string) string // returns skill name
@@ -224,7 +293,7 @@ func TestRemoveSkill(t *testing.T) {
 					ProjectDir: projectDir,
 					Agents:     []string{},
 				}
-				_, _, err := inst.InstallSkill(context.Background(), &source.LocalSource{Path: skillDir})
+				_, _, err := inst.InstallSkill(context.Background(), &source.LocalSource{Path: skillDir}, "")
 				if err != nil {
 					t.Fatalf("InstallSkill setup: %v", err)
 				}
@@ -249,7 +318,7 @@ func TestRemoveSkill(t *testing.T) {
 				Agents:     []string{},
 			}
 
-			err := inst.RemoveSkill(skillName)
+			err := inst.RemoveSkill(skillName, "")
 			if (err != nil) != tc.wantErr {
 				t.Fatalf("RemoveSkill() error = %v, wantErr = %v", err, tc.wantErr)
 			}
@@ -259,26 +328,69 @@ func TestRemoveSkill(t *testing.T) {
 
 func TestRemoveMCP(t *testing.T) {
 	tests := map[string]struct {
-		name    string
-		wantErr bool
+		name     string
+		agents   []string
+		existing string // .cursor/mcp.json before removing
+		owned    bool
+		wantKept bool
+		wantErr  bool
 	}{
 		"no-op when no agents": {
 			name: "my-server",
 		},
+		"owned entry is deleted": {
+			name:     "my-server",
+			agents:   []string{"cursor"},
+			existing: `{"mcpServers": {"my-server": {"command": "srv"}}}`,
+			owned:    true,
+		},
+		"unowned entry is kept": {
+			name:     "my-server",
+			agents:   []string{"cursor"},
+			existing: `{"mcpServers": {"my-server": {"command": "srv"}}}`,
+			wantKept: true,
+		},
 	}
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
+			projectDir := t.TempDir()
+			statePath := filepath.Join(t.TempDir(), projector.StateFileName)
+			configPath := filepath.Join(projectDir, ".cursor", "mcp.json")
+			if tc.existing != "" {
+				os.MkdirAll(filepath.Dir(configPath), 0o755)
+				if err := os.WriteFile(configPath, []byte(tc.existing), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tc.owned {
+				o, _ := projector.LoadOwnership(statePath)
+				o.Own(configPath, "/mcpServers/"+tc.name)
+				if err := o.Save(statePath); err != nil {
+					t.Fatal(err)
+				}
+			}
 			inst := &Installer{
 				Store:      store.New(t.TempDir()),
-				ProjectDir: t.TempDir(),
-				Agents:     []string{},
+				ProjectDir: projectDir,
+				Agents:     tc.agents,
+				StatePath:  statePath,
 			}
 
 			err := inst.RemoveMCP(tc.name)
 			if (err != nil) != tc.wantErr {
 				t.Fatalf("RemoveMCP() error = %v, wantErr = %v", err, tc.wantErr)
 			}
+			if tc.existing == "" {
+				return
+			}
+			names, err := projector.MCPServerNames(configPath, "/mcpServers")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if kept := slices.Contains(names, tc.name); kept != tc.wantKept {
+				t.Errorf("entry kept = %v, want %v", kept, tc.wantKept)
+			}
 		})
 	}
 }
@@ -290,20 +402,580 @@ func TestLockKey(t *testing.T) {
 	}{
 		"git source": {
 			input: config.SkillSource{Git: "https://github.com/a/b.git", Path: "skills/c"},
-			want:  "https://github.com/a/b.git|skills/c",
+			want:  "c|https://github.com/a/b.git|skills/c",
 		},
 		"local source": {
 			input: config.SkillSource{Path: "./my-skill"},
-			want:  "./my-skill",
+			want:  "c|./my-skill",
 		},
 	}
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			got := lockKey(tc.input)
+			got := lockKey("c", tc.input)
 			if got != tc.want {
 				t.Errorf("lockKey() = %q, want %q", got, tc.want)
 			}
 		})
 	}
 }
+
+func TestInstallAllEnvSet(t *testing.T) {
+	servers := map[string]config.MCPSource{
+		"tool": {
+			Transport:               "stdio",
+			UnmanagedStdioMCPConfig: &config.UnmanagedStdioMCPConfig{Command: "/bin/sh"},
+			LocalMCPConfig:          &config.LocalMCPConfig{Env: map[string]string{"BACKEND": "prod"}},
+			EnvSets: map[string]config.EnvSet{
+				"staging": {Env: map[string]string{"BACKEND": "staging", "DEBUG": "1"}},
+			},
+		},
+	}
+
+	tests := map[string]struct {
+		envSet      string
+		require     bool
+		wantEnvSet  string
+		wantEnvKeys []string
+		wantWarns   int
+		wantErr     bool
+	}{
+		"base config": {
+			wantEnvKeys: []string{"BACKEND"},
+		},
+		"env set applied": {
+			envSet:      "staging",
+			require:     true,
+			wantEnvSet:  "staging",
+			wantEnvKeys: []string{"BACKEND", "DEBUG"},
+		},
+		"undefined required env set": {
+			envSet:  "prod",
+			require: true,
+			wantErr: true,
+		},
+		"undefined env set from the dev config": {
+			envSet:      "prod",
+			wantEnvKeys: []string{"BACKEND"},
+			wantWarns:   1,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var warns int
+			inst := &Installer{
+				Store:         store.New(t.TempDir()),
+				ProjectDir:    t.TempDir(),
+				Agents:        []string{},
+				EnvSet:        tc.envSet,
+				RequireEnvSet: tc.require,
+				Warn:          func(error) { warns++ },
+			}
+
+			cfg := &config.Config{MCPServers: servers}
+			lf, err := inst.InstallAll(context.Background(), cfg, nil)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("InstallAll() error = %v, wantErr = %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+
+			if len(lf.MCPServers) != 1 {
+				t.Fatalf("lockfile has %d MCP servers, want 1", len(lf.MCPServers))
+			}
+			entry := lf.MCPServers[0]
+			if entry.EnvSet != tc.wantEnvSet {
+				t.Errorf("EnvSet = %q, want %q", entry.EnvSet, tc.wantEnvSet)
+			}
+			if !slicesEqual(entry.EnvKeys, tc.wantEnvKeys) {
+				t.Errorf("EnvKeys = %v, want %v", entry.EnvKeys, tc.wantEnvKeys)
+			}
+			if warns != tc.wantWarns {
+				t.Errorf("got %d warnings, want %d", warns, tc.wantWarns)
+			}
+		})
+	}
+}
+
+func TestInstallAllSkillScope(t *testing.T) {
+	tests := map[string]struct {
+		scope       string
+		wantProject bool
+		wantUser    bool
+		wantErr     bool
+	}{
+		"default scope": {wantProject: true},
+		"project scope": {scope: config.SkillScopeProject, wantProject: true},
+		"user scope":    {scope: config.SkillScopeUser, wantUser: true},
+		"unknown scope": {scope: "team", wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+
+			skillDir := t.TempDir()
+			writeSkill(t, skillDir, "my-skill")
+
+			projectDir := t.TempDir()
+			inst := &Installer{
+				Store:      store.New(t.TempDir()),
+				ProjectDir: projectDir,
+				Agents:     []string{"test-skills-only"},
+			}
+			cfg := &config.Config{
+				Skills: map[string]config.SkillSource{
+					"my-skill": {Path: skillDir, Scope: tc.scope},
+				},
+			}
+
+			_, err := inst.InstallAll(context.Background(), cfg, nil)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("InstallAll() error = %v, wantErr = %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+
+			projectLink := filepath.Join(projectDir, ".test", "skills", "my-skill")
+			userLink := filepath.Join(home, ".test", "skills", "my-skill")
+			if _, err := os.Lstat(projectLink); (err == nil) != tc.wantProject {
+				t.Errorf("project projection exists = %v, want %v", err == nil, tc.wantProject)
+			}
+			if _, err := os.Lstat(userLink); (err == nil) != tc.wantUser {
+				t.Errorf("user projection exists = %v, want %v", err == nil, tc.wantUser)
+			}
+
+			if err := inst.RemoveSkill("my-skill", tc.scope); err != nil {
+				t.Fatalf("RemoveSkill() error = %v", err)
+			}
+			for _, link := range []string{projectLink, userLink} {
+				if _, err := os.Lstat(link); err == nil {
+					t.Errorf("%s still exists after RemoveSkill()", link)
+				}
+			}
+		})
+	}
+}
+
+func TestSkillProjectionOpts(t *testing.T) {
+	tests := map[string]struct {
+		scope       string
+		wantGlobal  bool
+		wantHomeDir bool
+	}{
+		"project scope": {scope: config.SkillScopeProject},
+		"user scope":    {scope: config.SkillScopeUser, wantGlobal: true, wantHomeDir: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+
+			session := projector.NewConfigSession()
+			ownership := &projector.Ownership{}
+			inst := &Installer{
+				ProjectDir: t.TempDir(),
+				Projection: "copy",
+				Session:    session,
+				Warn:       func(error) {},
+				ownership:  ownership,
+			}
+
+			opts, err := inst.skillProjectionOpts(tc.scope)
+			if err != nil {
+				t.Fatalf("skillProjectionOpts() error = %v", err)
+			}
+			if (opts.Scope == projector.ScopeGlobal) != tc.wantGlobal {
+				t.Errorf("Scope = %v, want global %v", opts.Scope, tc.wantGlobal)
+			}
+			if (opts.ProjectDir == home) != tc.wantHomeDir {
+				t.Errorf("ProjectDir = %s, want the home directory %v", opts.ProjectDir, tc.wantHomeDir)
+			}
+			if opts.Strategy != "copy" || opts.Session != session || opts.Warn == nil || opts.Ownership != ownership || opts.Conflict == nil {
+				t.Errorf("opts = %+v, want the installer's strategy, session, warnings, ownership, and conflict handling", opts)
+			}
+		})
+	}
+}
+
+func TestInstallAllSnapshot(t *testing.T) {
+	skillDir := filepath.Join(t.TempDir(), "my-skill")
+	writeSkill(t, skillDir, "my-skill")
+
+	projectDir := t.TempDir()
+	st := store.New(t.TempDir())
+	inst := &Installer{
+		Store:      st,
+		ProjectDir: projectDir,
+		Agents:     []string{"test-skills-only"},
+	}
+	cfg := &config.Config{
+		Skills: map[string]config.SkillSource{
+			"my-skill": {Path: skillDir, Snapshot: true},
+		},
+	}
+	link := filepath.Join(projectDir, ".test", "skills", "my-skill")
+
+	lf, err := inst.InstallAll(context.Background(), cfg, nil)
+	if err != nil {
+		t.Fatalf("InstallAll() error = %v", err)
+	}
+	integrity := lf.Skills[0].Integrity
+	if integrity == "" {
+		t.Fatal("locked integrity is empty, want the snapshot's")
+	}
+	snapshot := st.Path(source.SnapshotStoreSegments(integrity)...)
+	if target, _ := os.Readlink(link); target != snapshot {
+		t.Errorf("projection points at %q, want snapshot %q", target, snapshot)
+	}
+
+	// Editing the skill re-snapshots it without an integrity error.
+	writeSkill(t, skillDir, "my-skill")
+	os.WriteFile(filepath.Join(skillDir, "notes.md"), []byte("notes"), 0o644)
+	if lf, err = inst.InstallAll(context.Background(), cfg, lf); err != nil {
+		t.Fatalf("InstallAll() after edit error = %v", err)
+	}
+	if lf.Skills[0].Integrity == integrity {
+		t.Error("locked integrity didn't change with the skill")
+	}
+	integrity = lf.Skills[0].Integrity
+
+	// Without the directory, the locked snapshot is projected.
+	os.RemoveAll(skillDir)
+	if _, err = inst.InstallAll(context.Background(), cfg, lf); err != nil {
+		t.Fatalf("InstallAll() without the directory error = %v", err)
+	}
+	if target, _ := os.Readlink(link); target != st.Path(source.SnapshotStoreSegments(integrity)...) {
+		t.Errorf("projection points at %q, want the locked snapshot", target)
+	}
+}
+
+func TestInstallAllArchive(t *testing.T) {
+	srv := apkgtest.NewFileServer(t, map[string][]byte{
+		"/my-skill.tar.gz": apkgtest.TarGz(t, map[string]string{
+			"my-skill-1.0/SKILL.md": "---\nname: my-skill\ndescription: test skill\n---\n# my-skill\n",
+		}),
+	})
+
+	projectDir := t.TempDir()
+	st := store.New(t.TempDir())
+	inst := &Installer{
+		Store:      st,
+		ProjectDir: projectDir,
+		Agents:     []string{"test-skills-only"},
+	}
+	cfg := &config.Config{
+		Skills: map[string]config.SkillSource{
+			"my-skill": {URL: srv.URL + "/my-skill.tar.gz"},
+		},
+	}
+	link := filepath.Join(projectDir, ".test", "skills", "my-skill")
+
+	lf, err := inst.InstallAll(context.Background(), cfg, nil)
+	if err != nil {
+		t.Fatalf("InstallAll() error = %v", err)
+	}
+	entry := lf.Skills[0]
+	if entry.URL != cfg.Skills["my-skill"].URL || entry.SHA256 == "" {
+		t.Fatalf("lock entry = %+v, want the URL and its sha256", entry)
+	}
+	archive := st.Path(source.ArchiveStoreSegments(entry.SHA256)...)
+	if target, _ := os.Readlink(link); target != archive {
+		t.Errorf("projection points at %q, want archive %q", target, archive)
+	}
+
+	// The locked sha256 finds the archive in the store.
+	if _, err = inst.InstallAll(context.Background(), cfg, lf); err != nil {
+		t.Fatalf("InstallAll() with lock error = %v", err)
+	}
+	if got := srv.Requests("/my-skill.tar.gz"); got != 1 {
+		t.Errorf("archive downloaded %d times, want 1", got)
+	}
+}
+
+// slowSource is a Source whose Fetch takes delay, or until ctx is done.
+type slowSource struct {
+	delay time.Duration
+}
+
+func (s slowSource) Fetch(ctx context.Context, st store.Store) (*source.ResolvedSource, error) {
+	select {
+	case <-time.After(s.delay):
+		return &source.ResolvedSource{Dir: st.Path("slow")}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestFetchTimeout(t *testing.T) {
+	tests := map[string]struct {
+		fetchTimeout time.Duration
+		timeout      string
+		delay        time.Duration
+		wantErr      bool
+	}{
+		"within installer timeout": {
+			fetchTimeout: time.Second,
+			delay:        time.Millisecond,
+		},
+		"installer timeout exceeded": {
+			fetchTimeout: 10 * time.Millisecond,
+			delay:        time.Minute,
+			wantErr:      true,
+		},
+		"package timeout overrides installer timeout": {
+			fetchTimeout: time.Minute,
+			timeout:      "10ms",
+			delay:        time.Minute,
+			wantErr:      true,
+		},
+		"package timeout extends installer timeout": {
+			fetchTimeout: time.Millisecond,
+			timeout:      "1s",
+			delay:        20 * time.Millisecond,
+		},
+		"invalid package timeout": {
+			timeout: "soon",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			inst := &Installer{Store: store.New(t.TempDir()), FetchTimeout: tc.fetchTimeout}
+			_, err := inst.fetch(context.Background(), slowSource{delay: tc.delay}, tc.timeout)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("fetch() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckSums(t *testing.T) {
+	src := &source.GitSource{URL: "https://github.com/org/skills.git", Path: "greet", Ref: "v1.0.0"}
+	first := &source.ResolvedSource{Commit: strings.Repeat("a", 40), Integrity: "sha256:aaa"}
+
+	tests := map[string]struct {
+		previous  *source.ResolvedSource // fetched from src before; first if nil
+		src       *source.GitSource
+		resolved  *source.ResolvedSource
+		wantWarns int
+	}{
+		"same content": {
+			src:      src,
+			resolved: first,
+		},
+		"tag moved to different content": {
+			src:       src,
+			resolved:  &source.ResolvedSource{Commit: strings.Repeat("b", 40), Integrity: "sha256:bbb"},
+			wantWarns: 1,
+		},
+		"commit with different content": {
+			src:       &source.GitSource{URL: src.URL, Path: src.Path, Ref: "main"},
+			resolved:  &source.ResolvedSource{Commit: first.Commit, Integrity: "sha256:bbb"},
+			wantWarns: 1,
+		},
+		"managed package with different content": {
+			previous:  &source.ResolvedSource{Package: "npm:server-fs", Version: "1.2.3", Integrity: "sha256:aaa"},
+			src:       &source.GitSource{},
+			resolved:  &source.ResolvedSource{Package: "npm:server-fs", Version: "1.2.3", Integrity: "sha256:bbb"},
+			wantWarns: 1,
+		},
+		"branch moved": {
+			src:      &source.GitSource{URL: src.URL, Path: src.Path, Ref: "main"},
+			resolved: &source.ResolvedSource{Commit: strings.Repeat("b", 40), Integrity: "sha256:bbb"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var warns []error
+			inst := &Installer{
+				Store: storetest.NewMemory(),
+				Warn:  func(err error) { warns = append(warns, err) },
+			}
+			previous := first
+			if tc.previous != nil {
+				previous = tc.previous
+			}
+			inst.checkSums(src, previous)
+			inst.checkSums(tc.src, tc.resolved)
+
+			if len(warns) != tc.wantWarns {
+				t.Errorf("got %d warnings %v, want %d", len(warns), warns, tc.wantWarns)
+			}
+		})
+	}
+}
+
+func TestMCPProjectionOpts(t *testing.T) {
+	tests := map[string]struct {
+		global    bool
+		mcpScopes map[string]string
+		agent     string
+		wantScope projector.Scope
+	}{
+		"project install": {
+			agent:     "cursor",
+			wantScope: projector.ScopeLocal,
+		},
+		"agent scoped globally": {
+			mcpScopes: map[string]string{"claude-code": config.ServerScopeGlobal},
+			agent:     "claude-code",
+			wantScope: projector.ScopeGlobal,
+		},
+		"other agent keeps project scope": {
+			mcpScopes: map[string]string{"claude-code": config.ServerScopeGlobal},
+			agent:     "cursor",
+			wantScope: projector.ScopeLocal,
+		},
+		"global install ignores project scope": {
+			global:    true,
+			mcpScopes: map[string]string{"cursor": config.ServerScopeProject},
+			agent:     "cursor",
+			wantScope: projector.ScopeGlobal,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			inst := &Installer{ProjectDir: t.TempDir(), Global: tc.global, MCPScopes: tc.mcpScopes}
+			if got := inst.mcpProjectionOpts(tc.agent).Scope; got != tc.wantScope {
+				t.Errorf("mcpProjectionOpts(%q).Scope = %v, want %v", tc.agent, got, tc.wantScope)
+			}
+		})
+	}
+}
+
+func TestSharedMCPEntriesKeyedByProject(t *testing.T) {
+	tests := map[string]struct {
+		agent     string
+		mcpScopes map[string]string
+		config    []string // the shared config, relative to the home directory
+		pointer   string
+	}{
+		"global mcp scope": {
+			agent:     "cursor",
+			mcpScopes: map[string]string{"cursor": config.ServerScopeGlobal},
+			config:    []string{".cursor", "mcp.json"},
+			pointer:   "/mcpServers",
+		},
+		"agent with a single config": {
+			agent:   "goose",
+			config:  []string{".config", "goose", "config.yaml"},
+			pointer: "/extensions",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+			t.Setenv("APKG_HOME", home)
+			st := store.New(t.TempDir())
+			statePath := filepath.Join(home, ".apkg", projector.StateFileName)
+
+			newInstaller := func(projectDir string) *Installer {
+				return &Installer{
+					Store:      st,
+					ProjectDir: projectDir,
+					Agents:     []string{tc.agent},
+					MCPScopes:  tc.mcpScopes,
+					StatePath:  statePath,
+				}
+			}
+			billing, shop := newInstaller(t.TempDir()), newInstaller(t.TempDir())
+			for _, inst := range []*Installer{billing, shop} {
+				if _, err := inst.InstallAll(context.Background(), recordConfig(t), nil); err != nil {
+					t.Fatalf("InstallAll() error = %v", err)
+				}
+			}
+
+			sharedConfig := filepath.Join(append([]string{home}, tc.config...)...)
+			names := func() []string {
+				t.Helper()
+				names, err := projector.MCPServerNames(sharedConfig, tc.pointer)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return names
+			}
+			billingEntry, shopEntry := billing.mcpEntryName(tc.agent, "tool"), shop.mcpEntryName(tc.agent, "tool")
+			if billingEntry == shopEntry || !strings.HasPrefix(billingEntry, "tool-") {
+				t.Fatalf("entry names = %q and %q, want one per project", billingEntry, shopEntry)
+			}
+			if got := names(); !slices.Contains(got, billingEntry) || !slices.Contains(got, shopEntry) {
+				t.Fatalf("shared entries = %v, want %s and %s", got, billingEntry, shopEntry)
+			}
+
+			if err := billing.RemoveMCP("tool"); err != nil {
+				t.Fatalf("RemoveMCP() error = %v", err)
+			}
+			if got := names(); slices.Contains(got, billingEntry) || !slices.Contains(got, shopEntry) {
+				t.Errorf("shared entries after removing from one project = %v, want only %s", got, shopEntry)
+			}
+		})
+	}
+}
+
+func TestServeRouted(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "mcp.toml"), []byte(`
+name = "db"
+image = "db-image"
+digest = "sha256:abc"
+path = "/mcp"
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	routed, err := mcp.Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		ep           serve.Endpoint
+		socketAgents []string
+		wantURL      string
+		wantWarn     bool
+	}{
+		"tcp only": {
+			ep:      serve.Endpoint{Port: 19600},
+			wantURL: "http://localhost:19600/mcp",
+		},
+		"socket agent": {
+			ep:           serve.Endpoint{Port: 19600, Socket: "/run/apkg.sock"},
+			socketAgents: []string{"cursor"},
+			wantURL:      "http+unix://%2Frun%2Fapkg.sock/mcp",
+		},
+		"tcp fallback": {
+			ep:       serve.Endpoint{Port: 19600, Socket: "/run/apkg.sock"},
+			wantURL:  "http://localhost:19600/mcp",
+			wantWarn: true,
+		},
+		"socket only": {
+			ep:       serve.Endpoint{Socket: "/run/apkg.sock"},
+			wantURL:  "http://localhost:19513/mcp",
+			wantWarn: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var warns []error
+			inst := &Installer{SocketAgents: tc.socketAgents, Warn: func(err error) { warns = append(warns, err) }}
+			got := inst.serveRouted("cursor", tc.ep, []mcp.MCPServer{routed})
+			if got[0].URL() != tc.wantURL {
+				t.Errorf("URL() = %q, want %q", got[0].URL(), tc.wantURL)
+			}
+			if (len(warns) > 0) != tc.wantWarn {
+				t.Errorf("warnings = %v, want warning %v", warns, tc.wantWarn)
+			}
+		})
+	}
+}
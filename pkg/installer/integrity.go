@@ -0,0 +1,73 @@
+package installer
+
+import (
+	"fmt"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+)
+
+// IntegrityError reports a package whose content doesn't match the
+// integrity recorded for it in the lockfile, although it resolved to the
+// locked commit or version: the cached copy was modified, or upstream
+// replaced the release.
+type IntegrityError struct {
+	Kind string // KindSkill or KindMCP
+	Name string
+	// Version is the locked commit or version.
+	Version string
+	Locked  string
+	Got     string
+}
+
+func (e *IntegrityError) Error() string {
+	return fmt.Sprintf("%s %q at %s doesn't match the lockfile: integrity is %s, locked %s", e.Kind, e.Name, e.Version, e.Got, e.Locked)
+}
+
+// skillIntegrity checks the lock entry of a skill against the entry prev
+// locked for it before, if any: git skills at the same commit and archive
+// skills with the same digest must keep their content. Local skills change
+// as they're edited.
+func skillIntegrity(name string, prev config.SkillLockEntry, entry config.SkillLockEntry) error {
+	if prev.Integrity == "" || entry.Integrity == "" || prev.Integrity == entry.Integrity {
+		return nil
+	}
+	var version string
+	switch {
+	case entry.URL != "":
+		if prev.URL != entry.URL || prev.SHA256 != entry.SHA256 {
+			return nil
+		}
+		version = "sha256:" + entry.SHA256
+	case entry.Commit != "":
+		if prev.Commit != entry.Commit {
+			return nil
+		}
+		version = entry.Commit
+	default:
+		return nil
+	}
+	return &IntegrityError{Kind: KindSkill, Name: name, Version: version, Locked: prev.Integrity, Got: entry.Integrity}
+}
+
+// mcpIntegrity checks the lock entry of a managed MCP server against the
+// entry prev locked for it before, if any. Other servers' integrity
+// covers their config rather than published content, so it may change.
+func mcpIntegrity(name string, prev config.MCPLockEntry, entry config.MCPLockEntry) error {
+	if entry.Package == "" || prev.Package != entry.Package || prev.ResolvedVersion != entry.ResolvedVersion {
+		return nil
+	}
+	if prev.Integrity == "" || entry.Integrity == "" || prev.Integrity == entry.Integrity {
+		return nil
+	}
+	return &IntegrityError{Kind: KindMCP, Name: name, Version: entry.ResolvedVersion, Locked: prev.Integrity, Got: entry.Integrity}
+}
+
+// verifyIntegrity returns err, an integrity check's result, unless
+// inst.Force accepts the mismatch, which is then only reported to Warn.
+func (inst *Installer) verifyIntegrity(err error) error {
+	if err == nil || !inst.Force {
+		return err
+	}
+	inst.warn(fmt.Errorf("%w; accepting it anyway", err))
+	return nil
+}
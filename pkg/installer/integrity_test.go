@@ -0,0 +1,94 @@
+package installer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+)
+
+func TestSkillIntegrity(t *testing.T) {
+	locked := config.SkillLockEntry{Name: "pdf", Commit: "c1", Integrity: "sha256:aaa"}
+
+	tests := map[string]struct {
+		prev    config.SkillLockEntry
+		entry   config.SkillLockEntry
+		wantErr bool
+	}{
+		"matches":         {prev: locked, entry: locked},
+		"not locked":      {entry: config.SkillLockEntry{Commit: "c1", Integrity: "sha256:bbb"}},
+		"new commit":      {prev: locked, entry: config.SkillLockEntry{Commit: "c2", Integrity: "sha256:bbb"}},
+		"content differs": {prev: locked, entry: config.SkillLockEntry{Commit: "c1", Integrity: "sha256:bbb"}, wantErr: true},
+		"local skill edited": {
+			prev:  config.SkillLockEntry{Name: "pdf", Path: "skills/pdf", Integrity: "sha256:aaa"},
+			entry: config.SkillLockEntry{Name: "pdf", Path: "skills/pdf", Integrity: "sha256:bbb"},
+		},
+		"archive content differs": {
+			prev:    config.SkillLockEntry{Name: "pdf", URL: "https://example.com/pdf.tgz", SHA256: "d1", Integrity: "sha256:aaa"},
+			entry:   config.SkillLockEntry{Name: "pdf", URL: "https://example.com/pdf.tgz", SHA256: "d1", Integrity: "sha256:bbb"},
+			wantErr: true,
+		},
+		"new archive": {
+			prev:  config.SkillLockEntry{Name: "pdf", URL: "https://example.com/pdf.tgz", SHA256: "d1", Integrity: "sha256:aaa"},
+			entry: config.SkillLockEntry{Name: "pdf", URL: "https://example.com/pdf.tgz", SHA256: "d2", Integrity: "sha256:bbb"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := skillIntegrity("pdf", tc.prev, tc.entry)
+			var integrityErr *IntegrityError
+			if errors.As(err, &integrityErr) != tc.wantErr {
+				t.Fatalf("skillIntegrity() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestMCPIntegrity(t *testing.T) {
+	locked := config.MCPLockEntry{Name: "fs", Package: "npm:server-fs", ResolvedVersion: "1.0.0", Integrity: "sha256:aaa"}
+	changed := locked
+	changed.Integrity = "sha256:bbb"
+	upgraded := changed
+	upgraded.ResolvedVersion = "1.1.0"
+	container := config.MCPLockEntry{Name: "fs", Image: "fs:1", Integrity: "sha256:aaa"}
+	reconfigured := container
+	reconfigured.Integrity = "sha256:bbb"
+
+	tests := map[string]struct {
+		prev    config.MCPLockEntry
+		entry   config.MCPLockEntry
+		wantErr bool
+	}{
+		"matches":                {prev: locked, entry: locked},
+		"new version":            {prev: locked, entry: upgraded},
+		"container reconfigured": {prev: container, entry: reconfigured},
+		"release differs":        {prev: locked, entry: changed, wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := mcpIntegrity("fs", tc.prev, tc.entry)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("mcpIntegrity() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyIntegrityForce(t *testing.T) {
+	mismatch := &IntegrityError{Kind: KindSkill, Name: "pdf", Version: "c1", Locked: "sha256:aaa", Got: "sha256:bbb"}
+
+	if err := (&Installer{}).verifyIntegrity(mismatch); err != mismatch {
+		t.Errorf("verifyIntegrity() = %v, want the mismatch", err)
+	}
+
+	var warnings []error
+	inst := &Installer{Force: true, Warn: func(err error) { warnings = append(warnings, err) }}
+	if err := inst.verifyIntegrity(mismatch); err != nil {
+		t.Errorf("verifyIntegrity() with Force = %v, want nil", err)
+	}
+	if len(warnings) != 1 || !errors.Is(warnings[0], mismatch) {
+		t.Errorf("warnings = %v, want the mismatch", warnings)
+	}
+}
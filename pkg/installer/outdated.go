@@ -0,0 +1,381 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/source"
+)
+
+const (
+	KindSkill = "skill"
+	KindMCP   = "mcp"
+)
+
+// Update describes a newer upstream version of an installed package.
+type Update struct {
+	Kind string `json:"kind"` // KindSkill or KindMCP
+	Name string `json:"name"`
+
+	// Current and Latest are the installed and newest versions: commits for
+	// skills tracking a branch, tags for skills pinned to a version tag, and
+	// package versions for managed MCP servers.
+	Current string `json:"current"`
+	Latest  string `json:"latest"`
+
+	// Ref is the manifest ref (skills) or package spec (MCP servers) to
+	// write when applying the update, or empty if the manifest entry
+	// already tracks the latest version (branches, unpinned packages).
+	Ref string `json:"ref,omitempty"`
+}
+
+// Outdated checks each git skill and managed MCP server in cfg against its
+// upstream and returns the packages with newer versions, sorted by kind and
+// name. Skills pinned to a commit, local skills, and unmanaged servers are
+// skipped. Packages are checked on up to Concurrency goroutines at once,
+// their lookups going through Metadata. Packages whose upstream can't be
+// reached are reported in the returned error alongside the updates found
+// for the others. Packages upstream no longer maintains are reported to
+// Warn.
+func (inst *Installer) Outdated(ctx context.Context, cfg *config.Config, lf *config.LockFile) ([]Update, error) {
+	lockIndex := buildLockIndex(cfg, lf)
+	mcpIndex := make(map[string]config.MCPLockEntry)
+	if lf != nil {
+		for _, entry := range lf.MCPServers {
+			mcpIndex[entry.Name] = entry
+		}
+	}
+
+	skillNames := sortedNames(cfg.Skills)
+	serverNames := sortedNames(cfg.MCPServers)
+	found := make([]*Update, len(skillNames)+len(serverNames))
+	failed := make([]error, len(found))
+	// Failed checks are collected rather than returned, so they don't
+	// cancel the others.
+	_ = inst.parallel(ctx, len(found), func(ctx context.Context, i int) error {
+		if i < len(skillNames) {
+			name := skillNames[i]
+			ss := cfg.Skills[name]
+			failed[i] = inst.withTimeout(ctx, ss.Timeout, func(ctx context.Context) error {
+				var err error
+				found[i], err = skillUpdate(ctx, inst.Metadata, name, ss, lockIndex[lockKey(name, ss)])
+				return err
+			})
+			return nil
+		}
+		name := serverNames[i-len(skillNames)]
+		ms := cfg.MCPServers[name]
+		failed[i] = inst.withTimeout(ctx, ms.Timeout, func(ctx context.Context) error {
+			var err error
+			found[i], err = mcpUpdate(ctx, inst.Metadata, name, ms, mcpIndex[name])
+			return err
+		})
+		return nil
+	})
+
+	var updates []Update
+	var errs []string
+	for i, err := range failed {
+		switch {
+		case err != nil && i < len(skillNames):
+			errs = append(errs, fmt.Sprintf("skill %q: %v", skillNames[i], err))
+		case err != nil:
+			errs = append(errs, fmt.Sprintf("MCP server %q: %v", serverNames[i-len(skillNames)], err))
+		case found[i] != nil:
+			updates = append(updates, *found[i])
+		}
+	}
+
+	if inst.Warn != nil {
+		inst.warnUnmaintained(ctx, cfg, mcpIndex)
+	}
+
+	if len(errs) > 0 {
+		return updates, fmt.Errorf("checking for updates:\n  %s", strings.Join(errs, "\n  "))
+	}
+	return updates, nil
+}
+
+// skillUpdate checks a git skill. Skills whose ref is a version tag are
+// offered the highest newer release tag; skills tracking a branch are
+// offered the branch's current commit if it moved since the lock. Pinned
+// skills are checked against the ref they track.
+func skillUpdate(ctx context.Context, meta *source.Metadata, name string, ss config.SkillSource, locked config.SkillLockEntry) (*Update, error) {
+	ss = unpinned(ss)
+	if ss.Git == "" || ss.Ref == "" || source.IsCommitRef(ss.Ref) {
+		return nil, nil
+	}
+
+	if _, ok := config.CompareVersions(ss.Ref, ss.Ref); ok {
+		tags, err := meta.RemoteTags(ctx, ss.Git)
+		if err != nil {
+			return nil, err
+		}
+		latest := latestTag(ss.Ref, tags)
+		if latest == "" {
+			return nil, nil
+		}
+		return &Update{Kind: KindSkill, Name: name, Current: ss.Ref, Latest: latest, Ref: latest}, nil
+	}
+
+	commit, err := meta.ResolveGitRef(ctx, ss.Git, ss.Ref)
+	if err != nil {
+		return nil, err
+	}
+	if locked.Commit == "" || locked.Commit == commit {
+		return nil, nil
+	}
+	return &Update{Kind: KindSkill, Name: name, Current: locked.Commit, Latest: commit}, nil
+}
+
+// mcpUpdate checks a managed MCP server. The installed version is the last
+// segment of its store path (e.g. npm/<pkg>/<version>).
+func mcpUpdate(ctx context.Context, meta *source.Metadata, name string, ms config.MCPSource, locked config.MCPLockEntry) (*Update, error) {
+	if ms.ManagedStdioMCPConfig == nil || ms.Package == "" {
+		return nil, nil
+	}
+
+	_, _, pinned := source.SplitPackage(ms.Package)
+	current := installedVersion(ms, locked)
+	if current == "" {
+		return nil, nil
+	}
+
+	latest, err := meta.LatestPackageVersion(ctx, ms.Package)
+	if err != nil {
+		return nil, err
+	}
+	if cmp, ok := config.CompareVersions(latest, current); !ok && latest == current || ok && cmp <= 0 {
+		return nil, nil
+	}
+
+	update := &Update{Kind: KindMCP, Name: name, Current: current, Latest: latest}
+	if pinned != "" {
+		update.Ref = source.PinPackage(ms.Package, latest)
+	}
+	return update, nil
+}
+
+// installedVersion returns the version of a managed MCP server's package:
+// its pin, or the version locked for it (the last segment of its store
+// path, e.g. npm/<pkg>/<version>, in older lockfiles).
+func installedVersion(ms config.MCPSource, locked config.MCPLockEntry) string {
+	if _, _, pinned := source.SplitPackage(ms.Package); pinned != "" {
+		return pinned
+	}
+	if locked.ResolvedVersion != "" {
+		return locked.ResolvedVersion
+	}
+	if locked.InstallPath != "" {
+		return filepath.Base(locked.InstallPath)
+	}
+	return ""
+}
+
+// warnUnmaintained warns about installed packages that upstream no longer
+// maintains: skills from archived GitHub repositories, and managed MCP
+// servers whose installed version is deprecated, yanked, or retracted.
+// The checks run in parallel, and their warnings are reported in order.
+// Failed lookups are skipped, since the checks are only advisory.
+func (inst *Installer) warnUnmaintained(ctx context.Context, cfg *config.Config, mcpIndex map[string]config.MCPLockEntry) {
+	// Each check returns its warning, or nil.
+	var checks []func(ctx context.Context) error
+
+	checked := make(map[string]bool)
+	for _, name := range sortedNames(cfg.Skills) {
+		ss := cfg.Skills[name]
+		if ss.Git == "" || checked[ss.Git] {
+			continue
+		}
+		checked[ss.Git] = true
+
+		checks = append(checks, func(ctx context.Context) error {
+			var archived bool
+			_ = inst.withTimeout(ctx, ss.Timeout, func(ctx context.Context) error {
+				var err error
+				archived, err = inst.Metadata.RepoArchived(ctx, ss.Git)
+				return err
+			})
+			if archived {
+				return fmt.Errorf("skill %q: repository %s is archived and no longer maintained", name, ss.Git)
+			}
+			return nil
+		})
+	}
+
+	for _, name := range sortedNames(cfg.MCPServers) {
+		ms := cfg.MCPServers[name]
+		if ms.ManagedStdioMCPConfig == nil || ms.Package == "" {
+			continue
+		}
+		version := installedVersion(ms, mcpIndex[name])
+		if version == "" {
+			continue
+		}
+
+		checks = append(checks, func(ctx context.Context) error {
+			var notice string
+			_ = inst.withTimeout(ctx, ms.Timeout, func(ctx context.Context) error {
+				var err error
+				notice, err = inst.Metadata.PackageDeprecation(ctx, ms.Package, version)
+				return err
+			})
+			if notice != "" {
+				kind, pkg, _ := source.SplitPackage(ms.Package)
+				return fmt.Errorf("MCP server %q: %s:%s %s is %s", name, kind, pkg, version, notice)
+			}
+			return nil
+		})
+	}
+
+	warnings := make([]error, len(checks))
+	_ = inst.parallel(ctx, len(checks), func(ctx context.Context, i int) error {
+		warnings[i] = checks[i](ctx)
+		return nil
+	})
+	for _, w := range warnings {
+		if w != nil {
+			inst.warn(w)
+		}
+	}
+}
+
+// latestTag returns the highest release tag newer than current, or "" if
+// there is none. Pre-release tags are ignored.
+func latestTag(current string, tags []string) string {
+	latest := current
+	for _, tag := range tags {
+		if strings.ContainsAny(strings.TrimPrefix(tag, "v"), "-+") {
+			continue
+		}
+		if cmp, ok := config.CompareVersions(tag, latest); ok && cmp > 0 {
+			latest = tag
+		}
+	}
+	if latest == current {
+		return ""
+	}
+	return latest
+}
+
+// ApplyUpdates installs the given updates only, leaving every other package
+// at its locked version. Manifest refs and package pins in cfg are rewritten
+// where the update requires it, and the returned lockfile is lf with the
+// updated entries replaced.
+func (inst *Installer) ApplyUpdates(ctx context.Context, cfg *config.Config, lf *config.LockFile, updates []Update) (updated *config.LockFile, err error) {
+	err = inst.batch(func() error {
+		updated, err = inst.applyUpdates(ctx, cfg, lf, updates)
+		return err
+	})
+	return updated, err
+}
+
+func (inst *Installer) applyUpdates(ctx context.Context, cfg *config.Config, lf *config.LockFile, updates []Update) (*config.LockFile, error) {
+	if lf == nil {
+		lf = &config.LockFile{Version: config.LockFileVersion}
+	}
+
+	locked, err := inst.InstallRuntimes(ctx, cfg.Runtimes, lf.Runtimes)
+	if err != nil {
+		return nil, err
+	}
+	lf.Runtimes = locked
+
+	for _, u := range updates {
+		switch u.Kind {
+		case KindSkill:
+			ss, ok := cfg.Skills[u.Name]
+			if !ok {
+				return nil, fmt.Errorf("skill %q not found in manifest", u.Name)
+			}
+			// Pinned skills are updated from the ref they track and pinned
+			// to the new commit again.
+			pinned := ss.Track != ""
+			ss = unpinned(ss)
+			if u.Ref != "" {
+				ss.Ref = u.Ref
+			}
+
+			_, resolved, err := inst.installSkill(ctx, source.SourceFromSkillConfig(ss), ss.Scope, ss.Timeout)
+			if err != nil {
+				return nil, fmt.Errorf("updating skill %q: %w", u.Name, err)
+			}
+			if pinned && resolved.Commit != "" {
+				ss.Track, ss.Ref = ss.Ref, resolved.Commit
+				resolved.Ref = resolved.Commit
+			}
+			cfg.Skills[u.Name] = ss
+			lf.Skills = UpsertSkillLockEntry(lf.Skills, lockEntryFromResolved(u.Name, ss, resolved))
+
+		case KindMCP:
+			ms, ok := cfg.MCPServers[u.Name]
+			if !ok {
+				return nil, fmt.Errorf("MCP server %q not found in manifest", u.Name)
+			}
+			if u.Ref != "" {
+				ms.Package = u.Ref
+			}
+
+			resolvedCfg, applied, err := ms.WithEnvSet(inst.EnvSet)
+			if err != nil {
+				return nil, fmt.Errorf("updating MCP server %q: %w", u.Name, err)
+			}
+			src, err := source.SourceFromMCPConfig(u.Name, resolvedCfg)
+			if err != nil {
+				return nil, fmt.Errorf("updating MCP server %q: %w", u.Name, err)
+			}
+			_, resolved, err := inst.installMCP(ctx, u.Name, src, ms.Timeout)
+			if err != nil {
+				return nil, fmt.Errorf("updating MCP server %q: %w", u.Name, err)
+			}
+			cfg.MCPServers[u.Name] = ms
+
+			entry := NewMCPLockEntry(u.Name, resolvedCfg, resolved)
+			if applied {
+				entry.EnvSet = inst.EnvSet
+			}
+			lf.MCPServers = upsertMCPLockEntry(lf.MCPServers, entry)
+
+		default:
+			return nil, fmt.Errorf("unknown package kind %q", u.Kind)
+		}
+	}
+
+	return lf, nil
+}
+
+// UpsertSkillLockEntry replaces the entry for the same skill in entries,
+// or an unnamed entry from an older lockfile with the same source, or
+// appends entry if there is none.
+func UpsertSkillLockEntry(entries []config.SkillLockEntry, entry config.SkillLockEntry) []config.SkillLockEntry {
+	for i, e := range entries {
+		if lockKeyFromEntry(e) == lockKeyFromEntry(entry) || e.Name == "" && sourceKey(e.Remote(), e.Path) == sourceKey(entry.Remote(), entry.Path) {
+			entries[i] = entry
+			return entries
+		}
+	}
+	return append(entries, entry)
+}
+
+func upsertMCPLockEntry(entries []config.MCPLockEntry, entry config.MCPLockEntry) []config.MCPLockEntry {
+	for i, e := range entries {
+		if e.Name == entry.Name {
+			entries[i] = entry
+			return entries
+		}
+	}
+	return append(entries, entry)
+}
+
+func sortedNames[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
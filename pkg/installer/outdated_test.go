@@ -0,0 +1,181 @@
+package installer
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/source"
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+func TestLatestTag(t *testing.T) {
+	tests := map[string]struct {
+		current string
+		tags    []string
+		want    string
+	}{
+		"newer release":       {current: "v1.0.0", tags: []string{"v1.0.0", "v1.2.0", "v1.10.0"}, want: "v1.10.0"},
+		"already latest":      {current: "v2.0.0", tags: []string{"v1.0.0", "v2.0.0"}},
+		"prereleases ignored": {current: "v1.0.0", tags: []string{"v1.1.0-rc.1", "v2.0.0-beta"}},
+		"non-version tags":    {current: "v1.0.0", tags: []string{"latest", "nightly", "v1.0.1"}, want: "v1.0.1"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := latestTag(tc.current, tc.tags); got != tc.want {
+				t.Errorf("latestTag(%q, %v) = %q, want %q", tc.current, tc.tags, got, tc.want)
+			}
+		})
+	}
+}
+
+// gitRepo is a work tree with a helper for running git in it.
+type gitRepo struct {
+	t   *testing.T
+	dir string
+}
+
+func newGitRepo(t *testing.T) *gitRepo {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found in PATH")
+	}
+	r := &gitRepo{t: t, dir: t.TempDir()}
+	r.run("init", "--initial-branch=main")
+	r.run("config", "user.email", "test@test.com")
+	r.run("config", "user.name", "Test")
+	return r
+}
+
+func (r *gitRepo) run(args ...string) string {
+	r.t.Helper()
+	out, err := exec.Command("git", append([]string{"-C", r.dir}, args...)...).CombinedOutput()
+	if err != nil {
+		r.t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// commitSkills writes a skill at each path with the given description and
+// commits them, returning the commit hash.
+func (r *gitRepo) commitSkills(description string, paths ...string) string {
+	r.t.Helper()
+	for _, path := range paths {
+		writeSkill(r.t, filepath.Join(r.dir, path), filepath.Base(path))
+		if err := os.WriteFile(filepath.Join(r.dir, path, "NOTES.md"), []byte(description), 0o644); err != nil {
+			r.t.Fatalf("writing NOTES.md: %v", err)
+		}
+	}
+	r.run("add", ".")
+	r.run("commit", "-m", description)
+	return r.run("rev-parse", "HEAD")
+}
+
+func TestOutdatedAndApplyUpdates(t *testing.T) {
+	repo := newGitRepo(t)
+	first := repo.commitSkills("first", "pdf", "docx", "xlsx")
+	repo.run("tag", "v1.0.0")
+	second := repo.commitSkills("second", "pdf", "docx", "xlsx")
+	repo.run("tag", "v1.1.0")
+	repo.run("tag", "v2.0.0-rc.1")
+
+	cfg := &config.Config{
+		Skills: map[string]config.SkillSource{
+			"tracking": {Git: repo.dir, Path: "pdf", Ref: "main"},
+			"tagged":   {Git: repo.dir, Path: "docx", Ref: "v1.0.0"},
+			"pinned":   {Git: repo.dir, Path: "xlsx", Ref: first},
+		},
+	}
+	// The lock predates the second commit.
+	lf := &config.LockFile{
+		Version: 1,
+		Skills: []config.SkillLockEntry{
+			{Git: repo.dir, Path: "pdf", Ref: "main", Commit: first},
+		},
+	}
+
+	inst := &Installer{Store: store.New(t.TempDir()), ProjectDir: t.TempDir()}
+	ctx := context.Background()
+
+	updates, err := inst.Outdated(ctx, cfg, lf)
+	if err != nil {
+		t.Fatalf("Outdated() error = %v", err)
+	}
+
+	want := []Update{
+		{Kind: KindSkill, Name: "tagged", Current: "v1.0.0", Latest: "v1.1.0", Ref: "v1.1.0"},
+		{Kind: KindSkill, Name: "tracking", Current: first, Latest: second},
+	}
+	if len(updates) != len(want) {
+		t.Fatalf("Outdated() = %+v, want %+v", updates, want)
+	}
+	for i := range want {
+		if updates[i] != want[i] {
+			t.Errorf("Outdated()[%d] = %+v, want %+v", i, updates[i], want[i])
+		}
+	}
+
+	// Apply only the tag bump.
+	lf, err = inst.ApplyUpdates(ctx, cfg, lf, updates[:1])
+	if err != nil {
+		t.Fatalf("ApplyUpdates() error = %v", err)
+	}
+
+	if got := cfg.Skills["tagged"].Ref; got != "v1.1.0" {
+		t.Errorf("manifest ref = %q, want v1.1.0", got)
+	}
+	index := buildLockIndex(cfg, lf)
+	if got := index[lockKey("tagged", cfg.Skills["tagged"])].Commit; got != second {
+		t.Errorf("tagged lock commit = %q, want %q", got, second)
+	}
+	if got := index[lockKey("tracking", cfg.Skills["tracking"])].Commit; got != first {
+		t.Errorf("tracking lock commit = %q, want it left at %q", got, first)
+	}
+}
+
+func TestOutdatedMetadata(t *testing.T) {
+	repo := newGitRepo(t)
+	repo.commitSkills("first", "pdf")
+	repo.run("tag", "v1.0.0")
+	repo.commitSkills("second", "pdf")
+	repo.run("tag", "v1.1.0")
+
+	cfg := &config.Config{
+		Skills: map[string]config.SkillSource{
+			"tagged": {Git: repo.dir, Path: "pdf", Ref: "v1.0.0"},
+		},
+	}
+	st := store.New(t.TempDir())
+	ctx := context.Background()
+	latest := func(meta *source.Metadata) string {
+		t.Helper()
+		inst := &Installer{Store: st, ProjectDir: t.TempDir(), Metadata: meta}
+		updates, err := inst.Outdated(ctx, cfg, nil)
+		if err != nil {
+			t.Fatalf("Outdated() error = %v", err)
+		}
+		if len(updates) != 1 {
+			t.Fatalf("Outdated() = %+v, want one update", updates)
+		}
+		return updates[0].Latest
+	}
+
+	if got := latest(&source.Metadata{Store: st}); got != "v1.1.0" {
+		t.Errorf("latest = %q, want v1.1.0", got)
+	}
+	repo.commitSkills("third", "pdf")
+	repo.run("tag", "v1.2.0")
+
+	// The tags stay cached until they are stale.
+	if got := latest(&source.Metadata{Store: st}); got != "v1.1.0" {
+		t.Errorf("latest from the cache = %q, want v1.1.0", got)
+	}
+	if got := latest(&source.Metadata{Store: st, TTL: -1}); got != "v1.2.0" {
+		t.Errorf("latest after revalidating = %q, want v1.2.0", got)
+	}
+}
@@ -0,0 +1,188 @@
+package installer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/mcp"
+	"github.com/agentpkg/agentpkg/pkg/skill"
+	"github.com/agentpkg/agentpkg/pkg/source"
+)
+
+// OverviewFileName is the file in the project's projector.ContentDir that
+// `apkg completion docs` writes the Overview to.
+const OverviewFileName = "OVERVIEW.md"
+
+// DefaultInspectTimeout bounds how long Overview waits for each MCP
+// server to list its tools.
+const DefaultInspectTimeout = 10 * time.Second
+
+// Overview summarizes the skills and MCP servers a project ships, for
+// the people and agents working in it.
+type Overview struct {
+	Skills     []OverviewSkill
+	MCPServers []OverviewServer
+}
+
+// OverviewSkill is a skill of an Overview.
+type OverviewSkill struct {
+	Name        string
+	Description string
+}
+
+// OverviewServer is an MCP server of an Overview.
+type OverviewServer struct {
+	Name      string
+	Transport string
+	Tools     []mcp.Tool
+	// ToolsError says why the server's tools couldn't be listed, if
+	// they were inspected.
+	ToolsError string
+}
+
+// Overview describes the skills and MCP servers of cfg as lf locks them,
+// sorted by name: skills with the description of their SKILL.md, and,
+// with inspect, MCP servers with the tools they list when started (see
+// mcp.ListTools). It only reads what is installed: skills missing from the
+// store are warned about and described without their description, and
+// servers that fail to list their tools are described without them.
+func (inst *Installer) Overview(ctx context.Context, cfg *config.Config, lf *config.LockFile, inspect bool) (*Overview, error) {
+	o := &Overview{Skills: []OverviewSkill{}, MCPServers: []OverviewServer{}}
+
+	lockIndex := buildLockIndex(cfg, lf)
+	for _, name := range sortedNames(cfg.Skills) {
+		dir, ok := inst.installedSkillDir(cfg.Skills[name], lockIndex[lockKey(name, cfg.Skills[name])])
+		if !ok {
+			inst.warn(fmt.Errorf("skill %q is not installed (run apkg install)", name))
+			o.Skills = append(o.Skills, OverviewSkill{Name: name})
+			continue
+		}
+		s, err := skill.Load(dir)
+		if err != nil {
+			return nil, fmt.Errorf("loading skill %q: %w", name, err)
+		}
+		o.Skills = append(o.Skills, OverviewSkill{Name: name, Description: s.Description()})
+	}
+
+	mcpIndex := buildMCPLockIndex(lf)
+	for _, name := range sortedNames(cfg.MCPServers) {
+		server := OverviewServer{Name: name, Transport: cfg.MCPServers[name].Transport}
+		if inspect {
+			tools, err := inst.inspectServer(ctx, name, mcpIndex)
+			if err != nil {
+				server.ToolsError = err.Error()
+			}
+			server.Tools = tools
+		}
+		o.MCPServers = append(o.MCPServers, server)
+	}
+	return o, nil
+}
+
+// inspectServer lists the tools of the installed MCP server name.
+func (inst *Installer) inspectServer(ctx context.Context, name string, mcpIndex map[string]config.MCPLockEntry) ([]mcp.Tool, error) {
+	entry, ok := mcpIndex[name]
+	if !ok {
+		return nil, fmt.Errorf("not installed (run apkg install)")
+	}
+	if !isDir(entry.InstallPath) {
+		return nil, fmt.Errorf("missing from the store (run apkg install)")
+	}
+	server, err := mcp.LoadWith(entry.InstallPath, lockedOptions(entry))
+	if err != nil {
+		return nil, err
+	}
+	server = mcp.WithProject(server, inst.projectID())
+
+	ctx, cancel := context.WithTimeout(ctx, DefaultInspectTimeout)
+	defer cancel()
+	return mcp.ListTools(ctx, server)
+}
+
+// installedSkillDir returns the directory the skill ss, locked as entry,
+// is installed from, without fetching it: a local skill's own directory,
+// or its snapshot, archive, or repo clone in the store. It returns false
+// if that isn't there.
+func (inst *Installer) installedSkillDir(ss config.SkillSource, entry config.SkillLockEntry) (string, bool) {
+	var dir string
+	switch {
+	case ss.Git == "" && ss.URL == "" && !ss.Snapshot:
+		dir = ss.Path
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(inst.ProjectDir, dir)
+		}
+	case ss.Git == "" && ss.URL == "":
+		if entry.Integrity == "" {
+			return "", false
+		}
+		dir = inst.Store.Path(source.SnapshotStoreSegments(entry.Integrity)...)
+	case ss.URL != "":
+		if entry.SHA256 == "" {
+			return "", false
+		}
+		dir = filepath.Join(inst.Store.Path(source.ArchiveStoreSegments(entry.SHA256)...), filepath.FromSlash(ss.Path))
+	default:
+		if entry.Commit == "" {
+			return "", false
+		}
+		segs, err := source.GitStoreSegments(ss.Git, entry.Commit)
+		if err != nil {
+			return "", false
+		}
+		dir = filepath.Join(inst.Store.Path(segs...), filepath.FromSlash(ss.Path))
+	}
+	return dir, isDir(dir)
+}
+
+// Markdown renders o as a markdown section: a list of the skills, and a
+// subsection per MCP server listing its tools.
+func (o *Overview) Markdown() []byte {
+	var b bytes.Buffer
+	b.WriteString("## Project tooling\n\n")
+	b.WriteString("Skills and MCP servers installed with apkg from apkg.toml. Regenerate this with `apkg completion docs`.\n")
+
+	b.WriteString("\n### Skills\n\n")
+	if len(o.Skills) == 0 {
+		b.WriteString("None.\n")
+	}
+	for _, s := range o.Skills {
+		fmt.Fprintf(&b, "- **%s**%s\n", s.Name, describe(s.Description))
+	}
+
+	b.WriteString("\n### MCP servers\n")
+	if len(o.MCPServers) == 0 {
+		b.WriteString("\nNone.\n")
+	}
+	for _, server := range o.MCPServers {
+		fmt.Fprintf(&b, "\n#### %s (%s)\n\n", server.Name, server.Transport)
+		switch {
+		case server.ToolsError != "":
+			fmt.Fprintf(&b, "Tools could not be listed: %s.\n", oneLine(server.ToolsError))
+		case len(server.Tools) == 0:
+			b.WriteString("No tools.\n")
+		}
+		for _, tool := range server.Tools {
+			fmt.Fprintf(&b, "- `%s`%s\n", tool.Name, describe(tool.Description))
+		}
+	}
+	return b.Bytes()
+}
+
+// describe returns ": description" for a list item, on one line, or ""
+// without a description.
+func describe(description string) string {
+	if description = oneLine(description); description == "" {
+		return ""
+	}
+	return ": " + description
+}
+
+// oneLine joins the lines of s with spaces.
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
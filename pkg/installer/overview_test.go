@@ -0,0 +1,104 @@
+package installer
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/apkgtest"
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+func TestOverview(t *testing.T) {
+	remote := apkgtest.NewMCPServer(t, map[string]string{"search": "Search the\ndocs"})
+
+	tests := map[string]struct {
+		inspect bool
+		// notFetched adds a git skill to the manifest and lockfile that
+		// isn't in the store.
+		notFetched bool
+		want       []string
+		wantNot    []string
+		wantWarns  int
+	}{
+		"inspected": {
+			inspect: true,
+			want: []string{
+				"- **my-skill**: test skill\n",
+				"#### docs (http)\n\n- `search`: Search the docs\n",
+				"#### broken (stdio)\n\nTools could not be listed: ",
+			},
+		},
+		"not inspected": {
+			want:    []string{"- **my-skill**: test skill\n", "#### docs (http)\n"},
+			wantNot: []string{"`search`", "could not be listed"},
+		},
+		"skill not fetched": {
+			notFetched: true,
+			want:       []string{"- **my-skill**: test skill\n", "- **remote**\n"},
+			wantWarns:  1,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			projectDir := t.TempDir()
+			skillDir := filepath.Join(t.TempDir(), "my-skill")
+			writeSkill(t, skillDir, "my-skill")
+			cfg := &config.Config{
+				Skills: map[string]config.SkillSource{"my-skill": {Path: skillDir}},
+				MCPServers: map[string]config.MCPSource{
+					"docs": {
+						Transport:             config.TransportHTTP,
+						ExternalHttpMCPConfig: &config.ExternalHttpMCPConfig{URL: remote.URL},
+					},
+					"broken": {
+						Transport:               config.TransportStdio,
+						UnmanagedStdioMCPConfig: &config.UnmanagedStdioMCPConfig{Command: "/bin/false"},
+					},
+				},
+			}
+			var warns []error
+			inst := &Installer{
+				Store:      store.New(t.TempDir()),
+				ProjectDir: projectDir,
+				Agents:     []string{"test-skills-only"},
+				Warn:       func(err error) { warns = append(warns, err) },
+			}
+			lf, err := inst.InstallAll(context.Background(), cfg, nil)
+			if err != nil {
+				t.Fatalf("InstallAll() error = %v", err)
+			}
+			if tc.notFetched {
+				// Overview must not fetch it from this unreachable host.
+				ss := config.SkillSource{Git: "https://skills.invalid/org/skills.git", Path: "remote", Ref: "main"}
+				cfg.Skills["remote"] = ss
+				lf.Skills = append(lf.Skills, config.SkillLockEntry{Name: "remote", Git: ss.Git, Path: ss.Path, Ref: ss.Ref, Commit: strings.Repeat("a", 40)})
+			}
+
+			o, err := inst.Overview(context.Background(), cfg, lf, tc.inspect)
+			if err != nil {
+				t.Fatalf("Overview() error = %v", err)
+			}
+			md := string(o.Markdown())
+			for _, want := range tc.want {
+				if !strings.Contains(md, want) {
+					t.Errorf("Markdown() lacks %q:\n%s", want, md)
+				}
+			}
+			for _, unwanted := range tc.wantNot {
+				if strings.Contains(md, unwanted) {
+					t.Errorf("Markdown() has %q:\n%s", unwanted, md)
+				}
+			}
+			if len(warns) != tc.wantWarns {
+				t.Errorf("warnings = %v, want %d", warns, tc.wantWarns)
+			}
+			if strings.Index(md, "#### broken") > strings.Index(md, "#### docs") {
+				t.Errorf("MCP servers not sorted by name:\n%s", md)
+			}
+		})
+	}
+}
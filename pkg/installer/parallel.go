@@ -0,0 +1,98 @@
+package installer
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+
+	"github.com/agentpkg/agentpkg/pkg/source"
+)
+
+// parallel calls fn with 0 through n-1 on up to Concurrency goroutines
+// at once. Once a call fails, the context of the others is canceled and
+// calls not yet started are skipped. It returns the error of the lowest
+// index that failed, so the error doesn't depend on scheduling.
+func (inst *Installer) parallel(ctx context.Context, n int, fn func(ctx context.Context, i int) error) error {
+	limit := inst.Concurrency
+	if limit <= 0 {
+		limit = DefaultConcurrency
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make([]error, n)
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i := range n {
+		sem <- struct{}{}
+		if ctx.Err() != nil {
+			<-sem
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			if errs[i] = fn(ctx, i); errs[i] != nil {
+				cancel()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// fetchKey identifies the store entries src fetches into: its repository,
+// package, archive, or local directory. Sources without one fetch into
+// entries of their own.
+func fetchKey(src source.Source) string {
+	switch src := src.(type) {
+	case *source.GitSource:
+		return "git " + src.URL
+	case *source.NPMSource:
+		return "npm " + src.Package
+	case *source.UVSource:
+		return "uv " + src.Package
+	case *source.GoSource:
+		return "go " + src.Package
+	case *source.PackageImageSource:
+		return "image " + src.MCPConfig.Package
+	case *source.OCISource:
+		if src.MCPConfig.ContainerMCPConfig != nil {
+			return "oci " + src.MCPConfig.Image
+		}
+	case *source.ArchiveSource:
+		return "archive " + src.URL
+	case *source.LocalSource:
+		// Skills of the same directory share its copy in the store.
+		if abs, err := filepath.Abs(src.Path); err == nil {
+			return "local " + abs
+		}
+		return "local " + src.Path
+	}
+	return ""
+}
+
+// lockFetch waits until no other fetch of key is running and returns the
+// function that lets the next one run.
+func (inst *Installer) lockFetch(key string) (unlock func()) {
+	inst.fetchMu.Lock()
+	if inst.fetchLocks == nil {
+		inst.fetchLocks = make(map[string]*sync.Mutex)
+	}
+	mu, ok := inst.fetchLocks[key]
+	if !ok {
+		mu = &sync.Mutex{}
+		inst.fetchLocks[key] = mu
+	}
+	inst.fetchMu.Unlock()
+
+	mu.Lock()
+	return mu.Unlock
+}
@@ -0,0 +1,153 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agentpkg/agentpkg/pkg/source"
+)
+
+func TestParallel(t *testing.T) {
+	tests := map[string]struct {
+		concurrency int
+		fail        []int
+		wantErr     string
+	}{
+		"all succeed":          {concurrency: 3},
+		"default concurrency":  {},
+		"serial":               {concurrency: 1},
+		"lowest failure wins":  {concurrency: 8, fail: []int{6, 2}, wantErr: "package 2 failed"},
+		"serial stops at fail": {concurrency: 1, fail: []int{0}, wantErr: "package 0 failed"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			inst := &Installer{Concurrency: tc.concurrency}
+			limit := tc.concurrency
+			if limit == 0 {
+				limit = DefaultConcurrency
+			}
+
+			var running, peak, calls atomic.Int32
+			err := inst.parallel(context.Background(), 8, func(ctx context.Context, i int) error {
+				calls.Add(1)
+				n := running.Add(1)
+				defer running.Add(-1)
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+				for _, f := range tc.fail {
+					if f == i {
+						// Let the others start before failing.
+						time.Sleep(10 * time.Millisecond)
+						return fmt.Errorf("package %d failed", i)
+					}
+				}
+				time.Sleep(time.Millisecond)
+				return nil
+			})
+
+			if tc.wantErr == "" && err != nil {
+				t.Fatalf("parallel() error = %v", err)
+			}
+			if tc.wantErr != "" && (err == nil || err.Error() != tc.wantErr) {
+				t.Fatalf("parallel() error = %v, want %q", err, tc.wantErr)
+			}
+			if int(peak.Load()) > limit {
+				t.Errorf("%d calls ran at once, want at most %d", peak.Load(), limit)
+			}
+			if tc.concurrency == 1 && tc.wantErr != "" && calls.Load() != 1 {
+				t.Errorf("%d calls made after the first failed, want none", calls.Load()-1)
+			}
+		})
+	}
+}
+
+func TestLockFetch(t *testing.T) {
+	inst := &Installer{}
+	unlock := inst.lockFetch("git https://example.com/skills.git")
+
+	other := make(chan struct{})
+	go func() {
+		inst.lockFetch("git https://example.com/other.git")()
+		close(other)
+	}()
+	select {
+	case <-other:
+	case <-time.After(time.Second):
+		t.Fatal("fetch of another repository waited for the lock")
+	}
+
+	same := make(chan struct{})
+	go func() {
+		inst.lockFetch("git https://example.com/skills.git")()
+		close(same)
+	}()
+	select {
+	case <-same:
+		t.Fatal("concurrent fetch of the same repository didn't wait")
+	case <-time.After(20 * time.Millisecond):
+	}
+	unlock()
+	select {
+	case <-same:
+	case <-time.After(time.Second):
+		t.Fatal("fetch of the same repository still waiting after unlock")
+	}
+}
+
+func TestFetchKey(t *testing.T) {
+	tests := map[string]struct {
+		a, b     source.Source
+		wantSame bool
+	}{
+		"same archive": {
+			a:        &source.ArchiveSource{URL: "https://example.com/skills.tar.gz", Path: "pdf"},
+			b:        &source.ArchiveSource{URL: "https://example.com/skills.tar.gz", Path: "docx"},
+			wantSame: true,
+		},
+		"other archive": {
+			a: &source.ArchiveSource{URL: "https://example.com/skills.tar.gz"},
+			b: &source.ArchiveSource{URL: "https://example.com/other.tar.gz"},
+		},
+		"same local directory": {
+			a:        &source.LocalSource{Path: "skills/pdf"},
+			b:        &source.LocalSource{Path: "./skills/../skills/pdf", Snapshot: true},
+			wantSame: true,
+		},
+		"other local directory": {
+			a: &source.LocalSource{Path: "skills/pdf"},
+			b: &source.LocalSource{Path: "skills/docx"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			a, b := fetchKey(tc.a), fetchKey(tc.b)
+			if a == "" || b == "" {
+				t.Fatalf("fetchKey() = %q, %q, want keys for both", a, b)
+			}
+			if (a == b) != tc.wantSame {
+				t.Errorf("fetchKey() = %q, %q, want same = %v", a, b, tc.wantSame)
+			}
+		})
+	}
+}
+
+func TestParallelCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := (&Installer{Concurrency: 1}).parallel(ctx, 3, func(ctx context.Context, i int) error {
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("parallel() error = %v, want context.Canceled", err)
+	}
+}
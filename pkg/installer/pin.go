@@ -0,0 +1,45 @@
+package installer
+
+import (
+	"github.com/agentpkg/agentpkg/pkg/config"
+)
+
+// PinRefs implements the pin_refs project policy (see
+// config.ProjectConfig.PinRefs): it rewrites the ref of each git skill in
+// cfg to the commit locked for it in lf, moving the original ref to Track,
+// and updates the lock entries to match. It reports whether cfg changed.
+func PinRefs(cfg *config.Config, lf *config.LockFile) bool {
+	if lf == nil {
+		return false
+	}
+
+	NameSkillLockEntries(cfg, lf)
+	changed := false
+	for name, ss := range cfg.Skills {
+		if ss.Git == "" {
+			continue
+		}
+		for i, entry := range lf.Skills {
+			if lockKeyFromEntry(entry) != lockKey(name, ss) || entry.Commit == "" || entry.Commit == ss.Ref {
+				continue
+			}
+			if ss.Track == "" {
+				ss.Track = ss.Ref
+			}
+			ss.Ref = entry.Commit
+			cfg.Skills[name] = ss
+			lf.Skills[i].Ref = entry.Commit
+			changed = true
+		}
+	}
+	return changed
+}
+
+// unpinned returns ss with its pinned commit replaced by the tracked ref,
+// so it resolves to the latest commit of the branch or tag again.
+func unpinned(ss config.SkillSource) config.SkillSource {
+	if ss.Track != "" {
+		ss.Ref, ss.Track = ss.Track, ""
+	}
+	return ss
+}
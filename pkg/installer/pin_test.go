@@ -0,0 +1,84 @@
+package installer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+func TestPinRefs(t *testing.T) {
+	// Separate repositories, since the two skills would otherwise share a
+	// sparse checkout of the same commit.
+	repo, tagRepo := newGitRepo(t), newGitRepo(t)
+	first := repo.commitSkills("first", "pdf")
+	firstTag := tagRepo.commitSkills("first", "docx")
+	tagRepo.run("tag", "v1.0.0")
+
+	cfg := &config.Config{
+		Project: config.ProjectConfig{PinRefs: true},
+		Skills: map[string]config.SkillSource{
+			"tracking": {Git: repo.dir, Path: "pdf", Ref: "main"},
+			"tagged":   {Git: tagRepo.dir, Path: "docx", Ref: "v1.0.0"},
+			"local":    {Path: repo.dir + "/pdf"},
+		},
+	}
+
+	inst := &Installer{Store: store.New(t.TempDir()), ProjectDir: t.TempDir()}
+	ctx := context.Background()
+	lf, err := inst.InstallAll(ctx, cfg, nil)
+	if err != nil {
+		t.Fatalf("InstallAll() error = %v", err)
+	}
+
+	if !PinRefs(cfg, lf) {
+		t.Fatal("PinRefs() = false, want true")
+	}
+	if PinRefs(cfg, lf) {
+		t.Error("second PinRefs() = true, want false")
+	}
+
+	wantManifest := map[string]config.SkillSource{
+		"tracking": {Git: repo.dir, Path: "pdf", Ref: first, Track: "main"},
+		"tagged":   {Git: tagRepo.dir, Path: "docx", Ref: firstTag, Track: "v1.0.0"},
+		"local":    {Path: repo.dir + "/pdf"},
+	}
+	for name, want := range wantManifest {
+		if got := cfg.Skills[name]; got != want {
+			t.Errorf("skill %q = %+v, want %+v", name, got, want)
+		}
+	}
+	index := buildLockIndex(cfg, lf)
+	if got := index[lockKey("tracking", cfg.Skills["tracking"])].Ref; got != first {
+		t.Errorf("tracking lock ref = %q, want %q", got, first)
+	}
+
+	// Updates follow the tracked refs and pin the new commits.
+	second := repo.commitSkills("second", "pdf")
+	secondTag := tagRepo.commitSkills("second", "docx")
+	tagRepo.run("tag", "v1.1.0")
+
+	updates, err := inst.Outdated(ctx, cfg, lf)
+	if err != nil {
+		t.Fatalf("Outdated() error = %v", err)
+	}
+	if len(updates) != 2 {
+		t.Fatalf("Outdated() = %+v, want updates of both pinned skills", updates)
+	}
+	if lf, err = inst.ApplyUpdates(ctx, cfg, lf, updates); err != nil {
+		t.Fatalf("ApplyUpdates() error = %v", err)
+	}
+
+	wantManifest["tracking"] = config.SkillSource{Git: repo.dir, Path: "pdf", Ref: second, Track: "main"}
+	wantManifest["tagged"] = config.SkillSource{Git: tagRepo.dir, Path: "docx", Ref: secondTag, Track: "v1.1.0"}
+	for name, want := range wantManifest {
+		if got := cfg.Skills[name]; got != want {
+			t.Errorf("skill %q after update = %+v, want %+v", name, got, want)
+		}
+	}
+	index = buildLockIndex(cfg, lf)
+	if got := index[lockKey("tagged", cfg.Skills["tagged"])]; got.Ref != secondTag || got.Commit != secondTag {
+		t.Errorf("tagged lock entry = %+v, want ref and commit %q", got, secondTag)
+	}
+}
@@ -0,0 +1,77 @@
+package installer
+
+import (
+	"context"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/policy"
+	"github.com/agentpkg/agentpkg/pkg/source"
+)
+
+// checkPolicy asks the Policy hook, if any, to approve pkgs.
+func (inst *Installer) checkPolicy(ctx context.Context, pkgs []policy.Package) error {
+	if inst.Policy == nil {
+		return nil
+	}
+	plan := &policy.Plan{Global: inst.Global, Packages: pkgs}
+	if !inst.Global {
+		plan.Project = inst.ProjectDir
+	}
+	return policy.Enforce(ctx, inst.Policy, plan)
+}
+
+// lockPolicyPackages returns the packages of cfg as lf resolved them.
+func lockPolicyPackages(cfg *config.Config, lf *config.LockFile) []policy.Package {
+	var pkgs []policy.Package
+	for _, entry := range lf.Skills {
+		pkgs = append(pkgs, policy.Package{
+			Kind:      KindSkill,
+			Name:      entry.Name,
+			Source:    skillSourceString(config.SkillSource{Git: entry.Git, URL: entry.URL, Path: entry.Path, Ref: entry.Ref}),
+			Version:   entry.Commit,
+			Integrity: entry.Integrity,
+		})
+	}
+	for _, entry := range lf.MCPServers {
+		pkgs = append(pkgs, policy.Package{
+			Kind:      KindMCP,
+			Name:      entry.Name,
+			Source:    mcpSourceString(cfg.MCPServers[entry.Name]),
+			Version:   mcpVersion(entry),
+			Integrity: entry.Integrity,
+		})
+	}
+	return pkgs
+}
+
+// sourcePolicyPackage returns the package name of kind fetched from src.
+func sourcePolicyPackage(kind, name string, src source.Source, resolved *source.ResolvedSource) policy.Package {
+	pkg := policy.Package{Kind: kind, Name: name, Integrity: resolved.Integrity}
+	switch src := src.(type) {
+	case *source.GitSource:
+		pkg.Source = skillSourceString(config.SkillSource{Git: src.URL, Path: src.Path, Ref: src.Ref})
+	case *source.LocalSource:
+		pkg.Source = src.Path
+	case *source.NPMSource:
+		pkg.Source = mcpSourceString(src.MCPConfig)
+	case *source.UVSource:
+		pkg.Source = mcpSourceString(src.MCPConfig)
+	case *source.GoSource:
+		pkg.Source = mcpSourceString(src.MCPConfig)
+	case *source.PackageImageSource:
+		pkg.Source = mcpSourceString(src.MCPConfig)
+	case *source.OCISource:
+		pkg.Source = mcpSourceString(src.MCPConfig)
+	case *source.StaticSource:
+		pkg.Source = mcpSourceString(src.MCPConfig)
+	}
+	switch {
+	case resolved.Commit != "":
+		pkg.Version = resolved.Commit
+	case resolved.Version != "":
+		pkg.Version = resolved.Version
+	default:
+		pkg.Version = resolved.Digest
+	}
+	return pkg
+}
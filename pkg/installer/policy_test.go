@@ -0,0 +1,76 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/policy"
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+// policyFunc is a policy.Hook deciding with a function.
+type policyFunc func(*policy.Plan) []policy.Decision
+
+func (f policyFunc) Check(_ context.Context, plan *policy.Plan) ([]policy.Decision, error) {
+	return f(plan), nil
+}
+
+func TestInstallAllPolicy(t *testing.T) {
+	skillsDir := t.TempDir()
+	writeSkill(t, filepath.Join(skillsDir, "pdf"), "pdf")
+	writeSkill(t, filepath.Join(skillsDir, "notes"), "notes")
+	cfg := &config.Config{
+		Skills: map[string]config.SkillSource{
+			"pdf":   {Path: filepath.Join(skillsDir, "pdf")},
+			"notes": {Path: filepath.Join(skillsDir, "notes")},
+		},
+	}
+
+	tests := map[string]struct {
+		denied     string
+		wantDenied bool
+	}{
+		"all allowed": {},
+		"one denied":  {denied: "notes", wantDenied: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			root := t.TempDir()
+			projectDir := filepath.Join(root, "project")
+			var plan *policy.Plan
+			inst := &Installer{
+				Store:      store.New(filepath.Join(root, "store")),
+				ProjectDir: projectDir,
+				Agents:     []string{"test-skills-only"},
+				Policy: policyFunc(func(p *policy.Plan) []policy.Decision {
+					plan = p
+					var decisions []policy.Decision
+					for _, pkg := range p.Packages {
+						decisions = append(decisions, policy.Decision{Kind: pkg.Kind, Name: pkg.Name, Allow: pkg.Name != tc.denied})
+					}
+					return decisions
+				}),
+			}
+
+			_, err := inst.InstallAll(context.Background(), cfg, nil)
+			var denied *policy.DeniedError
+			if tc.wantDenied != errors.As(err, &denied) {
+				t.Fatalf("InstallAll() error = %v, want denied %v", err, tc.wantDenied)
+			}
+			if plan == nil || len(plan.Packages) != 2 || plan.Project != projectDir {
+				t.Errorf("plan = %+v, want both skills of %s", plan, projectDir)
+			}
+
+			// A denied install projects nothing, not even the allowed skill.
+			_, statErr := os.Lstat(filepath.Join(projectDir, ".test", "skills", "pdf"))
+			if projected := statErr == nil; projected == tc.wantDenied {
+				t.Errorf("pdf projected = %v, want %v", projected, !tc.wantDenied)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package installer
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/container"
+	"github.com/agentpkg/agentpkg/pkg/source"
+)
+
+// Tools that packages need to be fetched.
+const (
+	toolGit       = "git"
+	toolNPM       = "npm"
+	toolUV        = "uv"
+	toolGo        = "go"
+	toolContainer = "docker or podman"
+)
+
+// toolOrder is the order missing tools are reported in.
+var toolOrder = []string{toolGit, toolNPM, toolUV, toolGo, toolContainer}
+
+// lookPath and detectEngine find tools; tests replace them.
+var (
+	lookPath     = exec.LookPath
+	detectEngine = container.DetectEngine
+)
+
+// toolHints are the ways to install each tool by GOOS, with "" for other
+// systems.
+var toolHints = map[string]map[string]string{
+	toolGit: {
+		"darwin":  "run `xcode-select --install` or `brew install git`",
+		"windows": "run `winget install Git.Git`",
+		"":        "install git with your package manager, e.g. `apt install git`",
+	},
+	toolNPM: {
+		"darwin":  "run `brew install node`, or pin node under [runtimes] in apkg.toml",
+		"windows": "run `winget install OpenJS.NodeJS`, or pin node under [runtimes] in apkg.toml",
+		"":        "install Node.js from https://nodejs.org, or pin node under [runtimes] in apkg.toml",
+	},
+	toolUV: {
+		"darwin":  "run `brew install uv`",
+		"windows": "run `winget install astral-sh.uv`",
+		"":        "run `curl -LsSf https://astral.sh/uv/install.sh | sh`",
+	},
+	toolGo: {
+		"darwin":  "run `brew install go`",
+		"windows": "run `winget install GoLang.Go`",
+		"":        "install Go from https://go.dev/dl",
+	},
+	toolContainer: {
+		"darwin":  "install Docker Desktop or run `brew install podman`, or set APKG_CONTAINER_ENGINE",
+		"windows": "install Docker Desktop or run `winget install RedHat.Podman`, or set APKG_CONTAINER_ENGINE",
+		"":        "install docker or podman with your package manager, or set APKG_CONTAINER_ENGINE",
+	},
+}
+
+// MissingTool is a tool that packages need to be fetched but isn't
+// installed.
+type MissingTool struct {
+	Tool string
+	// Packages are the packages that need it, e.g. `MCP server "fs"`.
+	Packages []string
+	// Hint says how to install the tool on this system.
+	Hint string
+}
+
+// MissingToolsError reports every tool a manifest needs that isn't
+// installed, so they can be installed in one go.
+type MissingToolsError struct {
+	Tools []MissingTool
+}
+
+func (e *MissingToolsError) Error() string {
+	var b strings.Builder
+	b.WriteString("missing tools needed to install packages:")
+	for _, t := range e.Tools {
+		fmt.Fprintf(&b, "\n  %s (for %s): %s", t.Tool, strings.Join(t.Packages, ", "), t.Hint)
+	}
+	return b.String()
+}
+
+// CheckTools checks that the tools the skills and MCP servers of cfg need
+// to be fetched are installed: git for git skills, npm, uv, or go for
+// managed servers, and a container engine for container servers. Only
+// packages that need fetching are checked: vendored packages, npm servers
+// on a pinned Node runtime, and packages whose version locked in existing
+// (which may be nil) is already in the store need none. All missing tools
+// are reported together as a *MissingToolsError.
+func (inst *Installer) CheckTools(cfg *config.Config, existing *config.LockFile) error {
+	lockIndex := buildLockIndex(cfg, existing)
+	mcpLockIndex := buildMCPLockIndex(existing)
+	needed := make(map[string][]string)
+	for _, name := range sortedNames(cfg.Skills) {
+		if inst.skillTool(name, cfg.Skills[name], lockIndex) != "" {
+			needed[toolGit] = append(needed[toolGit], fmt.Sprintf("skill %q", name))
+		}
+	}
+	for _, name := range sortedNames(cfg.MCPServers) {
+		if tool := inst.mcpTool(cfg, name, mcpLockIndex[name]); tool != "" {
+			needed[tool] = append(needed[tool], fmt.Sprintf("MCP server %q", name))
+		}
+	}
+
+	var missing []MissingTool
+	for _, tool := range toolOrder {
+		packages, ok := needed[tool]
+		if !ok || toolInstalled(tool) {
+			continue
+		}
+		missing = append(missing, MissingTool{Tool: tool, Packages: packages, Hint: toolHint(tool, runtime.GOOS)})
+	}
+	if len(missing) > 0 {
+		return &MissingToolsError{Tools: missing}
+	}
+	return nil
+}
+
+// SkillName returns the name cfg (which may be nil) declares the skill of
+// ss under, or else the name its source suggests: the last element of its
+// path, repository, or URL. It names a skill before it is fetched, e.g. to
+// check the tools it needs.
+func SkillName(cfg *config.Config, ss config.SkillSource) string {
+	if cfg != nil {
+		for _, name := range sortedNames(cfg.Skills) {
+			declared := cfg.Skills[name]
+			if declared.Git == ss.Git && declared.Path == ss.Path && declared.URL == ss.URL {
+				return name
+			}
+		}
+	}
+	for _, s := range []string{ss.Path, strings.TrimSuffix(ss.Git, ".git"), ss.URL} {
+		if s = strings.TrimRight(filepath.ToSlash(s), "/"); s != "" {
+			return s[strings.LastIndex(s, "/")+1:]
+		}
+	}
+	return ss.Short
+}
+
+// skillTool returns the tool the skill name needs to be fetched, or "".
+// Like fetchSkill, it fetches the locked commit while the ref is
+// unchanged.
+func (inst *Installer) skillTool(name string, ss config.SkillSource, lockIndex map[string]config.SkillLockEntry) string {
+	if ss.Git == "" || isDir(filepath.Join(inst.VendorDir, vendorSkillsDir, name)) {
+		return ""
+	}
+	ref := ss.Ref
+	if entry, ok := lockIndex[lockKey(name, ss)]; ok && entry.Commit != "" && entry.Ref == ss.Ref {
+		ref = entry.Commit
+	}
+	src := source.SourceFromSkillConfig(config.SkillSource{Git: ss.Git, Path: ss.Path, Ref: ref})
+	if inst.Store != nil && source.Cached(inst.Store, src) {
+		return ""
+	}
+	return toolGit
+}
+
+// mcpTool returns the tool the MCP server name of cfg, locked as entry,
+// needs to be fetched, or "".
+func (inst *Installer) mcpTool(cfg *config.Config, name string, entry config.MCPLockEntry) string {
+	ms := cfg.MCPServers[name]
+	if inst.VendorDir != "" {
+		if _, err := os.Stat(filepath.Join(inst.VendorDir, vendorMCPDir, name, mcpConfigFile)); err == nil {
+			return ""
+		}
+	}
+	if src, err := source.SourceFromMCPLock(name, ms, entry); err == nil && inst.Store != nil && source.Cached(inst.Store, src) {
+		return ""
+	}
+
+	switch {
+	case ms.ManagedStdioMCPConfig.InContainer():
+		return toolContainer
+	case ms.ManagedStdioMCPConfig != nil && strings.HasPrefix(ms.Package, "npm:"):
+		if cfg.Runtimes != nil && cfg.Runtimes.Node != "" {
+			return ""
+		}
+		return toolNPM
+	case ms.ManagedStdioMCPConfig != nil && (strings.HasPrefix(ms.Package, "uv:") || strings.HasPrefix(ms.Package, "uv-tool:")):
+		return toolUV
+	case ms.ManagedStdioMCPConfig != nil && strings.HasPrefix(ms.Package, "go:"):
+		return toolGo
+	case ms.ContainerMCPConfig != nil && ms.Image != "":
+		return toolContainer
+	}
+	return ""
+}
+
+func toolInstalled(tool string) bool {
+	switch tool {
+	case toolContainer:
+		_, err := detectEngine()
+		return err == nil
+	case toolNPM:
+		// npm servers also run on the system's node.
+		_, npmErr := lookPath("npm")
+		_, nodeErr := lookPath("node")
+		return npmErr == nil && nodeErr == nil
+	}
+	_, err := lookPath(tool)
+	return err == nil
+}
+
+func toolHint(tool, goos string) string {
+	if hint, ok := toolHints[tool][goos]; ok {
+		return hint
+	}
+	return toolHints[tool][""]
+}
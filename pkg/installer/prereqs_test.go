@@ -0,0 +1,174 @@
+package installer
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/container"
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+func TestCheckTools(t *testing.T) {
+	commit := strings.Repeat("a", 40)
+	npm := config.MCPSource{Transport: "stdio", ManagedStdioMCPConfig: &config.ManagedStdioMCPConfig{Package: "npm:server-fs"}}
+	uv := config.MCPSource{Transport: "stdio", ManagedStdioMCPConfig: &config.ManagedStdioMCPConfig{Package: "uv:mcp-server-git"}}
+	image := config.MCPSource{Transport: "http", ContainerMCPConfig: &config.ContainerMCPConfig{Image: "ghcr.io/org/db"}}
+	remote := config.MCPSource{Transport: "http", ExternalHttpMCPConfig: &config.ExternalHttpMCPConfig{URL: "https://example.com/mcp"}}
+
+	tests := map[string]struct {
+		cfg       *config.Config
+		installed []string
+		vendored  []string
+		locked    *config.LockFile
+		cached    [][]string // store entries
+		want      map[string][]string
+	}{
+		"all installed": {
+			cfg: &config.Config{
+				Skills:     map[string]config.SkillSource{"greet": {Git: "https://github.com/o/r.git", Ref: "main"}},
+				MCPServers: map[string]config.MCPSource{"fs": npm},
+			},
+			installed: []string{"git", "npm", "node"},
+		},
+		"missing tools reported together": {
+			cfg: &config.Config{
+				Skills: map[string]config.SkillSource{
+					"greet": {Git: "https://github.com/o/r.git", Ref: "main"},
+					"local": {Path: "./skills/local"},
+				},
+				MCPServers: map[string]config.MCPSource{"fs": npm, "git": uv, "db": image, "api": remote},
+			},
+			installed: []string{"npm"},
+			want: map[string][]string{
+				"git":              {`skill "greet"`},
+				"npm":              {`MCP server "fs"`},
+				"uv":               {`MCP server "git"`},
+				"docker or podman": {`MCP server "db"`},
+			},
+		},
+		"pinned node needs no npm": {
+			cfg: &config.Config{
+				MCPServers: map[string]config.MCPSource{"fs": npm},
+				Runtimes:   &config.RuntimesConfig{Node: "22"},
+			},
+		},
+		"vendored packages need nothing": {
+			cfg: &config.Config{
+				Skills:     map[string]config.SkillSource{"greet": {Git: "https://github.com/o/r.git", Ref: "main"}},
+				MCPServers: map[string]config.MCPSource{"fs": npm},
+			},
+			vendored: []string{"skills/greet", "mcp/fs"},
+		},
+		"locked packages in the store need nothing": {
+			cfg: &config.Config{
+				Skills:     map[string]config.SkillSource{"greet": {Git: "https://github.com/o/r.git", Ref: "main"}},
+				MCPServers: map[string]config.MCPSource{"fs": npm, "git": uv},
+			},
+			locked: &config.LockFile{
+				Skills: []config.SkillLockEntry{{Name: "greet", Git: "https://github.com/o/r.git", Ref: "main", Commit: commit}},
+				MCPServers: []config.MCPLockEntry{
+					{Name: "fs", Package: "npm:server-fs", ResolvedVersion: "1.0.0"},
+					{Name: "git", Package: "uv:mcp-server-git", ResolvedVersion: "2.0.0"},
+				},
+			},
+			cached: [][]string{{"repos", "github.com", "o", "r", commit}, {"npm", "server-fs", "1.0.0"}},
+			want:   map[string][]string{"uv": {`MCP server "git"`}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			lookPath = func(file string) (string, error) {
+				if slices.Contains(tc.installed, file) {
+					return "/usr/bin/" + file, nil
+				}
+				return "", exec.ErrNotFound
+			}
+			detectEngine = func() (container.Engine, error) { return nil, errors.New("no engine") }
+			t.Cleanup(func() { lookPath, detectEngine = exec.LookPath, container.DetectEngine })
+
+			vendorDir := t.TempDir()
+			for _, dir := range tc.vendored {
+				if err := os.MkdirAll(filepath.Join(vendorDir, dir), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if strings.HasPrefix(dir, vendorMCPDir) {
+					if err := os.WriteFile(filepath.Join(vendorDir, dir, mcpConfigFile), nil, 0o644); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			st := store.New(t.TempDir())
+			for _, segs := range tc.cached {
+				if err := os.MkdirAll(st.Path(segs...), 0o755); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			inst := &Installer{Store: st, VendorDir: vendorDir}
+			err := inst.CheckTools(tc.cfg, tc.locked)
+			if tc.want == nil {
+				if err != nil {
+					t.Fatalf("CheckTools() error = %v", err)
+				}
+				return
+			}
+
+			var missing *MissingToolsError
+			if !errors.As(err, &missing) {
+				t.Fatalf("CheckTools() error = %v, want *MissingToolsError", err)
+			}
+			got := make(map[string][]string)
+			var order []string
+			for _, tool := range missing.Tools {
+				got[tool.Tool] = tool.Packages
+				order = append(order, tool.Tool)
+				if tool.Hint == "" {
+					t.Errorf("%s has no install hint", tool.Tool)
+				}
+			}
+			if len(got) != len(tc.want) {
+				t.Errorf("missing = %v, want %v", got, tc.want)
+			}
+			for tool, packages := range tc.want {
+				if !slices.Equal(got[tool], packages) {
+					t.Errorf("%s needed by %v, want %v", tool, got[tool], packages)
+				}
+			}
+			if !slices.IsSortedFunc(order, func(a, b string) int { return slices.Index(toolOrder, a) - slices.Index(toolOrder, b) }) {
+				t.Errorf("tools reported in order %v, want %v", order, toolOrder)
+			}
+		})
+	}
+}
+
+func TestSkillName(t *testing.T) {
+	cfg := &config.Config{
+		Skills: map[string]config.SkillSource{"docs": {Git: "https://github.com/o/r.git", Path: "skills/pdf"}},
+	}
+
+	tests := map[string]struct {
+		ss   config.SkillSource
+		want string
+	}{
+		"declared":       {ss: config.SkillSource{Git: "https://github.com/o/r.git", Path: "skills/pdf", Ref: "v2"}, want: "docs"},
+		"path":           {ss: config.SkillSource{Git: "https://github.com/o/r.git", Path: "skills/xlsx"}, want: "xlsx"},
+		"repository":     {ss: config.SkillSource{Git: "https://github.com/o/greet.git"}, want: "greet"},
+		"trailing slash": {ss: config.SkillSource{Path: "./skills/local/"}, want: "local"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := SkillName(cfg, tc.ss); got != tc.want {
+				t.Errorf("SkillName() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
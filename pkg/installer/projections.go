@@ -0,0 +1,53 @@
+package installer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Projection is where a package is projected for an agent: the symlink or
+// rendered file of a skill, or the agent config file holding an MCP
+// server's entry.
+type Projection struct {
+	Agent string `json:"agent"`
+	Path  string `json:"path"`
+	// Pointer is the JSON pointer (RFC 6901) of an MCP server's entry in
+	// Path.
+	Pointer string `json:"pointer,omitempty"`
+	// Target is what a skill's symlink points at.
+	Target string `json:"target,omitempty"`
+}
+
+// recordedProjections returns the projections of the package name of kind
+// among records, the projections apkg recorded making (see
+// ProjectionRecord). Skills and config entries of the same name that apkg
+// didn't write aren't among them.
+func recordedProjections(records []ProjectionRecord, kind, name string) []Projection {
+	var projections []Projection
+	for _, r := range records {
+		if r.Kind == kind && r.Name == name {
+			projections = append(projections, Projection{Agent: r.Agent, Path: r.Path, Pointer: r.Pointer, Target: r.Target})
+		}
+	}
+	return projections
+}
+
+// dirEntry returns the path of name or name.<ext> in dir (see
+// dirHasEntry), or "" if it has neither.
+func dirEntry(dir, name string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading %s: %w", dir, err)
+	}
+	for _, e := range entries {
+		if e.Name() == name || strings.HasPrefix(e.Name(), name+".") {
+			return filepath.Join(dir, e.Name()), nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,155 @@
+package installer
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/source"
+)
+
+// SplitLockFile partitions lf into the entries of the given skills and MCP
+// servers being removed and the entries that remain. Skills, keyed by name,
+// are matched by name and source, or by source alone for unnamed entries
+// of older lockfiles; MCP servers are matched by name.
+func SplitLockFile(lf *config.LockFile, skills map[string]config.SkillSource, mcpServers []string) (removed, remaining *config.LockFile) {
+	removed = &config.LockFile{Version: lf.Version}
+	remaining = &config.LockFile{Version: lf.Version, Runtimes: lf.Runtimes}
+
+	keys := make(map[string]bool, len(skills))
+	sources := make(map[string]bool, len(skills))
+	for name, ss := range skills {
+		keys[lockKey(name, ss)] = true
+		sources[sourceKey(ss.Remote(), ss.Path)] = true
+	}
+	for _, entry := range lf.Skills {
+		if keys[lockKeyFromEntry(entry)] || entry.Name == "" && sources[sourceKey(entry.Remote(), entry.Path)] {
+			removed.Skills = append(removed.Skills, entry)
+		} else {
+			remaining.Skills = append(remaining.Skills, entry)
+		}
+	}
+
+	for _, entry := range lf.MCPServers {
+		if slices.Contains(mcpServers, entry.Name) {
+			removed.MCPServers = append(removed.MCPServers, entry)
+		} else {
+			remaining.MCPServers = append(remaining.MCPServers, entry)
+		}
+	}
+
+	return removed, remaining
+}
+
+// PurgeStore deletes the store entries of the removed lockfile entries that
+// no entry in the referenced lockfiles still uses, and returns the deleted
+// store paths. Local skills and entries outside the store are never
+// touched. Projects whose lockfiles aren't passed in re-fetch a purged
+// entry they share on their next install. Like CollectGarbage, it holds
+// the store lock.
+func (inst *Installer) PurgeStore(removed *config.LockFile, referenced ...*config.LockFile) ([]string, error) {
+	unlock, err := inst.Store.Lock(storeLockTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	inUse := make(map[string]bool)
+	for _, lf := range referenced {
+		if lf == nil {
+			continue
+		}
+		for _, segs := range inst.storeEntries(lf) {
+			inUse[filepath.Join(segs...)] = true
+		}
+	}
+
+	var purged []string
+	for _, segs := range inst.storeEntries(removed) {
+		key := filepath.Join(segs...)
+		if inUse[key] || slices.Contains(purged, inst.Store.Path(segs...)) {
+			continue
+		}
+
+		exists, err := inst.Store.Exists(segs...)
+		if err != nil {
+			return purged, fmt.Errorf("checking %s: %w", key, err)
+		}
+		if !exists {
+			continue
+		}
+
+		inst.Store.Remove(segs...)
+		purged = append(purged, inst.Store.Path(segs...))
+	}
+	return purged, nil
+}
+
+// storeEntries returns the store segments of every entry in lf that lives
+// in the store: the repo clone of git skills, the extracted archive of
+// skills from a URL, the snapshots of local skills (or, for lockfiles
+// loaded from disk, the copies of those with an ignore file), and the
+// install directory of MCP servers.
+func (inst *Installer) storeEntries(lf *config.LockFile) [][]string {
+	var entries [][]string
+	for _, entry := range lf.Skills {
+		if entry.URL != "" {
+			if entry.SHA256 != "" {
+				entries = append(entries, source.ArchiveStoreSegments(entry.SHA256))
+			}
+			continue
+		}
+		if entry.Git == "" && entry.Integrity != "" {
+			entries = append(entries, source.SnapshotStoreSegments(entry.Integrity))
+			continue
+		}
+		if isLocalCopy(entry) {
+			path := entry.Path
+			if !filepath.IsAbs(path) {
+				if lf.Dir == "" {
+					continue
+				}
+				path = filepath.Join(lf.Dir, path)
+			}
+			entries = append(entries, source.LocalStoreSegments(path))
+			continue
+		}
+		if entry.Git == "" || entry.Commit == "" {
+			continue
+		}
+		segs, err := source.GitStoreSegments(entry.Git, entry.Commit)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, segs)
+	}
+
+	for _, entry := range lf.MCPServers {
+		if segs, ok := inst.mcpStoreSegments(entry); ok {
+			entries = append(entries, segs)
+		}
+	}
+	return entries
+}
+
+// isLocalCopy reports whether entry is a local skill used in place, or
+// from a copy in the store if it has an ignore file.
+func isLocalCopy(entry config.SkillLockEntry) bool {
+	return entry.Git == "" && entry.URL == "" && entry.Integrity == "" && entry.Path != ""
+}
+
+// mcpStoreSegments returns the store segments of the install directory of
+// an MCP server's lock entry, or false if it has none or it lies outside
+// the store.
+func (inst *Installer) mcpStoreSegments(entry config.MCPLockEntry) ([]string, bool) {
+	if entry.InstallPath == "" {
+		return nil, false
+	}
+	rel, err := filepath.Rel(inst.Store.Path(), entry.InstallPath)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, false
+	}
+	return strings.Split(rel, string(filepath.Separator)), true
+}
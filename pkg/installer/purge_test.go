@@ -0,0 +1,121 @@
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+func TestSplitLockFile(t *testing.T) {
+	lf := &config.LockFile{
+		Version: 1,
+		Skills: []config.SkillLockEntry{
+			{Git: "https://github.com/org/skills.git", Path: "pdf", Commit: "abc"},
+			{Git: "https://github.com/org/skills.git", Path: "docx", Commit: "abc"},
+		},
+		MCPServers: []config.MCPLockEntry{{Name: "fs"}, {Name: "db"}},
+	}
+
+	removed, remaining := SplitLockFile(lf,
+		map[string]config.SkillSource{"pdf": {Git: "https://github.com/org/skills.git", Path: "pdf", Ref: "main"}},
+		[]string{"db"},
+	)
+
+	if len(removed.Skills) != 1 || removed.Skills[0].Path != "pdf" {
+		t.Errorf("removed skills = %+v, want pdf", removed.Skills)
+	}
+	if len(remaining.Skills) != 1 || remaining.Skills[0].Path != "docx" {
+		t.Errorf("remaining skills = %+v, want docx", remaining.Skills)
+	}
+	if len(removed.MCPServers) != 1 || removed.MCPServers[0].Name != "db" {
+		t.Errorf("removed MCP servers = %+v, want db", removed.MCPServers)
+	}
+	if len(remaining.MCPServers) != 1 || remaining.MCPServers[0].Name != "fs" {
+		t.Errorf("remaining MCP servers = %+v, want fs", remaining.MCPServers)
+	}
+}
+
+func TestPurgeStore(t *testing.T) {
+	const repo = "https://github.com/org/skills.git"
+
+	tests := map[string]struct {
+		removed    *config.LockFile
+		referenced *config.LockFile
+		wantPurged []string // store-relative paths
+		wantKept   []string
+	}{
+		"unreferenced entries are deleted": {
+			removed: &config.LockFile{
+				Skills:     []config.SkillLockEntry{{Git: repo, Path: "pdf", Commit: "c1"}},
+				MCPServers: []config.MCPLockEntry{{Name: "fs", InstallPath: "npm/fs/1.0.0"}},
+			},
+			referenced: &config.LockFile{},
+			wantPurged: []string{"repos/github.com/org/skills/c1", "npm/fs/1.0.0"},
+		},
+		"entries referenced elsewhere are kept": {
+			removed: &config.LockFile{
+				Skills:     []config.SkillLockEntry{{Git: repo, Path: "pdf", Commit: "c1"}},
+				MCPServers: []config.MCPLockEntry{{Name: "fs", InstallPath: "npm/fs/1.0.0"}},
+			},
+			referenced: &config.LockFile{
+				Skills:     []config.SkillLockEntry{{Git: repo, Path: "docx", Commit: "c1"}},
+				MCPServers: []config.MCPLockEntry{{Name: "files", InstallPath: "npm/fs/1.0.0"}},
+			},
+			wantKept: []string{"repos/github.com/org/skills/c1", "npm/fs/1.0.0"},
+		},
+		"paths outside the store are ignored": {
+			removed: &config.LockFile{
+				Skills:     []config.SkillLockEntry{{Path: "./skills/local"}},
+				MCPServers: []config.MCPLockEntry{{Name: "vendored", InstallPath: "/elsewhere/vendor/apkg/mcp/vendored"}},
+			},
+			referenced: &config.LockFile{},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			root := t.TempDir()
+			st := store.New(root)
+			inst := &Installer{Store: st}
+
+			for _, rel := range []string{"repos/github.com/org/skills/c1", "npm/fs/1.0.0"} {
+				os.MkdirAll(filepath.Join(root, rel), 0o755)
+			}
+			// InstallPath is recorded as an absolute store path.
+			for i, e := range tc.removed.MCPServers {
+				if !filepath.IsAbs(e.InstallPath) {
+					tc.removed.MCPServers[i].InstallPath = filepath.Join(root, e.InstallPath)
+				}
+			}
+			for i, e := range tc.referenced.MCPServers {
+				tc.referenced.MCPServers[i].InstallPath = filepath.Join(root, e.InstallPath)
+			}
+
+			purged, err := inst.PurgeStore(tc.removed, tc.referenced)
+			if err != nil {
+				t.Fatalf("PurgeStore() error = %v", err)
+			}
+
+			var want []string
+			for _, rel := range tc.wantPurged {
+				want = append(want, filepath.Join(root, rel))
+			}
+			if !slicesEqual(purged, want) {
+				t.Errorf("PurgeStore() = %v, want %v", purged, want)
+			}
+			for _, rel := range tc.wantPurged {
+				if _, err := os.Stat(filepath.Join(root, rel)); !os.IsNotExist(err) {
+					t.Errorf("%s still exists", rel)
+				}
+			}
+			for _, rel := range tc.wantKept {
+				if _, err := os.Stat(filepath.Join(root, rel)); err != nil {
+					t.Errorf("%s was deleted: %v", rel, err)
+				}
+			}
+		})
+	}
+}
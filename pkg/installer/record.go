@@ -0,0 +1,430 @@
+package installer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/agentpkg/agentpkg/pkg/fsutil"
+	"github.com/agentpkg/agentpkg/pkg/mcp"
+	"github.com/agentpkg/agentpkg/pkg/projector"
+	"github.com/agentpkg/agentpkg/pkg/skill"
+	"github.com/agentpkg/agentpkg/pkg/store"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// The projections apkg made are recorded in the projection state file
+// (see Installer.StatePath), next to the entries it owns, keyed by
+// project directory. Records hold this machine's absolute paths, so they
+// are kept per machine rather than in the project.
+const (
+	projectionsTable = "projections"
+	// globalProjections keys the records of the global install.
+	globalProjections = "global"
+)
+
+// ProjectionRecord is a file, symlink, or config entry apkg wrote for an
+// agent when it projected a package.
+type ProjectionRecord struct {
+	Kind  string `toml:"kind" json:"kind"` // KindSkill or KindMCP
+	Name  string `toml:"name" json:"name"`
+	Agent string `toml:"agent" json:"agent"`
+	Path  string `toml:"path" json:"path"`
+	// Pointer is the JSON pointer of an MCP server's entry in Path.
+	Pointer string `toml:"pointer,omitempty" json:"pointer,omitempty"`
+	// Target is what a skill's symlink points at.
+	Target string `toml:"target,omitempty" json:"target,omitempty"`
+	// Hash is the sha256 of a rendered skill file, or of the JSON of an
+	// MCP server's entry, as apkg wrote it.
+	Hash string `toml:"hash,omitempty" json:"hash,omitempty"`
+}
+
+type projectionsState struct {
+	Projections map[string][]ProjectionRecord `toml:"projections,omitempty"`
+}
+
+// LoadProjectionRecords reads the records of project, or of the global
+// install if project is "", from the state file at statePath, which may
+// not exist.
+func LoadProjectionRecords(statePath, project string) ([]ProjectionRecord, error) {
+	data, err := os.ReadFile(statePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", statePath, err)
+	}
+	var state projectionsState
+	if err := toml.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", statePath, err)
+	}
+	return state.Projections[projectionsKey(project)], nil
+}
+
+// saveProjectionRecords replaces the records of project in the state file
+// at statePath with records, sorted.
+func saveProjectionRecords(statePath, project string, records []ProjectionRecord) error {
+	records = slices.Clone(records)
+	sort.Slice(records, func(i, j int) bool {
+		a, b := records[i], records[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.Agent != b.Agent {
+			return a.Agent < b.Agent
+		}
+		return a.Path < b.Path
+	})
+	return projector.UpdateState(statePath, func(state map[string]any) {
+		projections, _ := state[projectionsTable].(map[string]any)
+		if projections == nil {
+			projections = make(map[string]any)
+		}
+		if len(records) == 0 {
+			delete(projections, projectionsKey(project))
+		} else {
+			projections[projectionsKey(project)] = records
+		}
+		if len(projections) == 0 {
+			delete(state, projectionsTable)
+			return
+		}
+		state[projectionsTable] = projections
+	})
+}
+
+// projectionsKey returns the key of project's records in the state file.
+func projectionsKey(project string) string {
+	if project == "" {
+		return globalProjections
+	}
+	return project
+}
+
+// loadRecords loads the install's records from StatePath for the current
+// batch.
+func (inst *Installer) loadRecords() error {
+	if inst.StatePath == "" {
+		return nil
+	}
+	records, err := LoadProjectionRecords(inst.StatePath, inst.projectID())
+	if err != nil {
+		return err
+	}
+	inst.records = &records
+	return nil
+}
+
+// saveRecords hashes the MCP server entries recorded in the batch, now
+// that their configs are written, and saves the records.
+func (inst *Installer) saveRecords() error {
+	if inst.records == nil {
+		return nil
+	}
+	kept := (*inst.records)[:0]
+	for _, r := range *inst.records {
+		if r.Kind == KindMCP && r.Hash == "" {
+			entry, ok, err := projector.Entry(r.Path, r.Pointer)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			r.Hash = hashJSON(entry)
+		}
+		kept = append(kept, r)
+	}
+	*inst.records = kept
+	return saveProjectionRecords(inst.StatePath, inst.projectID(), kept)
+}
+
+// record replaces the records of the package name of kind for agent with
+// r, or removes them if r is nil. Paths are recorded absolute, so they
+// can be checked from any working directory.
+func (inst *Installer) record(kind, name, agent string, r *ProjectionRecord) {
+	if inst.records == nil {
+		return
+	}
+	if r != nil {
+		if abs, err := filepath.Abs(r.Path); err == nil {
+			r.Path = abs
+		}
+	}
+	*inst.records = slices.DeleteFunc(*inst.records, func(old ProjectionRecord) bool {
+		return old.Kind == kind && old.Name == name && old.Agent == agent
+	})
+	if r != nil {
+		*inst.records = append(*inst.records, *r)
+	}
+}
+
+// recorded returns the records of the package name of kind.
+func (inst *Installer) recorded(kind, name string) []ProjectionRecord {
+	if inst.records == nil {
+		return nil
+	}
+	var records []ProjectionRecord
+	for _, r := range *inst.records {
+		if r.Kind == kind && r.Name == name {
+			records = append(records, r)
+		}
+	}
+	return records
+}
+
+// recordSkills records the projections of skills for agent with opts.
+func (inst *Installer) recordSkills(agent string, proj projector.Projector, opts projector.ProjectionOpts, skills []skill.Skill) error {
+	if inst.records == nil {
+		return nil
+	}
+	targets, err := proj.Targets(opts)
+	if err != nil {
+		return fmt.Errorf("resolving targets of %s: %w", agent, err)
+	}
+	for _, s := range skills {
+		path := ""
+		if targets.SkillsDir != "" {
+			if path, err = dirEntry(targets.SkillsDir, s.Name()); err != nil {
+				return err
+			}
+		}
+		if path == "" {
+			inst.record(KindSkill, s.Name(), agent, nil)
+			continue
+		}
+		r := &ProjectionRecord{Kind: KindSkill, Name: s.Name(), Agent: agent, Path: path}
+		info, err := os.Lstat(path)
+		if err != nil {
+			return err
+		}
+		switch {
+		case info.Mode()&fs.ModeSymlink != 0:
+			if r.Target, err = os.Readlink(path); err != nil {
+				return err
+			}
+		case info.Mode().IsRegular():
+			if r.Hash, err = hashFile(path); err != nil {
+				return err
+			}
+		case projector.IsSkillCopy(path):
+			if r.Hash, err = hashCopy(path); err != nil {
+				return err
+			}
+		}
+		inst.record(KindSkill, s.Name(), agent, r)
+	}
+	return nil
+}
+
+// recordMCPServers records the entries of servers in agent's config with
+// opts. Entries are hashed once the batch writes them (see saveRecords).
+// Entries left to their owner by a conflict resolution aren't apkg's.
+func (inst *Installer) recordMCPServers(agent string, proj projector.Projector, opts projector.ProjectionOpts, servers []mcp.MCPServer) error {
+	if inst.records == nil {
+		return nil
+	}
+	targets, err := proj.Targets(opts)
+	if err != nil {
+		return fmt.Errorf("resolving targets of %s: %w", agent, err)
+	}
+	for _, server := range servers {
+		if targets.MCPConfig == "" {
+			inst.record(KindMCP, server.Name(), agent, nil)
+			continue
+		}
+		pointer := projector.JoinPointer(targets.MCPPointer, inst.mcpEntryName(agent, server.Name()))
+		if opts.Ownership != nil && !opts.Ownership.Owns(targets.MCPConfig, pointer) {
+			inst.record(KindMCP, server.Name(), agent, nil)
+			continue
+		}
+		inst.record(KindMCP, server.Name(), agent, &ProjectionRecord{Kind: KindMCP, Name: server.Name(), Agent: agent, Path: targets.MCPConfig, Pointer: pointer})
+	}
+	return nil
+}
+
+// removeRecorded removes the recorded projections of the package name of
+// kind that removing it for the configured agents left behind, e.g. those
+// of agents since dropped from the config, and forgets them all.
+// Projections changed since apkg made them are left alone, with a
+// warning.
+func (inst *Installer) removeRecorded(kind, name string) error {
+	for _, r := range inst.recorded(kind, name) {
+		state, err := r.state()
+		if err != nil {
+			return err
+		}
+		switch state {
+		case "":
+			if kind == KindSkill {
+				remove := os.Remove
+				if projector.IsSkillCopy(r.Path) {
+					remove = os.RemoveAll
+				}
+				if err := remove(r.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+					return fmt.Errorf("removing %s: %w", r.Path, err)
+				}
+			} else if err := projector.DeleteEntry(inst.projectionOpts(), r.Path, r.Pointer); err != nil {
+				return err
+			}
+		case DriftModified:
+			inst.warn(fmt.Errorf("left %s of %s %q for %s in place: it was changed after apkg projected it", r.location(), kind, name, r.Agent))
+		}
+		inst.record(kind, name, r.Agent, nil)
+	}
+	return nil
+}
+
+// location returns where r is: its path, with the pointer of an entry.
+func (r ProjectionRecord) location() string {
+	if r.Pointer != "" {
+		return r.Path + "#" + r.Pointer
+	}
+	return r.Path
+}
+
+// hashFile returns the sha256 of the file at path.
+func hashFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("hashing %s: %w", path, err)
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// hashCopy returns the hash of the skill directory apkg copied to path,
+// leaving out its projector.CopyMarker.
+func hashCopy(path string) (string, error) {
+	return store.HashTreeFunc(path, func(rel string, d fs.DirEntry) bool {
+		return rel == projector.CopyMarker || fsutil.IsJunk(d)
+	})
+}
+
+// hashJSON returns the sha256 of v as JSON, which marshals object keys in
+// sorted order.
+func hashJSON(v any) string {
+	data, _ := json.Marshal(v)
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// States of projections that changed since apkg made them.
+const (
+	// DriftMissing is a projection that was deleted.
+	DriftMissing = "missing"
+	// DriftBroken is a skill symlink whose target is gone, e.g. a store
+	// entry that was cleaned up.
+	DriftBroken = "broken"
+	// DriftModified is a projection that was edited or replaced.
+	DriftModified = "modified"
+)
+
+// ProjectionDrift is a recorded projection that no longer is as apkg
+// made it.
+type ProjectionDrift struct {
+	ProjectionRecord
+	State string `json:"state"`
+}
+
+// ProjectionDrift compares the projections recorded in StatePath with the
+// agent configs and skills directories, returning those that were
+// deleted, broken, or changed out-of-band, sorted by kind, name, and
+// agent.
+func (inst *Installer) ProjectionDrift() ([]ProjectionDrift, error) {
+	if inst.StatePath == "" {
+		return nil, nil
+	}
+	records, err := LoadProjectionRecords(inst.StatePath, inst.projectID())
+	if err != nil {
+		return nil, err
+	}
+	var drift []ProjectionDrift
+	for _, r := range records {
+		state, err := r.state()
+		if err != nil {
+			return nil, err
+		}
+		if state != "" {
+			drift = append(drift, ProjectionDrift{ProjectionRecord: r, State: state})
+		}
+	}
+	sort.SliceStable(drift, func(i, j int) bool {
+		a, b := drift[i], drift[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Agent < b.Agent
+	})
+	return drift, nil
+}
+
+// state returns how the projection r differs from what apkg recorded:
+// one of the Drift states, or "" if it doesn't.
+func (r ProjectionRecord) state() (string, error) {
+	if r.Kind == KindMCP {
+		entry, ok, err := projector.Entry(r.Path, r.Pointer)
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case !ok:
+			return DriftMissing, nil
+		case r.Hash != "" && hashJSON(entry) != r.Hash:
+			return DriftModified, nil
+		}
+		return "", nil
+	}
+
+	info, err := os.Lstat(r.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return DriftMissing, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case r.Target != "":
+		if info.Mode()&fs.ModeSymlink == 0 {
+			return DriftModified, nil
+		}
+		target, err := os.Readlink(r.Path)
+		if err != nil {
+			return "", err
+		}
+		if target != r.Target {
+			return DriftModified, nil
+		}
+		if _, err := os.Stat(r.Path); errors.Is(err, fs.ErrNotExist) {
+			return DriftBroken, nil
+		}
+	case r.Hash != "":
+		hash := ""
+		switch {
+		case info.Mode().IsRegular():
+			hash, err = hashFile(r.Path)
+		case info.IsDir() && projector.IsSkillCopy(r.Path):
+			hash, err = hashCopy(r.Path)
+		}
+		if err != nil {
+			return "", err
+		}
+		if hash != r.Hash {
+			return DriftModified, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,333 @@
+package installer
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/projector"
+	_ "github.com/agentpkg/agentpkg/pkg/projector/cursor"
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+// recordConfig returns a manifest with the skill my-skill, in a directory
+// of its own, and the MCP server tool.
+func recordConfig(t *testing.T) *config.Config {
+	t.Helper()
+	skillDir := filepath.Join(t.TempDir(), "my-skill")
+	writeSkill(t, skillDir, "my-skill")
+	return &config.Config{
+		Skills: map[string]config.SkillSource{"my-skill": {Path: skillDir}},
+		MCPServers: map[string]config.MCPSource{
+			"tool": {
+				Transport:               "stdio",
+				UnmanagedStdioMCPConfig: &config.UnmanagedStdioMCPConfig{Command: "/bin/sh"},
+			},
+		},
+	}
+}
+
+// editMCPConfig applies edit to the mcpServers object of the project's
+// .cursor/mcp.json.
+func editMCPConfig(t *testing.T, projectDir string, edit func(servers map[string]any)) {
+	t.Helper()
+	path := filepath.Join(projectDir, ".cursor", "mcp.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	edit(doc["mcpServers"].(map[string]any))
+	if data, err = json.Marshal(doc); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProjectionDrift(t *testing.T) {
+	link := func(projectDir string) string {
+		return filepath.Join(projectDir, ".cursor", "skills", "my-skill")
+	}
+
+	tests := map[string]struct {
+		change func(t *testing.T, projectDir string)
+		want   []string // kind/name/state
+	}{
+		"unchanged": {},
+		"skill link deleted": {
+			change: func(t *testing.T, projectDir string) {
+				os.Remove(link(projectDir))
+			},
+			want: []string{"skill/my-skill/" + DriftMissing},
+		},
+		"skill link retargeted": {
+			change: func(t *testing.T, projectDir string) {
+				os.Remove(link(projectDir))
+				if err := os.Symlink(t.TempDir(), link(projectDir)); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: []string{"skill/my-skill/" + DriftModified},
+		},
+		"skill link target gone": {
+			change: func(t *testing.T, projectDir string) {
+				target, err := filepath.EvalSymlinks(link(projectDir))
+				if err != nil {
+					t.Fatal(err)
+				}
+				os.Chmod(target, 0o755)
+				if err := os.RemoveAll(target); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: []string{"skill/my-skill/" + DriftBroken},
+		},
+		"MCP entry edited": {
+			change: func(t *testing.T, projectDir string) {
+				editMCPConfig(t, projectDir, func(servers map[string]any) {
+					servers["tool"].(map[string]any)["command"] = "/bin/bash"
+				})
+			},
+			want: []string{"mcp/tool/" + DriftModified},
+		},
+		"MCP entry deleted": {
+			change: func(t *testing.T, projectDir string) {
+				editMCPConfig(t, projectDir, func(servers map[string]any) {
+					delete(servers, "tool")
+				})
+			},
+			want: []string{"mcp/tool/" + DriftMissing},
+		},
+		"unrelated MCP entry added": {
+			change: func(t *testing.T, projectDir string) {
+				editMCPConfig(t, projectDir, func(servers map[string]any) {
+					servers["other"] = map[string]any{"command": "other"}
+				})
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			projectDir := t.TempDir()
+			inst := &Installer{
+				Store:      store.New(t.TempDir()),
+				ProjectDir: projectDir,
+				Agents:     []string{"cursor"},
+				StatePath:  filepath.Join(t.TempDir(), projector.StateFileName),
+			}
+			if _, err := inst.InstallAll(context.Background(), recordConfig(t), nil); err != nil {
+				t.Fatalf("InstallAll() error = %v", err)
+			}
+			if tc.change != nil {
+				tc.change(t, projectDir)
+			}
+
+			drift, err := inst.ProjectionDrift()
+			if err != nil {
+				t.Fatalf("ProjectionDrift() error = %v", err)
+			}
+			var got []string
+			for _, d := range drift {
+				if d.Agent != "cursor" {
+					t.Errorf("drift of agent %q, want cursor", d.Agent)
+				}
+				got = append(got, d.Kind+"/"+d.Name+"/"+d.State)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("ProjectionDrift() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestProjectionRecordsPerProject(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), projector.StateFileName)
+	cfg := recordConfig(t)
+	projects := []string{t.TempDir(), t.TempDir()}
+	for _, projectDir := range projects {
+		inst := &Installer{
+			Store:      store.New(t.TempDir()),
+			ProjectDir: projectDir,
+			Agents:     []string{"cursor"},
+			StatePath:  statePath,
+		}
+		if _, err := inst.InstallAll(context.Background(), cfg, nil); err != nil {
+			t.Fatalf("InstallAll() error = %v", err)
+		}
+	}
+
+	// Each project keeps its own records, next to the entries apkg owns.
+	for _, projectDir := range projects {
+		records, err := LoadProjectionRecords(statePath, projectDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != 2 {
+			t.Fatalf("records of %s = %v, want the skill and the MCP server", projectDir, records)
+		}
+		for _, r := range records {
+			if !strings.HasPrefix(r.Path, projectDir) {
+				t.Errorf("record of %s has path %s", projectDir, r.Path)
+			}
+		}
+		ownership, err := projector.LoadOwnership(statePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ownership.Owns(filepath.Join(projectDir, ".cursor", "mcp.json"), "/mcpServers/tool") {
+			t.Errorf("ownership of %s's tool entry lost", projectDir)
+		}
+	}
+	if records, err := LoadProjectionRecords(statePath, ""); err != nil || len(records) != 0 {
+		t.Errorf("global records = %v, %v, want none", records, err)
+	}
+}
+
+func TestRemoveRecorded(t *testing.T) {
+	tests := map[string]struct {
+		modify   bool // edit the leftover entry before removing
+		wantKept bool
+	}{
+		"removes leftovers of dropped agents": {},
+		"keeps modified leftovers":            {modify: true, wantKept: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			projectDir := t.TempDir()
+			cfg := recordConfig(t)
+			inst := &Installer{
+				Store:      store.New(t.TempDir()),
+				ProjectDir: projectDir,
+				Agents:     []string{"cursor", "test-skills-only"},
+				StatePath:  filepath.Join(t.TempDir(), projector.StateFileName),
+			}
+			if _, err := inst.InstallAll(context.Background(), cfg, nil); err != nil {
+				t.Fatalf("InstallAll() error = %v", err)
+			}
+			if tc.modify {
+				editMCPConfig(t, projectDir, func(servers map[string]any) {
+					servers["tool"].(map[string]any)["command"] = "/bin/bash"
+				})
+			}
+
+			// cursor is dropped from the config before removing.
+			var warnings []error
+			inst.Agents = []string{"test-skills-only"}
+			inst.Warn = func(err error) { warnings = append(warnings, err) }
+			if err := inst.RemoveSkill("my-skill", ""); err != nil {
+				t.Fatalf("RemoveSkill() error = %v", err)
+			}
+			if err := inst.RemoveMCP("tool"); err != nil {
+				t.Fatalf("RemoveMCP() error = %v", err)
+			}
+
+			if _, err := os.Lstat(filepath.Join(projectDir, ".cursor", "skills", "my-skill")); !os.IsNotExist(err) {
+				t.Errorf("cursor skill link still exists (err = %v)", err)
+			}
+			var kept bool
+			editMCPConfig(t, projectDir, func(servers map[string]any) {
+				_, kept = servers["tool"]
+			})
+			if kept != tc.wantKept {
+				t.Errorf("cursor MCP entry kept = %v, want %v", kept, tc.wantKept)
+			}
+			if (len(warnings) > 0) != tc.wantKept {
+				t.Errorf("warnings = %v", warnings)
+			}
+
+			records, err := LoadProjectionRecords(inst.StatePath, projectDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != 0 {
+				t.Errorf("records after removal = %v, want none", records)
+			}
+		})
+	}
+}
+
+func TestProjectionModes(t *testing.T) {
+	tests := map[string]struct {
+		edit      bool // edit the cursor copy after installing
+		wantDrift []string
+	}{
+		"unchanged copy": {},
+		"copy edited":    {edit: true, wantDrift: []string{"skill/my-skill/" + DriftModified}},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			projectDir := t.TempDir()
+			cfg := recordConfig(t)
+			inst := &Installer{
+				Store:           store.New(t.TempDir()),
+				ProjectDir:      projectDir,
+				Agents:          []string{"cursor", "test-skills-only"},
+				ProjectionModes: map[string]string{"cursor": config.ProjectionModeCopy},
+				StatePath:       filepath.Join(t.TempDir(), projector.StateFileName),
+			}
+			if _, err := inst.InstallAll(context.Background(), cfg, nil); err != nil {
+				t.Fatalf("InstallAll() error = %v", err)
+			}
+
+			copied := filepath.Join(projectDir, ".cursor", "skills", "my-skill")
+			linked := filepath.Join(projectDir, ".test", "skills", "my-skill")
+			if info, err := os.Lstat(copied); err != nil || !info.IsDir() {
+				t.Fatalf("cursor skill is not a directory (err = %v)", err)
+			}
+			if info, err := os.Lstat(linked); err != nil || info.Mode()&os.ModeSymlink == 0 {
+				t.Fatalf("test-skills-only skill is not a symlink (err = %v)", err)
+			}
+
+			// Installing again brings the copy in line with the source.
+			source := cfg.Skills["my-skill"].Path
+			if err := os.WriteFile(filepath.Join(source, "notes.md"), []byte("new"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := inst.InstallAll(context.Background(), cfg, nil); err != nil {
+				t.Fatalf("InstallAll() again error = %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(copied, "notes.md")); err != nil {
+				t.Errorf("copy not refreshed: %v", err)
+			}
+
+			if tc.edit {
+				if err := os.WriteFile(filepath.Join(copied, "SKILL.md"), []byte("edited"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			drift, err := inst.ProjectionDrift()
+			if err != nil {
+				t.Fatalf("ProjectionDrift() error = %v", err)
+			}
+			var got []string
+			for _, d := range drift {
+				got = append(got, d.Kind+"/"+d.Name+"/"+d.State)
+			}
+			if !slices.Equal(got, tc.wantDrift) {
+				t.Errorf("ProjectionDrift() = %v, want %v", got, tc.wantDrift)
+			}
+
+			if err := inst.RemoveSkill("my-skill", ""); err != nil {
+				t.Fatalf("RemoveSkill() error = %v", err)
+			}
+			for _, path := range []string{copied, linked} {
+				if _, err := os.Lstat(path); !os.IsNotExist(err) {
+					t.Errorf("%s still exists after RemoveSkill (err = %v)", path, err)
+				}
+			}
+		})
+	}
+}
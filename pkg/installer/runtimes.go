@@ -0,0 +1,96 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/runtimes"
+)
+
+// InstallRuntimes installs the runtimes pinned in the manifest (see
+// config.RuntimesConfig) and runs the managed npm and uv servers fetched
+// afterwards on them. A runtime whose lock entry was resolved from the
+// same pinned version is installed at the locked release, which must
+// still match the locked integrity. Returns the lock entries of the
+// installed runtimes.
+//
+// Servers of runtimes the manifest doesn't pin run on the project's
+// toolchain (see useProjectToolchains) if it pins one.
+func (inst *Installer) InstallRuntimes(ctx context.Context, rc *config.RuntimesConfig, locked []config.RuntimeLockEntry) ([]config.RuntimeLockEntry, error) {
+	lockIndex := make(map[string]config.RuntimeLockEntry, len(locked))
+	for _, entry := range locked {
+		lockIndex[entry.Name] = entry
+	}
+
+	versions := rc.Versions()
+	var entries []config.RuntimeLockEntry
+	for _, name := range runtimes.Names {
+		version, ok := versions[name]
+		if !ok {
+			continue
+		}
+
+		want := version
+		entry, isLocked := lockIndex[name]
+		isLocked = isLocked && entry.Version == version && entry.ResolvedVersion != ""
+		if isLocked {
+			want = entry.ResolvedVersion
+		}
+
+		var rt *runtimes.Runtime
+		err := inst.withTimeout(ctx, "", func(ctx context.Context) error {
+			var err error
+			rt, err = runtimes.Install(ctx, inst.Store, name, want)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("installing %s runtime: %w", name, err)
+		}
+		if isLocked && entry.Integrity != "" && rt.Integrity != entry.Integrity {
+			return nil, fmt.Errorf("%s runtime %s has integrity %s, but the lockfile has %s", name, rt.Version, rt.Integrity, entry.Integrity)
+		}
+
+		if inst.runtimes == nil {
+			inst.runtimes = make(map[string]*runtimes.Runtime)
+		}
+		inst.runtimes[name] = rt
+
+		entries = append(entries, config.RuntimeLockEntry{
+			Name:            name,
+			Version:         version,
+			ResolvedVersion: rt.Version,
+			Integrity:       rt.Integrity,
+		})
+	}
+	inst.useProjectToolchains(ctx)
+	return entries, nil
+}
+
+// useProjectToolchains runs the managed servers of runtimes the manifest
+// doesn't pin on the ones the project pins for mise or asdf (see
+// runtimes.ProjectToolchain), rather than on whatever is first on PATH.
+// Toolchains that are pinned but can't be located are warned about, and
+// the servers fall back to PATH.
+func (inst *Installer) useProjectToolchains(ctx context.Context) {
+	if inst.Global || inst.ProjectDir == "" {
+		return
+	}
+	for _, name := range runtimes.ToolchainNames {
+		if inst.runtimes[name] != nil {
+			continue
+		}
+		rt, err := runtimes.ProjectToolchain(ctx, inst.ProjectDir, name)
+		if err != nil {
+			inst.warn(fmt.Errorf("using the %s on PATH: %w", name, err))
+			continue
+		}
+		if rt == nil {
+			continue
+		}
+		if inst.runtimes == nil {
+			inst.runtimes = make(map[string]*runtimes.Runtime)
+		}
+		inst.runtimes[name] = rt
+	}
+}
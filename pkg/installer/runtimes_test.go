@@ -0,0 +1,55 @@
+package installer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+func TestInstallRuntimes(t *testing.T) {
+	tests := map[string]struct {
+		rc      *config.RuntimesConfig
+		locked  []config.RuntimeLockEntry
+		wantErr string
+	}{
+		"no runtimes pinned": {},
+		"empty runtimes table": {
+			rc: &config.RuntimesConfig{},
+		},
+		"stale lock entries are dropped": {
+			locked: []config.RuntimeLockEntry{{Name: "node", Version: "22", ResolvedVersion: "22.11.0"}},
+		},
+		"invalid version": {
+			rc:      &config.RuntimesConfig{Node: "latest"},
+			wantErr: `installing node runtime: node runtime: invalid version "latest"`,
+		},
+		"invalid locked version": {
+			rc:      &config.RuntimesConfig{Python: "3.12"},
+			locked:  []config.RuntimeLockEntry{{Name: "python", Version: "3.12", ResolvedVersion: "3.12.x"}},
+			wantErr: `invalid version "3.12.x"`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			inst := &Installer{Store: store.New(t.TempDir())}
+
+			entries, err := inst.InstallRuntimes(context.Background(), tc.rc, tc.locked)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("InstallRuntimes() error = %v, want containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("InstallRuntimes() error = %v", err)
+			}
+			if len(entries) != 0 || len(inst.runtimes) != 0 {
+				t.Errorf("InstallRuntimes() = %v, want no runtimes", entries)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package installer
+
+import (
+	"os"
+	"slices"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/source"
+)
+
+// Package states reported by Status.
+const (
+	// StateInstalled means the package is locked and its store entry exists.
+	StateInstalled = "installed"
+	// StateNotInstalled means the package has no lockfile entry yet, e.g.
+	// it was added to apkg.toml by hand.
+	StateNotInstalled = "not-installed"
+	// StateMissing means the package is locked but its store entry was
+	// deleted, so `apkg install` has to fetch it again.
+	StateMissing = "missing"
+	// StateChanged means the package is locked, but its manifest entry
+	// changed since: a git skill's ref, an archive skill's checksum, or
+	// an MCP server's config, so `apkg install` has to resolve it anew.
+	StateChanged = "changed"
+)
+
+// PackageStatus is the install state of a manifest entry.
+type PackageStatus struct {
+	Kind  string `json:"kind"` // KindSkill or KindMCP
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+// Status compares a manifest with its lockfile and the store.
+type Status struct {
+	// Packages lists every manifest entry, sorted by kind and name.
+	Packages []PackageStatus `json:"packages"`
+	// Orphaned lists lockfile entries that no manifest entry refers to:
+	// skill sources (git URL and path, or local path) and MCP server names.
+	Orphaned []string `json:"orphaned,omitempty"`
+}
+
+// Status reports the install state of every package in cfg, and the
+// entries of lf left behind by packages removed from cfg.
+func (inst *Installer) Status(cfg *config.Config, lf *config.LockFile) *Status {
+	if lf == nil {
+		lf = &config.LockFile{}
+	}
+	lockIndex := buildLockIndex(cfg, lf)
+	mcpIndex := buildMCPLockIndex(lf)
+
+	status := &Status{}
+	for _, node := range inst.Tree(cfg, lf) {
+		var locked, changed bool
+		if node.Kind == KindSkill {
+			ss := cfg.Skills[node.Name]
+			var entry config.SkillLockEntry
+			entry, locked = lockIndex[lockKey(node.Name, ss)]
+			changed = skillChanged(ss, entry)
+		} else {
+			var entry config.MCPLockEntry
+			entry, locked = mcpIndex[node.Name]
+			changed = inst.mcpChanged(node.Name, cfg.MCPServers[node.Name], entry)
+		}
+
+		state := StateInstalled
+		switch {
+		case !locked:
+			state = StateNotInstalled
+		case changed:
+			state = StateChanged
+		case node.StorePath != "" && !exists(node.StorePath):
+			state = StateMissing
+		}
+		status.Packages = append(status.Packages, PackageStatus{Kind: node.Kind, Name: node.Name, State: state})
+	}
+
+	declared := make(map[string]bool)
+	for name, ss := range cfg.Skills {
+		declared[lockKey(name, ss)] = true
+	}
+	for _, entry := range lf.Skills {
+		if !declared[lockKeyFromEntry(entry)] {
+			status.Orphaned = append(status.Orphaned, skillSourceString(config.SkillSource{Git: entry.Git, URL: entry.URL, Path: entry.Path}))
+		}
+	}
+	for _, entry := range lf.MCPServers {
+		if _, ok := cfg.MCPServers[entry.Name]; !ok {
+			status.Orphaned = append(status.Orphaned, entry.Name)
+		}
+	}
+
+	return status
+}
+
+// skillChanged reports whether the manifest entry ss of a skill no longer
+// matches the entry locked for it from the same source.
+func skillChanged(ss config.SkillSource, entry config.SkillLockEntry) bool {
+	switch {
+	case ss.Git != "":
+		return ss.Ref != entry.Ref
+	case ss.URL != "":
+		return ss.SHA256 != "" && ss.SHA256 != entry.SHA256
+	default:
+		return false
+	}
+}
+
+// mcpChanged reports whether the manifest entry ms of an MCP server, with
+// inst.EnvSet applied, no longer matches the config locked for it.
+// Lockfiles written before config digests only have their keys compared.
+func (inst *Installer) mcpChanged(name string, ms config.MCPSource, entry config.MCPLockEntry) bool {
+	resolved, applied, err := ms.WithEnvSet(inst.EnvSet)
+	if err != nil {
+		return true
+	}
+	want := NewMCPLockEntry(name, resolved, &source.ResolvedSource{})
+	if applied {
+		want.EnvSet = inst.EnvSet
+	}
+	if entry.ConfigDigest == "" {
+		want.ConfigDigest = ""
+	}
+	return want.Transport != entry.Transport ||
+		want.Package != entry.Package ||
+		want.Image != entry.Image ||
+		want.Port != entry.Port ||
+		want.URL != entry.URL ||
+		want.Command != entry.Command ||
+		!slices.Equal(want.Args, entry.Args) ||
+		!slices.Equal(want.EnvKeys, entry.EnvKeys) ||
+		!slices.Equal(want.HeaderKeys, entry.HeaderKeys) ||
+		want.EnvSet != entry.EnvSet ||
+		want.ConfigDigest != entry.ConfigDigest
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
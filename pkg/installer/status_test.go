@@ -0,0 +1,80 @@
+package installer
+
+import (
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/source"
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+func TestStatus(t *testing.T) {
+	const repo = "https://github.com/org/skills.git"
+	s := store.New(t.TempDir())
+	inst := &Installer{Store: s}
+
+	segs, err := source.GitStoreSegments(repo, "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(s.Path(segs...), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{
+		Skills: map[string]config.SkillSource{
+			"pdf":  {Git: repo, Path: "pdf", Ref: "main"},
+			"docx": {Git: repo, Path: "docx", Ref: "main"},
+			"xlsx": {Git: repo, Path: "xlsx", Ref: "main"},
+			"csv":  {Git: repo, Path: "csv", Ref: "v2"},
+		},
+		MCPServers: map[string]config.MCPSource{
+			"fs": {Transport: "stdio", UnmanagedStdioMCPConfig: &config.UnmanagedStdioMCPConfig{Command: "fs-server"}},
+			"gh": {Transport: "stdio", UnmanagedStdioMCPConfig: &config.UnmanagedStdioMCPConfig{Command: "gh-server"}},
+		},
+	}
+	lf := &config.LockFile{
+		Skills: []config.SkillLockEntry{
+			{Git: repo, Path: "pdf", Ref: "main", Commit: "c1"},
+			{Git: repo, Path: "docx", Ref: "main", Commit: "c2"},
+			{Git: repo, Path: "csv", Ref: "v1", Commit: "c1"},
+			{Git: repo, Path: "pptx", Commit: "c1"},
+		},
+		MCPServers: []config.MCPLockEntry{
+			{Name: "fs", Transport: "stdio", Command: "fs-server"},
+			{Name: "gh", Transport: "stdio", Command: "old-gh-server"},
+			{Name: "stale"},
+		},
+	}
+
+	status := inst.Status(cfg, lf)
+
+	tests := map[string]struct {
+		wantState string
+	}{
+		"pdf":  {wantState: StateInstalled},
+		"docx": {wantState: StateMissing},
+		"xlsx": {wantState: StateNotInstalled},
+		"csv":  {wantState: StateChanged},
+		"fs":   {wantState: StateInstalled},
+		"gh":   {wantState: StateChanged},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			idx := slices.IndexFunc(status.Packages, func(p PackageStatus) bool { return p.Name == name })
+			if idx < 0 {
+				t.Fatalf("Status() has no entry for %q", name)
+			}
+			if got := status.Packages[idx].State; got != tc.wantState {
+				t.Errorf("state = %q, want %q", got, tc.wantState)
+			}
+		})
+	}
+
+	if want := []string{repo + "//pptx", "stale"}; !slices.Equal(status.Orphaned, want) {
+		t.Errorf("Orphaned = %v, want %v", status.Orphaned, want)
+	}
+}
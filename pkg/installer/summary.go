@@ -0,0 +1,96 @@
+package installer
+
+import (
+	"slices"
+	"time"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/projector"
+)
+
+// Statuses of a PackageResult, relative to the existing lockfile.
+const (
+	StatusNew       = "new"
+	StatusUpdated   = "updated"
+	StatusUnchanged = "unchanged"
+)
+
+// PackageResult is the outcome of installing one package with InstallAll.
+type PackageResult struct {
+	Kind string
+	// Name is the package's key in the manifest.
+	Name string
+	// Version is the commit, package version, or image digest the package
+	// resolved to, or "" for packages that have none (e.g. local skills).
+	Version string
+	Status  string
+	// Agents are the agents the package was projected to.
+	Agents []string
+	// Duration is the time taken to fetch and load the package.
+	Duration time.Duration
+}
+
+// report passes results to Installer.Report, filling in the agents each
+// package was projected to.
+func (inst *Installer) report(results []PackageResult) {
+	if inst.Report == nil {
+		return
+	}
+	for _, r := range results {
+		r.Agents = inst.projectedAgents(r.Kind, r.Name)
+		inst.Report(r)
+	}
+}
+
+// projectedAgents returns the agents a package of the given kind is
+// projected to.
+func (inst *Installer) projectedAgents(kind, name string) []string {
+	if kind == KindMCP && !inst.Global && slices.Contains(inst.DeferredServers, name) {
+		return nil
+	}
+	var agents []string
+	for _, agent := range inst.Agents {
+		proj, ok := projector.GetProjector(agent)
+		if !ok {
+			continue
+		}
+		if kind == KindSkill && proj.SupportsSkills() || kind == KindMCP && proj.SupportsMCPServers() {
+			agents = append(agents, agent)
+		}
+	}
+	return agents
+}
+
+// skillStatus compares a skill's new lock entry with the one it had, if
+// any.
+func skillStatus(prev config.SkillLockEntry, locked bool, entry config.SkillLockEntry) string {
+	switch {
+	case !locked:
+		return StatusNew
+	case prev.Commit != entry.Commit || prev.Integrity != entry.Integrity:
+		return StatusUpdated
+	default:
+		return StatusUnchanged
+	}
+}
+
+// mcpStatus compares an MCP server's new lock entry with the one it had,
+// if any.
+func mcpStatus(prev config.MCPLockEntry, locked bool, entry config.MCPLockEntry) string {
+	switch {
+	case !locked:
+		return StatusNew
+	case prev.InstallPath != entry.InstallPath || prev.Digest != entry.Digest || prev.Integrity != entry.Integrity:
+		return StatusUpdated
+	default:
+		return StatusUnchanged
+	}
+}
+
+// mcpVersion returns the version an MCP server's lock entry resolved to.
+func mcpVersion(entry config.MCPLockEntry) string {
+	if entry.ResolvedVersion != "" {
+		return entry.ResolvedVersion
+	}
+	return entry.Digest
+}
@@ -0,0 +1,91 @@
+package installer
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+func TestInstallAllReport(t *testing.T) {
+	tests := map[string]struct {
+		// change edits the lockfile of a first install before the
+		// reported one, or the first install is skipped when nil.
+		change     func(lf *config.LockFile)
+		wantStatus string
+	}{
+		"first install": {
+			wantStatus: StatusNew,
+		},
+		"reinstall": {
+			change:     func(lf *config.LockFile) {},
+			wantStatus: StatusUnchanged,
+		},
+		"locked content differs": {
+			change: func(lf *config.LockFile) {
+				lf.Skills[0].Integrity = "sha256-old"
+			},
+			wantStatus: StatusUpdated,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			skillDir := t.TempDir()
+			writeSkill(t, skillDir, "my-skill")
+			cfg := &config.Config{
+				Skills: map[string]config.SkillSource{"my-skill": {Path: skillDir}},
+				MCPServers: map[string]config.MCPSource{
+					"tool": {
+						Transport:               "stdio",
+						UnmanagedStdioMCPConfig: &config.UnmanagedStdioMCPConfig{Command: "/bin/sh"},
+					},
+				},
+			}
+
+			inst := &Installer{
+				Store:      store.New(t.TempDir()),
+				ProjectDir: t.TempDir(),
+				Agents:     []string{"test-skills-only"},
+			}
+
+			var existing *config.LockFile
+			if tc.change != nil {
+				var err error
+				existing, err = inst.InstallAll(context.Background(), cfg, nil)
+				if err != nil {
+					t.Fatalf("first InstallAll() error = %v", err)
+				}
+				tc.change(existing)
+			}
+
+			var results []PackageResult
+			inst.Report = func(r PackageResult) { results = append(results, r) }
+			if _, err := inst.InstallAll(context.Background(), cfg, existing); err != nil {
+				t.Fatalf("InstallAll() error = %v", err)
+			}
+
+			if len(results) != 2 {
+				t.Fatalf("got %d results, want 2", len(results))
+			}
+			skillResult, serverResult := results[0], results[1]
+			if skillResult.Kind != KindSkill || skillResult.Name != "my-skill" {
+				t.Errorf("results[0] = %s %q, want skill %q", skillResult.Kind, skillResult.Name, "my-skill")
+			}
+			if skillResult.Status != tc.wantStatus {
+				t.Errorf("skill status = %q, want %q", skillResult.Status, tc.wantStatus)
+			}
+			if !slices.Equal(skillResult.Agents, []string{"test-skills-only"}) {
+				t.Errorf("skill agents = %v, want [test-skills-only]", skillResult.Agents)
+			}
+			if serverResult.Kind != KindMCP || serverResult.Name != "tool" {
+				t.Errorf("results[1] = %s %q, want mcp %q", serverResult.Kind, serverResult.Name, "tool")
+			}
+			if len(serverResult.Agents) != 0 {
+				t.Errorf("server agents = %v, want none", serverResult.Agents)
+			}
+		})
+	}
+}
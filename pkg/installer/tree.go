@@ -0,0 +1,167 @@
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/projector"
+)
+
+// TreeNode describes a manifest entry, what it resolved to, and where it is
+// projected.
+type TreeNode struct {
+	Kind string `json:"kind"` // KindSkill or KindMCP
+	Name string `json:"name"`
+
+	// Source is the manifest source (git URL and path, local path, package
+	// spec, image, command, or URL).
+	Source string `json:"source"`
+	// Resolved is the locked commit, version, or image digest, if any.
+	Resolved string `json:"resolved,omitempty"`
+	// Integrity is the locked content hash, if any.
+	Integrity string `json:"integrity,omitempty"`
+	// StorePath is the store entry backing the package, if any.
+	StorePath string `json:"storePath,omitempty"`
+
+	// Agents lists the configured agents the package is projected to.
+	Agents []string `json:"agents,omitempty"`
+	// Projections are the symlinks, rendered files, and agent config
+	// entries projection created for the package, as recorded in the
+	// projection state file, for tooling that works on them directly.
+	Projections []Projection `json:"projections,omitempty"`
+	// SharedWith lists other entries backed by the same store entry, such
+	// as skills from the same repository commit.
+	SharedWith []string `json:"sharedWith,omitempty"`
+}
+
+// Tree returns a node for every skill and MCP server in cfg, sorted by kind
+// and name, using lf for resolved versions and store paths.
+func (inst *Installer) Tree(cfg *config.Config, lf *config.LockFile) []TreeNode {
+	lockIndex := buildLockIndex(cfg, lf)
+	mcpIndex := make(map[string]config.MCPLockEntry)
+	if lf != nil {
+		for _, entry := range lf.MCPServers {
+			mcpIndex[entry.Name] = entry
+		}
+	}
+
+	var records []ProjectionRecord
+	if inst.StatePath != "" {
+		var err error
+		if records, err = LoadProjectionRecords(inst.StatePath, inst.projectID()); err != nil {
+			inst.warn(err)
+		}
+	}
+
+	var nodes []TreeNode
+	for _, name := range sortedNames(cfg.Skills) {
+		ss := cfg.Skills[name]
+		node := TreeNode{
+			Kind:        KindSkill,
+			Name:        name,
+			Source:      skillSourceString(ss),
+			Agents:      inst.agentsSupporting(projector.Projector.SupportsSkills),
+			Projections: recordedProjections(records, KindSkill, name),
+		}
+		if entry, ok := lockIndex[lockKey(name, ss)]; ok {
+			node.Resolved = entry.Commit
+			if entry.SHA256 != "" {
+				node.Resolved = "sha256:" + entry.SHA256
+			}
+			node.Integrity = entry.Integrity
+			if segs := inst.storeEntries(&config.LockFile{Skills: []config.SkillLockEntry{entry}, Dir: inst.ProjectDir}); len(segs) > 0 {
+				node.StorePath = inst.Store.Path(segs[0]...)
+			}
+			// Local skills are only copied if they have an ignore file.
+			if isLocalCopy(entry) {
+				if _, err := os.Stat(node.StorePath); err != nil {
+					node.StorePath = ""
+				}
+			}
+		}
+		nodes = append(nodes, node)
+	}
+
+	for _, name := range sortedNames(cfg.MCPServers) {
+		ms := cfg.MCPServers[name]
+		node := TreeNode{
+			Kind:        KindMCP,
+			Name:        name,
+			Source:      mcpSourceString(ms),
+			Agents:      inst.agentsSupporting(projector.Projector.SupportsMCPServers),
+			Projections: recordedProjections(records, KindMCP, name),
+		}
+		if entry, ok := mcpIndex[name]; ok {
+			node.Resolved = entry.Digest
+			node.Integrity = entry.Integrity
+			if len(inst.storeEntries(&config.LockFile{MCPServers: []config.MCPLockEntry{entry}})) > 0 {
+				node.StorePath = entry.InstallPath
+				if entry.Package != "" {
+					// Managed packages are stored at <kind>/<pkg>/<version>.
+					node.Resolved = filepath.Base(entry.InstallPath)
+				}
+			}
+		}
+		nodes = append(nodes, node)
+	}
+
+	byStorePath := make(map[string][]string)
+	for _, node := range nodes {
+		if node.StorePath != "" {
+			byStorePath[node.StorePath] = append(byStorePath[node.StorePath], node.Name)
+		}
+	}
+	for i, node := range nodes {
+		for _, other := range byStorePath[node.StorePath] {
+			if other != node.Name && !slices.Contains(nodes[i].SharedWith, other) {
+				nodes[i].SharedWith = append(nodes[i].SharedWith, other)
+			}
+		}
+	}
+
+	return nodes
+}
+
+// agentsSupporting returns the configured agents whose projector reports
+// support via the given method.
+func (inst *Installer) agentsSupporting(supports func(projector.Projector) bool) []string {
+	var agents []string
+	for _, agent := range inst.Agents {
+		proj, ok := projector.GetProjector(agent)
+		if ok && supports(proj) {
+			agents = append(agents, agent)
+		}
+	}
+	return agents
+}
+
+func skillSourceString(ss config.SkillSource) string {
+	if ss.Remote() == "" {
+		return ss.Path
+	}
+	s := ss.Remote()
+	if ss.Path != "" {
+		s += "//" + ss.Path
+	}
+	if ss.Ref != "" {
+		s += "@" + ss.Ref
+	}
+	return s
+}
+
+func mcpSourceString(ms config.MCPSource) string {
+	switch {
+	case ms.ManagedStdioMCPConfig != nil:
+		return ms.Package
+	case ms.ContainerMCPConfig != nil:
+		return ms.Image
+	case ms.UnmanagedStdioMCPConfig != nil:
+		return ms.Command
+	case ms.ExternalHttpMCPConfig != nil:
+		return ms.URL
+	default:
+		return ms.Transport
+	}
+}
@@ -0,0 +1,140 @@
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+func TestTree(t *testing.T) {
+	const repo = "https://github.com/org/skills.git"
+	root := t.TempDir()
+	projectDir := t.TempDir()
+	statePath := filepath.Join(t.TempDir(), "state.toml")
+	inst := &Installer{Store: store.New(root), ProjectDir: projectDir, StatePath: statePath, Agents: []string{"test-skills-only", "unknown-agent"}}
+
+	// Only pdf and fs were projected by apkg. The docx skill was put in
+	// the skills directory by hand.
+	skillsDir := filepath.Join(projectDir, ".test", "skills")
+	if err := os.MkdirAll(skillsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"pdf", "docx"} {
+		if err := os.Symlink(root, filepath.Join(skillsDir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	configPath := filepath.Join(projectDir, ".test", "mcp.json")
+	err := saveProjectionRecords(statePath, inst.projectID(), []ProjectionRecord{
+		{Kind: KindSkill, Name: "pdf", Agent: "test-skills-only", Path: filepath.Join(skillsDir, "pdf"), Target: root},
+		{Kind: KindMCP, Name: "fs", Agent: "test-mcp", Path: configPath, Pointer: "/mcpServers/fs"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{
+		Skills: map[string]config.SkillSource{
+			"pdf":   {Git: repo, Path: "pdf", Ref: "main"},
+			"docx":  {Git: repo, Path: "docx", Ref: "main"},
+			"local": {Path: "./skills/local"},
+		},
+		MCPServers: map[string]config.MCPSource{
+			"fs": {
+				Transport:             "stdio",
+				ManagedStdioMCPConfig: &config.ManagedStdioMCPConfig{Package: "npm:server-fs"},
+			},
+		},
+	}
+	lf := &config.LockFile{
+		Skills: []config.SkillLockEntry{
+			{Git: repo, Path: "pdf", Commit: "c1", Integrity: "sha256:aaa"},
+			{Git: repo, Path: "docx", Commit: "c1"},
+			{Path: "./skills/local"},
+		},
+		MCPServers: []config.MCPLockEntry{
+			{Name: "fs", Package: "npm:server-fs", Integrity: "sha512-bbb", InstallPath: filepath.Join(root, "npm", "server-fs", "1.2.0")},
+		},
+	}
+
+	nodes := inst.Tree(cfg, lf)
+
+	byName := make(map[string]TreeNode)
+	var names []string
+	for _, node := range nodes {
+		byName[node.Name] = node
+		names = append(names, node.Name)
+	}
+	if want := []string{"docx", "local", "pdf", "fs"}; !slices.Equal(names, want) {
+		t.Fatalf("Tree() names = %v, want %v", names, want)
+	}
+
+	tests := map[string]struct {
+		wantSource    string
+		wantResolved  string
+		wantIntegrity string
+		wantStore     string
+		wantAgents    []string
+		wantShared    []string
+		wantProjected []Projection
+	}{
+		"pdf": {
+			wantSource:    repo + "//pdf@main",
+			wantResolved:  "c1",
+			wantIntegrity: "sha256:aaa",
+			wantStore:     filepath.Join(root, "repos", "github.com", "org", "skills", "c1"),
+			wantAgents:    []string{"test-skills-only"},
+			wantShared:    []string{"docx"},
+			wantProjected: []Projection{{Agent: "test-skills-only", Path: filepath.Join(skillsDir, "pdf"), Target: root}},
+		},
+		"docx": {
+			wantSource:   repo + "//docx@main",
+			wantResolved: "c1",
+			wantStore:    filepath.Join(root, "repos", "github.com", "org", "skills", "c1"),
+			wantAgents:   []string{"test-skills-only"},
+			wantShared:   []string{"pdf"},
+		},
+		"local": {
+			wantSource: "./skills/local",
+			wantAgents: []string{"test-skills-only"},
+		},
+		"fs": {
+			wantSource:    "npm:server-fs",
+			wantResolved:  "1.2.0",
+			wantIntegrity: "sha512-bbb",
+			wantStore:     filepath.Join(root, "npm", "server-fs", "1.2.0"),
+			wantProjected: []Projection{{Agent: "test-mcp", Path: configPath, Pointer: "/mcpServers/fs"}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			node := byName[name]
+			if node.Source != tc.wantSource {
+				t.Errorf("Source = %q, want %q", node.Source, tc.wantSource)
+			}
+			if node.Resolved != tc.wantResolved {
+				t.Errorf("Resolved = %q, want %q", node.Resolved, tc.wantResolved)
+			}
+			if node.Integrity != tc.wantIntegrity {
+				t.Errorf("Integrity = %q, want %q", node.Integrity, tc.wantIntegrity)
+			}
+			if node.StorePath != tc.wantStore {
+				t.Errorf("StorePath = %q, want %q", node.StorePath, tc.wantStore)
+			}
+			if !slices.Equal(node.Agents, tc.wantAgents) {
+				t.Errorf("Agents = %v, want %v", node.Agents, tc.wantAgents)
+			}
+			if !slices.Equal(node.SharedWith, tc.wantShared) {
+				t.Errorf("SharedWith = %v, want %v", node.SharedWith, tc.wantShared)
+			}
+			if !slices.Equal(node.Projections, tc.wantProjected) {
+				t.Errorf("Projections = %v, want %v", node.Projections, tc.wantProjected)
+			}
+		})
+	}
+}
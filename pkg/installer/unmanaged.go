@@ -0,0 +1,159 @@
+package installer
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/projector"
+)
+
+// Unmanaged is a skill or MCP server in an agent's configuration that apkg
+// didn't project, e.g. one added by hand or by another tool.
+type Unmanaged struct {
+	Agent string `json:"agent"`
+	Kind  string `json:"kind"` // KindSkill or KindMCP
+	Name  string `json:"name"`
+	// Path is the entry in the skills directory, or the config file
+	// holding the MCP server.
+	Path string `json:"path"`
+}
+
+// Unmanaged returns the skills and MCP servers in the configurations of
+// the configured agents that apkg doesn't manage, sorted by agent, kind,
+// and name. Skills count as managed if cfg declares them (like Drift),
+// they link into apkg's directories, or apkg rendered them; MCP servers
+// if cfg declares them or apkg owns their entry (see StatePath), which
+// covers the servers of other projects and the global install.
+func (inst *Installer) Unmanaged(cfg *config.Config) ([]Unmanaged, error) {
+	var ownership *projector.Ownership
+	if inst.StatePath != "" {
+		var err error
+		ownership, err = projector.LoadOwnership(inst.StatePath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var found []Unmanaged
+	skills, err := inst.unmanagedSkills(cfg)
+	if err != nil {
+		return nil, err
+	}
+	found = append(found, skills...)
+
+	seen := make(map[string]bool)
+	for _, agent := range inst.Agents {
+		proj, ok := projector.GetProjector(agent)
+		if !ok || !proj.SupportsMCPServers() {
+			continue
+		}
+		targets, err := proj.Targets(inst.mcpProjectionOpts(agent))
+		if err != nil {
+			return nil, fmt.Errorf("resolving targets of %s: %w", agent, err)
+		}
+		key := targets.MCPConfig + "\x00" + targets.MCPPointer
+		if targets.MCPConfig == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		names, err := projector.MCPServerNames(targets.MCPConfig, targets.MCPPointer)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			if _, declared := cfg.MCPServers[name]; declared {
+				continue
+			}
+			if ownership != nil && ownership.Owns(targets.MCPConfig, projector.JoinPointer(targets.MCPPointer, name)) {
+				continue
+			}
+			found = append(found, Unmanaged{Agent: agent, Kind: KindMCP, Name: name, Path: targets.MCPConfig})
+		}
+	}
+
+	slices.SortFunc(found, func(a, b Unmanaged) int {
+		return cmp.Or(cmp.Compare(a.Agent, b.Agent), cmp.Compare(a.Kind, b.Kind), cmp.Compare(a.Name, b.Name))
+	})
+	return found, nil
+}
+
+// unmanagedSkills returns the entries of the agents' skills directories,
+// for both skill scopes, that apkg doesn't manage.
+func (inst *Installer) unmanagedSkills(cfg *config.Config) ([]Unmanaged, error) {
+	owned := inst.ownedDirs()
+	seen := make(map[string]bool)
+	var found []Unmanaged
+	for _, scope := range []string{config.SkillScopeProject, config.SkillScopeUser} {
+		opts, err := inst.skillProjectionOpts(scope)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, agent := range inst.Agents {
+			proj, ok := projector.GetProjector(agent)
+			if !ok || !proj.SupportsSkills() {
+				continue
+			}
+			targets, err := proj.Targets(opts)
+			if err != nil {
+				return nil, fmt.Errorf("resolving targets of %s: %w", agent, err)
+			}
+			if targets.SkillsDir == "" || seen[targets.SkillsDir] {
+				continue
+			}
+			seen[targets.SkillsDir] = true
+
+			entries, err := os.ReadDir(targets.SkillsDir)
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("reading %s: %w", targets.SkillsDir, err)
+			}
+			for _, e := range entries {
+				name := e.Name()
+				if strings.HasPrefix(name, ".") {
+					continue
+				}
+				path := filepath.Join(targets.SkillsDir, name)
+				managed, err := managedSkillEntry(cfg, owned, targets.SkillsDir, e)
+				if err != nil {
+					return nil, err
+				}
+				if !managed {
+					found = append(found, Unmanaged{Agent: agent, Kind: KindSkill, Name: name, Path: path})
+				}
+			}
+		}
+	}
+	return found, nil
+}
+
+// managedSkillEntry reports whether the entry e of the skills directory
+// dir is a skill apkg manages.
+func managedSkillEntry(cfg *config.Config, owned []string, dir string, e os.DirEntry) (bool, error) {
+	for name := range cfg.Skills {
+		if e.Name() == name || strings.HasPrefix(e.Name(), name+".") {
+			return true, nil
+		}
+	}
+
+	path := filepath.Join(dir, e.Name())
+	if e.Type()&os.ModeSymlink != 0 {
+		target, err := linkTarget(dir, path)
+		if err != nil {
+			return false, err
+		}
+		return underAny(target, owned), nil
+	}
+	if e.IsDir() {
+		return projector.IsSkillCopy(path), nil
+	}
+	return projector.IsGenerated(path), nil
+}
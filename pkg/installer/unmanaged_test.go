@@ -0,0 +1,91 @@
+package installer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+func TestUnmanaged(t *testing.T) {
+	skillsDir := t.TempDir()
+	writeSkill(t, filepath.Join(skillsDir, "pdf"), "pdf")
+
+	cfg := &config.Config{
+		Skills: map[string]config.SkillSource{
+			"pdf": {Path: filepath.Join(skillsDir, "pdf")},
+		},
+	}
+
+	tests := map[string]struct {
+		name          string
+		create        func(t *testing.T, path, storeRoot string)
+		wantUnmanaged bool
+	}{
+		"hand-made skill": {
+			name:          "notes",
+			create:        func(t *testing.T, path, _ string) { writeSkill(t, path, "notes") },
+			wantUnmanaged: true,
+		},
+		"link outside apkg directories": {
+			name: "notes",
+			create: func(t *testing.T, path, _ string) {
+				target := t.TempDir()
+				writeSkill(t, target, "notes")
+				if err := os.Symlink(target, path); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantUnmanaged: true,
+		},
+		"undeclared link into store": {
+			name: "docx",
+			create: func(t *testing.T, path, storeRoot string) {
+				if err := os.Symlink(filepath.Join(storeRoot, "skills", "docx"), path); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		"hidden entry": {
+			name:   ".DS_Store",
+			create: func(t *testing.T, path, _ string) { os.WriteFile(path, nil, 0o644) },
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			root := t.TempDir()
+			projectDir := filepath.Join(root, "project")
+			storeRoot := filepath.Join(root, "store")
+			inst := &Installer{
+				Store:      store.New(storeRoot),
+				ProjectDir: projectDir,
+				Agents:     []string{"test-skills-only"},
+			}
+			if _, err := inst.InstallAll(context.Background(), cfg, nil); err != nil {
+				t.Fatalf("InstallAll() error = %v", err)
+			}
+
+			path := filepath.Join(projectDir, ".test", "skills", tc.name)
+			tc.create(t, path, storeRoot)
+
+			got, err := inst.Unmanaged(cfg)
+			if err != nil {
+				t.Fatalf("Unmanaged() error = %v", err)
+			}
+			if !tc.wantUnmanaged {
+				if len(got) != 0 {
+					t.Errorf("Unmanaged() = %v, want none", got)
+				}
+				return
+			}
+			want := Unmanaged{Agent: "test-skills-only", Kind: KindSkill, Name: tc.name, Path: path}
+			if len(got) != 1 || got[0] != want {
+				t.Errorf("Unmanaged() = %v, want [%v]", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/container"
+	"github.com/agentpkg/agentpkg/pkg/fsutil"
+	"github.com/agentpkg/agentpkg/pkg/policy"
+	"github.com/agentpkg/agentpkg/pkg/runtimes"
+	"github.com/agentpkg/agentpkg/pkg/source"
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+// VendorDirName is the project-relative directory that holds vendored
+// package copies.
+var VendorDirName = filepath.Join("vendor", "apkg")
+
+const (
+	vendorSkillsDir = "skills"
+	vendorMCPDir    = "mcp"
+	mcpConfigFile   = "mcp.toml"
+
+	// vendorLockFile records the source, ref, and commit each vendored
+	// skill was copied from, so installs can tell a stale copy.
+	vendorLockFile = "vendor-lock.toml"
+)
+
+// VendorResult summarizes a Vendor run.
+type VendorResult struct {
+	Skills     []string
+	MCPServers []string
+	// Skipped lists MCP servers that were not vendored because their
+	// definition depends on installed package content in the store.
+	Skipped []string
+}
+
+// Vendor fetches every skill in cfg and copies its content (without VCS
+// metadata) into inst.VendorDir/skills/<name>. When withMCP is true, the
+// mcp.toml definition of every MCP server that doesn't depend on installed
+// package content (unmanaged stdio, external HTTP, and container servers)
+// is copied into inst.VendorDir/mcp/<name>, once the Policy hook approved
+// them all. Existing vendored copies are replaced, and what each skill was
+// copied from is recorded in inst.VendorDir/vendor-lock.toml. A subsequent
+// InstallAll with the same VendorDir loads the vendored copies instead of
+// fetching.
+func (inst *Installer) Vendor(ctx context.Context, cfg *config.Config, existing *config.LockFile, withMCP bool) (*VendorResult, error) {
+	if inst.VendorDir == "" {
+		return nil, fmt.Errorf("no vendor directory configured")
+	}
+
+	lockIndex := buildLockIndex(cfg, existing)
+	result := &VendorResult{}
+
+	names := sortedNames(cfg.Skills)
+
+	// Nothing is copied until the policy hook approved every package.
+	var pkgs []policy.Package
+	skills := make([]*source.ResolvedSource, len(names))
+	for i, name := range names {
+		ss := cfg.Skills[name]
+		resolved, err := inst.fetchSkill(ctx, name, ss, lockIndex)
+		if err != nil {
+			return nil, fmt.Errorf("fetching skill %q: %w", name, err)
+		}
+		skills[i] = resolved
+		pkgs = append(pkgs, policy.Package{
+			Kind:      KindSkill,
+			Name:      name,
+			Source:    skillSourceString(ss),
+			Version:   resolved.Commit,
+			Integrity: resolved.Integrity,
+		})
+	}
+
+	var mcpNames []string
+	var servers []*source.ResolvedSource
+	if withMCP {
+		for _, name := range sortedNames(cfg.MCPServers) {
+			ms := cfg.MCPServers[name]
+			if ms.ManagedStdioMCPConfig != nil {
+				result.Skipped = append(result.Skipped, name)
+				continue
+			}
+
+			src, err := source.SourceFromMCPConfig(name, ms)
+			if err != nil {
+				return nil, fmt.Errorf("resolving MCP server %q: %w", name, err)
+			}
+
+			resolved, err := inst.fetch(ctx, src, ms.Timeout)
+			if err != nil {
+				return nil, fmt.Errorf("fetching MCP server %q: %w", name, err)
+			}
+			mcpNames = append(mcpNames, name)
+			servers = append(servers, resolved)
+			pkgs = append(pkgs, sourcePolicyPackage(KindMCP, name, src, resolved))
+		}
+	}
+
+	if err := inst.checkPolicy(ctx, pkgs); err != nil {
+		return nil, err
+	}
+
+	vendored := &config.LockFile{Version: config.LockFileVersion}
+	for i, name := range names {
+		dst := filepath.Join(inst.VendorDir, vendorSkillsDir, name)
+		if err := fsutil.CopyDir(skills[i].Dir, dst, fsutil.SkipJunk); err != nil {
+			return nil, fmt.Errorf("vendoring skill %q: %w", name, err)
+		}
+		// The copy leaves out junk files, so it has an integrity of its
+		// own, which installs compute.
+		entry := lockEntryFromResolved(name, cfg.Skills[name], skills[i])
+		entry.Integrity = ""
+		vendored.Skills = append(vendored.Skills, entry)
+		result.Skills = append(result.Skills, name)
+	}
+	if len(names) > 0 {
+		if err := config.SaveLockFile(filepath.Join(inst.VendorDir, vendorLockFile), vendored); err != nil {
+			return nil, fmt.Errorf("saving %s: %w", vendorLockFile, err)
+		}
+	}
+
+	for i, name := range mcpNames {
+		data, err := os.ReadFile(filepath.Join(servers[i].Dir, mcpConfigFile))
+		if err != nil {
+			return nil, fmt.Errorf("reading definition of MCP server %q: %w", name, err)
+		}
+
+		dir := filepath.Join(inst.VendorDir, vendorMCPDir, name)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("creating %s: %w", dir, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, mcpConfigFile), data, 0o644); err != nil {
+			return nil, fmt.Errorf("vendoring MCP server %q: %w", name, err)
+		}
+		result.MCPServers = append(result.MCPServers, name)
+	}
+
+	return result, nil
+}
+
+// vendoredSkill returns the resolved vendored copy of a skill, or nil if
+// there is none. Vendored content is not re-resolved, so the commit it was
+// copied from is taken from vendor-lock.toml, or for copies vendored
+// before that was written, from the lockfile. A copy of another source or
+// ref than the manifest's is an error: it is stale until vendored again.
+func (inst *Installer) vendoredSkill(name string, ss config.SkillSource, lockIndex map[string]config.SkillLockEntry) (*source.ResolvedSource, error) {
+	if inst.VendorDir == "" {
+		return nil, nil
+	}
+
+	dir := filepath.Join(inst.VendorDir, vendorSkillsDir, name)
+	if !isDir(dir) {
+		return nil, nil
+	}
+
+	integrity, err := store.HashTree(dir)
+	if err != nil {
+		return nil, fmt.Errorf("computing integrity hash: %w", err)
+	}
+
+	resolved := &source.ResolvedSource{
+		Dir:       dir,
+		Ref:       ss.Ref,
+		Integrity: integrity,
+	}
+	entry, ok, err := inst.vendoredSkillEntry(name)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		entry, ok = lockIndex[lockKey(name, ss)]
+	}
+	if ok {
+		if lockKeyFromEntry(entry) != lockKey(name, ss) || entry.Ref != ss.Ref {
+			return nil, fmt.Errorf("vendored copy is of %s, but the manifest asks for %s; run apkg vendor to update it", lockSourceString(entry), skillSourceString(ss))
+		}
+		resolved.Commit = entry.Commit
+		resolved.SHA256 = entry.SHA256
+	}
+	return resolved, nil
+}
+
+// vendoredSkillEntry returns the vendor-lock.toml entry of the named
+// skill, and whether there is one.
+func (inst *Installer) vendoredSkillEntry(name string) (config.SkillLockEntry, bool, error) {
+	lf, err := config.LoadLockFile(filepath.Join(inst.VendorDir, vendorLockFile))
+	if err != nil {
+		return config.SkillLockEntry{}, false, fmt.Errorf("loading %s: %w", vendorLockFile, err)
+	}
+	for _, entry := range lf.Skills {
+		if entry.Name == name {
+			return entry, true, nil
+		}
+	}
+	return config.SkillLockEntry{}, false, nil
+}
+
+// lockSourceString is skillSourceString for the source a lock entry
+// records.
+func lockSourceString(entry config.SkillLockEntry) string {
+	return skillSourceString(config.SkillSource{Git: entry.Git, URL: entry.URL, Path: entry.Path, Ref: entry.Ref})
+}
+
+// vendoredMCP returns the resolved vendored definition of the MCP server
+// name of ms, or nil if there is none. Vendored definitions are shared
+// like store entries, so the node binary and volumes this project runs the
+// server with are resolved here.
+func (inst *Installer) vendoredMCP(name string, ms config.MCPSource) (*source.ResolvedSource, error) {
+	if inst.VendorDir == "" {
+		return nil, nil
+	}
+
+	dir := filepath.Join(inst.VendorDir, vendorMCPDir, name)
+	if _, err := os.Stat(filepath.Join(dir, mcpConfigFile)); err != nil {
+		return nil, nil
+	}
+
+	integrity, err := store.HashTree(dir)
+	if err != nil {
+		return nil, fmt.Errorf("computing integrity hash: %w", err)
+	}
+
+	resolved := &source.ResolvedSource{Dir: dir, Integrity: integrity}
+	if ms.ManagedStdioMCPConfig != nil && strings.HasPrefix(ms.Package, "npm:") {
+		if node := inst.runtimes[runtimes.Node]; node != nil {
+			resolved.Runtime = node.Bin
+		} else if path, err := exec.LookPath("node"); err == nil {
+			resolved.Runtime = path
+		}
+	}
+	if cc := ms.ContainerMCPConfig; cc != nil {
+		// Relative volumes are relative to the project root (see fetch).
+		if resolved.Volumes, err = container.ResolveVolumes(cc.Volumes, inst.ProjectDir, cc.CreateVolumes); err != nil {
+			return nil, fmt.Errorf("invalid volume: %w", err)
+		}
+	}
+	return resolved, nil
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
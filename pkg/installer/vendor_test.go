@@ -0,0 +1,275 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/policy"
+	"github.com/agentpkg/agentpkg/pkg/runtimes"
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+func TestVendor(t *testing.T) {
+	tests := map[string]struct {
+		mcpServers  map[string]config.MCPSource
+		withMCP     bool
+		wantMCP     []string
+		wantSkipped []string
+	}{
+		"skills only": {
+			mcpServers: map[string]config.MCPSource{
+				"tool": {Transport: "stdio", UnmanagedStdioMCPConfig: &config.UnmanagedStdioMCPConfig{Command: "/bin/tool"}},
+			},
+		},
+		"with mcp definitions": {
+			mcpServers: map[string]config.MCPSource{
+				"tool": {Transport: "stdio", UnmanagedStdioMCPConfig: &config.UnmanagedStdioMCPConfig{Command: "/bin/tool"}},
+				"fs":   {Transport: "stdio", ManagedStdioMCPConfig: &config.ManagedStdioMCPConfig{Package: "npm:server-fs"}},
+			},
+			withMCP:     true,
+			wantMCP:     []string{"tool"},
+			wantSkipped: []string{"fs"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			skillDir := t.TempDir()
+			writeSkill(t, skillDir, "my-skill")
+
+			projectDir := t.TempDir()
+			inst := &Installer{
+				Store:      store.New(t.TempDir()),
+				ProjectDir: projectDir,
+				VendorDir:  filepath.Join(projectDir, VendorDirName),
+			}
+
+			cfg := &config.Config{
+				Skills:     map[string]config.SkillSource{"my-skill": {Path: skillDir}},
+				MCPServers: tc.mcpServers,
+			}
+
+			result, err := inst.Vendor(context.Background(), cfg, nil, tc.withMCP)
+			if err != nil {
+				t.Fatalf("Vendor() error: %v", err)
+			}
+
+			if !slicesEqual(result.MCPServers, tc.wantMCP) {
+				t.Errorf("vendored MCP servers = %v, want %v", result.MCPServers, tc.wantMCP)
+			}
+			if !slicesEqual(result.Skipped, tc.wantSkipped) {
+				t.Errorf("skipped MCP servers = %v, want %v", result.Skipped, tc.wantSkipped)
+			}
+
+			// Removing the original source must not break installs from
+			// the vendored copy.
+			os.RemoveAll(skillDir)
+			if !tc.withMCP {
+				cfg.MCPServers = nil
+			} else {
+				delete(cfg.MCPServers, "fs")
+			}
+
+			lf, err := inst.InstallAll(context.Background(), cfg, nil)
+			if err != nil {
+				t.Fatalf("InstallAll() error: %v", err)
+			}
+
+			wantDir := filepath.Join(inst.VendorDir, "skills", "my-skill")
+			if len(lf.Skills) != 1 || lf.Skills[0].Integrity == "" {
+				t.Fatalf("lockfile skills = %+v, want one entry with integrity", lf.Skills)
+			}
+			for _, entry := range lf.MCPServers {
+				if entry.InstallPath != filepath.Join(inst.VendorDir, "mcp", entry.Name) {
+					t.Errorf("MCP server %q install path = %q, want vendored path", entry.Name, entry.InstallPath)
+				}
+			}
+			if _, err := os.Stat(filepath.Join(wantDir, "SKILL.md")); err != nil {
+				t.Errorf("expected vendored SKILL.md: %v", err)
+			}
+		})
+	}
+}
+
+func TestVendorPolicy(t *testing.T) {
+	skillDir := t.TempDir()
+	writeSkill(t, skillDir, "my-skill")
+
+	projectDir := t.TempDir()
+	inst := &Installer{
+		Store:      store.New(t.TempDir()),
+		ProjectDir: projectDir,
+		VendorDir:  filepath.Join(projectDir, VendorDirName),
+		Policy: policyFunc(func(p *policy.Plan) []policy.Decision {
+			var decisions []policy.Decision
+			for _, pkg := range p.Packages {
+				decisions = append(decisions, policy.Decision{Kind: pkg.Kind, Name: pkg.Name, Allow: false})
+			}
+			return decisions
+		}),
+	}
+	cfg := &config.Config{
+		Skills: map[string]config.SkillSource{"my-skill": {Path: skillDir}},
+	}
+
+	_, err := inst.Vendor(context.Background(), cfg, nil, false)
+	var denied *policy.DeniedError
+	if !errors.As(err, &denied) {
+		t.Fatalf("Vendor() error = %v, want denied", err)
+	}
+	// A denied package is never copied into the vendor directory.
+	if _, err := os.Stat(filepath.Join(inst.VendorDir, "skills", "my-skill")); !os.IsNotExist(err) {
+		t.Errorf("denied skill vendored: stat error = %v", err)
+	}
+}
+
+func TestVendoredSkillStale(t *testing.T) {
+	tests := map[string]struct {
+		// moved makes the manifest point at another copy of the skill.
+		moved bool
+		// unrecorded removes vendor-lock.toml, as in vendor trees made
+		// before it was written, leaving the lockfile to go by.
+		unrecorded bool
+		locked     *config.SkillLockEntry
+		wantErr    bool
+	}{
+		"unchanged": {},
+		"source changed": {
+			moved:   true,
+			wantErr: true,
+		},
+		"unrecorded copy": {
+			unrecorded: true,
+		},
+		"unrecorded copy with a changed ref": {
+			unrecorded: true,
+			locked:     &config.SkillLockEntry{Name: "my-skill", Ref: "v1", Commit: "abc123"},
+			wantErr:    true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			skillDir := t.TempDir()
+			writeSkill(t, skillDir, "my-skill")
+
+			projectDir := t.TempDir()
+			inst := &Installer{
+				Store:      store.New(t.TempDir()),
+				ProjectDir: projectDir,
+				VendorDir:  filepath.Join(projectDir, VendorDirName),
+			}
+			cfg := &config.Config{
+				Skills: map[string]config.SkillSource{"my-skill": {Path: skillDir}},
+			}
+			if _, err := inst.Vendor(context.Background(), cfg, nil, false); err != nil {
+				t.Fatalf("Vendor() error: %v", err)
+			}
+
+			if tc.moved {
+				otherDir := t.TempDir()
+				writeSkill(t, otherDir, "my-skill")
+				cfg.Skills["my-skill"] = config.SkillSource{Path: otherDir}
+			}
+			if tc.unrecorded {
+				os.Remove(filepath.Join(inst.VendorDir, vendorLockFile))
+			}
+			existing := &config.LockFile{Version: config.LockFileVersion}
+			if tc.locked != nil {
+				entry := *tc.locked
+				entry.Path = skillDir
+				existing.Skills = append(existing.Skills, entry)
+			}
+
+			_, err := inst.InstallAll(context.Background(), cfg, existing)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("InstallAll() error = %v, wantErr = %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestVendoredMCP(t *testing.T) {
+	tests := map[string]struct {
+		ms          config.MCPSource
+		node        *runtimes.Runtime
+		wantRuntime string
+		// wantVolumes have host paths relative to the project.
+		wantVolumes []string
+		wantErr     bool
+	}{
+		"npm package on the managed node": {
+			ms: config.MCPSource{
+				Transport:             "stdio",
+				ManagedStdioMCPConfig: &config.ManagedStdioMCPConfig{Package: "npm:my-server"},
+			},
+			node:        &runtimes.Runtime{Name: runtimes.Node, Bin: "/runtimes/node/bin/node"},
+			wantRuntime: "/runtimes/node/bin/node",
+		},
+		"container volumes": {
+			ms: config.MCPSource{
+				Transport:          "stdio",
+				ContainerMCPConfig: &config.ContainerMCPConfig{Image: "my-image:1", Volumes: []string{"./data:/data"}, CreateVolumes: true},
+			},
+			wantVolumes: []string{"data:/data"},
+		},
+		"invalid volume": {
+			ms: config.MCPSource{
+				Transport:          "stdio",
+				ContainerMCPConfig: &config.ContainerMCPConfig{Image: "my-image:1", Volumes: []string{"./data:data"}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			projectDir := t.TempDir()
+			inst := &Installer{ProjectDir: projectDir, VendorDir: filepath.Join(projectDir, VendorDirName)}
+			if tc.node != nil {
+				inst.runtimes = map[string]*runtimes.Runtime{runtimes.Node: tc.node}
+			}
+			dir := filepath.Join(inst.VendorDir, vendorMCPDir, "my-server")
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, mcpConfigFile), []byte("name = \"my-server\"\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			resolved, err := inst.vendoredMCP("my-server", tc.ms)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("vendoredMCP() error = %v, wantErr = %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if resolved.Runtime != tc.wantRuntime {
+				t.Errorf("Runtime = %q, want %q", resolved.Runtime, tc.wantRuntime)
+			}
+			var wantVolumes []string
+			for _, v := range tc.wantVolumes {
+				wantVolumes = append(wantVolumes, filepath.Join(projectDir, v))
+			}
+			if !slicesEqual(resolved.Volumes, wantVolumes) {
+				t.Errorf("Volumes = %q, want %q", resolved.Volumes, wantVolumes)
+			}
+		})
+	}
+}
+
+func slicesEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+package installer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/source"
+)
+
+// Verify rehashes the store content of each git skill, skill archive, local
+// skill snapshot, and static or container MCP server locked in lf, bypassing the
+// store's hash cache, and returns an *IntegrityError for each whose
+// content no longer matches the integrity locked for it. Managed servers
+// are skipped, as their locked integrity is the publisher's rather than a
+// hash of the store, and so are entries missing from the store, which the
+// next install fetches.
+func (inst *Installer) Verify(lf *config.LockFile) ([]*IntegrityError, error) {
+	var mismatches []*IntegrityError
+	check := func(kind, name, version, locked string, segs []string) error {
+		exists, err := inst.Store.Exists(segs...)
+		if err != nil || !exists {
+			return err
+		}
+		got, err := inst.Store.RehashDir(segs...)
+		if err != nil {
+			return fmt.Errorf("hashing %s %q: %w", kind, name, err)
+		}
+		if got != locked {
+			mismatches = append(mismatches, &IntegrityError{Kind: kind, Name: name, Version: version, Locked: locked, Got: got})
+		}
+		return nil
+	}
+
+	for _, entry := range lf.Skills {
+		if entry.URL != "" {
+			if entry.SHA256 == "" || entry.Integrity == "" {
+				continue
+			}
+			segs := source.ArchiveStoreSegments(entry.SHA256)
+			if entry.Path != "" {
+				segs = append(segs, strings.Split(entry.Path, "/")...)
+			}
+			if err := check(KindSkill, entry.Name, "archive", entry.Integrity, segs); err != nil {
+				return mismatches, err
+			}
+			continue
+		}
+		if entry.Git == "" && entry.Integrity != "" {
+			// Snapshots of local skills (see config.SkillSource.Snapshot).
+			if err := check(KindSkill, entry.Name, "snapshot", entry.Integrity, source.SnapshotStoreSegments(entry.Integrity)); err != nil {
+				return mismatches, err
+			}
+			continue
+		}
+		if entry.Git == "" || entry.Commit == "" || entry.Integrity == "" {
+			continue
+		}
+		segs, err := source.GitStoreSegments(entry.Git, entry.Commit)
+		if err != nil {
+			continue
+		}
+		if entry.Path != "" {
+			segs = append(segs, strings.Split(entry.Path, "/")...)
+		}
+		name := entry.Name
+		if name == "" {
+			name = entry.Git
+		}
+		if err := check(KindSkill, name, entry.Commit, entry.Integrity, segs); err != nil {
+			return mismatches, err
+		}
+	}
+
+	for _, entry := range lf.MCPServers {
+		if entry.Package != "" || entry.Integrity == "" {
+			continue
+		}
+		segs, ok := inst.mcpStoreSegments(entry)
+		if !ok {
+			continue
+		}
+		if err := check(KindMCP, entry.Name, entry.Digest, entry.Integrity, segs); err != nil {
+			return mismatches, err
+		}
+	}
+	return mismatches, nil
+}
@@ -0,0 +1,76 @@
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+func TestVerify(t *testing.T) {
+	root := t.TempDir()
+	s := store.New(root)
+	inst := &Installer{Store: s}
+
+	mtime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	write := func(rel, content string) {
+		path := filepath.Join(root, rel)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		os.WriteFile(path, []byte(content), 0o644)
+		os.Chtimes(path, mtime, mtime)
+		os.Chtimes(filepath.Dir(path), mtime, mtime)
+	}
+	hash := func(segs ...string) string {
+		h, err := s.HashDir(segs...)
+		if err != nil {
+			t.Fatalf("HashDir(%v) error = %v", segs, err)
+		}
+		return h
+	}
+
+	write("repos/github.com/org/skills/c1/pdf/SKILL.md", "pdf")
+	write("repos/github.com/org/skills/c1/docx/SKILL.md", "docx")
+	write("static/fs/abc/mcp.toml", "command = \"fs\"")
+	write("npm/@mcp/db/1.0.0/index.js", "db")
+
+	lf := &config.LockFile{
+		Skills: []config.SkillLockEntry{
+			{Name: "pdf", Git: "https://github.com/org/skills.git", Path: "pdf", Commit: "c1", Integrity: hash("repos", "github.com", "org", "skills", "c1", "pdf")},
+			{Name: "docx", Git: "https://github.com/org/skills.git", Path: "docx", Commit: "c1", Integrity: hash("repos", "github.com", "org", "skills", "c1", "docx")},
+			// Not in the store: skipped.
+			{Name: "xlsx", Git: "https://github.com/org/skills.git", Path: "xlsx", Commit: "c2", Integrity: "sha256:0"},
+		},
+		MCPServers: []config.MCPLockEntry{
+			{Name: "fs", InstallPath: filepath.Join(root, "static", "fs", "abc"), Integrity: hash("static", "fs", "abc")},
+			// Managed: locked to the publisher's integrity.
+			{Name: "db", Package: "npm:@mcp/db", ResolvedVersion: "1.0.0", InstallPath: filepath.Join(root, "npm", "@mcp", "db", "1.0.0"), Integrity: "sha512-publisher"},
+		},
+	}
+
+	// Tamper with content without changing sizes or modification times,
+	// which the hash cache can't see.
+	write("repos/github.com/org/skills/c1/pdf/SKILL.md", "PDF")
+	write("static/fs/abc/mcp.toml", "command = \"FS\"")
+
+	mismatches, err := inst.Verify(lf)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	got := make(map[string]string)
+	for _, m := range mismatches {
+		got[m.Name] = m.Kind
+	}
+	want := map[string]string{"pdf": KindSkill, "fs": KindMCP}
+	if len(got) != len(want) {
+		t.Fatalf("Verify() mismatches = %v, want %v", got, want)
+	}
+	for name, kind := range want {
+		if got[name] != kind {
+			t.Errorf("Verify() mismatch of %q = %q, want %q", name, got[name], kind)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// Package journal records store and lockfile mutations to an append-only
+// log (~/.apkg/journal.log) so users can find out what changed an agent
+// configuration and when.
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+)
+
+// FileName is the journal file within the apkg config dir.
+const FileName = "journal.log"
+
+// Actions recorded in the journal.
+const (
+	ActionInstall = "install"
+	ActionUpdate  = "update"
+	ActionRemove  = "remove"
+	ActionPurge   = "purge"
+)
+
+// Event is a single journal entry, stored as one JSON object per line.
+type Event struct {
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"`
+	Kind   string    `json:"kind,omitempty"` // "skill" or "mcp"; empty for store entries
+	Name   string    `json:"name"`
+
+	// Source and Version identify the package: a git URL and commit, a
+	// package spec and version, an image and digest, or a store path.
+	Source  string `json:"source,omitempty"`
+	Version string `json:"version,omitempty"`
+
+	// Project is the project directory (or home directory for global
+	// installs), and Command the apkg invocation that caused the change.
+	Project string `json:"project,omitempty"`
+	Command string `json:"command,omitempty"`
+}
+
+// Journal appends events to and reads events from a journal file.
+type Journal struct {
+	Path string
+}
+
+// Default returns the journal in the global apkg config dir.
+func Default() (*Journal, error) {
+	dir, err := config.GlobalConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	return &Journal{Path: filepath.Join(dir, FileName)}, nil
+}
+
+// Append writes events to the end of the journal, each as one line so
+// concurrent apkg processes don't interleave partial entries.
+func (j *Journal) Append(events ...Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	f, err := os.OpenFile(j.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening journal: %w", err)
+	}
+	defer f.Close()
+
+	for _, event := range events {
+		line, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("encoding journal event: %w", err)
+		}
+		if _, err := f.Write(append(line, '\n')); err != nil {
+			return fmt.Errorf("writing journal: %w", err)
+		}
+	}
+	return nil
+}
+
+// Read returns all events in the journal, oldest first. A missing journal
+// has no events; lines that can't be decoded are skipped.
+func (j *Journal) Read() ([]Event, error) {
+	f, err := os.Open(j.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening journal: %w", err)
+	}
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			continue
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading journal: %w", err)
+	}
+	return events, nil
+}
+
+// Projects returns the project directories of the events in the journal,
+// sorted and without duplicates: every project apkg has changed on this
+// machine, including ones since deleted.
+func (j *Journal) Projects() ([]string, error) {
+	events, err := j.Read()
+	if err != nil {
+		return nil, err
+	}
+	var projects []string
+	for _, event := range events {
+		if event.Project != "" {
+			projects = append(projects, event.Project)
+		}
+	}
+	slices.Sort(projects)
+	return slices.Compact(projects), nil
+}
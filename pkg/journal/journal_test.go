@@ -0,0 +1,128 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+)
+
+func TestAppendRead(t *testing.T) {
+	j := &Journal{Path: filepath.Join(t.TempDir(), FileName)}
+
+	events, err := j.Read()
+	if err != nil || len(events) != 0 {
+		t.Fatalf("Read() on missing journal = %v, %v, want no events", events, err)
+	}
+
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	first := Event{Time: now, Action: ActionInstall, Kind: "skill", Name: "pdf", Command: "apkg install"}
+	second := Event{Time: now.Add(time.Hour), Action: ActionRemove, Kind: "mcp", Name: "fs", Command: "apkg remove mcp fs"}
+
+	if err := j.Append(first); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	// Corrupt lines (e.g. from a crash mid-write) are skipped.
+	f, _ := os.OpenFile(j.Path, os.O_APPEND|os.O_WRONLY, 0o644)
+	f.WriteString("{not json\n")
+	f.Close()
+	if err := j.Append(second); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	events, err = j.Read()
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Read() returned %d events, want 2", len(events))
+	}
+	for i, want := range []Event{first, second} {
+		if !events[i].Time.Equal(want.Time) || events[i].Action != want.Action || events[i].Name != want.Name || events[i].Command != want.Command {
+			t.Errorf("event %d = %+v, want %+v", i, events[i], want)
+		}
+	}
+}
+
+func TestProjects(t *testing.T) {
+	j := &Journal{Path: filepath.Join(t.TempDir(), FileName)}
+	if err := j.Append(
+		Event{Action: ActionInstall, Name: "pdf", Project: "/work/b"},
+		Event{Action: ActionPurge, Name: "npm/fs/1.0.0"},
+		Event{Action: ActionInstall, Name: "fs", Project: "/work/a"},
+		Event{Action: ActionRemove, Name: "pdf", Project: "/work/b"},
+	); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	got, err := j.Projects()
+	if err != nil {
+		t.Fatalf("Projects() error = %v", err)
+	}
+	if want := []string{"/work/a", "/work/b"}; !slices.Equal(got, want) {
+		t.Errorf("Projects() = %v, want %v", got, want)
+	}
+}
+
+func TestLockChanges(t *testing.T) {
+	const repo = "https://github.com/org/skills.git"
+	now := time.Now()
+
+	tests := map[string]struct {
+		old  *config.LockFile
+		new  *config.LockFile
+		want []string // action:name
+	}{
+		"fresh install": {
+			new: &config.LockFile{
+				Skills:     []config.SkillLockEntry{{Git: repo, Path: "skills/pdf", Commit: "c1"}},
+				MCPServers: []config.MCPLockEntry{{Name: "fs", Package: "npm:server-fs", InstallPath: "/store/npm/server-fs/1.0.0"}},
+			},
+			want: []string{"install:pdf", "install:fs"},
+		},
+		"unchanged": {
+			old:  &config.LockFile{Skills: []config.SkillLockEntry{{Git: repo, Path: "pdf", Commit: "c1"}}},
+			new:  &config.LockFile{Skills: []config.SkillLockEntry{{Git: repo, Path: "pdf", Commit: "c1"}}},
+			want: nil,
+		},
+		"updated and removed": {
+			old: &config.LockFile{
+				Skills: []config.SkillLockEntry{
+					{Git: repo, Path: "pdf", Commit: "c1"},
+					{Path: "./skills/local"},
+				},
+				MCPServers: []config.MCPLockEntry{{Name: "fs", InstallPath: "/store/npm/server-fs/1.0.0"}},
+			},
+			new: &config.LockFile{
+				Skills:     []config.SkillLockEntry{{Git: repo, Path: "pdf", Commit: "c2"}},
+				MCPServers: []config.MCPLockEntry{{Name: "fs", InstallPath: "/store/npm/server-fs/1.1.0"}},
+			},
+			want: []string{"update:pdf", "remove:local", "update:fs"},
+		},
+		"root skill named after repo": {
+			new:  &config.LockFile{Skills: []config.SkillLockEntry{{Git: repo, Commit: "c1"}}},
+			want: []string{"install:skills"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			events := LockChanges(tc.old, tc.new, now)
+			var got []string
+			for _, e := range events {
+				got = append(got, e.Action+":"+e.Name)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("LockChanges() = %v, want %v", got, tc.want)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Errorf("LockChanges()[%d] = %q, want %q", i, got[i], tc.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package journal
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+)
+
+// LockChanges returns the install, update, and remove events that turn
+// old into new. Skills are matched by git URL and path, MCP servers by
+// name. Either lockfile may be nil.
+func LockChanges(old, new *config.LockFile, now time.Time) []Event {
+	if old == nil {
+		old = &config.LockFile{}
+	}
+	if new == nil {
+		new = &config.LockFile{}
+	}
+
+	var events []Event
+
+	oldSkills := make(map[string]config.SkillLockEntry, len(old.Skills))
+	for _, entry := range old.Skills {
+		oldSkills[skillKey(entry)] = entry
+	}
+	newSkills := make(map[string]bool, len(new.Skills))
+	for _, entry := range new.Skills {
+		key := skillKey(entry)
+		newSkills[key] = true
+
+		prev, ok := oldSkills[key]
+		switch {
+		case !ok:
+			events = append(events, skillEvent(ActionInstall, entry, now))
+		case prev.Commit != entry.Commit || prev.Integrity != entry.Integrity:
+			events = append(events, skillEvent(ActionUpdate, entry, now))
+		}
+	}
+	for _, entry := range old.Skills {
+		if !newSkills[skillKey(entry)] {
+			events = append(events, skillEvent(ActionRemove, entry, now))
+		}
+	}
+
+	oldServers := make(map[string]config.MCPLockEntry, len(old.MCPServers))
+	for _, entry := range old.MCPServers {
+		oldServers[entry.Name] = entry
+	}
+	newServers := make(map[string]bool, len(new.MCPServers))
+	for _, entry := range new.MCPServers {
+		newServers[entry.Name] = true
+
+		prev, ok := oldServers[entry.Name]
+		switch {
+		case !ok:
+			events = append(events, mcpEvent(ActionInstall, entry, now))
+		case prev.InstallPath != entry.InstallPath || prev.Digest != entry.Digest || prev.Integrity != entry.Integrity:
+			events = append(events, mcpEvent(ActionUpdate, entry, now))
+		}
+	}
+	for _, entry := range old.MCPServers {
+		if !newServers[entry.Name] {
+			events = append(events, mcpEvent(ActionRemove, entry, now))
+		}
+	}
+
+	return events
+}
+
+func skillKey(entry config.SkillLockEntry) string {
+	return entry.Remote() + "|" + entry.Path
+}
+
+func skillEvent(action string, entry config.SkillLockEntry, now time.Time) Event {
+	name := entry.Name
+	if name == "" {
+		name = path.Base(filepath.ToSlash(entry.Path))
+	}
+	if name == "." || name == "/" {
+		name = strings.TrimSuffix(path.Base(entry.Git), ".git")
+	}
+	source := entry.Path
+	if remote := entry.Remote(); remote != "" {
+		source = remote + "//" + entry.Path
+	}
+	version := entry.Commit
+	if version == "" && entry.SHA256 != "" {
+		version = "sha256:" + entry.SHA256
+	}
+	return Event{Time: now, Action: action, Kind: "skill", Name: name, Source: source, Version: version}
+}
+
+func mcpEvent(action string, entry config.MCPLockEntry, now time.Time) Event {
+	event := Event{Time: now, Action: action, Kind: "mcp", Name: entry.Name}
+	switch {
+	case entry.Package != "":
+		event.Source = entry.Package
+		if entry.InstallPath != "" {
+			event.Version = filepath.Base(entry.InstallPath)
+		}
+	case entry.Image != "":
+		event.Source, event.Version = entry.Image, entry.Digest
+	case entry.URL != "":
+		event.Source = entry.URL
+	case entry.Command != "":
+		event.Source = entry.Command
+	}
+	return event
+}
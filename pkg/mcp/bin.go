@@ -0,0 +1,40 @@
+package mcp
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// venvBinDir returns the directory of the console scripts of the Python
+// virtualenv at venv on goos: Scripts on Windows, bin elsewhere.
+func venvBinDir(venv, goos string) string {
+	if goos == "windows" {
+		return filepath.Join(venv, "Scripts")
+	}
+	return filepath.Join(venv, "bin")
+}
+
+// exeName returns the file name of the executable name on goos, which
+// has an .exe suffix on Windows.
+func exeName(name, goos string) string {
+	if goos == "windows" && !strings.HasSuffix(strings.ToLower(name), ".exe") {
+		return name + ".exe"
+	}
+	return name
+}
+
+// npmBinPath returns the path to run the bin name of the npm package
+// pkgName installed at dir, whose package.json maps it to script. npm
+// links bins into node_modules/.bin; on Windows it writes .cmd shims
+// there instead, which a node runtime can't run, so with a runtime the
+// script itself is used.
+func npmBinPath(dir, pkgName, name, script string, withRuntime bool, goos string) string {
+	binDir := filepath.Join(dir, "node_modules", ".bin")
+	if goos != "windows" {
+		return filepath.Join(binDir, name)
+	}
+	if withRuntime {
+		return filepath.Join(dir, "node_modules", filepath.FromSlash(pkgName), filepath.FromSlash(script))
+	}
+	return filepath.Join(binDir, name+".cmd")
+}
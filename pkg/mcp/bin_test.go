@@ -0,0 +1,51 @@
+package mcp
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestBinPaths(t *testing.T) {
+	tests := map[string]struct {
+		got  func(goos string) string
+		want map[string]string // by GOOS
+	}{
+		"venv": {
+			got: func(goos string) string {
+				return filepath.Join(venvBinDir("venv", goos), exeName("mcp-server-git", goos))
+			},
+			want: map[string]string{
+				"linux":   "venv/bin/mcp-server-git",
+				"windows": "venv/Scripts/mcp-server-git.exe",
+			},
+		},
+		"exe suffix kept": {
+			got:  func(goos string) string { return exeName("server.EXE", goos) },
+			want: map[string]string{"linux": "server.EXE", "windows": "server.EXE"},
+		},
+		"npm": {
+			got: func(goos string) string { return npmBinPath("dir", "@scope/fs", "fs", "./dist/cli.js", false, goos) },
+			want: map[string]string{
+				"darwin":  "dir/node_modules/.bin/fs",
+				"windows": "dir/node_modules/.bin/fs.cmd",
+			},
+		},
+		"npm with runtime": {
+			got: func(goos string) string { return npmBinPath("dir", "@scope/fs", "fs", "./dist/cli.js", true, goos) },
+			want: map[string]string{
+				"darwin":  "dir/node_modules/.bin/fs",
+				"windows": "dir/node_modules/@scope/fs/dist/cli.js",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			for goos, want := range tc.want {
+				if got := tc.got(goos); got != filepath.FromSlash(want) {
+					t.Errorf("on %s: got %q, want %q", goos, got, filepath.FromSlash(want))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package mcp
+
+import (
+	"maps"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/source"
+)
+
+// WithCommand returns server launched the way style says (see
+// config.MCPCommandStyles), with launcher the command that runs apkg exec
+// or apkg run mcp for the server's project (e.g. ["/usr/local/bin/apkg",
+// "exec", "--project-dir", "/work/app"]). Managed packages run through
+// npx, uvx, or go run with config.MCPCommandPath, and through apkg exec
+// with config.MCPCommandExec. With config.MCPCommandRun every stdio
+// server runs through apkg run mcp, which reads its arguments and env
+// when the agent starts it, so the entry carries neither. Other servers
+// are returned unchanged.
+func WithCommand(server MCPServer, style string, launcher []string) MCPServer {
+	s, ok := server.(*localStdioMcpServer)
+	if !ok {
+		return server
+	}
+	if style == config.MCPCommandRun {
+		if len(launcher) == 0 {
+			return server
+		}
+		run := *s
+		run.command = launcher[0]
+		run.args = append(slices.Clone(launcher[1:]), s.name)
+		run.env = nil
+		return &run
+	}
+	if s.pkg == "" {
+		return server
+	}
+
+	var command string
+	var prefix []string
+	switch style {
+	case config.MCPCommandPath:
+		command, prefix = pathCommand(s.pinnedPackage(), filepath.Base(s.bin))
+	case config.MCPCommandExec:
+		if len(launcher) > 0 {
+			command = launcher[0]
+			prefix = append(slices.Clone(launcher[1:]), s.name, "--")
+		}
+	}
+	if command == "" {
+		return server
+	}
+
+	withCommand := *s
+	withCommand.command = command
+	withCommand.args = append(prefix, s.configuredArgs()...)
+	return &withCommand
+}
+
+// WithVersion returns server with the version of its managed package
+// that was installed, e.g. the one the lockfile records, which
+// config.MCPCommandPath launches instead of whatever version the package
+// spec in the manifest resolves to when the agent starts it. Other
+// servers, and an empty version, are returned unchanged.
+func WithVersion(server MCPServer, version string) MCPServer {
+	s, ok := server.(*localStdioMcpServer)
+	if !ok || s.pkg == "" || version == "" {
+		return server
+	}
+	withVersion := *s
+	withVersion.version = version
+	return &withVersion
+}
+
+// pinnedPackage returns the server's package spec pinned to its installed
+// version, if it's known.
+func (s *localStdioMcpServer) pinnedPackage() string {
+	if s.version == "" {
+		return s.pkg
+	}
+	return source.PinPackage(s.pkg, s.version)
+}
+
+// LaunchEnv returns environ, in os.Environ's form, with the env of server
+// added in key order. References like "${GITHUB_TOKEN}" in its values are
+// expanded from environ, so secrets can stay out of the manifest and the
+// agent config until launch.
+func LaunchEnv(server MCPServer, environ []string) []string {
+	lookup := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			lookup[k] = v
+		}
+	}
+
+	env := slices.Clone(environ)
+	for _, k := range slices.Sorted(maps.Keys(server.Env())) {
+		value := envRef.ReplaceAllStringFunc(server.Env()[k], func(ref string) string {
+			return lookup[ref[2:len(ref)-1]]
+		})
+		env = append(env, k+"="+value)
+	}
+	return env
+}
+
+// envRef matches a "${NAME}" reference to an environment variable.
+var envRef = regexp.MustCompile(`\$\{[A-Za-z_][A-Za-z0-9_]*\}`)
+
+// pathCommand returns the command and leading arguments that run bin of
+// the managed package pkg through its package manager's runner.
+func pathCommand(pkg, bin string) (string, []string) {
+	kind, spec, _ := strings.Cut(pkg, ":")
+	switch kind {
+	case "npm":
+		return "npx", []string{"-y", "--package", spec, bin}
+	case "uv", "uv-tool":
+		return "uvx", []string{"--from", spec, bin}
+	case "go":
+		return "go", []string{"run", spec}
+	}
+	return "", nil
+}
+
+// configuredArgs returns the server's arguments without the binary a
+// runtime command is given first (see loadManagedStdio).
+func (s *localStdioMcpServer) configuredArgs() []string {
+	if s.command != s.bin && len(s.args) > 0 && s.args[0] == s.bin {
+		return s.args[1:]
+	}
+	return s.args
+}
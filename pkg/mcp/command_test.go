@@ -0,0 +1,139 @@
+package mcp
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+)
+
+func TestWithVersion(t *testing.T) {
+	managed := &localStdioMcpServer{name: "fs", command: "/store/bin/server-fs", pkg: "npm:@mcp/fs", bin: "/store/bin/server-fs"}
+	if got := WithVersion(managed, "1.2.3").(*localStdioMcpServer); got.version != "1.2.3" || managed.version != "" {
+		t.Errorf("WithVersion() version = %q, original %q", got.version, managed.version)
+	}
+	unmanaged := &localStdioMcpServer{name: "local", command: "my-server"}
+	if got := WithVersion(unmanaged, "1.2.3"); got != MCPServer(unmanaged) {
+		t.Error("WithVersion() changed a server without a package")
+	}
+}
+
+func TestWithCommand(t *testing.T) {
+	launcher := []string{"/usr/local/bin/apkg", "exec", "--project-dir", "/work/app"}
+	runLauncher := []string{"/usr/local/bin/apkg", "run", "mcp", "--project-dir", "/work/app"}
+
+	tests := map[string]struct {
+		server      MCPServer
+		style       string
+		wantCommand string
+		wantArgs    []string
+	}{
+		"absolute": {
+			server:      &localStdioMcpServer{name: "fs", command: "/store/npm/fs/node_modules/.bin/fs", args: []string{"/tmp"}, pkg: "npm:fs@1.0.0", bin: "/store/npm/fs/node_modules/.bin/fs"},
+			style:       config.MCPCommandAbsolute,
+			wantCommand: "/store/npm/fs/node_modules/.bin/fs",
+			wantArgs:    []string{"/tmp"},
+		},
+		"npm on path": {
+			server:      &localStdioMcpServer{name: "fs", command: "/usr/bin/node", args: []string{"/store/bin/server-fs", "/tmp"}, pkg: "npm:@mcp/fs@1.0.0", bin: "/store/bin/server-fs"},
+			style:       config.MCPCommandPath,
+			wantCommand: "npx",
+			wantArgs:    []string{"-y", "--package", "@mcp/fs@1.0.0", "server-fs", "/tmp"},
+		},
+		"npm on path pinned to the installed version": {
+			server:      &localStdioMcpServer{name: "fs", command: "/store/bin/server-fs", pkg: "npm:@mcp/fs@^1.0.0", bin: "/store/bin/server-fs", version: "1.2.3"},
+			style:       config.MCPCommandPath,
+			wantCommand: "npx",
+			wantArgs:    []string{"-y", "--package", "@mcp/fs@1.2.3", "server-fs"},
+		},
+		"uv on path pinned to the installed version": {
+			server:      &localStdioMcpServer{name: "git", command: "/store/uv/git/.venv/bin/mcp-server-git", pkg: "uv:mcp-server-git", bin: "/store/uv/git/.venv/bin/mcp-server-git", version: "0.6.2"},
+			style:       config.MCPCommandPath,
+			wantCommand: "uvx",
+			wantArgs:    []string{"--from", "mcp-server-git==0.6.2", "mcp-server-git"},
+		},
+		"uv on path": {
+			server:      &localStdioMcpServer{name: "git", command: "/store/uv/git/.venv/bin/mcp-server-git", pkg: "uv:mcp-server-git==0.6.2", bin: "/store/uv/git/.venv/bin/mcp-server-git"},
+			style:       config.MCPCommandPath,
+			wantCommand: "uvx",
+			wantArgs:    []string{"--from", "mcp-server-git==0.6.2", "mcp-server-git"},
+		},
+		"uv tool on path": {
+			server:      &localStdioMcpServer{name: "proxy", command: "/store/tools/bin/mcp-proxy", pkg: "uv-tool:mcp-proxy==0.8.0", bin: "/store/tools/bin/mcp-proxy"},
+			style:       config.MCPCommandPath,
+			wantCommand: "uvx",
+			wantArgs:    []string{"--from", "mcp-proxy==0.8.0", "mcp-proxy"},
+		},
+		"go on path": {
+			server:      &localStdioMcpServer{name: "y", command: "/store/go/y/bin/y", args: []string{"-v"}, pkg: "go:github.com/x/y@v1.0.0", bin: "/store/go/y/bin/y"},
+			style:       config.MCPCommandPath,
+			wantCommand: "go",
+			wantArgs:    []string{"run", "github.com/x/y@v1.0.0", "-v"},
+		},
+		"exec": {
+			server:      &localStdioMcpServer{name: "fs", command: "/usr/bin/node", args: []string{"/store/bin/server-fs", "/tmp"}, pkg: "npm:@mcp/fs@1.0.0", bin: "/store/bin/server-fs"},
+			style:       config.MCPCommandExec,
+			wantCommand: "/usr/local/bin/apkg",
+			wantArgs:    []string{"exec", "--project-dir", "/work/app", "fs", "--", "/tmp"},
+		},
+		"run": {
+			server:      &localStdioMcpServer{name: "local", command: "my-server", args: []string{"--stdio"}, env: map[string]string{"TOKEN": "${TOKEN}"}},
+			style:       config.MCPCommandRun,
+			wantCommand: "/usr/local/bin/apkg",
+			wantArgs:    []string{"run", "mcp", "--project-dir", "/work/app", "local"},
+		},
+		"unmanaged server": {
+			server:      &localStdioMcpServer{name: "local", command: "my-server", args: []string{"--stdio"}},
+			style:       config.MCPCommandPath,
+			wantCommand: "my-server",
+			wantArgs:    []string{"--stdio"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			l := launcher
+			if tc.style == config.MCPCommandRun {
+				l = runLauncher
+			}
+			got := WithCommand(tc.server, tc.style, l)
+			if got.Command() != tc.wantCommand {
+				t.Errorf("Command() = %q, want %q", got.Command(), tc.wantCommand)
+			}
+			if !reflect.DeepEqual(got.Args(), tc.wantArgs) {
+				t.Errorf("Args() = %q, want %q", got.Args(), tc.wantArgs)
+			}
+		})
+	}
+
+	if env := WithCommand(tests["run"].server, config.MCPCommandRun, runLauncher).Env(); env != nil {
+		t.Errorf("Env() = %v, want none for apkg run mcp", env)
+	}
+
+	remote := &httpMCPServer{name: "api", url: "https://example.com/mcp"}
+	if got := WithCommand(remote, config.MCPCommandExec, launcher); got != MCPServer(remote) {
+		t.Errorf("WithCommand() changed an http server: %+v", got)
+	}
+}
+
+func TestLaunchEnv(t *testing.T) {
+	server := &localStdioMcpServer{name: "gh", env: map[string]string{
+		"GITHUB_TOKEN": "${GH_TOKEN}",
+		"API_URL":      "https://${HOST}/api",
+		"PRICE":        "$5",
+		"MISSING":      "${UNSET}",
+	}}
+	environ := []string{"PATH=/usr/bin", "GH_TOKEN=secret", "HOST=example.com"}
+
+	got := LaunchEnv(server, environ)
+	want := []string{
+		"PATH=/usr/bin", "GH_TOKEN=secret", "HOST=example.com",
+		"API_URL=https://example.com/api",
+		"GITHUB_TOKEN=secret",
+		"MISSING=",
+		"PRICE=$5",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LaunchEnv() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,310 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// inspectProtocolVersion is the MCP protocol version ListTools asks for.
+const inspectProtocolVersion = "2025-06-18"
+
+// Tool is a tool an MCP server offers, as its tools/list answer
+// describes it.
+type Tool struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// ListTools asks server for its tools: it starts stdio servers with their
+// env added to apkg's (see LaunchEnv) and stops them again, and talks to
+// HTTP servers at their URL, "${VAR}" references in their headers
+// expanded from apkg's environment. Servers on the older SSE transport
+// aren't supported.
+func ListTools(ctx context.Context, server MCPServer) ([]Tool, error) {
+	switch server.Transport() {
+	case transportStdio:
+		c, err := startStdioClient(ctx, server)
+		if err != nil {
+			return nil, err
+		}
+		defer c.close()
+		return listTools(ctx, c)
+	case transportHTTP:
+		headers := make(map[string]string, len(server.Headers()))
+		for k, v := range server.Headers() {
+			headers[k] = envRef.ReplaceAllStringFunc(v, func(ref string) string {
+				return os.Getenv(ref[2 : len(ref)-1])
+			})
+		}
+		return listTools(ctx, &httpClient{url: server.URL(), headers: headers})
+	default:
+		return nil, fmt.Errorf("listing the tools of %s servers is not supported", server.Transport())
+	}
+}
+
+// rpcClient sends JSON-RPC messages to an MCP server.
+type rpcClient interface {
+	// call sends a request and decodes the result of its response into
+	// result.
+	call(ctx context.Context, id int, method string, params, result any) error
+	// notify sends a notification.
+	notify(ctx context.Context, method string, params any) error
+}
+
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int            `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  any             `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// decodeResult decodes the result of the response msg into result.
+func (msg *rpcMessage) decodeResult(method string, result any) error {
+	if msg.Error != nil {
+		return fmt.Errorf("%s: %s (code %d)", method, msg.Error.Message, msg.Error.Code)
+	}
+	if err := json.Unmarshal(msg.Result, result); err != nil {
+		return fmt.Errorf("%s: decoding result: %w", method, err)
+	}
+	return nil
+}
+
+// listTools initializes the session and pages through tools/list.
+func listTools(ctx context.Context, c rpcClient) ([]Tool, error) {
+	initParams := map[string]any{
+		"protocolVersion": inspectProtocolVersion,
+		"capabilities":    map[string]any{},
+		"clientInfo":      map[string]any{"name": "apkg", "version": "1"},
+	}
+	var init struct {
+		Capabilities map[string]json.RawMessage `json:"capabilities"`
+	}
+	if err := c.call(ctx, 1, "initialize", initParams, &init); err != nil {
+		return nil, err
+	}
+	if err := c.notify(ctx, "notifications/initialized", nil); err != nil {
+		return nil, err
+	}
+	if _, ok := init.Capabilities["tools"]; !ok {
+		return nil, nil
+	}
+
+	var tools []Tool
+	cursor := ""
+	for id := 2; ; id++ {
+		var params map[string]any
+		if cursor != "" {
+			params = map[string]any{"cursor": cursor}
+		}
+		var page struct {
+			Tools      []Tool `json:"tools"`
+			NextCursor string `json:"nextCursor"`
+		}
+		if err := c.call(ctx, id, "tools/list", params, &page); err != nil {
+			return nil, err
+		}
+		tools = append(tools, page.Tools...)
+		if page.NextCursor == "" || page.NextCursor == cursor {
+			return tools, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// stdioClient talks to a server it started over its stdin and stdout,
+// one message per line.
+type stdioClient struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	dec    *json.Decoder
+	stderr *bytes.Buffer
+}
+
+func startStdioClient(ctx context.Context, server MCPServer) (*stdioClient, error) {
+	cmd := exec.CommandContext(ctx, server.Command(), server.Args()...)
+	cmd.Env = LaunchEnv(server, os.Environ())
+	cmd.WaitDelay = time.Second
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	c := &stdioClient{cmd: cmd, stdin: stdin, dec: json.NewDecoder(stdout), stderr: &bytes.Buffer{}}
+	cmd.Stderr = c.stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("starting %s: %w", server.Command(), err)
+	}
+	return c, nil
+}
+
+func (c *stdioClient) call(ctx context.Context, id int, method string, params, result any) error {
+	if err := c.send(rpcMessage{JSONRPC: "2.0", ID: &id, Method: method, Params: params}); err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	for {
+		var msg rpcMessage
+		if err := c.dec.Decode(&msg); err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("%s: %w", method, ctx.Err())
+			}
+			return fmt.Errorf("%s: reading response: %w%s", method, err, c.stderrNote())
+		}
+		// Skip the server's notifications and requests.
+		if msg.Method == "" && msg.ID != nil && *msg.ID == id {
+			return msg.decodeResult(method, result)
+		}
+	}
+}
+
+func (c *stdioClient) notify(_ context.Context, method string, params any) error {
+	if err := c.send(rpcMessage{JSONRPC: "2.0", Method: method, Params: params}); err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	return nil
+}
+
+func (c *stdioClient) send(msg rpcMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = c.stdin.Write(append(data, '\n'))
+	return err
+}
+
+// stderrNote returns the last line the server wrote to stderr, to explain
+// why it stopped answering.
+func (c *stdioClient) stderrNote() string {
+	lines := strings.Split(strings.TrimSpace(c.stderr.String()), "\n")
+	if last := strings.TrimSpace(lines[len(lines)-1]); last != "" {
+		return " (" + last + ")"
+	}
+	return ""
+}
+
+// close closes the server's stdin, which tells it to exit, and stops it
+// if it doesn't.
+func (c *stdioClient) close() {
+	c.stdin.Close()
+	done := make(chan struct{})
+	go func() {
+		c.cmd.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		c.cmd.Process.Kill()
+		<-done
+	}
+}
+
+// httpClient talks to a server over the streamable HTTP transport, which
+// answers each POSTed message with JSON or a stream of events.
+type httpClient struct {
+	url     string
+	headers map[string]string
+	session string
+}
+
+func (c *httpClient) call(ctx context.Context, id int, method string, params, result any) error {
+	resp, err := c.post(ctx, rpcMessage{JSONRPC: "2.0", ID: &id, Method: method, Params: params})
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		var msg rpcMessage
+		if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil {
+			return fmt.Errorf("%s: decoding response: %w", method, err)
+		}
+		return msg.decodeResult(method, result)
+	}
+
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(nil, 16<<20)
+	var data strings.Builder
+	for sc.Scan() {
+		line := sc.Text()
+		if rest, ok := strings.CutPrefix(line, "data:"); ok {
+			data.WriteString(strings.TrimPrefix(rest, " "))
+			continue
+		}
+		if line != "" || data.Len() == 0 {
+			continue
+		}
+		var msg rpcMessage
+		if err := json.Unmarshal([]byte(data.String()), &msg); err == nil && msg.Method == "" && msg.ID != nil && *msg.ID == id {
+			return msg.decodeResult(method, result)
+		}
+		data.Reset()
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("%s: reading events: %w", method, err)
+	}
+	return fmt.Errorf("%s: the server sent no response", method)
+}
+
+func (c *httpClient) notify(ctx context.Context, method string, params any) error {
+	resp, err := c.post(ctx, rpcMessage{JSONRPC: "2.0", Method: method, Params: params})
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// post sends msg, carrying over the session the server assigned.
+func (c *httpClient) post(ctx context.Context, msg rpcMessage) (*http.Response, error) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	req.Header.Set("MCP-Protocol-Version", inspectProtocolVersion)
+	if c.session != "" {
+		req.Header.Set("Mcp-Session-Id", c.session)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, errors.New(resp.Status)
+	}
+	if session := resp.Header.Get("Mcp-Session-Id"); session != "" {
+		c.session = session
+	}
+	return resp, nil
+}
@@ -0,0 +1,82 @@
+package mcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/apkgtest"
+)
+
+// stdioToolsScript answers initialize and tools/list on stdin in order,
+// with a log notification in between, as a stdio MCP server would.
+const stdioToolsScript = `#!/bin/sh
+read -r init
+echo '{"jsonrpc":"2.0","id":1,"result":{"protocolVersion":"2025-06-18","capabilities":{"tools":{}},"serverInfo":{"name":"fake"}}}'
+read -r initialized
+read -r list
+echo '{"jsonrpc":"2.0","method":"notifications/message","params":{"level":"info","data":"'"$GREETING"'"}}'
+echo '{"jsonrpc":"2.0","id":2,"result":{"tools":[{"name":"search","description":"Search '"$GREETING"'"}]}}'
+read -r eof
+`
+
+func TestListTools(t *testing.T) {
+	t.Setenv("APKGTEST_TOKEN", "secret")
+
+	script := filepath.Join(t.TempDir(), "server.sh")
+	if err := os.WriteFile(script, []byte(stdioToolsScript), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	remote := apkgtest.NewMCPServer(t, map[string]string{"create_issue": "Create an issue", "list_issues": ""})
+
+	tests := map[string]struct {
+		server  MCPServer
+		want    []Tool
+		wantErr bool
+		sh      bool // needs /bin/sh
+	}{
+		"stdio": {
+			server: &localStdioMcpServer{name: "fake", command: script, env: map[string]string{"GREETING": "the docs"}},
+			want:   []Tool{{Name: "search", Description: "Search the docs"}},
+			sh:     true,
+		},
+		"stdio server exits": {
+			server:  &localStdioMcpServer{name: "broken", command: "/bin/false"},
+			wantErr: true,
+			sh:      true,
+		},
+		"http": {
+			server: &httpMCPServer{name: "issues", url: remote.URL, transport: transportHTTP, headers: map[string]string{"Authorization": "Bearer ${APKGTEST_TOKEN}"}},
+			want:   []Tool{{Name: "create_issue", Description: "Create an issue"}, {Name: "list_issues"}},
+		},
+		"sse unsupported": {
+			server:  &httpMCPServer{name: "legacy", url: remote.URL, transport: "sse"},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if tc.sh && runtime.GOOS == "windows" {
+				t.Skip("needs /bin/sh")
+			}
+			got, err := ListTools(context.Background(), tc.server)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ListTools() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("ListTools() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	if h := remote.Headers(); len(h) == 0 || h[0].Get("Authorization") != "Bearer secret" {
+		t.Errorf("HTTP server got headers %v, want the expanded Authorization header", h)
+	}
+	if got, want := remote.Methods(), []string{"initialize", "notifications/initialized", "tools/list"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("HTTP server got %v, want %v", got, want)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strings"
 
@@ -24,6 +25,8 @@ const (
 
 	// serveProxyURL is the default URL for the apkg serve proxy that
 	// manages containerized MCP servers. Must match serve.DefaultPort.
+	// Installs replace it with the port serve last advertised (see
+	// WithServeURL).
 	serveProxyURL = "http://localhost:19513"
 	// serveRouteHeader is the HTTP header used by apkg serve to route
 	// requests to the correct container. Must match serve.MCPServerHeader.
@@ -31,6 +34,9 @@ const (
 	// serveRouteDigestHeader disambiguates when multiple projects install
 	// the same server name with different images. Must match serve.MCPServerDigestHeader.
 	serveRouteDigestHeader = "X-MCP-Server-Digest"
+	// serveRouteProjectHeader identifies the project a request comes from,
+	// for apkg serve's access control. Must match serve.MCPProjectHeader.
+	serveRouteProjectHeader = "X-MCP-Project"
 )
 
 type MCPServer interface {
@@ -47,56 +53,36 @@ type MCPServer interface {
 	Env() map[string]string
 }
 
+// Options are how the server installed at a store entry runs for one
+// project. Projects share store entries, so these are recorded in each
+// project's lockfile instead (see config.MCPLockEntry).
+type Options struct {
+	// Runtime is the node binary an npm package runs on. Empty runs the
+	// package's binary directly.
+	Runtime string
+	// Volumes are the volumes of a stdio container, with absolute host
+	// paths.
+	Volumes []string
+	// Env, if non-nil, replaces the env of a local server. Store entries
+	// hold the env of the last project to install them, which may have
+	// selected another env set.
+	Env map[string]string
+}
+
+// Load loads the server installed at dir with no Options.
 func Load(dir string) (MCPServer, error) {
-	configFile := filepath.Join(dir, mcpConfigFile)
+	return LoadWith(dir, Options{})
+}
 
-	data, err := os.ReadFile(configFile)
+// LoadWith loads the server installed at dir to run with opts.
+func LoadWith(dir string, opts Options) (MCPServer, error) {
+	cfg, err := loadConfig(dir, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read %q: %w", configFile, err)
-	}
-
-	cfg := &config.MCPSource{}
-	if err := toml.Unmarshal(data, cfg); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal %q: %w", configFile, err)
+		return nil, err
 	}
 
 	if cfg.ManagedStdioMCPConfig != nil {
-		var binPath string
-		var err error
-
-		switch {
-		case strings.HasPrefix(cfg.Package, "npm:"):
-			binPath, err = resolveNPMBin(dir, cfg.Package)
-		case strings.HasPrefix(cfg.Package, "uv:"):
-			binPath, err = resolveUVBin(dir, cfg.Package)
-		case strings.HasPrefix(cfg.Package, "go:"):
-			binPath, err = resolveGoBin(dir, cfg.Package)
-		default:
-			return nil, fmt.Errorf("unsupported managed package prefix in %q", cfg.Package)
-		}
-		if err != nil {
-			return nil, fmt.Errorf("resolving binary for %q: %w", cfg.Package, err)
-		}
-
-		server := &localStdioMcpServer{
-			name:    cfg.Name,
-			command: binPath,
-		}
-		if cfg.LocalMCPConfig != nil {
-			server.args = cfg.Args
-			server.env = cfg.Env
-		}
-
-		// For npm packages with a resolved runtime, use the runtime as
-		// the command and prepend the binary path to args. This ensures
-		// agents that don't source the shell environment (e.g. Cursor)
-		// can locate the interpreter.
-		if cfg.Runtime != "" {
-			server.args = append([]string{binPath}, server.args...)
-			server.command = cfg.Runtime
-		}
-
-		return server, nil
+		return loadManagedStdio(dir, cfg)
 	}
 
 	if cfg.UnmanagedStdioMCPConfig != nil {
@@ -152,6 +138,106 @@ func Load(dir string) (MCPServer, error) {
 	return nil, fmt.Errorf("unsupported MCP server configuration")
 }
 
+// LoadExec loads the managed package installed at dir like Load, but with
+// args in place of the configured arguments, for running the package's
+// own CLI (e.g. "--version") outside an agent.
+func LoadExec(dir string, args []string, opts Options) (MCPServer, error) {
+	cfg, err := loadConfig(dir, opts)
+	if err != nil {
+		return nil, err
+	}
+	// Packages run in a container (see config.RuntimeContainer) are
+	// stored as stdio container servers whose entrypoint is the package.
+	inContainer := cfg.ContainerMCPConfig != nil && cfg.Transport == transportStdio
+	if cfg.ManagedStdioMCPConfig == nil && !inContainer {
+		return nil, fmt.Errorf("MCP server %q is not a managed package", cfg.Name)
+	}
+
+	if cfg.LocalMCPConfig == nil {
+		cfg.LocalMCPConfig = &config.LocalMCPConfig{}
+	}
+	cfg.Args = args
+	if inContainer {
+		return loadContainerStdio(cfg)
+	}
+	return loadManagedStdio(dir, cfg)
+}
+
+// loadConfig reads the config of the server installed at dir, set up to
+// run with opts. Store entries written by older installs may hold another
+// project's runtime, volumes, and env, which opts replace.
+func loadConfig(dir string, opts Options) (*config.MCPSource, error) {
+	configFile := filepath.Join(dir, mcpConfigFile)
+
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", configFile, err)
+	}
+
+	cfg := &config.MCPSource{}
+	if err := toml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %q: %w", configFile, err)
+	}
+	if cfg.ManagedStdioMCPConfig != nil && !cfg.InContainer() {
+		cfg.Runtime = opts.Runtime
+	}
+	if cfg.ContainerMCPConfig != nil {
+		cfg.Volumes = opts.Volumes
+	}
+	if opts.Env != nil && cfg.ExternalHttpMCPConfig == nil {
+		if cfg.LocalMCPConfig == nil {
+			cfg.LocalMCPConfig = &config.LocalMCPConfig{}
+		}
+		cfg.Env = opts.Env
+	}
+	return cfg, nil
+}
+
+// loadManagedStdio runs the binary of the managed package installed at
+// dir.
+func loadManagedStdio(dir string, cfg *config.MCPSource) (MCPServer, error) {
+	var binPath string
+	var err error
+
+	switch {
+	case strings.HasPrefix(cfg.Package, "npm:"):
+		binPath, err = resolveNPMBin(dir, cfg.Package, cfg.Runtime != "")
+	case strings.HasPrefix(cfg.Package, "uv:"):
+		binPath, err = resolveUVBin(dir, cfg.Package)
+	case strings.HasPrefix(cfg.Package, "uv-tool:"):
+		binPath, err = resolveUVToolBin(dir, cfg.Package, cfg.Bin)
+	case strings.HasPrefix(cfg.Package, "go:"):
+		binPath, err = resolveGoBin(dir, cfg.Package)
+	default:
+		return nil, fmt.Errorf("unsupported managed package prefix in %q", cfg.Package)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("resolving binary for %q: %w", cfg.Package, err)
+	}
+
+	server := &localStdioMcpServer{
+		name:    cfg.Name,
+		command: binPath,
+		pkg:     cfg.Package,
+		bin:     binPath,
+	}
+	if cfg.LocalMCPConfig != nil {
+		server.args = cfg.Args
+		server.env = cfg.Env
+	}
+
+	// For npm packages with a resolved runtime, use the runtime as
+	// the command and prepend the binary path to args. This ensures
+	// agents that don't source the shell environment (e.g. Cursor)
+	// can locate the interpreter.
+	if cfg.Runtime != "" {
+		server.args = append([]string{binPath}, server.args...)
+		server.command = cfg.Runtime
+	}
+
+	return server, nil
+}
+
 // loadContainerStdio builds a localStdioMcpServer that runs the container
 // image via the detected container engine (docker/podman) with stdin attached.
 func loadContainerStdio(cfg *config.MCPSource) (MCPServer, error) {
@@ -161,7 +247,10 @@ func loadContainerStdio(cfg *config.MCPSource) (MCPServer, error) {
 	}
 
 	imageRef := cfg.Image
-	if cfg.Digest != "" {
+	switch {
+	case cfg.ManifestDigest != "":
+		imageRef = container.PinnedImage(cfg.Image, cfg.ManifestDigest)
+	case cfg.Digest != "":
 		imageRef = cfg.Image + "@sha256:" + cfg.Digest
 	}
 
@@ -170,6 +259,9 @@ func loadContainerStdio(cfg *config.MCPSource) (MCPServer, error) {
 	if cfg.Network != "" {
 		runArgs = append(runArgs, "--network", cfg.Network)
 	}
+	for _, alias := range cfg.NetworkAliases {
+		runArgs = append(runArgs, "--network-alias", alias)
+	}
 
 	for _, vol := range cfg.Volumes {
 		runArgs = append(runArgs, "-v", vol)
@@ -195,7 +287,7 @@ func loadContainerStdio(cfg *config.MCPSource) (MCPServer, error) {
 
 	return &localStdioMcpServer{
 		name:    cfg.Name,
-		command: engine.Path,
+		command: engine.Command(),
 		args:    runArgs,
 	}, nil
 }
@@ -203,8 +295,9 @@ func loadContainerStdio(cfg *config.MCPSource) (MCPServer, error) {
 // resolveNPMBin finds the executable binary for an npm package installed at dir.
 // It reads the package's package.json bin field and applies the same resolution
 // logic as npx: if there's a single entry use it, otherwise match the unscoped
-// package name.
-func resolveNPMBin(dir string, pkg string) (string, error) {
+// package name. withRuntime says whether the binary is run by a node runtime
+// (see npmBinPath).
+func resolveNPMBin(dir string, pkg string, withRuntime bool) (string, error) {
 	pkgName := strings.TrimPrefix(pkg, "npm:")
 	if idx := strings.LastIndex(pkgName, "@"); idx > 0 {
 		pkgName = pkgName[:idx]
@@ -223,8 +316,6 @@ func resolveNPMBin(dir string, pkg string) (string, error) {
 		return "", fmt.Errorf("parsing package.json: %w", err)
 	}
 
-	binDir := filepath.Join(dir, "node_modules", ".bin")
-
 	// bin can be a string (single binary, name = unscoped package name)
 	var single string
 	if err := json.Unmarshal(meta.Bin, &single); err == nil {
@@ -232,7 +323,7 @@ func resolveNPMBin(dir string, pkg string) (string, error) {
 		if i := strings.LastIndex(unscopedName, "/"); i >= 0 {
 			unscopedName = unscopedName[i+1:]
 		}
-		return filepath.Join(binDir, unscopedName), nil
+		return npmBinPath(dir, pkgName, unscopedName, single, withRuntime, runtime.GOOS), nil
 	}
 
 	// bin can be a map of name -> path
@@ -242,8 +333,8 @@ func resolveNPMBin(dir string, pkg string) (string, error) {
 	}
 
 	if len(bins) == 1 {
-		for name := range bins {
-			return filepath.Join(binDir, name), nil
+		for name, script := range bins {
+			return npmBinPath(dir, pkgName, name, script, withRuntime, runtime.GOOS), nil
 		}
 	}
 
@@ -252,8 +343,8 @@ func resolveNPMBin(dir string, pkg string) (string, error) {
 	if i := strings.LastIndex(unscopedName, "/"); i >= 0 {
 		unscopedName = unscopedName[i+1:]
 	}
-	if _, ok := bins[unscopedName]; ok {
-		return filepath.Join(binDir, unscopedName), nil
+	if script, ok := bins[unscopedName]; ok {
+		return npmBinPath(dir, pkgName, unscopedName, script, withRuntime, runtime.GOOS), nil
 	}
 
 	return "", fmt.Errorf("package %q has multiple bin entries and none match the package name %q", pkg, unscopedName)
@@ -264,6 +355,13 @@ type localStdioMcpServer struct {
 	command string
 	args    []string
 	env     map[string]string
+
+	// pkg and bin are the package and the absolute path of its binary,
+	// for servers of managed packages (see WithCommand), and version the
+	// installed version of the package, if known (see WithVersion).
+	pkg     string
+	bin     string
+	version string
 }
 
 func (s *localStdioMcpServer) Name() string {
@@ -325,14 +423,15 @@ func (s *httpMCPServer) Validate() error {
 }
 
 // resolveUVBin finds the executable binary for a uv package installed at dir.
-// It looks for the binary at .venv/bin/<package-name> inside the install directory.
+// It looks for the binary at .venv/bin/<package-name> inside the install directory
+// (.venv\Scripts\<package-name>.exe on Windows).
 func resolveUVBin(dir string, pkg string) (string, error) {
 	pkgName := strings.TrimPrefix(pkg, "uv:")
 	if idx := strings.Index(pkgName, "=="); idx >= 0 {
 		pkgName = pkgName[:idx]
 	}
 
-	binPath := filepath.Join(dir, ".venv", "bin", pkgName)
+	binPath := filepath.Join(venvBinDir(filepath.Join(dir, ".venv"), runtime.GOOS), exeName(pkgName, runtime.GOOS))
 	if _, err := os.Stat(binPath); err != nil {
 		return "", fmt.Errorf("binary not found at %s: %w", binPath, err)
 	}
@@ -343,7 +442,7 @@ func resolveUVBin(dir string, pkg string) (string, error) {
 // resolveGoBin finds the executable binary for a go module installed at dir.
 // It looks for the binary at bin/<last-segment-of-module-path> inside the
 // install directory. For example, github.com/go-delve/mcp-dap-server produces
-// bin/mcp-dap-server.
+// bin/mcp-dap-server (bin\mcp-dap-server.exe on Windows).
 func resolveGoBin(dir string, pkg string) (string, error) {
 	modPath := strings.TrimPrefix(pkg, "go:")
 	if idx := strings.LastIndex(modPath, "@"); idx > 0 {
@@ -356,7 +455,7 @@ func resolveGoBin(dir string, pkg string) (string, error) {
 		binName = modPath[i+1:]
 	}
 
-	binPath := filepath.Join(dir, "bin", binName)
+	binPath := filepath.Join(dir, "bin", exeName(binName, runtime.GOOS))
 	if _, err := os.Stat(binPath); err != nil {
 		return "", fmt.Errorf("binary not found at %s: %w", binPath, err)
 	}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"runtime"
 	"strings"
 	"testing"
 
@@ -13,6 +14,7 @@ import (
 func TestLoad(t *testing.T) {
 	tests := map[string]struct {
 		files    map[string]string
+		opts     Options
 		wantName string
 		wantType string // "stdio" or "http"
 		wantCmd  string // for stdio (relative to dir for managed, absolute for unmanaged check)
@@ -20,6 +22,9 @@ func TestLoad(t *testing.T) {
 		wantArgs []string
 		wantEnv  map[string]string
 		wantErr  bool
+		// unix is set for packages laid out as on Unix (see TestBinPaths
+		// for Windows).
+		unix bool
 	}{
 		"unmanaged stdio": {
 			files: map[string]string{
@@ -37,63 +42,82 @@ env = { FOO = "bar" }
 			wantEnv:  map[string]string{"FOO": "bar"},
 		},
 		"managed npm single bin string": {
+			unix: true,
 			files: map[string]string{
 				"mcp.toml": `
 name = "npm-single"
 package = "npm:my-pkg"
-runtime = "/usr/local/bin/node"
 `,
 				"node_modules/my-pkg/package.json": `{"bin": "cli.js"}`,
 			},
+			opts:     Options{Runtime: "/usr/local/bin/node"},
 			wantName: "npm-single",
 			wantType: "stdio",
 			wantCmd:  "/usr/local/bin/node",
 			wantArgs: []string{filepath.Join("node_modules", ".bin", "my-pkg")},
 		},
 		"managed npm bin map single": {
+			unix: true,
 			files: map[string]string{
 				"mcp.toml": `
 name = "npm-map"
 package = "npm:my-pkg-map"
-runtime = "/usr/local/bin/node"
 `,
 				"node_modules/my-pkg-map/package.json": `{"bin": {"my-cli": "cli.js"}}`,
 			},
+			opts:     Options{Runtime: "/usr/local/bin/node"},
 			wantName: "npm-map",
 			wantType: "stdio",
 			wantCmd:  "/usr/local/bin/node",
 			wantArgs: []string{filepath.Join("node_modules", ".bin", "my-cli")},
 		},
 		"managed npm bin map multi match unscoped": {
+			unix: true,
 			files: map[string]string{
 				"mcp.toml": `
 name = "npm-multi"
 package = "npm:@scope/my-pkg"
-runtime = "/usr/local/bin/node"
 `,
 				"node_modules/@scope/my-pkg/package.json": `{"bin": {"other": "other.js", "my-pkg": "cli.js"}}`,
 			},
+			opts:     Options{Runtime: "/usr/local/bin/node"},
 			wantName: "npm-multi",
 			wantType: "stdio",
 			wantCmd:  "/usr/local/bin/node",
 			wantArgs: []string{filepath.Join("node_modules", ".bin", "my-pkg")},
 		},
 		"managed npm with user args": {
+			unix: true,
 			files: map[string]string{
 				"mcp.toml": `
 name = "npm-args"
 package = "npm:my-pkg"
-runtime = "/usr/local/bin/node"
 args = ["--stdio"]
 `,
 				"node_modules/my-pkg/package.json": `{"bin": "cli.js"}`,
 			},
+			opts:     Options{Runtime: "/usr/local/bin/node"},
 			wantName: "npm-args",
 			wantType: "stdio",
 			wantCmd:  "/usr/local/bin/node",
 			wantArgs: []string{filepath.Join("node_modules", ".bin", "my-pkg"), "--stdio"},
 		},
+		"managed npm ignores a stored runtime": {
+			unix: true,
+			files: map[string]string{
+				"mcp.toml": `
+name = "npm-stale"
+package = "npm:my-pkg"
+runtime = "/other/project/node"
+`,
+				"node_modules/my-pkg/package.json": `{"bin": "cli.js"}`,
+			},
+			wantName: "npm-stale",
+			wantType: "stdio",
+			wantCmd:  filepath.Join("node_modules", ".bin", "my-pkg"),
+		},
 		"managed uv": {
+			unix: true,
 			files: map[string]string{
 				"mcp.toml": `
 name = "uv-test"
@@ -105,7 +129,74 @@ package = "uv:my-uv-pkg"
 			wantType: "stdio",
 			wantCmd:  filepath.Join(".venv", "bin", "my-uv-pkg"),
 		},
+		"managed uv tool": {
+			unix: true,
+			files: map[string]string{
+				"mcp.toml": `
+name = "proxy"
+package = "uv-tool:mcp-proxy==0.8.0"
+`,
+				"tools/mcp-proxy/uv-receipt.toml": `
+[tool]
+requirements = [{ name = "mcp-proxy" }]
+entrypoints = [
+    { name = "mcp-proxy", install-path = "/elsewhere/bin/mcp-proxy", from = "mcp-proxy" },
+    { name = "mcp-reverse-proxy", install-path = "/elsewhere/bin/mcp-reverse-proxy", from = "mcp-proxy" },
+]
+`,
+				"tools/mcp-proxy/bin/mcp-proxy":         "executable content",
+				"tools/mcp-proxy/bin/mcp-reverse-proxy": "executable content",
+			},
+			wantName: "proxy",
+			wantType: "stdio",
+			wantCmd:  filepath.Join("tools", "mcp-proxy", "bin", "mcp-proxy"),
+		},
+		"managed uv tool with bin": {
+			unix: true,
+			files: map[string]string{
+				"mcp.toml": `
+name = "proxy"
+package = "uv-tool:mcp-proxy"
+bin = "mcp-reverse-proxy"
+`,
+				"tools/mcp-proxy/uv-receipt.toml": `
+[tool]
+requirements = [{ name = "mcp-proxy" }]
+entrypoints = [
+    { name = "mcp-proxy", install-path = "/elsewhere/bin/mcp-proxy", from = "mcp-proxy" },
+    { name = "mcp-reverse-proxy", install-path = "/elsewhere/bin/mcp-reverse-proxy", from = "mcp-proxy" },
+]
+`,
+				"tools/mcp-proxy/bin/mcp-proxy":         "executable content",
+				"tools/mcp-proxy/bin/mcp-reverse-proxy": "executable content",
+			},
+			wantName: "proxy",
+			wantType: "stdio",
+			wantCmd:  filepath.Join("tools", "mcp-proxy", "bin", "mcp-reverse-proxy"),
+		},
+		"managed uv tool with unknown bin": {
+			unix: true,
+			files: map[string]string{
+				"mcp.toml": `
+name = "proxy"
+package = "uv-tool:mcp-proxy"
+bin = "missing"
+`,
+				"tools/mcp-proxy/uv-receipt.toml": `
+[tool]
+requirements = [{ name = "mcp-proxy" }]
+entrypoints = [
+    { name = "mcp-proxy", install-path = "/elsewhere/bin/mcp-proxy", from = "mcp-proxy" },
+    { name = "mcp-reverse-proxy", install-path = "/elsewhere/bin/mcp-reverse-proxy", from = "mcp-proxy" },
+]
+`,
+				"tools/mcp-proxy/bin/mcp-proxy":         "executable content",
+				"tools/mcp-proxy/bin/mcp-reverse-proxy": "executable content",
+			},
+			wantErr: true,
+		},
 		"managed go": {
+			unix: true,
 			files: map[string]string{
 				"mcp.toml": `
 name = "go-test"
@@ -118,6 +209,7 @@ package = "go:github.com/example/my-tool"
 			wantCmd:  filepath.Join("bin", "my-tool"),
 		},
 		"managed go scoped": {
+			unix: true,
 			files: map[string]string{
 				"mcp.toml": `
 name = "go-scoped"
@@ -168,10 +260,13 @@ path = "/mcp"
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
+			if tc.unix && runtime.GOOS == "windows" {
+				t.Skip("Unix package layout")
+			}
 			dir := setupDir(t, tc.files)
-			server, err := Load(dir)
+			server, err := LoadWith(dir, tc.opts)
 			if (err != nil) != tc.wantErr {
-				t.Fatalf("Load() error = %v, wantErr %v", err, tc.wantErr)
+				t.Fatalf("LoadWith() error = %v, wantErr %v", err, tc.wantErr)
 			}
 			if tc.wantErr {
 				return
@@ -232,16 +327,113 @@ path = "/mcp"
 	}
 }
 
+func TestLoadExec(t *testing.T) {
+	tests := map[string]struct {
+		config   string
+		files    map[string]string
+		args     []string
+		opts     Options
+		wantCmd  string
+		wantArgs []string
+		wantEnv  map[string]string
+		wantErr  bool
+		unix     bool // see TestLoad
+	}{
+		"npm replaces configured args after the runtime's script": {
+			config: `
+name = "npm-exec"
+package = "npm:my-pkg"
+args = ["--stdio"]
+env = { TOKEN = "secret" }
+`,
+			opts:     Options{Runtime: "/usr/local/bin/node"},
+			files:    map[string]string{"node_modules/my-pkg/package.json": `{"bin": "cli.js"}`},
+			args:     []string{"--version"},
+			wantCmd:  "/usr/local/bin/node",
+			wantArgs: []string{"node_modules/.bin/my-pkg", "--version"},
+			wantEnv:  map[string]string{"TOKEN": "secret"},
+			unix:     true,
+		},
+		"go without args": {
+			config: `
+name = "go-exec"
+package = "go:github.com/example/my-tool"
+args = ["serve"]
+`,
+			files:   map[string]string{"bin/my-tool": "executable content"},
+			wantCmd: "bin/my-tool",
+			unix:    true,
+		},
+		"not a managed package": {
+			config: `
+name = "simple"
+command = "echo"
+`,
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if tc.unix && runtime.GOOS == "windows" {
+				t.Skip("Unix package layout")
+			}
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "mcp.toml"), []byte(tc.config), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			for path, content := range tc.files {
+				full := filepath.Join(dir, path)
+				if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			server, err := LoadExec(dir, tc.args, tc.opts)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("LoadExec() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+
+			abs := func(p string) string {
+				if filepath.IsAbs(p) || !strings.Contains(p, "/") {
+					return p
+				}
+				return filepath.Join(dir, p)
+			}
+			if got, want := server.Command(), abs(tc.wantCmd); got != want {
+				t.Errorf("Command() = %q, want %q", got, want)
+			}
+			var wantArgs []string
+			for _, a := range tc.wantArgs {
+				wantArgs = append(wantArgs, abs(a))
+			}
+			if !reflect.DeepEqual(server.Args(), wantArgs) {
+				t.Errorf("Args() = %v, want %v", server.Args(), wantArgs)
+			}
+			if !reflect.DeepEqual(server.Env(), tc.wantEnv) {
+				t.Errorf("Env() = %v, want %v", server.Env(), tc.wantEnv)
+			}
+		})
+	}
+}
+
 func TestLoadContainerStdio(t *testing.T) {
 	// Stub the container engine detection so tests don't require docker/podman.
 	orig := detectContainerEngine
 	t.Cleanup(func() { detectContainerEngine = orig })
-	detectContainerEngine = func() (*container.Engine, error) {
-		return &container.Engine{Path: "/usr/bin/docker", Name: "docker"}, nil
+	detectContainerEngine = func() (container.Engine, error) {
+		return &container.Fake{Binary: "/usr/bin/docker"}, nil
 	}
 
 	tests := map[string]struct {
 		files    map[string]string
+		opts     Options
 		wantName string
 		wantCmd  string
 		wantArgs []string
@@ -266,12 +458,12 @@ name = "full-container"
 transport = "stdio"
 image = "my-image:latest"
 digest = "def456"
-volumes = ["/host/data:/data:ro"]
 network = "host"
 args = ["--verbose"]
 env = { API_KEY = "secret" }
 `,
 			},
+			opts:     Options{Volumes: []string{"/host/data:/data:ro"}},
 			wantName: "full-container",
 			wantCmd:  "/usr/bin/docker",
 			wantArgs: []string{
@@ -283,6 +475,53 @@ env = { API_KEY = "secret" }
 				"--verbose",
 			},
 		},
+		"ignores stored volumes": {
+			files: map[string]string{
+				"mcp.toml": `
+name = "stale-container"
+transport = "stdio"
+image = "my-image:latest"
+volumes = ["/other/project/data:/data"]
+`,
+			},
+			wantName: "stale-container",
+			wantCmd:  "/usr/bin/docker",
+			wantArgs: []string{"run", "--rm", "-i", "my-image:latest"},
+		},
+		"with network aliases": {
+			files: map[string]string{
+				"mcp.toml": `
+name = "kind-container"
+transport = "stdio"
+image = "my-image:latest"
+network = "kind"
+network_aliases = ["mcp", "tools"]
+`,
+			},
+			wantName: "kind-container",
+			wantCmd:  "/usr/bin/docker",
+			wantArgs: []string{
+				"run", "--rm", "-i",
+				"--network", "kind",
+				"--network-alias", "mcp",
+				"--network-alias", "tools",
+				"my-image:latest",
+			},
+		},
+		"manifest digest": {
+			files: map[string]string{
+				"mcp.toml": `
+name = "pinned-container"
+transport = "stdio"
+image = "ghcr.io/org/my-image:latest"
+digest = "abc123"
+manifest_digest = "sha256:m1"
+`,
+			},
+			wantName: "pinned-container",
+			wantCmd:  "/usr/bin/docker",
+			wantArgs: []string{"run", "--rm", "-i", "ghcr.io/org/my-image@sha256:m1"},
+		},
 		"no digest": {
 			files: map[string]string{
 				"mcp.toml": `
@@ -300,9 +539,9 @@ image = "my-image:latest"
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
 			dir := setupDir(t, tc.files)
-			server, err := Load(dir)
+			server, err := LoadWith(dir, tc.opts)
 			if err != nil {
-				t.Fatalf("Load() error = %v", err)
+				t.Fatalf("LoadWith() error = %v", err)
 			}
 
 			if server.Name() != tc.wantName {
@@ -0,0 +1,19 @@
+package mcp
+
+// WithName returns server with its agent config entry named name. Apply
+// it after the other With functions, which leave a renamed server
+// unchanged.
+func WithName(server MCPServer, name string) MCPServer {
+	if name == server.Name() {
+		return server
+	}
+	return &renamedServer{MCPServer: server, name: name}
+}
+
+// renamedServer is an MCPServer under another name.
+type renamedServer struct {
+	MCPServer
+	name string
+}
+
+func (s *renamedServer) Name() string { return s.name }
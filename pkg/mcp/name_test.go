@@ -0,0 +1,31 @@
+package mcp
+
+import "testing"
+
+func TestWithName(t *testing.T) {
+	tests := map[string]struct {
+		server MCPServer
+		name   string
+	}{
+		"renamed": {
+			server: &localStdioMcpServer{name: "fs", command: "fs-server"},
+			name:   "fs-billing-1a2b3c",
+		},
+		"same name": {
+			server: &httpMCPServer{name: "api", url: "https://example.com/mcp"},
+			name:   "api",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := WithName(tc.server, tc.name)
+			if got.Name() != tc.name {
+				t.Errorf("Name() = %q, want %q", got.Name(), tc.name)
+			}
+			if got.Command() != tc.server.Command() || got.URL() != tc.server.URL() {
+				t.Errorf("WithName() = %+v, want %+v renamed", got, tc.server)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package mcp
+
+import "maps"
+
+// WithProject returns server with its requests identifying project (the
+// absolute project directory) to apkg serve, which restricts the projects
+// allowed to reach a server (see serve.Server.Access). Servers not routed
+// through apkg serve, and an empty project, are returned unchanged.
+func WithProject(server MCPServer, project string) MCPServer {
+	s, ok := server.(*httpMCPServer)
+	if !ok || project == "" {
+		return server
+	}
+	if _, routed := s.headers[serveRouteHeader]; !routed {
+		return server
+	}
+
+	withProject := *s
+	withProject.headers = maps.Clone(s.headers)
+	withProject.headers[serveRouteProjectHeader] = project
+	return &withProject
+}
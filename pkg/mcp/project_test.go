@@ -0,0 +1,44 @@
+package mcp
+
+import "testing"
+
+func TestWithProject(t *testing.T) {
+	tests := map[string]struct {
+		server      MCPServer
+		project     string
+		wantProject string
+	}{
+		"served container": {
+			server: &httpMCPServer{name: "db", headers: map[string]string{
+				serveRouteHeader:       "db",
+				serveRouteDigestHeader: "abc",
+			}},
+			project:     "/work/billing",
+			wantProject: "/work/billing",
+		},
+		"global install": {
+			server:  &httpMCPServer{name: "db", headers: map[string]string{serveRouteHeader: "db"}},
+			project: "",
+		},
+		"external http server": {
+			server:  &httpMCPServer{name: "api", url: "https://example.com/mcp"},
+			project: "/work/billing",
+		},
+		"stdio server": {
+			server:  &localStdioMcpServer{name: "fs", command: "fs-server"},
+			project: "/work/billing",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := WithProject(tc.server, tc.project)
+			if project := got.Headers()[serveRouteProjectHeader]; project != tc.wantProject {
+				t.Errorf("project header = %q, want %q", project, tc.wantProject)
+			}
+			if _, ok := tc.server.Headers()[serveRouteProjectHeader]; ok {
+				t.Error("WithProject modified the original server's headers")
+			}
+		})
+	}
+}
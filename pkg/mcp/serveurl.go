@@ -0,0 +1,27 @@
+package mcp
+
+import "strings"
+
+// WithServeURL returns server with its requests sent to the apkg serve
+// proxy at base (e.g. "http://localhost:19600", or an http+unix:// URL for
+// its socket) instead of the default port. Servers not routed through apkg
+// serve, and an empty base, are returned unchanged.
+func WithServeURL(server MCPServer, base string) MCPServer {
+	if base == "" || base == serveProxyURL || !ServeRouted(server) {
+		return server
+	}
+
+	withURL := *server.(*httpMCPServer)
+	withURL.url = strings.TrimSuffix(base, "/") + strings.TrimPrefix(withURL.url, serveProxyURL)
+	return &withURL
+}
+
+// ServeRouted reports whether server is reached through apkg serve.
+func ServeRouted(server MCPServer) bool {
+	s, ok := server.(*httpMCPServer)
+	if !ok {
+		return false
+	}
+	_, routed := s.headers[serveRouteHeader]
+	return routed && strings.HasPrefix(s.url, serveProxyURL)
+}
@@ -0,0 +1,55 @@
+package mcp
+
+import "testing"
+
+func TestWithServeURL(t *testing.T) {
+	tests := map[string]struct {
+		server  MCPServer
+		base    string
+		wantURL string
+	}{
+		"served container": {
+			server: &httpMCPServer{name: "db", url: serveProxyURL + "/mcp", headers: map[string]string{
+				serveRouteHeader: "db",
+			}},
+			base:    "http://localhost:19600",
+			wantURL: "http://localhost:19600/mcp",
+		},
+		"trailing slash": {
+			server:  &httpMCPServer{name: "db", url: serveProxyURL + "/mcp", headers: map[string]string{serveRouteHeader: "db"}},
+			base:    "http://localhost:19600/",
+			wantURL: "http://localhost:19600/mcp",
+		},
+		"unix socket": {
+			server:  &httpMCPServer{name: "db", url: serveProxyURL + "/mcp", headers: map[string]string{serveRouteHeader: "db"}},
+			base:    "http+unix://%2Frun%2Fapkg.sock",
+			wantURL: "http+unix://%2Frun%2Fapkg.sock/mcp",
+		},
+		"default base": {
+			server:  &httpMCPServer{name: "db", url: serveProxyURL + "/mcp", headers: map[string]string{serveRouteHeader: "db"}},
+			wantURL: serveProxyURL + "/mcp",
+		},
+		"external http server": {
+			server:  &httpMCPServer{name: "api", url: "https://example.com/mcp"},
+			base:    "http://localhost:19600",
+			wantURL: "https://example.com/mcp",
+		},
+		"stdio server": {
+			server: &localStdioMcpServer{name: "fs", command: "fs-server"},
+			base:   "http://localhost:19600",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			original := tc.server.URL()
+			got := WithServeURL(tc.server, tc.base)
+			if got.URL() != tc.wantURL {
+				t.Errorf("URL() = %q, want %q", got.URL(), tc.wantURL)
+			}
+			if tc.server.URL() != original {
+				t.Error("WithServeURL modified the original server")
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package mcp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// uvReceiptFile is the file uv writes to a tool's environment recording
+// how it was installed and the console scripts it provides.
+const uvReceiptFile = "uv-receipt.toml"
+
+type uvReceipt struct {
+	Tool struct {
+		Entrypoints []struct {
+			Name string `toml:"name"`
+		} `toml:"entrypoints"`
+	} `toml:"tool"`
+}
+
+// resolveUVToolBin finds the console script to run for a uv-tool package
+// installed at dir. The tool's receipt under tools/ lists its scripts:
+// bin picks one by name, otherwise the one named like the package or the
+// only one is used. The script is run from the tool's own environment.
+func resolveUVToolBin(dir, pkg, bin string) (string, error) {
+	pkgName := strings.TrimPrefix(pkg, "uv-tool:")
+	if idx := strings.Index(pkgName, "=="); idx >= 0 {
+		pkgName = pkgName[:idx]
+	}
+
+	receipts, err := filepath.Glob(filepath.Join(dir, "tools", "*", uvReceiptFile))
+	if err != nil {
+		return "", fmt.Errorf("finding uv tool receipt: %w", err)
+	}
+	if len(receipts) != 1 {
+		return "", fmt.Errorf("expected one uv tool receipt in %s, found %d", filepath.Join(dir, "tools"), len(receipts))
+	}
+	data, err := os.ReadFile(receipts[0])
+	if err != nil {
+		return "", fmt.Errorf("reading uv tool receipt: %w", err)
+	}
+	var receipt uvReceipt
+	if err := toml.Unmarshal(data, &receipt); err != nil {
+		return "", fmt.Errorf("parsing %s: %w", receipts[0], err)
+	}
+
+	var names []string
+	for _, ep := range receipt.Tool.Entrypoints {
+		names = append(names, ep.Name)
+	}
+	name := bin
+	switch {
+	case bin != "":
+		if !slices.Contains(names, bin) {
+			return "", fmt.Errorf("%s has no console script %q (it has %s)", pkgName, bin, strings.Join(names, ", "))
+		}
+	case slices.Contains(names, pkgName):
+		name = pkgName
+	case len(names) == 1:
+		name = names[0]
+	case len(names) == 0:
+		return "", fmt.Errorf("%s has no console scripts", pkgName)
+	default:
+		return "", fmt.Errorf("%s has several console scripts (%s); set bin to the one to run", pkgName, strings.Join(names, ", "))
+	}
+
+	binPath := filepath.Join(venvBinDir(filepath.Dir(receipts[0]), runtime.GOOS), exeName(name, runtime.GOOS))
+	if _, err := os.Stat(binPath); err != nil {
+		return "", fmt.Errorf("binary not found at %s: %w", binPath, err)
+	}
+	return binPath, nil
+}
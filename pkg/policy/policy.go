@@ -0,0 +1,208 @@
+// Package policy asks an external approval system whether packages may be
+// installed, so organizations can hold apkg installs to their own review
+// and scanning processes.
+//
+// Before projecting anything, an install sends the hook its plan: every
+// package with the source and version it resolved to. The hook answers
+// with a decision per package, and the install fails if any package is
+// denied or left without a decision.
+//
+// A hook is an executable, which reads the plan as JSON on stdin and
+// writes the decisions as JSON to stdout, or an HTTP(S) endpoint, which is
+// POSTed the plan and responds with the decisions:
+//
+//	{"project": "/src/app", "global": false, "packages": [
+//	  {"kind": "skill", "name": "pdf", "source": "https://github.com/org/skills.git//pdf@main",
+//	   "version": "3f2c...", "integrity": "sha256:..."}]}
+//
+//	{"decisions": [{"kind": "skill", "name": "pdf", "allow": false, "reason": "not reviewed"}]}
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Timeout bounds how long a hook may take to decide.
+const Timeout = 30 * time.Second
+
+// Package is a package an install is about to install.
+type Package struct {
+	Kind string `json:"kind"` // "skill" or "mcp"
+	Name string `json:"name"`
+	// Source is where the package comes from, e.g. a git URL and path, a
+	// local path, a managed package, an image, or a URL.
+	Source string `json:"source"`
+	// Version is the commit, release, or image digest it resolved to.
+	Version   string `json:"version,omitempty"`
+	Integrity string `json:"integrity,omitempty"`
+}
+
+// Plan is what an install sends a hook.
+type Plan struct {
+	Project  string    `json:"project,omitempty"`
+	Global   bool      `json:"global"`
+	Packages []Package `json:"packages"`
+}
+
+// Decision is a hook's answer for one package of a plan.
+type Decision struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Allow  bool   `json:"allow"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// response is what a hook answers with.
+type response struct {
+	Decisions []Decision `json:"decisions"`
+}
+
+// Hook decides whether the packages of a plan may be installed.
+type Hook interface {
+	Check(ctx context.Context, plan *Plan) ([]Decision, error)
+}
+
+// New returns the hook spec configures: an http:// or https:// URL is
+// POSTed to, anything else is a command, split on spaces, that is run.
+func New(spec string) (Hook, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "http://") || strings.HasPrefix(spec, "https://") {
+		return &HTTPHook{URL: spec}, nil
+	}
+	fields := strings.Fields(spec)
+	if len(fields) == 0 {
+		return nil, errors.New("policy hook is empty")
+	}
+	return &ExecHook{Command: fields[0], Args: fields[1:]}, nil
+}
+
+// DeniedError reports the packages of a plan a hook didn't allow.
+type DeniedError struct {
+	Denied []Decision
+}
+
+func (e *DeniedError) Error() string {
+	lines := make([]string, len(e.Denied))
+	for i, d := range e.Denied {
+		lines[i] = fmt.Sprintf("  %s %q", d.Kind, d.Name)
+		if d.Reason != "" {
+			lines[i] += ": " + d.Reason
+		}
+	}
+	return fmt.Sprintf("policy hook denied %d package(s):\n%s", len(e.Denied), strings.Join(lines, "\n"))
+}
+
+// Enforce checks plan with hook, returning a *DeniedError listing the
+// packages it denied or made no decision for.
+func Enforce(ctx context.Context, hook Hook, plan *Plan) error {
+	if len(plan.Packages) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	decisions, err := hook.Check(ctx, plan)
+	if err != nil {
+		return fmt.Errorf("policy hook: %w", err)
+	}
+
+	byPackage := make(map[[2]string]Decision, len(decisions))
+	for _, d := range decisions {
+		byPackage[[2]string{d.Kind, d.Name}] = d
+	}
+	var denied []Decision
+	for _, pkg := range plan.Packages {
+		d, ok := byPackage[[2]string{pkg.Kind, pkg.Name}]
+		if !ok {
+			d = Decision{Kind: pkg.Kind, Name: pkg.Name, Reason: "no decision"}
+		}
+		if !d.Allow {
+			denied = append(denied, d)
+		}
+	}
+	if len(denied) > 0 {
+		return &DeniedError{Denied: denied}
+	}
+	return nil
+}
+
+// ExecHook runs a command with the plan on stdin and reads the decisions
+// from its stdout. A non-zero exit fails the check.
+type ExecHook struct {
+	Command string
+	Args    []string
+}
+
+func (h *ExecHook) Check(ctx context.Context, plan *Plan) ([]Decision, error) {
+	data, err := json.Marshal(plan)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, h.Command, h.Args...)
+	cmd.Stdin = bytes.NewReader(data)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("running %s: %w: %s", h.Command, err, msg)
+		}
+		return nil, fmt.Errorf("running %s: %w", h.Command, err)
+	}
+	return parseResponse(out)
+}
+
+// HTTPHook POSTs the plan to URL and reads the decisions from the
+// response. A status other than 200 fails the check.
+type HTTPHook struct {
+	URL string
+	// Client sends the request; nil uses http.DefaultClient.
+	Client *http.Client
+}
+
+func (h *HTTPHook) Check(ctx context.Context, plan *Plan) ([]Decision, error) {
+	data, err := json.Marshal(plan)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("reading response from %s: %w", h.URL, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s responded %s: %s", h.URL, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return parseResponse(body)
+}
+
+func parseResponse(data []byte) ([]Decision, error) {
+	var resp response
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("parsing decisions: %w", err)
+	}
+	return resp.Decisions, nil
+}
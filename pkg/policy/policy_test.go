@@ -0,0 +1,111 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestEnforce(t *testing.T) {
+	plan := &Plan{Packages: []Package{
+		{Kind: "skill", Name: "pdf", Source: "./skills/pdf"},
+		{Kind: "mcp", Name: "github", Source: "npm:@modelcontextprotocol/server-github", Version: "1.0.0"},
+	}}
+
+	tests := map[string]struct {
+		decisions  []Decision
+		wantDenied []string
+	}{
+		"all allowed": {
+			decisions: []Decision{
+				{Kind: "skill", Name: "pdf", Allow: true},
+				{Kind: "mcp", Name: "github", Allow: true},
+			},
+		},
+		"one denied": {
+			decisions: []Decision{
+				{Kind: "skill", Name: "pdf", Allow: true},
+				{Kind: "mcp", Name: "github", Reason: "not reviewed"},
+			},
+			wantDenied: []string{"github"},
+		},
+		"missing decision denies": {
+			decisions:  []Decision{{Kind: "mcp", Name: "github", Allow: true}},
+			wantDenied: []string{"pdf"},
+		},
+		"decision for another kind": {
+			decisions: []Decision{
+				{Kind: "mcp", Name: "pdf", Allow: true},
+				{Kind: "mcp", Name: "github", Allow: true},
+			},
+			wantDenied: []string{"pdf"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var got Plan
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil || len(got.Packages) != 2 {
+					t.Errorf("hook received %+v (%v), want the plan", got, err)
+				}
+				json.NewEncoder(w).Encode(response{Decisions: tc.decisions})
+			}))
+			defer srv.Close()
+
+			hook, err := New(srv.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = Enforce(context.Background(), hook, plan)
+			var denied *DeniedError
+			if len(tc.wantDenied) == 0 {
+				if err != nil {
+					t.Errorf("Enforce() error = %v", err)
+				}
+				return
+			}
+			if !errors.As(err, &denied) {
+				t.Fatalf("Enforce() error = %v, want *DeniedError", err)
+			}
+			var names []string
+			for _, d := range denied.Denied {
+				names = append(names, d.Name)
+			}
+			if len(names) != len(tc.wantDenied) || names[0] != tc.wantDenied[0] {
+				t.Errorf("denied = %v, want %v", names, tc.wantDenied)
+			}
+		})
+	}
+}
+
+func TestExecHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script")
+	}
+	script := filepath.Join(t.TempDir(), "hook")
+	body := "#!/bin/sh\ncat > /dev/null\necho '{\"decisions\": [{\"kind\": \"skill\", \"name\": \"pdf\", \"allow\": true}]}'\n"
+	if err := os.WriteFile(script, []byte(body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	hook, err := New(script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan := &Plan{Packages: []Package{{Kind: "skill", Name: "pdf"}}}
+	if err := Enforce(context.Background(), hook, plan); err != nil {
+		t.Errorf("Enforce() error = %v", err)
+	}
+
+	failing := &ExecHook{Command: "false"}
+	if err := Enforce(context.Background(), failing, plan); err == nil {
+		t.Error("Enforce() with a failing hook error = nil, want an error")
+	}
+}
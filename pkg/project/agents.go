@@ -0,0 +1,71 @@
+package project
+
+import (
+	"fmt"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/projector"
+)
+
+// AgentInfo describes a registered agent and where it projects packages.
+type AgentInfo struct {
+	Name               string
+	SupportsSkills     bool
+	SupportsMCPServers bool
+
+	// Project and Global are the agent's targets for the project and for
+	// global installs.
+	Project []Target
+	Global  []Target
+}
+
+// Target is a file or directory an agent writes projections to.
+type Target struct {
+	Kind   string // "skills" or "mcp"
+	Path   string
+	Exists bool
+}
+
+// DescribeAgents returns every registered agent with its projection
+// targets for the project in projectDir and for global installs.
+func DescribeAgents(projectDir string) ([]AgentInfo, error) {
+	home, err := config.HomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	var infos []AgentInfo
+	for _, agent := range projector.RegisteredAgents() {
+		proj, _ := projector.GetProjector(agent)
+		info := AgentInfo{
+			Name:               agent,
+			SupportsSkills:     proj.SupportsSkills(),
+			SupportsMCPServers: proj.SupportsMCPServers(),
+		}
+
+		projectTargets, err := proj.Targets(projector.ProjectionOpts{ProjectDir: projectDir, Scope: projector.ScopeLocal})
+		if err != nil {
+			return nil, fmt.Errorf("resolving %s targets: %w", agent, err)
+		}
+		globalTargets, err := proj.Targets(projector.ProjectionOpts{ProjectDir: home, Scope: projector.ScopeGlobal})
+		if err != nil {
+			return nil, fmt.Errorf("resolving %s targets: %w", agent, err)
+		}
+
+		info.Project = targetList(info, projectTargets)
+		info.Global = targetList(info, globalTargets)
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func targetList(info AgentInfo, t projector.Targets) []Target {
+	var targets []Target
+	if info.SupportsSkills && t.SkillsDir != "" {
+		targets = append(targets, Target{Kind: "skills", Path: t.SkillsDir, Exists: exists(t.SkillsDir)})
+	}
+	if info.SupportsMCPServers && t.MCPConfig != "" {
+		targets = append(targets, Target{Kind: "mcp", Path: t.MCPConfig, Exists: exists(t.MCPConfig)})
+	}
+	return targets
+}
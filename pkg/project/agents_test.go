@@ -0,0 +1,57 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDescribeAgents(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	projectDir := t.TempDir()
+	os.MkdirAll(filepath.Join(projectDir, ".alpha", "skills"), 0o755)
+	os.MkdirAll(filepath.Join(home, ".beta", "skills"), 0o755)
+
+	infos, err := DescribeAgents(projectDir)
+	if err != nil {
+		t.Fatalf("DescribeAgents() error = %v", err)
+	}
+
+	byName := make(map[string]AgentInfo)
+	for _, info := range infos {
+		byName[info.Name] = info
+	}
+
+	tests := map[string]struct {
+		wantProject Target
+		wantGlobal  Target
+	}{
+		"test-alpha": {
+			wantProject: Target{Kind: "skills", Path: filepath.Join(projectDir, ".alpha", "skills"), Exists: true},
+			wantGlobal:  Target{Kind: "skills", Path: filepath.Join(home, ".alpha", "skills")},
+		},
+		"test-beta": {
+			wantProject: Target{Kind: "skills", Path: filepath.Join(projectDir, ".beta", "skills")},
+			wantGlobal:  Target{Kind: "skills", Path: filepath.Join(home, ".beta", "skills"), Exists: true},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			info, ok := byName[name]
+			if !ok {
+				t.Fatalf("agent %q not described", name)
+			}
+			if !info.SupportsSkills || !info.SupportsMCPServers {
+				t.Errorf("supports = (%v, %v), want (true, true)", info.SupportsSkills, info.SupportsMCPServers)
+			}
+			if len(info.Project) != 1 || info.Project[0] != tc.wantProject {
+				t.Errorf("Project = %+v, want [%+v]", info.Project, tc.wantProject)
+			}
+			if len(info.Global) != 1 || info.Global[0] != tc.wantGlobal {
+				t.Errorf("Global = %+v, want [%+v]", info.Global, tc.wantGlobal)
+			}
+		})
+	}
+}
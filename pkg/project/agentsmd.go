@@ -0,0 +1,61 @@
+package project
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// AgentsFile is the file of instructions for coding agents at the project
+// root (see https://agents.md).
+const AgentsFile = "AGENTS.md"
+
+// Markers delimiting the section InjectAgentsSection manages in
+// AgentsFile.
+const (
+	agentsSectionStart = "<!-- apkg:overview:start -->"
+	agentsSectionEnd   = "<!-- apkg:overview:end -->"
+)
+
+// InjectAgentsSection writes content into the AgentsFile in dir, between
+// markers that let later calls replace it without touching the rest of
+// the file: the section is replaced where the markers are, appended
+// otherwise, and the file created if there is none. Returns whether the
+// file changed.
+func InjectAgentsSection(dir string, content []byte) (bool, error) {
+	path := filepath.Join(dir, AgentsFile)
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	var section bytes.Buffer
+	section.WriteString(agentsSectionStart + "\n")
+	section.Write(bytes.TrimRight(content, "\n"))
+	section.WriteString("\n" + agentsSectionEnd)
+
+	var updated []byte
+	start := bytes.Index(existing, []byte(agentsSectionStart))
+	end := bytes.Index(existing, []byte(agentsSectionEnd))
+	switch {
+	case start >= 0 && end > start:
+		updated = append(updated, existing[:start]...)
+		updated = append(updated, section.Bytes()...)
+		updated = append(updated, existing[end+len(agentsSectionEnd):]...)
+	case len(bytes.TrimSpace(existing)) == 0:
+		updated = append(section.Bytes(), '\n')
+	default:
+		updated = append(bytes.TrimRight(existing, "\n"), "\n\n"...)
+		updated = append(updated, section.Bytes()...)
+		updated = append(updated, '\n')
+	}
+
+	if bytes.Equal(updated, existing) {
+		return false, nil
+	}
+	if err := os.WriteFile(path, updated, 0o644); err != nil {
+		return false, fmt.Errorf("writing %s: %w", path, err)
+	}
+	return true, nil
+}
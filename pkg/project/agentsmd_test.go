@@ -0,0 +1,70 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInjectAgentsSection(t *testing.T) {
+	section := agentsSectionStart + "\n## Tooling\n" + agentsSectionEnd
+
+	tests := map[string]struct {
+		existing    *string
+		content     string
+		want        string
+		wantChanged bool
+	}{
+		"creates the file": {
+			content:     "## Tooling\n",
+			want:        section + "\n",
+			wantChanged: true,
+		},
+		"appends to instructions": {
+			existing:    ptr("# Agents\n\nRun make test.\n"),
+			content:     "## Tooling\n",
+			want:        "# Agents\n\nRun make test.\n\n" + section + "\n",
+			wantChanged: true,
+		},
+		"replaces the section in place": {
+			existing:    ptr("# Agents\n\n" + agentsSectionStart + "\n## Old\n" + agentsSectionEnd + "\n\nMore notes.\n"),
+			content:     "## Tooling\n",
+			want:        "# Agents\n\n" + section + "\n\nMore notes.\n",
+			wantChanged: true,
+		},
+		"unchanged": {
+			existing: ptr("# Agents\n\n" + section + "\n"),
+			content:  "## Tooling\n",
+			want:     "# Agents\n\n" + section + "\n",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, AgentsFile)
+			if tc.existing != nil {
+				if err := os.WriteFile(path, []byte(*tc.existing), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			changed, err := InjectAgentsSection(dir, []byte(tc.content))
+			if err != nil {
+				t.Fatalf("InjectAgentsSection() error = %v", err)
+			}
+			if changed != tc.wantChanged {
+				t.Errorf("changed = %v, want %v", changed, tc.wantChanged)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("%s = %q, want %q", AgentsFile, got, tc.want)
+			}
+		})
+	}
+}
+
+func ptr(s string) *string { return &s }
@@ -0,0 +1,167 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/projector"
+)
+
+// GitignoreSyncResult describes the changes made by SyncGitignore.
+type GitignoreSyncResult struct {
+	// Added lists entries appended for the configured agents.
+	Added []string
+	// Removed lists entries of unconfigured agents removed with prune.
+	Removed []string
+	// Stale lists entries of unconfigured agents left in place because
+	// prune was not requested.
+	Stale []string
+}
+
+// AgentGitignoreEntries returns the deduplicated gitignore entries of the
+// given agents' projectors, in agent order. Unknown agents are skipped.
+func AgentGitignoreEntries(agents []string) []string {
+	var entries []string
+	for _, agent := range agents {
+		proj, ok := projector.GetProjector(agent)
+		if !ok {
+			continue
+		}
+		for _, entry := range proj.GitignoreEntries() {
+			if !slices.Contains(entries, entry) {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	return entries
+}
+
+// UnprojectedAgents returns the agents that have not been projected into
+// dir yet and whose gitignore entries are missing from .gitignore. An agent
+// counts as projected once any of its entries exists on disk, so a user who
+// declined to ignore an agent's files is only asked once.
+func UnprojectedAgents(dir string, agents []string) ([]string, error) {
+	present, err := readGitignore(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for _, agent := range agents {
+		entries := AgentGitignoreEntries([]string{agent})
+		if len(entries) == 0 {
+			continue
+		}
+
+		seen := false
+		for _, entry := range entries {
+			if present[entry] || exists(filepath.Join(dir, entry)) {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			result = append(result, agent)
+		}
+	}
+	return result, nil
+}
+
+// SyncGitignore reconciles .gitignore in dir with the configured agents:
+// entries for the agents (and apkg.local.toml) are appended if missing.
+// Entries belonging only to other registered agents are removed when prune
+// is set, and reported as stale otherwise.
+func SyncGitignore(dir string, agents []string, prune bool) (*GitignoreSyncResult, error) {
+	want := append([]string{config.LocalConfigFile}, AgentGitignoreEntries(agents)...)
+
+	added, err := EnsureGitignore(dir, want)
+	if err != nil {
+		return nil, err
+	}
+
+	present, err := readGitignore(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var stale []string
+	for _, entry := range AgentGitignoreEntries(projector.RegisteredAgents()) {
+		if present[entry] && !slices.Contains(want, entry) {
+			stale = append(stale, entry)
+		}
+	}
+
+	result := &GitignoreSyncResult{Added: added}
+	if !prune {
+		result.Stale = stale
+		return result, nil
+	}
+
+	result.Removed, err = RemoveGitignoreEntries(dir, stale)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// RemoveGitignoreEntries removes lines matching any of entries from the
+// .gitignore file within dir. Returns the entries that were actually removed.
+func RemoveGitignoreEntries(dir string, entries []string) ([]string, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	path := filepath.Join(dir, ".gitignore")
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	var kept, removed []string
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		entry := strings.TrimSpace(line)
+		if slices.Contains(entries, entry) {
+			if !slices.Contains(removed, entry) {
+				removed = append(removed, entry)
+			}
+			continue
+		}
+		kept = append(kept, line)
+	}
+
+	if len(removed) == 0 {
+		return nil, nil
+	}
+
+	if err := os.WriteFile(path, []byte(strings.Join(kept, "")), 0o644); err != nil {
+		return nil, fmt.Errorf("writing %s: %w", path, err)
+	}
+	return removed, nil
+}
+
+// readGitignore returns the set of trimmed lines in dir's .gitignore.
+func readGitignore(dir string) (map[string]bool, error) {
+	path := filepath.Join(dir, ".gitignore")
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	present := make(map[string]bool)
+	for _, line := range strings.Split(string(data), "\n") {
+		present[strings.TrimSpace(line)] = true
+	}
+	return present, nil
+}
+
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
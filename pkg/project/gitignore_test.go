@@ -0,0 +1,143 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/mcp"
+	"github.com/agentpkg/agentpkg/pkg/projector"
+	"github.com/agentpkg/agentpkg/pkg/skill"
+)
+
+type stubProjector struct {
+	entries []string
+}
+
+func (s *stubProjector) GitignoreEntries() []string { return s.entries }
+func (s *stubProjector) Targets(opts projector.ProjectionOpts) (projector.Targets, error) {
+	return projector.Targets{SkillsDir: filepath.Join(opts.ProjectDir, s.entries[0], "skills")}, nil
+}
+func (s *stubProjector) SupportsSkills() bool                                            { return true }
+func (s *stubProjector) ProjectSkills(_ projector.ProjectionOpts, _ []skill.Skill) error { return nil }
+func (s *stubProjector) UnprojectSkills(_ projector.ProjectionOpts, _ []string) error    { return nil }
+func (s *stubProjector) SupportsMCPServers() bool                                        { return true }
+func (s *stubProjector) MCPLimits() projector.Limits                                     { return projector.Limits{} }
+func (s *stubProjector) MCPFormat() projector.MCPFormat                                  { return projector.MCPFormat{} }
+func (s *stubProjector) ProjectMCPServers(_ projector.ProjectionOpts, _ []mcp.MCPServer) error {
+	return nil
+}
+func (s *stubProjector) UnprojectMCPServers(_ projector.ProjectionOpts, _ []string) error { return nil }
+
+func init() {
+	projector.RegisterProjector("test-alpha", &stubProjector{entries: []string{".alpha/"}})
+	projector.RegisterProjector("test-beta", &stubProjector{entries: []string{".beta/"}})
+}
+
+func TestUnprojectedAgents(t *testing.T) {
+	tests := map[string]struct {
+		gitignore string
+		dirs      []string
+		want      []string
+	}{
+		"new agents": {
+			want: []string{"test-alpha", "test-beta"},
+		},
+		"already ignored": {
+			gitignore: ".alpha/\n",
+			want:      []string{"test-beta"},
+		},
+		"already projected": {
+			dirs: []string{".beta"},
+			want: []string{"test-alpha"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tc.gitignore != "" {
+				os.WriteFile(filepath.Join(dir, ".gitignore"), []byte(tc.gitignore), 0o644)
+			}
+			for _, d := range tc.dirs {
+				os.MkdirAll(filepath.Join(dir, d), 0o755)
+			}
+
+			got, err := UnprojectedAgents(dir, []string{"test-alpha", "test-beta", "unknown"})
+			if err != nil {
+				t.Fatalf("UnprojectedAgents() error = %v", err)
+			}
+			if !equal(got, tc.want) {
+				t.Errorf("UnprojectedAgents() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSyncGitignore(t *testing.T) {
+	tests := map[string]struct {
+		gitignore   string
+		prune       bool
+		wantAdded   []string
+		wantRemoved []string
+		wantStale   []string
+		wantFile    string
+	}{
+		"adds missing entries": {
+			gitignore: "node_modules/",
+			wantAdded: []string{"apkg.local.toml", ".alpha/"},
+			wantFile:  "node_modules/\napkg.local.toml\n.alpha/\n",
+		},
+		"reports stale entries": {
+			gitignore: "apkg.local.toml\n.alpha/\n.beta/\n",
+			wantStale: []string{".beta/"},
+			wantFile:  "apkg.local.toml\n.alpha/\n.beta/\n",
+		},
+		"prunes stale entries": {
+			gitignore:   "apkg.local.toml\n.beta/\n.alpha/\n",
+			prune:       true,
+			wantRemoved: []string{".beta/"},
+			wantFile:    "apkg.local.toml\n.alpha/\n",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, ".gitignore")
+			os.WriteFile(path, []byte(tc.gitignore), 0o644)
+
+			result, err := SyncGitignore(dir, []string{"test-alpha"}, tc.prune)
+			if err != nil {
+				t.Fatalf("SyncGitignore() error = %v", err)
+			}
+
+			if !equal(result.Added, tc.wantAdded) {
+				t.Errorf("Added = %v, want %v", result.Added, tc.wantAdded)
+			}
+			if !equal(result.Removed, tc.wantRemoved) {
+				t.Errorf("Removed = %v, want %v", result.Removed, tc.wantRemoved)
+			}
+			if !equal(result.Stale, tc.wantStale) {
+				t.Errorf("Stale = %v, want %v", result.Stale, tc.wantStale)
+			}
+
+			data, _ := os.ReadFile(path)
+			if string(data) != tc.wantFile {
+				t.Errorf(".gitignore = %q, want %q", data, tc.wantFile)
+			}
+		})
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
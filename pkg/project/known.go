@@ -0,0 +1,142 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/fsutil"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// KnownProjectsFileName is the file in apkg's global config directory
+// listing the projects apkg installed packages into, so commands can look
+// at every project on the machine (see RecordKnownProject).
+const KnownProjectsFileName = "projects.toml"
+
+// KnownProject is a project apkg installed packages into.
+type KnownProject struct {
+	Dir string `toml:"dir" json:"dir"`
+	// LastInstall is when its lockfile was last written.
+	LastInstall time.Time `toml:"last_install" json:"lastInstall"`
+}
+
+type knownProjectsFile struct {
+	Projects []KnownProject `toml:"projects,omitempty"`
+}
+
+// KnownProjectsPath returns the KnownProjectsFileName in apkg's global
+// config directory.
+func KnownProjectsPath() (string, error) {
+	dir, err := config.GlobalConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, KnownProjectsFileName), nil
+}
+
+// LoadKnownProjects reads the projects listed at path, which may not
+// exist, sorted by directory.
+func LoadKnownProjects(path string) ([]KnownProject, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	var f knownProjectsFile
+	if err := toml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return f.Projects, nil
+}
+
+// knownProjectsLockTimeout is how long writers of the known projects wait
+// for each other.
+const knownProjectsLockTimeout = 10 * time.Second
+
+// RecordKnownProject adds the project at dir to the projects listed at
+// path, or updates its LastInstall to now.
+func RecordKnownProject(path, dir string, now time.Time) error {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("resolving %s: %w", dir, err)
+	}
+	return updateKnownProjects(path, func(projects []KnownProject) []KnownProject {
+		for i := range projects {
+			if projects[i].Dir == abs {
+				projects[i].LastInstall = now.UTC()
+				return projects
+			}
+		}
+		return append(projects, KnownProject{Dir: abs, LastInstall: now.UTC()})
+	})
+}
+
+// PruneKnownProjects removes the projects without a manifest anymore, e.g.
+// because they were deleted or moved, from those listed at path, and
+// returns them.
+func PruneKnownProjects(path string) ([]KnownProject, error) {
+	var pruned []KnownProject
+	err := updateKnownProjects(path, func(projects []KnownProject) []KnownProject {
+		return slices.DeleteFunc(projects, func(p KnownProject) bool {
+			_, err := os.Stat(filepath.Join(p.Dir, ManifestFile))
+			if errors.Is(err, fs.ErrNotExist) {
+				pruned = append(pruned, p)
+				return true
+			}
+			return false
+		})
+	})
+	return pruned, err
+}
+
+// updateKnownProjects replaces the projects listed at path with what
+// update makes of them, holding a lock next to the file so concurrent
+// installs don't lose each other's projects. The file is replaced in one
+// step, so readers never see it half written.
+func updateKnownProjects(path string, update func([]KnownProject) []KnownProject) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating directory for %s: %w", path, err)
+	}
+	unlock, err := fsutil.Lock(path+".lock", knownProjectsLockTimeout)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	projects, err := LoadKnownProjects(path)
+	if err != nil {
+		return err
+	}
+	projects = update(projects)
+	sort.Slice(projects, func(i, j int) bool { return projects[i].Dir < projects[j].Dir })
+
+	data, err := toml.Marshal(knownProjectsFile{Projects: projects})
+	if err != nil {
+		return fmt.Errorf("marshaling known projects: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), KnownProjectsFileName+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing %s: %w", tmp.Name(), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRecordKnownProject(t *testing.T) {
+	t1 := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	t2 := t1.Add(24 * time.Hour)
+
+	tests := map[string]struct {
+		record []string // project dirs, recorded at t1 and then t2
+		want   []KnownProject
+	}{
+		"first project": {
+			record: []string{"/src/b"},
+			want:   []KnownProject{{Dir: filepath.FromSlash("/src/b"), LastInstall: t1}},
+		},
+		"sorted by directory": {
+			record: []string{"/src/b", "/src/a"},
+			want: []KnownProject{
+				{Dir: filepath.FromSlash("/src/a"), LastInstall: t2},
+				{Dir: filepath.FromSlash("/src/b"), LastInstall: t1},
+			},
+		},
+		"reinstall updates the time": {
+			record: []string{"/src/a", "/src/a"},
+			want:   []KnownProject{{Dir: filepath.FromSlash("/src/a"), LastInstall: t2}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), KnownProjectsFileName)
+			for i, dir := range tc.record {
+				abs, err := filepath.Abs(filepath.FromSlash(dir))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := RecordKnownProject(path, abs, t1.Add(time.Duration(i)*24*time.Hour)); err != nil {
+					t.Fatalf("RecordKnownProject() error = %v", err)
+				}
+			}
+
+			got, err := LoadKnownProjects(path)
+			if err != nil {
+				t.Fatalf("LoadKnownProjects() error = %v", err)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("LoadKnownProjects() = %v, want %v", got, tc.want)
+			}
+			for i := range got {
+				want, _ := filepath.Abs(tc.want[i].Dir)
+				if got[i].Dir != want || !got[i].LastInstall.Equal(tc.want[i].LastInstall) {
+					t.Errorf("project %d = %+v, want %+v", i, got[i], tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRecordKnownProjectConcurrently(t *testing.T) {
+	tests := map[string]struct {
+		projects int
+	}{
+		"two installs":    {projects: 2},
+		"twenty installs": {projects: 20},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, KnownProjectsFileName)
+
+			var wg sync.WaitGroup
+			errs := make(chan error, tc.projects)
+			for i := range tc.projects {
+				wg.Go(func() {
+					errs <- RecordKnownProject(path, filepath.Join(dir, fmt.Sprintf("project-%d", i)), time.Now())
+				})
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				if err != nil {
+					t.Fatalf("RecordKnownProject() error = %v", err)
+				}
+			}
+
+			got, err := LoadKnownProjects(path)
+			if err != nil {
+				t.Fatalf("LoadKnownProjects() error = %v", err)
+			}
+			if len(got) != tc.projects {
+				t.Errorf("LoadKnownProjects() has %d projects, want %d", len(got), tc.projects)
+			}
+		})
+	}
+}
+
+func TestPruneKnownProjects(t *testing.T) {
+	tests := map[string]struct {
+		projects   map[string]bool // project name to whether it has a manifest
+		wantKept   []string
+		wantPruned []string
+	}{
+		"nothing to prune": {
+			projects: map[string]bool{"a": true, "b": true},
+			wantKept: []string{"a", "b"},
+		},
+		"deleted project": {
+			projects:   map[string]bool{"a": true, "b": false},
+			wantKept:   []string{"a"},
+			wantPruned: []string{"b"},
+		},
+		"every project deleted": {
+			projects:   map[string]bool{"a": false, "b": false},
+			wantPruned: []string{"a", "b"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			root := t.TempDir()
+			path := filepath.Join(root, KnownProjectsFileName)
+			for p, hasManifest := range tc.projects {
+				dir := filepath.Join(root, p)
+				if hasManifest {
+					if err := os.MkdirAll(dir, 0o755); err != nil {
+						t.Fatal(err)
+					}
+					if err := os.WriteFile(filepath.Join(dir, ManifestFile), nil, 0o644); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if err := RecordKnownProject(path, dir, time.Now()); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			pruned, err := PruneKnownProjects(path)
+			if err != nil {
+				t.Fatalf("PruneKnownProjects() error = %v", err)
+			}
+			kept, err := LoadKnownProjects(path)
+			if err != nil {
+				t.Fatalf("LoadKnownProjects() error = %v", err)
+			}
+			if got := projectNames(pruned); !slices.Equal(got, tc.wantPruned) {
+				t.Errorf("PruneKnownProjects() = %v, want %v", got, tc.wantPruned)
+			}
+			if got := projectNames(kept); !slices.Equal(got, tc.wantKept) {
+				t.Errorf("kept projects = %v, want %v", got, tc.wantKept)
+			}
+		})
+	}
+}
+
+func projectNames(projects []KnownProject) []string {
+	var names []string
+	for _, p := range projects {
+		names = append(names, filepath.Base(p.Dir))
+	}
+	return names
+}
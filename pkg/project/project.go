@@ -16,6 +16,53 @@ func InferName(dir string) string {
 	return filepath.Base(dir)
 }
 
+// FindRoot walks up from dir to the nearest directory containing apkg.toml,
+// like git and npm do. ok is false if no parent directory has a manifest.
+func FindRoot(dir string) (root string, ok bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+
+	for {
+		if info, err := os.Stat(filepath.Join(dir, ManifestFile)); err == nil && !info.IsDir() {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// ManifestPath returns how a local path given on the command line is
+// recorded in the manifest of the project at projectDir: relative to the
+// project root (e.g. "./skills/pdf") so the project stays portable, or
+// absolute for the global manifest, which has no meaningful root.
+func ManifestPath(projectDir, path string, global bool) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("resolving %s: %w", path, err)
+	}
+	if global {
+		return abs, nil
+	}
+
+	rel, err := filepath.Rel(projectDir, abs)
+	if err != nil {
+		return abs, nil
+	}
+	switch rel = filepath.ToSlash(rel); {
+	case rel == ".":
+		return "./", nil
+	case rel == ".." || strings.HasPrefix(rel, "../"):
+		return rel, nil
+	default:
+		return "./" + rel, nil
+	}
+}
+
 // Init creates an apkg.toml manifest in dir with the given project name.
 // Returns an error if the manifest already exists.
 func Init(dir, name string) error {
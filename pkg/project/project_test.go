@@ -0,0 +1,91 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindRoot(t *testing.T) {
+	tests := map[string]struct {
+		manifestIn string
+		start      string
+		want       string
+		wantOK     bool
+	}{
+		"manifest in start dir": {
+			manifestIn: "proj",
+			start:      "proj",
+			want:       "proj",
+			wantOK:     true,
+		},
+		"manifest in ancestor": {
+			manifestIn: "proj",
+			start:      "proj/src/pkg",
+			want:       "proj",
+			wantOK:     true,
+		},
+		"no manifest": {
+			start: "proj/src",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			base := t.TempDir()
+			start := filepath.Join(base, tc.start)
+			os.MkdirAll(start, 0o755)
+			if tc.manifestIn != "" {
+				os.WriteFile(filepath.Join(base, tc.manifestIn, ManifestFile), []byte("[project]\n"), 0o644)
+			}
+
+			got, ok := FindRoot(start)
+			if ok != tc.wantOK {
+				t.Fatalf("FindRoot() ok = %v, want %v", ok, tc.wantOK)
+			}
+			if tc.wantOK && got != filepath.Join(base, tc.want) {
+				t.Errorf("FindRoot() = %q, want %q", got, filepath.Join(base, tc.want))
+			}
+		})
+	}
+}
+
+func TestManifestPath(t *testing.T) {
+	projectDir := filepath.Join(t.TempDir(), "proj")
+
+	tests := map[string]struct {
+		path   string
+		global bool
+		want   string
+	}{
+		"inside project": {
+			path: filepath.Join(projectDir, "skills", "pdf"),
+			want: "./skills/pdf",
+		},
+		"project root": {
+			path: projectDir,
+			want: "./",
+		},
+		"outside project": {
+			path: filepath.Join(filepath.Dir(projectDir), "shared"),
+			want: "../shared",
+		},
+		"global is absolute": {
+			path:   filepath.Join(projectDir, "skills", "pdf"),
+			global: true,
+			want:   filepath.Join(projectDir, "skills", "pdf"),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ManifestPath(projectDir, tc.path, tc.global)
+			if err != nil {
+				t.Fatalf("ManifestPath() error = %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("ManifestPath() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package aider
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/agentpkg/agentpkg/pkg/mcp"
+	"github.com/agentpkg/agentpkg/pkg/projector"
+	"github.com/agentpkg/agentpkg/pkg/skill"
+	"go.yaml.in/yaml/v3"
+)
+
+func init() {
+	projector.RegisterProjector("aider", &aiderProjector{
+		rp: projector.RenderedSkillProjector{
+			Dir:      filepath.Join(".aider", "conventions"),
+			Renderer: projector.ContextFileRenderer{},
+		},
+	})
+}
+
+// confFile is aider's config file, in the project or the home directory.
+const confFile = ".aider.conf.yml"
+
+// aiderProjector projects skills into aider as conventions files, since
+// aider has no skills directory: each skill is rendered to a Markdown file
+// that the "read" list of .aider.conf.yml loads into every chat. MCP
+// servers are not supported.
+type aiderProjector struct {
+	rp projector.RenderedSkillProjector
+}
+
+var _ projector.Projector = &aiderProjector{}
+
+func (a *aiderProjector) GitignoreEntries() []string {
+	return []string{".aider/"}
+}
+
+func (a *aiderProjector) Targets(opts projector.ProjectionOpts) (projector.Targets, error) {
+	return projector.Targets{SkillsDir: a.rp.SkillsDir(opts)}, nil
+}
+
+func (a *aiderProjector) SupportsSkills() bool {
+	return true
+}
+
+func (a *aiderProjector) ProjectSkills(opts projector.ProjectionOpts, packages []skill.Skill) error {
+	projectErr := a.rp.ProjectSkills(opts, packages)
+
+	// Only files apkg rendered are added, not ones it refused to replace.
+	var reads []string
+	for _, p := range packages {
+		if projector.IsGenerated(a.renderedPath(opts, p.Name())) {
+			reads = append(reads, a.readEntry(opts, p.Name()))
+		}
+	}
+	if err := updateReads(filepath.Join(opts.ProjectDir, confFile), reads, nil); err != nil {
+		return err
+	}
+	return projectErr
+}
+
+func (a *aiderProjector) UnprojectSkills(opts projector.ProjectionOpts, names []string) error {
+	reads := make([]string, len(names))
+	for i, name := range names {
+		reads[i] = a.readEntry(opts, name)
+	}
+	if err := updateReads(filepath.Join(opts.ProjectDir, confFile), nil, reads); err != nil {
+		return err
+	}
+	return a.rp.UnprojectSkills(opts, names)
+}
+
+func (a *aiderProjector) SupportsMCPServers() bool {
+	return false
+}
+
+func (a *aiderProjector) MCPLimits() projector.Limits {
+	return projector.Limits{}
+}
+
+func (a *aiderProjector) MCPFormat() projector.MCPFormat {
+	return projector.MCPFormat{}
+}
+
+func (a *aiderProjector) ProjectMCPServers(opts projector.ProjectionOpts, servers []mcp.MCPServer) error {
+	return nil
+}
+
+func (a *aiderProjector) UnprojectMCPServers(opts projector.ProjectionOpts, names []string) error {
+	return nil
+}
+
+// renderedPath returns the conventions file the skill name is rendered to.
+func (a *aiderProjector) renderedPath(opts projector.ProjectionOpts, name string) string {
+	return filepath.Join(a.rp.SkillsDir(opts), a.rp.Renderer.FileName(name))
+}
+
+// readEntry returns the entry of the skill name in the "read" list: its
+// path relative to the project, which aider runs in, or its absolute path
+// in the global config, which applies to every project.
+func (a *aiderProjector) readEntry(opts projector.ProjectionOpts, name string) string {
+	if opts.Scope == projector.ScopeGlobal {
+		return a.renderedPath(opts, name)
+	}
+	return filepath.ToSlash(filepath.Join(a.rp.Dir, a.rp.Renderer.FileName(name)))
+}
+
+// updateReads adds the entries of add to and removes those of remove from
+// the "read" list of the aider config at path, keeping the rest of the
+// file, comments included. A config that would be left without entries
+// loses its "read" key.
+func updateReads(path string, add, remove []string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+	if err != nil && len(add) == 0 {
+		return nil
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s is not a YAML mapping", path)
+	}
+
+	idx := -1
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "read" {
+			idx = i
+		}
+	}
+	var reads *yaml.Node
+	switch {
+	case idx < 0:
+		if len(add) == 0 {
+			return nil
+		}
+		reads = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "read"}, reads)
+		idx = len(root.Content) - 2
+	case root.Content[idx+1].Kind == yaml.ScalarNode:
+		// A single file can be given without a list.
+		single := root.Content[idx+1]
+		reads = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{single}}
+		root.Content[idx+1] = reads
+	default:
+		reads = root.Content[idx+1]
+	}
+
+	reads.Content = slices.DeleteFunc(reads.Content, func(n *yaml.Node) bool {
+		return slices.Contains(remove, n.Value)
+	})
+	for _, entry := range add {
+		if !slices.ContainsFunc(reads.Content, func(n *yaml.Node) bool { return n.Value == entry }) {
+			reads.Content = append(reads.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: entry})
+		}
+	}
+	if len(reads.Content) == 0 {
+		root.Content = slices.Delete(root.Content, idx, idx+2)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return fmt.Errorf("encoding %s: %w", path, err)
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	if bytes.Equal(buf.Bytes(), data) {
+		return nil
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
@@ -0,0 +1,145 @@
+package aider
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/projector"
+	"github.com/agentpkg/agentpkg/pkg/skill"
+)
+
+func TestSupports(t *testing.T) {
+	a := &aiderProjector{}
+	tests := map[string]struct {
+		got  bool
+		want bool
+	}{
+		"skills":      {got: a.SupportsSkills(), want: true},
+		"MCP servers": {got: a.SupportsMCPServers(), want: false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if tc.got != tc.want {
+				t.Errorf("supports %s = %v, want %v", name, tc.got, tc.want)
+			}
+		})
+	}
+}
+
+func TestProjectSkills(t *testing.T) {
+	tests := map[string]struct {
+		conf string // no .aider.conf.yml if empty
+		// wantConf are in .aider.conf.yml while pdf is projected.
+		wantConf []string
+		// wantKept are in .aider.conf.yml after pdf is unprojected.
+		wantKept []string
+	}{
+		"config with a read file": {
+			conf:     "# my settings\nmodel: sonnet\nread: CONVENTIONS.md\n",
+			wantConf: []string{"# my settings\n", "model: sonnet\n", "  - CONVENTIONS.md\n", "  - .aider/conventions/pdf.md\n"},
+			wantKept: []string{"# my settings\n", "model: sonnet\n", "CONVENTIONS.md"},
+		},
+		"no config": {
+			wantConf: []string{"read:\n", "  - .aider/conventions/pdf.md\n"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			proj, ok := projector.GetProjector("aider")
+			if !ok {
+				t.Fatal("aider projector not registered")
+			}
+
+			skillDir := filepath.Join(t.TempDir(), "pdf")
+			os.MkdirAll(skillDir, 0o755)
+			os.WriteFile(filepath.Join(skillDir, "SKILL.md"), []byte("---\nname: pdf\ndescription: Work with PDFs\n---\n# PDF\n"), 0o644)
+			sk, err := skill.Load(skillDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			projectDir := t.TempDir()
+			confPath := filepath.Join(projectDir, ".aider.conf.yml")
+			if tc.conf != "" {
+				os.WriteFile(confPath, []byte(tc.conf), 0o644)
+			}
+			opts := projector.ProjectionOpts{ProjectDir: projectDir}
+
+			if err := proj.ProjectSkills(opts, []skill.Skill{sk}); err != nil {
+				t.Fatalf("ProjectSkills() error = %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(projectDir, ".aider", "conventions", "pdf.md")); err != nil {
+				t.Errorf("conventions file not rendered: %v", err)
+			}
+			data, _ := os.ReadFile(confPath)
+			for _, want := range tc.wantConf {
+				if !strings.Contains(string(data), want) {
+					t.Errorf(".aider.conf.yml missing %q:\n%s", want, data)
+				}
+			}
+
+			// Projecting again doesn't add the entry twice.
+			if err := proj.ProjectSkills(opts, []skill.Skill{sk}); err != nil {
+				t.Fatalf("second ProjectSkills() error = %v", err)
+			}
+			data, _ = os.ReadFile(confPath)
+			if n := strings.Count(string(data), "pdf.md"); n != 1 {
+				t.Errorf(".aider.conf.yml lists pdf.md %d times:\n%s", n, data)
+			}
+
+			if err := proj.UnprojectSkills(opts, []string{"pdf"}); err != nil {
+				t.Fatalf("UnprojectSkills() error = %v", err)
+			}
+			data, _ = os.ReadFile(confPath)
+			if strings.Contains(string(data), "pdf.md") {
+				t.Errorf(".aider.conf.yml after UnprojectSkills():\n%s", data)
+			}
+			for _, want := range tc.wantKept {
+				if !strings.Contains(string(data), want) {
+					t.Errorf(".aider.conf.yml after UnprojectSkills() missing %q:\n%s", want, data)
+				}
+			}
+			if _, err := os.Stat(filepath.Join(projectDir, ".aider", "conventions", "pdf.md")); !os.IsNotExist(err) {
+				t.Error("conventions file still exists after UnprojectSkills()")
+			}
+		})
+	}
+}
+
+func TestUnprojectSkills(t *testing.T) {
+	tests := map[string]struct {
+		conf string // no .aider.conf.yml if empty
+	}{
+		"no config": {},
+		"config without the skill": {
+			conf: "# my settings\nmodel: sonnet\nread:\n  - CONVENTIONS.md\n",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			projectDir := t.TempDir()
+			confPath := filepath.Join(projectDir, ".aider.conf.yml")
+			if tc.conf != "" {
+				os.WriteFile(confPath, []byte(tc.conf), 0o644)
+			}
+
+			proj, _ := projector.GetProjector("aider")
+			if err := proj.UnprojectSkills(projector.ProjectionOpts{ProjectDir: projectDir}, []string{"pdf"}); err != nil {
+				t.Fatalf("UnprojectSkills() error = %v", err)
+			}
+			data, err := os.ReadFile(confPath)
+			if tc.conf == "" {
+				if !os.IsNotExist(err) {
+					t.Error("UnprojectSkills() created .aider.conf.yml")
+				}
+			} else if string(data) != tc.conf {
+				t.Errorf("UnprojectSkills() changed .aider.conf.yml:\n%s", data)
+			}
+		})
+	}
+}
@@ -2,9 +2,9 @@ package claudecode
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 
+	"github.com/agentpkg/agentpkg/pkg/config"
 	"github.com/agentpkg/agentpkg/pkg/mcp"
 	"github.com/agentpkg/agentpkg/pkg/projector"
 	"github.com/agentpkg/agentpkg/pkg/skill"
@@ -16,6 +16,15 @@ func init() {
 	})
 }
 
+// mcpFormat marks remote servers with their transport, as `claude mcp add
+// --transport` does.
+var mcpFormat = projector.MCPFormat{
+	Types: map[string]string{
+		config.TransportHTTP: "http",
+		config.TransportSSE:  "sse",
+	},
+}
+
 type claudeCodeProjector struct {
 	sp projector.SkillProjector
 }
@@ -26,6 +35,18 @@ func (c *claudeCodeProjector) GitignoreEntries() []string {
 	return []string{".claude/"}
 }
 
+func (c *claudeCodeProjector) Targets(opts projector.ProjectionOpts) (projector.Targets, error) {
+	claudeConfigPath, err := configPath()
+	if err != nil {
+		return projector.Targets{}, err
+	}
+	projectDir, err := filepath.Abs(opts.ProjectDir)
+	if err != nil {
+		return projector.Targets{}, fmt.Errorf("failed to resolve absolute path for project dir %q: %w", opts.ProjectDir, err)
+	}
+	return projector.Targets{SkillsDir: c.sp.SkillsDir(opts), MCPConfig: claudeConfigPath, MCPPointer: serversPointer(opts, projectDir)}, nil
+}
+
 func (c *claudeCodeProjector) SupportsSkills() bool {
 	return true
 }
@@ -42,15 +63,16 @@ func (c *claudeCodeProjector) SupportsMCPServers() bool {
 	return true
 }
 
-func (c *claudeCodeProjector) ProjectMCPServers(opts projector.ProjectionOpts, servers []mcp.MCPServer) error {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return fmt.Errorf("failed to get home directory: %w", err)
-	}
+func (c *claudeCodeProjector) MCPLimits() projector.Limits {
+	return projector.Limits{}
+}
 
-	claudeConfigPath := filepath.Join(homeDir, ".claude.json")
+func (c *claudeCodeProjector) MCPFormat() projector.MCPFormat {
+	return mcpFormat
+}
 
-	config, err := projector.ReadJsonConfig(claudeConfigPath)
+func (c *claudeCodeProjector) ProjectMCPServers(opts projector.ProjectionOpts, servers []mcp.MCPServer) error {
+	claudeConfigPath, err := configPath()
 	if err != nil {
 		return err
 	}
@@ -60,32 +82,25 @@ func (c *claudeCodeProjector) ProjectMCPServers(opts projector.ProjectionOpts, s
 		return fmt.Errorf("failed to resolve absolute path for project dir %q: %w", opts.ProjectDir, err)
 	}
 
-	for _, server := range servers {
-		serverConfig := projector.BuildMCPServerJsonConfig(server)
-
+	return projector.UpdateJsonConfig(opts, claudeConfigPath, func(config map[string]any) error {
+		var mcpServers map[string]any
 		if opts.Scope == projector.ScopeGlobal {
-			mcpServers := projector.GetOrCreateMap(config, "mcpServers")
-			mcpServers[server.Name()] = serverConfig
+			mcpServers = projector.GetOrCreateMap(config, "mcpServers")
 		} else {
 			projects := projector.GetOrCreateMap(config, "projects")
 			project := projector.GetOrCreateMap(projects, projectDir)
-			mcpServers := projector.GetOrCreateMap(project, "mcpServers")
-			mcpServers[server.Name()] = serverConfig
+			mcpServers = projector.GetOrCreateMap(project, "mcpServers")
 		}
-	}
 
-	return projector.WriteJsonConfig(claudeConfigPath, config)
+		if err := projector.SetMCPServers(opts, mcpFormat, claudeConfigPath, serversPointer(opts, projectDir), mcpServers, servers); err != nil {
+			return err
+		}
+		return projector.CheckMCPLimits("claude-code", claudeConfigPath, c.MCPLimits(), opts, mcpServers)
+	})
 }
 
 func (c *claudeCodeProjector) UnprojectMCPServers(opts projector.ProjectionOpts, names []string) error {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return fmt.Errorf("failed to get home directory: %w", err)
-	}
-
-	claudeConfigPath := filepath.Join(homeDir, ".claude.json")
-
-	config, err := projector.ReadJsonConfig(claudeConfigPath)
+	claudeConfigPath, err := configPath()
 	if err != nil {
 		return err
 	}
@@ -95,21 +110,37 @@ func (c *claudeCodeProjector) UnprojectMCPServers(opts projector.ProjectionOpts,
 		return fmt.Errorf("failed to resolve absolute path for project dir %q: %w", opts.ProjectDir, err)
 	}
 
-	for _, name := range names {
+	return projector.UpdateJsonConfig(opts, claudeConfigPath, func(config map[string]any) error {
+		var mcpServers map[string]any
 		if opts.Scope == projector.ScopeGlobal {
-			if mcpServers, ok := config["mcpServers"].(map[string]any); ok {
-				delete(mcpServers, name)
-			}
-		} else {
-			if projects, ok := config["projects"].(map[string]any); ok {
-				if project, ok := projects[projectDir].(map[string]any); ok {
-					if mcpServers, ok := project["mcpServers"].(map[string]any); ok {
-						delete(mcpServers, name)
-					}
-				}
+			mcpServers, _ = config["mcpServers"].(map[string]any)
+		} else if projects, ok := config["projects"].(map[string]any); ok {
+			if project, ok := projects[projectDir].(map[string]any); ok {
+				mcpServers, _ = project["mcpServers"].(map[string]any)
 			}
 		}
+		if mcpServers != nil {
+			projector.DeleteMCPServers(opts, claudeConfigPath, serversPointer(opts, projectDir), mcpServers, names)
+		}
+		return nil
+	})
+}
+
+// serversPointer returns the JSON pointer of the MCP servers object that
+// projections with opts write to.
+func serversPointer(opts projector.ProjectionOpts, projectDir string) string {
+	if opts.Scope == projector.ScopeGlobal {
+		return "/mcpServers"
 	}
+	return projector.JoinPointer("/projects", projectDir) + "/mcpServers"
+}
 
-	return projector.WriteJsonConfig(claudeConfigPath, config)
+// configPath returns ~/.claude.json, which holds both global MCP servers and
+// per-project ones (under projects.<dir>).
+func configPath() (string, error) {
+	homeDir, err := config.HomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".claude.json"), nil
 }
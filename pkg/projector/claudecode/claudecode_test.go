@@ -89,6 +89,7 @@ func TestUnprojectMCPServers(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			homeDir := t.TempDir()
 			t.Setenv("HOME", homeDir)
+			t.Setenv("APKG_HOME", homeDir)
 
 			projectDir := t.TempDir()
 
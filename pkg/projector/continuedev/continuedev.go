@@ -0,0 +1,64 @@
+package continuedev
+
+import (
+	"github.com/agentpkg/agentpkg/pkg/mcp"
+	"github.com/agentpkg/agentpkg/pkg/projector"
+	"github.com/agentpkg/agentpkg/pkg/skill"
+)
+
+func init() {
+	projector.RegisterProjector("continue", &continueProjector{
+		rp: projector.RenderedSkillProjector{
+			Dir:      ".continue/prompts",
+			Renderer: projector.ContinuePromptRenderer{},
+		},
+	})
+}
+
+// continueProjector projects skills into Continue as prompt files, since
+// Continue doesn't read the SKILL.md layout. MCP servers are not supported.
+type continueProjector struct {
+	rp projector.RenderedSkillProjector
+}
+
+var _ projector.Projector = &continueProjector{}
+
+func (c *continueProjector) GitignoreEntries() []string {
+	return []string{".continue/"}
+}
+
+func (c *continueProjector) Targets(opts projector.ProjectionOpts) (projector.Targets, error) {
+	return projector.Targets{SkillsDir: c.rp.SkillsDir(opts)}, nil
+}
+
+func (c *continueProjector) SupportsSkills() bool {
+	return true
+}
+
+func (c *continueProjector) ProjectSkills(opts projector.ProjectionOpts, packages []skill.Skill) error {
+	return c.rp.ProjectSkills(opts, packages)
+}
+
+func (c *continueProjector) UnprojectSkills(opts projector.ProjectionOpts, names []string) error {
+	return c.rp.UnprojectSkills(opts, names)
+}
+
+func (c *continueProjector) SupportsMCPServers() bool {
+	return false
+}
+
+func (c *continueProjector) MCPLimits() projector.Limits {
+	return projector.Limits{}
+}
+
+func (c *continueProjector) MCPFormat() projector.MCPFormat {
+	return projector.MCPFormat{}
+}
+
+func (c *continueProjector) ProjectMCPServers(opts projector.ProjectionOpts, servers []mcp.MCPServer) error {
+	return nil
+}
+
+func (c *continueProjector) UnprojectMCPServers(opts projector.ProjectionOpts, names []string) error {
+	return nil
+}
@@ -0,0 +1,58 @@
+package continuedev
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/projector"
+)
+
+func TestSupports(t *testing.T) {
+	c := &continueProjector{}
+	tests := map[string]struct {
+		got  bool
+		want bool
+	}{
+		"skills":      {got: c.SupportsSkills(), want: true},
+		"MCP servers": {got: c.SupportsMCPServers(), want: false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if tc.got != tc.want {
+				t.Errorf("supports %s = %v, want %v", name, tc.got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTargets(t *testing.T) {
+	tests := map[string]struct {
+		scope projector.Scope
+	}{
+		"local": {scope: projector.ScopeLocal},
+		// Global projections get the home directory as the project.
+		"global": {scope: projector.ScopeGlobal},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			proj, ok := projector.GetProjector("continue")
+			if !ok {
+				t.Fatal("continue projector not registered")
+			}
+
+			projectDir := t.TempDir()
+			targets, err := proj.Targets(projector.ProjectionOpts{ProjectDir: projectDir, Scope: tc.scope})
+			if err != nil {
+				t.Fatalf("Targets() error = %v", err)
+			}
+			if want := filepath.Join(projectDir, ".continue", "prompts"); targets.SkillsDir != want {
+				t.Errorf("SkillsDir = %q, want %q", targets.SkillsDir, want)
+			}
+			if targets.MCPConfig != "" {
+				t.Errorf("MCPConfig = %q, want empty", targets.MCPConfig)
+			}
+		})
+	}
+}
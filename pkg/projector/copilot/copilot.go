@@ -0,0 +1,125 @@
+package copilot
+
+import (
+	"path/filepath"
+	"runtime"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/mcp"
+	"github.com/agentpkg/agentpkg/pkg/projector"
+	"github.com/agentpkg/agentpkg/pkg/skill"
+)
+
+func init() {
+	projector.RegisterProjector("copilot", &copilotProjector{})
+}
+
+// mcpFormat marks every server with its transport, as VS Code expects.
+var mcpFormat = projector.MCPFormat{
+	Types: map[string]string{
+		config.TransportStdio: "stdio",
+		config.TransportHTTP:  "http",
+		config.TransportSSE:   "sse",
+	},
+}
+
+// copilotProjector projects MCP servers into VS Code, where GitHub Copilot's
+// agent mode picks them up. VS Code keeps them under "servers" in
+// .vscode/mcp.json, or in mcp.json in the user settings directory for
+// global projections. Skills are not supported.
+type copilotProjector struct{}
+
+var _ projector.Projector = &copilotProjector{}
+
+func (c *copilotProjector) GitignoreEntries() []string {
+	return []string{".vscode/mcp.json"}
+}
+
+func (c *copilotProjector) Targets(opts projector.ProjectionOpts) (projector.Targets, error) {
+	configPath, err := mcpConfigPath(opts)
+	if err != nil {
+		return projector.Targets{}, err
+	}
+	return projector.Targets{MCPConfig: configPath, MCPPointer: "/servers"}, nil
+}
+
+func (c *copilotProjector) SupportsSkills() bool {
+	return false
+}
+
+func (c *copilotProjector) ProjectSkills(opts projector.ProjectionOpts, packages []skill.Skill) error {
+	return nil
+}
+
+func (c *copilotProjector) UnprojectSkills(opts projector.ProjectionOpts, names []string) error {
+	return nil
+}
+
+func (c *copilotProjector) SupportsMCPServers() bool {
+	return true
+}
+
+// MCPLimits reflects that Copilot passes at most 128 tools to the model
+// and drops the rest. Every server exposes at least one tool, so more than
+// 128 servers always exceed it.
+func (c *copilotProjector) MCPLimits() projector.Limits {
+	return projector.Limits{MaxMCPServers: 128, Truncates: true}
+}
+
+func (c *copilotProjector) MCPFormat() projector.MCPFormat {
+	return mcpFormat
+}
+
+func (c *copilotProjector) ProjectMCPServers(opts projector.ProjectionOpts, servers []mcp.MCPServer) error {
+	configPath, err := mcpConfigPath(opts)
+	if err != nil {
+		return err
+	}
+
+	return projector.UpdateJsonConfig(opts, configPath, func(config map[string]any) error {
+		mcpServers := projector.GetOrCreateMap(config, "servers")
+		if err := projector.SetMCPServers(opts, mcpFormat, configPath, "/servers", mcpServers, servers); err != nil {
+			return err
+		}
+		return projector.CheckMCPLimits("copilot", configPath, c.MCPLimits(), opts, mcpServers)
+	})
+}
+
+func (c *copilotProjector) UnprojectMCPServers(opts projector.ProjectionOpts, names []string) error {
+	configPath, err := mcpConfigPath(opts)
+	if err != nil {
+		return err
+	}
+
+	return projector.UpdateJsonConfig(opts, configPath, func(config map[string]any) error {
+		if mcpServers, ok := config["servers"].(map[string]any); ok {
+			projector.DeleteMCPServers(opts, configPath, "/servers", mcpServers, names)
+		}
+		return nil
+	})
+}
+
+// mcpConfigPath returns .vscode/mcp.json in the project, or mcp.json in
+// VS Code's user settings directory for global projections.
+func mcpConfigPath(opts projector.ProjectionOpts) (string, error) {
+	if opts.Scope != projector.ScopeGlobal {
+		return filepath.Join(opts.ProjectDir, ".vscode", "mcp.json"), nil
+	}
+	homeDir, err := config.HomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(userDir(homeDir, runtime.GOOS), "mcp.json"), nil
+}
+
+// userDir returns VS Code's user settings directory under homeDir on goos.
+func userDir(homeDir, goos string) string {
+	switch goos {
+	case "darwin":
+		return filepath.Join(homeDir, "Library", "Application Support", "Code", "User")
+	case "windows":
+		return filepath.Join(homeDir, "AppData", "Roaming", "Code", "User")
+	default:
+		return filepath.Join(homeDir, ".config", "Code", "User")
+	}
+}
@@ -0,0 +1,84 @@
+package copilot
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/projector"
+)
+
+func TestUnprojectMCPServers(t *testing.T) {
+	tests := map[string]struct {
+		scope projector.Scope
+	}{
+		"project scope": {scope: projector.ScopeLocal},
+		"global scope":  {scope: projector.ScopeGlobal},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			homeDir := t.TempDir()
+			t.Setenv("HOME", homeDir)
+			t.Setenv("APKG_HOME", homeDir)
+			opts := projector.ProjectionOpts{ProjectDir: t.TempDir(), Scope: tc.scope}
+			configPath, err := mcpConfigPath(opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			initial := map[string]any{
+				"inputs": []any{},
+				"servers": map[string]any{
+					"my-server": map[string]any{"type": "stdio", "command": "test"},
+					"keep":      map[string]any{"type": "stdio", "command": "keep"},
+				},
+			}
+			if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+				t.Fatal(err)
+			}
+			data, _ := json.Marshal(initial)
+			if err := os.WriteFile(configPath, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			c := &copilotProjector{}
+			if err := c.UnprojectMCPServers(opts, []string{"my-server"}); err != nil {
+				t.Fatalf("UnprojectMCPServers() error = %v", err)
+			}
+
+			result, err := os.ReadFile(configPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var config map[string]any
+			if err := json.Unmarshal(result, &config); err != nil {
+				t.Fatal(err)
+			}
+			servers := config["servers"].(map[string]any)
+			if _, ok := servers["my-server"]; ok {
+				t.Error("expected my-server to be removed")
+			}
+			if _, ok := servers["keep"]; !ok {
+				t.Error("expected keep to remain")
+			}
+			if _, ok := config["inputs"]; !ok {
+				t.Error("expected inputs to remain")
+			}
+		})
+	}
+}
+
+func TestUserDir(t *testing.T) {
+	tests := map[string]string{
+		"linux":   filepath.Join("/home/me", ".config", "Code", "User"),
+		"darwin":  filepath.Join("/home/me", "Library", "Application Support", "Code", "User"),
+		"windows": filepath.Join("/home/me", "AppData", "Roaming", "Code", "User"),
+	}
+	for goos, want := range tests {
+		if got := userDir("/home/me", goos); got != want {
+			t.Errorf("userDir(%q) = %q, want %q", goos, got, want)
+		}
+	}
+}
@@ -1,10 +1,9 @@
 package cursor
 
 import (
-	"fmt"
-	"os"
 	"path/filepath"
 
+	"github.com/agentpkg/agentpkg/pkg/config"
 	"github.com/agentpkg/agentpkg/pkg/mcp"
 	"github.com/agentpkg/agentpkg/pkg/projector"
 	"github.com/agentpkg/agentpkg/pkg/skill"
@@ -16,6 +15,15 @@ func init() {
 	})
 }
 
+// mcpFormat marks remote servers with their transport. Cursor reads
+// "streamable-http" as well as "http".
+var mcpFormat = projector.MCPFormat{
+	Types: map[string]string{
+		config.TransportHTTP: "http",
+		config.TransportSSE:  "sse",
+	},
+}
+
 type cursorProjector struct {
 	sp projector.SkillProjector
 }
@@ -26,6 +34,14 @@ func (c *cursorProjector) GitignoreEntries() []string {
 	return []string{".cursor/"}
 }
 
+func (c *cursorProjector) Targets(opts projector.ProjectionOpts) (projector.Targets, error) {
+	configPath, err := mcpConfigPath(opts)
+	if err != nil {
+		return projector.Targets{}, err
+	}
+	return projector.Targets{SkillsDir: c.sp.SkillsDir(opts), MCPConfig: configPath, MCPPointer: "/mcpServers"}, nil
+}
+
 func (c *cursorProjector) SupportsSkills() bool {
 	return true
 }
@@ -42,54 +58,55 @@ func (c *cursorProjector) SupportsMCPServers() bool {
 	return true
 }
 
-func (c *cursorProjector) ProjectMCPServers(opts projector.ProjectionOpts, servers []mcp.MCPServer) error {
-	var configPath string
-	if opts.Scope == projector.ScopeGlobal {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return fmt.Errorf("failed to get home directory: %w", err)
-		}
-		configPath = filepath.Join(homeDir, ".cursor", "mcp.json")
-	} else {
-		configPath = filepath.Join(opts.ProjectDir, ".cursor", "mcp.json")
-	}
+// MCPLimits reflects that Cursor passes at most 40 MCP tools to the model
+// and drops the rest. Every server exposes at least one tool, so more than
+// 40 servers always exceed it.
+func (c *cursorProjector) MCPLimits() projector.Limits {
+	return projector.Limits{MaxMCPServers: 40, Truncates: true}
+}
 
-	config, err := projector.ReadJsonConfig(configPath)
+func (c *cursorProjector) MCPFormat() projector.MCPFormat {
+	return mcpFormat
+}
+
+func (c *cursorProjector) ProjectMCPServers(opts projector.ProjectionOpts, servers []mcp.MCPServer) error {
+	configPath, err := mcpConfigPath(opts)
 	if err != nil {
 		return err
 	}
 
-	for _, server := range servers {
-		serverConfig := projector.BuildMCPServerJsonConfig(server)
+	return projector.UpdateJsonConfig(opts, configPath, func(config map[string]any) error {
 		mcpServers := projector.GetOrCreateMap(config, "mcpServers")
-		mcpServers[server.Name()] = serverConfig
-	}
-
-	return projector.WriteJsonConfig(configPath, config)
+		if err := projector.SetMCPServers(opts, mcpFormat, configPath, "/mcpServers", mcpServers, servers); err != nil {
+			return err
+		}
+		return projector.CheckMCPLimits("cursor", configPath, c.MCPLimits(), opts, mcpServers)
+	})
 }
 
 func (c *cursorProjector) UnprojectMCPServers(opts projector.ProjectionOpts, names []string) error {
-	var configPath string
-	if opts.Scope == projector.ScopeGlobal {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return fmt.Errorf("failed to get home directory: %w", err)
-		}
-		configPath = filepath.Join(homeDir, ".cursor", "mcp.json")
-	} else {
-		configPath = filepath.Join(opts.ProjectDir, ".cursor", "mcp.json")
-	}
-
-	config, err := projector.ReadJsonConfig(configPath)
+	configPath, err := mcpConfigPath(opts)
 	if err != nil {
 		return err
 	}
 
-	if mcpServers, ok := config["mcpServers"].(map[string]any); ok {
-		for _, name := range names {
-			delete(mcpServers, name)
+	return projector.UpdateJsonConfig(opts, configPath, func(config map[string]any) error {
+		if mcpServers, ok := config["mcpServers"].(map[string]any); ok {
+			projector.DeleteMCPServers(opts, configPath, "/mcpServers", mcpServers, names)
 		}
-	}
+		return nil
+	})
+}
 
-	return projector.WriteJsonConfig(configPath, config)
+// mcpConfigPath returns .cursor/mcp.json in the home directory for global
+// projections and in the project otherwise.
+func mcpConfigPath(opts projector.ProjectionOpts) (string, error) {
+	if opts.Scope != projector.ScopeGlobal {
+		return filepath.Join(opts.ProjectDir, ".cursor", "mcp.json"), nil
+	}
+	homeDir, err := config.HomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".cursor", "mcp.json"), nil
 }
@@ -90,6 +90,7 @@ func TestUnprojectMCPServers(t *testing.T) {
 			if tc.scope == projector.ScopeGlobal {
 				homeDir := t.TempDir()
 				t.Setenv("HOME", homeDir)
+				t.Setenv("APKG_HOME", homeDir)
 				configPath = filepath.Join(homeDir, ".cursor", "mcp.json")
 			} else {
 				projectDir := t.TempDir()
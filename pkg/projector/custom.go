@@ -0,0 +1,226 @@
+package projector
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/mcp"
+	"github.com/agentpkg/agentpkg/pkg/skill"
+)
+
+// defaultMCPKey is the table custom projectors write MCP servers to when
+// their config doesn't name one, as Claude Code and Cursor do.
+const defaultMCPKey = "mcpServers"
+
+// customMCPFormat writes servers of custom projectors with the types most
+// agents use; mcp_types in the developer config overrides them.
+var customMCPFormat = MCPFormat{
+	Types: map[string]string{
+		config.TransportStdio: "stdio",
+		config.TransportHTTP:  "http",
+		config.TransportSSE:   "sse",
+	},
+}
+
+// RegisterCustomProjectors registers a projector for each agent defined in
+// the developer config (see config.DevConfig.Projectors), replacing the
+// ones of an earlier call. Agents with a built-in projector can't be
+// redefined. It is safe to call while other goroutines look projectors
+// up, e.g. when the RPC server opens workspaces concurrently.
+func RegisterCustomProjectors(defs map[string]config.ProjectorConfig) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for agent := range customAgents {
+		delete(defaultRegistry, agent)
+		delete(customAgents, agent)
+	}
+
+	agents := make([]string, 0, len(defs))
+	for agent := range defs {
+		agents = append(agents, agent)
+	}
+	sort.Strings(agents)
+	for _, agent := range agents {
+		if _, ok := defaultRegistry[agent]; ok {
+			return fmt.Errorf("%s.%s: agent %q has a built-in projector", config.KeyProjectors, agent, agent)
+		}
+		defaultRegistry[agent] = &customProjector{cfg: defs[agent]}
+		customAgents[agent] = true
+	}
+	return nil
+}
+
+// customProjector projects into an agent described by the developer
+// config instead of code: skills are symlinked into its skills directory
+// and MCP servers written to a table of its JSON, TOML, or YAML config.
+type customProjector struct {
+	cfg config.ProjectorConfig
+}
+
+var _ Projector = &customProjector{}
+
+// GitignoreEntries returns the projector's paths inside the project.
+func (c *customProjector) GitignoreEntries() []string {
+	var entries []string
+	if c.cfg.SkillsDir != "" && isProjectPath(c.cfg.SkillsDir) {
+		entries = append(entries, filepath.ToSlash(filepath.Clean(c.cfg.SkillsDir))+"/")
+	}
+	if c.cfg.MCPConfig != "" && isProjectPath(c.cfg.MCPConfig) {
+		entries = append(entries, filepath.ToSlash(filepath.Clean(c.cfg.MCPConfig)))
+	}
+	return entries
+}
+
+func (c *customProjector) Targets(opts ProjectionOpts) (Targets, error) {
+	var targets Targets
+	if c.SupportsSkills() {
+		dir, err := resolvePath(opts, c.cfg.SkillsDir)
+		if err != nil {
+			return Targets{}, err
+		}
+		targets.SkillsDir = dir
+	}
+	if c.SupportsMCPServers() {
+		path, err := c.mcpConfigPath(opts)
+		if err != nil {
+			return Targets{}, err
+		}
+		targets.MCPConfig, targets.MCPPointer = path, c.mcpPointer()
+	}
+	return targets, nil
+}
+
+func (c *customProjector) SupportsSkills() bool {
+	return c.cfg.SkillsDir != ""
+}
+
+func (c *customProjector) ProjectSkills(opts ProjectionOpts, packages []skill.Skill) error {
+	if !c.SupportsSkills() {
+		return nil
+	}
+	dir, err := resolvePath(opts, c.cfg.SkillsDir)
+	if err != nil {
+		return err
+	}
+	return (&SkillProjector{Dir: dir}).ProjectSkills(opts, packages)
+}
+
+func (c *customProjector) UnprojectSkills(opts ProjectionOpts, names []string) error {
+	if !c.SupportsSkills() {
+		return nil
+	}
+	dir, err := resolvePath(opts, c.cfg.SkillsDir)
+	if err != nil {
+		return err
+	}
+	return (&SkillProjector{Dir: dir}).UnprojectSkills(opts, names)
+}
+
+func (c *customProjector) SupportsMCPServers() bool {
+	return c.cfg.MCPConfig != ""
+}
+
+func (c *customProjector) MCPLimits() Limits {
+	return Limits{}
+}
+
+func (c *customProjector) MCPFormat() MCPFormat {
+	return customMCPFormat
+}
+
+func (c *customProjector) ProjectMCPServers(opts ProjectionOpts, servers []mcp.MCPServer) error {
+	if !c.SupportsMCPServers() {
+		return nil
+	}
+	configPath, err := c.mcpConfigPath(opts)
+	if err != nil {
+		return err
+	}
+
+	return UpdateJsonConfig(opts, configPath, func(config map[string]any) error {
+		mcpServers := config
+		for _, key := range c.mcpKeys() {
+			mcpServers = GetOrCreateMap(mcpServers, key)
+		}
+		return SetMCPServers(opts, customMCPFormat, configPath, c.mcpPointer(), mcpServers, servers)
+	})
+}
+
+func (c *customProjector) UnprojectMCPServers(opts ProjectionOpts, names []string) error {
+	if !c.SupportsMCPServers() {
+		return nil
+	}
+	configPath, err := c.mcpConfigPath(opts)
+	if err != nil {
+		return err
+	}
+
+	return UpdateJsonConfig(opts, configPath, func(config map[string]any) error {
+		mcpServers := config
+		for _, key := range c.mcpKeys() {
+			var ok bool
+			if mcpServers, ok = mcpServers[key].(map[string]any); !ok {
+				return nil
+			}
+		}
+		DeleteMCPServers(opts, configPath, c.mcpPointer(), mcpServers, names)
+		return nil
+	})
+}
+
+// mcpConfigPath returns the config file MCP servers are written to,
+// recording its format if the config gives one.
+func (c *customProjector) mcpConfigPath(opts ProjectionOpts) (string, error) {
+	path, err := resolvePath(opts, c.cfg.MCPConfig)
+	if err != nil {
+		return "", err
+	}
+	if c.cfg.Format != "" {
+		SetConfigFormat(path, c.cfg.Format)
+	}
+	return path, nil
+}
+
+// mcpKeys returns the keys of the table holding the MCP servers, from
+// the outermost.
+func (c *customProjector) mcpKeys() []string {
+	if c.cfg.MCPKey == "" {
+		return []string{defaultMCPKey}
+	}
+	return strings.Split(c.cfg.MCPKey, ".")
+}
+
+// mcpPointer returns the JSON pointer of the table holding the MCP
+// servers.
+func (c *customProjector) mcpPointer() string {
+	var pointer string
+	for _, key := range c.mcpKeys() {
+		pointer = JoinPointer(pointer, key)
+	}
+	return pointer
+}
+
+// resolvePath returns the absolute path of p, a path of a custom
+// projector's config (see config.ProjectorConfig).
+func resolvePath(opts ProjectionOpts, p string) (string, error) {
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		home, err := config.HomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, strings.TrimPrefix(p[1:], "/")), nil
+	}
+	if filepath.IsAbs(p) {
+		return p, nil
+	}
+	return filepath.Join(opts.ProjectDir, p), nil
+}
+
+// isProjectPath reports whether p, a path of a custom projector's
+// config, is inside the project.
+func isProjectPath(p string) bool {
+	return !filepath.IsAbs(p) && p != "~" && !strings.HasPrefix(p, "~/") && !strings.HasPrefix(filepath.Clean(p), "..")
+}
@@ -0,0 +1,202 @@
+package projector
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/mcp"
+	"github.com/agentpkg/agentpkg/pkg/skill"
+)
+
+func TestCustomProjector(t *testing.T) {
+	tests := map[string]struct {
+		cfg config.ProjectorConfig
+		// existing is the agent config before projecting, kept after.
+		existing    string
+		wantPointer string
+		// wantConfig are in the config while the server is projected.
+		wantConfig []string
+	}{
+		"toml": {
+			cfg:         config.ProjectorConfig{MCPConfig: ".acme/agent.conf", Format: "toml", MCPKey: "mcp.servers", SkillsDir: ".acme/skills"},
+			existing:    "theme = \"dark\"\n",
+			wantPointer: "/mcp/servers",
+			wantConfig:  []string{`theme = 'dark'`, "[mcp.servers.api]", `url = 'https://apkg.example/mcp'`},
+		},
+		"json with the default key": {
+			cfg:         config.ProjectorConfig{MCPConfig: ".acme/agent.json", SkillsDir: ".acme/skills"},
+			existing:    `{"theme": "dark"}`,
+			wantPointer: "/mcpServers",
+			wantConfig:  []string{`"theme": "dark"`, `"mcpServers"`, `"url": "https://apkg.example/mcp"`},
+		},
+		"yaml": {
+			cfg:         config.ProjectorConfig{MCPConfig: ".acme/agent.yaml", Format: "yaml", MCPKey: "servers", SkillsDir: ".acme/skills"},
+			existing:    "theme: dark\n",
+			wantPointer: "/servers",
+			wantConfig:  []string{"theme: dark", "servers:", "url: https://apkg.example/mcp"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Cleanup(func() { RegisterCustomProjectors(nil) })
+			if err := RegisterCustomProjectors(map[string]config.ProjectorConfig{"acme": tc.cfg}); err != nil {
+				t.Fatalf("RegisterCustomProjectors() error = %v", err)
+			}
+			proj, ok := GetProjector("acme")
+			if !ok {
+				t.Fatal("acme projector not registered")
+			}
+
+			projectDir := t.TempDir()
+			opts := ProjectionOpts{ProjectDir: projectDir}
+			configPath := filepath.Join(projectDir, tc.cfg.MCPConfig)
+			os.MkdirAll(filepath.Dir(configPath), 0o755)
+			os.WriteFile(configPath, []byte(tc.existing), 0o644)
+
+			targets, err := proj.Targets(opts)
+			if err != nil {
+				t.Fatalf("Targets() error = %v", err)
+			}
+			want := Targets{SkillsDir: filepath.Join(projectDir, tc.cfg.SkillsDir), MCPConfig: configPath, MCPPointer: tc.wantPointer}
+			if targets != want {
+				t.Errorf("Targets() = %+v, want %+v", targets, want)
+			}
+
+			server := httpServer{name: "api", url: "https://apkg.example/mcp"}
+			if err := proj.ProjectMCPServers(opts, []mcp.MCPServer{server}); err != nil {
+				t.Fatalf("ProjectMCPServers() error = %v", err)
+			}
+			data, _ := os.ReadFile(configPath)
+			for _, s := range tc.wantConfig {
+				if !strings.Contains(string(data), s) {
+					t.Errorf("config missing %q:\n%s", s, data)
+				}
+			}
+			if names, err := MCPServerNames(targets.MCPConfig, targets.MCPPointer); err != nil || len(names) != 1 || names[0] != "api" {
+				t.Errorf("MCPServerNames() = %q, %v, want [api]", names, err)
+			}
+
+			skillDir := filepath.Join(t.TempDir(), "pdf")
+			os.MkdirAll(skillDir, 0o755)
+			if err := proj.ProjectSkills(opts, []skill.Skill{&fakeSkill{name: "pdf", dir: skillDir}}); err != nil {
+				t.Fatalf("ProjectSkills() error = %v", err)
+			}
+			if target, err := os.Readlink(filepath.Join(targets.SkillsDir, "pdf")); err != nil || target != skillDir {
+				t.Errorf("pdf links to %q, %v, want %q", target, err, skillDir)
+			}
+
+			if err := proj.UnprojectMCPServers(opts, []string{"api"}); err != nil {
+				t.Fatalf("UnprojectMCPServers() error = %v", err)
+			}
+			data, _ = os.ReadFile(configPath)
+			if strings.Contains(string(data), "apkg.example") || !strings.Contains(string(data), "theme") {
+				t.Errorf("config after UnprojectMCPServers():\n%s", data)
+			}
+			if err := proj.UnprojectSkills(opts, []string{"pdf"}); err != nil {
+				t.Fatalf("UnprojectSkills() error = %v", err)
+			}
+			if _, err := os.Lstat(filepath.Join(targets.SkillsDir, "pdf")); !os.IsNotExist(err) {
+				t.Error("pdf still projected after UnprojectSkills()")
+			}
+		})
+	}
+}
+
+func TestRegisterCustomProjectors(t *testing.T) {
+	tests := map[string]struct {
+		// calls are the definitions of successive calls.
+		calls      []map[string]config.ProjectorConfig
+		wantErr    bool
+		wantAgents []string
+		wantGone   []string
+	}{
+		"custom agent": {
+			calls:      []map[string]config.ProjectorConfig{{"one": {SkillsDir: ".one/skills"}}},
+			wantAgents: []string{"one"},
+		},
+		"built-in agent": {
+			calls:   []map[string]config.ProjectorConfig{{"test-builtin": {SkillsDir: ".x/skills"}}},
+			wantErr: true,
+		},
+		"later call replaces earlier ones": {
+			calls: []map[string]config.ProjectorConfig{
+				{"one": {SkillsDir: ".one/skills"}},
+				{"two": {SkillsDir: ".two/skills"}},
+			},
+			wantAgents: []string{"two"},
+			wantGone:   []string{"one"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Cleanup(func() { RegisterCustomProjectors(nil) })
+			RegisterHiddenProjector("test-builtin", &stubProjector{})
+			t.Cleanup(func() { UnregisterProjector("test-builtin") })
+
+			var err error
+			for _, defs := range tc.calls {
+				err = RegisterCustomProjectors(defs)
+			}
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("RegisterCustomProjectors() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if err := ValidateAgents(tc.wantAgents); err != nil {
+				t.Errorf("ValidateAgents() error = %v", err)
+			}
+			for _, agent := range tc.wantGone {
+				if _, ok := GetProjector(agent); ok {
+					t.Errorf("agent %q of an earlier call still registered", agent)
+				}
+			}
+		})
+	}
+}
+
+func TestRegisterCustomProjectorsConcurrently(t *testing.T) {
+	tests := map[string]struct {
+		defs map[string]config.ProjectorConfig
+	}{
+		"one agent": {
+			defs: map[string]config.ProjectorConfig{"one": {SkillsDir: ".one/skills"}},
+		},
+		"several agents": {
+			defs: map[string]config.ProjectorConfig{"one": {SkillsDir: ".one/skills"}, "two": {MCPConfig: ".two/mcp.json"}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Cleanup(func() { RegisterCustomProjectors(nil) })
+
+			var wg sync.WaitGroup
+			for range 8 {
+				wg.Add(2)
+				go func() {
+					defer wg.Done()
+					if err := RegisterCustomProjectors(tc.defs); err != nil {
+						t.Errorf("RegisterCustomProjectors() error = %v", err)
+					}
+				}()
+				go func() {
+					defer wg.Done()
+					for agent := range tc.defs {
+						GetProjector(agent)
+					}
+					RegisteredAgents()
+				}()
+			}
+			wg.Wait()
+			for agent := range tc.defs {
+				if _, ok := GetProjector(agent); !ok {
+					t.Errorf("agent %q not registered", agent)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package projector
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// MCPServerNames returns the names of the MCP servers in the object at
+// pointer (see Targets.MCPPointer) of the JSON config file at path,
+// sorted. A missing file or object has none.
+func MCPServerNames(path, pointer string) ([]string, error) {
+	servers, err := MCPServerEntries(path, pointer)
+	if err != nil {
+		return nil, err
+	}
+	return slices.Sorted(maps.Keys(servers)), nil
+}
+
+// MCPServerEntries returns the object at pointer of the JSON config file
+// at path, holding the entries of MCP servers by name. A missing file or
+// object has none.
+func MCPServerEntries(path, pointer string) (map[string]any, error) {
+	config, err := ReadJsonConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var node any = config
+	for _, key := range splitPointer(pointer) {
+		obj, ok := node.(map[string]any)
+		if !ok {
+			return nil, nil
+		}
+		node = obj[key]
+	}
+	servers, _ := node.(map[string]any)
+	return servers, nil
+}
+
+// splitPointer returns the keys of the JSON pointer (RFC 6901) pointer,
+// the inverse of JoinPointer.
+func splitPointer(pointer string) []string {
+	if pointer == "" {
+		return nil
+	}
+	keys := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	unescape := strings.NewReplacer("~1", "/", "~0", "~")
+	for i, key := range keys {
+		keys[i] = unescape.Replace(key)
+	}
+	return keys
+}
+
+// Entry returns the value at pointer of the config file at path, and
+// whether there is one.
+func Entry(path, pointer string) (any, bool, error) {
+	config, err := ReadJsonConfig(path)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var node any = config
+	for _, key := range splitPointer(pointer) {
+		obj, ok := node.(map[string]any)
+		if !ok {
+			return nil, false, nil
+		}
+		if node, ok = obj[key]; !ok {
+			return nil, false, nil
+		}
+	}
+	return node, true, nil
+}
+
+// DeleteEntry deletes the value at pointer of the config file at path, if
+// there is one, and forgets that apkg owned it.
+func DeleteEntry(opts ProjectionOpts, path, pointer string) error {
+	keys := splitPointer(pointer)
+	if len(keys) == 0 {
+		return nil
+	}
+	if opts.Ownership != nil {
+		opts.Ownership.Disown(path, pointer)
+	}
+	return UpdateJsonConfig(opts, path, func(config map[string]any) error {
+		obj := config
+		for _, key := range keys[:len(keys)-1] {
+			var ok bool
+			if obj, ok = obj[key].(map[string]any); !ok {
+				return nil
+			}
+		}
+		delete(obj, keys[len(keys)-1])
+		return nil
+	})
+}
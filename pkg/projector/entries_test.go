@@ -0,0 +1,63 @@
+package projector
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMCPServerNames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	content := `{
+  "mcpServers": {"github": {}, "fs": {}},
+  "projects": {"/work/a/b": {"mcpServers": {"linear": {}}}}
+}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		path    string
+		pointer string
+		want    []string
+	}{
+		"top-level servers": {
+			path:    path,
+			pointer: "/mcpServers",
+			want:    []string{"fs", "github"},
+		},
+		"escaped project key": {
+			path:    path,
+			pointer: JoinPointer(JoinPointer("/projects", "/work/a/b"), "mcpServers"),
+			want:    []string{"linear"},
+		},
+		"missing object": {
+			path:    path,
+			pointer: "/servers",
+		},
+		"pointer through a non-object": {
+			path:    path,
+			pointer: "/mcpServers/github/command/x",
+		},
+		"missing file": {
+			path:    filepath.Join(t.TempDir(), "missing.json"),
+			pointer: "/mcpServers",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := MCPServerNames(tc.path, tc.pointer)
+			if err != nil {
+				t.Fatalf("MCPServerNames() error = %v", err)
+			}
+			if len(got) == 0 && len(tc.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("MCPServerNames() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -1,10 +1,9 @@
 package gemini
 
 import (
-	"fmt"
-	"os"
 	"path/filepath"
 
+	"github.com/agentpkg/agentpkg/pkg/config"
 	"github.com/agentpkg/agentpkg/pkg/mcp"
 	"github.com/agentpkg/agentpkg/pkg/projector"
 	"github.com/agentpkg/agentpkg/pkg/skill"
@@ -16,6 +15,15 @@ func init() {
 	})
 }
 
+// mcpFormat tells streamable HTTP servers from SSE ones by the key of
+// their URL, as Gemini CLI expects, rather than by a type.
+var mcpFormat = projector.MCPFormat{
+	URLKeys: map[string]string{
+		config.TransportHTTP: "httpUrl",
+		config.TransportSSE:  "url",
+	},
+}
+
 type geminiProjector struct {
 	sp projector.SkillProjector
 }
@@ -26,6 +34,14 @@ func (g *geminiProjector) GitignoreEntries() []string {
 	return []string{".gemini/"}
 }
 
+func (g *geminiProjector) Targets(opts projector.ProjectionOpts) (projector.Targets, error) {
+	configPath, err := mcpConfigPath(opts)
+	if err != nil {
+		return projector.Targets{}, err
+	}
+	return projector.Targets{SkillsDir: g.sp.SkillsDir(opts), MCPConfig: configPath, MCPPointer: "/mcpServers"}, nil
+}
+
 func (g *geminiProjector) SupportsSkills() bool {
 	return true
 }
@@ -42,57 +58,52 @@ func (g *geminiProjector) SupportsMCPServers() bool {
 	return true
 }
 
-func (g *geminiProjector) ProjectMCPServers(opts projector.ProjectionOpts, servers []mcp.MCPServer) error {
-	var configPath string
-	if opts.Scope == projector.ScopeGlobal {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return fmt.Errorf("failed to get home directory: %w", err)
-		}
+func (g *geminiProjector) MCPLimits() projector.Limits {
+	return projector.Limits{}
+}
 
-		configPath = filepath.Join(homeDir, ".gemini", "settings.json")
-	} else {
-		configPath = filepath.Join(opts.ProjectDir, ".gemini", "settings.json")
-	}
+func (g *geminiProjector) MCPFormat() projector.MCPFormat {
+	return mcpFormat
+}
 
-	config, err := projector.ReadJsonConfig(configPath)
+func (g *geminiProjector) ProjectMCPServers(opts projector.ProjectionOpts, servers []mcp.MCPServer) error {
+	configPath, err := mcpConfigPath(opts)
 	if err != nil {
 		return err
 	}
 
-	for _, server := range servers {
-		serverConfig := projector.BuildMCPServerJsonConfig(server)
-
+	return projector.UpdateJsonConfig(opts, configPath, func(config map[string]any) error {
 		mcpServers := projector.GetOrCreateMap(config, "mcpServers")
-		mcpServers[server.Name()] = serverConfig
-	}
-
-	return projector.WriteJsonConfig(configPath, config)
+		if err := projector.SetMCPServers(opts, mcpFormat, configPath, "/mcpServers", mcpServers, servers); err != nil {
+			return err
+		}
+		return projector.CheckMCPLimits("gemini", configPath, g.MCPLimits(), opts, mcpServers)
+	})
 }
 
 func (g *geminiProjector) UnprojectMCPServers(opts projector.ProjectionOpts, names []string) error {
-	var configPath string
-	if opts.Scope == projector.ScopeGlobal {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return fmt.Errorf("failed to get home directory: %w", err)
-		}
-
-		configPath = filepath.Join(homeDir, ".gemini", "settings.json")
-	} else {
-		configPath = filepath.Join(opts.ProjectDir, ".gemini", "settings.json")
-	}
-
-	config, err := projector.ReadJsonConfig(configPath)
+	configPath, err := mcpConfigPath(opts)
 	if err != nil {
 		return err
 	}
 
-	if mcpServers, ok := config["mcpServers"].(map[string]any); ok {
-		for _, name := range names {
-			delete(mcpServers, name)
+	return projector.UpdateJsonConfig(opts, configPath, func(config map[string]any) error {
+		if mcpServers, ok := config["mcpServers"].(map[string]any); ok {
+			projector.DeleteMCPServers(opts, configPath, "/mcpServers", mcpServers, names)
 		}
-	}
+		return nil
+	})
+}
 
-	return projector.WriteJsonConfig(configPath, config)
+// mcpConfigPath returns .gemini/settings.json in the home directory for global
+// projections and in the project otherwise.
+func mcpConfigPath(opts projector.ProjectionOpts) (string, error) {
+	if opts.Scope != projector.ScopeGlobal {
+		return filepath.Join(opts.ProjectDir, ".gemini", "settings.json"), nil
+	}
+	homeDir, err := config.HomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".gemini", "settings.json"), nil
 }
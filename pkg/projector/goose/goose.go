@@ -0,0 +1,125 @@
+package goose
+
+import (
+	"path/filepath"
+	"runtime"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/mcp"
+	"github.com/agentpkg/agentpkg/pkg/projector"
+	"github.com/agentpkg/agentpkg/pkg/skill"
+)
+
+func init() {
+	projector.RegisterProjector("goose", &gooseProjector{})
+}
+
+// mcpFormat writes servers as Goose extensions, which repeat their name,
+// take stdio commands under "cmd" and env under "envs", and remote URLs
+// under "uri".
+var mcpFormat = projector.MCPFormat{
+	Types: map[string]string{
+		config.TransportStdio: "stdio",
+		config.TransportHTTP:  "streamable_http",
+		config.TransportSSE:   "sse",
+	},
+	URLKeys: map[string]string{
+		config.TransportHTTP: "uri",
+		config.TransportSSE:  "uri",
+	},
+	CommandKey: "cmd",
+	EnvKey:     "envs",
+	NameKey:    "name",
+	Fields: map[string]any{
+		"enabled": true,
+		"timeout": 300,
+	},
+}
+
+// gooseProjector projects MCP servers into Block's Goose as extensions.
+// Goose has a single config file, so servers of project installs are
+// written there too, like servers with a global scope (see
+// config.ServerScopeGlobal), under entries named after their project (see
+// projector.Targets.SharedMCPConfig). Skills are not supported.
+type gooseProjector struct{}
+
+var _ projector.Projector = &gooseProjector{}
+
+func (g *gooseProjector) GitignoreEntries() []string {
+	return nil
+}
+
+func (g *gooseProjector) Targets(opts projector.ProjectionOpts) (projector.Targets, error) {
+	configPath, err := mcpConfigPath()
+	if err != nil {
+		return projector.Targets{}, err
+	}
+	return projector.Targets{MCPConfig: configPath, MCPPointer: "/extensions", SharedMCPConfig: true}, nil
+}
+
+func (g *gooseProjector) SupportsSkills() bool {
+	return false
+}
+
+func (g *gooseProjector) ProjectSkills(opts projector.ProjectionOpts, packages []skill.Skill) error {
+	return nil
+}
+
+func (g *gooseProjector) UnprojectSkills(opts projector.ProjectionOpts, names []string) error {
+	return nil
+}
+
+func (g *gooseProjector) SupportsMCPServers() bool {
+	return true
+}
+
+func (g *gooseProjector) MCPLimits() projector.Limits {
+	return projector.Limits{}
+}
+
+func (g *gooseProjector) MCPFormat() projector.MCPFormat {
+	return mcpFormat
+}
+
+func (g *gooseProjector) ProjectMCPServers(opts projector.ProjectionOpts, servers []mcp.MCPServer) error {
+	configPath, err := mcpConfigPath()
+	if err != nil {
+		return err
+	}
+
+	return projector.UpdateJsonConfig(opts, configPath, func(config map[string]any) error {
+		extensions := projector.GetOrCreateMap(config, "extensions")
+		return projector.SetMCPServers(opts, mcpFormat, configPath, "/extensions", extensions, servers)
+	})
+}
+
+func (g *gooseProjector) UnprojectMCPServers(opts projector.ProjectionOpts, names []string) error {
+	configPath, err := mcpConfigPath()
+	if err != nil {
+		return err
+	}
+
+	return projector.UpdateJsonConfig(opts, configPath, func(config map[string]any) error {
+		if extensions, ok := config["extensions"].(map[string]any); ok {
+			projector.DeleteMCPServers(opts, configPath, "/extensions", extensions, names)
+		}
+		return nil
+	})
+}
+
+// mcpConfigPath returns Goose's config.yaml in the home directory.
+func mcpConfigPath() (string, error) {
+	homeDir, err := config.HomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir(homeDir, runtime.GOOS), "config.yaml"), nil
+}
+
+// configDir returns Goose's config directory under homeDir on goos.
+func configDir(homeDir, goos string) string {
+	if goos == "windows" {
+		return filepath.Join(homeDir, "AppData", "Roaming", "Block", "goose", "config")
+	}
+	return filepath.Join(homeDir, ".config", "goose")
+}
@@ -0,0 +1,95 @@
+package goose
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/mcp"
+	"github.com/agentpkg/agentpkg/pkg/projector"
+	"sigs.k8s.io/yaml"
+)
+
+// server is an MCP server with fixed fields.
+type server struct {
+	name, transport, command, url string
+	env, headers                  map[string]string
+}
+
+func (s server) Name() string               { return s.name }
+func (s server) Validate() error            { return nil }
+func (s server) Transport() string          { return s.transport }
+func (s server) Command() string            { return s.command }
+func (s server) Args() []string             { return nil }
+func (s server) URL() string                { return s.url }
+func (s server) Headers() map[string]string { return s.headers }
+func (s server) Env() map[string]string     { return s.env }
+
+func TestProjectMCPServers(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("APKG_HOME", homeDir)
+	configPath, err := mcpConfigPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(configPath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	initial := "GOOSE_PROVIDER: anthropic\nextensions:\n  developer:\n    bundled: true\n    enabled: true\n    name: developer\n    type: builtin\n"
+	if err := os.WriteFile(configPath, []byte(initial), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	g := &gooseProjector{}
+	opts := projector.ProjectionOpts{ProjectDir: t.TempDir()}
+	servers := []mcp.MCPServer{
+		server{name: "fs", transport: "stdio", command: "/store/bin/server-fs", env: map[string]string{"ROOT": "/src"}},
+		server{name: "api", transport: "http", url: "https://example.com/mcp", headers: map[string]string{"Authorization": "Bearer t"}},
+	}
+	if err := g.ProjectMCPServers(opts, servers); err != nil {
+		t.Fatalf("ProjectMCPServers() error = %v", err)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := yaml.Unmarshal(data, &got); err != nil {
+		t.Fatalf("parsing %s: %v\n%s", configPath, err, data)
+	}
+	if got["GOOSE_PROVIDER"] != "anthropic" {
+		t.Errorf("GOOSE_PROVIDER = %v, want it kept", got["GOOSE_PROVIDER"])
+	}
+	extensions := got["extensions"].(map[string]any)
+	want := map[string]any{
+		"fs": map[string]any{
+			"name": "fs", "type": "stdio", "cmd": "/store/bin/server-fs",
+			"envs": map[string]any{"ROOT": "/src"}, "enabled": true, "timeout": float64(300),
+		},
+		"api": map[string]any{
+			"name": "api", "type": "streamable_http", "uri": "https://example.com/mcp",
+			"headers": map[string]any{"Authorization": "Bearer t"}, "enabled": true, "timeout": float64(300),
+		},
+	}
+	for name, entry := range want {
+		if !reflect.DeepEqual(extensions[name], entry) {
+			t.Errorf("extensions[%s] = %v, want %v", name, extensions[name], entry)
+		}
+	}
+	if _, ok := extensions["developer"]; !ok {
+		t.Error("expected the developer extension to remain")
+	}
+
+	if err := g.UnprojectMCPServers(opts, []string{"fs", "api"}); err != nil {
+		t.Fatalf("UnprojectMCPServers() error = %v", err)
+	}
+	names, err := projector.MCPServerNames(configPath, "/extensions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names, []string{"developer"}) {
+		t.Errorf("extensions after UnprojectMCPServers() = %v, want [developer]", names)
+	}
+}
@@ -0,0 +1,71 @@
+package projector
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Limits are bounds an agent puts on the MCP servers config it loads. Zero
+// fields are unlimited.
+type Limits struct {
+	// MaxMCPServers is the number of MCP servers the agent loads.
+	MaxMCPServers int
+	// MaxMCPConfigBytes is the size of the MCP servers config, as JSON,
+	// the agent loads.
+	MaxMCPConfigBytes int
+	// Truncates reports whether the agent loads what fits the limits and
+	// drops the rest. Otherwise it rejects the whole config, and projecting
+	// more than fits is refused.
+	Truncates bool
+}
+
+// LimitError is returned when a projection would exceed a limit of an
+// agent that rejects configs over it, or passed to ProjectionOpts.Warn if
+// the agent truncates them.
+type LimitError struct {
+	Agent     string
+	Path      string
+	Reason    string
+	Truncates bool
+}
+
+func (e *LimitError) Error() string {
+	effect := "would reject"
+	if e.Truncates {
+		effect = "only loads part of"
+	}
+	return fmt.Sprintf("%s %s %s: %s; consider running some servers as containers behind `apkg serve`, whose config entries are only a URL",
+		e.Agent, effect, e.Path, e.Reason)
+}
+
+// CheckMCPLimits checks servers, the MCP servers config of agent at path
+// as it will be written, against limits. Exceeding the limits of an agent
+// that truncates its config is passed to opts.Warn; otherwise it is an
+// error.
+func CheckMCPLimits(agent, path string, limits Limits, opts ProjectionOpts, servers map[string]any) error {
+	var reason string
+	if limits.MaxMCPServers > 0 && len(servers) > limits.MaxMCPServers {
+		reason = fmt.Sprintf("%d MCP servers exceed the limit of %d", len(servers), limits.MaxMCPServers)
+	}
+	if reason == "" && limits.MaxMCPConfigBytes > 0 {
+		data, err := json.Marshal(servers)
+		if err != nil {
+			return fmt.Errorf("failed to marshal MCP servers config: %w", err)
+		}
+		if len(data) > limits.MaxMCPConfigBytes {
+			reason = fmt.Sprintf("MCP servers config of %d bytes exceeds the limit of %d", len(data), limits.MaxMCPConfigBytes)
+		}
+	}
+	if reason == "" {
+		return nil
+	}
+
+	err := &LimitError{Agent: agent, Path: path, Reason: reason, Truncates: limits.Truncates}
+	if !limits.Truncates {
+		return err
+	}
+	if opts.Warn != nil {
+		opts.Warn(err)
+	}
+	return nil
+}
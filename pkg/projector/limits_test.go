@@ -0,0 +1,80 @@
+package projector
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestCheckMCPLimits(t *testing.T) {
+	servers := func(n int) map[string]any {
+		m := make(map[string]any)
+		for i := range n {
+			m[fmt.Sprintf("server-%d", i)] = map[string]any{"command": "run"}
+		}
+		return m
+	}
+
+	tests := map[string]struct {
+		limits   Limits
+		servers  map[string]any
+		wantErr  bool
+		wantWarn bool
+	}{
+		"no limits": {
+			servers: servers(100),
+		},
+		"within server limit": {
+			limits:  Limits{MaxMCPServers: 2},
+			servers: servers(2),
+		},
+		"over server limit is refused": {
+			limits:  Limits{MaxMCPServers: 2},
+			servers: servers(3),
+			wantErr: true,
+		},
+		"over server limit of truncating agent warns": {
+			limits:   Limits{MaxMCPServers: 2, Truncates: true},
+			servers:  servers(3),
+			wantWarn: true,
+		},
+		"within size limit": {
+			limits:  Limits{MaxMCPConfigBytes: 1000},
+			servers: servers(3),
+		},
+		"over size limit is refused": {
+			limits:  Limits{MaxMCPConfigBytes: 50},
+			servers: servers(3),
+			wantErr: true,
+		},
+		"over size limit of truncating agent warns": {
+			limits:   Limits{MaxMCPConfigBytes: 50, Truncates: true},
+			servers:  servers(3),
+			wantWarn: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var warnings []error
+			opts := ProjectionOpts{Warn: func(err error) { warnings = append(warnings, err) }}
+
+			err := CheckMCPLimits("test-agent", "mcp.json", tc.limits, opts, tc.servers)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("CheckMCPLimits() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if (len(warnings) > 0) != tc.wantWarn {
+				t.Errorf("warnings = %v, wantWarn %v", warnings, tc.wantWarn)
+			}
+
+			reported := err
+			if len(warnings) > 0 {
+				reported = warnings[0]
+			}
+			var limitErr *LimitError
+			if reported != nil && !errors.As(reported, &limitErr) {
+				t.Errorf("expected a LimitError, got %v", reported)
+			}
+		})
+	}
+}
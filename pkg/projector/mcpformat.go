@@ -0,0 +1,165 @@
+package projector
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+)
+
+// MCPFormat describes how an agent's JSON config writes MCP server
+// entries: the "type" that marks each transport, and the key remote
+// servers take their URL under. Agents disagree on both, e.g. Gemini CLI
+// tells streamable HTTP from SSE servers by "httpUrl" instead of a type.
+type MCPFormat struct {
+	// Types maps transports (see config.Transports) to the "type" of
+	// their entries. Entries of transports missing from it have no type.
+	Types map[string]string
+	// URLKeys maps remote transports to the key of their URL, "url" for
+	// those missing from it.
+	URLKeys map[string]string
+	// CommandKey and EnvKey are the keys of stdio servers' command and
+	// env, "command" and "env" if empty (Goose uses "cmd" and "envs").
+	CommandKey string
+	EnvKey     string
+	// NameKey, if set, is the key entries repeat their server's name
+	// under.
+	NameKey string
+	// Fields are added to every entry, e.g. Goose's "enabled".
+	Fields map[string]any
+}
+
+// WithTypes returns f with the types in overrides (see
+// ProjectionOpts.MCPTypes) replacing its own.
+func (f MCPFormat) WithTypes(overrides map[string]string) MCPFormat {
+	if len(overrides) == 0 {
+		return f
+	}
+	types := make(map[string]string, len(f.Types)+len(overrides))
+	maps.Copy(types, f.Types)
+	maps.Copy(types, overrides)
+	f.Types = types
+	return f
+}
+
+// commandKey returns the key of a stdio server's command.
+func (f MCPFormat) commandKey() string {
+	if f.CommandKey != "" {
+		return f.CommandKey
+	}
+	return "command"
+}
+
+// envKey returns the key of a stdio server's env.
+func (f MCPFormat) envKey() string {
+	if f.EnvKey != "" {
+		return f.EnvKey
+	}
+	return "env"
+}
+
+// urlKey returns the key of a server's URL for transport.
+func (f MCPFormat) urlKey(transport string) string {
+	if key, ok := f.URLKeys[transport]; ok {
+		return key
+	}
+	return "url"
+}
+
+// ParseMCPServerJsonConfig returns the config of the MCP server whose
+// entry in an agent config written in format is entry, the inverse of
+// BuildMCPServerJsonConfig. Entries with a command are unmanaged stdio
+// servers; the others are remote servers, whose transport is read from
+// their type or, for formats without one, the key of their URL. Types
+// other agents write, like "streamable-http", are understood too.
+func ParseMCPServerJsonConfig(entry map[string]any, format MCPFormat) (config.MCPSource, error) {
+	if command, ok := entry[format.commandKey()].(string); ok && command != "" {
+		ms := config.MCPSource{
+			Transport:               config.TransportStdio,
+			UnmanagedStdioMCPConfig: &config.UnmanagedStdioMCPConfig{Command: command},
+		}
+		args := jsonStrings(entry["args"])
+		env := jsonStringMap(entry[format.envKey()])
+		if len(args) > 0 || len(env) > 0 {
+			ms.LocalMCPConfig = &config.LocalMCPConfig{Args: args, Env: env}
+		}
+		return ms, nil
+	}
+
+	transport := ""
+	if typ, _ := entry["type"].(string); typ != "" {
+		for t, name := range format.Types {
+			if name == typ {
+				transport = t
+			}
+		}
+		if transport == "" {
+			switch typ {
+			case "http", "streamable-http", "streamableHttp":
+				transport = config.TransportHTTP
+			case "sse":
+				transport = config.TransportSSE
+			default:
+				return config.MCPSource{}, fmt.Errorf("unknown MCP server type %q", typ)
+			}
+		}
+	} else {
+		// Without a type, the key of the URL tells the transport apart in
+		// formats that have no type for it (e.g. Gemini CLI's "httpUrl").
+		for _, t := range []string{config.TransportHTTP, config.TransportSSE} {
+			if _, typed := format.Types[t]; typed {
+				continue
+			}
+			if _, ok := entry[format.urlKey(t)].(string); ok {
+				transport = t
+				break
+			}
+		}
+		if transport == "" {
+			transport = config.TransportHTTP
+		}
+	}
+
+	url, _ := entry[format.urlKey(transport)].(string)
+	if url == "" {
+		url, _ = entry["url"].(string)
+	}
+	if url == "" {
+		return config.MCPSource{}, fmt.Errorf("MCP server entry has neither a command nor a URL")
+	}
+	ms := config.MCPSource{
+		Transport:             transport,
+		ExternalHttpMCPConfig: &config.ExternalHttpMCPConfig{URL: url},
+	}
+	if headers := jsonStringMap(entry["headers"]); len(headers) > 0 {
+		ms.HttpMCPConfig = &config.HttpMCPConfig{Headers: headers}
+	}
+	return ms, nil
+}
+
+// jsonStrings returns the strings of the JSON array v.
+func jsonStrings(v any) []string {
+	items, _ := v.([]any)
+	var strs []string
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			strs = append(strs, s)
+		}
+	}
+	return strs
+}
+
+// jsonStringMap returns the string values of the JSON object v.
+func jsonStringMap(v any) map[string]string {
+	obj, _ := v.(map[string]any)
+	if len(obj) == 0 {
+		return nil
+	}
+	m := make(map[string]string, len(obj))
+	for k, item := range obj {
+		if s, ok := item.(string); ok {
+			m[k] = s
+		}
+	}
+	return m
+}
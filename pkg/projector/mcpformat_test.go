@@ -0,0 +1,184 @@
+package projector
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+)
+
+type stdioServer struct{ httpServer }
+
+func (s stdioServer) Transport() string { return "stdio" }
+func (s stdioServer) Command() string   { return "server" }
+
+func TestBuildMCPServerJsonConfig(t *testing.T) {
+	typed := MCPFormat{Types: map[string]string{"http": "http", "sse": "sse"}}
+	byURLKey := MCPFormat{URLKeys: map[string]string{"http": "httpUrl", "sse": "url"}}
+
+	tests := map[string]struct {
+		format    MCPFormat
+		overrides map[string]string
+		transport string
+		want      map[string]any
+	}{
+		"typed http": {
+			format:    typed,
+			transport: "http",
+			want:      map[string]any{"type": "http", "url": "https://example.com/mcp"},
+		},
+		"typed sse": {
+			format:    typed,
+			transport: "sse",
+			want:      map[string]any{"type": "sse", "url": "https://example.com/mcp"},
+		},
+		"http by url key": {
+			format:    byURLKey,
+			transport: "http",
+			want:      map[string]any{"httpUrl": "https://example.com/mcp"},
+		},
+		"sse by url key": {
+			format:    byURLKey,
+			transport: "sse",
+			want:      map[string]any{"url": "https://example.com/mcp"},
+		},
+		"overridden type": {
+			format:    typed,
+			overrides: map[string]string{"http": "streamable-http"},
+			transport: "http",
+			want:      map[string]any{"type": "streamable-http", "url": "https://example.com/mcp"},
+		},
+		"stdio without type": {
+			format:    typed,
+			transport: "stdio",
+			want:      map[string]any{"command": "server"},
+		},
+		"stdio with overridden type": {
+			format:    typed,
+			overrides: map[string]string{"stdio": "stdio"},
+			transport: "stdio",
+			want:      map[string]any{"type": "stdio", "command": "server"},
+		},
+		"renamed keys with name and fields": {
+			format:    MCPFormat{CommandKey: "cmd", NameKey: "name", Fields: map[string]any{"enabled": true}},
+			transport: "stdio",
+			want:      map[string]any{"name": "api", "cmd": "server", "enabled": true},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			remote := httpServer{name: "api", url: "https://example.com/mcp"}
+			var got map[string]any
+			switch tc.transport {
+			case "stdio":
+				got = BuildMCPServerJsonConfig(stdioServer{remote}, tc.format.WithTypes(tc.overrides))
+			case "sse":
+				got = BuildMCPServerJsonConfig(sseServer{remote}, tc.format.WithTypes(tc.overrides))
+			default:
+				got = BuildMCPServerJsonConfig(remote, tc.format.WithTypes(tc.overrides))
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("BuildMCPServerJsonConfig() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	if len(typed.Types) != 2 {
+		t.Errorf("WithTypes() changed the format's own types: %v", typed.Types)
+	}
+}
+
+type sseServer struct{ httpServer }
+
+func (s sseServer) Transport() string { return "sse" }
+
+func TestParseMCPServerJsonConfig(t *testing.T) {
+	typed := MCPFormat{Types: map[string]string{"http": "http", "sse": "sse"}}
+	byURLKey := MCPFormat{URLKeys: map[string]string{"http": "httpUrl", "sse": "url"}}
+	remote := func(transport string) config.MCPSource {
+		return config.MCPSource{Transport: transport, ExternalHttpMCPConfig: &config.ExternalHttpMCPConfig{URL: "https://example.com/mcp"}}
+	}
+
+	tests := map[string]struct {
+		format  MCPFormat
+		entry   map[string]any
+		want    config.MCPSource
+		wantErr bool
+	}{
+		"stdio": {
+			format: typed,
+			entry:  map[string]any{"command": "server", "args": []any{"--port", "0"}, "env": map[string]any{"KEY": "value"}},
+			want: config.MCPSource{
+				Transport:               "stdio",
+				UnmanagedStdioMCPConfig: &config.UnmanagedStdioMCPConfig{Command: "server"},
+				LocalMCPConfig:          &config.LocalMCPConfig{Args: []string{"--port", "0"}, Env: map[string]string{"KEY": "value"}},
+			},
+		},
+		"renamed command and env keys": {
+			format: MCPFormat{CommandKey: "cmd", EnvKey: "envs"},
+			entry:  map[string]any{"name": "api", "cmd": "server", "envs": map[string]any{"KEY": "value"}, "enabled": true},
+			want: config.MCPSource{
+				Transport:               "stdio",
+				UnmanagedStdioMCPConfig: &config.UnmanagedStdioMCPConfig{Command: "server"},
+				LocalMCPConfig:          &config.LocalMCPConfig{Env: map[string]string{"KEY": "value"}},
+			},
+		},
+		"typed sse": {
+			format: typed,
+			entry:  map[string]any{"type": "sse", "url": "https://example.com/mcp"},
+			want:   remote("sse"),
+		},
+		"other agent's type": {
+			format: typed,
+			entry:  map[string]any{"type": "streamable-http", "url": "https://example.com/mcp"},
+			want:   remote("http"),
+		},
+		"untyped in typed format": {
+			format: typed,
+			entry:  map[string]any{"url": "https://example.com/mcp"},
+			want:   remote("http"),
+		},
+		"http by url key": {
+			format: byURLKey,
+			entry:  map[string]any{"httpUrl": "https://example.com/mcp"},
+			want:   remote("http"),
+		},
+		"sse by url key": {
+			format: byURLKey,
+			entry:  map[string]any{"url": "https://example.com/mcp"},
+			want:   remote("sse"),
+		},
+		"headers": {
+			format: typed,
+			entry:  map[string]any{"type": "http", "url": "https://example.com/mcp", "headers": map[string]any{"X-Key": "k"}},
+			want: config.MCPSource{
+				Transport:             "http",
+				ExternalHttpMCPConfig: &config.ExternalHttpMCPConfig{URL: "https://example.com/mcp"},
+				HttpMCPConfig:         &config.HttpMCPConfig{Headers: map[string]string{"X-Key": "k"}},
+			},
+		},
+		"unknown type": {
+			format:  typed,
+			entry:   map[string]any{"type": "websocket", "url": "wss://example.com/mcp"},
+			wantErr: true,
+		},
+		"no command or url": {
+			format:  typed,
+			entry:   map[string]any{"type": "http"},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseMCPServerJsonConfig(tc.entry, tc.format)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParseMCPServerJsonConfig() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if !tc.wantErr && !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("ParseMCPServerJsonConfig() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
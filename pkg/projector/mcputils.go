@@ -3,10 +3,13 @@ package projector
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/agentpkg/agentpkg/pkg/mcp"
+	"github.com/pelletier/go-toml/v2"
 )
 
 const (
@@ -24,44 +27,106 @@ func ReadJsonConfig(path string) (map[string]any, error) {
 		return nil, fmt.Errorf("failed to read %q: %w", path, err)
 	}
 
-	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse %q as json: %w", path, err)
+	if err := unmarshalConfig(path, data, &config); err != nil {
+		return nil, err
 	}
 
 	return config, nil
 }
 
-func WriteJsonConfig(path string, config map[string]any) error {
-	data, err := json.MarshalIndent(config, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal config: %w", err)
+// configFormats maps the config files of custom projectors to their
+// format (see SetConfigFormat).
+var configFormats sync.Map
+
+// SetConfigFormat records that the config file at path is in format, one
+// of config.ProjectorFormats, whatever its extension.
+func SetConfigFormat(path, format string) {
+	configFormats.Store(filepath.Clean(path), format)
+}
+
+// configFormat returns the format of the config file at path: the one
+// recorded with SetConfigFormat, YAML for files like Goose's config.yaml,
+// TOML for .toml files, and JSON otherwise. Agent configs of any format
+// are read and written as the same objects.
+func configFormat(path string) string {
+	if format, ok := configFormats.Load(filepath.Clean(path)); ok {
+		return format.(string)
 	}
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".toml":
+		return "toml"
+	default:
+		return "json"
+	}
+}
 
-	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
-		return fmt.Errorf("failed to create directory for %q: %w", path, err)
+// unmarshalConfig parses data, the content of the config file at path,
+// into config.
+func unmarshalConfig(path string, data []byte, config *map[string]any) error {
+	switch configFormat(path) {
+	case "yaml":
+		if err := unmarshalYAML(data, config); err != nil {
+			return fmt.Errorf("failed to parse %q as yaml: %w", path, err)
+		}
+		return nil
+	case "toml":
+		if err := toml.Unmarshal(data, config); err != nil {
+			return fmt.Errorf("failed to parse %q as toml: %w", path, err)
+		}
+		return nil
+	}
+	if err := json.Unmarshal(data, config); err != nil {
+		return fmt.Errorf("failed to parse %q as json: %w", path, err)
 	}
+	return nil
+}
 
-	if err := os.WriteFile(path, data, configFilePerms); err != nil {
-		return fmt.Errorf("failed to write %q: %w", path, err)
+// marshalConfig returns the content of the config file at path holding
+// config. YAML files are changed in place (see marshalYAML).
+func marshalConfig(path string, config map[string]any) ([]byte, error) {
+	switch configFormat(path) {
+	case "yaml":
+		data, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		return marshalYAML(data, config)
+	case "toml":
+		return toml.Marshal(config)
 	}
+	return json.MarshalIndent(config, "", "  ")
+}
 
-	return nil
+func WriteJsonConfig(path string, config map[string]any) error {
+	return writeJsonConfigAtomic(path, config)
 }
 
-func BuildMCPServerJsonConfig(server mcp.MCPServer) map[string]any {
-	config := make(map[string]any)
+// BuildMCPServerJsonConfig returns the entry of server in an agent config
+// written in format.
+func BuildMCPServerJsonConfig(server mcp.MCPServer, format MCPFormat) map[string]any {
+	config := maps.Clone(format.Fields)
+	if config == nil {
+		config = make(map[string]any)
+	}
+	if format.NameKey != "" {
+		config[format.NameKey] = server.Name()
+	}
+	if typ, ok := format.Types[server.Transport()]; ok {
+		config["type"] = typ
+	}
 
 	if server.Transport() == "stdio" {
-		config["command"] = server.Command()
+		config[format.commandKey()] = server.Command()
 		if args := server.Args(); len(args) > 0 {
 			config["args"] = args
 		}
 		if env := server.Env(); len(env) > 0 {
-			config["env"] = env
+			config[format.envKey()] = env
 		}
 	} else {
-		config["type"] = server.Transport()
-		config["url"] = server.URL()
+		config[format.urlKey(server.Transport())] = server.URL()
 		if headers := server.Headers(); len(headers) > 0 {
 			config["headers"] = headers
 		}
@@ -0,0 +1,269 @@
+package projector
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/agentpkg/agentpkg/pkg/mcp"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// StateFileName is the file in apkg's global config directory that
+// records the agent config entries apkg owns, across projects, and what
+// apkg projected for each project (see UpdateState).
+const StateFileName = "state.toml"
+
+// Ways to resolve a Conflict.
+const (
+	// ConflictOverwrite replaces the existing entry with apkg's and owns it.
+	ConflictOverwrite = "overwrite"
+	// ConflictAdopt keeps the existing entry this time and owns it, so
+	// later projections and removals manage it.
+	ConflictAdopt = "adopt"
+	// ConflictSkip leaves the existing entry alone and unowned.
+	ConflictSkip = "skip"
+)
+
+// ConflictResolutions are the accepted resolutions of a Conflict.
+var ConflictResolutions = []string{ConflictOverwrite, ConflictAdopt, ConflictSkip}
+
+// Conflict is an MCP server entry in an agent config that apkg is about to
+// project over, but didn't create.
+type Conflict struct {
+	// Path is the agent config file.
+	Path string
+	// Pointer is the JSON pointer of the entry in the file.
+	Pointer string
+	Server  string
+}
+
+// Ownership records the entries of agent config files that apkg created,
+// so projections can tell them apart from entries added by hand or by
+// other tools. It is safe for concurrent use.
+type Ownership struct {
+	mu sync.Mutex
+	// entries maps config file paths to the JSON pointers of the entries
+	// apkg owns in them.
+	entries map[string][]string
+	// adopted are the entries adopted since loading, as path and pointer
+	// joined by NUL, which are kept as they are while the process runs.
+	adopted map[string]bool
+	// disowned are the entries disowned since loading, keyed like
+	// adopted, which a replayed deletion may still delete.
+	disowned map[string]bool
+}
+
+type ownershipFile struct {
+	Owned map[string][]string `toml:"owned,omitempty"`
+}
+
+// LoadOwnership reads the ownership recorded at path, which may not exist.
+func LoadOwnership(path string) (*Ownership, error) {
+	o := &Ownership{entries: make(map[string][]string), adopted: make(map[string]bool), disowned: make(map[string]bool)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return o, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	var f ownershipFile
+	if err := toml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	for file, pointers := range f.Owned {
+		o.entries[file] = pointers
+	}
+	return o, nil
+}
+
+// Save writes the ownership to path, keeping the state file's other
+// tables, and creating its directory.
+func (o *Ownership) Save(path string) error {
+	o.mu.Lock()
+	owned := make(map[string][]string, len(o.entries))
+	for file, pointers := range o.entries {
+		if len(pointers) > 0 {
+			owned[file] = slices.Sorted(slices.Values(pointers))
+		}
+	}
+	o.mu.Unlock()
+
+	return UpdateState(path, func(state map[string]any) {
+		if len(owned) == 0 {
+			delete(state, "owned")
+			return
+		}
+		state["owned"] = owned
+	})
+}
+
+// UpdateState rewrites the state file at path with update, which changes
+// the tables it is responsible for and leaves the others alone, so
+// ownership and the projections recorded by the installer share the file.
+func UpdateState(path string, update func(state map[string]any)) error {
+	state := make(map[string]any)
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+	if err := toml.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+	update(state)
+
+	if data, err = toml.Marshal(state); err != nil {
+		return fmt.Errorf("marshaling %s: %w", path, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating directory for %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
+
+// Owns reports whether apkg owns the entry at pointer in the file at path.
+func (o *Ownership) Owns(path, pointer string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return slices.Contains(o.entries[path], pointer)
+}
+
+// Own records that apkg owns the entry at pointer in the file at path.
+func (o *Ownership) Own(path, pointer string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if !slices.Contains(o.entries[path], pointer) {
+		o.entries[path] = append(o.entries[path], pointer)
+	}
+}
+
+// Adopt records that apkg owns the existing entry at pointer in the file
+// at path, but should leave its content alone until the next load.
+func (o *Ownership) Adopt(path, pointer string) {
+	o.Own(path, pointer)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.adopted[path+"\x00"+pointer] = true
+}
+
+func (o *Ownership) isAdopted(path, pointer string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.adopted[path+"\x00"+pointer]
+}
+
+// Disown forgets the entry at pointer in the file at path.
+func (o *Ownership) Disown(path, pointer string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.entries[path] = slices.DeleteFunc(o.entries[path], func(p string) bool { return p == pointer })
+	o.disowned[path+"\x00"+pointer] = true
+}
+
+// ownedOrDisowned reports whether apkg owns the entry at pointer in the
+// file at path, or did until this process disowned it.
+func (o *Ownership) ownedOrDisowned(path, pointer string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return slices.Contains(o.entries[path], pointer) || o.disowned[path+"\x00"+pointer]
+}
+
+// Owned returns the pointers of the entries apkg owns in the file at
+// path, sorted.
+func (o *Ownership) Owned(path string) []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	owned := slices.Clone(o.entries[path])
+	sort.Strings(owned)
+	return owned
+}
+
+// SetMCPServers sets the entries of servers in mcpServers, the object at
+// pointer (e.g. "/mcpServers") of the config file at path, written in
+// format with the types of opts.MCPTypes. With
+// opts.Ownership set, an existing entry apkg doesn't own and that differs
+// from apkg's is resolved by opts.Conflict, or overwritten without it.
+func SetMCPServers(opts ProjectionOpts, format MCPFormat, path, pointer string, mcpServers map[string]any, servers []mcp.MCPServer) error {
+	format = format.WithTypes(opts.MCPTypes)
+	for _, server := range servers {
+		name := server.Name()
+		entry := BuildMCPServerJsonConfig(server, format)
+		entryPointer := JoinPointer(pointer, name)
+
+		if opts.Ownership == nil {
+			mcpServers[name] = entry
+			continue
+		}
+
+		if opts.Ownership.isAdopted(path, entryPointer) {
+			continue
+		}
+		resolution := ConflictOverwrite
+		existing, ok := mcpServers[name]
+		if ok && !opts.Ownership.Owns(path, entryPointer) && !sameJSON(existing, entry) && opts.Conflict != nil {
+			var err error
+			resolution, err = opts.Conflict(Conflict{Path: path, Pointer: entryPointer, Server: name})
+			if err != nil {
+				return err
+			}
+		}
+		switch resolution {
+		case ConflictSkip:
+			continue
+		case ConflictAdopt:
+			opts.Ownership.Adopt(path, entryPointer)
+			continue
+		}
+		mcpServers[name] = entry
+		opts.Ownership.Own(path, entryPointer)
+	}
+	return nil
+}
+
+// DeleteMCPServers deletes the entries of names from mcpServers, the
+// object at pointer of the config file at path, and forgets that apkg
+// owned them. With opts.Ownership set, entries apkg doesn't own are left
+// alone, with a warning to opts.Warn; those it disowned earlier in the
+// process are deleted again when a session replays the deletion.
+func DeleteMCPServers(opts ProjectionOpts, path, pointer string, mcpServers map[string]any, names []string) {
+	for _, name := range names {
+		if opts.Ownership == nil {
+			delete(mcpServers, name)
+			continue
+		}
+		entryPointer := JoinPointer(pointer, name)
+		if _, ok := mcpServers[name]; ok && !opts.Ownership.ownedOrDisowned(path, entryPointer) {
+			if opts.Warn != nil {
+				opts.Warn(fmt.Errorf("left MCP server %q in %s in place: apkg didn't create it", name, path))
+			}
+			continue
+		}
+		delete(mcpServers, name)
+		opts.Ownership.Disown(path, entryPointer)
+	}
+}
+
+// JoinPointer appends key to the JSON pointer (RFC 6901) pointer.
+func JoinPointer(pointer, key string) string {
+	return pointer + "/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
+
+// sameJSON reports whether a and b encode to the same JSON.
+func sameJSON(a, b any) bool {
+	aData, aErr := json.Marshal(a)
+	bData, bErr := json.Marshal(b)
+	if aErr != nil || bErr != nil {
+		return reflect.DeepEqual(a, b)
+	}
+	return string(aData) == string(bData)
+}
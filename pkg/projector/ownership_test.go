@@ -0,0 +1,200 @@
+package projector
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/mcp"
+)
+
+type httpServer struct{ name, url string }
+
+func (s httpServer) Name() string               { return s.name }
+func (s httpServer) Validate() error            { return nil }
+func (s httpServer) Transport() string          { return "http" }
+func (s httpServer) Command() string            { return "" }
+func (s httpServer) Args() []string             { return nil }
+func (s httpServer) URL() string                { return s.url }
+func (s httpServer) Headers() map[string]string { return nil }
+func (s httpServer) Env() map[string]string     { return nil }
+
+func TestSetMCPServers(t *testing.T) {
+	const path = "/home/dev/.cursor/mcp.json"
+	server := httpServer{name: "api", url: "https://apkg.example/mcp"}
+	handWritten := map[string]any{"url": "https://hand.example/mcp"}
+
+	tests := map[string]struct {
+		existing   map[string]any
+		owned      bool
+		resolution string
+		wantAsked  bool
+		wantURL    string
+		wantOwned  bool
+	}{
+		"new entry": {
+			wantURL:   "https://apkg.example/mcp",
+			wantOwned: true,
+		},
+		"owned entry is replaced without asking": {
+			existing:  map[string]any{"api": handWritten},
+			owned:     true,
+			wantURL:   "https://apkg.example/mcp",
+			wantOwned: true,
+		},
+		"unowned entry matching apkg's is taken over": {
+			existing:  map[string]any{"api": map[string]any{"type": "http", "url": "https://apkg.example/mcp"}},
+			wantURL:   "https://apkg.example/mcp",
+			wantOwned: true,
+		},
+		"unowned entry overwritten": {
+			existing:   map[string]any{"api": handWritten},
+			resolution: ConflictOverwrite,
+			wantAsked:  true,
+			wantURL:    "https://apkg.example/mcp",
+			wantOwned:  true,
+		},
+		"unowned entry adopted": {
+			existing:   map[string]any{"api": handWritten},
+			resolution: ConflictAdopt,
+			wantAsked:  true,
+			wantURL:    "https://hand.example/mcp",
+			wantOwned:  true,
+		},
+		"unowned entry skipped": {
+			existing:   map[string]any{"api": handWritten},
+			resolution: ConflictSkip,
+			wantAsked:  true,
+			wantURL:    "https://hand.example/mcp",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ownership, err := LoadOwnership(filepath.Join(t.TempDir(), StateFileName))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.owned {
+				ownership.Own(path, "/mcpServers/api")
+			}
+
+			mcpServers := map[string]any{}
+			for k, v := range tc.existing {
+				mcpServers[k] = v
+			}
+			var asked []Conflict
+			opts := ProjectionOpts{
+				Ownership: ownership,
+				Conflict: func(c Conflict) (string, error) {
+					asked = append(asked, c)
+					return tc.resolution, nil
+				},
+			}
+
+			// A second projection, as when a session replays its mutations,
+			// must not ask again or undo the first.
+			for range 2 {
+				if err := SetMCPServers(opts, MCPFormat{Types: map[string]string{"http": "http"}}, path, "/mcpServers", mcpServers, []mcp.MCPServer{server}); err != nil {
+					t.Fatalf("SetMCPServers() error = %v", err)
+				}
+			}
+
+			wantAsked := 0
+			if tc.wantAsked {
+				wantAsked = 1
+				if tc.resolution == ConflictSkip {
+					wantAsked = 2
+				}
+			}
+			if len(asked) != wantAsked {
+				t.Errorf("asked %d time(s), want %d", len(asked), wantAsked)
+			}
+			if len(asked) > 0 && (asked[0].Path != path || asked[0].Pointer != "/mcpServers/api" || asked[0].Server != "api") {
+				t.Errorf("conflict = %+v", asked[0])
+			}
+			entry, _ := mcpServers["api"].(map[string]any)
+			if entry["url"] != tc.wantURL {
+				t.Errorf("url = %v, want %q", entry["url"], tc.wantURL)
+			}
+			if got := ownership.Owns(path, "/mcpServers/api"); got != tc.wantOwned {
+				t.Errorf("Owns() = %v, want %v", got, tc.wantOwned)
+			}
+		})
+	}
+}
+
+func TestDeleteMCPServers(t *testing.T) {
+	const path = "/home/dev/.cursor/mcp.json"
+
+	tests := map[string]struct {
+		owned     bool
+		wantKept  bool
+		wantWarns int
+	}{
+		"owned entry is deleted": {
+			owned: true,
+		},
+		"unowned entry is kept": {
+			wantKept:  true,
+			wantWarns: 2,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ownership, err := LoadOwnership(filepath.Join(t.TempDir(), StateFileName))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.owned {
+				ownership.Own(path, "/mcpServers/api")
+			}
+			var warns []error
+			opts := ProjectionOpts{Ownership: ownership, Warn: func(err error) { warns = append(warns, err) }}
+
+			// A session replaying the deletion on a re-read config deletes
+			// the entry again.
+			for range 2 {
+				mcpServers := map[string]any{"api": map[string]any{"url": "https://hand.example/mcp"}}
+				DeleteMCPServers(opts, path, "/mcpServers", mcpServers, []string{"api"})
+				if _, kept := mcpServers["api"]; kept != tc.wantKept {
+					t.Errorf("entry kept = %v, want %v", kept, tc.wantKept)
+				}
+			}
+			if len(warns) != tc.wantWarns {
+				t.Errorf("got %d warnings %v, want %d", len(warns), warns, tc.wantWarns)
+			}
+			if ownership.Owns(path, "/mcpServers/api") {
+				t.Error("entry still owned after deleting it")
+			}
+		})
+	}
+}
+
+func TestOwnershipSaveLoad(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), StateFileName)
+	o, err := LoadOwnership(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.Own("/p/.mcp.json", JoinPointer("/mcpServers", "b"))
+	o.Own("/p/.mcp.json", JoinPointer("/mcpServers", "a/x"))
+	o.Own("/home/.claude.json", "/mcpServers/c")
+	o.Disown("/home/.claude.json", "/mcpServers/c")
+	if err := o.Save(statePath); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := LoadOwnership(statePath)
+	if err != nil {
+		t.Fatalf("LoadOwnership() error = %v", err)
+	}
+	want := []string{"/mcpServers/a~1x", "/mcpServers/b"}
+	if got := loaded.Owned("/p/.mcp.json"); !slices.Equal(got, want) {
+		t.Errorf("Owned() = %v, want %v", got, want)
+	}
+	if got := loaded.Owned("/home/.claude.json"); len(got) != 0 {
+		t.Errorf("Owned() of disowned file = %v, want none", got)
+	}
+}
@@ -0,0 +1,270 @@
+package projector
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/agentpkg/agentpkg/pkg/mcp"
+	"github.com/agentpkg/agentpkg/pkg/skill"
+)
+
+// PluginPrefix starts the names of projector plugins: an executable named
+// apkg-projector-<agent> on PATH projects for <agent>.
+const PluginPrefix = "apkg-projector-"
+
+// PluginProtocolVersion is the version of the requests apkg sends plugins.
+const PluginProtocolVersion = 1
+
+// Operations of plugin requests.
+const (
+	PluginDescribe            = "describe"
+	PluginTargets             = "targets"
+	PluginProjectSkills       = "project-skills"
+	PluginUnprojectSkills     = "unproject-skills"
+	PluginProjectMCPServers   = "project-mcp-servers"
+	PluginUnprojectMCPServers = "unproject-mcp-servers"
+)
+
+// PluginRequest is the JSON document apkg writes to a plugin's stdin. The
+// plugin answers with a PluginResponse on stdout and exits 0, or exits
+// non-zero with an error message on stderr.
+type PluginRequest struct {
+	Version   int    `json:"version"`
+	Operation string `json:"operation"`
+	// Scope is "project" or "global".
+	Scope      string         `json:"scope"`
+	ProjectDir string         `json:"project_dir"`
+	Skills     []PluginSkill  `json:"skills,omitempty"`
+	Servers    []PluginServer `json:"servers,omitempty"`
+	// Names are the skills or servers to unproject.
+	Names []string `json:"names,omitempty"`
+}
+
+// PluginSkill is a skill to project, stored at Dir.
+type PluginSkill struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Dir         string `json:"dir"`
+}
+
+// PluginServer is an MCP server to project, as agents launch or connect
+// to it.
+type PluginServer struct {
+	Name      string            `json:"name"`
+	Transport string            `json:"transport"`
+	Command   string            `json:"command,omitempty"`
+	Args      []string          `json:"args,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+	URL       string            `json:"url,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+}
+
+// PluginResponse is a plugin's answer. describe fills in what the agent
+// supports and its gitignore entries, targets the fields of Targets;
+// other operations may leave it empty.
+type PluginResponse struct {
+	Skills     bool     `json:"skills,omitempty"`
+	MCPServers bool     `json:"mcp_servers,omitempty"`
+	Gitignore  []string `json:"gitignore,omitempty"`
+	SkillsDir  string   `json:"skills_dir,omitempty"`
+	MCPConfig  string   `json:"mcp_config,omitempty"`
+	MCPPointer string   `json:"mcp_pointer,omitempty"`
+	// Warnings are passed to ProjectionOpts.Warn.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// plugins caches the plugins found on PATH, looked up again when PATH
+// changes.
+var plugins struct {
+	sync.Mutex
+	path   string
+	agents map[string]*pluginProjector
+}
+
+// pluginAgents returns projectors for the plugins on PATH by agent. The
+// first plugin on PATH for an agent wins, as the shell would run it.
+func pluginAgents() map[string]*pluginProjector {
+	plugins.Lock()
+	defer plugins.Unlock()
+	path := os.Getenv("PATH")
+	if plugins.agents != nil && plugins.path == path {
+		return plugins.agents
+	}
+
+	agents := make(map[string]*pluginProjector)
+	for _, dir := range filepath.SplitList(path) {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			agent, ok := strings.CutPrefix(entry.Name(), PluginPrefix)
+			agent = strings.TrimSuffix(agent, ".exe")
+			if !ok || agent == "" || agents[agent] != nil {
+				continue
+			}
+			bin := filepath.Join(dir, entry.Name())
+			if info, err := os.Stat(bin); err != nil || info.IsDir() || info.Mode()&0o111 == 0 {
+				continue
+			}
+			agents[agent] = &pluginProjector{agent: agent, bin: bin}
+		}
+	}
+	plugins.path, plugins.agents = path, agents
+	return agents
+}
+
+// pluginProjector projects for an agent by running its plugin (see
+// PluginRequest).
+type pluginProjector struct {
+	agent string
+	bin   string
+
+	once    sync.Once
+	desc    PluginResponse
+	descErr error
+}
+
+var _ Projector = &pluginProjector{}
+
+// describe asks the plugin what it supports, once.
+func (p *pluginProjector) describe() (PluginResponse, error) {
+	p.once.Do(func() {
+		resp, err := p.run(ProjectionOpts{}, PluginRequest{Operation: PluginDescribe})
+		if err != nil {
+			p.descErr = err
+			return
+		}
+		p.desc = *resp
+	})
+	return p.desc, p.descErr
+}
+
+// run sends req, with the scope and project of opts, to the plugin.
+func (p *pluginProjector) run(opts ProjectionOpts, req PluginRequest) (*PluginResponse, error) {
+	req.Version = PluginProtocolVersion
+	req.Scope, req.ProjectDir = "project", opts.ProjectDir
+	if opts.Scope == ScopeGlobal {
+		req.Scope = "global"
+	}
+	input, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("encoding %s request: %w", req.Operation, err)
+	}
+
+	cmd := exec.Command(p.bin)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Dir = opts.ProjectDir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("projector plugin %s: %s: %s", p.bin, req.Operation, msg)
+		}
+		return nil, fmt.Errorf("projector plugin %s: %s: %w", p.bin, req.Operation, err)
+	}
+
+	resp := &PluginResponse{}
+	if out := bytes.TrimSpace(stdout.Bytes()); len(out) > 0 {
+		if err := json.Unmarshal(out, resp); err != nil {
+			return nil, fmt.Errorf("projector plugin %s: %s: parsing response: %w", p.bin, req.Operation, err)
+		}
+	}
+	if opts.Warn != nil {
+		for _, w := range resp.Warnings {
+			opts.Warn(fmt.Errorf("%s: %s", p.agent, w))
+		}
+	}
+	return resp, nil
+}
+
+func (p *pluginProjector) GitignoreEntries() []string {
+	desc, _ := p.describe()
+	return desc.Gitignore
+}
+
+func (p *pluginProjector) Targets(opts ProjectionOpts) (Targets, error) {
+	if _, err := p.describe(); err != nil {
+		return Targets{}, err
+	}
+	resp, err := p.run(opts, PluginRequest{Operation: PluginTargets})
+	if err != nil {
+		return Targets{}, err
+	}
+	return Targets{SkillsDir: resp.SkillsDir, MCPConfig: resp.MCPConfig, MCPPointer: resp.MCPPointer}, nil
+}
+
+func (p *pluginProjector) SupportsSkills() bool {
+	desc, err := p.describe()
+	return err == nil && desc.Skills
+}
+
+func (p *pluginProjector) ProjectSkills(opts ProjectionOpts, packages []skill.Skill) error {
+	if !p.SupportsSkills() {
+		return p.descErr
+	}
+	req := PluginRequest{Operation: PluginProjectSkills}
+	for _, s := range packages {
+		req.Skills = append(req.Skills, PluginSkill{Name: s.Name(), Description: s.Description(), Dir: s.Dir()})
+	}
+	_, err := p.run(opts, req)
+	return err
+}
+
+func (p *pluginProjector) UnprojectSkills(opts ProjectionOpts, names []string) error {
+	if !p.SupportsSkills() {
+		return p.descErr
+	}
+	_, err := p.run(opts, PluginRequest{Operation: PluginUnprojectSkills, Names: names})
+	return err
+}
+
+func (p *pluginProjector) SupportsMCPServers() bool {
+	desc, err := p.describe()
+	return err == nil && desc.MCPServers
+}
+
+func (p *pluginProjector) MCPLimits() Limits {
+	return Limits{}
+}
+
+func (p *pluginProjector) MCPFormat() MCPFormat {
+	return customMCPFormat
+}
+
+func (p *pluginProjector) ProjectMCPServers(opts ProjectionOpts, servers []mcp.MCPServer) error {
+	if !p.SupportsMCPServers() {
+		return p.descErr
+	}
+	req := PluginRequest{Operation: PluginProjectMCPServers}
+	for _, s := range servers {
+		req.Servers = append(req.Servers, PluginServer{
+			Name:      s.Name(),
+			Transport: s.Transport(),
+			Command:   s.Command(),
+			Args:      s.Args(),
+			Env:       s.Env(),
+			URL:       s.URL(),
+			Headers:   s.Headers(),
+		})
+	}
+	_, err := p.run(opts, req)
+	return err
+}
+
+func (p *pluginProjector) UnprojectMCPServers(opts ProjectionOpts, names []string) error {
+	if !p.SupportsMCPServers() {
+		return p.descErr
+	}
+	_, err := p.run(opts, PluginRequest{Operation: PluginUnprojectMCPServers, Names: names})
+	return err
+}
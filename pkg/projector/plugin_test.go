@@ -0,0 +1,155 @@
+package projector
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/mcp"
+	"github.com/agentpkg/agentpkg/pkg/skill"
+)
+
+// pluginScript records each request in $APKG_PLUGIN_LOG and answers
+// describe and targets.
+const pluginScript = `#!/bin/sh
+input=$(cat)
+echo "$input" >> "$APKG_PLUGIN_LOG"
+case "$input" in
+*'"operation":"describe"'*) echo '{"skills": true, "mcp_servers": true, "gitignore": [".acme/"]}' ;;
+*'"operation":"targets"'*) echo '{"skills_dir": "/skills", "mcp_config": "/acme.json", "mcp_pointer": "/servers"}' ;;
+*'"operation":"project-mcp-servers"'*) echo '{"warnings": ["restart acme to load servers"]}' ;;
+*'"names":["broken"]'*) echo "no such server" >&2; exit 1 ;;
+esac
+`
+
+func TestPluginProjector(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the plugin is a shell script")
+	}
+
+	tests := map[string]struct {
+		scope Scope
+		// call runs the operation under test on the acme plugin.
+		call         func(Projector, ProjectionOpts) error
+		wantErr      string
+		wantWarnings []string
+		// wantRequest is the request the operation sends, less its version
+		// and project directory.
+		wantRequest PluginRequest
+	}{
+		"project skills": {
+			scope: ScopeGlobal,
+			call: func(p Projector, opts ProjectionOpts) error {
+				return p.ProjectSkills(opts, []skill.Skill{&fakeSkill{name: "pdf", dir: "/store/pdf"}})
+			},
+			wantRequest: PluginRequest{Operation: PluginProjectSkills, Scope: "global", Skills: []PluginSkill{{Name: "pdf", Dir: "/store/pdf"}}},
+		},
+		"unproject skills": {
+			call: func(p Projector, opts ProjectionOpts) error {
+				return p.UnprojectSkills(opts, []string{"pdf"})
+			},
+			wantRequest: PluginRequest{Operation: PluginUnprojectSkills, Scope: "project", Names: []string{"pdf"}},
+		},
+		"project MCP servers with a warning": {
+			call: func(p Projector, opts ProjectionOpts) error {
+				return p.ProjectMCPServers(opts, []mcp.MCPServer{httpServer{name: "api", url: "https://apkg.example/mcp"}})
+			},
+			wantWarnings: []string{"acme: restart acme to load servers"},
+			wantRequest:  PluginRequest{Operation: PluginProjectMCPServers, Scope: "project", Servers: []PluginServer{{Name: "api", Transport: "http", URL: "https://apkg.example/mcp"}}},
+		},
+		"failing operation": {
+			call: func(p Projector, opts ProjectionOpts) error {
+				return p.UnprojectMCPServers(opts, []string{"broken"})
+			},
+			// The plugin's stderr.
+			wantErr:     "no such server",
+			wantRequest: PluginRequest{Operation: PluginUnprojectMCPServers, Scope: "project", Names: []string{"broken"}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			binDir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(binDir, PluginPrefix+"acme"), []byte(pluginScript), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			// Files that aren't executable aren't plugins.
+			os.WriteFile(filepath.Join(binDir, PluginPrefix+"notes"), []byte("#!/bin/sh\n"), 0o644)
+			logPath := filepath.Join(t.TempDir(), "requests.jsonl")
+			t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+			t.Setenv("APKG_PLUGIN_LOG", logPath)
+
+			origRegistry := defaultRegistry
+			defaultRegistry = registry{"claude-code": &stubProjector{}}
+			t.Cleanup(func() { defaultRegistry = origRegistry })
+
+			if got := RegisteredAgents(); !slices.Equal(got, []string{"acme", "claude-code"}) {
+				t.Errorf("RegisteredAgents() = %q, want built-ins and plugins merged", got)
+			}
+			if err := ValidateAgents([]string{"acme"}); err != nil {
+				t.Errorf("ValidateAgents() error = %v", err)
+			}
+			proj, ok := GetProjector("acme")
+			if !ok {
+				t.Fatal("GetProjector(acme) found no plugin")
+			}
+			if !proj.SupportsSkills() || !proj.SupportsMCPServers() || !slices.Equal(proj.GitignoreEntries(), []string{".acme/"}) {
+				t.Errorf("plugin description not applied")
+			}
+
+			projectDir := t.TempDir()
+			var warnings []string
+			opts := ProjectionOpts{ProjectDir: projectDir, Scope: tc.scope, Warn: func(err error) { warnings = append(warnings, err.Error()) }}
+			targets, err := proj.Targets(opts)
+			if err != nil {
+				t.Fatalf("Targets() error = %v", err)
+			}
+			if want := (Targets{SkillsDir: "/skills", MCPConfig: "/acme.json", MCPPointer: "/servers"}); targets != want {
+				t.Errorf("Targets() = %+v, want %+v", targets, want)
+			}
+
+			err = tc.call(proj, opts)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Errorf("error = %v, want %q", err, tc.wantErr)
+				}
+			} else if err != nil {
+				t.Errorf("error = %v", err)
+			}
+			if !slices.Equal(warnings, tc.wantWarnings) {
+				t.Errorf("warnings = %q, want %q", warnings, tc.wantWarnings)
+			}
+
+			data, err := os.ReadFile(logPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var requests []PluginRequest
+			for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+				var req PluginRequest
+				if err := json.Unmarshal([]byte(line), &req); err != nil {
+					t.Fatalf("plugin got invalid JSON %q: %v", line, err)
+				}
+				requests = append(requests, req)
+			}
+			var ops []string
+			for _, req := range requests {
+				ops = append(ops, req.Operation)
+			}
+			// describe is only asked once.
+			if want := []string{PluginDescribe, PluginTargets, tc.wantRequest.Operation}; !slices.Equal(ops, want) {
+				t.Fatalf("operations = %q, want %q", ops, want)
+			}
+			want := tc.wantRequest
+			want.Version, want.ProjectDir = PluginProtocolVersion, projectDir
+			if got := requests[2]; !reflect.DeepEqual(got, want) {
+				t.Errorf("request = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
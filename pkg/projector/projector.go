@@ -15,6 +15,44 @@ const (
 type ProjectionOpts struct {
 	ProjectDir string
 	Scope      Scope
+	// Strategy selects what projected skill symlinks point at, one of
+	// config.ProjectionStrategies. "" is config.ProjectionAbsolute.
+	Strategy string
+	// Mode selects whether skills are symlinked, copied, or hardlinked,
+	// one of config.ProjectionModes. "" is config.ProjectionModeSymlink.
+	// Strategy only applies to symlinks.
+	Mode string
+	// Session, if set, batches changes to JSON config files until it is
+	// committed. Without one, each projection writes its files right away.
+	Session *ConfigSession
+	// Warn, if set, receives problems that don't fail the projection, such
+	// as an MCP config the agent will only load in part (see Limits).
+	Warn func(error)
+	// Ownership, if set, records the MCP server entries apkg owns in agent
+	// configs, and Conflict decides what happens to existing entries it
+	// doesn't own (see SetMCPServers).
+	Ownership *Ownership
+	Conflict  func(Conflict) (string, error)
+	// MCPTypes overrides the "type" the projector gives MCP servers of
+	// each transport (see MCPFormat).
+	MCPTypes map[string]string
+}
+
+// Targets are the locations an agent's projections are written to.
+type Targets struct {
+	// SkillsDir is the directory skills are symlinked into, or "" if the
+	// agent doesn't support skills.
+	SkillsDir string
+	// MCPConfig is the config file MCP servers are written to, or "" if the
+	// agent doesn't support MCP servers.
+	MCPConfig string
+	// MCPPointer is the JSON pointer of the object in MCPConfig holding the
+	// MCP servers by name (see MCPServerNames).
+	MCPPointer string
+	// SharedMCPConfig is set when MCPConfig is shared by every project even
+	// for project installs, like Goose's single config file, so projects
+	// must keep their entries apart.
+	SharedMCPConfig bool
 }
 
 type Projector interface {
@@ -22,6 +60,9 @@ type Projector interface {
 	// this agent (e.g. ".claude/").
 	GitignoreEntries() []string
 
+	// Targets returns where projections with the given options are written.
+	Targets(opts ProjectionOpts) (Targets, error)
+
 	// SupportsSkills returns whether or not the given agent supports skills
 	SupportsSkills() bool
 	// Project projects the packages to the appropriate handler by type
@@ -31,6 +72,11 @@ type Projector interface {
 
 	// SupportsMCPServers returns whether or not the given agent supports MCP servers
 	SupportsMCPServers() bool
+	// MCPLimits returns the bounds the agent puts on its MCP servers config.
+	MCPLimits() Limits
+	// MCPFormat returns how the agent writes MCP server entries, to read
+	// existing ones back (see ParseMCPServerJsonConfig).
+	MCPFormat() MCPFormat
 	ProjectMCPServers(opts ProjectionOpts, servers []mcp.MCPServer) error
 	// UnprojectMCPServers removes previously projected MCP servers by name
 	UnprojectMCPServers(opts ProjectionOpts, names []string) error
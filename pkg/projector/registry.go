@@ -3,33 +3,72 @@ package projector
 import (
 	"fmt"
 	"sort"
+	"strings"
+	"sync"
+	"unicode"
 )
 
 // registry tracks Projector instances for different agents
 type registry map[string]Projector
 
 var (
+	// registryMu guards defaultRegistry, hiddenAgents, and customAgents,
+	// which RegisterCustomProjectors replaces whenever a workspace is
+	// opened.
+	registryMu      sync.RWMutex
 	defaultRegistry = make(registry)
+	// hiddenAgents are registered agents left out of RegisteredAgents.
+	hiddenAgents = make(map[string]bool)
+	// customAgents are the agents registered by RegisterCustomProjectors.
+	customAgents = make(map[string]bool)
 )
 
-// RegisteredAgents returns a sorted list of all registered agent names.
+// RegisteredAgents returns a sorted list of all registered agent names,
+// including those of projector plugins on PATH (see PluginPrefix).
 func RegisteredAgents() []string {
+	plugins := pluginAgents()
+
+	registryMu.RLock()
 	agents := make([]string, 0, len(defaultRegistry))
 	for name := range defaultRegistry {
+		if hiddenAgents[name] {
+			continue
+		}
 		agents = append(agents, name)
 	}
+	for name := range plugins {
+		if _, ok := defaultRegistry[name]; !ok {
+			agents = append(agents, name)
+		}
+	}
+	registryMu.RUnlock()
 	sort.Strings(agents)
 	return agents
 }
 
+// GetProjector returns the projector of agent: the registered one, or
+// else the agent's projector plugin on PATH.
 func GetProjector(agent string) (Projector, bool) {
+	registryMu.RLock()
 	proj, ok := defaultRegistry[agent]
-	return proj, ok
+	registryMu.RUnlock()
+	if ok {
+		return proj, true
+	}
+	if proj, ok := pluginAgents()[agent]; ok {
+		return proj, true
+	}
+	return nil, false
 }
 
 // RegisterProjector registers a projector for a given agent
-// Note: this is NOT thread safe, and should only be called in init()
 func RegisterProjector(agent string, proj Projector) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	return registerProjector(agent, proj)
+}
+
+func registerProjector(agent string, proj Projector) error {
 	if _, ok := defaultRegistry[agent]; ok {
 		return fmt.Errorf("failed to registery projector for agent %q: other projector already registered", agent)
 	}
@@ -38,3 +77,88 @@ func RegisterProjector(agent string, proj Projector) error {
 
 	return nil
 }
+
+// RegisterHiddenProjector registers a projector like RegisterProjector, but
+// leaves the agent out of RegisteredAgents, so it is only used when named
+// explicitly (e.g. by apkg selftest).
+func RegisterHiddenProjector(agent string, proj Projector) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if err := registerProjector(agent, proj); err != nil {
+		return err
+	}
+	hiddenAgents[agent] = true
+	return nil
+}
+
+// UnregisterProjector removes the projector registered for agent, e.g. one
+// a test registered.
+func UnregisterProjector(agent string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	delete(defaultRegistry, agent)
+	delete(hiddenAgents, agent)
+}
+
+// ValidateAgents returns an error naming the first agent without a
+// registered projector, suggesting the closest registered agent when the
+// name looks like a typo (e.g. "claudecode" for "claude-code").
+func ValidateAgents(agents []string) error {
+	for _, agent := range agents {
+		if _, ok := GetProjector(agent); ok {
+			continue
+		}
+		if suggestion := closestAgent(agent); suggestion != "" {
+			return fmt.Errorf("unknown agent %q, did you mean %q?", agent, suggestion)
+		}
+		return fmt.Errorf("unknown agent %q (available: %s)", agent, strings.Join(RegisteredAgents(), ", "))
+	}
+	return nil
+}
+
+// closestAgent returns the registered agent closest to name by edit
+// distance, ignoring case and punctuation, or "" if none is close enough.
+func closestAgent(name string) string {
+	normalized := normalizeAgent(name)
+	best, bestDist := "", -1
+	for _, agent := range RegisteredAgents() {
+		dist := editDistance(normalized, normalizeAgent(agent))
+		if bestDist == -1 || dist < bestDist {
+			best, bestDist = agent, dist
+		}
+	}
+	if bestDist == -1 || bestDist > max(2, len(normalized)/3) {
+		return ""
+	}
+	return best
+}
+
+func normalizeAgent(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
@@ -9,13 +9,16 @@ import (
 
 type stubProjector struct{}
 
-func (s *stubProjector) GitignoreEntries() []string                                    { return nil }
-func (s *stubProjector) SupportsSkills() bool                                          { return true }
-func (s *stubProjector) ProjectSkills(_ ProjectionOpts, _ []skill.Skill) error         { return nil }
-func (s *stubProjector) UnprojectSkills(_ ProjectionOpts, _ []string) error            { return nil }
-func (s *stubProjector) SupportsMCPServers() bool                                      { return true }
-func (s *stubProjector) ProjectMCPServers(_ ProjectionOpts, _ []mcp.MCPServer) error   { return nil }
-func (s *stubProjector) UnprojectMCPServers(_ ProjectionOpts, _ []string) error        { return nil }
+func (s *stubProjector) GitignoreEntries() []string                                  { return nil }
+func (s *stubProjector) Targets(_ ProjectionOpts) (Targets, error)                   { return Targets{}, nil }
+func (s *stubProjector) SupportsSkills() bool                                        { return true }
+func (s *stubProjector) ProjectSkills(_ ProjectionOpts, _ []skill.Skill) error       { return nil }
+func (s *stubProjector) UnprojectSkills(_ ProjectionOpts, _ []string) error          { return nil }
+func (s *stubProjector) SupportsMCPServers() bool                                    { return true }
+func (s *stubProjector) MCPLimits() Limits                                           { return Limits{} }
+func (s *stubProjector) MCPFormat() MCPFormat                                        { return MCPFormat{} }
+func (s *stubProjector) ProjectMCPServers(_ ProjectionOpts, _ []mcp.MCPServer) error { return nil }
+func (s *stubProjector) UnprojectMCPServers(_ ProjectionOpts, _ []string) error      { return nil }
 
 func TestRegisteredAgents(t *testing.T) {
 	tests := map[string]struct {
@@ -46,6 +49,24 @@ func TestRegisteredAgents(t *testing.T) {
 			},
 			want: []string{"aider", "claude-code", "cursor"},
 		},
+		"hidden agent omitted": {
+			setup: func() {
+				defaultRegistry = make(registry)
+				hiddenAgents = make(map[string]bool)
+				_ = RegisterProjector("claude-code", &stubProjector{})
+				_ = RegisterHiddenProjector("selftest", &stubProjector{})
+			},
+			want: []string{"claude-code"},
+		},
+		"unregistered agent omitted": {
+			setup: func() {
+				defaultRegistry = make(registry)
+				_ = RegisterProjector("claude-code", &stubProjector{})
+				_ = RegisterProjector("cursor", &stubProjector{})
+				UnregisterProjector("cursor")
+			},
+			want: []string{"claude-code"},
+		},
 	}
 
 	for name, tc := range tests {
@@ -64,3 +85,47 @@ func TestRegisteredAgents(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateAgents(t *testing.T) {
+	defaultRegistry = registry{
+		"claude-code": &stubProjector{},
+		"cursor":      &stubProjector{},
+		"gemini":      &stubProjector{},
+	}
+
+	tests := map[string]struct {
+		agents  []string
+		wantErr string
+	}{
+		"all registered": {
+			agents: []string{"claude-code", "cursor"},
+		},
+		"missing punctuation": {
+			agents:  []string{"claudecode"},
+			wantErr: `unknown agent "claudecode", did you mean "claude-code"?`,
+		},
+		"typo": {
+			agents:  []string{"cursor", "gemni"},
+			wantErr: `unknown agent "gemni", did you mean "gemini"?`,
+		},
+		"no close match": {
+			agents:  []string{"vim"},
+			wantErr: `unknown agent "vim" (available: claude-code, cursor, gemini)`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := ValidateAgents(tc.agents)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateAgents() error = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tc.wantErr {
+				t.Errorf("ValidateAgents() error = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
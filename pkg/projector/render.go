@@ -0,0 +1,167 @@
+package projector
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/agentpkg/agentpkg/pkg/skill"
+)
+
+// SkillRenderer converts a skill into a single file in an agent-native
+// format, for agents that don't understand the SKILL.md directory layout.
+type SkillRenderer interface {
+	// FileName returns the name of the file a skill is rendered to.
+	FileName(skillName string) string
+	// Render returns the rendered file for s, given its SKILL.md body.
+	Render(s skill.Skill, body []byte) ([]byte, error)
+}
+
+// generatedMarker is appended to every rendered file. Files without it are
+// never overwritten or removed, since they were not written by apkg.
+const generatedMarker = "<!-- Generated by apkg. Do not edit; changes are overwritten on install. -->\n"
+
+// RenderedSkillProjector projects skills by rendering them into files under
+// <projectDir>/<Dir>. Unlike SkillProjector, the rendered file is a copy,
+// so it is rewritten on every install.
+type RenderedSkillProjector struct {
+	// Dir is the directory rendered files are written to, relative to the
+	// project (e.g. ".cursor/rules").
+	Dir      string
+	Renderer SkillRenderer
+}
+
+// SkillsDir returns the directory rendered skills are written to.
+func (rp *RenderedSkillProjector) SkillsDir(opts ProjectionOpts) string {
+	return filepath.Join(opts.ProjectDir, rp.Dir)
+}
+
+func (rp *RenderedSkillProjector) ProjectSkills(opts ProjectionOpts, packages []skill.Skill) error {
+	dir := rp.SkillsDir(opts)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to make %q dir for skills: %w", dir, err)
+	}
+
+	var projectErr error
+	for _, p := range packages {
+		path := filepath.Join(dir, rp.Renderer.FileName(p.Name()))
+		if exists, generated := checkGenerated(path); exists && !generated {
+			projectErr = errors.Join(projectErr, fmt.Errorf("failed to render skill %q: %s already exists and was not generated by apkg", p.Name(), path))
+			continue
+		}
+
+		body, err := skill.ReadBody(p.Dir())
+		if err != nil {
+			projectErr = errors.Join(projectErr, fmt.Errorf("failed to render skill %q: %w", p.Name(), err))
+			continue
+		}
+		content, err := rp.Renderer.Render(p, body)
+		if err != nil {
+			projectErr = errors.Join(projectErr, fmt.Errorf("failed to render skill %q: %w", p.Name(), err))
+			continue
+		}
+
+		content = append(ensureTrailingNewline(content), "\n"+generatedMarker...)
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			projectErr = errors.Join(projectErr, fmt.Errorf("failed to write rendered skill %q: %w", p.Name(), err))
+		}
+	}
+
+	return projectErr
+}
+
+func (rp *RenderedSkillProjector) UnprojectSkills(opts ProjectionOpts, names []string) error {
+	dir := rp.SkillsDir(opts)
+
+	var removeErr error
+	for _, name := range names {
+		path := filepath.Join(dir, rp.Renderer.FileName(name))
+		exists, generated := checkGenerated(path)
+		if !exists {
+			continue
+		}
+		if !generated {
+			removeErr = errors.Join(removeErr, fmt.Errorf("refusing to remove %q: not generated by apkg", path))
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			removeErr = errors.Join(removeErr, fmt.Errorf("failed to remove rendered skill %q: %w", name, err))
+		}
+	}
+
+	return removeErr
+}
+
+// checkGenerated reports whether path exists and whether it carries the
+// generated marker.
+func checkGenerated(path string) (exists, generated bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return !os.IsNotExist(err), false
+	}
+	return true, bytes.Contains(data, []byte(generatedMarker))
+}
+
+// IsGenerated reports whether the file at path was rendered by apkg.
+func IsGenerated(path string) bool {
+	_, generated := checkGenerated(path)
+	return generated
+}
+
+func ensureTrailingNewline(b []byte) []byte {
+	if len(b) > 0 && b[len(b)-1] != '\n' {
+		return append(b, '\n')
+	}
+	return b
+}
+
+// supportingFilesNote tells the agent where a rendered skill's scripts and
+// reference files live, since only SKILL.md is rendered.
+func supportingFilesNote(s skill.Skill) string {
+	return fmt.Sprintf("\nSupporting files for this skill (scripts, references) are in %s.\n", s.Dir())
+}
+
+// CursorRuleRenderer renders skills as Cursor project rules (.mdc) that the
+// agent attaches on demand based on their description.
+type CursorRuleRenderer struct{}
+
+func (CursorRuleRenderer) FileName(skillName string) string { return skillName + ".mdc" }
+
+func (CursorRuleRenderer) Render(s skill.Skill, body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "---\ndescription: %s\nalwaysApply: false\n---\n", strconv.Quote(s.Description()))
+	buf.Write(ensureTrailingNewline(body))
+	buf.WriteString(supportingFilesNote(s))
+	return buf.Bytes(), nil
+}
+
+// ContinuePromptRenderer renders skills as Continue prompt files, invoked
+// as slash commands named after the skill.
+type ContinuePromptRenderer struct{}
+
+func (ContinuePromptRenderer) FileName(skillName string) string { return skillName + ".prompt" }
+
+func (ContinuePromptRenderer) Render(s skill.Skill, body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "name: %s\ndescription: %s\n---\n", s.Name(), strconv.Quote(s.Description()))
+	buf.Write(ensureTrailingNewline(body))
+	buf.WriteString(supportingFilesNote(s))
+	return buf.Bytes(), nil
+}
+
+// ContextFileRenderer renders skills as plain Markdown context files, for
+// agents that only read instruction files.
+type ContextFileRenderer struct{}
+
+func (ContextFileRenderer) FileName(skillName string) string { return skillName + ".md" }
+
+func (ContextFileRenderer) Render(s skill.Skill, body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Skill: %s\n\nUse this skill when: %s\n\n", s.Name(), s.Description())
+	buf.Write(ensureTrailingNewline(body))
+	buf.WriteString(supportingFilesNote(s))
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,92 @@
+package projector
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/skill"
+)
+
+func TestRenderedSkillProjector(t *testing.T) {
+	tests := map[string]struct {
+		renderer     SkillRenderer
+		wantFile     string
+		wantContains []string
+	}{
+		"cursor rule": {
+			renderer:     CursorRuleRenderer{},
+			wantFile:     "my-skill.mdc",
+			wantContains: []string{"---\ndescription: \"\"\nalwaysApply: false\n---\n# My Skill\n"},
+		},
+		"continue prompt": {
+			renderer:     ContinuePromptRenderer{},
+			wantFile:     "my-skill.prompt",
+			wantContains: []string{"name: my-skill\n", "---\n# My Skill\n"},
+		},
+		"context file": {
+			renderer:     ContextFileRenderer{},
+			wantFile:     "my-skill.md",
+			wantContains: []string{"# Skill: my-skill\n", "# My Skill\n"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			skillDir := filepath.Join(t.TempDir(), "my-skill")
+			os.MkdirAll(skillDir, 0o755)
+			os.WriteFile(filepath.Join(skillDir, "SKILL.md"), []byte("---\nname: my-skill\n---\n# My Skill\n"), 0o644)
+
+			projectDir := t.TempDir()
+			opts := ProjectionOpts{ProjectDir: projectDir}
+			rp := &RenderedSkillProjector{Dir: ".agent/rules", Renderer: tc.renderer}
+
+			if err := rp.ProjectSkills(opts, []skill.Skill{&fakeSkill{name: "my-skill", dir: skillDir}}); err != nil {
+				t.Fatalf("ProjectSkills() error = %v", err)
+			}
+
+			path := filepath.Join(projectDir, ".agent", "rules", tc.wantFile)
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("reading rendered skill: %v", err)
+			}
+			for _, want := range append(tc.wantContains, skillDir, generatedMarker) {
+				if !strings.Contains(string(data), want) {
+					t.Errorf("rendered skill missing %q:\n%s", want, data)
+				}
+			}
+
+			if err := rp.UnprojectSkills(opts, []string{"my-skill"}); err != nil {
+				t.Fatalf("UnprojectSkills() error = %v", err)
+			}
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("rendered skill still exists after UnprojectSkills()")
+			}
+		})
+	}
+}
+
+func TestRenderedSkillProjectorKeepsUserFiles(t *testing.T) {
+	skillDir := filepath.Join(t.TempDir(), "my-skill")
+	os.MkdirAll(skillDir, 0o755)
+	os.WriteFile(filepath.Join(skillDir, "SKILL.md"), []byte("---\nname: my-skill\n---\nbody\n"), 0o644)
+
+	projectDir := t.TempDir()
+	opts := ProjectionOpts{ProjectDir: projectDir}
+	rp := &RenderedSkillProjector{Dir: "rules", Renderer: ContextFileRenderer{}}
+
+	path := filepath.Join(projectDir, "rules", "my-skill.md")
+	os.MkdirAll(filepath.Dir(path), 0o755)
+	os.WriteFile(path, []byte("hand-written rule\n"), 0o644)
+
+	if err := rp.ProjectSkills(opts, []skill.Skill{&fakeSkill{name: "my-skill", dir: skillDir}}); err == nil {
+		t.Error("ProjectSkills() over a user file succeeded, want error")
+	}
+	if err := rp.UnprojectSkills(opts, []string{"my-skill"}); err == nil {
+		t.Error("UnprojectSkills() of a user file succeeded, want error")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "hand-written rule\n" {
+		t.Errorf("user file was modified: %q", data)
+	}
+}
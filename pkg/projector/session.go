@@ -0,0 +1,334 @@
+package projector
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// maxReplays bounds how often Commit re-reads a config file that changed
+// on disk and reapplies the session's mutations to it.
+const maxReplays = 3
+
+// ConcurrentModificationError is returned by ConfigSession.Commit when a
+// config file kept changing on disk while apkg tried to write it, e.g.
+// because the agent itself is rewriting it.
+type ConcurrentModificationError struct {
+	Path string
+}
+
+func (e *ConcurrentModificationError) Error() string {
+	return fmt.Sprintf("%s is being modified concurrently, try again once the agent has finished writing it", e.Path)
+}
+
+// ExternalChangeWarning is passed to ConfigSession.Warn when another
+// program changed entries of a config file that the session changes too,
+// between the session reading the file and writing it. The session's
+// changes are reapplied on top and win, but an agent that keeps the file
+// in memory may write its own copy back, so it should be restarted.
+type ExternalChangeWarning struct {
+	Path string
+	// Keys are the dotted paths of the entries changed on both sides.
+	Keys []string
+}
+
+func (w *ExternalChangeWarning) Error() string {
+	return fmt.Sprintf("%s was changed by another program while apkg was updating %s; restart the agent using it so it doesn't overwrite apkg's changes",
+		w.Path, strings.Join(w.Keys, ", "))
+}
+
+// ConfigSession batches changes to agent JSON config files: each file is
+// read once on first use, mutations apply to the parsed copy, and Commit
+// writes every changed file once, atomically through a temporary file and
+// rename. If a file changed on disk since it was read (e.g. the agent
+// wrote it meanwhile), Commit reapplies the mutations on top of the new
+// content instead of clobbering it.
+type ConfigSession struct {
+	// Warn, if set, receives an ExternalChangeWarning for each file whose
+	// entries changed on disk while the session was changing them too.
+	Warn func(error)
+
+	files map[string]*sessionFile
+}
+
+type sessionFile struct {
+	config map[string]any
+	// base is a copy of config before the mutations, to tell apart the
+	// entries the session changes.
+	base map[string]any
+	// sum is the checksum of the content config was parsed from; the zero
+	// value for a file that didn't exist.
+	sum       [sha256.Size]byte
+	mutations []func(map[string]any) error
+}
+
+// NewConfigSession returns an empty session.
+func NewConfigSession() *ConfigSession {
+	return &ConfigSession{files: make(map[string]*sessionFile)}
+}
+
+// Update applies mutate to the config at path, reading the file first if
+// this is the session's first change to it. Nothing is written until
+// Commit.
+func (s *ConfigSession) Update(path string, mutate func(config map[string]any) error) error {
+	f, ok := s.files[path]
+	if !ok {
+		config, sum, err := readJsonConfigSum(path)
+		if err != nil {
+			return err
+		}
+		f = &sessionFile{config: config, base: cloneValue(config).(map[string]any), sum: sum}
+		s.files[path] = f
+	}
+
+	if err := mutate(f.config); err != nil {
+		return err
+	}
+	f.mutations = append(f.mutations, mutate)
+	return nil
+}
+
+// Commit writes every config file changed in the session.
+func (s *ConfigSession) Commit() error {
+	paths := make([]string, 0, len(s.files))
+	for path := range s.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		if err := s.commitFile(path, s.files[path]); err != nil {
+			return err
+		}
+		delete(s.files, path)
+	}
+	return nil
+}
+
+func (s *ConfigSession) commitFile(path string, f *sessionFile) error {
+	var conflicts []string
+	for replays := 0; ; replays++ {
+		config, sum, err := readJsonConfigSum(path)
+		if err != nil {
+			return err
+		}
+		if sum == f.sum {
+			if err := writeJsonConfigAtomic(path, f.config); err != nil {
+				return err
+			}
+			if len(conflicts) > 0 && s.Warn != nil {
+				s.Warn(&ExternalChangeWarning{Path: path, Keys: conflicts})
+			}
+			return nil
+		}
+		if replays == maxReplays {
+			return &ConcurrentModificationError{Path: path}
+		}
+
+		for _, key := range conflictingKeys(f.base, f.config, config) {
+			if !slices.Contains(conflicts, key) {
+				conflicts = append(conflicts, key)
+			}
+		}
+		f.config, f.base, f.sum = config, cloneValue(config).(map[string]any), sum
+		for _, mutate := range f.mutations {
+			if err := mutate(f.config); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// conflictingKeys returns the dotted paths of the entries that both ours
+// and theirs changed relative to base, sorted.
+func conflictingKeys(base, ours, theirs map[string]any) []string {
+	// Mutations may store Go types (e.g. []string) that compare unequal
+	// to the parsed JSON they were read back as.
+	if data, err := json.Marshal(ours); err == nil {
+		var normalized map[string]any
+		if json.Unmarshal(data, &normalized) == nil {
+			ours = normalized
+		}
+	}
+
+	changed := changedKeys(base, ours, nil)
+	var keys []string
+	for _, external := range changedKeys(base, theirs, nil) {
+		for _, key := range changed {
+			if !isPrefix(key, external) && !isPrefix(external, key) {
+				continue
+			}
+			// Report the shorter of the two, the entry apkg manages.
+			if len(external) < len(key) {
+				key = external
+			}
+			if joined := strings.Join(key, "."); !slices.Contains(keys, joined) {
+				keys = append(keys, joined)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// changedKeys returns the paths of the values that differ between a and
+// b, descending into objects present in both.
+func changedKeys(a, b map[string]any, prefix []string) [][]string {
+	var keys [][]string
+	for key, av := range a {
+		path := append(slices.Clip(prefix), key)
+		bv, ok := b[key]
+		if !ok {
+			keys = append(keys, path)
+			continue
+		}
+		am, aIsMap := av.(map[string]any)
+		bm, bIsMap := bv.(map[string]any)
+		if aIsMap && bIsMap {
+			keys = append(keys, changedKeys(am, bm, path)...)
+		} else if !reflect.DeepEqual(av, bv) {
+			keys = append(keys, path)
+		}
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, append(slices.Clip(prefix), key))
+		}
+	}
+	return keys
+}
+
+func isPrefix(prefix, path []string) bool {
+	return len(prefix) <= len(path) && slices.Equal(prefix, path[:len(prefix)])
+}
+
+// cloneValue deep-copies a parsed JSON value.
+func cloneValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for key, e := range v {
+			m[key] = cloneValue(e)
+		}
+		return m
+	case []any:
+		s := make([]any, len(v))
+		for i, e := range v {
+			s[i] = cloneValue(e)
+		}
+		return s
+	default:
+		return v
+	}
+}
+
+// UpdateJsonConfig applies mutate to the JSON config at path in
+// opts.Session, or reads, mutates, and writes the file right away if opts
+// has no session.
+func UpdateJsonConfig(opts ProjectionOpts, path string, mutate func(config map[string]any) error) error {
+	if opts.Session != nil {
+		return opts.Session.Update(path, mutate)
+	}
+	s := NewConfigSession()
+	s.Warn = opts.Warn
+	if err := s.Update(path, mutate); err != nil {
+		return err
+	}
+	return s.Commit()
+}
+
+// readJsonConfigSum reads the config at path like ReadJsonConfig and
+// returns the checksum of its content.
+func readJsonConfigSum(path string) (map[string]any, [sha256.Size]byte, error) {
+	config := make(map[string]any)
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return config, [sha256.Size]byte{}, nil
+	}
+	if err != nil {
+		return nil, [sha256.Size]byte{}, fmt.Errorf("failed to read %q: %w", path, err)
+	}
+
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := unmarshalConfig(path, data, &config); err != nil {
+			return nil, [sha256.Size]byte{}, err
+		}
+	}
+	return config, sha256.Sum256(data), nil
+}
+
+// writeJsonConfigAtomic writes config to a temporary file next to path and
+// renames it into place, so readers never see a partially written file.
+// The permissions of an existing file are kept, and a symlinked config
+// (e.g. one kept in a dotfiles repository) is written through the link.
+func writeJsonConfigAtomic(path string, config map[string]any) error {
+	data, err := marshalConfig(path, config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	if path, err = resolveLinks(path); err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create directory for %q: %w", path, err)
+	}
+
+	perm := os.FileMode(configFilePerms)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file for %q: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %q: %w", tmp.Name(), err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to set permissions of %q: %w", tmp.Name(), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %q: %w", tmp.Name(), err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %q: %w", path, err)
+	}
+	return nil
+}
+
+// maxLinks bounds the symlinks resolveLinks follows, like the OS does.
+const maxLinks = 40
+
+// resolveLinks returns the file the symlink at path points to, following
+// links to links, or path itself if it isn't a symlink. Unlike
+// filepath.EvalSymlinks, it resolves links to files that don't exist yet.
+func resolveLinks(path string) (string, error) {
+	for range maxLinks {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return path, nil
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
+		path = target
+	}
+	return "", fmt.Errorf("too many levels of symbolic links at %q", path)
+}
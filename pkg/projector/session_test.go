@@ -0,0 +1,299 @@
+package projector
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestConfigSession(t *testing.T) {
+	setServer := func(name string) func(map[string]any) error {
+		return func(config map[string]any) error {
+			GetOrCreateMap(config, "mcpServers")[name] = map[string]any{"command": name}
+			return nil
+		}
+	}
+
+	tests := map[string]struct {
+		initial string
+		// mutations are applied in order; concurrent, if set, is written
+		// to the file after the mutations and before Commit.
+		mutations  func(path string) []func(map[string]any) error
+		concurrent string
+		want       map[string]any
+		wantErr    bool
+		wantConcur bool
+	}{
+		"creates missing file": {
+			mutations: func(string) []func(map[string]any) error {
+				return []func(map[string]any) error{setServer("a"), setServer("b")}
+			},
+			want: map[string]any{"mcpServers": map[string]any{
+				"a": map[string]any{"command": "a"},
+				"b": map[string]any{"command": "b"},
+			}},
+		},
+		"keeps unrelated keys": {
+			initial: `{"theme": "dark"}`,
+			mutations: func(string) []func(map[string]any) error {
+				return []func(map[string]any) error{setServer("a")}
+			},
+			want: map[string]any{
+				"theme":      "dark",
+				"mcpServers": map[string]any{"a": map[string]any{"command": "a"}},
+			},
+		},
+		"empty file is an empty config": {
+			initial: "\n",
+			mutations: func(string) []func(map[string]any) error {
+				return []func(map[string]any) error{setServer("a")}
+			},
+			want: map[string]any{"mcpServers": map[string]any{"a": map[string]any{"command": "a"}}},
+		},
+		"replays mutations on concurrent modification": {
+			initial: `{"theme": "dark"}`,
+			mutations: func(string) []func(map[string]any) error {
+				return []func(map[string]any) error{setServer("a")}
+			},
+			concurrent: `{"theme": "light", "mcpServers": {"other": {"command": "other"}}}`,
+			want: map[string]any{
+				"theme": "light",
+				"mcpServers": map[string]any{
+					"a":     map[string]any{"command": "a"},
+					"other": map[string]any{"command": "other"},
+				},
+			},
+		},
+		"fails if the file keeps changing": {
+			initial: `{}`,
+			mutations: func(path string) []func(map[string]any) error {
+				n := 0
+				return []func(map[string]any) error{func(config map[string]any) error {
+					n++
+					data, _ := json.Marshal(map[string]any{"writes": n})
+					return os.WriteFile(path, data, 0o644)
+				}}
+			},
+			wantErr:    true,
+			wantConcur: true,
+		},
+		"invalid json": {
+			initial: `{`,
+			mutations: func(string) []func(map[string]any) error {
+				return []func(map[string]any) error{setServer("a")}
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "nested", "config.json")
+			if tc.initial != "" {
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(tc.initial), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			s := NewConfigSession()
+			var err error
+			for _, mutate := range tc.mutations(path) {
+				if err = s.Update(path, mutate); err != nil {
+					break
+				}
+			}
+			if err == nil && tc.concurrent != "" {
+				if err := os.WriteFile(path, []byte(tc.concurrent), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err == nil {
+				err = s.Commit()
+			}
+
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tc.wantErr)
+			}
+			var concurErr *ConcurrentModificationError
+			if errors.As(err, &concurErr) != tc.wantConcur {
+				t.Errorf("err = %v, want concurrent modification error: %v", err, tc.wantConcur)
+			}
+			if tc.wantErr {
+				return
+			}
+
+			got, err := ReadJsonConfig(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("config = %v, want %v", got, tc.want)
+			}
+
+			entries, err := os.ReadDir(filepath.Dir(path))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 {
+				t.Errorf("expected only the config file, found %d entries (leftover temp file?)", len(entries))
+			}
+			// Windows only keeps a read-only bit.
+			if tc.initial != "" && runtime.GOOS != "windows" {
+				info, err := os.Stat(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if perm := info.Mode().Perm(); perm != 0o600 {
+					t.Errorf("permissions = %o, want existing 0600 kept", perm)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigSession_WritesOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	s := NewConfigSession()
+
+	for _, name := range []string{"a", "b", "c"} {
+		opts := ProjectionOpts{Session: s}
+		err := UpdateJsonConfig(opts, path, func(config map[string]any) error {
+			GetOrCreateMap(config, "mcpServers")[name] = map[string]any{}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Fatalf("config written before commit: %v", err)
+		}
+	}
+
+	if err := s.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadJsonConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if servers := got["mcpServers"].(map[string]any); len(servers) != 3 {
+		t.Errorf("got %d servers, want 3", len(servers))
+	}
+}
+
+func TestConfigSession_WritesThroughSymlink(t *testing.T) {
+	tests := map[string]struct {
+		initial string // content of the link's target, none if empty
+	}{
+		"existing target": {initial: `{"theme": "dark"}`},
+		"missing target":  {},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			target := filepath.Join(dir, "dotfiles", "config.json")
+			os.MkdirAll(filepath.Dir(target), 0o755)
+			if tc.initial != "" {
+				os.WriteFile(target, []byte(tc.initial), 0o644)
+			}
+			path := filepath.Join(dir, "config.json")
+			if err := os.Symlink(filepath.Join("dotfiles", "config.json"), path); err != nil {
+				t.Skipf("creating symlinks: %v", err)
+			}
+
+			err := UpdateJsonConfig(ProjectionOpts{}, path, func(config map[string]any) error {
+				GetOrCreateMap(config, "mcpServers")["a"] = map[string]any{}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if info, err := os.Lstat(path); err != nil || info.Mode()&os.ModeSymlink == 0 {
+				t.Fatalf("config is no longer a symlink: %v", err)
+			}
+			got, err := ReadJsonConfig(target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := got["mcpServers"].(map[string]any)["a"]; !ok {
+				t.Errorf("link target = %v, want the server written through the link", got)
+			}
+		})
+	}
+}
+
+func TestConfigSession_ExternalChangeWarning(t *testing.T) {
+	setServer := func(config map[string]any) error {
+		GetOrCreateMap(config, "mcpServers")["a"] = map[string]any{"command": "a", "args": []string{"--x"}}
+		return nil
+	}
+
+	tests := map[string]struct {
+		concurrent string
+		wantKeys   []string
+	}{
+		"unrelated key": {
+			concurrent: `{"theme": "light", "mcpServers": {}}`,
+		},
+		"other server": {
+			concurrent: `{"mcpServers": {"b": {"command": "b"}}}`,
+		},
+		"same server": {
+			concurrent: `{"mcpServers": {"a": {"command": "other"}}}`,
+			wantKeys:   []string{"mcpServers.a"},
+		},
+		"managed object replaced": {
+			concurrent: `{"mcpServers": "none"}`,
+			wantKeys:   []string{"mcpServers"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.json")
+			if err := os.WriteFile(path, []byte(`{"mcpServers": {}}`), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			var warnings []error
+			s := NewConfigSession()
+			s.Warn = func(err error) { warnings = append(warnings, err) }
+			if err := s.Update(path, setServer); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(tc.concurrent), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Commit(); err != nil {
+				t.Fatalf("Commit() error = %v", err)
+			}
+
+			if tc.wantKeys == nil {
+				if len(warnings) > 0 {
+					t.Errorf("warnings = %v, want none", warnings)
+				}
+				return
+			}
+			if len(warnings) != 1 {
+				t.Fatalf("got %d warnings, want 1", len(warnings))
+			}
+			var changeErr *ExternalChangeWarning
+			if !errors.As(warnings[0], &changeErr) {
+				t.Fatalf("warning = %v, want ExternalChangeWarning", warnings[0])
+			}
+			if !reflect.DeepEqual(changeErr.Keys, tc.wantKeys) {
+				t.Errorf("Keys = %v, want %v", changeErr.Keys, tc.wantKeys)
+			}
+		})
+	}
+}
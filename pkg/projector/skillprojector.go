@@ -6,18 +6,41 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/fsutil"
 	"github.com/agentpkg/agentpkg/pkg/skill"
 )
 
+// ContentDir is the project directory that skills are copied into with the
+// config.ProjectionProject strategy.
+const ContentDir = ".apkg"
+
 // SkillProjector projects skills into a given agent directory by creating
-// symlinks under <projectDir>/<agentDir>/skills/<skill-name>.
+// symlinks under <projectDir>/<agentDir>/skills/<skill-name>. What the links
+// point at depends on the projection strategy (see linkTarget). With the
+// copy and hardlink projection modes, the skill directories are copied
+// there instead (see copySkill).
 type SkillProjector struct {
 	// AgentDir is the agent-specific directory name (e.g. ".claude", ".gemini").
 	AgentDir string
+	// Dir, if set, is the skills directory itself, relative to the project
+	// or absolute, instead of the skills directory in AgentDir.
+	Dir string
+}
+
+// SkillsDir returns the directory skills are symlinked into.
+func (sp *SkillProjector) SkillsDir(opts ProjectionOpts) string {
+	if filepath.IsAbs(sp.Dir) {
+		return sp.Dir
+	}
+	if sp.Dir != "" {
+		return filepath.Join(opts.ProjectDir, sp.Dir)
+	}
+	return filepath.Join(opts.ProjectDir, sp.AgentDir, "skills")
 }
 
 func (sp *SkillProjector) ProjectSkills(opts ProjectionOpts, packages []skill.Skill) error {
-	skillsDir := filepath.Join(opts.ProjectDir, sp.AgentDir, "skills")
+	skillsDir := sp.SkillsDir(opts)
 	err := os.MkdirAll(skillsDir, 0755)
 	if err != nil {
 		return fmt.Errorf("failed to make %q dir for skills: %w", skillsDir, err)
@@ -26,11 +49,36 @@ func (sp *SkillProjector) ProjectSkills(opts ProjectionOpts, packages []skill.Sk
 	var projectErr error
 	for _, p := range packages {
 		link := filepath.Join(skillsDir, p.Name())
-		// if exists & is symlink - overwrite
-		// if exists & is not symlink - error (TODO: accept user input to confirm overwrite?)
+		switch opts.Mode {
+		case "", config.ProjectionModeSymlink:
+		case config.ProjectionModeCopy, config.ProjectionModeHardlink:
+			if err := copySkill(opts.Mode, p.Dir(), link); err != nil {
+				projectErr = errors.Join(projectErr, fmt.Errorf("failed to copy skill %q: %w", p.Name(), err))
+			}
+			continue
+		default:
+			projectErr = errors.Join(projectErr, fmt.Errorf("failed to project skill %q: unknown projection mode %q", p.Name(), opts.Mode))
+			continue
+		}
+
+		target, err := linkTarget(opts, skillsDir, p)
+		if err != nil {
+			projectErr = errors.Join(projectErr, fmt.Errorf("failed to project skill %q: %w", p.Name(), err))
+			continue
+		}
+
+		// if exists & is symlink or a copy of ours - overwrite
+		// if exists & is anything else - error (TODO: accept user input to confirm overwrite?)
 		exists, isSymlink := checkExistenceAndIsSymlink(link)
+		if exists && !isSymlink && IsSkillCopy(link) {
+			if err := os.RemoveAll(link); err != nil {
+				projectErr = errors.Join(projectErr, fmt.Errorf("failed to remove copy of skill %q: %w", p.Name(), err))
+				continue
+			}
+			exists = false
+		}
 		if !exists {
-			err := os.Symlink(p.Dir(), link)
+			err := fsutil.SymlinkDir(target, link)
 			if err != nil {
 				projectErr = errors.Join(projectErr, fmt.Errorf("failed to create symlink for skill %q: %w", p.Name(), err))
 			}
@@ -39,7 +87,7 @@ func (sp *SkillProjector) ProjectSkills(opts ProjectionOpts, packages []skill.Sk
 		}
 
 		if isSymlink {
-			err := overwriteSymlink(p.Dir(), link)
+			err := fsutil.ReplaceSymlinkDir(target, link)
 			if err != nil {
 				projectErr = errors.Join(projectErr, fmt.Errorf("failed to overwrite symlink for skill %q: %w", p.Name(), err))
 			}
@@ -52,7 +100,7 @@ func (sp *SkillProjector) ProjectSkills(opts ProjectionOpts, packages []skill.Sk
 }
 
 func (sp *SkillProjector) UnprojectSkills(opts ProjectionOpts, names []string) error {
-	skillsDir := filepath.Join(opts.ProjectDir, sp.AgentDir, "skills")
+	skillsDir := sp.SkillsDir(opts)
 
 	var removeErr error
 	for _, name := range names {
@@ -62,7 +110,11 @@ func (sp *SkillProjector) UnprojectSkills(opts ProjectionOpts, names []string) e
 			continue
 		}
 		if !isSymlink {
-			removeErr = errors.Join(removeErr, fmt.Errorf("refusing to remove %q: not a symlink", name))
+			if !IsSkillCopy(link) {
+				removeErr = errors.Join(removeErr, fmt.Errorf("refusing to remove %q: not a symlink or a copy made by apkg", name))
+			} else if err := os.RemoveAll(link); err != nil {
+				removeErr = errors.Join(removeErr, fmt.Errorf("failed to remove copy of skill %q: %w", name, err))
+			}
 			continue
 		}
 		if err := os.Remove(link); err != nil {
@@ -73,22 +125,108 @@ func (sp *SkillProjector) UnprojectSkills(opts ProjectionOpts, names []string) e
 	return removeErr
 }
 
-func overwriteSymlink(newTargetPath, linkPath string) error {
-	tmpLinkPath := fmt.Sprintf("%s.tmp", linkPath)
+// SkillContentDir returns where the config.ProjectionProject strategy
+// copies the named skill.
+func SkillContentDir(opts ProjectionOpts, name string) string {
+	return filepath.Join(opts.ProjectDir, ContentDir, "skills", name)
+}
 
-	if err := os.Remove(tmpLinkPath); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to remove existing temporary link dir: %w", err)
+// RemoveSkillContent removes the project copy of the named skill made by
+// the config.ProjectionProject strategy, if there is one.
+func RemoveSkillContent(opts ProjectionOpts, name string) error {
+	if err := os.RemoveAll(SkillContentDir(opts, name)); err != nil {
+		return fmt.Errorf("failed to remove project copy of skill %q: %w", name, err)
 	}
+	return nil
+}
 
-	if err := os.Symlink(newTargetPath, tmpLinkPath); err != nil {
-		return fmt.Errorf("failed to create temporary symlink: %w", err)
+// linkTarget returns the path the symlink for s in skillsDir points at:
+// the store entry by absolute path, the store entry by relative path, or a
+// copy in the project's ContentDir by relative path. Global projections
+// have no project to copy into, so the project strategy links to the store
+// entry by relative path for them.
+func linkTarget(opts ProjectionOpts, skillsDir string, s skill.Skill) (string, error) {
+	target := s.Dir()
+	switch opts.Strategy {
+	case "", config.ProjectionAbsolute:
+		return target, nil
+	case config.ProjectionProject:
+		if opts.Scope == ScopeGlobal {
+			break
+		}
+		contentDir := SkillContentDir(opts, s.Name())
+		if filepath.Clean(target) != filepath.Clean(contentDir) {
+			if err := fsutil.CopyDir(target, contentDir, fsutil.SkipJunk); err != nil {
+				return "", fmt.Errorf("copying into %s: %w", ContentDir, err)
+			}
+		}
+		target = contentDir
+	case config.ProjectionRelative:
+	default:
+		return "", fmt.Errorf("unknown projection strategy %q", opts.Strategy)
 	}
 
-	if err := os.Rename(tmpLinkPath, linkPath); err != nil {
-		os.Remove(tmpLinkPath)
-		return fmt.Errorf("failed to rename temporary symlink: %w", err)
+	// Resolve symlinks in both paths first: the link is resolved from the
+	// physical skills directory, so a lexical relative path would break if
+	// e.g. the project is reached through a symlink.
+	from, err := filepath.EvalSymlinks(skillsDir)
+	if err != nil {
+		return "", err
+	}
+	to, err := filepath.EvalSymlinks(target)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(from, to)
+	if err != nil {
+		return "", fmt.Errorf("no relative path from %s to %s: %w", from, to, err)
 	}
+	return rel, nil
+}
+
+// CopyMarker is the file apkg writes into the skill directories it copies
+// into skills directories (see config.ProjectionModeCopy), naming the
+// directory they were copied from. It tells apkg's copies apart from
+// skills put there by hand, which apkg leaves alone.
+const CopyMarker = ".apkg-copy"
 
+// IsSkillCopy reports whether path is a skill directory apkg copied.
+func IsSkillCopy(path string) bool {
+	info, err := os.Lstat(filepath.Join(path, CopyMarker))
+	return err == nil && info.Mode().IsRegular()
+}
+
+// copySkill replaces what is at dest, unless it is a directory apkg didn't
+// copy, with a copy of the skill directory src, its files hardlinked for
+// config.ProjectionModeHardlink. The copy is made next to dest and renamed
+// into place, so a failed copy leaves the previous one intact.
+func copySkill(mode, src, dest string) error {
+	exists, isSymlink := checkExistenceAndIsSymlink(dest)
+	if exists && !isSymlink && !IsSkillCopy(dest) {
+		return fmt.Errorf("file/dir already exists at path")
+	}
+
+	tmp := dest + ".tmp"
+	copyDir := fsutil.CopyDir
+	if mode == config.ProjectionModeHardlink {
+		copyDir = fsutil.LinkDir
+	}
+	if err := copyDir(src, tmp, fsutil.SkipJunk); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(tmp, CopyMarker), []byte(src+"\n"), 0o644); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	if err := os.RemoveAll(dest); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
 	return nil
 }
 
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/agentpkg/agentpkg/pkg/config"
 	"github.com/agentpkg/agentpkg/pkg/skill"
 )
 
@@ -15,10 +16,11 @@ type fakeSkill struct {
 
 var _ skill.Skill = &fakeSkill{}
 
-func (f *fakeSkill) Name() string    { return f.name }
-func (f *fakeSkill) Type() string    { return "skill" }
-func (f *fakeSkill) Dir() string     { return f.dir }
-func (f *fakeSkill) Validate() error { return nil }
+func (f *fakeSkill) Name() string        { return f.name }
+func (f *fakeSkill) Description() string { return "" }
+func (f *fakeSkill) Type() string        { return "skill" }
+func (f *fakeSkill) Dir() string         { return f.dir }
+func (f *fakeSkill) Validate() error     { return nil }
 
 func TestSkillProjector_ProjectSkills(t *testing.T) {
 	tests := map[string]struct {
@@ -283,3 +285,166 @@ func TestSkillProjector_UnprojectSkills(t *testing.T) {
 		})
 	}
 }
+
+func TestSkillProjector_Strategies(t *testing.T) {
+	tests := map[string]struct {
+		strategy string
+		scope    Scope
+		// wantTarget returns the path the link should resolve to.
+		wantTarget func(projectDir, storeDir string) string
+		wantAbs    bool
+	}{
+		"default links to store by absolute path": {
+			wantTarget: func(projectDir, storeDir string) string { return storeDir },
+			wantAbs:    true,
+		},
+		"relative links to store by relative path": {
+			strategy:   config.ProjectionRelative,
+			wantTarget: func(projectDir, storeDir string) string { return storeDir },
+		},
+		"project links to copy in content dir": {
+			strategy: config.ProjectionProject,
+			wantTarget: func(projectDir, storeDir string) string {
+				return filepath.Join(projectDir, ContentDir, "skills", "my-skill")
+			},
+		},
+		"project in global scope links to store": {
+			strategy:   config.ProjectionProject,
+			scope:      ScopeGlobal,
+			wantTarget: func(projectDir, storeDir string) string { return storeDir },
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			projectDir, err := filepath.EvalSymlinks(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			storeDir, err := filepath.EvalSymlinks(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(storeDir, "SKILL.md"), []byte("# skill\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			sp := &SkillProjector{AgentDir: ".testagent"}
+			opts := ProjectionOpts{ProjectDir: projectDir, Scope: tc.scope, Strategy: tc.strategy}
+			if err := sp.ProjectSkills(opts, []skill.Skill{&fakeSkill{name: "my-skill", dir: storeDir}}); err != nil {
+				t.Fatalf("ProjectSkills() error = %v", err)
+			}
+
+			link := filepath.Join(projectDir, ".testagent", "skills", "my-skill")
+			raw, err := os.Readlink(link)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if filepath.IsAbs(raw) != tc.wantAbs {
+				t.Errorf("link target %q: absolute = %v, want %v", raw, filepath.IsAbs(raw), tc.wantAbs)
+			}
+
+			got, err := filepath.EvalSymlinks(link)
+			if err != nil {
+				t.Fatalf("resolving %s: %v", link, err)
+			}
+			if want := tc.wantTarget(projectDir, storeDir); got != want {
+				t.Errorf("link resolves to %q, want %q", got, want)
+			}
+			if _, err := os.Stat(filepath.Join(link, "SKILL.md")); err != nil {
+				t.Errorf("SKILL.md not reachable through link: %v", err)
+			}
+		})
+	}
+}
+
+func TestSkillProjector_Modes(t *testing.T) {
+	tests := map[string]struct {
+		mode string
+		// existing is what is at the skill's path before projecting:
+		// "symlink", "copy", or "dir" (a directory apkg didn't make).
+		existing  string
+		wantCopy  bool
+		wantLinks bool
+		wantErr   bool
+	}{
+		"copy":                       {mode: config.ProjectionModeCopy, wantCopy: true},
+		"hardlink":                   {mode: config.ProjectionModeHardlink, wantCopy: true, wantLinks: true},
+		"copy replaces symlink":      {mode: config.ProjectionModeCopy, existing: "symlink", wantCopy: true},
+		"copy refreshes its copy":    {mode: config.ProjectionModeCopy, existing: "copy", wantCopy: true},
+		"copy leaves user dir alone": {mode: config.ProjectionModeCopy, existing: "dir", wantErr: true},
+		"symlink replaces copy":      {mode: config.ProjectionModeSymlink, existing: "copy"},
+		"unknown mode":               {mode: "junction", wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			projectDir := t.TempDir()
+			storeDir := t.TempDir()
+			os.WriteFile(filepath.Join(storeDir, "SKILL.md"), []byte("# skill\n"), 0644)
+			s := &fakeSkill{name: "my-skill", dir: storeDir}
+
+			sp := &SkillProjector{AgentDir: ".testagent"}
+			path := filepath.Join(projectDir, ".testagent", "skills", "my-skill")
+			switch tc.existing {
+			case "symlink":
+				if err := sp.ProjectSkills(ProjectionOpts{ProjectDir: projectDir}, []skill.Skill{s}); err != nil {
+					t.Fatal(err)
+				}
+			case "copy":
+				if err := sp.ProjectSkills(ProjectionOpts{ProjectDir: projectDir, Mode: config.ProjectionModeCopy}, []skill.Skill{s}); err != nil {
+					t.Fatal(err)
+				}
+				os.WriteFile(filepath.Join(path, "stale.md"), []byte("old"), 0644)
+			case "dir":
+				os.MkdirAll(path, 0755)
+				os.WriteFile(filepath.Join(path, "SKILL.md"), []byte("mine"), 0644)
+			}
+
+			err := sp.ProjectSkills(ProjectionOpts{ProjectDir: projectDir, Mode: tc.mode}, []skill.Skill{s})
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ProjectSkills() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				if tc.existing == "dir" {
+					if data, _ := os.ReadFile(filepath.Join(path, "SKILL.md")); string(data) != "mine" {
+						t.Errorf("user's SKILL.md = %q, want it untouched", data)
+					}
+				}
+				return
+			}
+
+			info, err := os.Lstat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if isLink := info.Mode()&os.ModeSymlink != 0; isLink == tc.wantCopy {
+				t.Fatalf("symlink = %v, want copy = %v", isLink, tc.wantCopy)
+			}
+			if IsSkillCopy(path) != tc.wantCopy {
+				t.Errorf("IsSkillCopy() = %v, want %v", IsSkillCopy(path), tc.wantCopy)
+			}
+			if _, err := os.Stat(filepath.Join(path, "stale.md")); !os.IsNotExist(err) {
+				t.Errorf("stale.md of an earlier copy still there")
+			}
+			copied, err := os.Stat(filepath.Join(path, "SKILL.md"))
+			if err != nil {
+				t.Fatalf("SKILL.md not projected: %v", err)
+			}
+			orig, _ := os.Stat(filepath.Join(storeDir, "SKILL.md"))
+			if tc.wantCopy && os.SameFile(copied, orig) != tc.wantLinks {
+				t.Errorf("SKILL.md hardlinked = %v, want %v", os.SameFile(copied, orig), tc.wantLinks)
+			}
+
+			if err := sp.UnprojectSkills(ProjectionOpts{ProjectDir: projectDir}, []string{"my-skill"}); err != nil {
+				t.Fatalf("UnprojectSkills() error = %v", err)
+			}
+			if _, err := os.Lstat(path); !os.IsNotExist(err) {
+				t.Errorf("skill still projected after UnprojectSkills")
+			}
+			if _, err := os.Stat(filepath.Join(storeDir, "SKILL.md")); err != nil {
+				t.Errorf("store entry damaged by UnprojectSkills: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package projector
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// unmarshalYAML parses the YAML document data into config.
+func unmarshalYAML(data []byte, config *map[string]any) error {
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return err
+	}
+	// An empty document is null, which leaves no map.
+	if *config == nil {
+		*config = make(map[string]any)
+	}
+	return nil
+}
+
+// marshalYAML returns the YAML document data changed to hold config. YAML
+// configs like Goose's are edited by hand too, so the parts config leaves
+// as they are keep their comments, order, and style, as aider's config
+// does (see the aider projector).
+func marshalYAML(data []byte, config map[string]any) ([]byte, error) {
+	// Values compare equal to the document's once they are what decoding
+	// YAML returns, e.g. []any instead of []string.
+	normalized, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	var want any
+	if err := yaml.Unmarshal(normalized, &want); err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind == 0 || len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	if want == nil {
+		want = map[string]any{}
+	}
+	if err := patchYAML(doc.Content[0], want); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// patchYAML changes node to hold v. Mappings are patched key by key,
+// keeping the order of their keys and adding new ones sorted; any other
+// value that changed is replaced, keeping the comments around it.
+func patchYAML(node *yaml.Node, v any) error {
+	var have any
+	if err := node.Decode(&have); err == nil && reflect.DeepEqual(have, v) {
+		return nil
+	}
+
+	m, ok := v.(map[string]any)
+	if !ok || node.Kind != yaml.MappingNode {
+		var replacement yaml.Node
+		if err := replacement.Encode(v); err != nil {
+			return fmt.Errorf("encoding %v: %w", v, err)
+		}
+		replacement.HeadComment, replacement.LineComment, replacement.FootComment = node.HeadComment, node.LineComment, node.FootComment
+		*node = replacement
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var content []*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		want, ok := m[key.Value]
+		if !ok || seen[key.Value] {
+			continue
+		}
+		seen[key.Value] = true
+		if err := patchYAML(value, want); err != nil {
+			return err
+		}
+		content = append(content, key, value)
+	}
+	for _, key := range slices.Sorted(maps.Keys(m)) {
+		if seen[key] {
+			continue
+		}
+		var value yaml.Node
+		if err := value.Encode(m[key]); err != nil {
+			return fmt.Errorf("encoding %s: %w", key, err)
+		}
+		content = append(content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, &value)
+	}
+	node.Content = content
+	return nil
+}
@@ -0,0 +1,49 @@
+package projector
+
+import (
+	"testing"
+)
+
+func TestMarshalYAML(t *testing.T) {
+	tests := map[string]struct {
+		data   string
+		config map[string]any
+		want   string
+	}{
+		"empty document": {
+			config: map[string]any{"extensions": map[string]any{"fs": map[string]any{"cmd": "fs", "args": []string{"-v"}}}},
+			want:   "extensions:\n  fs:\n    args:\n      - -v\n    cmd: fs\n",
+		},
+		"keeps comments and order": {
+			data: "# Goose settings\nGOOSE_PROVIDER: anthropic # the default\nextensions:\n  # bundled with goose\n  developer:\n    enabled: true\n",
+			config: map[string]any{
+				"GOOSE_PROVIDER": "anthropic",
+				"extensions": map[string]any{
+					"developer": map[string]any{"enabled": true},
+					"fs":        map[string]any{"cmd": "fs", "timeout": 300},
+				},
+			},
+			want: "# Goose settings\nGOOSE_PROVIDER: anthropic # the default\nextensions:\n  # bundled with goose\n  developer:\n    enabled: true\n  fs:\n    cmd: fs\n    timeout: 300\n",
+		},
+		"changes and removes values": {
+			data: "model: a # pinned\nextensions:\n  fs:\n    cmd: fs\n  old:\n    cmd: old\n",
+			config: map[string]any{
+				"model":      "b",
+				"extensions": map[string]any{"fs": map[string]any{"cmd": "fs"}},
+			},
+			want: "model: b # pinned\nextensions:\n  fs:\n    cmd: fs\n",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := marshalYAML([]byte(tc.data), tc.config)
+			if err != nil {
+				t.Fatalf("marshalYAML() error = %v", err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("marshalYAML() =\n%s\nwant\n%s", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package prompt
+
+import (
+	"os"
+	"slices"
+
+	"github.com/charmbracelet/huh"
+	"github.com/mattn/go-isatty"
+)
+
+// Huh prompts at the terminal with huh forms.
+type Huh struct {
+	// NoColor selects an uncolored theme.
+	NoColor bool
+	// Accessible switches to huh's line-based accessible mode, for
+	// terminals that can't redraw the interactive widgets.
+	Accessible bool
+}
+
+// Interactive reports whether stdin is an interactive terminal.
+func (h *Huh) Interactive() bool {
+	return isatty.IsTerminal(os.Stdin.Fd()) || isatty.IsCygwinTerminal(os.Stdin.Fd())
+}
+
+func (h *Huh) Select(title string, options []string) (int, error) {
+	var selected int
+	err := h.run(
+		huh.NewSelect[int]().
+			Title(title).
+			Options(huhOptions(options)...).
+			Value(&selected),
+	)
+	return selected, err
+}
+
+func (h *Huh) MultiSelect(title string, options []string) ([]int, error) {
+	var selected []int
+	err := h.run(
+		huh.NewMultiSelect[int]().
+			Title(title).
+			Options(huhOptions(options)...).
+			Value(&selected),
+	)
+	slices.Sort(selected)
+	return selected, err
+}
+
+func (h *Huh) Password(title string, validate func(string) error) (string, error) {
+	var text string
+	input := huh.NewInput().
+		Title(title).
+		EchoMode(huh.EchoModePassword).
+		Value(&text)
+	if validate != nil {
+		input = input.Validate(validate)
+	}
+	err := h.run(input)
+	return text, err
+}
+
+func (h *Huh) run(field huh.Field) error {
+	form := huh.NewForm(huh.NewGroup(field))
+	if h.NoColor {
+		form = form.WithTheme(huh.ThemeBase())
+	}
+	if h.Accessible {
+		form = form.WithAccessible(true)
+	}
+	return form.Run()
+}
+
+func huhOptions(labels []string) []huh.Option[int] {
+	options := make([]huh.Option[int], len(labels))
+	for i, label := range labels {
+		options[i] = huh.NewOption(label, i)
+	}
+	return options
+}
@@ -0,0 +1,19 @@
+// Package prompt asks the user questions. Commands take a Prompter so their
+// interactive flows can run headless: Huh prompts at the terminal, Script
+// answers from a list for tests.
+package prompt
+
+// Prompter asks the user to choose among options or enter text.
+type Prompter interface {
+	// Interactive reports whether the prompter can ask questions. Callers
+	// fall back to flags or defaults when it can't.
+	Interactive() bool
+	// Select asks for one of options and returns its index.
+	Select(title string, options []string) (int, error)
+	// MultiSelect asks for any number of options and returns their indexes
+	// in the order of options.
+	MultiSelect(title string, options []string) ([]int, error)
+	// Password asks for text without echoing it, repeating the question
+	// until validate accepts the answer.
+	Password(title string, validate func(string) error) (string, error)
+}
@@ -0,0 +1,100 @@
+package prompt
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Answer is a scripted reply to one prompt.
+type Answer struct {
+	// Labels are the options chosen at a Select (exactly one) or a
+	// MultiSelect (any number).
+	Labels []string
+	// Text is the reply to a Password prompt.
+	Text string
+}
+
+// Choose answers a Select or MultiSelect with the options labeled labels.
+func Choose(labels ...string) Answer {
+	return Answer{Labels: labels}
+}
+
+// Type answers a Password prompt with text.
+func Type(text string) Answer {
+	return Answer{Text: text}
+}
+
+// Script answers prompts from Answers in order, for tests. A prompt
+// without a remaining answer, or whose answer names an option that wasn't
+// offered, fails.
+type Script struct {
+	Answers []Answer
+	// Asked records the title of every prompt, in order.
+	Asked []string
+}
+
+// Interactive always reports true, so commands prompt instead of falling
+// back to their non-interactive defaults.
+func (s *Script) Interactive() bool {
+	return true
+}
+
+func (s *Script) Select(title string, options []string) (int, error) {
+	answer, err := s.next(title)
+	if err != nil {
+		return 0, err
+	}
+	if len(answer.Labels) != 1 {
+		return 0, fmt.Errorf("prompt %q: want one scripted choice, got %d", title, len(answer.Labels))
+	}
+	return indexOf(title, options, answer.Labels[0])
+}
+
+func (s *Script) MultiSelect(title string, options []string) ([]int, error) {
+	answer, err := s.next(title)
+	if err != nil {
+		return nil, err
+	}
+	selected := make([]int, 0, len(answer.Labels))
+	for _, label := range answer.Labels {
+		idx, err := indexOf(title, options, label)
+		if err != nil {
+			return nil, err
+		}
+		selected = append(selected, idx)
+	}
+	slices.Sort(selected)
+	return slices.Compact(selected), nil
+}
+
+func (s *Script) Password(title string, validate func(string) error) (string, error) {
+	answer, err := s.next(title)
+	if err != nil {
+		return "", err
+	}
+	if validate != nil {
+		if err := validate(answer.Text); err != nil {
+			return "", fmt.Errorf("prompt %q: %w", title, err)
+		}
+	}
+	return answer.Text, nil
+}
+
+func (s *Script) next(title string) (Answer, error) {
+	s.Asked = append(s.Asked, title)
+	if len(s.Answers) == 0 {
+		return Answer{}, fmt.Errorf("prompt %q: no scripted answer left", title)
+	}
+	answer := s.Answers[0]
+	s.Answers = s.Answers[1:]
+	return answer, nil
+}
+
+func indexOf(title string, options []string, label string) (int, error) {
+	idx := slices.Index(options, label)
+	if idx < 0 {
+		return 0, fmt.Errorf("prompt %q: no option %q (options: %s)", title, label, strings.Join(options, ", "))
+	}
+	return idx, nil
+}
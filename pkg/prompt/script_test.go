@@ -0,0 +1,87 @@
+package prompt
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestScript(t *testing.T) {
+	options := []string{"claude-code", "cursor", "gemini"}
+
+	tests := map[string]struct {
+		answers []Answer
+		ask     func(s *Script) (any, error)
+		want    any
+		wantErr bool
+	}{
+		"select": {
+			answers: []Answer{Choose("cursor")},
+			ask:     func(s *Script) (any, error) { return s.Select("Agent", options) },
+			want:    1,
+		},
+		"select needs one choice": {
+			answers: []Answer{Choose("cursor", "gemini")},
+			ask:     func(s *Script) (any, error) { return s.Select("Agent", options) },
+			wantErr: true,
+		},
+		"multi-select in option order": {
+			answers: []Answer{Choose("gemini", "claude-code", "gemini")},
+			ask:     func(s *Script) (any, error) { return s.MultiSelect("Agents", options) },
+			want:    []int{0, 2},
+		},
+		"multi-select nothing": {
+			answers: []Answer{Choose()},
+			ask:     func(s *Script) (any, error) { return s.MultiSelect("Agents", options) },
+			want:    []int{},
+		},
+		"unknown option": {
+			answers: []Answer{Choose("aider")},
+			ask:     func(s *Script) (any, error) { return s.MultiSelect("Agents", options) },
+			wantErr: true,
+		},
+		"password": {
+			answers: []Answer{Type("secret")},
+			ask:     func(s *Script) (any, error) { return s.Password("Token", nil) },
+			want:    "secret",
+		},
+		"password rejected by validate": {
+			answers: []Answer{Type("")},
+			ask: func(s *Script) (any, error) {
+				return s.Password("Token", func(string) error { return errors.New("token is required") })
+			},
+			wantErr: true,
+		},
+		"no answer left": {
+			ask:     func(s *Script) (any, error) { return s.Select("Agent", options) },
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := &Script{Answers: tc.answers}
+			got, err := tc.ask(s)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if len(s.Asked) != 1 {
+				t.Errorf("Asked = %q, want one prompt", s.Asked)
+			}
+			if tc.wantErr {
+				return
+			}
+
+			switch want := tc.want.(type) {
+			case []int:
+				if !slices.Equal(got.([]int), want) {
+					t.Errorf("got %v, want %v", got, want)
+				}
+			default:
+				if got != want {
+					t.Errorf("got %v, want %v", got, want)
+				}
+			}
+		})
+	}
+}
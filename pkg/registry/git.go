@@ -0,0 +1,114 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/agentpkg/agentpkg/pkg/credentials"
+)
+
+// GitIndex publishes metadata to a git repository used as a package index.
+// Each version is committed as <kind>/<name>/<version>.toml and pushed to
+// the repository's default branch.
+type GitIndex struct {
+	URL string
+	// Token, when set, is sent as a bearer token on HTTP(S) requests, or
+	// as basic auth when Username is also set. Otherwise git's own
+	// credential helpers and SSH keys are used.
+	Token    string
+	Username string
+}
+
+var _ Publisher = &GitIndex{}
+
+func (g *GitIndex) Publish(ctx context.Context, meta *Metadata) (string, error) {
+	data, err := meta.Marshal()
+	if err != nil {
+		return "", fmt.Errorf("marshaling metadata: %w", err)
+	}
+
+	workDir, err := os.MkdirTemp("", "apkg-index-*")
+	if err != nil {
+		return "", fmt.Errorf("creating temp dir: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	if err := g.git(ctx, "", "clone", "--depth", "1", g.URL, workDir); err != nil {
+		return "", fmt.Errorf("cloning index %s: %w", g.URL, err)
+	}
+
+	rel := meta.IndexPath()
+	dest := filepath.Join(workDir, filepath.FromSlash(rel))
+	if _, err := os.Stat(dest); err == nil {
+		return "", fmt.Errorf("%s %s@%s is already published", meta.Kind, meta.Name, meta.Version)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return "", fmt.Errorf("creating %s: %w", filepath.Dir(dest), err)
+	}
+	if err := os.WriteFile(dest, data, 0o644); err != nil {
+		return "", fmt.Errorf("writing %s: %w", rel, err)
+	}
+
+	message := fmt.Sprintf("Publish %s %s@%s", meta.Kind, meta.Name, meta.Version)
+	for _, args := range [][]string{
+		{"add", rel},
+		append(g.identityArgs(ctx, workDir), "commit", "-m", message),
+		{"push", "origin", "HEAD"},
+	} {
+		if err := g.git(ctx, workDir, args...); err != nil {
+			return "", fmt.Errorf("publishing to %s: %w", g.URL, err)
+		}
+	}
+
+	return g.URL + "#" + rel, nil
+}
+
+// identityArgs returns -c overrides for the commit author when the user
+// has no git identity configured, so publishing works on fresh CI runners.
+func (g *GitIndex) identityArgs(ctx context.Context, dir string) []string {
+	var args []string
+	if out, _ := exec.CommandContext(ctx, "git", "-C", dir, "config", "user.name").Output(); len(strings.TrimSpace(string(out))) == 0 {
+		args = append(args, "-c", "user.name=apkg")
+	}
+	if out, _ := exec.CommandContext(ctx, "git", "-C", dir, "config", "user.email").Output(); len(strings.TrimSpace(string(out))) == 0 {
+		args = append(args, "-c", "user.email=apkg@localhost")
+	}
+	return args
+}
+
+// git runs a git command in dir (or the current directory if empty). The
+// token is passed through the environment rather than argv so it doesn't
+// show up in process listings.
+func (g *GitIndex) git(ctx context.Context, dir string, args ...string) error {
+	if dir != "" {
+		args = append([]string{"-C", dir}, args...)
+	}
+	cmd := exec.CommandContext(ctx, "git", args...)
+	if g.Token != "" {
+		auth := "Bearer " + g.Token
+		if g.Username != "" {
+			auth = credentials.Credential{Username: g.Username, Token: g.Token}.BasicAuth()
+		}
+		cmd.Env = append(os.Environ(),
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraHeader",
+			"GIT_CONFIG_VALUE_0=Authorization: "+auth,
+		)
+	}
+	if _, err := cmd.Output(); err != nil {
+		return execError(err)
+	}
+	return nil
+}
+
+func execError(err error) error {
+	if ee, ok := err.(*exec.ExitError); ok && len(ee.Stderr) > 0 {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(ee.Stderr)))
+	}
+	return err
+}
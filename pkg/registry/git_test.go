@@ -0,0 +1,50 @@
+package registry
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestGitIndexPublish(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found in PATH")
+	}
+
+	tests := map[string]struct {
+		publishTwice bool
+		wantErr      bool
+	}{
+		"first publish": {},
+		"duplicate version": {
+			publishTwice: true,
+			wantErr:      true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			bare := filepath.Join(t.TempDir(), "index.git")
+			if out, err := exec.Command("git", "init", "--bare", "--initial-branch=main", bare).CombinedOutput(); err != nil {
+				t.Fatalf("git init --bare: %v\n%s", err, out)
+			}
+
+			g := &GitIndex{URL: bare}
+			meta := &Metadata{Name: "pdf", Kind: KindSkill, Version: "v1.0.0", Integrity: "sha256:abc"}
+
+			_, err := g.Publish(context.Background(), meta)
+			if tc.publishTwice && err == nil {
+				_, err = g.Publish(context.Background(), meta)
+			}
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Publish() error = %v, wantErr = %v", err, tc.wantErr)
+			}
+
+			out, err := exec.Command("git", "--git-dir", bare, "show", "main:skill/pdf/v1.0.0.toml").CombinedOutput()
+			if err != nil {
+				t.Fatalf("published metadata not found in index: %v\n%s", err, out)
+			}
+		})
+	}
+}
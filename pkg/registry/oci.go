@@ -0,0 +1,77 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	ociArtifactType      = "application/vnd.apkg.package.v1"
+	ociMetadataMediaType = "application/vnd.apkg.metadata.v1+toml"
+	defaultOCIUsername   = "apkg"
+)
+
+// OCIRegistry publishes metadata as an OCI artifact using the oras CLI.
+// Each version is pushed to <Repository>/<kind>-<name>:<version>.
+type OCIRegistry struct {
+	Repository string
+	Username   string
+	// Token, when set, is passed to oras via stdin. Otherwise oras uses
+	// the credentials from `oras login` / docker config.
+	Token string
+}
+
+var _ Publisher = &OCIRegistry{}
+
+func (o *OCIRegistry) Publish(ctx context.Context, meta *Metadata) (string, error) {
+	orasPath, err := exec.LookPath("oras")
+	if err != nil {
+		return "", fmt.Errorf("publishing to an OCI registry requires the oras CLI: %w", err)
+	}
+
+	data, err := meta.Marshal()
+	if err != nil {
+		return "", fmt.Errorf("marshaling metadata: %w", err)
+	}
+
+	workDir, err := os.MkdirTemp("", "apkg-oci-*")
+	if err != nil {
+		return "", fmt.Errorf("creating temp dir: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	if err := os.WriteFile(filepath.Join(workDir, metadataFileName), data, 0o644); err != nil {
+		return "", fmt.Errorf("writing metadata: %w", err)
+	}
+
+	ref := o.Reference(meta)
+	args := []string{"push", ref, "--artifact-type", ociArtifactType}
+	if o.Token != "" {
+		username := o.Username
+		if username == "" {
+			username = defaultOCIUsername
+		}
+		args = append(args, "--username", username, "--password-stdin")
+	}
+	args = append(args, metadataFileName+":"+ociMetadataMediaType)
+
+	cmd := exec.CommandContext(ctx, orasPath, args...)
+	cmd.Dir = workDir
+	if o.Token != "" {
+		cmd.Stdin = strings.NewReader(o.Token)
+	}
+	if _, err := cmd.Output(); err != nil {
+		return "", fmt.Errorf("pushing %s: %w", ref, execError(err))
+	}
+
+	return ref, nil
+}
+
+// Reference returns the OCI reference the metadata is pushed to.
+func (o *OCIRegistry) Reference(meta *Metadata) string {
+	return fmt.Sprintf("%s/%s-%s:%s", strings.TrimSuffix(o.Repository, "/"), meta.Kind, meta.Name, meta.Version)
+}
@@ -0,0 +1,230 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/credentials"
+	"github.com/agentpkg/agentpkg/pkg/skill"
+	"github.com/agentpkg/agentpkg/pkg/source"
+	"github.com/agentpkg/agentpkg/pkg/store"
+	"github.com/pelletier/go-toml/v2"
+)
+
+const (
+	KindSkill = "skill"
+	KindMCP   = "mcp"
+
+	TypeGit = "git"
+	TypeOCI = "oci"
+
+	metadataFileName = "metadata.toml"
+	hashPrefix       = "sha256:"
+)
+
+// Metadata describes a single published package version. It is the record
+// stored in a registry index.
+type Metadata struct {
+	Name        string `toml:"name"`
+	Kind        string `toml:"kind"`
+	Version     string `toml:"version"`
+	Description string `toml:"description,omitempty"`
+	Integrity   string `toml:"integrity"`
+
+	// Skill is where consumers fetch the skill content from.
+	Skill *config.SkillSource `toml:"skill,omitempty"`
+	// MCP is the server definition consumers install.
+	MCP *config.MCPSource `toml:"mcp,omitempty"`
+}
+
+// IndexPath returns the slash-separated path of the metadata file within
+// a registry index: <kind>/<name>/<version>.toml.
+func (m *Metadata) IndexPath() string {
+	return path.Join(m.Kind, m.Name, m.Version+".toml")
+}
+
+func (m *Metadata) Marshal() ([]byte, error) {
+	return toml.Marshal(m)
+}
+
+// Publisher pushes package metadata to a registry.
+type Publisher interface {
+	// Publish stores the metadata in the registry and returns a
+	// human-readable location of the published record.
+	Publish(ctx context.Context, meta *Metadata) (string, error)
+}
+
+// NewPublisher returns the Publisher for a configured registry.
+func NewPublisher(cfg config.RegistryConfig) (Publisher, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("registry has no url configured")
+	}
+
+	var token string
+	if cfg.TokenEnv != "" {
+		token = os.Getenv(cfg.TokenEnv)
+	}
+
+	// Without a token from the environment, fall back to the credential
+	// stored for the registry's host by `apkg login`. Stored credentials
+	// are sent as basic auth, so they carry a username.
+	username := cfg.Username
+	storedCred := false
+	if token == "" {
+		if host := credentials.Host(cfg.URL); host != "" {
+			if cred, ok := credentials.Lookup(host); ok {
+				token = cred.Token
+				storedCred = true
+				if username == "" {
+					username = cred.User()
+				}
+			}
+		}
+	}
+
+	switch cfg.Type {
+	case TypeGit, "":
+		g := &GitIndex{URL: cfg.URL, Token: token}
+		if storedCred {
+			g.Username = username
+		}
+		return g, nil
+	case TypeOCI:
+		return &OCIRegistry{Repository: cfg.URL, Username: username, Token: token}, nil
+	default:
+		return nil, fmt.Errorf("unsupported registry type %q (want %q or %q)", cfg.Type, TypeGit, TypeOCI)
+	}
+}
+
+// SkillMetadata validates the skill at dir and builds its metadata. src
+// records where consumers fetch the skill from.
+func SkillMetadata(dir, version string, src config.SkillSource) (*Metadata, error) {
+	if version == "" {
+		return nil, fmt.Errorf("a version is required")
+	}
+
+	s, err := skill.Load(dir)
+	if err != nil {
+		return nil, fmt.Errorf("loading skill: %w", err)
+	}
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("validating skill: %w", err)
+	}
+
+	integrity, err := store.HashTree(dir)
+	if err != nil {
+		return nil, fmt.Errorf("computing integrity hash: %w", err)
+	}
+
+	return &Metadata{
+		Name:        s.Name(),
+		Kind:        KindSkill,
+		Version:     version,
+		Description: s.Description(),
+		Integrity:   integrity,
+		Skill:       &src,
+	}, nil
+}
+
+// MCPMetadata validates the MCP server definition (mcp.toml) at file and
+// builds its metadata.
+func MCPMetadata(file, version string) (*Metadata, error) {
+	if version == "" {
+		return nil, fmt.Errorf("a version is required")
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", file, err)
+	}
+
+	var ms config.MCPSource
+	if err := toml.Unmarshal(data, &ms); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", file, err)
+	}
+
+	if !slices.Contains(config.Transports, ms.Transport) {
+		return nil, fmt.Errorf("transport must be one of %s, got %q", strings.Join(config.Transports, ", "), ms.Transport)
+	}
+
+	name := ms.Name
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(filepath.Dir(file)), string(filepath.Separator))
+	}
+	if _, err := source.SourceFromMCPConfig(name, ms); err != nil {
+		return nil, err
+	}
+
+	h := sha256.Sum256(data)
+	return &Metadata{
+		Name:      name,
+		Kind:      KindMCP,
+		Version:   version,
+		Integrity: hashPrefix + hex.EncodeToString(h[:]),
+		MCP:       &ms,
+	}, nil
+}
+
+// InferGitSource builds the git source consumers use to fetch the skill at
+// dir: the repository's origin URL, the path of dir within the repository,
+// and ref.
+func InferGitSource(ctx context.Context, dir, ref string) (config.SkillSource, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return config.SkillSource{}, fmt.Errorf("resolving %s: %w", dir, err)
+	}
+
+	top, err := gitOutput(ctx, absDir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return config.SkillSource{}, fmt.Errorf("%s is not in a git repository: %w", dir, err)
+	}
+
+	remote, err := gitOutput(ctx, absDir, "remote", "get-url", "origin")
+	if err != nil {
+		return config.SkillSource{}, fmt.Errorf("reading origin remote: %w", err)
+	}
+
+	// Resolve symlinks on both sides so the relative path is computed
+	// against the same physical tree git reports.
+	realDir, err := filepath.EvalSymlinks(absDir)
+	if err != nil {
+		return config.SkillSource{}, err
+	}
+	realTop, err := filepath.EvalSymlinks(top)
+	if err != nil {
+		return config.SkillSource{}, err
+	}
+	rel, err := filepath.Rel(realTop, realDir)
+	if err != nil {
+		return config.SkillSource{}, err
+	}
+	if rel == "." {
+		rel = ""
+	}
+
+	return config.SkillSource{Git: remote, Path: filepath.ToSlash(rel), Ref: ref}, nil
+}
+
+func gitOutput(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
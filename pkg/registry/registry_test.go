@@ -0,0 +1,167 @@
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+)
+
+func TestSkillMetadata(t *testing.T) {
+	tests := map[string]struct {
+		content  string
+		version  string
+		wantName string
+		wantErr  bool
+	}{
+		"valid skill": {
+			content:  "---\nname: pdf\ndescription: work with pdfs\n---\n# pdf\n",
+			version:  "v1.0.0",
+			wantName: "pdf",
+		},
+		"invalid skill name": {
+			content: "---\nname: Not_Valid\ndescription: bad\n---\n",
+			version: "v1.0.0",
+			wantErr: true,
+		},
+		"missing version": {
+			content: "---\nname: pdf\ndescription: work with pdfs\n---\n",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte(tc.content), 0o644)
+
+			src := config.SkillSource{Git: "https://github.com/org/skills.git", Path: "pdf", Ref: tc.version}
+			meta, err := SkillMetadata(dir, tc.version, src)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("SkillMetadata() error = %v, wantErr = %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+
+			if meta.Name != tc.wantName {
+				t.Errorf("Name = %q, want %q", meta.Name, tc.wantName)
+			}
+			if meta.Description != "work with pdfs" {
+				t.Errorf("Description = %q, want %q", meta.Description, "work with pdfs")
+			}
+			if !strings.HasPrefix(meta.Integrity, hashPrefix) {
+				t.Errorf("Integrity = %q, want %s prefix", meta.Integrity, hashPrefix)
+			}
+			if got, want := meta.IndexPath(), "skill/pdf/v1.0.0.toml"; got != want {
+				t.Errorf("IndexPath() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestMCPMetadata(t *testing.T) {
+	tests := map[string]struct {
+		content  string
+		wantName string
+		wantErr  bool
+	}{
+		"named http server": {
+			content:  "transport = 'http'\nname = 'remote'\nurl = 'https://example.com/mcp'\n",
+			wantName: "remote",
+		},
+		"sse server": {
+			content:  "transport = 'sse'\nname = 'legacy'\nurl = 'https://example.com/sse'\n",
+			wantName: "legacy",
+		},
+		"name inferred from directory": {
+			content:  "transport = 'stdio'\ncommand = '/usr/bin/tool'\n",
+			wantName: "tool-server",
+		},
+		"invalid transport": {
+			content: "transport = 'carrier-pigeon'\ncommand = '/usr/bin/tool'\n",
+			wantErr: true,
+		},
+		"unsupported config": {
+			content: "transport = 'stdio'\n",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "tool-server")
+			os.MkdirAll(dir, 0o755)
+			file := filepath.Join(dir, "mcp.toml")
+			os.WriteFile(file, []byte(tc.content), 0o644)
+
+			meta, err := MCPMetadata(file, "1.0.0")
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("MCPMetadata() error = %v, wantErr = %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+
+			if meta.Name != tc.wantName {
+				t.Errorf("Name = %q, want %q", meta.Name, tc.wantName)
+			}
+			if meta.MCP == nil {
+				t.Fatal("MCP definition is nil")
+			}
+		})
+	}
+}
+
+func TestNewPublisher(t *testing.T) {
+	tests := map[string]struct {
+		cfg     config.RegistryConfig
+		want    string
+		wantErr bool
+	}{
+		"default type is git": {
+			cfg:  config.RegistryConfig{URL: "https://github.com/org/index.git"},
+			want: "*registry.GitIndex",
+		},
+		"oci": {
+			cfg:  config.RegistryConfig{Type: TypeOCI, URL: "ghcr.io/org/apkg"},
+			want: "*registry.OCIRegistry",
+		},
+		"missing url": {
+			cfg:     config.RegistryConfig{Type: TypeGit},
+			wantErr: true,
+		},
+		"unknown type": {
+			cfg:     config.RegistryConfig{Type: "ftp", URL: "ftp://example.com"},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			pub, err := NewPublisher(tc.cfg)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("NewPublisher() error = %v, wantErr = %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if got := typeName(pub); got != tc.want {
+				t.Errorf("NewPublisher() type = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case *GitIndex:
+		return "*registry.GitIndex"
+	case *OCIRegistry:
+		return "*registry.OCIRegistry"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,354 @@
+// Package rpc serves apkg operations as JSON-RPC 2.0 over stdio, framed
+// like the Language Server Protocol, for editor extensions that embed apkg
+// as a child process. Besides answering requests, the server notifies the
+// client when the project's lockfile changes or its projections drift.
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/agentpkg/agentpkg/pkg/installer"
+	"github.com/agentpkg/agentpkg/pkg/workspace"
+)
+
+// Methods handled by the server.
+const (
+	MethodPackages     = "apkg/packages"
+	MethodStatus       = "apkg/status"
+	MethodInstall      = "apkg/install"
+	MethodInstallSkill = "apkg/installSkill"
+	MethodRemove       = "apkg/remove"
+	MethodShutdown     = "shutdown"
+	MethodExit         = "exit"
+)
+
+// Notifications sent by the server.
+const (
+	// NotifyLockfileChanged is sent when the project lockfile is written,
+	// by the server or anything else.
+	NotifyLockfileChanged = "apkg/lockfileChanged"
+	// NotifyProjectionDrift is sent when the set of manifest entries
+	// missing from agent configurations changes (see installer.Drift).
+	NotifyProjectionDrift = "apkg/projectionDrift"
+)
+
+// Error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+
+	// CodePackageNotFound is returned when removing a package that is not
+	// in the manifest.
+	CodePackageNotFound = -32001
+)
+
+// DefaultPollInterval is how often the project is checked for changes to
+// notify the client about.
+const DefaultPollInterval = 2 * time.Second
+
+// Server answers requests for the workspaces returned by Open.
+type Server struct {
+	// Open returns the project workspace, or the global one when global is
+	// set (requests with "global": true).
+	Open func(global bool) (*workspace.Workspace, error)
+	// PollInterval is how often the project workspace is checked for
+	// lockfile changes and projection drift; DefaultPollInterval if zero.
+	PollInterval time.Duration
+}
+
+// Error is a JSON-RPC error object.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string { return e.Message }
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+type notification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+// scopeParams selects the workspace of a request.
+type scopeParams struct {
+	Global bool `json:"global,omitempty"`
+}
+
+type installSkillParams struct {
+	scopeParams
+	// Ref is a skill reference as accepted by `apkg install skill`.
+	Ref string `json:"ref"`
+	// Scope is "project" (default) or "user".
+	Scope string `json:"scope,omitempty"`
+}
+
+type removeParams struct {
+	scopeParams
+	Kind string `json:"kind"` // "skill" or "mcp"
+	Name string `json:"name"`
+	// Purge also purges the store and stops containers (see
+	// workspace.Workspace.Remove).
+	Purge bool `json:"purge,omitempty"`
+}
+
+// LockfileChangedParams are the params of NotifyLockfileChanged.
+type LockfileChangedParams struct {
+	Path string `json:"path"`
+}
+
+// ProjectionDriftParams are the params of NotifyProjectionDrift.
+type ProjectionDriftParams struct {
+	Drift []installer.Drift `json:"drift"`
+}
+
+// Serve reads requests from r and writes responses and notifications to w
+// until r is closed, an exit notification is received, or ctx is
+// cancelled. Requests are handled one at a time, in order.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	c := newConn(r, w)
+	if ws, err := s.Open(false); err == nil {
+		// Take the baseline before handling requests so changes they make
+		// are notified.
+		drift, _ := ws.Drift()
+		go s.watch(ctx, c, ws, fileSum(ws.LockPath), drift)
+	}
+
+	msgs := make(chan []byte)
+	errCh := make(chan error, 1)
+	go func() {
+		for {
+			body, err := c.read()
+			if err != nil {
+				errCh <- err
+				return
+			}
+			select {
+			case msgs <- body:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errCh:
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		case body := <-msgs:
+			exit, err := s.handle(ctx, c, body)
+			if err != nil {
+				return err
+			}
+			if exit {
+				return nil
+			}
+		}
+	}
+}
+
+// handle dispatches a single message and reports whether the client asked
+// the server to exit.
+func (s *Server) handle(ctx context.Context, c *conn, body []byte) (bool, error) {
+	var req request
+	if err := json.Unmarshal(body, &req); err != nil {
+		return false, c.write(response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &Error{Code: codeParseError, Message: err.Error()}})
+	}
+	if req.Method == MethodExit {
+		return true, nil
+	}
+
+	result, err := s.call(ctx, req)
+	if req.ID == nil {
+		// Notifications get no response, even on error.
+		return false, nil
+	}
+
+	resp := response{JSONRPC: "2.0", ID: req.ID}
+	if err != nil {
+		resp.Error = toError(err)
+	} else if resp.Result, err = json.Marshal(result); err != nil {
+		resp.Error = toError(err)
+	}
+	return false, c.write(resp)
+}
+
+func (s *Server) call(ctx context.Context, req request) (any, error) {
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return nil, &Error{Code: codeInvalidRequest, Message: "invalid JSON-RPC 2.0 request"}
+	}
+
+	switch req.Method {
+	case MethodShutdown:
+		return nil, nil
+
+	case MethodPackages:
+		var p scopeParams
+		return withWorkspace(s, req.Params, &p, &p, func(ws *workspace.Workspace) (any, error) {
+			nodes, err := ws.Packages()
+			if nodes == nil && err == nil {
+				nodes = []installer.TreeNode{}
+			}
+			return nodes, err
+		})
+
+	case MethodStatus:
+		var p scopeParams
+		return withWorkspace(s, req.Params, &p, &p, func(ws *workspace.Workspace) (any, error) {
+			return ws.Status()
+		})
+
+	case MethodInstall:
+		var p scopeParams
+		return withWorkspace(s, req.Params, &p, &p, func(ws *workspace.Workspace) (any, error) {
+			lf, err := ws.InstallAll(ctx, workspace.InstallOptions{})
+			if err != nil {
+				return nil, err
+			}
+			return map[string]int{"skills": len(lf.Skills), "mcpServers": len(lf.MCPServers)}, nil
+		})
+
+	case MethodInstallSkill:
+		var p installSkillParams
+		return withWorkspace(s, req.Params, &p, &p.scopeParams, func(ws *workspace.Workspace) (any, error) {
+			if p.Ref == "" {
+				return nil, &Error{Code: codeInvalidParams, Message: `"ref" is required`}
+			}
+			name, err := ws.InstallSkill(ctx, p.Ref, workspace.SkillOptions{Scope: p.Scope})
+			if err != nil {
+				return nil, err
+			}
+			return map[string]string{"name": name}, nil
+		})
+
+	case MethodRemove:
+		var p removeParams
+		return withWorkspace(s, req.Params, &p, &p.scopeParams, func(ws *workspace.Workspace) (any, error) {
+			if p.Kind != installer.KindSkill && p.Kind != installer.KindMCP {
+				return nil, &Error{Code: codeInvalidParams, Message: fmt.Sprintf(`"kind" must be %q or %q`, installer.KindSkill, installer.KindMCP)}
+			}
+			var skills, mcpServers []string
+			if p.Kind == installer.KindSkill {
+				skills = []string{p.Name}
+			} else {
+				mcpServers = []string{p.Name}
+			}
+			_, err := ws.Remove(ctx, skills, mcpServers, p.Purge)
+			return nil, err
+		})
+
+	default:
+		return nil, &Error{Code: codeMethodNotFound, Message: fmt.Sprintf("method %q not found", req.Method)}
+	}
+}
+
+// withWorkspace decodes raw into params, opens the workspace selected by
+// scope (which points into params), and calls fn with it.
+func withWorkspace(s *Server, raw json.RawMessage, params any, scope *scopeParams, fn func(*workspace.Workspace) (any, error)) (any, error) {
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, params); err != nil {
+			return nil, &Error{Code: codeInvalidParams, Message: err.Error()}
+		}
+	}
+
+	ws, err := s.Open(scope.Global)
+	if err != nil {
+		return nil, err
+	}
+	ws.Command = "apkg rpc"
+	ws.Warn = func(err error) { fmt.Fprintf(os.Stderr, "Warning: %v\n", err) }
+	return fn(ws)
+}
+
+func toError(err error) *Error {
+	var rpcErr *Error
+	switch {
+	case errors.As(err, &rpcErr):
+		return rpcErr
+	case errors.Is(err, workspace.ErrNotFound):
+		return &Error{Code: CodePackageNotFound, Message: err.Error()}
+	default:
+		return &Error{Code: codeInternalError, Message: err.Error()}
+	}
+}
+
+// watch polls ws until ctx is cancelled, notifying the client when the
+// lockfile or the projection drift differ from lockSum and drift.
+func (s *Server) watch(ctx context.Context, c *conn, ws *workspace.Workspace, lockSum []byte, drift []installer.Drift) {
+	interval := s.PollInterval
+	if interval == 0 {
+		interval = DefaultPollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if sum := fileSum(ws.LockPath); !bytes.Equal(sum, lockSum) {
+			lockSum = sum
+			c.write(notification{JSONRPC: "2.0", Method: NotifyLockfileChanged, Params: LockfileChangedParams{Path: ws.LockPath}})
+		}
+
+		// Drift can't be computed without a readable manifest; keep the
+		// last result until there is one again.
+		current, err := ws.Drift()
+		if err != nil || slices.Equal(current, drift) {
+			continue
+		}
+		drift = current
+		if current == nil {
+			current = []installer.Drift{}
+		}
+		c.write(notification{JSONRPC: "2.0", Method: NotifyProjectionDrift, Params: ProjectionDriftParams{Drift: current}})
+	}
+}
+
+// fileSum returns the SHA-256 of the file at path, or nil if it can't be
+// read.
+func fileSum(path string) []byte {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
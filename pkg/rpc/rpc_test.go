@@ -0,0 +1,190 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/journal"
+	"github.com/agentpkg/agentpkg/pkg/store"
+	"github.com/agentpkg/agentpkg/pkg/workspace"
+)
+
+// testClient talks to a Server over in-memory pipes.
+type testClient struct {
+	t      *testing.T
+	c      *conn
+	nextID int
+}
+
+func startServer(t *testing.T, dir string) *testClient {
+	t.Helper()
+	s := &Server{
+		PollInterval: 10 * time.Millisecond,
+		Open: func(global bool) (*workspace.Workspace, error) {
+			return &workspace.Workspace{
+				Dir:          dir,
+				ManifestPath: filepath.Join(dir, "apkg.toml"),
+				LockPath:     filepath.Join(dir, config.LockFileName),
+				Store:        store.New(filepath.Join(dir, "store")),
+				Journal:      &journal.Journal{Path: filepath.Join(dir, "journal.log")},
+			}, nil
+		},
+	}
+
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Serve(ctx, inR, outW)
+		outW.Close()
+	}()
+	t.Cleanup(func() {
+		cancel()
+		inW.Close()
+		go io.Copy(io.Discard, outR)
+		if err := <-done; err != nil {
+			t.Errorf("Serve() error = %v", err)
+		}
+	})
+
+	return &testClient{t: t, c: newConn(outR, inW)}
+}
+
+// call sends a request and returns its response, skipping notifications.
+func (tc *testClient) call(method string, params any) response {
+	tc.t.Helper()
+	tc.nextID++
+	raw, _ := json.Marshal(params)
+	id := json.RawMessage(fmtID(tc.nextID))
+	if err := tc.c.write(request{JSONRPC: "2.0", ID: id, Method: method, Params: raw}); err != nil {
+		tc.t.Fatal(err)
+	}
+	for {
+		msg := tc.next()
+		if string(msg.ID) == string(id) {
+			return response{JSONRPC: msg.JSONRPC, ID: msg.ID, Result: msg.Result, Error: msg.Error}
+		}
+	}
+}
+
+// message is any message the server sends.
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	Result  json.RawMessage `json:"result"`
+	Error   *Error          `json:"error"`
+}
+
+func (tc *testClient) next() message {
+	tc.t.Helper()
+	body, err := tc.c.read()
+	if err != nil {
+		tc.t.Fatalf("reading message: %v", err)
+	}
+	var msg message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		tc.t.Fatalf("decoding message %s: %v", body, err)
+	}
+	return msg
+}
+
+// waitNotification returns the params of the next notification named
+// method, skipping other messages.
+func (tc *testClient) waitNotification(method string) json.RawMessage {
+	tc.t.Helper()
+	for {
+		if msg := tc.next(); msg.Method == method {
+			return msg.Params
+		}
+	}
+}
+
+func fmtID(id int) string {
+	data, _ := json.Marshal(id)
+	return string(data)
+}
+
+func newProject(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := config.SaveFile(filepath.Join(dir, "apkg.toml"), &config.Config{}); err != nil {
+		t.Fatal(err)
+	}
+	skillDir := filepath.Join(dir, "skills", "pdf")
+	if err := os.MkdirAll(skillDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	content := "---\nname: pdf\ndescription: test skill\n---\n# pdf\n"
+	if err := os.WriteFile(filepath.Join(skillDir, "SKILL.md"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestRequests(t *testing.T) {
+	tests := map[string]struct {
+		method   string
+		params   any
+		wantCode int // 0 for success
+	}{
+		"status":              {method: MethodStatus},
+		"packages":            {method: MethodPackages, params: map[string]any{"global": false}},
+		"unknown method":      {method: "apkg/frobnicate", wantCode: codeMethodNotFound},
+		"install without ref": {method: MethodInstallSkill, params: map[string]any{}, wantCode: codeInvalidParams},
+		"remove bad kind":     {method: MethodRemove, params: map[string]any{"kind": "plugin", "name": "x"}, wantCode: codeInvalidParams},
+		"remove missing":      {method: MethodRemove, params: map[string]any{"kind": "skill", "name": "x"}, wantCode: CodePackageNotFound},
+		"invalid params":      {method: MethodRemove, params: []string{"skill"}, wantCode: codeInvalidParams},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := startServer(t, newProject(t))
+			resp := client.call(tc.method, tc.params)
+
+			gotCode := 0
+			if resp.Error != nil {
+				gotCode = resp.Error.Code
+			}
+			if gotCode != tc.wantCode {
+				t.Errorf("error code = %d (%v), want %d", gotCode, resp.Error, tc.wantCode)
+			}
+		})
+	}
+}
+
+func TestNotifications(t *testing.T) {
+	dir := newProject(t)
+	client := startServer(t, dir)
+
+	resp := client.call(MethodInstallSkill, map[string]any{"ref": "./skills/pdf"})
+	if resp.Error != nil {
+		t.Fatalf("%s error = %v", MethodInstallSkill, resp.Error)
+	}
+	var installed struct{ Name string }
+	if err := json.Unmarshal(resp.Result, &installed); err != nil || installed.Name != "pdf" {
+		t.Fatalf("%s result = %s, want name pdf", MethodInstallSkill, resp.Result)
+	}
+
+	var changed LockfileChangedParams
+	if err := json.Unmarshal(client.waitNotification(NotifyLockfileChanged), &changed); err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, config.LockFileName); changed.Path != want {
+		t.Errorf("lockfile changed path = %q, want %q", changed.Path, want)
+	}
+
+	resp = client.call(MethodRemove, map[string]any{"kind": "skill", "name": "pdf"})
+	if resp.Error != nil || string(resp.Result) != "null" {
+		t.Fatalf("%s = %s %v, want null result", MethodRemove, resp.Result, resp.Error)
+	}
+	client.waitNotification(NotifyLockfileChanged)
+}
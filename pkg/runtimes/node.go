@@ -0,0 +1,203 @@
+package runtimes
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/fsutil"
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+// nodeDistURL is the Node.js download site, replaced in tests.
+var nodeDistURL = "https://nodejs.org/dist"
+
+// installNode downloads the official Node.js build for this platform into
+// runtimes/node/<version>, verifying it against the SHASUMS256.txt
+// published with the release.
+func installNode(ctx context.Context, st store.Store, version string) (*Runtime, error) {
+	if !isConcrete(version) {
+		resolved, err := resolveNodeVersion(ctx, version)
+		if err != nil {
+			return nil, err
+		}
+		version = resolved
+	}
+
+	segs := []string{"runtimes", Node, version}
+	rt := &Runtime{
+		Name:    Node,
+		Version: version,
+		Bin:     st.Path(append(slices.Clone(segs), "bin", "node")...),
+	}
+
+	cached, err := st.Exists(segs...)
+	if err != nil {
+		return nil, fmt.Errorf("checking cached node %s: %w", version, err)
+	}
+	if cached {
+		data, err := st.ReadFile(append(slices.Clone(segs), integrityFile)...)
+		if err != nil {
+			return nil, fmt.Errorf("reading integrity of node %s: %w", version, err)
+		}
+		rt.Integrity = strings.TrimSpace(string(data))
+		return rt, nil
+	}
+
+	platform, err := nodePlatform()
+	if err != nil {
+		return nil, err
+	}
+	archive := fmt.Sprintf("node-v%s-%s.tar.gz", version, platform)
+
+	checksum, err := nodeChecksum(ctx, version, archive)
+	if err != nil {
+		return nil, err
+	}
+
+	// Extract next to the final location and rename, so an interrupted
+	// install never leaves a partial runtime behind.
+	st.EnsureDir("runtimes", Node)
+	tmp := st.Path("runtimes", Node, "."+version+".tmp")
+	if err := os.RemoveAll(tmp); err != nil {
+		return nil, fmt.Errorf("cleaning up previous node install: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := downloadNode(ctx, fmt.Sprintf("%s/v%s/%s", nodeDistURL, version, archive), checksum, tmp); err != nil {
+		return nil, fmt.Errorf("installing node %s: %w", version, err)
+	}
+
+	rt.Integrity = "sha256:" + checksum
+	if err := os.WriteFile(filepath.Join(tmp, integrityFile), []byte(rt.Integrity+"\n"), 0o644); err != nil {
+		return nil, fmt.Errorf("recording integrity of node %s: %w", version, err)
+	}
+	if err := os.Rename(tmp, st.Path(segs...)); err != nil {
+		return nil, fmt.Errorf("installing node %s: %w", version, err)
+	}
+	return rt, nil
+}
+
+// resolveNodeVersion returns the newest release whose version starts with
+// the dotted prefix, from the release index of the download site.
+func resolveNodeVersion(ctx context.Context, prefix string) (string, error) {
+	body, err := httpGet(ctx, nodeDistURL+"/index.json")
+	if err != nil {
+		return "", fmt.Errorf("listing node releases: %w", err)
+	}
+	defer body.Close()
+
+	var releases []struct {
+		Version string `json:"version"`
+	}
+	if err := json.NewDecoder(body).Decode(&releases); err != nil {
+		return "", fmt.Errorf("decoding node release index: %w", err)
+	}
+
+	var best string
+	for _, r := range releases {
+		v := strings.TrimPrefix(r.Version, "v")
+		if v != prefix && !strings.HasPrefix(v, prefix+".") {
+			continue
+		}
+		if c, ok := config.CompareVersions(v, best); best == "" || (ok && c > 0) {
+			best = v
+		}
+	}
+	if best == "" {
+		return "", fmt.Errorf("no node release matches %s", prefix)
+	}
+	return best, nil
+}
+
+// nodeChecksum returns the hex sha256 of archive listed in the release's
+// SHASUMS256.txt.
+func nodeChecksum(ctx context.Context, version, archive string) (string, error) {
+	body, err := httpGet(ctx, fmt.Sprintf("%s/v%s/SHASUMS256.txt", nodeDistURL, version))
+	if err != nil {
+		return "", fmt.Errorf("fetching checksums of node %s: %w", version, err)
+	}
+	defer body.Close()
+
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[1] == archive {
+			return fields[0], nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("reading checksums of node %s: %w", version, err)
+	}
+	return "", fmt.Errorf("node %s has no build %s", version, archive)
+}
+
+// downloadNode downloads the archive at url, checks its sha256 against
+// checksum, and extracts it into dest without its top-level directory.
+func downloadNode(ctx context.Context, url, checksum, dest string) error {
+	f, err := os.CreateTemp("", "apkg-node-*.tar.gz")
+	if err != nil {
+		return fmt.Errorf("creating download file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	body, err := httpGet(ctx, url)
+	if err != nil {
+		return fmt.Errorf("downloading %s: %w", url, err)
+	}
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(f, h), body)
+	body.Close()
+	if err != nil {
+		return fmt.Errorf("downloading %s: %w", url, err)
+	}
+
+	if got := hex.EncodeToString(h.Sum(nil)); got != checksum {
+		return fmt.Errorf("checksum mismatch for %s: got %s, want %s", url, got, checksum)
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("reading download: %w", err)
+	}
+	return fsutil.ExtractTarGz(f, dest, 1)
+}
+
+// nodePlatform returns the platform suffix of Node.js build archives for
+// the running OS and architecture, e.g. "linux-x64".
+func nodePlatform() (string, error) {
+	arch, ok := map[string]string{"amd64": "x64", "arm64": "arm64"}[runtime.GOARCH]
+	if !ok || (runtime.GOOS != "linux" && runtime.GOOS != "darwin") {
+		return "", fmt.Errorf("managed node is not available for %s/%s", runtime.GOOS, runtime.GOARCH)
+	}
+	return runtime.GOOS + "-" + arch, nil
+}
+
+// httpGet issues a GET request and returns the response body, failing on
+// non-200 responses.
+func httpGet(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+	}
+	return resp.Body, nil
+}
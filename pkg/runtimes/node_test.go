@@ -0,0 +1,186 @@
+package runtimes
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+type tarEntry struct {
+	name     string
+	body     string
+	linkname string
+}
+
+func makeTarGz(t *testing.T, entries []tarEntry) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Mode: 0o755, Size: int64(len(e.body)), Typeflag: tar.TypeReg}
+		if e.linkname != "" {
+			hdr.Typeflag, hdr.Linkname, hdr.Size = tar.TypeSymlink, e.linkname, 0
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// serveNodeDist serves a release index with 20.18.0, 22.10.0 and 22.11.0
+// and a build of each for this platform. badSum corrupts the published
+// checksums.
+func serveNodeDist(t *testing.T, badSum bool) {
+	t.Helper()
+
+	platform, err := nodePlatform()
+	if err != nil {
+		t.Skip(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/index.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"version":"v22.11.0"},{"version":"v22.10.0"},{"version":"v20.18.0"}]`)
+	})
+	for _, version := range []string{"20.18.0", "22.10.0", "22.11.0"} {
+		top := fmt.Sprintf("node-v%s-%s", version, platform)
+		archive := makeTarGz(t, []tarEntry{
+			{name: top + "/bin/node", body: "node " + version},
+			{name: top + "/lib/npm-cli.js", body: "npm"},
+			{name: top + "/bin/npm", linkname: "../lib/npm-cli.js"},
+		})
+		sum := sha256.Sum256(archive)
+		checksum := hex.EncodeToString(sum[:])
+		if badSum {
+			checksum = strings.Repeat("0", 64)
+		}
+
+		mux.HandleFunc("/v"+version+"/"+top+".tar.gz", func(w http.ResponseWriter, r *http.Request) {
+			w.Write(archive)
+		})
+		mux.HandleFunc("/v"+version+"/SHASUMS256.txt", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, "%s  %s.tar.gz\n", checksum, top)
+		})
+	}
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	orig := nodeDistURL
+	nodeDistURL = srv.URL
+	t.Cleanup(func() { nodeDistURL = orig })
+}
+
+func TestInstallNode(t *testing.T) {
+	tests := map[string]struct {
+		version     string
+		badSum      bool
+		wantVersion string
+		wantErr     string
+	}{
+		"concrete version": {
+			version:     "22.10.0",
+			wantVersion: "22.10.0",
+		},
+		"major resolves to newest release": {
+			version:     "22",
+			wantVersion: "22.11.0",
+		},
+		"minor prefix": {
+			version:     "20.18",
+			wantVersion: "20.18.0",
+		},
+		"no matching release": {
+			version: "18",
+			wantErr: "no node release matches 18",
+		},
+		"checksum mismatch": {
+			version: "22.11.0",
+			badSum:  true,
+			wantErr: "checksum mismatch",
+		},
+		"invalid version": {
+			version: "lts",
+			wantErr: "invalid version",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			serveNodeDist(t, tc.badSum)
+			st := store.New(t.TempDir())
+
+			rt, err := Install(context.Background(), st, Node, tc.version)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("Install() error = %v, want containing %q", err, tc.wantErr)
+				}
+				entries, _ := os.ReadDir(st.Path("runtimes", Node))
+				if len(entries) != 0 {
+					t.Errorf("failed install left %d entries behind", len(entries))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Install() error = %v", err)
+			}
+
+			if rt.Version != tc.wantVersion {
+				t.Errorf("Version = %q, want %q", rt.Version, tc.wantVersion)
+			}
+			if !strings.HasPrefix(rt.Integrity, "sha256:") {
+				t.Errorf("Integrity = %q, want sha256 checksum", rt.Integrity)
+			}
+			if want := st.Path("runtimes", Node, tc.wantVersion, "bin", "node"); rt.Bin != want {
+				t.Errorf("Bin = %q, want %q", rt.Bin, want)
+			}
+			data, err := os.ReadFile(filepath.Join(rt.BinDir(), "npm"))
+			if err != nil || string(data) != "npm" {
+				t.Errorf("bin/npm = %q, %v; want symlink to npm-cli.js", data, err)
+			}
+
+			// Installing again reuses the cached runtime.
+			again, err := Install(context.Background(), st, Node, tc.wantVersion)
+			if err != nil {
+				t.Fatalf("cached Install() error = %v", err)
+			}
+			if *again != *rt {
+				t.Errorf("cached Install() = %+v, want %+v", again, rt)
+			}
+		})
+	}
+}
+
+func TestRuntimeEnv(t *testing.T) {
+	rt := &Runtime{Bin: "/store/runtimes/node/22.11.0/bin/node"}
+	env := rt.Env([]string{"HOME=/home/me", "PATH=/usr/bin"})
+
+	want := []string{"HOME=/home/me", "PATH=/store/runtimes/node/22.11.0/bin" + string(os.PathListSeparator) + "/usr/bin"}
+	if fmt.Sprint(env) != fmt.Sprint(want) {
+		t.Errorf("Env() = %v, want %v", env, want)
+	}
+}
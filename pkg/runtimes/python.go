@@ -0,0 +1,59 @@
+package runtimes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+// installPython installs a Python build into runtimes/python with uv,
+// which downloads and verifies the standalone builds it manages. uv keeps
+// one directory per build there, so any number of versions coexist.
+func installPython(ctx context.Context, st store.Store, version string) (*Runtime, error) {
+	st.EnsureDir("runtimes", Python)
+	dir := st.Path("runtimes", Python)
+
+	// Run from the install directory so uv doesn't pick up a
+	// .python-version file of the current project, and keep it from
+	// linking the build into ~/.local/bin.
+	env := append(os.Environ(), "UV_PYTHON_INSTALL_DIR="+dir, "UV_PYTHON_INSTALL_BIN=0")
+
+	install := exec.CommandContext(ctx, "uv", "python", "install", version)
+	install.Dir, install.Env = dir, env
+	if _, err := install.Output(); err != nil {
+		return nil, fmt.Errorf("installing python %s: %w", version, execError(err))
+	}
+
+	find := exec.CommandContext(ctx, "uv", "python", "find", "--python-preference", "only-managed", version)
+	find.Dir, find.Env = dir, env
+	out, err := find.Output()
+	if err != nil {
+		return nil, fmt.Errorf("locating python %s: %w", version, execError(err))
+	}
+	bin := strings.TrimSpace(string(out))
+
+	out, err = exec.CommandContext(ctx, bin, "-c", "import platform; print(platform.python_version())").Output()
+	if err != nil {
+		return nil, fmt.Errorf("reading version of %s: %w", bin, execError(err))
+	}
+
+	return &Runtime{
+		Name:    Python,
+		Version: strings.TrimSpace(string(out)),
+		Bin:     bin,
+	}, nil
+}
+
+// execError adds the stderr of a failed command to err.
+func execError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return err
+}
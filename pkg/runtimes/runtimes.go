@@ -0,0 +1,97 @@
+// Package runtimes installs pinned Node.js and Python builds into the
+// store, so managed npm and uv MCP servers keep working when the system's
+// runtimes are upgraded or missing.
+package runtimes
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+// Runtime names, as used in the manifest's [runtimes] table and the
+// lockfile.
+const (
+	Node   = "node"
+	Python = "python"
+)
+
+// Names lists the supported runtimes in install order.
+var Names = []string{Node, Python}
+
+// integrityFile holds a runtime's integrity next to its installed tree,
+// so cached installs don't download the checksums again.
+const integrityFile = ".apkg-integrity"
+
+// Runtime is a runtime installed in the store.
+type Runtime struct {
+	Name    string
+	Version string // concrete version, e.g. "22.11.0"
+	// Integrity is the checksum of the downloaded build ("sha256:<hex>"),
+	// empty when the build was downloaded and verified by another tool
+	// (Python builds are installed by uv).
+	Integrity string
+	// Bin is the absolute path to the interpreter.
+	Bin string
+}
+
+// BinDir returns the directory holding the interpreter and the tools
+// that ship with it (e.g. npm).
+func (rt *Runtime) BinDir() string {
+	return filepath.Dir(rt.Bin)
+}
+
+// Env returns environ with BinDir prepended to PATH, so tools that look
+// up the interpreter by name (e.g. npm's "#!/usr/bin/env node") run on
+// rt instead of the system's.
+func (rt *Runtime) Env(environ []string) []string {
+	env := make([]string, 0, len(environ)+1)
+	path := rt.BinDir()
+	for _, kv := range environ {
+		if v, ok := strings.CutPrefix(kv, "PATH="); ok {
+			path += string(os.PathListSeparator) + v
+			continue
+		}
+		env = append(env, kv)
+	}
+	return append(env, "PATH="+path)
+}
+
+// Install installs the runtime name at version into the store and returns
+// it. version is either a concrete version ("22.11.0") or a prefix of one
+// ("22", "3.12"), which resolves to the newest matching release.
+func Install(ctx context.Context, st store.Store, name, version string) (*Runtime, error) {
+	if err := ValidateVersion(version); err != nil {
+		return nil, fmt.Errorf("%s runtime: %w", name, err)
+	}
+
+	switch name {
+	case Node:
+		return installNode(ctx, st, version)
+	case Python:
+		return installPython(ctx, st, version)
+	default:
+		return nil, fmt.Errorf("unsupported runtime %q (supported: %s)", name, strings.Join(Names, ", "))
+	}
+}
+
+var versionPattern = regexp.MustCompile(`^\d+(\.\d+){0,2}$`)
+
+// ValidateVersion checks that version is a dotted numeric version or a
+// prefix of one, with at most three parts.
+func ValidateVersion(version string) error {
+	if !versionPattern.MatchString(version) {
+		return fmt.Errorf("invalid version %q: want a version like 22 or 22.11.0", version)
+	}
+	return nil
+}
+
+// isConcrete reports whether version names a single release.
+func isConcrete(version string) bool {
+	return strings.Count(version, ".") == 2
+}
@@ -0,0 +1,176 @@
+package runtimes
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Go is the Go toolchain, which a project can pin (see ProjectToolchain)
+// but apkg doesn't install.
+const Go = "go"
+
+// ToolchainNames lists the runtimes ProjectToolchain looks up.
+var ToolchainNames = []string{Node, Python, Go}
+
+// toolAliases are the names a runtime goes by in .tool-versions and
+// mise.toml: asdf's plugin names and mise's own.
+var toolAliases = map[string][]string{
+	Node:   {"node", "nodejs"},
+	Python: {"python"},
+	Go:     {"go", "golang"},
+}
+
+// toolchainManagers are the version managers that resolve pinned
+// runtimes, in order of preference.
+var toolchainManagers = []string{"mise", "asdf"}
+
+// lookPath finds version managers, replaced in tests.
+var lookPath = exec.LookPath
+
+// ProjectToolchain returns the runtime name as the project in dir pins it
+// for mise or asdf, in .tool-versions, mise.toml, or .mise.toml of dir or
+// its nearest parent that pins it, resolved to the binary of the version
+// manager that installed it. It returns nil if the project doesn't pin
+// the runtime, and an error if it does but neither manager can locate it.
+func ProjectToolchain(ctx context.Context, dir, name string) (*Runtime, error) {
+	version, file, err := pinnedVersion(dir, name)
+	if err != nil || version == "" {
+		return nil, err
+	}
+
+	var errs []string
+	for _, manager := range toolchainManagers {
+		path, err := lookPath(manager)
+		if err != nil {
+			continue
+		}
+		// Run in the project, so the manager reads the same pin.
+		cmd := exec.CommandContext(ctx, path, "which", name)
+		cmd.Dir = dir
+		out, err := cmd.Output()
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", manager, execError(err)))
+			continue
+		}
+		if bin := strings.TrimSpace(string(out)); bin != "" {
+			return &Runtime{Name: name, Version: version, Bin: bin}, nil
+		}
+	}
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("%s pins %s %s, but neither mise nor asdf is installed", file, name, version)
+	}
+	return nil, fmt.Errorf("%s pins %s %s, but it couldn't be located: %s", file, name, version, strings.Join(errs, "; "))
+}
+
+// pinnedVersion returns the version of the runtime name pinned for dir
+// and the file pinning it, walking up from dir to the first directory
+// whose version files mention it.
+func pinnedVersion(dir, name string) (version, file string, err error) {
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return "", "", err
+	}
+	for {
+		for _, base := range []string{"mise.toml", ".mise.toml", ".tool-versions"} {
+			path := filepath.Join(dir, base)
+			var version string
+			var err error
+			if base == ".tool-versions" {
+				version, err = toolVersionsEntry(path, name)
+			} else {
+				version, err = miseEntry(path, name)
+			}
+			if err != nil {
+				return "", "", err
+			}
+			if version != "" {
+				return version, path, nil
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", nil
+		}
+		dir = parent
+	}
+}
+
+// toolVersionsEntry returns the first version the asdf .tool-versions
+// file at path lists for the runtime name, or "" if it has none.
+func toolVersionsEntry(path, name string) (string, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && isAlias(fields[0], name) {
+			return fields[1], nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("reading %s: %w", path, err)
+	}
+	return "", nil
+}
+
+// miseEntry returns the version the [tools] table of the mise config at
+// path pins the runtime name to, or "" if it doesn't. A list of versions
+// pins the first, and a table its "version".
+func miseEntry(path, name string) (string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	var cfg struct {
+		Tools map[string]any `toml:"tools"`
+	}
+	if err := toml.Unmarshal(data, &cfg); err != nil {
+		return "", fmt.Errorf("parsing %s: %w", path, err)
+	}
+	for tool, v := range cfg.Tools {
+		if !isAlias(tool, name) {
+			continue
+		}
+		switch v := v.(type) {
+		case string:
+			return v, nil
+		case []any:
+			if len(v) > 0 {
+				s, _ := v[0].(string)
+				return s, nil
+			}
+		case map[string]any:
+			s, _ := v["version"].(string)
+			return s, nil
+		}
+	}
+	return "", nil
+}
+
+// isAlias reports whether tool names the runtime name.
+func isAlias(tool, name string) bool {
+	for _, alias := range toolAliases[name] {
+		if tool == alias {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package runtimes
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestPinnedVersion(t *testing.T) {
+	tests := map[string]struct {
+		files map[string]string // relative path → content
+		name  string
+		want  string
+	}{
+		"tool-versions": {
+			files: map[string]string{".tool-versions": "# toolchain\nnodejs 20.11.0 18.19.0\npython 3.12.1\n"},
+			name:  Node,
+			want:  "20.11.0",
+		},
+		"mise.toml": {
+			files: map[string]string{"mise.toml": "[tools]\npython = \"3.11\"\ngo = [\"1.22.0\", \"1.21\"]\n"},
+			name:  Go,
+			want:  "1.22.0",
+		},
+		"mise table": {
+			files: map[string]string{".mise.toml": "[tools]\nnode = { version = \"22\" }\n"},
+			name:  Node,
+			want:  "22",
+		},
+		"mise.toml before tool-versions": {
+			files: map[string]string{"mise.toml": "[tools]\nnode = \"22\"\n", ".tool-versions": "nodejs 20\n"},
+			name:  Node,
+			want:  "22",
+		},
+		"nearest parent pinning it": {
+			files: map[string]string{".tool-versions": "golang 1.22.0\n", "app/.tool-versions": "nodejs 20\n"},
+			name:  Go,
+			want:  "1.22.0",
+		},
+		"not pinned": {
+			files: map[string]string{".tool-versions": "nodejs 20\n"},
+			name:  Python,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			root := t.TempDir()
+			project := filepath.Join(root, "app")
+			if err := os.MkdirAll(project, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			for rel, content := range tc.files {
+				if err := os.WriteFile(filepath.Join(root, rel), []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, _, err := pinnedVersion(project, tc.name)
+			if err != nil {
+				t.Fatalf("pinnedVersion() error = %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("pinnedVersion() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestProjectToolchain(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script")
+	}
+	project := t.TempDir()
+	if err := os.WriteFile(filepath.Join(project, ".tool-versions"), []byte("nodejs 20.11.0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mise := filepath.Join(t.TempDir(), "mise")
+	script := "#!/bin/sh\n[ \"$1 $2\" = \"which node\" ] && echo /mise/installs/node/20.11.0/bin/node\n"
+	if err := os.WriteFile(mise, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		managers map[string]string
+		want     string
+		wantErr  bool
+	}{
+		"mise locates it": {managers: map[string]string{"mise": mise}, want: "/mise/installs/node/20.11.0/bin/node"},
+		"no manager":      {wantErr: true},
+		"manager fails":   {managers: map[string]string{"mise": "false"}, wantErr: true},
+		"asdf after mise": {managers: map[string]string{"mise": "false", "asdf": mise}, want: "/mise/installs/node/20.11.0/bin/node"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			orig := lookPath
+			lookPath = func(file string) (string, error) {
+				if path, ok := tc.managers[file]; ok {
+					return path, nil
+				}
+				return "", errors.New("not found")
+			}
+			t.Cleanup(func() { lookPath = orig })
+
+			rt, err := ProjectToolchain(context.Background(), project, Node)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ProjectToolchain() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if rt.Bin != tc.want || rt.Version != "20.11.0" {
+				t.Errorf("ProjectToolchain() = %+v, want %s at 20.11.0", rt, tc.want)
+			}
+		})
+	}
+
+	rt, err := ProjectToolchain(context.Background(), project, Python)
+	if rt != nil || err != nil {
+		t.Errorf("ProjectToolchain() of an unpinned runtime = %+v, %v, want nil", rt, err)
+	}
+}
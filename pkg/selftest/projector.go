@@ -0,0 +1,92 @@
+package selftest
+
+import (
+	"path/filepath"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/mcp"
+	"github.com/agentpkg/agentpkg/pkg/projector"
+	"github.com/agentpkg/agentpkg/pkg/skill"
+)
+
+// Agent is the hidden agent the self-test projects to. It isn't listed
+// among the registered agents, so it is only used when named explicitly.
+const Agent = "apkg-selftest"
+
+// agentDir is the project directory the test projector writes to.
+const agentDir = ".apkg-selftest"
+
+func init() {
+	projector.RegisterHiddenProjector(Agent, &testProjector{
+		sp: projector.SkillProjector{AgentDir: agentDir},
+	})
+}
+
+// mcpFormat marks remote servers with their transport, like most agents.
+var mcpFormat = projector.MCPFormat{
+	Types: map[string]string{
+		config.TransportHTTP: "http",
+		config.TransportSSE:  "sse",
+	},
+}
+
+// testProjector symlinks skills like the real agents do and writes MCP
+// servers to <projectDir>/.apkg-selftest/mcp.json, in the mcpServers
+// layout most agents share.
+type testProjector struct {
+	sp projector.SkillProjector
+}
+
+var _ projector.Projector = &testProjector{}
+
+func (p *testProjector) GitignoreEntries() []string {
+	return []string{agentDir + "/"}
+}
+
+func (p *testProjector) Targets(opts projector.ProjectionOpts) (projector.Targets, error) {
+	return projector.Targets{SkillsDir: p.sp.SkillsDir(opts), MCPConfig: mcpConfigPath(opts.ProjectDir), MCPPointer: "/mcpServers"}, nil
+}
+
+func (p *testProjector) SupportsSkills() bool {
+	return true
+}
+
+func (p *testProjector) ProjectSkills(opts projector.ProjectionOpts, packages []skill.Skill) error {
+	return p.sp.ProjectSkills(opts, packages)
+}
+
+func (p *testProjector) UnprojectSkills(opts projector.ProjectionOpts, names []string) error {
+	return p.sp.UnprojectSkills(opts, names)
+}
+
+func (p *testProjector) SupportsMCPServers() bool {
+	return true
+}
+
+func (p *testProjector) MCPLimits() projector.Limits {
+	return projector.Limits{}
+}
+
+func (p *testProjector) MCPFormat() projector.MCPFormat {
+	return mcpFormat
+}
+
+func (p *testProjector) ProjectMCPServers(opts projector.ProjectionOpts, servers []mcp.MCPServer) error {
+	return projector.UpdateJsonConfig(opts, mcpConfigPath(opts.ProjectDir), func(config map[string]any) error {
+		mcpServers := projector.GetOrCreateMap(config, "mcpServers")
+		return projector.SetMCPServers(opts, mcpFormat, mcpConfigPath(opts.ProjectDir), "/mcpServers", mcpServers, servers)
+	})
+}
+
+func (p *testProjector) UnprojectMCPServers(opts projector.ProjectionOpts, names []string) error {
+	return projector.UpdateJsonConfig(opts, mcpConfigPath(opts.ProjectDir), func(config map[string]any) error {
+		if mcpServers, ok := config["mcpServers"].(map[string]any); ok {
+			projector.DeleteMCPServers(opts, mcpConfigPath(opts.ProjectDir), "/mcpServers", mcpServers, names)
+		}
+		return nil
+	})
+}
+
+func mcpConfigPath(projectDir string) string {
+	return filepath.Join(projectDir, agentDir, "mcp.json")
+}
@@ -0,0 +1,204 @@
+// Package selftest exercises the install pipeline end to end in a scratch
+// directory: it creates a local skill and a fake MCP server, installs them
+// into a project, checks their projections for a hidden test agent, and
+// removes them again. Packagers and users run it with apkg selftest to
+// validate an installation.
+package selftest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/installer"
+	"github.com/agentpkg/agentpkg/pkg/projector"
+	"github.com/agentpkg/agentpkg/pkg/skill"
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+const (
+	skillName  = "selftest-skill"
+	serverName = "selftest-server"
+)
+
+// Step is the outcome of one stage of the self-test.
+type Step struct {
+	Name string
+	Err  error
+}
+
+// Run runs the self-test in dir, which should be empty, calling report
+// with the outcome of each step. It stops at the first failing step and
+// returns its error.
+func Run(ctx context.Context, dir string, report func(Step)) error {
+	r := &run{
+		dir:        dir,
+		projectDir: filepath.Join(dir, "project"),
+		skillDir:   filepath.Join(dir, "skills", skillName),
+		serverPath: filepath.Join(dir, "bin", serverName),
+	}
+	r.inst = &installer.Installer{
+		Store:      store.New(filepath.Join(dir, "store")),
+		ProjectDir: r.projectDir,
+		Agents:     []string{Agent},
+	}
+
+	steps := []struct {
+		name string
+		fn   func(context.Context) error
+	}{
+		{"create fixtures", r.createFixtures},
+		{"install", r.install},
+		{"verify projections", r.verifyProjected},
+		{"remove", r.remove},
+		{"verify removal", r.verifyRemoved},
+	}
+	for _, step := range steps {
+		err := step.fn(ctx)
+		if report != nil {
+			report(Step{Name: step.name, Err: err})
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", step.name, err)
+		}
+	}
+	return nil
+}
+
+type run struct {
+	dir        string
+	projectDir string
+	skillDir   string
+	serverPath string
+	inst       *installer.Installer
+}
+
+// createFixtures writes the skill, the fake server, and a project
+// manifest declaring both.
+func (r *run) createFixtures(_ context.Context) error {
+	for _, d := range []string{r.projectDir, r.skillDir, filepath.Dir(r.serverPath)} {
+		if err := os.MkdirAll(d, 0o755); err != nil {
+			return fmt.Errorf("creating %s: %w", d, err)
+		}
+	}
+
+	content := "---\nname: " + skillName + "\ndescription: apkg self-test skill\n---\n# " + skillName + "\n"
+	if err := os.WriteFile(filepath.Join(r.skillDir, "SKILL.md"), []byte(content), 0o644); err != nil {
+		return fmt.Errorf("writing skill: %w", err)
+	}
+	if err := os.WriteFile(r.serverPath, []byte("#!/bin/sh\nexit 0\n"), 0o755); err != nil {
+		return fmt.Errorf("writing MCP server: %w", err)
+	}
+
+	cfg := &config.Config{
+		Project: config.ProjectConfig{Name: "apkg-selftest"},
+		Skills: map[string]config.SkillSource{
+			skillName: {Path: r.skillDir},
+		},
+		MCPServers: map[string]config.MCPSource{
+			serverName: {
+				Transport:               "stdio",
+				UnmanagedStdioMCPConfig: &config.UnmanagedStdioMCPConfig{Command: r.serverPath},
+				LocalMCPConfig:          &config.LocalMCPConfig{Args: []string{"--selftest"}},
+			},
+		},
+	}
+	return config.SaveFile(filepath.Join(r.projectDir, config.ManifestFileName), cfg)
+}
+
+// install installs the manifest the way apkg install does and writes the
+// lockfile.
+func (r *run) install(ctx context.Context) error {
+	cfg, err := config.LoadFile(filepath.Join(r.projectDir, config.ManifestFileName))
+	if err != nil {
+		return err
+	}
+	lf, err := r.inst.InstallAll(ctx, cfg, nil)
+	if err != nil {
+		return err
+	}
+	if len(lf.Skills) != 1 || len(lf.MCPServers) != 1 {
+		return fmt.Errorf("lockfile has %d skills and %d MCP servers, want 1 of each", len(lf.Skills), len(lf.MCPServers))
+	}
+	return config.SaveLockFile(filepath.Join(r.projectDir, config.LockFileName), lf)
+}
+
+// verifyProjected checks that the skill link loads the skill and that the
+// agent config runs the fake server.
+func (r *run) verifyProjected(_ context.Context) error {
+	targets, err := r.targets()
+	if err != nil {
+		return err
+	}
+
+	s, err := skill.Load(filepath.Join(targets.SkillsDir, skillName))
+	if err != nil {
+		return fmt.Errorf("loading projected skill: %w", err)
+	}
+	if s.Name() != skillName {
+		return fmt.Errorf("projected skill is named %q, want %q", s.Name(), skillName)
+	}
+
+	server, err := projectedServer(targets.MCPConfig)
+	if err != nil {
+		return err
+	}
+	if server == nil {
+		return fmt.Errorf("MCP server %q is missing from %s", serverName, targets.MCPConfig)
+	}
+	if server["command"] != r.serverPath {
+		return fmt.Errorf("MCP server %q runs %v, want %s", serverName, server["command"], r.serverPath)
+	}
+	return nil
+}
+
+func (r *run) remove(_ context.Context) error {
+	if err := r.inst.RemoveSkill(skillName, ""); err != nil {
+		return err
+	}
+	return r.inst.RemoveMCPServers([]string{serverName})
+}
+
+// verifyRemoved checks that neither projection is left behind.
+func (r *run) verifyRemoved(_ context.Context) error {
+	targets, err := r.targets()
+	if err != nil {
+		return err
+	}
+
+	link := filepath.Join(targets.SkillsDir, skillName)
+	if _, err := os.Lstat(link); !os.IsNotExist(err) {
+		return fmt.Errorf("skill link %s was not removed", link)
+	}
+
+	server, err := projectedServer(targets.MCPConfig)
+	if err != nil {
+		return err
+	}
+	if server != nil {
+		return fmt.Errorf("MCP server %q is still in %s", serverName, targets.MCPConfig)
+	}
+	return nil
+}
+
+func (r *run) targets() (projector.Targets, error) {
+	proj, ok := projector.GetProjector(Agent)
+	if !ok {
+		return projector.Targets{}, fmt.Errorf("no projector registered for agent %q", Agent)
+	}
+	return proj.Targets(projector.ProjectionOpts{ProjectDir: r.projectDir})
+}
+
+// projectedServer returns the fake server's entry in the agent config at
+// path, or nil if it has none.
+func projectedServer(path string) (map[string]any, error) {
+	cfg, err := projector.ReadJsonConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	servers, _ := cfg["mcpServers"].(map[string]any)
+	server, _ := servers[serverName].(map[string]any)
+	return server, nil
+}
@@ -0,0 +1,68 @@
+package selftest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/projector"
+)
+
+func TestRun(t *testing.T) {
+	tests := map[string]struct {
+		setup     func(t *testing.T, dir string)
+		wantSteps []string
+		wantErr   bool
+	}{
+		"all steps pass": {
+			wantSteps: []string{"create fixtures", "install", "verify projections", "remove", "verify removal"},
+		},
+		"stops at the first failing step": {
+			setup: func(t *testing.T, dir string) {
+				if err := os.WriteFile(filepath.Join(dir, "project"), nil, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantSteps: []string{"create fixtures"},
+			wantErr:   true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tc.setup != nil {
+				tc.setup(t, dir)
+			}
+
+			var steps []string
+			var failed error
+			err := Run(context.Background(), dir, func(s Step) {
+				steps = append(steps, s.Name)
+				if s.Err != nil {
+					failed = s.Err
+				}
+			})
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Run() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if tc.wantErr && failed == nil {
+				t.Error("no step reported the failure")
+			}
+			if !slices.Equal(steps, tc.wantSteps) {
+				t.Errorf("steps = %v, want %v", steps, tc.wantSteps)
+			}
+		})
+	}
+}
+
+func TestAgentHidden(t *testing.T) {
+	if slices.Contains(projector.RegisteredAgents(), Agent) {
+		t.Errorf("RegisteredAgents() lists %q", Agent)
+	}
+	if err := projector.ValidateAgents([]string{Agent}); err != nil {
+		t.Errorf("ValidateAgents(%q) error = %v", Agent, err)
+	}
+}
@@ -0,0 +1,133 @@
+package serve
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/agentpkg/agentpkg/pkg/store"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// advertFile, in the store root, records where the most recently started
+// apkg serve listens, so installs point agents at the port (or socket) the
+// proxy actually uses rather than DefaultPort.
+const advertFile = "serve.toml"
+
+// Endpoint is where a proxy listens: a localhost TCP port, a Unix domain
+// socket, or both.
+type Endpoint struct {
+	// Port is the TCP port, or 0 if the proxy doesn't listen on TCP.
+	Port int `toml:"port"`
+	// Socket is the path of the Unix domain socket, if any.
+	Socket string `toml:"socket,omitempty"`
+}
+
+type advertisement struct {
+	Endpoint
+	PID int `toml:"pid,omitempty"`
+}
+
+// defaultEndpoint is assumed when no proxy has advertised one.
+var defaultEndpoint = Endpoint{Port: DefaultPort}
+
+// Advertise records ep as where apkg serve listens and returns the
+// previously recorded endpoint, so the caller can tell when agent configs
+// written before point elsewhere.
+func Advertise(st store.Store, ep Endpoint) (Endpoint, error) {
+	prev := Advertised(st)
+
+	data, err := toml.Marshal(advertisement{Endpoint: ep, PID: os.Getpid()})
+	if err != nil {
+		return prev, fmt.Errorf("marshaling %s: %w", advertFile, err)
+	}
+	if err := st.WriteFile(data, 0o644, advertFile); err != nil {
+		return prev, fmt.Errorf("writing %s: %w", advertFile, err)
+	}
+	return prev, nil
+}
+
+// Advertised returns the endpoint recorded by the last apkg serve to
+// start, or DefaultPort if none has (or the record is unreadable).
+func Advertised(st store.Store) Endpoint {
+	data, err := st.ReadFile(advertFile)
+	if err != nil {
+		return defaultEndpoint
+	}
+
+	var ad advertisement
+	if err := toml.Unmarshal(data, &ad); err != nil || ad.Port < 0 || (ad.Port == 0 && ad.Socket == "") {
+		return defaultEndpoint
+	}
+	return ad.Endpoint
+}
+
+func (ep Endpoint) String() string {
+	switch {
+	case ep.Socket == "":
+		return fmt.Sprintf("port %d", ep.Port)
+	case ep.Port == 0:
+		return "socket " + ep.Socket
+	default:
+		return fmt.Sprintf("port %d and socket %s", ep.Port, ep.Socket)
+	}
+}
+
+// URL returns the base URL agents reach the proxy at over TCP. A proxy
+// listening only on a socket has no TCP URL; the default port's is
+// returned so configs still name the proxy.
+func (ep Endpoint) URL() string {
+	port := ep.Port
+	if port == 0 {
+		port = DefaultPort
+	}
+	return fmt.Sprintf("http://localhost:%d", port)
+}
+
+// SocketURL returns the http+unix:// base URL agents reach the proxy at
+// over its socket, with the socket path escaped as the host, or "" if it
+// doesn't listen on one.
+func (ep Endpoint) SocketURL() string {
+	if ep.Socket == "" {
+		return ""
+	}
+	return "http+unix://" + url.PathEscape(ep.Socket)
+}
+
+// dial connects to the proxy, preferring its socket.
+func (ep Endpoint) dial(ctx context.Context) (net.Conn, error) {
+	var d net.Dialer
+	if ep.Socket != "" {
+		return d.DialContext(ctx, "unix", ep.Socket)
+	}
+	return d.DialContext(ctx, "tcp", fmt.Sprintf("127.0.0.1:%d", ep.Port))
+}
+
+// client returns an HTTP client whose requests reach the proxy whatever
+// host their URL names.
+func (ep Endpoint) client(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return ep.dial(ctx)
+			},
+		},
+	}
+}
+
+// Reachable reports whether a proxy is accepting connections at ep.
+func (ep Endpoint) Reachable() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	conn, err := ep.dial(ctx)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
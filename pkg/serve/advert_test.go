@@ -0,0 +1,83 @@
+package serve
+
+import (
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/store/storetest"
+)
+
+func TestAdvertise(t *testing.T) {
+	st := storetest.NewMemory()
+
+	if got := Advertised(st); got != defaultEndpoint {
+		t.Errorf("Advertised() before serve ran = %v, want %v", got, defaultEndpoint)
+	}
+
+	for _, step := range []struct {
+		ep       Endpoint
+		wantPrev Endpoint
+	}{
+		{ep: Endpoint{Port: 19600}, wantPrev: defaultEndpoint},
+		{ep: Endpoint{Port: 19600}, wantPrev: Endpoint{Port: 19600}},
+		{ep: Endpoint{Socket: "/run/apkg.sock"}, wantPrev: Endpoint{Port: 19600}},
+	} {
+		prev, err := Advertise(st, step.ep)
+		if err != nil {
+			t.Fatalf("Advertise(%v) error: %v", step.ep, err)
+		}
+		if prev != step.wantPrev {
+			t.Errorf("Advertise(%v) = %v, want %v", step.ep, prev, step.wantPrev)
+		}
+		if got := Advertised(st); got != step.ep {
+			t.Errorf("Advertised() = %v, want %v", got, step.ep)
+		}
+	}
+}
+
+func TestAdvertisedInvalid(t *testing.T) {
+	tests := map[string]string{
+		"bad port type": "port = 'x'",
+		"no listener":   "port = 0",
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			st := storetest.NewMemory()
+			if err := st.WriteFile([]byte(data), 0o644, advertFile); err != nil {
+				t.Fatal(err)
+			}
+			if got := Advertised(st); got != defaultEndpoint {
+				t.Errorf("Advertised() = %v, want %v", got, defaultEndpoint)
+			}
+		})
+	}
+}
+
+func TestEndpointURLs(t *testing.T) {
+	tests := map[string]struct {
+		ep            Endpoint
+		wantURL       string
+		wantSocketURL string
+	}{
+		"tcp": {
+			ep:      Endpoint{Port: 19600},
+			wantURL: "http://localhost:19600",
+		},
+		"socket only": {
+			ep:            Endpoint{Socket: "/run/user/1000/apkg.sock"},
+			wantURL:       "http://localhost:19513",
+			wantSocketURL: "http+unix://%2Frun%2Fuser%2F1000%2Fapkg.sock",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := tc.ep.URL(); got != tc.wantURL {
+				t.Errorf("URL() = %q, want %q", got, tc.wantURL)
+			}
+			if got := tc.ep.SocketURL(); got != tc.wantSocketURL {
+				t.Errorf("SocketURL() = %q, want %q", got, tc.wantSocketURL)
+			}
+		})
+	}
+}
@@ -2,13 +2,18 @@ package serve
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
 	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/agentpkg/agentpkg/pkg/container"
@@ -27,6 +32,9 @@ const (
 	containerPrefix    = "apkg-"
 	healthTimeout      = 30 * time.Second
 	healthPollInterval = 250 * time.Millisecond
+	// healthProbeTimeout bounds the check of whether a container that
+	// failed a request still accepts connections.
+	healthProbeTimeout = time.Second
 
 	// DefaultIdleTimeout is how long a container can be idle before it is
 	// automatically stopped. Containers are restarted on the next request.
@@ -46,24 +54,78 @@ type managedContainer struct {
 	env           map[string]string
 	args          []string
 	volumes       []string
-	network       string
+	// project is the project whose volumes the container mounts, if any.
+	project string
+	network string
+	aliases []string
+	// eager containers are started with the proxy and never stopped for
+	// being idle.
+	eager bool
+	// installed is when the server's config was last written to the
+	// store, which picks the install of an eager server to start.
+	installed time.Time
 
 	mu       sync.Mutex
 	status   containerStatus
+	startErr error                  // why the last start failed, nil once running
 	proxy    *httputil.ReverseProxy // cached proxy, created after container starts
-	lastUsed time.Time              // updated on each proxied request
+	lastUsed time.Time              // updated when proxied requests start and finish
+	// inFlight counts proxied requests still open, including SSE streams,
+	// which keep the container from being reaped however long they last.
+	inFlight int
 }
 
-// containerName returns the docker/podman container name used for this server.
+// claimedHostPort returns the host port the container always listens on,
+// which is only known up front with host networking; other containers get
+// a free port when they start.
+func (mc *managedContainer) claimedHostPort() (int, bool) {
+	return mc.containerPort, mc.network == "host"
+}
+
+// containerName returns the docker/podman container name used for this
+// server. Containers mounting a project's volumes are told apart by a hash
+// of the project and volumes.
 func (mc *managedContainer) containerName() string {
-	return containerPrefix + mc.name
+	if len(mc.volumes) == 0 {
+		return containerPrefix + mc.name
+	}
+	sum := sha256.Sum256([]byte(mc.project + "\n" + strings.Join(mc.volumes, "\n")))
+	return containerPrefix + mc.name + "-" + hex.EncodeToString(sum[:4])
 }
 
-// touch updates the last-used timestamp under the lock.
-func (mc *managedContainer) touch() {
+// withVolumes returns a stopped copy of mc that mounts the volumes of
+// project.
+func (mc *managedContainer) withVolumes(project string, volumes []string) *managedContainer {
+	return &managedContainer{
+		name:          mc.name,
+		image:         mc.image,
+		containerPort: mc.containerPort,
+		env:           mc.env,
+		args:          mc.args,
+		volumes:       volumes,
+		project:       project,
+		network:       mc.network,
+		aliases:       mc.aliases,
+		eager:         mc.eager,
+		installed:     mc.installed,
+	}
+}
+
+// begin records the start of a proxied request. The returned func records
+// its end and must be called once the response is done; the idle timeout
+// then counts from that moment.
+func (mc *managedContainer) begin() (end func()) {
 	mc.mu.Lock()
+	mc.inFlight++
 	mc.lastUsed = time.Now()
 	mc.mu.Unlock()
+
+	return func() {
+		mc.mu.Lock()
+		mc.inFlight--
+		mc.lastUsed = time.Now()
+		mc.mu.Unlock()
+	}
 }
 
 // ensureRunning is idempotent: if the container is already running it
@@ -72,10 +134,11 @@ func (mc *managedContainer) touch() {
 // starts it, waits for TCP readiness, and builds a cached reverse proxy.
 //
 // Concurrent callers block on the mutex — only the first one starts the
-// container.
-func (mc *managedContainer) ensureRunning(ctx context.Context, engine *container.Engine) error {
+// container. The proxy reaches the container through transport.
+func (mc *managedContainer) ensureRunning(ctx context.Context, engine container.Engine, transport http.RoundTripper) (err error) {
 	mc.mu.Lock()
 	defer mc.mu.Unlock()
+	defer func() { mc.startErr = err }()
 
 	if mc.status == statusRunning {
 		return nil
@@ -88,6 +151,12 @@ func (mc *managedContainer) ensureRunning(ctx context.Context, engine *container
 		return err
 	}
 
+	// The network may have been removed since install.
+	if err := engine.EnsureNetwork(ctx, mc.network); err != nil {
+		mc.status = statusStopped
+		return err
+	}
+
 	// Clean up any stale container with the same name.
 	_ = engine.Stop(ctx, mc.containerName())
 
@@ -106,10 +175,11 @@ func (mc *managedContainer) ensureRunning(ctx context.Context, engine *container
 	}
 
 	opts := &container.RunOpts{
-		Env:     mc.env,
-		Args:    mc.args,
-		Volumes: mc.volumes,
-		Network: mc.network,
+		Env:            mc.env,
+		Args:           mc.args,
+		Volumes:        mc.volumes,
+		Network:        mc.network,
+		NetworkAliases: mc.aliases,
 	}
 	if _, err := engine.Run(ctx, mc.containerName(), mc.image, mc.hostPort, mc.containerPort, opts); err != nil {
 		mc.status = statusStopped
@@ -122,14 +192,14 @@ func (mc *managedContainer) ensureRunning(ctx context.Context, engine *container
 		return fmt.Errorf("container %q did not become ready: %w", mc.name, err)
 	}
 
-	mc.proxy = mc.buildProxy(engine)
+	mc.proxy = mc.buildProxy(transport)
 	mc.lastUsed = time.Now()
 	mc.status = statusRunning
 	return nil
 }
 
 // stopLocked stops the container and resets state. Must be called with mc.mu held.
-func (mc *managedContainer) stopLocked(ctx context.Context, engine *container.Engine) error {
+func (mc *managedContainer) stopLocked(ctx context.Context, engine container.Engine) error {
 	var err error
 	if mc.status != statusStopped {
 		err = engine.Stop(ctx, mc.containerName())
@@ -139,13 +209,14 @@ func (mc *managedContainer) stopLocked(ctx context.Context, engine *container.En
 	return err
 }
 
-// stopIfIdle stops the container if it has been idle longer than timeout.
-// Returns true if the container was stopped.
-func (mc *managedContainer) stopIfIdle(ctx context.Context, engine *container.Engine, timeout time.Duration) bool {
+// stopIfIdle stops the container if it has been idle longer than timeout,
+// unless it is eager or serving requests. Returns true if the container
+// was stopped.
+func (mc *managedContainer) stopIfIdle(ctx context.Context, engine container.Engine, timeout time.Duration) bool {
 	mc.mu.Lock()
 	defer mc.mu.Unlock()
 
-	if mc.status != statusRunning {
+	if mc.status != statusRunning || mc.eager || mc.inFlight > 0 {
 		return false
 	}
 	if time.Since(mc.lastUsed) <= timeout {
@@ -161,9 +232,11 @@ func (mc *managedContainer) stopIfIdle(ctx context.Context, engine *container.En
 
 // buildProxy creates an httputil.ReverseProxy targeting the container's
 // host port. The proxy strips apkg routing headers before forwarding,
-// supports SSE streaming, and marks the container as stopped on
-// connection errors so the next request triggers a restart.
-func (mc *managedContainer) buildProxy(engine *container.Engine) *httputil.ReverseProxy {
+// supports SSE streaming (exempting streams from the request timeout), and
+// marks the container as stopped on connection errors and timeouts so the
+// next request triggers a restart. Oversized request bodies are rejected
+// without touching the container's state.
+func (mc *managedContainer) buildProxy(transport http.RoundTripper) *httputil.ReverseProxy {
 	target := &url.URL{
 		Scheme: "http",
 		Host:   fmt.Sprintf("127.0.0.1:%d", mc.hostPort),
@@ -177,22 +250,59 @@ func (mc *managedContainer) buildProxy(engine *container.Engine) *httputil.Rever
 			// Strip apkg routing headers; user headers pass through.
 			req.Header.Del(MCPServerHeader)
 			req.Header.Del(MCPServerDigestHeader)
+			req.Header.Del(MCPProjectHeader)
 		},
+		Transport: transport,
 		// FlushInterval -1 enables streaming/SSE support.
-		FlushInterval: -1,
+		FlushInterval:  -1,
+		ModifyResponse: exemptStream,
 		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				http.Error(w, fmt.Sprintf("request body exceeds %d bytes", maxBytesErr.Limit),
+					http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			status := http.StatusBadGateway
+			if context.Cause(r.Context()) == errRequestTimeout {
+				err = errRequestTimeout
+				status = http.StatusGatewayTimeout
+			}
+
+			// Client cancels, timeouts, and dropped connections leave a
+			// container that still accepts connections running.
+			if r.Context().Err() != nil || !mc.unreachable(err) {
+				log.Printf("proxy error for %q: %v", mc.name, err)
+				http.Error(w, fmt.Sprintf("MCP server %q failed: %v", mc.name, err), status)
+				return
+			}
+
 			log.Printf("proxy error for %q: %v; marking container as stopped", mc.name, err)
 			mc.mu.Lock()
 			mc.status = statusStopped
 			mc.proxy = nil
 			mc.mu.Unlock()
-			http.Error(w, fmt.Sprintf("MCP server %q is unavailable: %v", mc.name, err),
-				http.StatusBadGateway)
+			http.Error(w, fmt.Sprintf("MCP server %q is unavailable: %v", mc.name, err), status)
 		},
 	}
 	return proxy
 }
 
+// unreachable reports whether the container is gone after a request to it
+// failed with err: it refused the connection, or no longer accepts one.
+func (mc *managedContainer) unreachable(err error) bool {
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", mc.hostPort), healthProbeTimeout)
+	if err != nil {
+		return true
+	}
+	conn.Close()
+	return false
+}
+
 // freePort asks the OS for an available TCP port by binding to :0.
 func freePort() (int, error) {
 	l, err := net.Listen("tcp", "127.0.0.1:0")
@@ -232,7 +342,7 @@ func waitForTCP(ctx context.Context, port int) error {
 }
 
 // stopAllContainers stops and removes all managed containers.
-func stopAllContainers(ctx context.Context, engine *container.Engine, containers []*managedContainer) error {
+func stopAllContainers(ctx context.Context, engine container.Engine, containers []*managedContainer) error {
 	var errs []error
 	for _, mc := range containers {
 		mc.mu.Lock()
@@ -250,7 +360,7 @@ func stopAllContainers(ctx context.Context, engine *container.Engine, containers
 // startIdleReaper launches a background goroutine that periodically stops
 // containers that haven't received a request within the idle timeout.
 // It returns when ctx is cancelled.
-func startIdleReaper(ctx context.Context, engine *container.Engine, containers map[containerKey]*managedContainer, idleTimeout time.Duration) {
+func startIdleReaper(ctx context.Context, engine container.Engine, containers func() []*managedContainer, idleTimeout time.Duration) {
 	ticker := time.NewTicker(idleCheckInterval)
 	defer ticker.Stop()
 
@@ -259,7 +369,7 @@ func startIdleReaper(ctx context.Context, engine *container.Engine, containers m
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			for _, mc := range containers {
+			for _, mc := range containers() {
 				mc.stopIfIdle(ctx, engine, idleTimeout)
 			}
 		}
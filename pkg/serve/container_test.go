@@ -1,10 +1,13 @@
 package serve
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"testing"
 	"time"
+
+	"github.com/agentpkg/agentpkg/pkg/container"
 )
 
 func TestContainerName(t *testing.T) {
@@ -68,22 +71,6 @@ func TestManagedContainerInitialStatus(t *testing.T) {
 	}
 }
 
-func TestTouch(t *testing.T) {
-	mc := &managedContainer{name: "test"}
-
-	before := time.Now()
-	mc.touch()
-	after := time.Now()
-
-	mc.mu.Lock()
-	lastUsed := mc.lastUsed
-	mc.mu.Unlock()
-
-	if lastUsed.Before(before) || lastUsed.After(after) {
-		t.Errorf("lastUsed = %v, want between %v and %v", lastUsed, before, after)
-	}
-}
-
 func TestStopIfIdleNotRunning(t *testing.T) {
 	mc := &managedContainer{
 		name:   "test",
@@ -106,3 +93,38 @@ func TestStopIfIdleNotYetIdle(t *testing.T) {
 		t.Error("stopIfIdle returned true for recently used container")
 	}
 }
+
+func TestStopIfIdleInFlight(t *testing.T) {
+	engine := &container.Fake{}
+	mc := &managedContainer{name: "test", status: statusRunning}
+
+	// A stream opened long ago is still in flight.
+	end := mc.begin()
+	mc.lastUsed = time.Now().Add(-time.Hour)
+	if mc.stopIfIdle(context.Background(), engine, time.Millisecond) {
+		t.Fatal("stopIfIdle stopped a container with an open stream")
+	}
+
+	// The idle timeout counts from the end of the stream.
+	end()
+	if mc.stopIfIdle(context.Background(), engine, time.Hour) {
+		t.Fatal("stopIfIdle stopped a container whose stream just ended")
+	}
+	time.Sleep(2 * time.Millisecond)
+	if !mc.stopIfIdle(context.Background(), engine, time.Millisecond) {
+		t.Error("stopIfIdle didn't stop a container once idle")
+	}
+}
+
+func TestStopIfIdleEager(t *testing.T) {
+	mc := &managedContainer{
+		name:     "test",
+		status:   statusRunning,
+		eager:    true,
+		lastUsed: time.Now().Add(-time.Hour),
+	}
+	// Should return false since eager containers keep running while idle.
+	if mc.stopIfIdle(nil, nil, time.Millisecond) {
+		t.Error("stopIfIdle returned true for eager container")
+	}
+}
@@ -0,0 +1,81 @@
+package serve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/agentpkg/agentpkg/pkg/container"
+	"github.com/agentpkg/agentpkg/pkg/source"
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+// GCResult reports what GC removed, or would remove on a dry run.
+type GCResult struct {
+	// Containers are the stopped apkg- containers removed.
+	Containers []string
+	// Images are the images apkg built that no oci/ store entry
+	// references any more.
+	Images []string
+	// Dangling is the number of untagged images apkg built that were
+	// pruned. Dry runs leave it zero.
+	Dangling int
+}
+
+// GC cleans up the container engine state apkg leaves behind: it removes
+// the stopped apkg- containers a crashed proxy or agent didn't, the images
+// apkg built for managed packages (see source.PackageImageSource) that no
+// server in the store's oci/ directory runs any more, and the dangling
+// images rebuilding them left. Running containers and images apkg didn't
+// build are never touched. With dryRun, GC only reports what it would
+// remove.
+func GC(ctx context.Context, st store.Store, engine container.Engine, dryRun bool) (*GCResult, error) {
+	result := &GCResult{}
+	var errs []error
+
+	names, err := engine.StoppedContainers(ctx, containerPrefix)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		if !dryRun {
+			if err := engine.Stop(ctx, name); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		result.Containers = append(result.Containers, name)
+	}
+
+	containers, err := discoverContainers(st)
+	if err != nil {
+		return nil, fmt.Errorf("discovering containers: %w", err)
+	}
+	referenced := make(map[string]bool, len(containers))
+	for _, mc := range containers {
+		referenced[mc.image] = true
+	}
+	images, err := engine.Images(ctx, source.PackageImagePrefix)
+	if err != nil {
+		return nil, err
+	}
+	for _, image := range images {
+		if referenced[image] {
+			continue
+		}
+		if !dryRun {
+			if err := engine.RemoveImage(ctx, image); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		result.Images = append(result.Images, image)
+	}
+
+	if !dryRun {
+		if result.Dangling, err = engine.PruneImages(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return result, errors.Join(errs...)
+}
@@ -0,0 +1,78 @@
+package serve
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/container"
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+func TestGC(t *testing.T) {
+	const (
+		used   = "localhost/apkg-npm-server:1111111111111111"
+		unused = "localhost/apkg-npm-server:2222222222222222"
+	)
+
+	tests := map[string]struct {
+		dryRun       bool
+		wantImages   []string
+		wantDangling int
+	}{
+		"removes": {
+			wantImages:   []string{used, "nginx:latest"},
+			wantDangling: 2,
+		},
+		"dry run": {
+			dryRun:     true,
+			wantImages: []string{used, unused, "nginx:latest"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			st := store.New(t.TempDir())
+			seedOCIStore(t, st, "server", "abc123", `
+transport = "stdio"
+name = "server"
+image = "`+used+`"
+`)
+			engine := &container.Fake{
+				Exited:   map[string]string{"apkg-fetch": "fetch:latest", "other": "nginx:latest"},
+				Dangling: 2,
+			}
+			for _, image := range []string{used, unused} {
+				engine.Build(context.Background(), image, nil)
+			}
+			engine.Pull(context.Background(), "nginx:latest")
+			engine.Run(context.Background(), "apkg-postgres", "postgres-mcp:latest", 0, 8080, nil)
+
+			result, err := GC(context.Background(), st, engine, tc.dryRun)
+			if err != nil {
+				t.Fatalf("GC() error = %v", err)
+			}
+			if !slices.Equal(result.Containers, []string{"apkg-fetch"}) {
+				t.Errorf("Containers = %q, want [apkg-fetch]", result.Containers)
+			}
+			if !slices.Equal(result.Images, []string{unused}) {
+				t.Errorf("Images = %q, want [%s]", result.Images, unused)
+			}
+			if result.Dangling != tc.wantDangling {
+				t.Errorf("Dangling = %d, want %d", result.Dangling, tc.wantDangling)
+			}
+
+			// Running containers and images apkg didn't build are kept.
+			if running := engine.Running(); !slices.Equal(running, []string{"apkg-postgres"}) {
+				t.Errorf("Running() = %q, want [apkg-postgres]", running)
+			}
+			images, _ := engine.Images(context.Background(), "")
+			if !slices.Equal(images, slices.Sorted(slices.Values(tc.wantImages))) {
+				t.Errorf("images left = %q, want %q", images, tc.wantImages)
+			}
+			if _, ok := engine.Exited["apkg-fetch"]; ok == !tc.dryRun {
+				t.Errorf("apkg-fetch removed = %v, want %v", !ok, !tc.dryRun)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package serve
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+)
+
+const (
+	// healthzPath reports that the proxy is up.
+	healthzPath = "/healthz"
+	// readyzPath + name reports whether the named server's container is
+	// ready, starting it if it isn't running.
+	readyzPath = "/readyz/"
+)
+
+// healthzHandler reports that the proxy is alive.
+func (s *Server) healthzHandler(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintln(w, "ok")
+}
+
+// readyzHandler reports whether the named server's container is running
+// (200) or not yet (503). A stopped container is started in the background,
+// so callers can poll until it is ready instead of timing out on their
+// first MCP call while the image pulls. The install is routed like
+// proxied requests (see route), with the digest also taken from the digest
+// query parameter; without either, it is the most recently installed one
+// of those projects share.
+func (s *Server) readyzHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	digest := r.Header.Get(MCPServerDigestHeader)
+	if digest == "" {
+		digest = r.URL.Query().Get("digest")
+	}
+	project := r.Header.Get(MCPProjectHeader)
+	if project != "" {
+		project = filepath.Clean(project)
+	}
+
+	if !s.allowed(name, project) {
+		http.Error(w, fmt.Sprintf("project %q may not access MCP server %q", project, name), http.StatusForbidden)
+		return
+	}
+	mc, ok, err := s.route(name, digest, project)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("looking up the install of MCP server %q: %v", name, err), http.StatusInternalServerError)
+		return
+	}
+	if !ok && digest == "" {
+		mc, ok = s.newestInstall(name)
+	}
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown MCP server %q", name), http.StatusNotFound)
+		return
+	}
+
+	// A start in progress holds the container's lock.
+	if !mc.mu.TryLock() {
+		http.Error(w, "starting", http.StatusServiceUnavailable)
+		return
+	}
+	status, startErr := mc.status, mc.startErr
+	mc.mu.Unlock()
+
+	if status == statusRunning {
+		fmt.Fprintln(w, "ready")
+		return
+	}
+
+	go func() {
+		if err := mc.ensureRunning(s.baseContext(), s.Engine, s.upstreamTransport()); err != nil {
+			log.Printf("failed to start container for %q: %v", name, err)
+		}
+	}()
+
+	if startErr != nil {
+		http.Error(w, fmt.Sprintf("starting (last attempt failed: %v)", startErr), http.StatusServiceUnavailable)
+		return
+	}
+	http.Error(w, "starting", http.StatusServiceUnavailable)
+}
+
+// newestInstall returns the most recently installed container of the
+// named server that isn't a project's own (see route).
+func (s *Server) newestInstall(name string) (*managedContainer, bool) {
+	var newest *managedContainer
+	for _, e := range s.Containers.entries() {
+		if e.key.name == name && e.key.project == "" && (newest == nil || e.mc.installed.After(newest.installed)) {
+			newest = e.mc
+		}
+	}
+	return newest, newest != nil
+}
+
+// baseContext returns the context containers are started with outside of
+// a request.
+func (s *Server) baseContext() context.Context {
+	if s.ctx != nil {
+		return s.ctx
+	}
+	return context.Background()
+}
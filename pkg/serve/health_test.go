@@ -0,0 +1,176 @@
+package serve
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agentpkg/agentpkg/pkg/container"
+)
+
+func TestHealthzHandler(t *testing.T) {
+	srv := &Server{Containers: testRegistry(t, nil)}
+
+	rec := httptest.NewRecorder()
+	srv.healthzHandler(rec, httptest.NewRequest(http.MethodGet, healthzPath, nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestReadyzHandler(t *testing.T) {
+	now := time.Now()
+
+	tests := map[string]struct {
+		name       string
+		digest     string
+		project    string
+		setup      func(running, old *managedContainer)
+		wantStatus int
+		wantBody   string
+		// wantMounts is set if the project's own container, mounting its
+		// volumes, is the one reached.
+		wantMounts bool
+	}{
+		"unknown server": {
+			name:       "unknown",
+			wantStatus: http.StatusNotFound,
+		},
+		"newest install running": {
+			name:       "postgres",
+			wantStatus: http.StatusOK,
+			wantBody:   "ready",
+		},
+		"older install by digest": {
+			name:       "postgres",
+			digest:     "old",
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   "starting",
+		},
+		"start in progress": {
+			name: "postgres",
+			setup: func(running, old *managedContainer) {
+				running.mu.Lock()
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   "starting",
+		},
+		"last start failed": {
+			name:   "postgres",
+			digest: "old",
+			setup: func(running, old *managedContainer) {
+				old.startErr = errors.New("pull failed")
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   "last attempt failed: pull failed",
+		},
+		"project denied": {
+			name:       "postgres",
+			project:    "/work/website",
+			wantStatus: http.StatusForbidden,
+		},
+		"project's own install": {
+			name:       "postgres",
+			project:    "/work/billing",
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   "starting",
+		},
+		"project's own volumes": {
+			name:       "postgres",
+			project:    "/work/docs",
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   "starting",
+			wantMounts: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			running := &managedContainer{name: "postgres", status: statusRunning, installed: now}
+			old := &managedContainer{name: "postgres", installed: now.Add(-time.Hour)}
+			if tc.setup != nil {
+				tc.setup(running, old)
+			}
+
+			engine := &container.Fake{Errors: map[string]error{"pull": errors.New("pull failed")}}
+			srv := &Server{
+				Engine: engine,
+				Containers: testRegistry(t, map[containerKey]*managedContainer{
+					{name: "postgres", digest: "new"}: running,
+					{name: "postgres", digest: "old"}: old,
+				}),
+			}
+			var routed bool
+			srv.Installs = func(project, name string) (Install, bool, error) {
+				routed = true
+				switch project {
+				case "/work/billing":
+					return Install{Digest: "old"}, true, nil
+				case "/work/docs":
+					return Install{Digest: "new", Volumes: []string{"/data:/data"}}, true, nil
+				}
+				return Install{}, false, nil
+			}
+			if tc.project != "" {
+				srv.Access = map[string][]string{"postgres": {"/work/billing", "/work/docs"}}
+			}
+
+			req := httptest.NewRequest(http.MethodGet, readyzPath+tc.name+"?digest="+tc.digest, nil)
+			req.SetPathValue("name", tc.name)
+			if tc.project != "" {
+				req.Header.Set(MCPProjectHeader, tc.project)
+			}
+			rec := httptest.NewRecorder()
+			srv.readyzHandler(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Errorf("status = %d, want %d (body %q)", rec.Code, tc.wantStatus, rec.Body.String())
+			}
+			if !strings.Contains(rec.Body.String(), tc.wantBody) {
+				t.Errorf("body = %q, want it to contain %q", rec.Body.String(), tc.wantBody)
+			}
+			if denied := tc.wantStatus == http.StatusForbidden; routed == denied {
+				t.Errorf("routed = %v, want a lookup only for allowed requests", routed)
+			}
+			if _, mounted := srv.Containers.get(containerKey{name: "postgres", digest: "new", project: tc.project}); tc.project != "" && mounted != tc.wantMounts {
+				t.Errorf("project container registered = %v, want %v", mounted, tc.wantMounts)
+			}
+		})
+	}
+}
+
+func TestReadyzHandlerStartsContainer(t *testing.T) {
+	mc := &managedContainer{name: "postgres", image: "pg:16"}
+	engine := &container.Fake{Errors: map[string]error{"pull": errors.New("pull failed")}}
+	srv := &Server{
+		Engine:     engine,
+		Containers: testRegistry(t, map[containerKey]*managedContainer{{name: "postgres", digest: "abc"}: mc}),
+	}
+
+	req := httptest.NewRequest(http.MethodGet, readyzPath+"postgres", nil)
+	req.SetPathValue("name", "postgres")
+	srv.readyzHandler(httptest.NewRecorder(), req)
+
+	// The start runs in the background; wait for its attempt.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mc.mu.Lock()
+		startErr := mc.startErr
+		mc.mu.Unlock()
+		if startErr != nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("readyz didn't start the container")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if ops := engine.Ops(); len(ops) == 0 || ops[0] != "pull pg:16" {
+		t.Errorf("engine ops = %v, want a pull of pg:16", ops)
+	}
+}
@@ -0,0 +1,80 @@
+package serve
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	// DefaultDialTimeout bounds connecting to a container.
+	DefaultDialTimeout = 10 * time.Second
+	// DefaultResponseHeaderTimeout bounds how long a container may take to
+	// start responding to a request.
+	DefaultResponseHeaderTimeout = 5 * time.Minute
+	// DefaultRequestTimeout bounds a whole proxied request, except SSE
+	// streams, which stay open as long as the client and container keep
+	// them open.
+	DefaultRequestTimeout = 10 * time.Minute
+	// DefaultMaxRequestBody is the largest request body forwarded to a
+	// container.
+	DefaultMaxRequestBody = 10 << 20
+)
+
+// errRequestTimeout is the cancellation cause of a request that exceeded
+// Server.RequestTimeout.
+var errRequestTimeout = errors.New("request timed out")
+
+// requestTimerKey is the context key of the timer enforcing a request's
+// timeout, which the proxy stops once the response turns out to be an
+// SSE stream.
+type requestTimerKey struct{}
+
+// upstreamTransport returns the transport the proxies use to reach
+// containers, built once from the server's timeouts.
+func (s *Server) upstreamTransport() http.RoundTripper {
+	s.transportOnce.Do(func() {
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		if s.DialTimeout > 0 {
+			t.DialContext = (&net.Dialer{Timeout: s.DialTimeout, KeepAlive: 30 * time.Second}).DialContext
+		}
+		t.ResponseHeaderTimeout = s.ResponseHeaderTimeout
+		s.transport = t
+	})
+	return s.transport
+}
+
+// limitRequest applies the server's body limit and request timeout to r.
+// The returned stop func must be called once the request is done.
+func (s *Server) limitRequest(w http.ResponseWriter, r *http.Request) (*http.Request, func()) {
+	if s.MaxRequestBody > 0 && r.Body != nil {
+		r.Body = http.MaxBytesReader(w, r.Body, s.MaxRequestBody)
+	}
+	if s.RequestTimeout <= 0 {
+		return r, func() {}
+	}
+
+	ctx, cancel := context.WithCancelCause(r.Context())
+	timer := time.AfterFunc(s.RequestTimeout, func() { cancel(errRequestTimeout) })
+	ctx = context.WithValue(ctx, requestTimerKey{}, timer)
+	return r.WithContext(ctx), func() {
+		timer.Stop()
+		cancel(nil)
+	}
+}
+
+// exemptStream stops the request timeout of resp's request if resp is an
+// SSE stream, which is expected to stay open.
+func exemptStream(resp *http.Response) error {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/event-stream" {
+		return nil
+	}
+	if timer, ok := resp.Request.Context().Value(requestTimerKey{}).(*time.Timer); ok {
+		timer.Stop()
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package serve
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProxyLimits(t *testing.T) {
+	tests := map[string]struct {
+		handler    http.HandlerFunc
+		body       string
+		wantStatus int
+		// closed stops the upstream before the request.
+		closed      bool
+		wantBody    string
+		wantRunning bool
+	}{
+		"within limits": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				io.Copy(w, r.Body)
+			},
+			body:        "hello",
+			wantStatus:  http.StatusOK,
+			wantBody:    "hello",
+			wantRunning: true,
+		},
+		"body too large": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				io.Copy(w, r.Body)
+			},
+			body:        strings.Repeat("x", 200),
+			wantStatus:  http.StatusRequestEntityTooLarge,
+			wantRunning: true,
+		},
+		"request timeout": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-r.Context().Done():
+				case <-time.After(5 * time.Second):
+				}
+			},
+			wantStatus:  http.StatusGatewayTimeout,
+			wantRunning: true,
+		},
+		"connection dropped": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				conn, _, err := w.(http.Hijacker).Hijack()
+				if err == nil {
+					conn.Close()
+				}
+			},
+			wantStatus:  http.StatusBadGateway,
+			wantRunning: true,
+		},
+		"container gone": {
+			handler:    func(w http.ResponseWriter, r *http.Request) {},
+			closed:     true,
+			wantStatus: http.StatusBadGateway,
+		},
+		"SSE stream outlives request timeout": {
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				w.WriteHeader(http.StatusOK)
+				w.(http.Flusher).Flush()
+				time.Sleep(300 * time.Millisecond)
+				io.WriteString(w, "data: done\n\n")
+			},
+			wantStatus:  http.StatusOK,
+			wantBody:    "data: done\n\n",
+			wantRunning: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			upstream := httptest.NewServer(tc.handler)
+			defer upstream.Close()
+			hostPort := serverPort(t, upstream)
+			if tc.closed {
+				upstream.Close()
+			}
+
+			srv := &Server{
+				RequestTimeout: 100 * time.Millisecond,
+				MaxRequestBody: 100,
+			}
+			mc := &managedContainer{name: "test", hostPort: hostPort, status: statusRunning}
+			mc.proxy = mc.buildProxy(srv.upstreamTransport())
+			srv.Containers = testRegistry(t, map[containerKey]*managedContainer{{name: "test"}: mc})
+
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+			req.Header.Set(MCPServerHeader, "test")
+			rec := httptest.NewRecorder()
+			srv.proxyHandler(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Errorf("status = %d, want %d (body %q)", rec.Code, tc.wantStatus, rec.Body.String())
+			}
+			if tc.wantBody != "" && rec.Body.String() != tc.wantBody {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tc.wantBody)
+			}
+			if running := mc.status == statusRunning; running != tc.wantRunning {
+				t.Errorf("container running = %v, want %v", running, tc.wantRunning)
+			}
+		})
+	}
+}
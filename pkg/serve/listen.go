@@ -0,0 +1,107 @@
+package serve
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+)
+
+// Endpoint returns where the server listens.
+func (s *Server) Endpoint() Endpoint {
+	ep := Endpoint{Socket: s.Socket}
+	if !s.NoTCP {
+		ep.Port = s.Port
+	}
+	return ep
+}
+
+// listen opens the server's TCP and socket listeners.
+func (s *Server) listen() ([]net.Listener, error) {
+	if s.NoTCP && s.Socket == "" {
+		return nil, fmt.Errorf("a socket is required when TCP is disabled")
+	}
+
+	var listeners []net.Listener
+	if !s.NoTCP {
+		l, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", s.Port))
+		if err != nil {
+			return nil, err
+		}
+		listeners = append(listeners, l)
+	}
+	if s.Socket != "" {
+		l, err := listenSocket(s.Socket)
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, err
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
+}
+
+// listenSocket listens on the Unix domain socket at path, which only the
+// current user may connect to. A socket left behind by a proxy that didn't
+// shut down cleanly is replaced; one still accepting connections is not.
+//
+// The socket is created in a private directory and restricted there
+// before it is moved to path, so it is never reachable with the default
+// permissions.
+func listenSocket(path string) (net.Listener, error) {
+	info, err := os.Lstat(path)
+	switch {
+	case err == nil && info.Mode()&fs.ModeSocket == 0:
+		return nil, fmt.Errorf("listening on %s: file exists and is not a socket", path)
+	case err == nil:
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("listening on %s: another proxy is using it", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("removing stale socket: %w", err)
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return nil, fmt.Errorf("checking socket: %w", err)
+	}
+
+	// MkdirTemp creates the directory with mode 0700.
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".apkg-serve-")
+	if err != nil {
+		return nil, fmt.Errorf("creating socket directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	private := filepath.Join(dir, "sock")
+	l, err := net.Listen("unix", private)
+	if err != nil {
+		return nil, err
+	}
+	// The socket is removed from path on Close instead.
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	if err := os.Chmod(private, 0o600); err != nil {
+		l.Close()
+		return nil, fmt.Errorf("restricting socket permissions: %w", err)
+	}
+	if err := os.Rename(private, path); err != nil {
+		l.Close()
+		return nil, fmt.Errorf("moving socket into place: %w", err)
+	}
+	return &socketListener{Listener: l, path: path}, nil
+}
+
+// socketListener is a socket listener that removes its socket on Close.
+type socketListener struct {
+	net.Listener
+	path string
+}
+
+func (l *socketListener) Close() error {
+	err := l.Listener.Close()
+	os.Remove(l.path)
+	return err
+}
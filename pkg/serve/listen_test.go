@@ -0,0 +1,179 @@
+package serve
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/container"
+)
+
+func TestListenSocket(t *testing.T) {
+	tests := map[string]struct {
+		setup   func(t *testing.T, path string)
+		wantErr bool
+	}{
+		"new socket": {},
+		"stale socket": {
+			setup: func(t *testing.T, path string) {
+				l, err := net.Listen("unix", path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				// Leave the file behind, as a crashed proxy would.
+				l.(*net.UnixListener).SetUnlinkOnClose(false)
+				l.Close()
+			},
+		},
+		"socket in use": {
+			setup: func(t *testing.T, path string) {
+				l, err := net.Listen("unix", path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				t.Cleanup(func() { l.Close() })
+			},
+			wantErr: true,
+		},
+		"not a socket": {
+			setup: func(t *testing.T, path string) {
+				if err := os.WriteFile(path, nil, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Socket paths are limited to about 100 bytes, too short for
+			// t.TempDir on some systems.
+			dir, err := os.MkdirTemp("", "apkg")
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { os.RemoveAll(dir) })
+			path := filepath.Join(dir, "serve.sock")
+			if tc.setup != nil {
+				tc.setup(t, path)
+			}
+
+			l, err := listenSocket(path)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("listenSocket() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if perm := info.Mode().Perm(); perm != 0o600 {
+				t.Errorf("socket permissions = %o, want 600", perm)
+			}
+			conn, err := net.Dial("unix", path)
+			if err != nil {
+				t.Fatalf("dialing socket: %v", err)
+			}
+			conn.Close()
+			if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+				t.Errorf("socket directory has %d entries, want only the socket", len(entries))
+			}
+
+			l.Close()
+			if _, err := os.Lstat(path); !os.IsNotExist(err) {
+				t.Errorf("socket not removed on close: %v", err)
+			}
+		})
+	}
+}
+
+func TestRemoveServerOverSocket(t *testing.T) {
+	dir, err := os.MkdirTemp("", "apkg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	srv := &Server{
+		Socket:     filepath.Join(dir, "serve.sock"),
+		NoTCP:      true,
+		Engine:     &container.Fake{},
+		Containers: testRegistry(t, map[containerKey]*managedContainer{{name: "postgres"}: {name: "postgres"}}),
+	}
+	listeners, err := srv.listen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("DELETE "+serversPath+"{name}", srv.removeHandler)
+	hs := &http.Server{Handler: mux}
+	go hs.Serve(listeners[0])
+	defer hs.Close()
+
+	if err := RemoveServer(context.Background(), srv.Endpoint(), nil, "postgres"); err != nil {
+		t.Fatalf("RemoveServer() error: %v", err)
+	}
+	if srv.Containers.Len() != 0 {
+		t.Error("server wasn't removed from the proxy")
+	}
+}
+
+func TestListeningAfterBind(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+	free, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	freePort := free.Addr().(*net.TCPAddr).Port
+	free.Close()
+
+	tests := map[string]struct {
+		port          int
+		wantListening bool
+	}{
+		"port taken":     {port: busy.Addr().(*net.TCPAddr).Port},
+		"port available": {port: freePort, wantListening: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got []Endpoint
+			srv := &Server{
+				Port:       tc.port,
+				Engine:     &container.Fake{},
+				Containers: testRegistry(t, map[containerKey]*managedContainer{{name: "postgres"}: {name: "postgres"}}),
+				Listening: func(ep Endpoint) error {
+					got = append(got, ep)
+					// Stop the proxy once it would serve.
+					return errors.New("stop")
+				},
+			}
+
+			err := srv.ListenAndServe(context.Background())
+			if err == nil {
+				t.Fatal("ListenAndServe() succeeded, want an error")
+			}
+			if tc.wantListening {
+				if want := []Endpoint{{Port: tc.port}}; !slices.Equal(got, want) {
+					t.Errorf("Listening called with %v, want %v", got, want)
+				}
+				return
+			}
+			if len(got) != 0 {
+				t.Errorf("Listening called with %v before the port was bound", got)
+			}
+		})
+	}
+}
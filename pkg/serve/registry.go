@@ -0,0 +1,230 @@
+package serve
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+)
+
+// containerEntry is a managed container with the key it is registered under.
+type containerEntry struct {
+	key containerKey
+	mc  *managedContainer
+}
+
+// ContainerRegistry is the set of containers a Server manages, keyed by
+// server name and digest. It is safe for concurrent use: requests look
+// containers up while the admin endpoint and reloads add and remove them.
+//
+// The registry also tracks the host ports claimed by host-network
+// containers, whose port can't be remapped, so two servers can't be set up
+// to listen on the same one.
+type ContainerRegistry struct {
+	mu         sync.RWMutex
+	containers map[containerKey]*managedContainer
+	// hostPorts maps ports claimed by host-network containers to the
+	// servers claiming them, with the number of installs (digests) of the
+	// server holding the claim.
+	hostPorts map[int]portClaim
+}
+
+type portClaim struct {
+	name     string
+	installs int
+}
+
+// newContainerRegistry returns a registry holding containers, or an error
+// if two of them claim the same host port.
+func newContainerRegistry(containers map[containerKey]*managedContainer) (*ContainerRegistry, error) {
+	r := &ContainerRegistry{
+		containers: make(map[containerKey]*managedContainer, len(containers)),
+		hostPorts:  make(map[int]portClaim),
+	}
+
+	// Add in key order so conflicts are reported deterministically.
+	keys := make([]containerKey, 0, len(containers))
+	for key := range containers {
+		keys = append(keys, key)
+	}
+	sortKeys(keys)
+	for _, key := range keys {
+		if _, err := r.add(key, containers[key]); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Len returns the number of registered containers.
+func (r *ContainerRegistry) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.containers)
+}
+
+// get returns the container registered under key.
+func (r *ContainerRegistry) get(key containerKey) (*managedContainer, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	mc, ok := r.containers[key]
+	return mc, ok
+}
+
+// add registers mc under key and returns the container it replaced, if
+// any, so the caller can stop it. Installs of the same server share its
+// container name and never run at once, so only a host port claimed by a
+// different server is a conflict.
+func (r *ContainerRegistry) add(key containerKey, mc *managedContainer) (*managedContainer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.addLocked(key, mc)
+}
+
+// addProject returns the container registered under key, which names the
+// project whose volumes it mounts, registering mc there first if there is
+// none or the registered one mounts other volumes. The project's other
+// containers of the server, e.g. of an install it replaced, are
+// unregistered and returned along with any container mc replaced.
+func (r *ContainerRegistry) addProject(key containerKey, mc *managedContainer) (*managedContainer, []*managedContainer, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	removed := r.removeProjectLocked(key.name, key.project, key)
+	if registered, ok := r.containers[key]; ok && slices.Equal(registered.volumes, mc.volumes) {
+		return registered, removed, nil
+	}
+	old, err := r.addLocked(key, mc)
+	if err != nil {
+		return nil, removed, err
+	}
+	if old != nil {
+		removed = append(removed, old)
+	}
+	return mc, removed, nil
+}
+
+// removeProject unregisters the containers of the named server that mount
+// the volumes of project, and returns them.
+func (r *ContainerRegistry) removeProject(name, project string) []*managedContainer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.removeProjectLocked(name, project, containerKey{})
+}
+
+// removeProjectLocked is removeProject, keeping the container under keep.
+// Must be called with r.mu held.
+func (r *ContainerRegistry) removeProjectLocked(name, project string, keep containerKey) []*managedContainer {
+	var removed []*managedContainer
+	for key, mc := range r.containers {
+		if key.name == name && key.project == project && key != keep {
+			removed = append(removed, mc)
+			r.releasePort(mc)
+			delete(r.containers, key)
+		}
+	}
+	return removed
+}
+
+// addLocked is add. Must be called with r.mu held.
+func (r *ContainerRegistry) addLocked(key containerKey, mc *managedContainer) (*managedContainer, error) {
+	if port, ok := mc.claimedHostPort(); ok {
+		if claim, taken := r.hostPorts[port]; taken && claim.name != key.name {
+			return nil, fmt.Errorf("MCP servers %q and %q both use host networking on port %d; change the port of one of them", claim.name, key.name, port)
+		}
+	}
+
+	old := r.containers[key]
+	if old != nil {
+		r.releasePort(old)
+	}
+	r.containers[key] = mc
+	if port, ok := mc.claimedHostPort(); ok {
+		claim := r.hostPorts[port]
+		r.hostPorts[port] = portClaim{name: key.name, installs: claim.installs + 1}
+	}
+	return old, nil
+}
+
+// remove unregisters the container under key and returns it.
+func (r *ContainerRegistry) remove(key containerKey) (*managedContainer, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	mc, ok := r.containers[key]
+	if ok {
+		r.releasePort(mc)
+		delete(r.containers, key)
+	}
+	return mc, ok
+}
+
+// releasePort drops mc's share of its host port claim. Must be called with
+// r.mu held.
+func (r *ContainerRegistry) releasePort(mc *managedContainer) {
+	port, ok := mc.claimedHostPort()
+	if !ok {
+		return
+	}
+	claim := r.hostPorts[port]
+	if claim.installs <= 1 {
+		delete(r.hostPorts, port)
+		return
+	}
+	claim.installs--
+	r.hostPorts[port] = claim
+}
+
+// removeName unregisters every install of the named server and returns
+// the removed containers.
+func (r *ContainerRegistry) removeName(name string) []*managedContainer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var removed []*managedContainer
+	for key, mc := range r.containers {
+		if key.name == name {
+			removed = append(removed, mc)
+			r.releasePort(mc)
+			delete(r.containers, key)
+		}
+	}
+	return removed
+}
+
+// entries returns a snapshot of the registered containers sorted by key.
+// Iterating it doesn't hold the registry lock, so callers may stop
+// containers (or the registry may change) meanwhile.
+func (r *ContainerRegistry) entries() []containerEntry {
+	r.mu.RLock()
+	all := make([]containerEntry, 0, len(r.containers))
+	for key, mc := range r.containers {
+		all = append(all, containerEntry{key: key, mc: mc})
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(all, func(i, j int) bool { return all[i].key.less(all[j].key) })
+	return all
+}
+
+func sortKeys(keys []containerKey) {
+	sort.Slice(keys, func(i, j int) bool { return keys[i].less(keys[j]) })
+}
+
+func (k containerKey) less(other containerKey) bool {
+	if k.name != other.name {
+		return k.name < other.name
+	}
+	if k.digest != other.digest {
+		return k.digest < other.digest
+	}
+	return k.project < other.project
+}
+
+// snapshot returns the registered containers (see entries).
+func (r *ContainerRegistry) snapshot() []*managedContainer {
+	entries := r.entries()
+	all := make([]*managedContainer, len(entries))
+	for i, e := range entries {
+		all[i] = e.mc
+	}
+	return all
+}
@@ -0,0 +1,253 @@
+package serve
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// testRegistry returns a registry holding containers.
+func testRegistry(t *testing.T, containers map[containerKey]*managedContainer) *ContainerRegistry {
+	t.Helper()
+	r, err := newContainerRegistry(containers)
+	if err != nil {
+		t.Fatalf("newContainerRegistry() error: %v", err)
+	}
+	return r
+}
+
+func TestContainerRegistry(t *testing.T) {
+	pg16 := &managedContainer{name: "postgres", image: "pg:16"}
+	pg17 := &managedContainer{name: "postgres", image: "pg:17"}
+	redis := &managedContainer{name: "redis", image: "redis:7"}
+
+	tests := map[string]struct {
+		op       func(r *ContainerRegistry) []*managedContainer
+		wantOut  []*managedContainer
+		wantKeys []containerKey
+	}{
+		"add new": {
+			op: func(r *ContainerRegistry) []*managedContainer {
+				old, err := r.add(containerKey{name: "redis", digest: "123"}, redis)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if old != nil {
+					return []*managedContainer{old}
+				}
+				return nil
+			},
+			wantKeys: []containerKey{{name: "postgres", digest: "abc"}, {name: "postgres", digest: "def"}, {name: "redis", digest: "123"}},
+		},
+		"add replaces": {
+			op: func(r *ContainerRegistry) []*managedContainer {
+				old, err := r.add(containerKey{name: "postgres", digest: "abc"}, redis)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return []*managedContainer{old}
+			},
+			wantOut:  []*managedContainer{pg16},
+			wantKeys: []containerKey{{name: "postgres", digest: "abc"}, {name: "postgres", digest: "def"}},
+		},
+		"remove": {
+			op: func(r *ContainerRegistry) []*managedContainer {
+				mc, _ := r.remove(containerKey{name: "postgres", digest: "def"})
+				return []*managedContainer{mc}
+			},
+			wantOut:  []*managedContainer{pg17},
+			wantKeys: []containerKey{{name: "postgres", digest: "abc"}},
+		},
+		"remove unknown": {
+			op: func(r *ContainerRegistry) []*managedContainer {
+				if _, ok := r.remove(containerKey{name: "redis"}); ok {
+					t.Error("remove() reported an unknown container as removed")
+				}
+				return nil
+			},
+			wantKeys: []containerKey{{name: "postgres", digest: "abc"}, {name: "postgres", digest: "def"}},
+		},
+		"add project replaces its other installs": {
+			op: func(r *ContainerRegistry) []*managedContainer {
+				if _, err := r.add(containerKey{name: "postgres", digest: "abc", project: "/work/billing"}, redis); err != nil {
+					t.Fatal(err)
+				}
+				mc, removed, err := r.addProject(containerKey{name: "postgres", digest: "def", project: "/work/billing"}, pg17.withVolumes("/work/billing", []string{"/data:/data"}))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if mc.image != pg17.image {
+					t.Errorf("addProject() = %s, want a copy of %s", mc.image, pg17.image)
+				}
+				return removed
+			},
+			wantOut:  []*managedContainer{redis},
+			wantKeys: []containerKey{{name: "postgres", digest: "abc"}, {name: "postgres", digest: "def"}, {name: "postgres", digest: "def", project: "/work/billing"}},
+		},
+		"remove project": {
+			op: func(r *ContainerRegistry) []*managedContainer {
+				if _, err := r.add(containerKey{name: "postgres", digest: "abc", project: "/work/billing"}, redis); err != nil {
+					t.Fatal(err)
+				}
+				return r.removeProject("postgres", "/work/billing")
+			},
+			wantOut:  []*managedContainer{redis},
+			wantKeys: []containerKey{{name: "postgres", digest: "abc"}, {name: "postgres", digest: "def"}},
+		},
+		"remove name": {
+			op: func(r *ContainerRegistry) []*managedContainer {
+				return r.removeName("postgres")
+			},
+			wantOut: []*managedContainer{pg16, pg17},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := testRegistry(t, map[containerKey]*managedContainer{
+				{name: "postgres", digest: "abc"}: pg16,
+				{name: "postgres", digest: "def"}: pg17,
+			})
+
+			out := tc.op(r)
+			if len(out) != len(tc.wantOut) {
+				t.Fatalf("op returned %d containers, want %d", len(out), len(tc.wantOut))
+			}
+			for _, want := range tc.wantOut {
+				found := false
+				for _, mc := range out {
+					found = found || mc == want
+				}
+				if !found {
+					t.Errorf("op didn't return %s", want.image)
+				}
+			}
+
+			entries := r.entries()
+			if len(entries) != len(tc.wantKeys) || r.Len() != len(tc.wantKeys) {
+				t.Fatalf("registry has %d entries, want %d", len(entries), len(tc.wantKeys))
+			}
+			for i, key := range tc.wantKeys {
+				if entries[i].key != key {
+					t.Errorf("entries()[%d] = %v, want %v", i, entries[i].key, key)
+				}
+			}
+		})
+	}
+}
+
+func TestContainerRegistryConcurrent(t *testing.T) {
+	r := testRegistry(t, nil)
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 100 {
+				key := containerKey{name: fmt.Sprintf("server-%d", i), digest: fmt.Sprint(j)}
+				if _, err := r.add(key, &managedContainer{name: key.name}); err != nil {
+					t.Error(err)
+				}
+				r.get(key)
+				r.snapshot()
+				if j%2 == 0 {
+					r.remove(key)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := r.Len(); got != 8*50 {
+		t.Errorf("Len() = %d, want %d", got, 8*50)
+	}
+}
+
+func TestContainerRegistryHostPorts(t *testing.T) {
+	hostNet := func(name string, port int) *managedContainer {
+		return &managedContainer{name: name, containerPort: port, network: "host"}
+	}
+
+	tests := map[string]struct {
+		containers map[containerKey]*managedContainer
+		// then, if set, is added after creating the registry.
+		then    map[containerKey]*managedContainer
+		wantErr string
+	}{
+		"distinct ports": {
+			containers: map[containerKey]*managedContainer{
+				{name: "postgres", digest: "abc"}: hostNet("postgres", 5432),
+				{name: "redis", digest: "123"}:    hostNet("redis", 6379),
+			},
+		},
+		"same port without host networking": {
+			containers: map[containerKey]*managedContainer{
+				{name: "a", digest: "1"}: {name: "a", containerPort: 8080},
+				{name: "b", digest: "2"}: {name: "b", containerPort: 8080},
+			},
+		},
+		"installs of the same server share a port": {
+			containers: map[containerKey]*managedContainer{
+				{name: "postgres", digest: "abc"}: hostNet("postgres", 5432),
+				{name: "postgres", digest: "def"}: hostNet("postgres", 5432),
+			},
+		},
+		"conflict at discovery": {
+			containers: map[containerKey]*managedContainer{
+				{name: "postgres", digest: "abc"}:   hostNet("postgres", 5432),
+				{name: "pg-replica", digest: "def"}: hostNet("pg-replica", 5432),
+			},
+			wantErr: `MCP servers "pg-replica" and "postgres" both use host networking on port 5432; change the port of one of them`,
+		},
+		"conflict on add": {
+			containers: map[containerKey]*managedContainer{
+				{name: "postgres", digest: "abc"}: hostNet("postgres", 5432),
+			},
+			then: map[containerKey]*managedContainer{
+				{name: "pg-replica", digest: "def"}: hostNet("pg-replica", 5432),
+			},
+			wantErr: `MCP servers "postgres" and "pg-replica" both use host networking on port 5432; change the port of one of them`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			r, err := newContainerRegistry(tc.containers)
+			for key, mc := range tc.then {
+				if err != nil {
+					break
+				}
+				_, err = r.add(key, mc)
+			}
+
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tc.wantErr {
+				t.Errorf("error = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestContainerRegistryReleasesHostPorts(t *testing.T) {
+	r := testRegistry(t, map[containerKey]*managedContainer{
+		{name: "postgres", digest: "abc"}: {name: "postgres", containerPort: 5432, network: "host"},
+		{name: "postgres", digest: "def"}: {name: "postgres", containerPort: 5432, network: "host"},
+	})
+
+	// The port stays claimed while an install of postgres remains.
+	r.remove(containerKey{name: "postgres", digest: "abc"})
+	if _, err := r.add(containerKey{name: "other"}, &managedContainer{name: "other", containerPort: 5432, network: "host"}); err == nil {
+		t.Fatal("expected a conflict while postgres still claims the port")
+	}
+
+	r.removeName("postgres")
+	if _, err := r.add(containerKey{name: "other"}, &managedContainer{name: "other", containerPort: 5432, network: "host"}); err != nil {
+		t.Fatalf("port wasn't released: %v", err)
+	}
+}
@@ -3,11 +3,15 @@ package serve
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -25,37 +29,115 @@ const (
 	// MCPServerDigestHeader disambiguates when multiple installs use the
 	// same server name with different images.
 	MCPServerDigestHeader = "X-MCP-Server-Digest"
+	// MCPProjectHeader identifies the project a request comes from, by
+	// its absolute directory, for routing and access control (see
+	// Server.Installs and Server.Access). Global installs send none.
+	MCPProjectHeader = "X-MCP-Project"
+
+	// GlobalAccess in a server's Server.Access list lets the global
+	// installs reach it.
+	GlobalAccess = config.ServerScopeGlobal
+
+	// serversPath is the admin endpoint used to drop servers from a running
+	// proxy (DELETE serversPath + name) after they are uninstalled.
+	serversPath = "/_apkg/servers/"
 )
 
-// containerKey uniquely identifies a managed container by name + digest.
+// containerKey uniquely identifies a managed container by name + digest,
+// and the project of installs that mount volumes (see Server.Installs), or
+// GlobalAccess for the global installs.
 type containerKey struct {
-	name   string
-	digest string
+	name    string
+	digest  string
+	project string
+}
+
+// Install is a project's install of a container server, as its lockfile
+// records it.
+type Install struct {
+	Digest string
+	// Volumes are the install's volumes with absolute host paths. The
+	// store entries serve discovers are shared by projects, so installs
+	// mounting volumes get a container of their own.
+	Volumes []string
 }
 
 // Server is the apkg serve HTTP proxy. It lazily starts containers on first
 // request and reverse-proxies traffic to them.
 type Server struct {
-	Port        int
+	Port int
+	// Socket, if set, is the path of a Unix domain socket the proxy also
+	// listens on; Windows 10 and later support these too. Only the user
+	// running the proxy may connect to it. With NoTCP the socket is the
+	// only listener, leaving nothing reachable over localhost TCP.
+	Socket      string
+	NoTCP       bool
 	IdleTimeout time.Duration
-	Engine      *container.Engine
-	Containers  map[containerKey]*managedContainer
+	Engine      container.Engine
+	Containers  *ContainerRegistry
+	// Store, if set, is the store the servers were discovered in, which
+	// GC consults on shutdown.
+	Store store.Store
+
+	// Access maps server names to the projects (absolute directories, as
+	// sent in MCPProjectHeader, or GlobalAccess) allowed to reach them.
+	// Servers without an entry are reachable by every project.
+	Access map[string][]string
+
+	// Installs, if set, returns the install of the named server by
+	// project (as sent in MCPProjectHeader, or "" for global installs),
+	// and false if the project has none. Requests are routed to the
+	// install of the project they come from, so projects installing
+	// different servers of the same name each reach their own; requests
+	// from elsewhere are routed by MCPServerDigestHeader.
+	Installs func(project, name string) (Install, bool, error)
+
+	// Listening, if set, is called with Endpoint once the proxy listens
+	// there and before it serves requests, e.g. to Advertise it. An error
+	// stops the proxy.
+	Listening func(Endpoint) error
+
+	// DialTimeout and ResponseHeaderTimeout bound connecting to a
+	// container and waiting for its response. RequestTimeout bounds a
+	// whole request except SSE streams. MaxRequestBody is the largest
+	// request body forwarded, in bytes. Zero disables the limit.
+	DialTimeout           time.Duration
+	ResponseHeaderTimeout time.Duration
+	RequestTimeout        time.Duration
+	MaxRequestBody        int64
+
+	transportOnce sync.Once
+	transport     *http.Transport
+
+	// ctx is the context of ListenAndServe, which outlives requests, for
+	// starting containers in the background.
+	ctx context.Context
 }
 
 // NewServerFromStore creates a Server by scanning the store's oci/ directory
 // for installed container MCP servers. Each subdirectory at
 // oci/<name>/<digest>/mcp.toml describes a container server.
-func NewServerFromStore(st store.Store, port int, engine *container.Engine) (*Server, error) {
+func NewServerFromStore(st store.Store, port int, engine container.Engine) (*Server, error) {
 	containers, err := discoverContainers(st)
 	if err != nil {
 		return nil, fmt.Errorf("discovering containers: %w", err)
 	}
+	registry, err := newContainerRegistry(containers)
+	if err != nil {
+		return nil, err
+	}
 
 	return &Server{
 		Port:        port,
 		IdleTimeout: DefaultIdleTimeout,
 		Engine:      engine,
-		Containers:  containers,
+		Containers:  registry,
+		Store:       st,
+
+		DialTimeout:           DefaultDialTimeout,
+		ResponseHeaderTimeout: DefaultResponseHeaderTimeout,
+		RequestTimeout:        DefaultRequestTimeout,
+		MaxRequestBody:        DefaultMaxRequestBody,
 	}, nil
 }
 
@@ -92,6 +174,10 @@ func discoverContainers(st store.Store) (map[containerKey]*managedContainer, err
 			digest := digestEntry.Name()
 
 			mcpPath := filepath.Join(ociDir, name, digest, "mcp.toml")
+			info, err := os.Stat(mcpPath)
+			if err != nil {
+				continue
+			}
 			data, err := os.ReadFile(mcpPath)
 			if err != nil {
 				continue
@@ -114,10 +200,12 @@ func discoverContainers(st store.Store) (map[containerKey]*managedContainer, err
 
 			mc := &managedContainer{
 				name:          name,
-				image:         ms.Image,
+				image:         container.PinnedImage(ms.Image, ms.ManifestDigest),
 				containerPort: containerPort,
-				volumes:       ms.Volumes,
 				network:       ms.Network,
+				aliases:       ms.NetworkAliases,
+				eager:         ms.Eager,
+				installed:     info.ModTime(),
 			}
 			if ms.LocalMCPConfig != nil {
 				mc.env = ms.Env
@@ -135,41 +223,60 @@ func discoverContainers(st store.Store) (map[containerKey]*managedContainer, err
 // ListenAndServe starts the proxy and blocks until a shutdown signal is
 // received. It returns nil on clean shutdown.
 func (s *Server) ListenAndServe(ctx context.Context) error {
-	if len(s.Containers) == 0 {
+	if s.Containers.Len() == 0 {
 		return fmt.Errorf("no containerized HTTP MCP servers found in store")
 	}
 
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
+	s.ctx = ctx
+
+	listeners, err := s.listen()
+	if err != nil {
+		return err
+	}
+	if s.Listening != nil {
+		if err := s.Listening(s.Endpoint()); err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return err
+		}
+	}
 
-	// Start the idle reaper in the background.
-	go startIdleReaper(ctx, s.Engine, s.Containers, s.IdleTimeout)
+	// Start the idle reaper and eager containers in the background.
+	go startIdleReaper(ctx, s.Engine, s.Containers.snapshot, s.IdleTimeout)
+	s.startEager(ctx)
 
 	mux := http.NewServeMux()
+	mux.HandleFunc("DELETE "+serversPath+"{name}", s.removeHandler)
+	mux.HandleFunc("GET "+healthzPath, s.healthzHandler)
+	mux.HandleFunc("GET "+readyzPath+"{name}", s.readyzHandler)
 	mux.HandleFunc("/", s.proxyHandler)
 
-	addr := fmt.Sprintf("127.0.0.1:%d", s.Port)
-	srv := &http.Server{
-		Addr:    addr,
-		Handler: mux,
-	}
+	srv := &http.Server{Handler: mux}
 
 	// Graceful shutdown on signal.
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 
-	errCh := make(chan error, 1)
-	go func() {
-		log.Printf("apkg serve listening on %s", addr)
-		for key := range s.Containers {
-			digest := key.digest
-			if len(digest) > 12 {
-				digest = digest[:12]
-			}
-			log.Printf("  %s [%s] → %s (lazy start)", key.name, digest, s.Containers[key].image)
+	for _, l := range listeners {
+		log.Printf("apkg serve listening on %s", l.Addr())
+	}
+	for _, e := range s.Containers.entries() {
+		digest := e.key.digest
+		if len(digest) > 12 {
+			digest = digest[:12]
 		}
-		errCh <- srv.ListenAndServe()
-	}()
+		log.Printf("  %s [%s] → %s (lazy start)", e.key.name, digest, e.mc.image)
+	}
+
+	errCh := make(chan error, len(listeners))
+	for _, l := range listeners {
+		go func() {
+			errCh <- srv.Serve(l)
+		}()
+	}
 
 	select {
 	case err := <-errCh:
@@ -177,6 +284,7 @@ func (s *Server) ListenAndServe(ctx context.Context) error {
 			break
 		}
 		cancel()
+		srv.Close()
 		return err
 	case sig := <-sigCh:
 		log.Printf("received %v, shutting down", sig)
@@ -193,20 +301,28 @@ func (s *Server) ListenAndServe(ctx context.Context) error {
 	}
 
 	// Stop all containers.
-	all := make([]*managedContainer, 0, len(s.Containers))
-	for _, mc := range s.Containers {
-		all = append(all, mc)
-	}
-	if err := stopAllContainers(context.Background(), s.Engine, all); err != nil {
+	if err := stopAllContainers(context.Background(), s.Engine, s.Containers.snapshot()); err != nil {
 		log.Printf("error stopping containers: %v", err)
 	}
 
+	// Clean up what earlier crashes and reinstalls left behind.
+	if s.Store != nil {
+		result, err := GC(context.Background(), s.Store, s.Engine, false)
+		if err != nil {
+			log.Printf("error cleaning up containers and images: %v", err)
+		}
+		if result != nil && len(result.Containers)+len(result.Images)+result.Dangling > 0 {
+			log.Printf("removed %d stopped container(s) and %d unused image(s)", len(result.Containers), len(result.Images)+result.Dangling)
+		}
+	}
+
 	return nil
 }
 
-// proxyHandler routes requests based on the X-MCP-Server and
-// X-MCP-Server-Digest headers, lazily starting containers on first request
-// and reusing the cached reverse proxy for subsequent requests.
+// proxyHandler routes requests based on the X-MCP-Server,
+// X-MCP-Server-Digest, and X-MCP-Project headers (see route), lazily
+// starting containers on first request and reusing the cached reverse
+// proxy for subsequent requests.
 func (s *Server) proxyHandler(w http.ResponseWriter, r *http.Request) {
 	log.Printf("Received request: %s %s", r.Method, r.URL.Path)
 	log.Printf("  Headers: %v", r.Header)
@@ -217,22 +333,38 @@ func (s *Server) proxyHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	digest := r.Header.Get(MCPServerDigestHeader)
-	key := containerKey{name: serverName, digest: digest}
+	project := r.Header.Get(MCPProjectHeader)
+	if project != "" {
+		project = filepath.Clean(project)
+	}
+
+	// Denied requests mustn't start or register containers.
+	if !s.allowed(serverName, project) {
+		log.Printf("denied access to %q from project %q", serverName, project)
+		http.Error(w, fmt.Sprintf("project %q may not access MCP server %q", project, serverName), http.StatusForbidden)
+		return
+	}
 
-	mc, ok := s.Containers[key]
+	mc, ok, err := s.route(serverName, digest, project)
+	if err != nil {
+		log.Printf("failed to look up the install of %q by project %q: %v", serverName, project, err)
+		http.Error(w, fmt.Sprintf("looking up the install of MCP server %q: %v", serverName, err), http.StatusInternalServerError)
+		return
+	}
 	if !ok {
 		http.Error(w, fmt.Sprintf("unknown MCP server %q (digest %q)", serverName, digest), http.StatusNotFound)
 		return
 	}
 
-	if err := mc.ensureRunning(r.Context(), s.Engine); err != nil {
+	if err := mc.ensureRunning(r.Context(), s.Engine, s.upstreamTransport()); err != nil {
 		log.Printf("failed to start container for %q: %v", serverName, err)
 		http.Error(w, fmt.Sprintf("failed to start MCP server %q: %v", serverName, err),
 			http.StatusServiceUnavailable)
 		return
 	}
 
-	mc.touch()
+	end := mc.begin()
+	defer end()
 
 	mc.mu.Lock()
 	proxy := mc.proxy
@@ -243,5 +375,158 @@ func (s *Server) proxyHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	r, stop := s.limitRequest(w, r)
+	defer stop()
 	proxy.ServeHTTP(w, r)
 }
+
+// removeHandler drops every install of the named server from the proxy and
+// stops its container, so an uninstalled server can't be restarted by a
+// stale agent config.
+func (s *Server) removeHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	removed := s.Containers.removeName(name)
+	if len(removed) == 0 {
+		http.Error(w, fmt.Sprintf("unknown MCP server %q", name), http.StatusNotFound)
+		return
+	}
+
+	log.Printf("removing MCP server %q", name)
+	if err := stopAllContainers(r.Context(), s.Engine, removed); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// startEager starts the containers of eager servers in the background.
+// When a server has several installs, which share its container, the most
+// recently installed one is started.
+func (s *Server) startEager(ctx context.Context) {
+	for _, mc := range eagerContainers(s.Containers.entries()) {
+		go func() {
+			log.Printf("starting eager container %q", mc.name)
+			if err := mc.ensureRunning(ctx, s.Engine, s.upstreamTransport()); err != nil {
+				log.Printf("failed to start eager container %q: %v", mc.name, err)
+			}
+		}()
+	}
+}
+
+// eagerContainers returns the newest install of each eager server.
+func eagerContainers(entries []containerEntry) []*managedContainer {
+	newest := make(map[string]*managedContainer)
+	var names []string
+	for _, e := range entries {
+		if !e.mc.eager {
+			continue
+		}
+		cur, ok := newest[e.key.name]
+		if !ok {
+			names = append(names, e.key.name)
+		}
+		if !ok || e.mc.installed.After(cur.installed) {
+			newest[e.key.name] = e.mc
+		}
+	}
+
+	eager := make([]*managedContainer, len(names))
+	for i, name := range names {
+		eager[i] = newest[name]
+	}
+	return eager
+}
+
+// route returns the container a request for the named server from
+// project ("" for global installs) reaches: that of the project's own
+// install if Installs knows it, else that of the install with the given
+// digest. It reports false if there is no such install.
+//
+// Installs mounting volumes get a container of their own, registered for
+// the project until it no longer installs the server with volumes.
+func (s *Server) route(name, digest, project string) (*managedContainer, bool, error) {
+	var install Install
+	var installed bool
+	if s.Installs != nil {
+		var err error
+		if install, installed, err = s.Installs(project, name); err != nil {
+			return nil, false, err
+		}
+		if installed {
+			digest = install.Digest
+		}
+	}
+
+	scope := project
+	if scope == "" {
+		scope = GlobalAccess
+	}
+	key := containerKey{name: name, digest: digest}
+	mc, ok := s.Containers.get(key)
+	if !ok || len(install.Volumes) == 0 {
+		s.stopRemoved(s.Containers.removeProject(name, scope))
+		return mc, ok, nil
+	}
+	key.project = scope
+	mc, removed, err := s.Containers.addProject(key, mc.withVolumes(scope, install.Volumes))
+	s.stopRemoved(removed)
+	return mc, err == nil, err
+}
+
+// stopRemoved stops the containers dropped from the registry in the
+// background.
+func (s *Server) stopRemoved(removed []*managedContainer) {
+	if len(removed) == 0 || s.Engine == nil {
+		return
+	}
+	go func() {
+		if err := stopAllContainers(s.baseContext(), s.Engine, removed); err != nil {
+			log.Printf("error stopping containers: %v", err)
+		}
+	}()
+}
+
+// allowed reports whether project, or the global installs if project is
+// "", may reach the named server.
+func (s *Server) allowed(name, project string) bool {
+	projects, restricted := s.Access[name]
+	if !restricted {
+		return true
+	}
+	if project == "" {
+		return slices.Contains(projects, GlobalAccess)
+	}
+	return slices.Contains(projects, filepath.Clean(project))
+}
+
+// RemoveServer tells the proxy listening at ep to drop the named server and
+// stop its container. When no proxy is running, the server's container is
+// stopped directly through engine (if one is given). It is not an error if
+// the server is unknown to the proxy or has no container.
+func RemoveServer(ctx context.Context, ep Endpoint, engine container.Engine, name string) error {
+	// The client dials ep whatever the URL's host.
+	url := "http://apkg-serve" + serversPath + name
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := ep.client(30 * time.Second).Do(req)
+	if err != nil {
+		// Proxy isn't running; stop any leftover container ourselves.
+		if engine == nil {
+			return nil
+		}
+		return engine.Stop(ctx, containerPrefix+name)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("serve proxy: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+}
@@ -1,16 +1,31 @@
 package serve
 
 import (
+	"maps"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/agentpkg/agentpkg/pkg/container"
 	"github.com/agentpkg/agentpkg/pkg/store"
 )
 
+// serverPort returns the port of the httptest server s.
+func serverPort(t *testing.T, s *httptest.Server) int {
+	t.Helper()
+	port, err := strconv.Atoi(s.URL[strings.LastIndex(s.URL, ":")+1:])
+	if err != nil {
+		t.Fatalf("parsing port of %s: %v", s.URL, err)
+	}
+	return port
+}
+
 // seedOCIStore writes an mcp.toml into the store at oci/<name>/<digest>/mcp.toml.
 func seedOCIStore(t *testing.T, st store.Store, name, digest, tomlContent string) {
 	t.Helper()
@@ -119,20 +134,20 @@ digest = "digest-v2"
 			st := store.New(t.TempDir())
 			tc.seed(t, st)
 
-			srv, err := NewServerFromStore(st, DefaultPort, &container.Engine{})
+			srv, err := NewServerFromStore(st, DefaultPort, &container.Fake{})
 			if err != nil {
 				t.Fatalf("NewServerFromStore() error: %v", err)
 			}
-			if len(srv.Containers) != tc.wantCount {
-				t.Errorf("container count = %d, want %d", len(srv.Containers), tc.wantCount)
+			if srv.Containers.Len() != tc.wantCount {
+				t.Errorf("container count = %d, want %d", srv.Containers.Len(), tc.wantCount)
 			}
 			for _, key := range tc.wantKeys {
-				if _, ok := srv.Containers[key]; !ok {
+				if _, ok := srv.Containers.get(key); !ok {
 					t.Errorf("missing container key {name: %q, digest: %q}", key.name, key.digest)
 				}
 			}
 			for key, port := range tc.wantPorts {
-				mc, ok := srv.Containers[key]
+				mc, ok := srv.Containers.get(key)
 				if !ok {
 					t.Errorf("missing container key {name: %q, digest: %q} for port check", key.name, key.digest)
 					continue
@@ -147,7 +162,7 @@ digest = "digest-v2"
 
 func TestProxyHandlerMissingHeader(t *testing.T) {
 	srv := &Server{
-		Containers: map[containerKey]*managedContainer{},
+		Containers: testRegistry(t, nil),
 	}
 
 	req := httptest.NewRequest(http.MethodGet, "/", nil)
@@ -161,9 +176,9 @@ func TestProxyHandlerMissingHeader(t *testing.T) {
 
 func TestProxyHandlerUnknownServer(t *testing.T) {
 	srv := &Server{
-		Containers: map[containerKey]*managedContainer{
+		Containers: testRegistry(t, map[containerKey]*managedContainer{
 			{name: "known", digest: "abc"}: {name: "known", image: "img:latest"},
-		},
+		}),
 	}
 
 	req := httptest.NewRequest(http.MethodGet, "/", nil)
@@ -179,9 +194,9 @@ func TestProxyHandlerUnknownServer(t *testing.T) {
 
 func TestProxyHandlerWrongDigest(t *testing.T) {
 	srv := &Server{
-		Containers: map[containerKey]*managedContainer{
+		Containers: testRegistry(t, map[containerKey]*managedContainer{
 			{name: "postgres", digest: "abc"}: {name: "postgres", image: "pg:latest"},
-		},
+		}),
 	}
 
 	req := httptest.NewRequest(http.MethodGet, "/", nil)
@@ -194,3 +209,282 @@ func TestProxyHandlerWrongDigest(t *testing.T) {
 		t.Errorf("status = %d, want %d", rec.Code, http.StatusNotFound)
 	}
 }
+
+func TestRemoveHandler(t *testing.T) {
+	tests := map[string]struct {
+		name       string
+		wantStatus int
+		wantLeft   int
+	}{
+		"removes every install of the server": {
+			name:       "postgres",
+			wantStatus: http.StatusNoContent,
+			wantLeft:   1,
+		},
+		"unknown server": {
+			name:       "unknown",
+			wantStatus: http.StatusNotFound,
+			wantLeft:   3,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv := &Server{
+				Containers: testRegistry(t, map[containerKey]*managedContainer{
+					{name: "postgres", digest: "abc"}: {name: "postgres", image: "pg:16"},
+					{name: "postgres", digest: "def"}: {name: "postgres", image: "pg:17"},
+					{name: "redis", digest: "123"}:    {name: "redis", image: "redis:7"},
+				}),
+			}
+
+			req := httptest.NewRequest(http.MethodDelete, serversPath+tc.name, nil)
+			req.SetPathValue("name", tc.name)
+			rec := httptest.NewRecorder()
+			srv.removeHandler(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tc.wantStatus)
+			}
+			if srv.Containers.Len() != tc.wantLeft {
+				t.Errorf("%d containers left, want %d", srv.Containers.Len(), tc.wantLeft)
+			}
+		})
+	}
+}
+
+func TestProxyHandlerAccess(t *testing.T) {
+	tests := map[string]struct {
+		server     string
+		project    string
+		wantStatus int
+	}{
+		"unrestricted server": {
+			server:     "cache",
+			wantStatus: http.StatusOK,
+		},
+		"allowed project": {
+			server:     "postgres",
+			project:    "/work/billing/",
+			wantStatus: http.StatusOK,
+		},
+		"other project": {
+			server:     "postgres",
+			project:    "/work/website",
+			wantStatus: http.StatusForbidden,
+		},
+		"no project": {
+			server:     "postgres",
+			wantStatus: http.StatusForbidden,
+		},
+		"global install": {
+			server:     "redis",
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var gotProjectHeader string
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotProjectHeader = r.Header.Get(MCPProjectHeader)
+			}))
+			defer upstream.Close()
+			hostPort := serverPort(t, upstream)
+
+			var routed bool
+			srv := &Server{
+				Access: map[string][]string{"postgres": {"/work/billing"}, "redis": {"/work/billing", GlobalAccess}},
+				Installs: func(project, name string) (Install, bool, error) {
+					routed = true
+					return Install{}, false, nil
+				},
+			}
+			containers := make(map[containerKey]*managedContainer)
+			for _, name := range []string{"postgres", "redis", "cache"} {
+				mc := &managedContainer{name: name, hostPort: hostPort, status: statusRunning}
+				mc.proxy = mc.buildProxy(srv.upstreamTransport())
+				containers[containerKey{name: name}] = mc
+			}
+			srv.Containers = testRegistry(t, containers)
+
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req.Header.Set(MCPServerHeader, tc.server)
+			if tc.project != "" {
+				req.Header.Set(MCPProjectHeader, tc.project)
+			}
+			rec := httptest.NewRecorder()
+			srv.proxyHandler(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tc.wantStatus)
+			}
+			if gotProjectHeader != "" {
+				t.Errorf("project header %q was forwarded to the container", gotProjectHeader)
+			}
+			if denied := tc.wantStatus == http.StatusForbidden; routed == denied {
+				t.Errorf("routed = %v, want a lookup only for allowed requests", routed)
+			}
+		})
+	}
+}
+
+func TestRoute(t *testing.T) {
+	installs := map[string]Install{
+		"/work/billing": {Digest: "abc", Volumes: []string{"/data:/data"}},
+		"/work/docs":    {Digest: "abc", Volumes: []string{"/data:/data"}},
+		"/work/website": {Digest: "def"},
+		"/work/old":     {Digest: "gone"},
+	}
+	tests := map[string]struct {
+		project string
+		digest  string
+		// registered are containers registered before the request.
+		registered map[containerKey]*managedContainer
+		// wantDigest is the install reached, "" for none.
+		wantDigest  string
+		wantVolumes []string
+		// wantKeys are the registered containers after the request.
+		wantKeys []containerKey
+	}{
+		"project's own install": {
+			project:     "/work/billing",
+			digest:      "def",
+			wantDigest:  "abc",
+			wantVolumes: []string{"/data:/data"},
+			wantKeys:    []containerKey{{name: "postgres", digest: "abc"}, {name: "postgres", digest: "abc", project: "/work/billing"}, {name: "postgres", digest: "def"}},
+		},
+		"project without a digest header": {
+			project:    "/work/website",
+			wantDigest: "def",
+			wantKeys:   []containerKey{{name: "postgres", digest: "abc"}, {name: "postgres", digest: "def"}},
+		},
+		"unknown project goes by digest": {
+			project:    "/work/unknown",
+			digest:     "def",
+			wantDigest: "def",
+			wantKeys:   []containerKey{{name: "postgres", digest: "abc"}, {name: "postgres", digest: "def"}},
+		},
+		"global install goes by digest": {
+			digest:     "abc",
+			wantDigest: "abc",
+			wantKeys:   []containerKey{{name: "postgres", digest: "abc"}, {name: "postgres", digest: "def"}},
+		},
+		"project's install not in the store": {
+			project:  "/work/old",
+			digest:   "abc",
+			wantKeys: []containerKey{{name: "postgres", digest: "abc"}, {name: "postgres", digest: "def"}},
+		},
+		"project's replaced install is dropped": {
+			project: "/work/billing",
+			registered: map[containerKey]*managedContainer{
+				{name: "postgres", digest: "def", project: "/work/billing"}: {name: "postgres", image: "pg:17", volumes: []string{"/data:/data"}},
+			},
+			wantDigest:  "abc",
+			wantVolumes: []string{"/data:/data"},
+			wantKeys:    []containerKey{{name: "postgres", digest: "abc"}, {name: "postgres", digest: "abc", project: "/work/billing"}, {name: "postgres", digest: "def"}},
+		},
+		"unregistered project is dropped": {
+			project: "/work/unknown",
+			digest:  "abc",
+			registered: map[containerKey]*managedContainer{
+				{name: "postgres", digest: "abc", project: "/work/unknown"}: {name: "postgres", image: "pg:16", volumes: []string{"/data:/data"}},
+			},
+			wantDigest: "abc",
+			wantKeys:   []containerKey{{name: "postgres", digest: "abc"}, {name: "postgres", digest: "def"}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv := &Server{Installs: func(project, name string) (Install, bool, error) {
+				install, ok := installs[project]
+				return install, ok && name == "postgres", nil
+			}}
+			containers := map[containerKey]*managedContainer{
+				{name: "postgres", digest: "abc"}: {name: "postgres", image: "pg:16"},
+				{name: "postgres", digest: "def"}: {name: "postgres", image: "pg:17"},
+			}
+			maps.Copy(containers, tc.registered)
+			srv.Containers = testRegistry(t, containers)
+
+			mc, ok, err := srv.route("postgres", tc.digest, tc.project)
+			if err != nil {
+				t.Fatalf("route() error = %v", err)
+			}
+			var keys []containerKey
+			for _, e := range srv.Containers.entries() {
+				keys = append(keys, e.key)
+			}
+			if !slices.Equal(keys, tc.wantKeys) {
+				t.Errorf("registered %v, want %v", keys, tc.wantKeys)
+			}
+			if ok != (tc.wantDigest != "") {
+				t.Fatalf("route() found = %v, want %v", ok, tc.wantDigest != "")
+			}
+			if !ok {
+				return
+			}
+			if want := map[string]string{"abc": "pg:16", "def": "pg:17"}[tc.wantDigest]; mc.image != want {
+				t.Errorf("route() reached %q, want %q", mc.image, want)
+			}
+			if !slices.Equal(mc.volumes, tc.wantVolumes) {
+				t.Errorf("route() mounts %q, want %q", mc.volumes, tc.wantVolumes)
+			}
+
+			// Repeated requests reach the same container, and other
+			// projects mounting the same volumes another one.
+			again, _, _ := srv.route("postgres", tc.digest, tc.project)
+			if again != mc {
+				t.Error("route() reached another container the second time")
+			}
+			if len(tc.wantVolumes) > 0 {
+				other, _, _ := srv.route("postgres", tc.digest, "/work/docs")
+				if other.containerName() == mc.containerName() {
+					t.Errorf("projects with the same volumes share container %s", mc.containerName())
+				}
+			}
+		})
+	}
+}
+
+func TestEagerContainers(t *testing.T) {
+	now := time.Now()
+	pgOld := &managedContainer{name: "postgres", eager: true, installed: now.Add(-time.Hour)}
+	pgNew := &managedContainer{name: "postgres", eager: true, installed: now}
+	redis := &managedContainer{name: "redis", eager: true, installed: now}
+	lazy := &managedContainer{name: "lazy", installed: now}
+
+	tests := map[string]struct {
+		entries []containerEntry
+		want    []*managedContainer
+	}{
+		"none eager": {
+			entries: []containerEntry{{key: containerKey{name: "lazy"}, mc: lazy}},
+		},
+		"one per server": {
+			entries: []containerEntry{
+				{key: containerKey{name: "lazy"}, mc: lazy},
+				{key: containerKey{name: "postgres", digest: "abc"}, mc: pgOld},
+				{key: containerKey{name: "redis"}, mc: redis},
+			},
+			want: []*managedContainer{pgOld, redis},
+		},
+		"newest install": {
+			entries: []containerEntry{
+				{key: containerKey{name: "postgres", digest: "abc"}, mc: pgNew},
+				{key: containerKey{name: "postgres", digest: "def"}, mc: pgOld},
+			},
+			want: []*managedContainer{pgNew},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := eagerContainers(tc.entries)
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("eagerContainers() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
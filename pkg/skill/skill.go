@@ -26,6 +26,8 @@ var (
 type Skill interface {
 	// Name returns the name of the package
 	Name() string
+	// Description returns the description from the package front matter
+	Description() string
 	// Type returns the type of the package (e.g. "skill", or "mcp" in the future)
 	Type() string
 	// Dir returns where the package contents lives on disk
@@ -78,19 +80,23 @@ func Load(dir string) (Skill, error) {
 }
 
 type skill struct {
-	SkillName     string            `json:"name"`
-	Description   string            `json:"description"`
-	License       string            `json:"license,omitempty"`
-	Compatability string            `json:"compatability,omitempty"`
-	Metadata      map[string]string `json:"metadata,omitempty"`
-	AllowedTools  string            `json:"allowed-tools,omitempty"` // space delimited string
-	dir           string
+	SkillName        string            `json:"name"`
+	SkillDescription string            `json:"description"`
+	License          string            `json:"license,omitempty"`
+	Compatability    string            `json:"compatability,omitempty"`
+	Metadata         map[string]string `json:"metadata,omitempty"`
+	AllowedTools     string            `json:"allowed-tools,omitempty"` // space delimited string
+	dir              string
 }
 
 func (s *skill) Name() string {
 	return s.SkillName
 }
 
+func (s *skill) Description() string {
+	return s.SkillDescription
+}
+
 func (s *skill) Type() string {
 	return TypeSkill
 }
@@ -105,10 +111,10 @@ func (s *skill) Validate() error {
 		err = errors.Join(err, fmt.Errorf("skill name must be max 64 characters with only lowercase letters, numbers, and hyphens. must not start or end with a hyphen"))
 	}
 
-	if len(s.Description) > 1024 {
+	if len(s.SkillDescription) > 1024 {
 		err = errors.Join(err, fmt.Errorf("skill description must be max 1024 characters"))
 	}
-	if len(s.Description) == 0 {
+	if len(s.SkillDescription) == 0 {
 		err = errors.Join(err, fmt.Errorf("skill description must be provided"))
 	}
 
@@ -118,3 +124,30 @@ func (s *skill) Validate() error {
 
 	return err
 }
+
+// ReadBody returns the Markdown body of the SKILL.md in dir, without its
+// YAML front matter.
+func ReadBody(dir string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(dir, skillsFileName))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s in %q: %w", skillsFileName, dir, err)
+	}
+
+	// Skip everything up to and including the closing front matter delimiter.
+	delims := 0
+	for len(data) > 0 && delims < 2 {
+		line := data
+		rest := []byte(nil)
+		if idx := bytes.IndexByte(data, '\n'); idx >= 0 {
+			line, rest = data[:idx+1], data[idx+1:]
+		}
+		if bytes.HasPrefix(line, yamlFrontMatterDelim) {
+			delims++
+		}
+		data = rest
+	}
+	if delims < 2 {
+		return nil, fmt.Errorf("%s in %q is missing YAML front matter ('---' delimiters)", skillsFileName, dir)
+	}
+	return bytes.TrimLeft(data, "\n"), nil
+}
@@ -91,105 +91,105 @@ func TestValidate(t *testing.T) {
 	}{
 		"valid skill": {
 			skill: skill{
-				SkillName:   "my-skill",
-				Description: "a valid description",
+				SkillName:        "my-skill",
+				SkillDescription: "a valid description",
 			},
 		},
 		"valid name single char": {
 			skill: skill{
-				SkillName:   "a",
-				Description: "desc",
+				SkillName:        "a",
+				SkillDescription: "desc",
 			},
 		},
 		"valid name max length": {
 			skill: skill{
-				SkillName:   "a" + strings.Repeat("-a", 31),
-				Description: "desc",
+				SkillName:        "a" + strings.Repeat("-a", 31),
+				SkillDescription: "desc",
 			},
 		},
 		"invalid name with uppercase": {
 			skill: skill{
-				SkillName:   "My-Skill",
-				Description: "desc",
+				SkillName:        "My-Skill",
+				SkillDescription: "desc",
 			},
 			wantErr:    true,
 			wantErrMsg: "skill name must be max 64 characters",
 		},
 		"invalid name starts with hyphen": {
 			skill: skill{
-				SkillName:   "-my-skill",
-				Description: "desc",
+				SkillName:        "-my-skill",
+				SkillDescription: "desc",
 			},
 			wantErr:    true,
 			wantErrMsg: "skill name must be max 64 characters",
 		},
 		"invalid name ends with hyphen": {
 			skill: skill{
-				SkillName:   "my-skill-",
-				Description: "desc",
+				SkillName:        "my-skill-",
+				SkillDescription: "desc",
 			},
 			wantErr:    true,
 			wantErrMsg: "skill name must be max 64 characters",
 		},
 		"invalid name with underscore": {
 			skill: skill{
-				SkillName:   "my_skill",
-				Description: "desc",
+				SkillName:        "my_skill",
+				SkillDescription: "desc",
 			},
 			wantErr:    true,
 			wantErrMsg: "skill name must be max 64 characters",
 		},
 		"empty name": {
 			skill: skill{
-				SkillName:   "",
-				Description: "desc",
+				SkillName:        "",
+				SkillDescription: "desc",
 			},
 			wantErr:    true,
 			wantErrMsg: "skill name must be max 64 characters",
 		},
 		"empty description": {
 			skill: skill{
-				SkillName:   "my-skill",
-				Description: "",
+				SkillName:        "my-skill",
+				SkillDescription: "",
 			},
 			wantErr:    true,
 			wantErrMsg: "skill description must be provided",
 		},
 		"description too long": {
 			skill: skill{
-				SkillName:   "my-skill",
-				Description: strings.Repeat("a", 1025),
+				SkillName:        "my-skill",
+				SkillDescription: strings.Repeat("a", 1025),
 			},
 			wantErr:    true,
 			wantErrMsg: "skill description must be max 1024 characters",
 		},
 		"description exactly at limit": {
 			skill: skill{
-				SkillName:   "my-skill",
-				Description: strings.Repeat("a", 1024),
+				SkillName:        "my-skill",
+				SkillDescription: strings.Repeat("a", 1024),
 			},
 		},
 		"compatability too long": {
 			skill: skill{
-				SkillName:     "my-skill",
-				Description:   "desc",
-				Compatability: strings.Repeat("a", 501),
+				SkillName:        "my-skill",
+				SkillDescription: "desc",
+				Compatability:    strings.Repeat("a", 501),
 			},
 			wantErr:    true,
 			wantErrMsg: "compatability must be max 500 characters",
 		},
 		"compatability exactly at limit": {
 			skill: skill{
-				SkillName:     "my-skill",
-				Description:   "desc",
-				Compatability: strings.Repeat("a", 500),
+				SkillName:        "my-skill",
+				SkillDescription: "desc",
+				Compatability:    strings.Repeat("a", 500),
 			},
 		},
 		"multiple validation errors": {
 			skill: skill{
-				SkillName:     "",
-				Description:   "",
-				Compatability: strings.Repeat("a", 501),
+				SkillName:        "",
+				SkillDescription: "",
+				Compatability:    strings.Repeat("a", 501),
 			},
 			wantErr: true,
 		},
@@ -209,3 +209,32 @@ func TestValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestReadBody(t *testing.T) {
+	tests := map[string]struct {
+		dir      string
+		wantBody string
+		wantErr  bool
+	}{
+		"front matter stripped": {
+			dir:      "valid-basic",
+			wantBody: "# My Skill\n",
+		},
+		"no front matter delimiters": {
+			dir:     "no-frontmatter",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			body, err := ReadBody(filepath.Join(testdataDir(t), tc.dir))
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ReadBody() error = %v, wantErr = %v", err, tc.wantErr)
+			}
+			if !tc.wantErr && !strings.HasPrefix(string(body), tc.wantBody) {
+				t.Errorf("ReadBody() = %q, want prefix %q", body, tc.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/agentpkg/agentpkg/pkg/credentials"
+	"github.com/agentpkg/agentpkg/pkg/fsutil"
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+// sha256Hex matches a hex-encoded SHA-256 digest.
+var sha256Hex = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// ArchiveSource is a skill published as a .tar.gz or .zip archive at an
+// HTTP(S) URL, e.g. a release artifact. The archive is extracted into the
+// store keyed by its SHA-256 digest, without its top-level directory if
+// it has nothing else, so a known digest is installed without downloading
+// it again.
+type ArchiveSource struct {
+	URL string
+	// Path is the skill's directory within the archive.
+	Path string
+	// SHA256 is the hex digest the archive must have, if known.
+	SHA256 string
+}
+
+var _ Source = &ArchiveSource{}
+
+func (a *ArchiveSource) Fetch(ctx context.Context, s store.Store) (*ResolvedSource, error) {
+	u, err := url.Parse(a.URL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("invalid archive URL %q: must be an http(s) URL", a.URL)
+	}
+	want := strings.ToLower(strings.TrimPrefix(a.SHA256, "sha256:"))
+	if want != "" && !sha256Hex.MatchString(want) {
+		return nil, fmt.Errorf("invalid sha256 %q for %s: must be 64 hex digits", a.SHA256, a.URL)
+	}
+
+	digest := want
+	cached := false
+	if digest != "" {
+		if cached, err = s.Exists(ArchiveStoreSegments(digest)...); err != nil {
+			return nil, fmt.Errorf("checking cache: %w", err)
+		}
+	}
+	if !cached {
+		if digest, err = a.download(ctx, s, want); err != nil {
+			return nil, err
+		}
+	}
+
+	contentSegs := ArchiveStoreSegments(digest)
+	if a.Path != "" {
+		contentSegs = append(contentSegs, strings.Split(a.Path, "/")...)
+	}
+	integrity, err := s.HashDir(contentSegs...)
+	if err != nil {
+		return nil, fmt.Errorf("computing integrity hash: %w", err)
+	}
+	return &ResolvedSource{
+		Dir:       s.Path(contentSegs...),
+		Integrity: integrity,
+		SHA256:    digest,
+	}, nil
+}
+
+// download fetches the archive, checks its digest against want unless it
+// is empty, and extracts it into the store. It returns the digest.
+func (a *ArchiveSource) download(ctx context.Context, s store.Store, want string) (string, error) {
+	s.EnsureDir("archives")
+	f, err := os.CreateTemp(s.Path("archives"), "download-*"+partialSuffix)
+	if err != nil {
+		return "", fmt.Errorf("creating download file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.URL, nil)
+	if err != nil {
+		return "", err
+	}
+	// Credentials are only sent over https, where they aren't readable on
+	// the wire, and dropped if a redirect leaves it.
+	if cred, ok := credentials.Lookup(req.URL.Host); ok && req.URL.Scheme == "https" {
+		req.Header.Set("Authorization", cred.BasicAuth())
+	}
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		if req.URL.Scheme != "https" {
+			req.Header.Del("Authorization")
+		}
+		return nil
+	}}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("downloading %s: %w", a.URL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("downloading %s: %s", a.URL, resp.Status)
+	}
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, h), resp.Body); err != nil {
+		return "", fmt.Errorf("downloading %s: %w", a.URL, err)
+	}
+	digest := hex.EncodeToString(h.Sum(nil))
+	if want != "" && digest != want {
+		return "", fmt.Errorf("checksum mismatch for %s: got sha256 %s, want %s", a.URL, digest, want)
+	}
+
+	segs := ArchiveStoreSegments(digest)
+	if exists, _ := s.Exists(segs...); exists {
+		return digest, nil
+	}
+	partial := s.Path(partialSegments(segs)...)
+	os.RemoveAll(partial)
+	if err := extractArchive(f, partial); err != nil {
+		os.RemoveAll(partial)
+		return "", fmt.Errorf("extracting %s: %w", a.URL, err)
+	}
+	err = fsutil.RemoveJunk(partial)
+	var root string
+	if err == nil {
+		root, err = archiveRoot(partial)
+	}
+	if err == nil {
+		err = os.Rename(root, s.Path(segs...))
+	}
+	os.RemoveAll(partial)
+	if err != nil {
+		return "", fmt.Errorf("finalizing %s: %w", a.URL, err)
+	}
+	return digest, nil
+}
+
+// extractArchive extracts the .tar.gz or .zip archive f into dest, telling
+// them apart by their content rather than the URL, which may not end in
+// an extension.
+func extractArchive(f *os.File, dest string) error {
+	magic := make([]byte, 4)
+	if _, err := f.ReadAt(magic, 0); err != nil {
+		return fmt.Errorf("reading archive: %w", err)
+	}
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		return fsutil.ExtractTarGz(f, dest, 0)
+	case bytes.HasPrefix(magic, []byte("PK\x03\x04")):
+		return fsutil.ExtractZip(f.Name(), dest, 0)
+	default:
+		return fmt.Errorf("not a .tar.gz or .zip archive")
+	}
+}
+
+// archiveRoot returns the directory an archive extracted into dir holds,
+// if that's all it holds, as in archives of a release's source tree, or
+// dir itself.
+func archiveRoot(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 1 && entries[0].IsDir() {
+		return filepath.Join(dir, entries[0].Name()), nil
+	}
+	return dir, nil
+}
+
+// ArchiveStoreSegments returns the store path segments of the extracted
+// archive with the hex SHA-256 digest (see ArchiveSource):
+// archives/<digest>.
+func ArchiveStoreSegments(digest string) []string {
+	return []string{"archives", digest}
+}
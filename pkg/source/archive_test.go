@@ -0,0 +1,121 @@
+package source
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/apkgtest"
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+func TestArchiveSourceFetch(t *testing.T) {
+	tarball := apkgtest.TarGz(t, map[string]string{
+		"pdf-1.0/SKILL.md":       "---\nname: pdf\n---\n",
+		"pdf-1.0/scripts/run.py": "print()",
+	})
+	zipped := apkgtest.Zip(t, map[string]string{
+		"README.md":           "skills",
+		"skills/pdf/SKILL.md": "---\nname: pdf\n---\n",
+	})
+	srv := apkgtest.NewFileServer(t, map[string][]byte{
+		"/pdf.tar.gz":      tarball,
+		"/skills.zip":      zipped,
+		"/releases/latest": tarball,
+		"/notes.txt":       []byte("not an archive"),
+	})
+
+	tests := map[string]struct {
+		src      ArchiveSource
+		wantFile string // relative to the resolved directory
+		wantErr  bool
+	}{
+		"tarball with a top-level directory": {
+			src:      ArchiveSource{URL: srv.URL + "/pdf.tar.gz"},
+			wantFile: "scripts/run.py",
+		},
+		"zip with the skill in a subdirectory": {
+			src:      ArchiveSource{URL: srv.URL + "/skills.zip", Path: "skills/pdf"},
+			wantFile: "SKILL.md",
+		},
+		"URL without an extension": {
+			src:      ArchiveSource{URL: srv.URL + "/releases/latest", SHA256: digest(tarball)},
+			wantFile: "SKILL.md",
+		},
+		"checksum mismatch": {
+			src:     ArchiveSource{URL: srv.URL + "/pdf.tar.gz", SHA256: digest(zipped)},
+			wantErr: true,
+		},
+		"malformed checksum": {
+			src:     ArchiveSource{URL: srv.URL + "/pdf.tar.gz", SHA256: "abc"},
+			wantErr: true,
+		},
+		"not an archive": {
+			src:     ArchiveSource{URL: srv.URL + "/notes.txt"},
+			wantErr: true,
+		},
+		"not found": {
+			src:     ArchiveSource{URL: srv.URL + "/missing.zip"},
+			wantErr: true,
+		},
+		"not http": {
+			src:     ArchiveSource{URL: "file:///tmp/pdf.tar.gz"},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := store.New(t.TempDir())
+			got, err := tc.src.Fetch(context.Background(), s)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Fetch() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				if entries, _ := os.ReadDir(s.Path("archives")); len(entries) != 0 {
+					t.Errorf("failed fetch left %d entries in the store", len(entries))
+				}
+				return
+			}
+			if _, err := os.Stat(filepath.Join(got.Dir, tc.wantFile)); err != nil {
+				t.Errorf("resolved directory lacks %s: %v", tc.wantFile, err)
+			}
+			if got.Integrity == "" {
+				t.Error("Integrity is empty")
+			}
+			if got.SHA256 == "" || (tc.src.SHA256 != "" && got.SHA256 != tc.src.SHA256) {
+				t.Errorf("SHA256 = %q, want the archive's digest", got.SHA256)
+			}
+		})
+	}
+}
+
+func TestArchiveSourceFetchCached(t *testing.T) {
+	tarball := apkgtest.TarGz(t, map[string]string{"SKILL.md": "---\nname: pdf\n---\n"})
+	srv := apkgtest.NewFileServer(t, map[string][]byte{"/pdf.tar.gz": tarball})
+	s := store.New(t.TempDir())
+
+	first, err := (&ArchiveSource{URL: srv.URL + "/pdf.tar.gz"}).Fetch(context.Background(), s)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	// With the digest known, the archive in the store is used.
+	again, err := (&ArchiveSource{URL: srv.URL + "/pdf.tar.gz", SHA256: first.SHA256}).Fetch(context.Background(), s)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if again.Dir != first.Dir || again.Integrity != first.Integrity {
+		t.Errorf("cached Fetch() = %+v, want %+v", again, first)
+	}
+	if n := srv.Requests("/pdf.tar.gz"); n != 1 {
+		t.Errorf("archive downloaded %d times, want once", n)
+	}
+}
+
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
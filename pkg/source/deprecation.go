@@ -0,0 +1,159 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+)
+
+// githubAPIBaseURL is the GitHub REST API host, replaced in tests.
+var githubAPIBaseURL = "https://api.github.com"
+
+// PackageDeprecation returns why version of the managed package pkg
+// ("npm:<pkg>", "uv:<pkg>", "uv-tool:<pkg>", or "go:<module>") should no longer be used:
+// the npm deprecation message, the PyPI yank reason, or the Go module's
+// deprecation or retraction notice. It returns "" if upstream doesn't
+// flag the version.
+func PackageDeprecation(ctx context.Context, pkg, version string) (string, error) {
+	return packageDeprecation(ctx, nil, pkg, version)
+}
+
+func packageDeprecation(ctx context.Context, m *Metadata, pkg, version string) (string, error) {
+	kind, name, _ := SplitPackage(pkg)
+	switch kind {
+	case "npm":
+		out, err := m.lookup(ctx, "npm deprecated "+name+"@"+version, npmRegistryHost, func(ctx context.Context) ([]byte, error) {
+			notice, err := npmDeprecation(ctx, name, version)
+			return []byte(notice), err
+		})
+		return string(out), err
+	case "uv", "uv-tool":
+		return pypiYank(ctx, m, name, version)
+	case "go":
+		out, err := m.lookup(ctx, "go deprecated "+name+"@"+version, goProxyHost, func(ctx context.Context) ([]byte, error) {
+			notice, err := goDeprecation(ctx, name, version)
+			return []byte(notice), err
+		})
+		return string(out), err
+	default:
+		return "", fmt.Errorf("unsupported package %q", pkg)
+	}
+}
+
+// npmDeprecation returns the deprecation message of name@version, which
+// `npm view` prints as a JSON string, or nothing if there is none.
+func npmDeprecation(ctx context.Context, name, version string) (string, error) {
+	cmd := exec.CommandContext(ctx, "npm", "view", name+"@"+version, "deprecated", "--json")
+	out, err := cmd.Output()
+	if err != nil {
+		return "", execError(err)
+	}
+	if len(strings.TrimSpace(string(out))) == 0 {
+		return "", nil
+	}
+
+	var message string
+	if err := json.Unmarshal(out, &message); err != nil {
+		return "", fmt.Errorf("parsing 'npm view %s@%s deprecated' output: %w", name, version, err)
+	}
+	if message == "" {
+		return "", nil
+	}
+	return "deprecated: " + message, nil
+}
+
+// pypiYank reports whether the release name==version was yanked from PyPI.
+func pypiYank(ctx context.Context, m *Metadata, name, version string) (string, error) {
+	url := fmt.Sprintf("%s/pypi/%s/%s/json", pypiBaseURL, name, version)
+
+	status, body, err := m.get(ctx, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("querying pypi for %s: %w", name, err)
+	}
+	if status != http.StatusOK {
+		return "", fmt.Errorf("pypi returned status %d for %s==%s", status, name, version)
+	}
+
+	var result struct {
+		Info struct {
+			Yanked       bool   `json:"yanked"`
+			YankedReason string `json:"yanked_reason"`
+		} `json:"info"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("decoding pypi response for %s: %w", name, err)
+	}
+
+	if !result.Info.Yanked {
+		return "", nil
+	}
+	if result.Info.YankedReason != "" {
+		return "yanked: " + result.Info.YankedReason, nil
+	}
+	return "yanked", nil
+}
+
+// goDeprecation returns the deprecation notice of the module at version,
+// or why the version was retracted. Packages in a subdirectory of their
+// module can't be listed by path and are reported as fine.
+func goDeprecation(ctx context.Context, module, version string) (string, error) {
+	cmd := exec.CommandContext(ctx, "go", "list", "-m", "-json", "-retracted", module+"@"+version)
+	cmd.Env = append(cmd.Environ(), "GOWORK=off")
+	out, err := cmd.Output()
+	if err != nil {
+		return "", nil
+	}
+
+	var result struct {
+		Deprecated string   `json:"Deprecated"`
+		Retracted  []string `json:"Retracted"`
+	}
+	if err := json.Unmarshal(out, &result); err != nil {
+		return "", fmt.Errorf("parsing 'go list -m %s@%s' output: %w", module, version, err)
+	}
+
+	switch {
+	case len(result.Retracted) > 0:
+		return "retracted: " + strings.Join(result.Retracted, "; "), nil
+	case result.Deprecated != "":
+		return "deprecated: " + result.Deprecated, nil
+	default:
+		return "", nil
+	}
+}
+
+// RepoArchived reports whether the repository at url is archived. Only
+// GitHub repositories are checked; other hosts are reported as not
+// archived.
+func RepoArchived(ctx context.Context, url string) (bool, error) {
+	return repoArchived(ctx, nil, url)
+}
+
+func repoArchived(ctx context.Context, m *Metadata, url string) (bool, error) {
+	host, repoPath, err := parseGitURL(url)
+	if err != nil {
+		return false, fmt.Errorf("parsing git URL: %w", err)
+	}
+	if host != "github.com" {
+		return false, nil
+	}
+
+	status, body, err := m.get(ctx, githubAPIBaseURL+"/repos/"+repoPath, map[string]string{"Accept": "application/vnd.github+json"})
+	if err != nil {
+		return false, fmt.Errorf("querying github for %s: %w", repoPath, err)
+	}
+	if status != http.StatusOK {
+		return false, fmt.Errorf("github returned status %d for %s", status, repoPath)
+	}
+
+	var result struct {
+		Archived bool `json:"archived"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return false, fmt.Errorf("decoding github response for %s: %w", repoPath, err)
+	}
+	return result.Archived, nil
+}
@@ -0,0 +1,104 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPypiYank(t *testing.T) {
+	tests := map[string]struct {
+		info       map[string]any
+		statusCode int
+		want       string
+		wantErr    bool
+	}{
+		"not yanked": {
+			info:       map[string]any{"yanked": false},
+			statusCode: http.StatusOK,
+		},
+		"yanked with reason": {
+			info:       map[string]any{"yanked": true, "yanked_reason": "broken wheel"},
+			statusCode: http.StatusOK,
+			want:       "yanked: broken wheel",
+		},
+		"yanked without reason": {
+			info:       map[string]any{"yanked": true, "yanked_reason": nil},
+			statusCode: http.StatusOK,
+			want:       "yanked",
+		},
+		"unknown release": {
+			statusCode: http.StatusNotFound,
+			wantErr:    true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/pypi/pkg/1.0/json" || tc.statusCode != http.StatusOK {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				json.NewEncoder(w).Encode(map[string]any{"info": tc.info})
+			}))
+			defer server.Close()
+
+			orig := pypiBaseURL
+			pypiBaseURL = server.URL
+			t.Cleanup(func() { pypiBaseURL = orig })
+
+			got, err := PackageDeprecation(context.Background(), "uv:pkg", "1.0")
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("PackageDeprecation() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("PackageDeprecation() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRepoArchived(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/org/old":
+			json.NewEncoder(w).Encode(map[string]any{"archived": true})
+		case "/repos/org/active":
+			json.NewEncoder(w).Encode(map[string]any{"archived": false})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	orig := githubAPIBaseURL
+	githubAPIBaseURL = server.URL
+	t.Cleanup(func() { githubAPIBaseURL = orig })
+
+	tests := map[string]struct {
+		url     string
+		want    bool
+		wantErr bool
+	}{
+		"archived":             {url: "https://github.com/org/old.git", want: true},
+		"active":               {url: "https://github.com/org/active.git"},
+		"ssh shorthand":        {url: "git@github.com:org/old.git", want: true},
+		"other host unchecked": {url: "https://gitlab.com/org/old.git"},
+		"missing repository":   {url: "https://github.com/org/gone.git", wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := RepoArchived(context.Background(), tc.url)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("RepoArchived(%q) error = %v, wantErr %v", tc.url, err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("RepoArchived(%q) = %v, want %v", tc.url, got, tc.want)
+			}
+		})
+	}
+}
@@ -7,16 +7,33 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 
+	"github.com/agentpkg/agentpkg/pkg/credentials"
+	"github.com/agentpkg/agentpkg/pkg/fsutil"
 	"github.com/agentpkg/agentpkg/pkg/store"
 )
 
+// partialSuffix is appended to the store directory of a clone that has not
+// completed yet.
+const partialSuffix = ".partial"
+
+// sparseMarker marks a cached clone that holds only the subpaths skills
+// were fetched from (see GitSource.complete).
+const sparseMarker = ".apkg-sparse"
+
 type GitSource struct {
 	URL  string
 	Path string
 	Ref  string
+
+	// authEnv caches the environment carrying stored credentials for the
+	// repository's host (see credentials.Lookup).
+	authEnv    []string
+	authLoaded bool
 }
 
 var _ Source = &GitSource{}
@@ -43,12 +60,26 @@ func (g *GitSource) Fetch(ctx context.Context, s store.Store) (*ResolvedSource,
 		// 3. Create parent directories for the clone destination.
 		s.EnsureDir(segs[:len(segs)-1]...)
 
-		// 4-5. Clone the repository into the store path.
-		dest := s.Path(segs...)
-		if err := g.clone(ctx, dest, commit); err != nil {
-			s.Remove(segs...)
-			return nil, fmt.Errorf("cloning %s: %w", g.URL, err)
+		// 4-5. Clone the repository into a partial directory next to the
+		// final store path. An interrupted clone is left in place so the
+		// next fetch resumes from the objects already downloaded, and the
+		// directory is only moved into place once checkout has completed.
+		// The checkout's .git is only needed to resume and is stripped
+		// with other junk before the move.
+		partial, err := g.checkout(ctx, s, segs, commit)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.Rename(partial, s.Path(segs...)); err != nil {
+			return nil, fmt.Errorf("finalizing clone of %s: %w", g.URL, err)
 		}
+	} else if isGitDir(s.Path(segs...)) {
+		// Clones cached by older versions kept their .git.
+		if err := os.RemoveAll(filepath.Join(s.Path(segs...), ".git")); err != nil {
+			return nil, fmt.Errorf("removing git metadata of cached %s: %w", g.URL, err)
+		}
+	} else if err := g.complete(ctx, s, segs, commit); err != nil {
+		return nil, err
 	}
 
 	// 6. Compute integrity hash over the content subdirectory.
@@ -72,6 +103,77 @@ func (g *GitSource) Fetch(ctx context.Context, s store.Store) (*ResolvedSource,
 	}, nil
 }
 
+// checkout clones the repository at commit into the partial directory
+// next to the store entry at segs and strips it down to the checkout,
+// marking sparse checkouts (see sparseMarker). It returns the partial
+// directory.
+func (g *GitSource) checkout(ctx context.Context, s store.Store, segs []string, commit string) (string, error) {
+	partial := s.Path(partialSegments(segs)...)
+	if err := g.clone(ctx, partial, commit); err != nil {
+		return "", fmt.Errorf("cloning %s: %w", g.URL, err)
+	}
+	if err := fsutil.RemoveJunk(partial); err != nil {
+		return "", fmt.Errorf("cleaning clone of %s: %w", g.URL, err)
+	}
+	if g.Path != "" {
+		if err := os.WriteFile(filepath.Join(partial, sparseMarker), nil, 0o644); err != nil {
+			return "", fmt.Errorf("marking clone of %s: %w", g.URL, err)
+		}
+	}
+	return partial, nil
+}
+
+// complete fetches what the cached clone at segs lacks: the subpath of g
+// if the clone is a sparse checkout of other subpaths, or the whole tree
+// if g has no subpath. The content of a commit never changes, so subpaths
+// checked out separately are merged into the one store entry.
+func (g *GitSource) complete(ctx context.Context, s store.Store, segs []string, commit string) error {
+	dir := s.Path(segs...)
+	if _, err := os.Stat(filepath.Join(dir, sparseMarker)); err != nil {
+		return nil
+	}
+	if g.Path != "" {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(g.Path))); err == nil {
+			return nil
+		}
+	}
+
+	partial, err := g.checkout(ctx, s, segs, commit)
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(partial)
+
+	if g.Path == "" {
+		old := dir + ".old"
+		os.RemoveAll(old)
+		if err := os.Rename(dir, old); err != nil {
+			return fmt.Errorf("replacing sparse clone of %s: %w", g.URL, err)
+		}
+		if err := os.Rename(partial, dir); err != nil {
+			return fmt.Errorf("finalizing clone of %s: %w", g.URL, err)
+		}
+		return os.RemoveAll(old)
+	}
+
+	src := filepath.Join(partial, filepath.FromSlash(g.Path))
+	dst := filepath.Join(dir, filepath.FromSlash(g.Path))
+	if _, err := os.Stat(src); err != nil {
+		return fmt.Errorf("path %q not found in %s at %s", g.Path, g.URL, commit)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	// Another apkg may have added the subpath meanwhile.
+	if err := os.Rename(src, dst); err != nil {
+		if fi, statErr := os.Stat(dst); statErr == nil && fi.IsDir() {
+			return nil
+		}
+		return fmt.Errorf("adding %s to the clone of %s: %w", g.Path, g.URL, err)
+	}
+	return nil
+}
+
 // resolveRef resolves g.Ref to a full 40-char commit hash.
 // Full commit hashes are returned as-is. Short commit hashes are resolved
 // via git fetch + rev-parse. Branch and tag names are resolved via ls-remote.
@@ -84,7 +186,7 @@ func (g *GitSource) resolveRef(ctx context.Context) (string, error) {
 		return g.resolveShortHash(ctx)
 	}
 
-	cmd := exec.CommandContext(ctx, "git", "ls-remote", g.URL, g.Ref, g.Ref+"^{}")
+	cmd := g.git(ctx, "ls-remote", g.URL, g.Ref, g.Ref+"^{}")
 	out, err := cmd.Output()
 	if err != nil {
 		return "", execError(err)
@@ -114,7 +216,7 @@ func (g *GitSource) resolveRef(ctx context.Context) (string, error) {
 // resolveShortHash expands a short commit hash to the full 40-char hash
 // by listing all refs and prefix-matching their commit hashes.
 func (g *GitSource) resolveShortHash(ctx context.Context) (string, error) {
-	cmd := exec.CommandContext(ctx, "git", "ls-remote", g.URL)
+	cmd := g.git(ctx, "ls-remote", g.URL)
 	out, err := cmd.Output()
 	if err != nil {
 		return "", execError(err)
@@ -144,34 +246,47 @@ func (g *GitSource) resolveShortHash(ctx context.Context) (string, error) {
 	return match, nil
 }
 
-// clone performs a shallow clone of the repository into dest.
-// Uses --branch for branch/tag refs, and init+fetch for commit hashes.
+// clone performs a shallow clone of the repository into dest, resuming a
+// previously interrupted clone when dest already contains a git directory.
+// If resuming fails (e.g. the partial clone is corrupt), the directory is
+// wiped and the clone is retried once from scratch.
 // commit is the full resolved hash used for the fetch-by-SHA path.
 func (g *GitSource) clone(ctx context.Context, dest string, commit string) error {
-	if isHexString(g.Ref) {
-		return g.cloneCommit(ctx, dest, commit)
+	resuming := isGitDir(dest)
+
+	err := g.fetchInto(ctx, dest, commit)
+	if err != nil && resuming && ctx.Err() == nil {
+		if rmErr := os.RemoveAll(dest); rmErr != nil {
+			return errors.Join(err, rmErr)
+		}
+		err = g.fetchInto(ctx, dest, commit)
 	}
-	return g.cloneBranch(ctx, dest)
+	return err
 }
 
-func (g *GitSource) cloneBranch(ctx context.Context, dest string) error {
-	cmd := exec.CommandContext(ctx, "git", "clone", "--depth", "1", "--branch", g.Ref, g.URL, dest)
-	if _, err := cmd.Output(); err != nil {
-		return execError(err)
+// fetchInto initializes dest (unless it is already a git directory), fetches
+// a single commit, and checks it out. When the source has a subpath, the
+// fetch is blobless (--filter=blob:none) and a sparse checkout limits the
+// blobs fetched on demand to that subpath, so only the skill's content is
+// downloaded from very large repositories.
+func (g *GitSource) fetchInto(ctx context.Context, dest string, commit string) error {
+	var steps [][]string
+	if !isGitDir(dest) {
+		steps = append(steps,
+			[]string{"init", dest},
+			[]string{"-C", dest, "remote", "add", "origin", g.URL},
+		)
 	}
-	return nil
-}
+	if g.Path != "" {
+		steps = append(steps, []string{"-C", dest, "sparse-checkout", "set", "--", g.Path})
+	}
+	steps = append(steps,
+		g.fetchArgs(dest, commit),
+		[]string{"-C", dest, "checkout", "--force", "FETCH_HEAD"},
+	)
 
-// cloneCommit fetches a single commit by SHA. Requires the server to support
-// uploadpack.allowReachableSHA1InWant (GitHub, GitLab, and Bitbucket do).
-func (g *GitSource) cloneCommit(ctx context.Context, dest string, commit string) error {
-	for _, args := range [][]string{
-		{"init", dest},
-		{"-C", dest, "remote", "add", "origin", g.URL},
-		{"-C", dest, "fetch", "--depth", "1", "origin", commit},
-		{"-C", dest, "checkout", "FETCH_HEAD"},
-	} {
-		cmd := exec.CommandContext(ctx, "git", args...)
+	for _, args := range steps {
+		cmd := g.git(ctx, args...)
 		if _, err := cmd.Output(); err != nil {
 			return execError(err)
 		}
@@ -179,6 +294,76 @@ func (g *GitSource) cloneCommit(ctx context.Context, dest string, commit string)
 	return nil
 }
 
+// fetchArgs returns the git arguments used to fetch the target commit into
+// dest. Hex refs are fetched by SHA, which requires the server to support
+// uploadpack.allowReachableSHA1InWant (GitHub, GitLab, and Bitbucket do);
+// branch and tag names are fetched by name.
+func (g *GitSource) fetchArgs(dest string, commit string) []string {
+	args := []string{"-C", dest, "fetch", "--depth", "1"}
+	if g.Path != "" {
+		args = append(args, "--filter=blob:none")
+	}
+
+	target := g.Ref
+	if isHexString(g.Ref) {
+		target = commit
+	}
+	return append(args, "origin", target)
+}
+
+// git returns a git command authenticated with the credential stored for
+// the repository's host by `apkg login`, if any. The credential is passed
+// as an http.extraHeader through the environment rather than argv so it
+// doesn't show up in process listings. Plain http and SSH URLs, and hosts
+// without a stored credential, fall back to git's own credential helpers
+// and keys.
+func (g *GitSource) git(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	if env := g.credentialEnv(); len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	return cmd
+}
+
+func (g *GitSource) credentialEnv() []string {
+	if g.authLoaded {
+		return g.authEnv
+	}
+	g.authLoaded = true
+
+	// Like archives, credentials are only sent over https, where they
+	// aren't readable on the wire.
+	u, err := url.Parse(g.URL)
+	if err != nil || u.Scheme != "https" {
+		return nil
+	}
+	cred, ok := credentials.Lookup(u.Host)
+	if !ok {
+		return nil
+	}
+	g.authEnv = []string{
+		"GIT_CONFIG_COUNT=1",
+		"GIT_CONFIG_KEY_0=http.extraHeader",
+		"GIT_CONFIG_VALUE_0=Authorization: " + cred.BasicAuth(),
+	}
+	return g.authEnv
+}
+
+// partialSegments returns the store segments for the in-progress clone of
+// the repo at segs (the final segment suffixed with ".partial").
+func partialSegments(segs []string) []string {
+	partial := make([]string, len(segs))
+	copy(partial, segs)
+	partial[len(partial)-1] += partialSuffix
+	return partial
+}
+
+// isGitDir reports whether dir contains a .git directory.
+func isGitDir(dir string) bool {
+	info, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil && info.IsDir()
+}
+
 // repoSegments returns the store path segments for caching this repo at a given commit.
 // e.g. "https://github.com/anthropics/skills.git" at commit "abc123..." →
 //
@@ -194,6 +379,12 @@ func (g *GitSource) repoSegments(commit string) ([]string, error) {
 	return segs, nil
 }
 
+// GitStoreSegments returns the store path segments of the clone of the repo
+// at url checked out at commit (see GitSource.Fetch).
+func GitStoreSegments(url, commit string) ([]string, error) {
+	return (&GitSource{URL: url}).repoSegments(commit)
+}
+
 // parseGitURL extracts the host and repository path from a git URL.
 // Supports HTTPS URLs and SSH shorthand (git@host:owner/repo.git).
 func parseGitURL(rawURL string) (host, repoPath string, err error) {
@@ -5,9 +5,13 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
+	"github.com/agentpkg/agentpkg/pkg/apkgtest"
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/credentials"
 	"github.com/agentpkg/agentpkg/pkg/store"
 )
 
@@ -368,6 +372,14 @@ func TestFetch(t *testing.T) {
 				t.Fatalf("Dir %q is not a directory", result.Dir)
 			}
 
+			segs, err := g.repoSegments(wantCommit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Stat(s.Path(append(segs, ".git")...)); !os.IsNotExist(err) {
+				t.Errorf("expected .git to be stripped from the store, stat error = %v", err)
+			}
+
 			if tc.path == "skills/pdf" {
 				manifest := filepath.Join(result.Dir, "manifest.toml")
 				if _, err := os.Stat(manifest); err != nil {
@@ -378,6 +390,64 @@ func TestFetch(t *testing.T) {
 	}
 }
 
+// TestFetchOverHTTP fetches from a repository served like git hosts
+// serve them, rather than from a path on disk.
+func TestFetchOverHTTP(t *testing.T) {
+	repo := apkgtest.NewGitServer(t).NewRepo(t, "org/skills")
+	first := repo.Commit(t, map[string]string{"skills/pdf/SKILL.md": "---\nname: pdf\n---\n"}, "add pdf")
+	repo.Tag(t, "v1.0", "")
+	latest := repo.Commit(t, map[string]string{"skills/pdf/SKILL.md": "---\nname: pdf\ndescription: PDFs\n---\n"}, "describe pdf")
+
+	tests := map[string]struct {
+		ref        string
+		wantCommit string
+		wantErr    bool
+	}{
+		"tag":         {ref: "v1.0", wantCommit: first},
+		"branch":      {ref: "main", wantCommit: latest},
+		"missing ref": {ref: "v9.9", wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := &GitSource{URL: repo.URL, Ref: tc.ref, Path: "skills/pdf"}
+			result, err := g.Fetch(context.Background(), store.New(t.TempDir()))
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Fetch() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if result.Commit != tc.wantCommit {
+				t.Errorf("Fetch() Commit = %q, want %q", result.Commit, tc.wantCommit)
+			}
+			if _, err := os.Stat(filepath.Join(result.Dir, "SKILL.md")); err != nil {
+				t.Errorf("Fetch(): %v", err)
+			}
+		})
+	}
+}
+
+func TestFetchRootIntegrity(t *testing.T) {
+	requireGit(t)
+	repoURL, _ := setupBareRepo(t)
+
+	// The clone's .git differs between stores (index timestamps, logs),
+	// so a skill at the repository root only hashes the same without it.
+	var integrities []string
+	for range 2 {
+		g := &GitSource{URL: repoURL, Ref: "main"}
+		result, err := g.Fetch(context.Background(), store.New(t.TempDir()))
+		if err != nil {
+			t.Fatalf("Fetch() error: %v", err)
+		}
+		integrities = append(integrities, result.Integrity)
+	}
+	if integrities[0] != integrities[1] {
+		t.Errorf("Integrity differs between clones: %q != %q", integrities[0], integrities[1])
+	}
+}
+
 func TestFetchCached(t *testing.T) {
 	requireGit(t)
 	repoURL, _ := setupBareRepo(t)
@@ -421,3 +491,142 @@ func TestFetchContextCanceled(t *testing.T) {
 		t.Fatal("expected error with canceled context, got nil")
 	}
 }
+
+func TestFetchResumesPartialClone(t *testing.T) {
+	requireGit(t)
+	repoURL, commit := setupBareRepo(t)
+
+	tests := map[string]struct {
+		setupPartial func(t *testing.T, dir string)
+	}{
+		"initialized but never fetched": {
+			setupPartial: func(t *testing.T, dir string) {
+				for _, args := range [][]string{
+					{"init", dir},
+					{"-C", dir, "remote", "add", "origin", repoURL},
+				} {
+					if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+						t.Fatalf("git %v: %v\n%s", args, err, out)
+					}
+				}
+			},
+		},
+		"corrupt git directory is recloned": {
+			setupPartial: func(t *testing.T, dir string) {
+				os.MkdirAll(filepath.Join(dir, ".git"), 0o755)
+				os.WriteFile(filepath.Join(dir, ".git", "HEAD"), []byte("garbage"), 0o644)
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := store.New(t.TempDir())
+			g := &GitSource{URL: repoURL, Ref: "main", Path: "skills/pdf"}
+
+			segs, err := g.repoSegments(commit)
+			if err != nil {
+				t.Fatalf("repoSegments() error: %v", err)
+			}
+			partial := s.Path(partialSegments(segs)...)
+			tc.setupPartial(t, partial)
+
+			result, err := g.Fetch(context.Background(), s)
+			if err != nil {
+				t.Fatalf("Fetch() error: %v", err)
+			}
+
+			if _, err := os.Stat(filepath.Join(result.Dir, "manifest.toml")); err != nil {
+				t.Errorf("expected manifest.toml in %q: %v", result.Dir, err)
+			}
+			if _, err := os.Stat(partial); !os.IsNotExist(err) {
+				t.Errorf("partial clone dir %q still exists after successful fetch", partial)
+			}
+		})
+	}
+}
+
+func TestFetchSparseSubpaths(t *testing.T) {
+	repo := apkgtest.NewGitServer(t).NewRepo(t, "org/skills")
+	repo.Commit(t, map[string]string{
+		"README.md":           "skills",
+		"skills/pdf/SKILL.md": "---\nname: pdf\n---\n",
+		"skills/doc/SKILL.md": "---\nname: doc\n---\n",
+	}, "add skills")
+
+	// Skills from the same commit share a clone, which gains each
+	// subpath as it is fetched, and the whole tree for a root skill.
+	s := store.New(t.TempDir())
+	tests := []struct {
+		path     string
+		wantFile string
+	}{
+		{path: "skills/pdf", wantFile: "SKILL.md"},
+		{path: "skills/doc", wantFile: "SKILL.md"},
+		{path: "skills/pdf", wantFile: "SKILL.md"},
+		{path: "", wantFile: "README.md"},
+		{path: "skills/doc", wantFile: "SKILL.md"},
+	}
+	for _, tc := range tests {
+		g := &GitSource{URL: repo.URL, Ref: "main", Path: tc.path}
+		result, err := g.Fetch(context.Background(), s)
+		if err != nil {
+			t.Fatalf("Fetch(%q) error: %v", tc.path, err)
+		}
+		if _, err := os.Stat(filepath.Join(result.Dir, tc.wantFile)); err != nil {
+			t.Errorf("Fetch(%q): %v", tc.path, err)
+		}
+	}
+
+	g := &GitSource{URL: repo.URL, Ref: "main", Path: "skills/missing"}
+	if _, err := g.Fetch(context.Background(), s); err == nil {
+		t.Error("Fetch() of a missing path error = nil")
+	}
+}
+
+func TestCredentialEnv(t *testing.T) {
+	tests := map[string]struct {
+		url      string
+		stored   bool
+		wantAuth bool
+	}{
+		"https with a credential": {
+			url:      "https://https-cred.example.com/org/skills.git",
+			stored:   true,
+			wantAuth: true,
+		},
+		"https without a credential": {
+			url: "https://https-nocred.example.com/org/skills.git",
+		},
+		"plain http": {
+			url:    "http://http-cred.example.com/org/skills.git",
+			stored: true,
+		},
+		"ssh": {
+			url:    "git@ssh-cred.example.com:org/skills.git",
+			stored: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			if tc.stored {
+				dir, err := config.GlobalConfigDir()
+				if err != nil {
+					t.Fatal(err)
+				}
+				host := credentials.Host(tc.url)
+				if err := (&credentials.File{Dir: dir}).Set(host, credentials.Credential{Token: "secret"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			env := (&GitSource{URL: tc.url}).credentialEnv()
+			gotAuth := slices.ContainsFunc(env, func(v string) bool { return strings.Contains(v, "Authorization:") })
+			if gotAuth != tc.wantAuth {
+				t.Errorf("credentialEnv() = %q, want an Authorization header: %v", env, tc.wantAuth)
+			}
+		})
+	}
+}
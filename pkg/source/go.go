@@ -2,11 +2,15 @@ package source
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
 	"strings"
 
 	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/runtimes"
 	"github.com/agentpkg/agentpkg/pkg/store"
 	"github.com/pelletier/go-toml/v2"
 )
@@ -14,6 +18,15 @@ import (
 type GoSource struct {
 	Package   string
 	MCPConfig config.MCPSource
+	// Version, if set, is the concrete version to install instead of
+	// resolving Package's, e.g. the one recorded in the lockfile.
+	Version string
+	// Output, if set, receives the output of go install as it installs the
+	// package, e.g. for apkg install --verbose.
+	Output io.Writer
+	// Go, if set, is the toolchain the package is built with instead of
+	// the go on PATH, e.g. the one the project pins for mise.
+	Go *runtimes.Runtime
 }
 
 var _ Source = &GoSource{}
@@ -35,7 +48,7 @@ func (s *GoSource) Fetch(ctx context.Context, store store.Store) (*ResolvedSourc
 		store.EnsureDir(segs...)
 		path := store.Path(segs...)
 
-		if err := s.install(ctx, path, version); err != nil {
+		if err := s.install(ctx, store, path, version); err != nil {
 			store.Remove(segs...)
 			return nil, fmt.Errorf("failed to install go module %s@%s: %w", s.modulePath(), version, err)
 		}
@@ -47,25 +60,71 @@ func (s *GoSource) Fetch(ctx context.Context, store store.Store) (*ResolvedSourc
 		return nil, fmt.Errorf("writing mcp config: %w", err)
 	}
 
-	integrity, err := store.HashDir(segs...)
+	pkg := "go:" + s.modulePath()
+	integrity, err := managedIntegrity(store, segs, pkg, version, func() (string, error) {
+		return s.moduleSum(ctx, version)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	treeIntegrity, err := store.HashDir(segs...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to compute integrity hash: %w", err)
 	}
 
 	return &ResolvedSource{
-		Dir:       store.Path(segs...),
-		Integrity: integrity,
+		Dir:           store.Path(segs...),
+		Integrity:     integrity,
+		Package:       pkg,
+		Version:       version,
+		TreeIntegrity: treeIntegrity,
 	}, nil
 }
 
+// moduleSum returns the go.sum hash of the module at version. Packages in
+// a subdirectory of their module can't be downloaded by path, so they get
+// "": their integrity then only covers the version, which the Go checksum
+// database already ties to fixed content. Other failures, e.g. an
+// unreachable module proxy, are returned.
+func (s *GoSource) moduleSum(ctx context.Context, version string) (string, error) {
+	mod := s.modulePath() + "@" + version
+	// go mod download -json reports failures in the Error field of its
+	// output, and exits non-zero.
+	out, runErr := s.goCmd(ctx, "mod", "download", "-json", mod).Output()
+
+	var result struct {
+		Sum   string `json:"Sum"`
+		Error string `json:"Error"`
+	}
+	if err := json.Unmarshal(out, &result); err != nil {
+		if runErr != nil {
+			return "", fmt.Errorf("go mod download %s: %w", mod, runErr)
+		}
+		return "", fmt.Errorf("parsing go mod download output for %s: %w", mod, err)
+	}
+	if result.Error != "" {
+		// The module proxy answers 404 or 410 for package paths below a
+		// module root.
+		if msg := strings.ToLower(result.Error); strings.Contains(msg, "not found") || strings.Contains(msg, "410 gone") {
+			return "", nil
+		}
+		return "", fmt.Errorf("go mod download %s: %s", mod, result.Error)
+	}
+	return result.Sum, nil
+}
+
 func (s *GoSource) resolveConcreteVersion(ctx context.Context) (string, error) {
+	if s.Version != "" {
+		return s.Version, nil
+	}
+
 	mod := s.modulePath()
 	ver := s.versionSuffix()
 
 	// go list -m resolves any ref (latest, branch name, tag, pseudo-version)
 	// to a concrete version string. This works for module-root packages.
-	cmd := exec.CommandContext(ctx, "go", "list", "-m", "-f", "{{.Version}}", mod+"@"+ver)
-	cmd.Env = append(cmd.Environ(), "GOWORK=off")
+	cmd := s.goCmd(ctx, "list", "-m", "-f", "{{.Version}}", mod+"@"+ver)
 	out, err := cmd.Output()
 	if err == nil {
 		version := strings.TrimSpace(string(out))
@@ -109,16 +168,25 @@ func (s *GoSource) versionSuffix() string {
 	return "latest"
 }
 
-func (s *GoSource) install(ctx context.Context, dest string, version string) error {
+func (s *GoSource) install(ctx context.Context, st store.Store, dest string, version string) error {
 	pkg := fmt.Sprintf("%s@%s", s.modulePath(), version)
 
-	cmd := exec.CommandContext(ctx, "go", "install", pkg)
-	cmd.Env = append(cmd.Environ(), "GOBIN="+dest+"/bin", "GOWORK=off")
-	if _, err := cmd.Output(); err != nil {
-		return execError(err)
-	}
+	cmd := s.goCmd(ctx, "install", pkg)
+	cmd.Env = append(cmd.Env, "GOBIN="+dest+"/bin")
+	return runTool(cmd, st, "go-"+pkg, s.Output)
+}
 
-	return nil
+// goCmd returns a go command outside any workspace, running the Go
+// toolchain when it is set.
+func (s *GoSource) goCmd(ctx context.Context, args ...string) *exec.Cmd {
+	if s.Go == nil {
+		cmd := exec.CommandContext(ctx, "go", args...)
+		cmd.Env = append(cmd.Environ(), "GOWORK=off")
+		return cmd
+	}
+	cmd := exec.CommandContext(ctx, s.Go.Bin, args...)
+	cmd.Env = append(s.Go.Env(os.Environ()), "GOWORK=off")
+	return cmd
 }
 
 func (s *GoSource) writeMCPConfig(store store.Store, segs []string) error {
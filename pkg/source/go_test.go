@@ -2,13 +2,16 @@ package source
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 
 	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/runtimes"
 	"github.com/agentpkg/agentpkg/pkg/store"
 )
 
@@ -286,3 +289,52 @@ func TestGoFetchContextCanceled(t *testing.T) {
 		t.Fatal("expected error with canceled context, got nil")
 	}
 }
+
+func TestGoModuleSum(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script")
+	}
+	tests := map[string]struct {
+		output  string
+		exit    int
+		want    string
+		wantErr bool
+	}{
+		"module": {
+			output: `{"Path": "example.com/tool", "Version": "v1.0.0", "Sum": "h1:abc="}`,
+			want:   "h1:abc=",
+		},
+		"package below its module root": {
+			output: `{"Path": "example.com/tool/cmd/tool", "Version": "v1.0.0", "Error": "reading https://proxy.golang.org/example.com/tool/cmd/tool/@v/v1.0.0.info: 404 Not Found"}`,
+			exit:   1,
+		},
+		"unreachable proxy": {
+			output:  `{"Path": "example.com/tool", "Version": "v1.0.0", "Error": "dial tcp: lookup proxy.golang.org: no such host"}`,
+			exit:    1,
+			wantErr: true,
+		},
+		"toolchain failure": {
+			exit:    2,
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			bin := filepath.Join(t.TempDir(), "go")
+			script := fmt.Sprintf("#!/bin/sh\necho '%s'\nexit %d\n", tc.output, tc.exit)
+			if err := os.WriteFile(bin, []byte(script), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			s := &GoSource{Package: "example.com/tool", Go: &runtimes.Runtime{Name: runtimes.Go, Bin: bin}}
+
+			got, err := s.moduleSum(context.Background(), "v1.0.0")
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("moduleSum() error = %v, wantErr = %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("moduleSum() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package source
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+// packageSumFile holds a managed package's normalized integrity next to
+// its installed tree, so cached installs don't query the registry again.
+const packageSumFile = ".apkg-integrity"
+
+// PackageIntegrity returns the integrity of a managed package release,
+// computed over its name, version, and the checksum the registry publishes
+// for it (artifact) rather than over the installed tree. npm and uv
+// installs include platform-specific binaries, so only this hash is the
+// same on every machine and can be shared through the lockfile.
+func PackageIntegrity(pkg, version, artifact string) string {
+	h := sha256.Sum256([]byte(pkg + "@" + version + "\n" + artifact + "\n"))
+	return "sha256:" + hex.EncodeToString(h[:])
+}
+
+// managedIntegrity returns the PackageIntegrity of the package installed
+// at segs, computing it with artifact (which queries the registry) the
+// first time and recording it in packageSumFile.
+func managedIntegrity(st store.Store, segs []string, pkg, version string, artifact func() (string, error)) (string, error) {
+	sumSegs := append(slices.Clone(segs), packageSumFile)
+	data, err := st.ReadFile(sumSegs...)
+	if err == nil {
+		return strings.TrimSpace(string(data)), nil
+	}
+	if !os.IsNotExist(err) {
+		return "", fmt.Errorf("reading integrity of %s@%s: %w", pkg, version, err)
+	}
+
+	checksum, err := artifact()
+	if err != nil {
+		return "", fmt.Errorf("looking up registry checksum of %s@%s: %w", pkg, version, err)
+	}
+	integrity := PackageIntegrity(pkg, version, checksum)
+	if err := st.WriteFile([]byte(integrity+"\n"), mcpFilePerms, sumSegs...); err != nil {
+		return "", fmt.Errorf("recording integrity of %s@%s: %w", pkg, version, err)
+	}
+	return integrity, nil
+}
@@ -0,0 +1,81 @@
+package source
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/store/storetest"
+)
+
+func TestManagedIntegrity(t *testing.T) {
+	segs := []string{"npm", "server-fs", "1.2.3"}
+	want := PackageIntegrity("npm:server-fs", "1.2.3", "sha512-abc")
+
+	tests := map[string]struct {
+		recorded  string
+		artifact  func() (string, error)
+		want      string
+		wantCalls int
+		wantErr   bool
+	}{
+		"computed from registry checksum": {
+			artifact:  func() (string, error) { return "sha512-abc", nil },
+			want:      want,
+			wantCalls: 1,
+		},
+		"recorded integrity is reused": {
+			recorded: want + "\n",
+			artifact: func() (string, error) { return "", errors.New("registry unreachable") },
+			want:     want,
+		},
+		"registry error": {
+			artifact:  func() (string, error) { return "", errors.New("registry unreachable") },
+			wantCalls: 1,
+			wantErr:   true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			st := storetest.NewMemory()
+			st.EnsureDir(segs...)
+			if tc.recorded != "" {
+				st.WriteFile([]byte(tc.recorded), 0o644, append(segs, packageSumFile)...)
+			}
+
+			calls := 0
+			got, err := managedIntegrity(st, segs, "npm:server-fs", "1.2.3", func() (string, error) {
+				calls++
+				return tc.artifact()
+			})
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("managedIntegrity() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("managedIntegrity() = %q, want %q", got, tc.want)
+			}
+			if calls != tc.wantCalls {
+				t.Errorf("artifact called %d times, want %d", calls, tc.wantCalls)
+			}
+		})
+	}
+}
+
+func TestPackageIntegrity(t *testing.T) {
+	base := PackageIntegrity("npm:server-fs", "1.2.3", "sha512-abc")
+	tests := map[string]struct {
+		pkg, version, artifact string
+	}{
+		"other package":  {pkg: "npm:server-git", version: "1.2.3", artifact: "sha512-abc"},
+		"other version":  {pkg: "npm:server-fs", version: "1.2.4", artifact: "sha512-abc"},
+		"other artifact": {pkg: "npm:server-fs", version: "1.2.3", artifact: "sha512-def"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := PackageIntegrity(tc.pkg, tc.version, tc.artifact); got == base {
+				t.Errorf("PackageIntegrity(%q, %q, %q) = %q, same as the base package", tc.pkg, tc.version, tc.artifact, got)
+			}
+		})
+	}
+}
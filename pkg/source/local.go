@@ -2,15 +2,35 @@ package source
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/agentpkg/agentpkg/pkg/fsutil"
 	"github.com/agentpkg/agentpkg/pkg/store"
 )
 
+// LocalSource is a package directory on disk. It's used in place, so
+// agents see edits right away, unless it has an fsutil.IgnoreFile: then the
+// files that aren't ignored are copied into the store on every fetch, and
+// the copy is used.
+//
+// In Snapshot mode, the directory is instead copied into the store keyed
+// by the hash of its content, which becomes the resolved integrity: a
+// changed directory is snapshotted anew on the next fetch, while the
+// snapshots made before stay as they were.
 type LocalSource struct {
 	Path string
+
+	// Snapshot copies the directory into the store instead of using it in
+	// place.
+	Snapshot bool
+	// Locked is the integrity of a snapshot made before, used in Snapshot
+	// mode if Path no longer exists.
+	Locked string
 }
 
 var _ Source = &LocalSource{}
@@ -23,6 +43,9 @@ func (l *LocalSource) Fetch(ctx context.Context, s store.Store) (*ResolvedSource
 
 	info, err := os.Stat(absPath)
 	if err != nil {
+		if os.IsNotExist(err) && l.Snapshot && l.Locked != "" {
+			return l.lockedSnapshot(absPath, s)
+		}
 		if os.IsNotExist(err) {
 			return nil, fmt.Errorf("local source path does not exist: %s", absPath)
 		}
@@ -33,8 +56,91 @@ func (l *LocalSource) Fetch(ctx context.Context, s store.Store) (*ResolvedSource
 		return nil, fmt.Errorf("local source path is not a directory: %s", absPath)
 	}
 
+	ignore, err := fsutil.LoadIgnore(absPath)
+	if err != nil {
+		return nil, err
+	}
+	if l.Snapshot {
+		return l.snapshot(absPath, ignore, s)
+	}
+	if ignore == nil {
+		return &ResolvedSource{
+			Dir: absPath,
+		}, nil
+	}
+
+	segs := LocalStoreSegments(absPath)
+	if err := fsutil.CopyDir(absPath, s.Path(segs...), ignore.Skip); err != nil {
+		s.Remove(segs...)
+		return nil, fmt.Errorf("copying %s without the files in its %s: %w", absPath, fsutil.IgnoreFile, err)
+	}
 	return &ResolvedSource{
-		Dir: absPath,
+		Dir: s.Path(segs...),
 	}, nil
 }
 
+// snapshot copies the directory at absPath, without what ignore ignores,
+// into the store, unless a snapshot of the same content is there already.
+func (l *LocalSource) snapshot(absPath string, ignore *fsutil.Ignore, s store.Store) (*ResolvedSource, error) {
+	integrity, err := store.HashTreeFunc(absPath, ignore.Skip)
+	if err != nil {
+		return nil, fmt.Errorf("hashing %s: %w", absPath, err)
+	}
+	segs := SnapshotStoreSegments(integrity)
+	exists, err := s.Exists(segs...)
+	if err != nil {
+		return nil, fmt.Errorf("checking cache: %w", err)
+	}
+	if exists {
+		return &ResolvedSource{Dir: s.Path(segs...), Integrity: integrity}, nil
+	}
+
+	// Copy next to the final path first and hash the copy, which is what
+	// agents will see if the directory changes while it's copied.
+	partial := partialSegments(segs)
+	if err := fsutil.CopyDir(absPath, s.Path(partial...), ignore.Skip); err != nil {
+		s.Remove(partial...)
+		return nil, fmt.Errorf("snapshotting %s: %w", absPath, err)
+	}
+	if integrity, err = store.HashTree(s.Path(partial...)); err != nil {
+		s.Remove(partial...)
+		return nil, fmt.Errorf("hashing snapshot of %s: %w", absPath, err)
+	}
+	segs = SnapshotStoreSegments(integrity)
+	if exists, _ := s.Exists(segs...); exists {
+		s.Remove(partial...)
+	} else if err := os.Rename(s.Path(partial...), s.Path(segs...)); err != nil {
+		s.Remove(partial...)
+		return nil, fmt.Errorf("finalizing snapshot of %s: %w", absPath, err)
+	}
+	return &ResolvedSource{Dir: s.Path(segs...), Integrity: integrity}, nil
+}
+
+// lockedSnapshot returns the Locked snapshot of the directory at absPath,
+// which was deleted.
+func (l *LocalSource) lockedSnapshot(absPath string, s store.Store) (*ResolvedSource, error) {
+	segs := SnapshotStoreSegments(l.Locked)
+	exists, err := s.Exists(segs...)
+	if err != nil {
+		return nil, fmt.Errorf("checking cache: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("local source path does not exist, and neither does its snapshot: %s", absPath)
+	}
+	return &ResolvedSource{Dir: s.Path(segs...), Integrity: l.Locked}, nil
+}
+
+// SnapshotStoreSegments returns the store path segments of the snapshot of
+// a local directory with integrity (see LocalSource.Snapshot):
+// snapshots/<hex-of-sha256>.
+func SnapshotStoreSegments(integrity string) []string {
+	return []string{"snapshots", strings.TrimPrefix(integrity, "sha256:")}
+}
+
+// LocalStoreSegments returns the store path segments of the copy of the
+// local directory at absPath: local/<sha256-of-path>, so each directory
+// has one copy, replaced on every fetch.
+func LocalStoreSegments(absPath string) []string {
+	h := sha256.Sum256([]byte(absPath))
+	return []string{"local", hex.EncodeToString(h[:])}
+}
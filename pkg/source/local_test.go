@@ -80,3 +80,117 @@ func TestLocalSourceFetchRelativePath(t *testing.T) {
 		t.Errorf("Dir = %q, want absolute path", result.Dir)
 	}
 }
+
+func TestLocalSourceFetchIgnore(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]bool{
+		"SKILL.md":                              true,
+		".apkgignore":                           true,
+		filepath.Join("scripts", "run.py"):      true,
+		filepath.Join("scripts", "run.pyc"):     false,
+		filepath.Join(".venv", "bin", "python"): false,
+		filepath.Join("tests", "fixture.json"):  false,
+		".DS_Store":                             false,
+	}
+	ignore := "*.pyc\n.venv/\n/tests\n"
+	for f := range files {
+		os.MkdirAll(filepath.Join(dir, filepath.Dir(f)), 0o755)
+		content := "x"
+		if f == ".apkgignore" {
+			content = ignore
+		}
+		os.WriteFile(filepath.Join(dir, f), []byte(content), 0o644)
+	}
+
+	s := store.New(t.TempDir())
+	result, err := (&LocalSource{Path: dir}).Fetch(context.Background(), s)
+	if err != nil {
+		t.Fatalf("Fetch() error: %v", err)
+	}
+	if result.Dir == dir {
+		t.Fatalf("Dir = source dir, want a copy without ignored files")
+	}
+	for f, keep := range files {
+		_, err := os.Stat(filepath.Join(result.Dir, f))
+		if keep && err != nil {
+			t.Errorf("expected %s to be copied: %v", f, err)
+		}
+		if !keep && !os.IsNotExist(err) {
+			t.Errorf("expected %s to be left out", f)
+		}
+	}
+
+	// Changes show up on the next fetch, in the same copy.
+	os.Remove(filepath.Join(dir, "scripts", "run.py"))
+	again, err := (&LocalSource{Path: dir}).Fetch(context.Background(), s)
+	if err != nil {
+		t.Fatalf("second Fetch() error: %v", err)
+	}
+	if again.Dir != result.Dir {
+		t.Errorf("second Fetch() Dir = %q, want %q", again.Dir, result.Dir)
+	}
+	if _, err := os.Stat(filepath.Join(again.Dir, "scripts", "run.py")); !os.IsNotExist(err) {
+		t.Errorf("expected deleted scripts/run.py to be gone from the copy")
+	}
+}
+
+func TestLocalSourceFetchSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte("v1"), 0o644)
+	os.WriteFile(filepath.Join(dir, ".apkgignore"), []byte("*.log\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "debug.log"), []byte("x"), 0o644)
+
+	s := store.New(t.TempDir())
+	first, err := (&LocalSource{Path: dir, Snapshot: true}).Fetch(context.Background(), s)
+	if err != nil {
+		t.Fatalf("Fetch() error: %v", err)
+	}
+	if first.Integrity == "" {
+		t.Fatal("Integrity is empty, want the snapshot's hash")
+	}
+	if want := s.Path(SnapshotStoreSegments(first.Integrity)...); first.Dir != want {
+		t.Errorf("Dir = %q, want %q", first.Dir, want)
+	}
+	if _, err := os.Stat(filepath.Join(first.Dir, "debug.log")); !os.IsNotExist(err) {
+		t.Error("ignored debug.log was snapshotted")
+	}
+
+	// Unchanged content reuses the snapshot; ignored files don't count.
+	os.WriteFile(filepath.Join(dir, "debug.log"), []byte("y"), 0o644)
+	same, err := (&LocalSource{Path: dir, Snapshot: true}).Fetch(context.Background(), s)
+	if err != nil {
+		t.Fatalf("second Fetch() error: %v", err)
+	}
+	if same.Integrity != first.Integrity || same.Dir != first.Dir {
+		t.Errorf("unchanged Fetch() = %q at %q, want %q at %q", same.Integrity, same.Dir, first.Integrity, first.Dir)
+	}
+
+	// Changed content is snapshotted anew, leaving the old snapshot.
+	os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte("v2"), 0o644)
+	changed, err := (&LocalSource{Path: dir, Snapshot: true}).Fetch(context.Background(), s)
+	if err != nil {
+		t.Fatalf("third Fetch() error: %v", err)
+	}
+	if changed.Integrity == first.Integrity {
+		t.Error("Integrity didn't change with the content")
+	}
+	if data, _ := os.ReadFile(filepath.Join(first.Dir, "SKILL.md")); string(data) != "v1" {
+		t.Errorf("old snapshot SKILL.md = %q, want v1", data)
+	}
+	if got, err := store.HashTree(changed.Dir); err != nil || got != changed.Integrity {
+		t.Errorf("HashTree(snapshot) = %q, %v, want %q", got, err, changed.Integrity)
+	}
+
+	// The locked snapshot is used once the directory is gone.
+	os.RemoveAll(dir)
+	locked, err := (&LocalSource{Path: dir, Snapshot: true, Locked: changed.Integrity}).Fetch(context.Background(), s)
+	if err != nil {
+		t.Fatalf("Fetch() of deleted dir error: %v", err)
+	}
+	if locked.Dir != changed.Dir {
+		t.Errorf("Fetch() of deleted dir = %q, want %q", locked.Dir, changed.Dir)
+	}
+	if _, err := (&LocalSource{Path: dir, Snapshot: true}).Fetch(context.Background(), s); err == nil {
+		t.Error("Fetch() of deleted dir without a locked snapshot succeeded")
+	}
+}
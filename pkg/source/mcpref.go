@@ -0,0 +1,116 @@
+package source
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+)
+
+// mcpRefPrefixes are the prefixes of short-form MCP server references.
+var mcpRefPrefixes = []string{"npm:", "uv:", "uv-tool:", "go:", "oci:", "http://", "https://"}
+
+// IsMCPRef reports whether ref is a short-form MCP server reference (see
+// ParseMCPRef) rather than a server name.
+func IsMCPRef(ref string) bool {
+	for _, prefix := range mcpRefPrefixes {
+		if strings.HasPrefix(ref, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseMCPRef parses a short-form MCP server reference into its config and
+// a default server name derived from it:
+//
+//	npm:@scope/pkg[@version]         managed npm package, run over stdio
+//	uv:pkg[==version|@version]       managed Python package, run over stdio
+//	uv-tool:pkg[==version|@version]  managed Python package installed as a
+//	                                 uv tool, run over stdio
+//	go:module[@version]              managed Go module, run over stdio
+//	oci:image[:tag|@digest]          container image, run over stdio
+//	https://host/path                remote server over http
+func ParseMCPRef(ref string) (config.MCPSource, string, error) {
+	kind, spec, _ := strings.Cut(ref, ":")
+	if spec == "" {
+		return config.MCPSource{}, "", fmt.Errorf("invalid MCP ref %q: nothing after %q", ref, kind+":")
+	}
+
+	switch kind {
+	case "npm":
+		pkg := &NPMSource{Package: spec}
+		return managedMCPSource("npm:" + spec), path.Base(pkg.packageName()), nil
+	case "uv", "uv-tool":
+		// Accept npm-style versions for uv too, written the way uv wants.
+		if name, version, ok := strings.Cut(spec, "@"); ok && !strings.Contains(spec, "==") {
+			spec = name + "==" + version
+		}
+		pkg := &UVSource{Package: spec}
+		return managedMCPSource(kind + ":" + spec), pkg.packageName(), nil
+	case "go":
+		pkg := &GoSource{Package: spec}
+		return managedMCPSource("go:" + spec), goModuleName(pkg.modulePath()), nil
+	case "oci":
+		ms := config.MCPSource{
+			Transport:          "stdio",
+			ContainerMCPConfig: &config.ContainerMCPConfig{Image: spec},
+		}
+		return ms, imageName(spec), nil
+	case "http", "https":
+		u, err := url.Parse(ref)
+		if err != nil || u.Host == "" {
+			return config.MCPSource{}, "", fmt.Errorf("invalid MCP ref %q: not a valid URL", ref)
+		}
+		ms := config.MCPSource{
+			Transport:             "http",
+			ExternalHttpMCPConfig: &config.ExternalHttpMCPConfig{URL: ref},
+		}
+		return ms, hostName(u.Hostname()), nil
+	default:
+		return config.MCPSource{}, "", fmt.Errorf("invalid MCP ref %q: must start with one of %s", ref, strings.Join(mcpRefPrefixes, ", "))
+	}
+}
+
+func managedMCPSource(pkg string) config.MCPSource {
+	return config.MCPSource{
+		Transport:             "stdio",
+		ManagedStdioMCPConfig: &config.ManagedStdioMCPConfig{Package: pkg},
+	}
+}
+
+// goModuleName returns the last element of a module path, skipping a
+// major version suffix (github.com/x/y/v2 is "y").
+func goModuleName(module string) string {
+	dir, name := path.Split(module)
+	if len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" && dir != "" {
+		return path.Base(strings.TrimSuffix(dir, "/"))
+	}
+	return name
+}
+
+// imageName returns the last element of an image's repository, without
+// its tag or digest.
+func imageName(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	name := path.Base(image)
+	name, _, _ = strings.Cut(name, ":")
+	return name
+}
+
+// hostName returns the distinctive label of a server's host name, skipping
+// generic leading labels (mcp.linear.app is "linear").
+func hostName(host string) string {
+	labels := strings.Split(host, ".")
+	for len(labels) > 2 {
+		switch labels[0] {
+		case "www", "mcp", "api":
+			labels = labels[1:]
+			continue
+		}
+		break
+	}
+	return labels[0]
+}
@@ -0,0 +1,129 @@
+package source
+
+import "testing"
+
+func TestParseMCPRef(t *testing.T) {
+	tests := map[string]struct {
+		ref           string
+		wantName      string
+		wantTransport string
+		wantPackage   string
+		wantImage     string
+		wantURL       string
+		wantErr       bool
+	}{
+		"scoped npm package with version": {
+			ref:           "npm:@modelcontextprotocol/server-filesystem@1.2.3",
+			wantName:      "server-filesystem",
+			wantTransport: "stdio",
+			wantPackage:   "npm:@modelcontextprotocol/server-filesystem@1.2.3",
+		},
+		"npm package": {
+			ref:           "npm:mcp-remote",
+			wantName:      "mcp-remote",
+			wantTransport: "stdio",
+			wantPackage:   "npm:mcp-remote",
+		},
+		"uv package": {
+			ref:           "uv:mcp-server-git",
+			wantName:      "mcp-server-git",
+			wantTransport: "stdio",
+			wantPackage:   "uv:mcp-server-git",
+		},
+		"uv package with npm-style version": {
+			ref:           "uv:mcp-server-git@0.6.2",
+			wantName:      "mcp-server-git",
+			wantTransport: "stdio",
+			wantPackage:   "uv:mcp-server-git==0.6.2",
+		},
+		"uv tool package with version": {
+			ref:           "uv-tool:mcp-proxy@0.8.0",
+			wantName:      "mcp-proxy",
+			wantTransport: "stdio",
+			wantPackage:   "uv-tool:mcp-proxy==0.8.0",
+		},
+		"go module with major version": {
+			ref:           "go:github.com/x/y/v2@v2.1.0",
+			wantName:      "y",
+			wantTransport: "stdio",
+			wantPackage:   "go:github.com/x/y/v2@v2.1.0",
+		},
+		"go module": {
+			ref:           "go:github.com/x/y@v1",
+			wantName:      "y",
+			wantTransport: "stdio",
+			wantPackage:   "go:github.com/x/y@v1",
+		},
+		"oci image with tag": {
+			ref:           "oci:ghcr.io/org/img:tag",
+			wantName:      "img",
+			wantTransport: "stdio",
+			wantImage:     "ghcr.io/org/img:tag",
+		},
+		"oci image on registry with port": {
+			ref:           "oci:localhost:5000/tools/db@sha256:abc",
+			wantName:      "db",
+			wantTransport: "stdio",
+			wantImage:     "localhost:5000/tools/db@sha256:abc",
+		},
+		"https url": {
+			ref:           "https://mcp.linear.app/sse",
+			wantName:      "linear",
+			wantTransport: "http",
+			wantURL:       "https://mcp.linear.app/sse",
+		},
+		"http url on localhost": {
+			ref:           "http://localhost:8080/mcp",
+			wantName:      "localhost",
+			wantTransport: "http",
+			wantURL:       "http://localhost:8080/mcp",
+		},
+		"empty package": {
+			ref:     "npm:",
+			wantErr: true,
+		},
+		"url without host": {
+			ref:     "https:///mcp",
+			wantErr: true,
+		},
+		"unknown kind": {
+			ref:     "cargo:mcp-server",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ms, gotName, err := ParseMCPRef(tc.ref)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParseMCPRef(%q) error = %v, wantErr %v", tc.ref, err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+
+			if gotName != tc.wantName {
+				t.Errorf("name = %q, want %q", gotName, tc.wantName)
+			}
+			if ms.Transport != tc.wantTransport {
+				t.Errorf("Transport = %q, want %q", ms.Transport, tc.wantTransport)
+			}
+			var pkg, image, url string
+			if ms.ManagedStdioMCPConfig != nil {
+				pkg = ms.Package
+			}
+			if ms.ContainerMCPConfig != nil {
+				image = ms.Image
+			}
+			if ms.ExternalHttpMCPConfig != nil {
+				url = ms.URL
+			}
+			if pkg != tc.wantPackage || image != tc.wantImage || url != tc.wantURL {
+				t.Errorf("package, image, url = %q, %q, %q, want %q, %q, %q", pkg, image, url, tc.wantPackage, tc.wantImage, tc.wantURL)
+			}
+			if _, err := SourceFromMCPConfig(gotName, ms); err != nil {
+				t.Errorf("SourceFromMCPConfig() error = %v", err)
+			}
+		})
+	}
+}
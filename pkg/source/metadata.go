@@ -0,0 +1,294 @@
+package source
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+const (
+	// DefaultMetadataTTL is how long Metadata answers a lookup from its
+	// cache without asking upstream.
+	DefaultMetadataTTL = 15 * time.Minute
+
+	// DefaultHostInterval is how far apart Metadata spaces the requests
+	// it sends to each host.
+	DefaultHostInterval = 100 * time.Millisecond
+)
+
+// metadataDir is the store directory of the Metadata cache.
+const metadataDir = "metadata"
+
+// Hosts the lookups made with npm and go are rate limited as. They may
+// reach a mirror instead, but are counted against the public registry.
+const (
+	npmRegistryHost = "registry.npmjs.org"
+	goProxyHost     = "proxy.golang.org"
+)
+
+// Metadata looks up upstream metadata for update checks: latest package
+// versions, release tags, deprecations, and archived repositories. It
+// caches the answers in the store for TTL, revalidates stale HTTP answers
+// with their ETag, and spaces out the requests to each host by
+// HostInterval, so checking a large manifest stays fast and doesn't trip
+// the registries' rate limits. Branch heads aren't cached, since they
+// move. A nil *Metadata asks upstream every time. Its methods are safe for
+// concurrent use.
+type Metadata struct {
+	Store store.Store
+
+	// TTL is how long a cached answer is used without asking upstream.
+	// Zero means DefaultMetadataTTL, and a negative TTL revalidates every
+	// answer.
+	TTL time.Duration
+
+	// HostInterval is the least time between two requests to the same
+	// host. Zero means DefaultHostInterval.
+	HostInterval time.Duration
+
+	mu   sync.Mutex
+	next map[string]time.Time   // when each host may be asked next
+	keys map[string]*sync.Mutex // lookups in progress
+}
+
+// metadataEntry is a cached answer.
+type metadataEntry struct {
+	Key     string    `json:"key"`
+	ETag    string    `json:"etag,omitempty"`
+	Fetched time.Time `json:"fetched"`
+	Data    []byte    `json:"data"`
+}
+
+// LatestPackageVersion is the cached LatestPackageVersion.
+func (m *Metadata) LatestPackageVersion(ctx context.Context, pkg string) (string, error) {
+	return latestPackageVersion(ctx, m, pkg)
+}
+
+// PackageDeprecation is the cached PackageDeprecation.
+func (m *Metadata) PackageDeprecation(ctx context.Context, pkg, version string) (string, error) {
+	return packageDeprecation(ctx, m, pkg, version)
+}
+
+// RemoteTags is the cached RemoteTags.
+func (m *Metadata) RemoteTags(ctx context.Context, url string) ([]string, error) {
+	return remoteTags(ctx, m, url)
+}
+
+// ResolveGitRef is ResolveGitRef, rate limited. The commit isn't cached,
+// since branches move.
+func (m *Metadata) ResolveGitRef(ctx context.Context, url, ref string) (string, error) {
+	if err := m.wait(ctx, gitHost(url)); err != nil {
+		return "", err
+	}
+	return ResolveGitRef(ctx, url, ref)
+}
+
+// RepoArchived is the cached RepoArchived.
+func (m *Metadata) RepoArchived(ctx context.Context, url string) (bool, error) {
+	return repoArchived(ctx, m, url)
+}
+
+// lookup returns the answer cached for key, or the one fetch returns once
+// host may be asked, caching it. Failed lookups aren't cached.
+func (m *Metadata) lookup(ctx context.Context, key, host string, fetch func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	if m == nil {
+		return fetch(ctx)
+	}
+	defer m.lockKey(key)()
+
+	if entry := m.load(key); entry != nil && m.fresh(entry) {
+		return entry.Data, nil
+	}
+	if err := m.wait(ctx, host); err != nil {
+		return nil, err
+	}
+	data, err := fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.save(&metadataEntry{Key: key, Fetched: time.Now(), Data: data})
+	return data, nil
+}
+
+// get GETs rawURL with header and returns the response's status and
+// body. OK responses are cached, and answered from the cache until they
+// are stale, then revalidated with their ETag.
+func (m *Metadata) get(ctx context.Context, rawURL string, header map[string]string) (int, []byte, error) {
+	var entry *metadataEntry
+	if m != nil {
+		defer m.lockKey(rawURL)()
+		if entry = m.load(rawURL); entry != nil && m.fresh(entry) {
+			return http.StatusOK, entry.Data, nil
+		}
+		if err := m.wait(ctx, urlHost(rawURL)); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	if entry != nil && entry.ETag != "" {
+		req.Header.Set("If-None-Match", entry.ETag)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		entry.Fetched = time.Now()
+		m.save(entry)
+		return http.StatusOK, entry.Data, nil
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+	if resp.StatusCode == http.StatusOK && m != nil {
+		m.save(&metadataEntry{Key: rawURL, ETag: resp.Header.Get("ETag"), Fetched: time.Now(), Data: body})
+	}
+	return resp.StatusCode, body, nil
+}
+
+// fresh reports whether entry can be used without asking upstream.
+func (m *Metadata) fresh(entry *metadataEntry) bool {
+	ttl := m.TTL
+	if ttl == 0 {
+		ttl = DefaultMetadataTTL
+	}
+	return time.Since(entry.Fetched) < ttl
+}
+
+// wait blocks until host may be asked again, and books its next slot.
+func (m *Metadata) wait(ctx context.Context, host string) error {
+	if m == nil {
+		return nil
+	}
+	interval := m.HostInterval
+	if interval <= 0 {
+		interval = DefaultHostInterval
+	}
+
+	m.mu.Lock()
+	if m.next == nil {
+		m.next = make(map[string]time.Time)
+	}
+	at := time.Now()
+	if next := m.next[host]; next.After(at) {
+		at = next
+	}
+	m.next[host] = at.Add(interval)
+	m.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(at))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// lockKey waits until no other lookup of key is running and returns the
+// function that lets the next one run, which then finds the answer cached.
+func (m *Metadata) lockKey(key string) (unlock func()) {
+	m.mu.Lock()
+	if m.keys == nil {
+		m.keys = make(map[string]*sync.Mutex)
+	}
+	mu, ok := m.keys[key]
+	if !ok {
+		mu = &sync.Mutex{}
+		m.keys[key] = mu
+	}
+	m.mu.Unlock()
+
+	mu.Lock()
+	return mu.Unlock
+}
+
+// load returns the entry cached for key, or nil if there is none.
+func (m *Metadata) load(key string) *metadataEntry {
+	if m.Store == nil {
+		return nil
+	}
+	data, err := m.Store.ReadFile(metadataSegments(key)...)
+	if err != nil {
+		return nil
+	}
+	var entry metadataEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Key != key {
+		return nil
+	}
+	return &entry
+}
+
+// save caches entry, replacing the cache file atomically so concurrent
+// apkg processes never read half of it. The cache is only an
+// optimization, so failures are ignored.
+func (m *Metadata) save(entry *metadataEntry) {
+	if m.Store == nil {
+		return
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	m.Store.EnsureDir(metadataDir)
+	path := m.Store.Path(metadataSegments(entry.Key)...)
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// metadataSegments returns the store location of the entry cached for key.
+func metadataSegments(key string) []string {
+	sum := sha256.Sum256([]byte(key))
+	return []string{metadataDir, hex.EncodeToString(sum[:]) + ".json"}
+}
+
+// urlHost returns the host of rawURL, or rawURL if it has none.
+func urlHost(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return rawURL
+}
+
+// gitHost returns the host of the repository at url, or url if it can't
+// be parsed.
+func gitHost(url string) string {
+	if host, _, err := parseGitURL(url); err == nil {
+		return host
+	}
+	return url
+}
@@ -0,0 +1,140 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+func TestMetadataRevalidates(t *testing.T) {
+	tests := map[string]struct {
+		ref string
+		// ttls are the TTLs of successive lookups of ref in one store.
+		ttls []time.Duration
+		// wantVersion is the answer of every lookup; they fail if empty.
+		wantVersion     string
+		wantRequests    int32
+		wantNotModified int32
+	}{
+		"fresh answer": {
+			ref:          "uv:pkg",
+			ttls:         []time.Duration{0, 0},
+			wantVersion:  "1.2.0",
+			wantRequests: 1,
+		},
+		"stale answer revalidated with its ETag": {
+			ref:             "uv:pkg",
+			ttls:            []time.Duration{0, -1},
+			wantVersion:     "1.2.0",
+			wantRequests:    2,
+			wantNotModified: 1,
+		},
+		"failures aren't cached": {
+			ref:          "uv:missing",
+			ttls:         []time.Duration{0, 0},
+			wantRequests: 2,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var requests, notModified atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				if r.URL.Path != "/pypi/pkg/json" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				if r.Header.Get("If-None-Match") == `"v1"` {
+					notModified.Add(1)
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				w.Header().Set("ETag", `"v1"`)
+				fmt.Fprint(w, `{"info":{"version":"1.2.0"}}`)
+			}))
+			defer server.Close()
+
+			orig := pypiBaseURL
+			pypiBaseURL = server.URL
+			t.Cleanup(func() { pypiBaseURL = orig })
+
+			st := store.New(t.TempDir())
+			for _, ttl := range tc.ttls {
+				got, err := (&Metadata{Store: st, TTL: ttl}).LatestPackageVersion(context.Background(), tc.ref)
+				if tc.wantVersion == "" {
+					if err == nil {
+						t.Errorf("LatestPackageVersion() = %q, want an error", got)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("LatestPackageVersion() error = %v", err)
+				}
+				if got != tc.wantVersion {
+					t.Errorf("LatestPackageVersion() = %q, want %q", got, tc.wantVersion)
+				}
+			}
+			if got, gotNotModified := requests.Load(), notModified.Load(); got != tc.wantRequests || gotNotModified != tc.wantNotModified {
+				t.Errorf("upstream got %d requests, %d not modified, want %d and %d", got, gotNotModified, tc.wantRequests, tc.wantNotModified)
+			}
+		})
+	}
+}
+
+func TestMetadataLookup(t *testing.T) {
+	type lookup struct{ key, host string }
+	tests := map[string]struct {
+		lookups     []lookup
+		wantFetches int
+		// The lookups take at least wantMin, and less than wantMax unless
+		// it is zero.
+		wantMin, wantMax time.Duration
+	}{
+		"requests to one host spaced out": {
+			lookups:     []lookup{{"a", "example.com"}, {"b", "example.com"}, {"c", "example.com"}, {"a", "example.com"}, {"b", "example.com"}},
+			wantFetches: 3,
+			wantMin:     100 * time.Millisecond,
+		},
+		"other hosts don't wait": {
+			lookups:     []lookup{{"a", "example.com"}, {"d", "example.org"}},
+			wantFetches: 2,
+			wantMax:     40 * time.Millisecond,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			m := &Metadata{Store: store.New(t.TempDir()), TTL: time.Hour, HostInterval: 50 * time.Millisecond}
+			ctx := context.Background()
+
+			var fetches int
+			start := time.Now()
+			for _, l := range tc.lookups {
+				got, err := m.lookup(ctx, l.key, l.host, func(context.Context) ([]byte, error) {
+					fetches++
+					return []byte("answer " + l.key), nil
+				})
+				if err != nil {
+					t.Fatalf("lookup(%q) error = %v", l.key, err)
+				}
+				if string(got) != "answer "+l.key {
+					t.Errorf("lookup(%q) = %q, want %q", l.key, got, "answer "+l.key)
+				}
+			}
+			elapsed := time.Since(start)
+			if fetches != tc.wantFetches {
+				t.Errorf("fetched %d times, want %d", fetches, tc.wantFetches)
+			}
+			if elapsed < tc.wantMin || (tc.wantMax != 0 && elapsed >= tc.wantMax) {
+				t.Errorf("lookups took %v, want at least %v and less than %v", elapsed, tc.wantMin, tc.wantMax)
+			}
+		})
+	}
+}
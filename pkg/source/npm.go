@@ -4,10 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 
 	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/runtimes"
 	"github.com/agentpkg/agentpkg/pkg/store"
 	"github.com/pelletier/go-toml/v2"
 )
@@ -20,6 +24,15 @@ const (
 type NPMSource struct {
 	Package   string
 	MCPConfig config.MCPSource
+	// Version, if set, is the concrete version to install instead of
+	// resolving Package's, e.g. the one recorded in the lockfile.
+	Version string
+	// Node, if set, is the managed runtime the package is installed with
+	// and run on instead of the system's node.
+	Node *runtimes.Runtime
+	// Output, if set, receives the output of npm as it installs the
+	// package, e.g. for apkg install --verbose.
+	Output io.Writer
 }
 
 var _ Source = &NPMSource{}
@@ -42,7 +55,7 @@ func (s *NPMSource) Fetch(ctx context.Context, store store.Store) (*ResolvedSour
 		store.EnsureDir(segs...)
 		path := store.Path(segs...)
 
-		if err := s.install(ctx, path, version); err != nil {
+		if err := s.install(ctx, store, path, version); err != nil {
 			store.Remove(segs...)
 			return nil, fmt.Errorf("failed to install npm package %s@%s: %w", s.packageName(), version, err)
 		}
@@ -50,11 +63,10 @@ func (s *NPMSource) Fetch(ctx context.Context, store store.Store) (*ResolvedSour
 
 	// Resolve the node binary so that agents which do not source the
 	// shell environment (e.g. Cursor) can locate the runtime.
-	nodePath, err := exec.LookPath("node")
+	nodePath, err := s.nodePath()
 	if err != nil {
-		return nil, fmt.Errorf("node not found in PATH: %w", err)
+		return nil, err
 	}
-	s.MCPConfig.ManagedStdioMCPConfig.Runtime = nodePath
 
 	// Always write mcp.toml so config changes are picked up even when
 	// the package version is already cached.
@@ -62,19 +74,51 @@ func (s *NPMSource) Fetch(ctx context.Context, store store.Store) (*ResolvedSour
 		return nil, fmt.Errorf("writing mcp config: %w", err)
 	}
 
-	integrity, err := store.HashDir(segs...)
+	pkg := "npm:" + s.packageName()
+	integrity, err := managedIntegrity(store, segs, pkg, version, func() (string, error) {
+		return s.tarballIntegrity(ctx, version)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	treeIntegrity, err := store.HashDir(segs...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to compute integrity hash: %w", err)
 	}
 
 	return &ResolvedSource{
-		Dir:       store.Path(segs...),
-		Integrity: integrity,
+		Dir:           store.Path(segs...),
+		Integrity:     integrity,
+		Package:       pkg,
+		Version:       version,
+		TreeIntegrity: treeIntegrity,
+		Runtime:       nodePath,
 	}, nil
 }
 
+// tarballIntegrity returns the integrity the npm registry publishes for
+// the package tarball of version (dist.integrity).
+func (s *NPMSource) tarballIntegrity(ctx context.Context, version string) (string, error) {
+	cmd := s.npm(ctx, "view", s.packageName()+"@"+version, "dist.integrity", "--json")
+	out, err := cmd.Output()
+	if err != nil {
+		return "", execError(err)
+	}
+
+	var integrity string
+	if err := json.Unmarshal(out, &integrity); err != nil || integrity == "" {
+		return "", fmt.Errorf("no dist.integrity for %s@%s", s.packageName(), version)
+	}
+	return integrity, nil
+}
+
 func (s *NPMSource) resolveConcreteVersion(ctx context.Context) (string, error) {
-	cmd := exec.CommandContext(ctx, "npm", "view", s.Package, "version", "--json")
+	if s.Version != "" {
+		return s.Version, nil
+	}
+
+	cmd := s.npm(ctx, "view", s.Package, "version", "--json")
 	out, err := cmd.Output()
 	if err != nil {
 		return "", execError(err)
@@ -106,11 +150,39 @@ func (s *NPMSource) getStoreSegments(resolvedVersion string) []string {
 	segs := make([]string, 0, 2+len(packageParts))
 	segs = append(segs, "npm")
 	segs = append(segs, packageParts...)
+	// Native addons are built against the node that installs them, so
+	// installs on a managed runtime are kept apart.
+	if s.Node != nil {
+		resolvedVersion += "-node" + s.Node.Version
+	}
 	segs = append(segs, resolvedVersion)
 
 	return segs
 }
 
+// nodePath returns the node binary the package runs on.
+func (s *NPMSource) nodePath() (string, error) {
+	if s.Node != nil {
+		return s.Node.Bin, nil
+	}
+	nodePath, err := exec.LookPath("node")
+	if err != nil {
+		return "", fmt.Errorf("node not found in PATH: %w", err)
+	}
+	return nodePath, nil
+}
+
+// npm returns an npm command, running the npm that ships with Node when
+// it is set.
+func (s *NPMSource) npm(ctx context.Context, args ...string) *exec.Cmd {
+	if s.Node == nil {
+		return exec.CommandContext(ctx, "npm", args...)
+	}
+	cmd := exec.CommandContext(ctx, filepath.Join(s.Node.BinDir(), "npm"), args...)
+	cmd.Env = s.Node.Env(os.Environ())
+	return cmd
+}
+
 func (s *NPMSource) packageName() string {
 	packageName := s.Package
 	// if idx == -1, no version tag, if idx == 0, then there is a scoped package, and no version tag (i.e. @modencontextprotocol/inspector)
@@ -121,15 +193,11 @@ func (s *NPMSource) packageName() string {
 	return packageName
 }
 
-func (s *NPMSource) install(ctx context.Context, dest string, version string) error {
+func (s *NPMSource) install(ctx context.Context, st store.Store, dest string, version string) error {
 	pkg := fmt.Sprintf("%s@%s", s.packageName(), version)
 
-	cmd := exec.CommandContext(ctx, "npm", "install", "--prefix", dest, pkg)
-	if _, err := cmd.Output(); err != nil {
-		return execError(err)
-	}
-
-	return nil
+	cmd := s.npm(ctx, "install", "--prefix", dest, pkg)
+	return runTool(cmd, st, "npm-"+pkg, s.Output)
 }
 
 func (s *NPMSource) writeMCPConfig(store store.Store, segs []string) error {
@@ -8,16 +8,23 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/agentpkg/agentpkg/pkg/apkgtest"
 	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/runtimes"
 	"github.com/agentpkg/agentpkg/pkg/store"
 )
 
-// requireNPM skips the test if npm is not available.
+// requireNPM skips the test if npm is not available, and points it at a
+// local registry serving the packages the tests install.
 func requireNPM(t *testing.T) {
 	t.Helper()
 	if _, err := exec.LookPath("npm"); err != nil {
 		t.Skip("npm not found in PATH")
 	}
+	registry := apkgtest.NewNPMRegistry(t)
+	registry.Publish(t, "is-number", "7.0.0", map[string]string{"index.js": "module.exports = n => typeof n === 'number';\n"})
+	registry.Publish(t, "@anthropic-ai/tokenizer", "0.0.3", map[string]string{"index.js": "module.exports = {};\n"})
+	registry.Setenv(t)
 }
 
 func TestPackageName(t *testing.T) {
@@ -66,6 +73,7 @@ func TestGetStoreSegments(t *testing.T) {
 	tests := map[string]struct {
 		pkg     string
 		version string
+		node    *runtimes.Runtime
 		want    []string
 	}{
 		"plain package": {
@@ -83,11 +91,17 @@ func TestGetStoreSegments(t *testing.T) {
 			version: "1.0.0",
 			want:    []string{"npm", "@modelcontextprotocol", "inspector", "1.0.0"},
 		},
+		"managed node": {
+			pkg:     "some-mcp-server",
+			version: "1.2.3",
+			node:    &runtimes.Runtime{Name: runtimes.Node, Version: "22.11.0"},
+			want:    []string{"npm", "some-mcp-server", "1.2.3-node22.11.0"},
+		},
 	}
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			s := &NPMSource{Package: tc.pkg}
+			s := &NPMSource{Package: tc.pkg, Node: tc.node}
 			got := s.getStoreSegments(tc.version)
 			if len(got) != len(tc.want) {
 				t.Fatalf("getStoreSegments() = %v, want %v", got, tc.want)
@@ -108,7 +122,7 @@ func TestWriteMCPConfig(t *testing.T) {
 		wantErr   bool
 	}{
 		"stdio transport": {
-			mcpConfig: config.MCPSource{Transport: "stdio"},
+			mcpConfig: config.MCPSource{Transport: "stdio", ManagedStdioMCPConfig: &config.ManagedStdioMCPConfig{}},
 			segs:      []string{"npm", "some-pkg", "1.0.0"},
 		},
 		"http transport": {
@@ -148,7 +162,7 @@ func TestWriteMCPConfigContent(t *testing.T) {
 	st.EnsureDir(segs...)
 
 	s := &NPMSource{
-		MCPConfig: config.MCPSource{Transport: "stdio"},
+		MCPConfig: config.MCPSource{Transport: "stdio", ManagedStdioMCPConfig: &config.ManagedStdioMCPConfig{}},
 	}
 
 	if err := s.writeMCPConfig(st, segs); err != nil {
@@ -173,7 +187,7 @@ func TestWriteMCPConfigFilePerms(t *testing.T) {
 	st.EnsureDir(segs...)
 
 	s := &NPMSource{
-		MCPConfig: config.MCPSource{Transport: "stdio"},
+		MCPConfig: config.MCPSource{Transport: "stdio", ManagedStdioMCPConfig: &config.ManagedStdioMCPConfig{}},
 	}
 
 	if err := s.writeMCPConfig(st, segs); err != nil {
@@ -205,11 +219,11 @@ func TestNPMFetch(t *testing.T) {
 	}{
 		"plain package": {
 			pkg:       "is-number@7.0.0",
-			mcpConfig: config.MCPSource{Transport: "stdio"},
+			mcpConfig: config.MCPSource{Transport: "stdio", ManagedStdioMCPConfig: &config.ManagedStdioMCPConfig{}},
 		},
 		"scoped package": {
 			pkg:       "@anthropic-ai/tokenizer@0.0.3",
-			mcpConfig: config.MCPSource{Transport: "stdio"},
+			mcpConfig: config.MCPSource{Transport: "stdio", ManagedStdioMCPConfig: &config.ManagedStdioMCPConfig{}},
 		},
 	}
 
@@ -257,7 +271,7 @@ func TestNPMFetchCached(t *testing.T) {
 	s := store.New(t.TempDir())
 	src := &NPMSource{
 		Package:   "is-number@7.0.0",
-		MCPConfig: config.MCPSource{Transport: "stdio"},
+		MCPConfig: config.MCPSource{Transport: "stdio", ManagedStdioMCPConfig: &config.ManagedStdioMCPConfig{}},
 	}
 
 	first, err := src.Fetch(context.Background(), s)
@@ -284,7 +298,7 @@ func TestNPMFetchContextCanceled(t *testing.T) {
 	s := store.New(t.TempDir())
 	src := &NPMSource{
 		Package:   "is-number@7.0.0",
-		MCPConfig: config.MCPSource{Transport: "stdio"},
+		MCPConfig: config.MCPSource{Transport: "stdio", ManagedStdioMCPConfig: &config.ManagedStdioMCPConfig{}},
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
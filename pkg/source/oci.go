@@ -6,6 +6,7 @@ import (
 
 	"github.com/agentpkg/agentpkg/pkg/config"
 	"github.com/agentpkg/agentpkg/pkg/container"
+	"github.com/agentpkg/agentpkg/pkg/credentials"
 	"github.com/agentpkg/agentpkg/pkg/store"
 	"github.com/pelletier/go-toml/v2"
 )
@@ -19,34 +20,93 @@ import (
 type OCISource struct {
 	Name      string
 	MCPConfig config.MCPSource
+	// Engine pulls the image; nil detects docker or podman.
+	Engine container.Engine
+	// BaseDir is the directory relative volume host paths are resolved
+	// against; empty uses the working directory.
+	BaseDir string
+	// ManifestDigest, if set, is the registry manifest digest to pull the
+	// image by instead of its tag, e.g. the one recorded in the lockfile.
+	ManifestDigest string
 }
 
 var _ Source = &OCISource{}
 
 func (s *OCISource) Fetch(ctx context.Context, st store.Store) (*ResolvedSource, error) {
-	engine, err := container.DetectEngine()
-	if err != nil {
-		return nil, fmt.Errorf("detecting container engine: %w", err)
+	var volumes []string
+	if cc := s.MCPConfig.ContainerMCPConfig; cc != nil {
+		if err := container.CheckNetwork(cc.Network, cc.NetworkAliases, s.MCPConfig.Transport == "http"); err != nil {
+			return nil, fmt.Errorf("invalid network for %q: %w", s.Name, err)
+		}
+
+		var err error
+		if volumes, err = container.ResolveVolumes(cc.Volumes, s.BaseDir, cc.CreateVolumes); err != nil {
+			return nil, fmt.Errorf("invalid volume for %q: %w", s.Name, err)
+		}
+	}
+
+	engine := s.Engine
+	if engine == nil {
+		var err error
+		if engine, err = container.DetectEngine(); err != nil {
+			return nil, fmt.Errorf("detecting container engine: %w", err)
+		}
+	}
+
+	// Log the engine in with the credential stored by `apkg login` so
+	// private images pull without a separate `docker login`.
+	host := container.RegistryHost(s.MCPConfig.Image)
+	if cred, ok := credentials.Lookup(host); ok {
+		if err := engine.Login(ctx, host, cred.User(), cred.Token); err != nil {
+			return nil, err
+		}
 	}
 
-	if err := engine.Pull(ctx, s.MCPConfig.Image); err != nil {
+	image := container.PinnedImage(s.MCPConfig.Image, s.ManifestDigest)
+	if err := engine.Pull(ctx, image); err != nil {
 		return nil, fmt.Errorf("pulling image: %w", err)
 	}
 
-	digest, err := engine.ImageDigest(ctx, s.MCPConfig.Image)
+	// Create the server's network now so stdio containers, which agents
+	// run directly, find it.
+	if err := engine.EnsureNetwork(ctx, s.MCPConfig.Network); err != nil {
+		return nil, err
+	}
+
+	digest, err := engine.ImageDigest(ctx, image)
 	if err != nil {
 		return nil, fmt.Errorf("resolving image digest: %w", err)
 	}
+	manifestDigest := s.ManifestDigest
+	if manifestDigest == "" {
+		if manifestDigest, err = engine.ManifestDigest(ctx, image); err != nil {
+			return nil, fmt.Errorf("resolving image manifest digest: %w", err)
+		}
+	}
 
-	// Stamp the resolved digest into the config so mcp.Load can read it
-	// from the persisted mcp.toml and set the routing headers.
+	// Stamp the resolved digests into the config so mcp.Load can read
+	// them from the persisted mcp.toml to pin the image and set the
+	// routing headers.
 	if s.MCPConfig.ContainerMCPConfig != nil {
 		s.MCPConfig.ContainerMCPConfig.Digest = digest
+		s.MCPConfig.ContainerMCPConfig.ManifestDigest = manifestDigest
 		if s.MCPConfig.ContainerMCPConfig.Path == "" {
 			s.MCPConfig.ContainerMCPConfig.Path = "/mcp"
 		}
 	}
 
+	// Projects installing the same image share its store entry, so the
+	// stored config leaves out the volumes, whose host paths are the
+	// project's. They are returned with absolute paths for the lockfile
+	// instead, so the container mounts the same directories wherever
+	// serve or the agent runs it.
+	stored := s.MCPConfig
+	if cc := s.MCPConfig.ContainerMCPConfig; cc != nil {
+		shared := *cc
+		shared.Volumes = nil
+		stored.ContainerMCPConfig = &shared
+	}
+
 	segs := []string{"oci", s.Name, digest}
 
 	cached, err := st.Exists(segs...)
@@ -60,7 +120,7 @@ func (s *OCISource) Fetch(ctx context.Context, st store.Store) (*ResolvedSource,
 
 	// Always write mcp.toml so config changes are picked up even when
 	// the image digest is already cached.
-	if err := s.writeMCPConfig(st, segs); err != nil {
+	if err := s.writeMCPConfig(st, stored, segs); err != nil {
 		return nil, fmt.Errorf("writing mcp config: %w", err)
 	}
 
@@ -70,13 +130,18 @@ func (s *OCISource) Fetch(ctx context.Context, st store.Store) (*ResolvedSource,
 	}
 
 	return &ResolvedSource{
-		Dir:       st.Path(segs...),
-		Integrity: integrity,
+		Dir:            st.Path(segs...),
+		Integrity:      integrity,
+		Digest:         digest,
+		ManifestDigest: manifestDigest,
+		Volumes:        volumes,
 	}, nil
 }
 
-func (s *OCISource) writeMCPConfig(st store.Store, segs []string) error {
-	data, err := toml.Marshal(s.MCPConfig)
+// writeMCPConfig writes ms, the source's config without volumes, as
+// the mcp.toml of the store directory segs.
+func (s *OCISource) writeMCPConfig(st store.Store, ms config.MCPSource, segs []string) error {
+	data, err := toml.Marshal(ms)
 	if err != nil {
 		return fmt.Errorf("marshaling mcp config: %w", err)
 	}
@@ -1,9 +1,17 @@
 package source
 
 import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/container"
+	"github.com/agentpkg/agentpkg/pkg/store/storetest"
+	"github.com/pelletier/go-toml/v2"
 )
 
 func TestOCISourceImplementsSource(t *testing.T) {
@@ -54,3 +62,135 @@ func TestOCISourceWriteMCPConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestOCISourceFetch(t *testing.T) {
+	tests := map[string]struct {
+		engine     *container.Fake
+		network    string
+		aliases    []string
+		volumes    []string
+		locked     string
+		wantDigest string
+		// wantManifest is the manifest digest stamped into mcp.toml.
+		wantManifest string
+		// wantVolumes are the volumes returned for the lockfile, with host
+		// paths relative to the source's BaseDir. mcp.toml stores none.
+		wantVolumes []string
+		wantOps     []string
+		wantErr     bool
+	}{
+		"pulls and stamps digest": {
+			engine: &container.Fake{
+				Digests:   map[string]string{"fetch-mcp:1": "abc123"},
+				Manifests: map[string]string{"fetch-mcp:1": "sha256:m1"},
+			},
+			wantDigest:   "abc123",
+			wantManifest: "sha256:m1",
+			wantOps:      []string{"pull fetch-mcp:1", "digest fetch-mcp:1", "manifest fetch-mcp:1"},
+		},
+		"pulls the locked manifest digest": {
+			engine:       &container.Fake{Digests: map[string]string{"fetch-mcp@sha256:m0": "abc000"}},
+			locked:       "sha256:m0",
+			wantDigest:   "abc000",
+			wantManifest: "sha256:m0",
+			wantOps:      []string{"pull fetch-mcp@sha256:m0", "digest fetch-mcp@sha256:m0"},
+		},
+		"pull fails": {
+			engine:  &container.Fake{Errors: map[string]error{"pull": errors.New("unauthorized")}},
+			wantErr: true,
+		},
+		"creates user-defined network": {
+			engine:     &container.Fake{Digests: map[string]string{"fetch-mcp:1": "abc123"}},
+			network:    "kind",
+			aliases:    []string{"fetch"},
+			wantDigest: "abc123",
+			wantOps:    []string{"pull fetch-mcp:1", "network kind", "digest fetch-mcp:1", "manifest fetch-mcp:1"},
+		},
+		"resolves relative volumes": {
+			engine:      &container.Fake{Digests: map[string]string{"fetch-mcp:1": "abc123"}},
+			volumes:     []string{"./data:/data:ro"},
+			wantDigest:  "abc123",
+			wantOps:     []string{"pull fetch-mcp:1", "digest fetch-mcp:1", "manifest fetch-mcp:1"},
+			wantVolumes: []string{"data:/data:ro"},
+		},
+		"invalid volume": {
+			engine:  &container.Fake{},
+			volumes: []string{"./data:data"},
+			wantErr: true,
+		},
+		"aliases on host network": {
+			engine:  &container.Fake{},
+			network: "host",
+			aliases: []string{"fetch"},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			baseDir := t.TempDir()
+			st := storetest.NewMemory()
+			src := &OCISource{
+				Name: "fetch",
+				MCPConfig: config.MCPSource{
+					Name:      "fetch",
+					Transport: "http",
+					ContainerMCPConfig: &config.ContainerMCPConfig{
+						Image:          "fetch-mcp:1",
+						Network:        tc.network,
+						NetworkAliases: tc.aliases,
+						Volumes:        tc.volumes,
+						CreateVolumes:  true,
+					},
+				},
+				Engine:         tc.engine,
+				BaseDir:        baseDir,
+				ManifestDigest: tc.locked,
+			}
+
+			resolved, err := src.Fetch(context.Background(), st)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Fetch() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+
+			if want := st.Path("oci", "fetch", tc.wantDigest); resolved.Dir != want {
+				t.Errorf("Dir = %q, want %q", resolved.Dir, want)
+			}
+			data, err := st.ReadFile("oci", "fetch", tc.wantDigest, mcpFileName)
+			if err != nil {
+				t.Fatalf("reading mcp.toml: %v", err)
+			}
+			if !strings.Contains(string(data), `digest = '`+tc.wantDigest+`'`) {
+				t.Errorf("mcp.toml missing digest:\n%s", data)
+			}
+			if tc.wantManifest != "" && (resolved.ManifestDigest != tc.wantManifest || !strings.Contains(string(data), `manifest_digest = '`+tc.wantManifest+`'`)) {
+				t.Errorf("manifest digest = %q, want %q in mcp.toml:\n%s", resolved.ManifestDigest, tc.wantManifest, data)
+			}
+			if ops := tc.engine.Ops(); !slices.Equal(ops, tc.wantOps) {
+				t.Errorf("Ops() = %q", ops)
+			}
+
+			var stored config.MCPSource
+			if err := toml.Unmarshal(data, &stored); err != nil {
+				t.Fatalf("parsing mcp.toml: %v", err)
+			}
+			var want []string
+			for _, v := range tc.wantVolumes {
+				want = append(want, filepath.Join(baseDir, v))
+			}
+			if !slices.Equal(resolved.Volumes, want) {
+				t.Errorf("resolved volumes = %q, want %q", resolved.Volumes, want)
+			}
+			if len(stored.Volumes) > 0 {
+				t.Errorf("stored volumes = %q, want none in the shared store entry", stored.Volumes)
+			}
+			if !slices.Equal(src.MCPConfig.Volumes, tc.volumes) {
+				t.Errorf("manifest volumes = %q, want them unchanged", src.MCPConfig.Volumes)
+			}
+		})
+	}
+}
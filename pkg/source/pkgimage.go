@@ -0,0 +1,321 @@
+package source
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/container"
+	"github.com/agentpkg/agentpkg/pkg/store"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Base images package images are built on, by package kind. Go servers
+// are compiled on goBuildImage and run on goBaseImage.
+const (
+	nodeBaseImage   = "docker.io/library/node:22-slim"
+	pythonBaseImage = "ghcr.io/astral-sh/uv:python3.12-bookworm-slim"
+	goBuildImage    = "docker.io/library/golang:1.25"
+	goBaseImage     = "gcr.io/distroless/static-debian12"
+)
+
+// PackageImagePrefix starts the names of the images apkg builds.
+const PackageImagePrefix = "localhost/apkg-"
+
+// npmRegistryBaseURL and goProxyBaseURL are the registry hosts package
+// images resolve versions with, replaced in tests.
+var (
+	npmRegistryBaseURL = "https://registry.npmjs.org"
+	goProxyBaseURL     = "https://proxy.golang.org"
+)
+
+var (
+	goMajorVersion = regexp.MustCompile(`^v[0-9]+$`)
+	npmDistTag     = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9._-]*$`)
+	// exactVersion matches a full semantic version, with or without a
+	// leading v.
+	exactVersion = regexp.MustCompile(`^v?[0-9]+\.[0-9]+\.[0-9]+([-+][0-9A-Za-z.+-]*)?$`)
+)
+
+// PackageImageSource installs a managed package (see
+// config.ManagedStdioMCPConfig) with runtime = "container": it builds a
+// container image with the package installed on the base image of its
+// ecosystem and records a stdio container server running it in the store
+// at oci/<name>/<hash>/, like OCISource. The host only needs a container
+// engine. Images are tagged, and their store entries named, by their
+// Dockerfile, so an install whose package version and base image haven't
+// changed reuses the image, and the lockfile doesn't depend on the ID the
+// engine gave it.
+type PackageImageSource struct {
+	Name      string
+	MCPConfig config.MCPSource
+	// Version, if set, is the concrete version to install instead of
+	// resolving the package's, e.g. the one recorded in the lockfile.
+	Version string
+	// Engine builds the image; nil detects docker or podman.
+	Engine container.Engine
+}
+
+var _ Source = &PackageImageSource{}
+
+func (s *PackageImageSource) Fetch(ctx context.Context, st store.Store) (*ResolvedSource, error) {
+	kind, name, pinned := SplitPackage(s.MCPConfig.Package)
+
+	version := s.Version
+	if version == "" {
+		var err error
+		if version, err = registryVersion(ctx, kind, name, pinned); err != nil {
+			return nil, fmt.Errorf("resolving version of %s: %w", s.MCPConfig.Package, err)
+		}
+	}
+
+	dockerfile, err := s.dockerfile(kind, name, version)
+	if err != nil {
+		return nil, err
+	}
+	image := PackageImageTag(kind, name, dockerfile)
+	_, hash, _ := strings.Cut(image, ":")
+
+	engine := s.Engine
+	if engine == nil {
+		if engine, err = container.DetectEngine(); err != nil {
+			return nil, fmt.Errorf("detecting container engine: %w", err)
+		}
+	}
+
+	if _, err := engine.ImageDigest(ctx, image); err != nil {
+		if err := engine.Build(ctx, image, []byte(dockerfile)); err != nil {
+			return nil, err
+		}
+	}
+
+	// Agents run the image as a stdio container with the server's args
+	// and env.
+	stored := config.MCPSource{
+		Transport:          "stdio",
+		Name:               s.MCPConfig.Name,
+		ContainerMCPConfig: &config.ContainerMCPConfig{Image: image},
+		LocalMCPConfig:     s.MCPConfig.LocalMCPConfig,
+	}
+	data, err := toml.Marshal(stored)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling mcp config: %w", err)
+	}
+
+	segs := []string{"oci", s.Name, hash}
+	st.EnsureDir(segs...)
+	if err := st.WriteFile(data, mcpFilePerms, append(segs, mcpFileName)...); err != nil {
+		return nil, fmt.Errorf("writing mcp config: %w", err)
+	}
+
+	// The integrity is the release's, as for packages installed on the
+	// host, so the lockfile doesn't depend on where the package runs.
+	pkg := kind + ":" + name
+	integrity, err := managedIntegrity(st, segs, pkg, version, func() (string, error) {
+		return registryChecksum(ctx, kind, name, version)
+	})
+	if err != nil {
+		return nil, err
+	}
+	treeIntegrity, err := st.HashDir(segs...)
+	if err != nil {
+		return nil, fmt.Errorf("computing integrity hash: %w", err)
+	}
+
+	return &ResolvedSource{
+		Dir:           st.Path(segs...),
+		Integrity:     integrity,
+		Package:       pkg,
+		Version:       version,
+		TreeIntegrity: treeIntegrity,
+	}, nil
+}
+
+// PackageImageTag returns the tag of the image built from dockerfile for
+// the package name of kind, e.g. "localhost/apkg-npm-scope-server:<hash>".
+func PackageImageTag(kind, name, dockerfile string) string {
+	sum := sha256.Sum256([]byte(dockerfile))
+	repo := strings.Trim(strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '.', r == '_':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		case r == '@':
+			return -1
+		default:
+			return '-'
+		}
+	}, kind+"-"+name), "-.")
+	return PackageImagePrefix + repo + ":" + hex.EncodeToString(sum[:])[:16]
+}
+
+// dockerfile returns the Dockerfile installing version of the package
+// name of kind, with the server's binary as entrypoint.
+func (s *PackageImageSource) dockerfile(kind, name, version string) (string, error) {
+	var b strings.Builder
+	switch kind {
+	case "npm":
+		fmt.Fprintf(&b, "FROM %s\n", nodeBaseImage)
+		b.WriteString("WORKDIR /app\n")
+		fmt.Fprintf(&b, "RUN npm install --omit=dev --no-audit --no-fund %s@%s\n", name, version)
+		fmt.Fprintf(&b, "ENTRYPOINT [\"npx\", \"--no\", %q]\n", name)
+	case "uv", "uv-tool":
+		bin := s.MCPConfig.Bin
+		if bin == "" {
+			bin = name
+		}
+		fmt.Fprintf(&b, "FROM %s\n", pythonBaseImage)
+		b.WriteString("ENV UV_TOOL_BIN_DIR=/usr/local/bin\n")
+		fmt.Fprintf(&b, "RUN uv tool install %s==%s\n", name, version)
+		fmt.Fprintf(&b, "ENTRYPOINT [%q]\n", "/usr/local/bin/"+bin)
+	case "go":
+		bin := goBinaryName(name)
+		fmt.Fprintf(&b, "FROM %s AS build\n", goBuildImage)
+		fmt.Fprintf(&b, "RUN CGO_ENABLED=0 GOBIN=/out go install %s@%s\n", name, version)
+		fmt.Fprintf(&b, "FROM %s\n", goBaseImage)
+		fmt.Fprintf(&b, "COPY --from=build /out/%s /usr/local/bin/%s\n", bin, bin)
+		fmt.Fprintf(&b, "ENTRYPOINT [%q]\n", "/usr/local/bin/"+bin)
+	default:
+		return "", fmt.Errorf("package %q can't run in a container", s.MCPConfig.Package)
+	}
+	return b.String(), nil
+}
+
+// goBinaryName returns the name go install gives the binary of the
+// package at pkgPath: its last element, or the one before a major version
+// suffix like /v2.
+func goBinaryName(pkgPath string) string {
+	base := path.Base(pkgPath)
+	if goMajorVersion.MatchString(base) {
+		return path.Base(path.Dir(pkgPath))
+	}
+	return base
+}
+
+// registryVersion resolves the version of the package name of kind to
+// install from its registry's HTTP API, so no package manager has to be
+// installed on the host. pinned, the version in the package spec, is
+// used as is if it's an exact version; dist-tags like "latest", ranges,
+// and Go branches or commits are resolved to the exact version they
+// stand for, which the image is built with.
+func registryVersion(ctx context.Context, kind, name, pinned string) (string, error) {
+	switch kind {
+	case "npm":
+		tag := pinned
+		switch {
+		case tag == "":
+			tag = "latest"
+		case exactVersion.MatchString(tag):
+			return pinned, nil
+		case !npmDistTag.MatchString(tag):
+			return npmRangeVersion(ctx, name, pinned)
+		}
+		var result struct {
+			Version string `json:"version"`
+		}
+		if err := getJSON(ctx, fmt.Sprintf("%s/%s/%s", npmRegistryBaseURL, url.PathEscape(name), url.PathEscape(tag)), &result); err != nil {
+			return "", err
+		}
+		if result.Version == "" {
+			return "", fmt.Errorf("no version %q of %s on npm", tag, name)
+		}
+		return result.Version, nil
+	case "uv", "uv-tool":
+		if strings.Contains(pinned, "*") {
+			return pypiPrefixVersion(ctx, name, pinned)
+		}
+		if pinned != "" {
+			return pinned, nil
+		}
+		return (&UVSource{Package: name}).resolveConcreteVersion(ctx)
+	case "go":
+		if exactVersion.MatchString(pinned) {
+			return pinned, nil
+		}
+		query := "@latest"
+		if pinned != "" && pinned != "latest" {
+			query = "@v/" + url.PathEscape(pinned) + ".info"
+		}
+		// The package may be in a subdirectory of its module, so ask
+		// the proxy for the longest prefix that is one, as go does.
+		for mod := name; strings.Contains(mod, "/"); mod = path.Dir(mod) {
+			var result struct {
+				Version string `json:"Version"`
+			}
+			if err := getJSON(ctx, fmt.Sprintf("%s/%s/%s", goProxyBaseURL, escapeModulePath(mod), query), &result); err == nil && result.Version != "" {
+				return result.Version, nil
+			}
+		}
+		if pinned != "" && pinned != "latest" {
+			return "", fmt.Errorf("no module providing %s@%s found on the Go proxy", name, pinned)
+		}
+		return "", fmt.Errorf("no module providing %s found on the Go proxy", name)
+	default:
+		return "", fmt.Errorf("unsupported package kind %q", kind)
+	}
+}
+
+// registryChecksum returns the checksum the registry publishes for version
+// of the package name of kind (see PackageIntegrity). Go packages get "",
+// as GoSource gives packages in a subdirectory of their module.
+func registryChecksum(ctx context.Context, kind, name, version string) (string, error) {
+	switch kind {
+	case "npm":
+		var result struct {
+			Dist struct {
+				Integrity string `json:"integrity"`
+			} `json:"dist"`
+		}
+		if err := getJSON(ctx, fmt.Sprintf("%s/%s/%s", npmRegistryBaseURL, url.PathEscape(name), url.PathEscape(version)), &result); err != nil {
+			return "", err
+		}
+		if result.Dist.Integrity == "" {
+			return "", fmt.Errorf("no dist.integrity for %s@%s", name, version)
+		}
+		return result.Dist.Integrity, nil
+	case "uv", "uv-tool":
+		return (&UVSource{Package: name}).releaseDigests(ctx, version)
+	default:
+		return "", nil
+	}
+}
+
+// escapeModulePath escapes a module path for the Go proxy protocol, which
+// writes upper-case letters as "!" and their lower-case form.
+func escapeModulePath(mod string) string {
+	var b strings.Builder
+	for _, r := range mod {
+		if r >= 'A' && r <= 'Z' {
+			b.WriteByte('!')
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// getJSON decodes the JSON document at url into v.
+func getJSON(ctx context.Context, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,154 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/container"
+	"github.com/agentpkg/agentpkg/pkg/store/storetest"
+	"github.com/pelletier/go-toml/v2"
+)
+
+func TestPackageImageSourceFetch(t *testing.T) {
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/@scope%2Fserver/latest":
+			json.NewEncoder(w).Encode(map[string]any{"version": "1.2.3"})
+		case "/@scope%2Fserver/1.2.3":
+			json.NewEncoder(w).Encode(map[string]any{"version": "1.2.3", "dist": map[string]string{"integrity": "sha512-abc"}})
+		case "/@scope%2Fserver/1.1.9":
+			json.NewEncoder(w).Encode(map[string]any{"version": "1.1.9", "dist": map[string]string{"integrity": "sha512-def"}})
+		case "/@scope%2Fserver":
+			json.NewEncoder(w).Encode(map[string]any{"versions": map[string]any{"1.1.9": nil, "1.2.3": nil, "2.0.0": nil}})
+		case "/github.com/org/tools/@latest":
+			json.NewEncoder(w).Encode(map[string]any{"Version": "v0.4.0"})
+		case "/github.com/org/tools/@v/main.info":
+			json.NewEncoder(w).Encode(map[string]any{"Version": "v0.4.1-0.20260101000000-abcdefabcdef"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer registry.Close()
+	origNPM, origGo := npmRegistryBaseURL, goProxyBaseURL
+	npmRegistryBaseURL, goProxyBaseURL = registry.URL, registry.URL
+	t.Cleanup(func() { npmRegistryBaseURL, goProxyBaseURL = origNPM, origGo })
+
+	tests := map[string]struct {
+		pkg         string
+		version     string
+		wantVersion string
+		// wantRun is a line the Dockerfile must contain.
+		wantRun string
+	}{
+		"npm latest": {
+			pkg:         "npm:@scope/server",
+			wantVersion: "1.2.3",
+			wantRun:     "RUN npm install --omit=dev --no-audit --no-fund @scope/server@1.2.3",
+		},
+		"npm locked version": {
+			pkg:         "npm:@scope/server",
+			version:     "1.2.3",
+			wantVersion: "1.2.3",
+			wantRun:     "RUN npm install --omit=dev --no-audit --no-fund @scope/server@1.2.3",
+		},
+		"npm range": {
+			pkg:         "npm:@scope/server@~1.1.0",
+			wantVersion: "1.1.9",
+			wantRun:     "RUN npm install --omit=dev --no-audit --no-fund @scope/server@1.1.9",
+		},
+		"go package in a module subdirectory": {
+			pkg:         "go:github.com/org/tools/cmd/server",
+			wantVersion: "v0.4.0",
+			wantRun:     "RUN CGO_ENABLED=0 GOBIN=/out go install github.com/org/tools/cmd/server@v0.4.0",
+		},
+		"go branch": {
+			pkg:         "go:github.com/org/tools/cmd/server@main",
+			wantVersion: "v0.4.1-0.20260101000000-abcdefabcdef",
+			wantRun:     "RUN CGO_ENABLED=0 GOBIN=/out go install github.com/org/tools/cmd/server@v0.4.1-0.20260101000000-abcdefabcdef",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			st := storetest.NewMemory()
+			engine := &container.Fake{}
+			src := &PackageImageSource{
+				Name: "server",
+				MCPConfig: config.MCPSource{
+					Name:                  "server",
+					ManagedStdioMCPConfig: &config.ManagedStdioMCPConfig{Package: tc.pkg, Runtime: config.RuntimeContainer},
+					LocalMCPConfig:        &config.LocalMCPConfig{Args: []string{"--stdio"}},
+				},
+				Version: tc.version,
+				Engine:  engine,
+			}
+
+			resolved, err := src.Fetch(context.Background(), st)
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if resolved.Version != tc.wantVersion {
+				t.Errorf("Version = %q, want %q", resolved.Version, tc.wantVersion)
+			}
+
+			kind, pkgName, _ := SplitPackage(tc.pkg)
+			dockerfile, err := src.dockerfile(kind, pkgName, tc.wantVersion)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(dockerfile, tc.wantRun+"\n") {
+				t.Errorf("Dockerfile missing %q:\n%s", tc.wantRun, dockerfile)
+			}
+			image := PackageImageTag(kind, pkgName, dockerfile)
+			if ops := engine.Ops(); len(ops) != 2 || ops[1] != "build "+image {
+				t.Errorf("Ops() = %q, want the image built as %s", ops, image)
+			}
+
+			// The store entry and the lockfile depend on the Dockerfile, not
+			// on the image ID the engine gave the build.
+			if resolved.Digest != "" {
+				t.Errorf("Digest = %q, want none", resolved.Digest)
+			}
+			_, hash, _ := strings.Cut(image, ":")
+			if want := st.Path("oci", "server", hash); resolved.Dir != want {
+				t.Errorf("Dir = %q, want %q", resolved.Dir, want)
+			}
+			data, err := st.ReadFile("oci", "server", hash, mcpFileName)
+			if err != nil {
+				t.Fatalf("reading mcp.toml: %v", err)
+			}
+			var stored config.MCPSource
+			if err := toml.Unmarshal(data, &stored); err != nil {
+				t.Fatalf("parsing mcp.toml: %v", err)
+			}
+			if stored.Transport != "stdio" || stored.ContainerMCPConfig == nil || stored.Image != image || stored.LocalMCPConfig == nil || stored.Args[0] != "--stdio" {
+				t.Errorf("stored config = %s, want a stdio container server running %s", data, image)
+			}
+
+			// Images are reused while the Dockerfile is unchanged.
+			if _, err := src.Fetch(context.Background(), st); err != nil {
+				t.Fatalf("second Fetch() error = %v", err)
+			}
+			for _, op := range engine.Ops()[2:] {
+				if strings.HasPrefix(op, "build ") {
+					t.Errorf("second Fetch() rebuilt the image: %q", engine.Ops())
+				}
+			}
+		})
+	}
+}
+
+func TestPackageImageTag(t *testing.T) {
+	got := PackageImageTag("npm", "@Scope/Server", "FROM node\n")
+	if !strings.HasPrefix(got, PackageImagePrefix+"npm-scope-server:") || len(got) != len(PackageImagePrefix+"npm-scope-server:")+16 {
+		t.Errorf("PackageImageTag() = %q", got)
+	}
+	if PackageImageTag("npm", "@Scope/Server", "FROM node:23\n") == got {
+		t.Error("PackageImageTag() doesn't depend on the Dockerfile")
+	}
+}
@@ -10,14 +10,18 @@ import (
 
 // ParseRef parses a user-provided reference into a Source and its config
 // representation. Local filesystem paths (starting with ./, ../, or absolute)
-// produce a LocalSource. Everything else is treated as a git short-form
-// reference: owner/repo/path@ref, mapped to a GitHub HTTPS URL.
+// produce a LocalSource, and http(s) URLs an ArchiveSource. Everything else
+// is treated as a git short-form reference: owner/repo/path@ref, mapped to a
+// GitHub HTTPS URL.
 func ParseRef(ref string) (Source, config.SkillSource, error) {
 	if isLocalPath(ref) {
 		src := &LocalSource{Path: ref}
 		ss := config.SkillSource{Path: ref}
 		return src, ss, nil
 	}
+	if strings.HasPrefix(ref, "https://") || strings.HasPrefix(ref, "http://") {
+		return &ArchiveSource{URL: ref}, config.SkillSource{URL: ref}, nil
+	}
 
 	parts := strings.SplitN(ref, "@", 2)
 	if len(parts) != 2 || parts[1] == "" {
@@ -34,10 +38,15 @@ func ParseRef(ref string) (Source, config.SkillSource, error) {
 
 	owner := segments[0]
 	repo := segments[1]
+	if owner == "" || repo == "" {
+		return nil, config.SkillSource{}, fmt.Errorf("invalid ref %q: must have at least owner/repo", ref)
+	}
 
+	// Without a subpath (owner/repo@ref, or owner/repo/@ref) the skill
+	// is at the repository root.
 	var subPath string
 	if len(segments) > 2 {
-		subPath = strings.Join(segments[2:], "/")
+		subPath = strings.Trim(strings.Join(segments[2:], "/"), "/")
 	}
 
 	gitURL := fmt.Sprintf("https://github.com/%s/%s.git", owner, repo)
@@ -48,7 +57,8 @@ func ParseRef(ref string) (Source, config.SkillSource, error) {
 }
 
 // SourceFromSkillConfig converts a config.SkillSource into a Source.
-// If Git is set, returns a GitSource; otherwise returns a LocalSource using Path.
+// If Git is set, returns a GitSource; if URL is set, an ArchiveSource;
+// otherwise returns a LocalSource using Path.
 func SourceFromSkillConfig(ss config.SkillSource) Source {
 	if ss.Git != "" {
 		return &GitSource{
@@ -57,9 +67,17 @@ func SourceFromSkillConfig(ss config.SkillSource) Source {
 			Ref:  ss.Ref,
 		}
 	}
+	if ss.URL != "" {
+		return &ArchiveSource{
+			URL:    ss.URL,
+			Path:   ss.Path,
+			SHA256: ss.SHA256,
+		}
+	}
 
 	return &LocalSource{
-		Path: ss.Path,
+		Path:     ss.Path,
+		Snapshot: ss.Snapshot,
 	}
 }
 
@@ -69,10 +87,14 @@ func SourceFromMCPConfig(name string, ms config.MCPSource) (Source, error) {
 	}
 
 	switch {
+	case ms.ManagedStdioMCPConfig != nil && ms.InContainer():
+		return &PackageImageSource{Name: ms.Name, MCPConfig: ms}, nil
 	case ms.ManagedStdioMCPConfig != nil && strings.HasPrefix(ms.Package, "npm:"):
 		return &NPMSource{Package: strings.TrimPrefix(ms.Package, "npm:"), MCPConfig: ms}, nil
 	case ms.ManagedStdioMCPConfig != nil && strings.HasPrefix(ms.Package, "uv:"):
 		return &UVSource{Package: strings.TrimPrefix(ms.Package, "uv:"), MCPConfig: ms}, nil
+	case ms.ManagedStdioMCPConfig != nil && strings.HasPrefix(ms.Package, "uv-tool:"):
+		return &UVSource{Package: strings.TrimPrefix(ms.Package, "uv-tool:"), MCPConfig: ms, Tool: true}, nil
 	case ms.ManagedStdioMCPConfig != nil && strings.HasPrefix(ms.Package, "go:"):
 		return &GoSource{Package: strings.TrimPrefix(ms.Package, "go:"), MCPConfig: ms}, nil
 	case ms.UnmanagedStdioMCPConfig != nil:
@@ -86,6 +108,39 @@ func SourceFromMCPConfig(name string, ms config.MCPSource) (Source, error) {
 	}
 }
 
+// SourceFromMCPLock is SourceFromMCPConfig with a managed package pinned to
+// the version resolved for it in entry, the server's lock entry, so
+// installs get the version the lockfile records instead of re-resolving
+// e.g. "latest", and a container image pinned to its locked manifest
+// digest instead of pulling whatever its tag points to now. The pin only
+// holds while the manifest's package or image matches the locked one;
+// apkg update resolves newer versions.
+func SourceFromMCPLock(name string, ms config.MCPSource, entry config.MCPLockEntry) (Source, error) {
+	src, err := SourceFromMCPConfig(name, ms)
+	if err != nil {
+		return nil, err
+	}
+	if s, ok := src.(*OCISource); ok && entry.ManifestDigest != "" && entry.Image == ms.Image {
+		s.ManifestDigest = entry.ManifestDigest
+		return src, nil
+	}
+	if ms.ManagedStdioMCPConfig == nil || entry.ResolvedVersion == "" || entry.Package != ms.Package {
+		return src, nil
+	}
+
+	switch s := src.(type) {
+	case *NPMSource:
+		s.Version = entry.ResolvedVersion
+	case *UVSource:
+		s.Version = entry.ResolvedVersion
+	case *GoSource:
+		s.Version = entry.ResolvedVersion
+	case *PackageImageSource:
+		s.Version = entry.ResolvedVersion
+	}
+	return src, nil
+}
+
 // isLocalPath reports whether ref looks like a local filesystem path.
 func isLocalPath(ref string) bool {
 	return strings.HasPrefix(ref, "./") || strings.HasPrefix(ref, "../") || filepath.IsAbs(ref)
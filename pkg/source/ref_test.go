@@ -28,6 +28,16 @@ func TestParseRef(t *testing.T) {
 			wantPath: "",
 			wantRef:  "v1.0",
 		},
+		"git ref with trailing slash": {
+			ref:      "anthropics/skills/@v1.0",
+			wantGit:  "https://github.com/anthropics/skills.git",
+			wantPath: "",
+			wantRef:  "v1.0",
+		},
+		"empty repo": {
+			ref:     "anthropics//skills@main",
+			wantErr: true,
+		},
 		"git ref with deep subpath": {
 			ref:      "org/repo/a/b/c/d@feature",
 			wantGit:  "https://github.com/org/repo.git",
@@ -117,6 +127,7 @@ func TestSourceFromSkillConfig(t *testing.T) {
 		input      config.SkillSource
 		wantType   string
 		wantGitURL string
+		wantURL    string
 		wantPath   string
 	}{
 		"git source": {
@@ -136,6 +147,15 @@ func TestSourceFromSkillConfig(t *testing.T) {
 			wantType: "local",
 			wantPath: "./my-skills/review",
 		},
+		"archive source": {
+			input: config.SkillSource{
+				URL:  "https://example.com/skills.zip",
+				Path: "skills/pdf",
+			},
+			wantType: "archive",
+			wantURL:  "https://example.com/skills.zip",
+			wantPath: "skills/pdf",
+		},
 	}
 
 	for name, tc := range tests {
@@ -154,6 +174,17 @@ func TestSourceFromSkillConfig(t *testing.T) {
 				if gs.Path != tc.wantPath {
 					t.Errorf("Path = %q, want %q", gs.Path, tc.wantPath)
 				}
+			case "archive":
+				as, ok := src.(*ArchiveSource)
+				if !ok {
+					t.Fatalf("SourceFromConfig() returned %T, want *ArchiveSource", src)
+				}
+				if as.URL != tc.wantURL {
+					t.Errorf("URL = %q, want %q", as.URL, tc.wantURL)
+				}
+				if as.Path != tc.wantPath {
+					t.Errorf("Path = %q, want %q", as.Path, tc.wantPath)
+				}
 			case "local":
 				ls, ok := src.(*LocalSource)
 				if !ok {
@@ -239,6 +270,83 @@ func TestSourceFromMCPConfig(t *testing.T) {
 	}
 }
 
+func TestSourceFromMCPLock(t *testing.T) {
+	managed := func(pkg string) config.MCPSource {
+		return config.MCPSource{
+			Transport:             "stdio",
+			ManagedStdioMCPConfig: &config.ManagedStdioMCPConfig{Package: pkg},
+		}
+	}
+	image := func(image string) config.MCPSource {
+		return config.MCPSource{
+			Transport:          "http",
+			ContainerMCPConfig: &config.ContainerMCPConfig{Image: image},
+		}
+	}
+
+	tests := map[string]struct {
+		ms          config.MCPSource
+		entry       config.MCPLockEntry
+		wantVersion string
+	}{
+		"npm pinned to locked version": {
+			ms:          managed("npm:some-pkg"),
+			entry:       config.MCPLockEntry{Package: "npm:some-pkg", ResolvedVersion: "1.4.2"},
+			wantVersion: "1.4.2",
+		},
+		"uv pinned to locked version": {
+			ms:          managed("uv:some-pkg"),
+			entry:       config.MCPLockEntry{Package: "uv:some-pkg", ResolvedVersion: "0.9.1"},
+			wantVersion: "0.9.1",
+		},
+		"go pinned to locked version": {
+			ms:          managed("go:github.com/example/tool@latest"),
+			entry:       config.MCPLockEntry{Package: "go:github.com/example/tool@latest", ResolvedVersion: "v1.2.0"},
+			wantVersion: "v1.2.0",
+		},
+		"package changed in manifest": {
+			ms:    managed("npm:some-pkg@2"),
+			entry: config.MCPLockEntry{Package: "npm:some-pkg", ResolvedVersion: "1.4.2"},
+		},
+		"not locked": {
+			ms: managed("npm:some-pkg"),
+		},
+		"image pinned to locked manifest digest": {
+			ms:          image("fetch-mcp:1"),
+			entry:       config.MCPLockEntry{Image: "fetch-mcp:1", ManifestDigest: "sha256:m1"},
+			wantVersion: "sha256:m1",
+		},
+		"image changed in manifest": {
+			ms:    image("fetch-mcp:2"),
+			entry: config.MCPLockEntry{Image: "fetch-mcp:1", ManifestDigest: "sha256:m1"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			src, err := SourceFromMCPLock("server", tc.ms, tc.entry)
+			if err != nil {
+				t.Fatalf("SourceFromMCPLock() error = %v", err)
+			}
+
+			var version string
+			switch s := src.(type) {
+			case *NPMSource:
+				version = s.Version
+			case *UVSource:
+				version = s.Version
+			case *GoSource:
+				version = s.Version
+			case *OCISource:
+				version = s.ManifestDigest
+			}
+			if version != tc.wantVersion {
+				t.Errorf("pinned version = %q, want %q", version, tc.wantVersion)
+			}
+		})
+	}
+}
+
 func TestIsLocalPath(t *testing.T) {
 	tests := map[string]struct {
 		input string
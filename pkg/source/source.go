@@ -16,5 +16,68 @@ type ResolvedSource struct {
 	Dir       string // Path to package content on disk
 	Commit    string // Resolved commit hash (git only)
 	Ref       string // Original ref (git only)
-	Integrity string // SHA256 of directory contents (empty for local)
+	Integrity string // SHA256 of directory contents (empty for local); see PackageIntegrity for managed packages
+
+	// Package and Version identify the installed release of a managed
+	// package, e.g. "npm:@scope/server" and "1.2.3".
+	Package string
+	Version string
+	// TreeIntegrity is the SHA256 of the installed tree of a managed
+	// package. It differs between platforms, so it is only meaningful on
+	// the machine that installed the package.
+	TreeIntegrity string
+
+	// Digest and ManifestDigest are the image ID and registry manifest
+	// digest of a container image.
+	Digest         string
+	ManifestDigest string
+
+	// SHA256 is the hex digest of a downloaded archive (see
+	// ArchiveSource).
+	SHA256 string
+
+	// Runtime is the node binary an npm package runs on, and Volumes are
+	// the volumes of a container with their host paths made absolute.
+	// Both depend on the project installing the package, so they are
+	// left out of its store entry, which projects share (see
+	// mcp.Options).
+	Runtime string
+	Volumes []string
+}
+
+// Cached reports whether fetching src would find its content in st
+// without cloning or installing anything: a git source at a full commit,
+// or a managed package at a concrete version (e.g. the locked one), that
+// is already in the store. Other sources report false.
+func Cached(st store.Store, src Source) bool {
+	var segs []string
+	switch src := src.(type) {
+	case *GitSource:
+		if !isCommitHash(src.Ref) {
+			return false
+		}
+		var err error
+		if segs, err = src.repoSegments(src.Ref); err != nil {
+			return false
+		}
+	case *NPMSource:
+		if src.Version == "" {
+			return false
+		}
+		segs = src.getStoreSegments(src.Version)
+	case *UVSource:
+		if src.Version == "" {
+			return false
+		}
+		segs = src.getStoreSegments(src.Version)
+	case *GoSource:
+		if src.Version == "" {
+			return false
+		}
+		segs = src.getStoreSegments(src.Version)
+	default:
+		return false
+	}
+	cached, err := st.Exists(segs...)
+	return err == nil && cached
 }
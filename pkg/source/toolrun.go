@@ -0,0 +1,110 @@
+package source
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+// LogsDir is the store directory that keeps the output of failed package
+// manager runs.
+const LogsDir = "logs"
+
+// maxToolLogs is how many logs LogsDir keeps: writing another deletes the
+// oldest, so retrying a failing install doesn't fill the store.
+const maxToolLogs = 20
+
+// runTool runs cmd, a package manager installing the package name,
+// streaming its output to out if set. If it fails, its full output is
+// written to a log in the store's LogsDir, and the error names the log
+// and ends with the output's last line, which usually says what went
+// wrong.
+func runTool(cmd *exec.Cmd, st store.Store, name string, out io.Writer) error {
+	var buf bytes.Buffer
+	var w io.Writer = &buf
+	if out != nil {
+		w = io.MultiWriter(&buf, out)
+	}
+	cmd.Stdout = w
+	cmd.Stderr = w
+
+	runErr := cmd.Run()
+	if runErr == nil {
+		return nil
+	}
+
+	last := lastLine(buf.String())
+	logPath, err := writeToolLog(st, name, cmd, buf.Bytes())
+	switch {
+	case err != nil && last == "":
+		return runErr
+	case err != nil:
+		return fmt.Errorf("%w: %s", runErr, last)
+	case last == "":
+		return fmt.Errorf("%w (full output in %s)", runErr, logPath)
+	}
+	return fmt.Errorf("%w: %s (full output in %s)", runErr, last, logPath)
+}
+
+// writeToolLog writes the command line and output of cmd to a new log in
+// LogsDir, returning its path.
+func writeToolLog(st store.Store, name string, cmd *exec.Cmd, output []byte) (string, error) {
+	st.EnsureDir(LogsDir)
+	file := strings.NewReplacer("/", "_", "@", "", ":", "_").Replace(name) + "-" + time.Now().Format("20060102-150405") + ".log"
+	path := st.Path(LogsDir, file)
+
+	var log bytes.Buffer
+	fmt.Fprintf(&log, "$ %s\n", strings.Join(cmd.Args, " "))
+	log.Write(output)
+	if err := os.WriteFile(path, log.Bytes(), 0o644); err != nil {
+		return "", fmt.Errorf("writing %s: %w", path, err)
+	}
+	pruneToolLogs(st.Path(LogsDir), maxToolLogs)
+	return path, nil
+}
+
+// pruneToolLogs deletes all but the newest keep logs in dir. Failing to
+// prune doesn't fail the install that wrote the log.
+func pruneToolLogs(dir string, keep int) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	type logFile struct {
+		name    string
+		modTime time.Time
+	}
+	var logs []logFile
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".log" {
+			continue
+		}
+		if info, err := e.Info(); err == nil {
+			logs = append(logs, logFile{e.Name(), info.ModTime()})
+		}
+	}
+	if len(logs) <= keep {
+		return
+	}
+
+	slices.SortFunc(logs, func(a, b logFile) int {
+		return b.modTime.Compare(a.modTime)
+	})
+	for _, l := range logs[keep:] {
+		os.Remove(filepath.Join(dir, l.name))
+	}
+}
+
+// lastLine returns the last non-blank line of s, trimmed.
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
@@ -0,0 +1,118 @@
+package source
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+func TestRunTool(t *testing.T) {
+	tests := map[string]struct {
+		script  string
+		verbose bool
+		wantErr string
+		// wantLog is the output the failure's log should hold, after the
+		// command line.
+		wantLog    string
+		wantStream string
+	}{
+		"success leaves no log": {
+			script: "echo installed",
+		},
+		"failure keeps the full output": {
+			script:  "echo resolving; echo 'npm ERR! code E404' >&2; echo 'npm ERR! 404 Not Found' >&2; exit 1",
+			wantErr: "exit status 1: npm ERR! 404 Not Found (full output in ",
+			wantLog: "resolving\nnpm ERR! code E404\nnpm ERR! 404 Not Found\n",
+		},
+		"failure without output": {
+			script:  "exit 2",
+			wantErr: "exit status 2 (full output in ",
+			wantLog: "",
+		},
+		"verbose streams the output": {
+			script:     "echo resolving; echo fetching >&2",
+			verbose:    true,
+			wantStream: "resolving\nfetching\n",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			st := store.New(t.TempDir())
+			var stream bytes.Buffer
+			cmd := exec.Command("sh", "-c", tc.script)
+			var err error
+			if tc.verbose {
+				err = runTool(cmd, st, "npm-@scope/pkg@1.0.0", &stream)
+			} else {
+				err = runTool(cmd, st, "npm-@scope/pkg@1.0.0", nil)
+			}
+
+			if stream.String() != tc.wantStream {
+				t.Errorf("streamed %q, want %q", stream.String(), tc.wantStream)
+			}
+			logs, _ := filepath.Glob(st.Path(LogsDir, "*.log"))
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("runTool() error = %v", err)
+				}
+				if len(logs) > 0 {
+					t.Errorf("logs = %v, want none", logs)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("runTool() error = %v, want it to contain %q", err, tc.wantErr)
+			}
+			if len(logs) != 1 {
+				t.Fatalf("logs = %v, want one", logs)
+			}
+			if !strings.Contains(err.Error(), logs[0]) {
+				t.Errorf("error %q doesn't name the log %s", err, logs[0])
+			}
+			data, err := os.ReadFile(logs[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := "$ sh -c " + tc.script + "\n" + tc.wantLog; string(data) != want {
+				t.Errorf("log = %q, want %q", data, want)
+			}
+		})
+	}
+}
+
+func TestPruneToolLogs(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Now().Add(-time.Hour)
+	for i := range 5 {
+		path := filepath.Join(dir, fmt.Sprintf("npm-pkg-%d.log", i))
+		os.WriteFile(path, nil, 0o644)
+		mod := start.Add(time.Duration(i) * time.Minute)
+		os.Chtimes(path, mod, mod)
+	}
+	os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0o644)
+
+	pruneToolLogs(dir, 3)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Name())
+	}
+	want := []string{"notes.txt", "npm-pkg-2.log", "npm-pkg-3.log", "npm-pkg-4.log"}
+	if !slices.Equal(got, want) {
+		t.Errorf("after pruning = %v, want %v", got, want)
+	}
+}
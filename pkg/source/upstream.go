@@ -0,0 +1,124 @@
+package source
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ResolveGitRef resolves ref (a branch, tag, or commit) in the repository at
+// url to a full commit hash.
+func ResolveGitRef(ctx context.Context, url, ref string) (string, error) {
+	return (&GitSource{URL: url, Ref: ref}).resolveRef(ctx)
+}
+
+// RemoteTags lists the tag names of the repository at url.
+func RemoteTags(ctx context.Context, url string) ([]string, error) {
+	return remoteTags(ctx, nil, url)
+}
+
+func remoteTags(ctx context.Context, m *Metadata, url string) ([]string, error) {
+	out, err := m.lookup(ctx, "git tags "+url, gitHost(url), func(ctx context.Context) ([]byte, error) {
+		g := &GitSource{URL: url}
+		out, err := g.git(ctx, "ls-remote", "--tags", "--refs", url).Output()
+		if err != nil {
+			return nil, execError(err)
+		}
+		return out, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []string
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		if tag, ok := strings.CutPrefix(fields[1], "refs/tags/"); ok {
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+// LatestPackageVersion returns the latest published version of a managed
+// package ("npm:<pkg>", "uv:<pkg>", "uv-tool:<pkg>", or "go:<module>"),
+// ignoring any version pinned in the spec.
+func LatestPackageVersion(ctx context.Context, pkg string) (string, error) {
+	return latestPackageVersion(ctx, nil, pkg)
+}
+
+func latestPackageVersion(ctx context.Context, m *Metadata, pkg string) (string, error) {
+	kind, name, _ := SplitPackage(pkg)
+	switch kind {
+	case "npm":
+		out, err := m.lookup(ctx, "npm latest "+name, npmRegistryHost, func(ctx context.Context) ([]byte, error) {
+			version, err := (&NPMSource{Package: name}).resolveConcreteVersion(ctx)
+			return []byte(version), err
+		})
+		return string(out), err
+	case "uv", "uv-tool":
+		return pypiLatest(ctx, m, name)
+	case "go":
+		out, err := m.lookup(ctx, "go latest "+name, goProxyHost, func(ctx context.Context) ([]byte, error) {
+			version, err := (&GoSource{Package: name + "@latest"}).resolveConcreteVersion(ctx)
+			return []byte(version), err
+		})
+		if err != nil {
+			return "", err
+		}
+		if string(out) == "latest" {
+			return "", fmt.Errorf("resolving latest version of %s: module not found", name)
+		}
+		return string(out), nil
+	default:
+		return "", fmt.Errorf("unsupported package %q", pkg)
+	}
+}
+
+// SplitPackage splits a managed package spec into its kind ("npm", "uv",
+// "uv-tool", or "go"), package name, and pinned version (empty if unpinned).
+func SplitPackage(pkg string) (kind, name, version string) {
+	kind, spec, ok := strings.Cut(pkg, ":")
+	if !ok {
+		return "", pkg, ""
+	}
+
+	switch kind {
+	case "uv", "uv-tool":
+		name, version, _ = strings.Cut(spec, "==")
+	case "npm", "go":
+		// A leading @ is an npm scope, not a version separator.
+		name = spec
+		if idx := strings.LastIndex(spec, "@"); idx > 0 {
+			name, version = spec[:idx], spec[idx+1:]
+		}
+	default:
+		name = spec
+	}
+	return kind, name, version
+}
+
+// PinPackage returns the managed package spec pinned to version.
+func PinPackage(pkg, version string) string {
+	kind, name, _ := SplitPackage(pkg)
+	switch kind {
+	case "uv", "uv-tool":
+		return kind + ":" + name + "==" + version
+	case "npm", "go":
+		return kind + ":" + name + "@" + version
+	default:
+		return pkg
+	}
+}
+
+// IsCommitRef reports whether ref is a full or abbreviated commit hash
+// rather than a branch or tag name.
+func IsCommitRef(ref string) bool {
+	return isCommitHash(ref) || isShortCommitHash(ref)
+}
@@ -0,0 +1,72 @@
+package source
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestSplitPackage(t *testing.T) {
+	tests := map[string]struct {
+		pkg         string
+		wantKind    string
+		wantName    string
+		wantVersion string
+	}{
+		"npm unpinned":      {pkg: "npm:server-fs", wantKind: "npm", wantName: "server-fs"},
+		"npm pinned":        {pkg: "npm:server-fs@1.2.0", wantKind: "npm", wantName: "server-fs", wantVersion: "1.2.0"},
+		"npm scoped":        {pkg: "npm:@org/server", wantKind: "npm", wantName: "@org/server"},
+		"npm scoped pinned": {pkg: "npm:@org/server@2.0.0", wantKind: "npm", wantName: "@org/server", wantVersion: "2.0.0"},
+		"uv pinned":         {pkg: "uv:mcp-server-git==0.6.2", wantKind: "uv", wantName: "mcp-server-git", wantVersion: "0.6.2"},
+		"uv-tool pinned":    {pkg: "uv-tool:mcp-proxy==0.8.0", wantKind: "uv-tool", wantName: "mcp-proxy", wantVersion: "0.8.0"},
+		"go pinned":         {pkg: "go:github.com/org/server@v1.4.0", wantKind: "go", wantName: "github.com/org/server", wantVersion: "v1.4.0"},
+		"no kind":           {pkg: "server-fs", wantName: "server-fs"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			kind, pkgName, version := SplitPackage(tc.pkg)
+			if kind != tc.wantKind || pkgName != tc.wantName || version != tc.wantVersion {
+				t.Errorf("SplitPackage(%q) = (%q, %q, %q), want (%q, %q, %q)",
+					tc.pkg, kind, pkgName, version, tc.wantKind, tc.wantName, tc.wantVersion)
+			}
+		})
+	}
+}
+
+func TestPinPackage(t *testing.T) {
+	tests := map[string]struct {
+		pkg     string
+		version string
+		want    string
+	}{
+		"npm repin":    {pkg: "npm:@org/server@1.0.0", version: "1.1.0", want: "npm:@org/server@1.1.0"},
+		"npm unpinned": {pkg: "npm:server-fs", version: "1.1.0", want: "npm:server-fs@1.1.0"},
+		"uv":           {pkg: "uv:mcp-server-git==0.6.2", version: "0.7.0", want: "uv:mcp-server-git==0.7.0"},
+		"uv-tool":      {pkg: "uv-tool:mcp-proxy", version: "0.8.0", want: "uv-tool:mcp-proxy==0.8.0"},
+		"go":           {pkg: "go:github.com/org/server@v1.4.0", version: "v1.5.0", want: "go:github.com/org/server@v1.5.0"},
+		"unknown kind": {pkg: "pip:server", version: "1.0", want: "pip:server"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := PinPackage(tc.pkg, tc.version); got != tc.want {
+				t.Errorf("PinPackage(%q, %q) = %q, want %q", tc.pkg, tc.version, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRemoteTags(t *testing.T) {
+	requireGit(t)
+	repoURL, _ := setupBareRepo(t)
+
+	tags, err := RemoteTags(context.Background(), repoURL)
+	if err != nil {
+		t.Fatalf("RemoteTags() error = %v", err)
+	}
+	slices.Sort(tags)
+	if want := []string{"v1.0", "v2.0"}; !slices.Equal(tags, want) {
+		t.Errorf("RemoteTags() = %v, want %v", tags, want)
+	}
+}
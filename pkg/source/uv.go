@@ -4,18 +4,39 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"os/exec"
+	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/runtimes"
 	"github.com/agentpkg/agentpkg/pkg/store"
 	"github.com/pelletier/go-toml/v2"
 )
 
+// pypiBaseURL is the PyPI JSON API host, replaced in tests.
+var pypiBaseURL = "https://pypi.org"
+
 type UVSource struct {
 	Package   string
 	MCPConfig config.MCPSource
+	// Version, if set, is the concrete version to install instead of
+	// resolving Package's, e.g. the one recorded in the lockfile.
+	Version string
+	// Python, if set, is the managed runtime the package's virtualenv is
+	// created with instead of the Python uv picks.
+	Python *runtimes.Runtime
+	// Output, if set, receives the output of uv as it installs the
+	// package, e.g. for apkg install --verbose.
+	Output io.Writer
+	// Tool installs the package with `uv tool install`, as for uv-tool:
+	// packages, into an isolated tool environment whose receipt lists
+	// the console scripts it provides, instead of into a plain
+	// virtualenv.
+	Tool bool
 }
 
 var _ Source = &UVSource{}
@@ -37,7 +58,7 @@ func (s *UVSource) Fetch(ctx context.Context, store store.Store) (*ResolvedSourc
 		store.EnsureDir(segs...)
 		path := store.Path(segs...)
 
-		if err := s.install(ctx, path, version); err != nil {
+		if err := s.install(ctx, store, path, version); err != nil {
 			store.Remove(segs...)
 			return nil, fmt.Errorf("failed to install uv package %s==%s: %w", s.packageName(), version, err)
 		}
@@ -49,25 +70,34 @@ func (s *UVSource) Fetch(ctx context.Context, store store.Store) (*ResolvedSourc
 		return nil, fmt.Errorf("writing mcp config: %w", err)
 	}
 
-	integrity, err := store.HashDir(segs...)
+	pkg := s.kind() + ":" + s.packageName()
+	integrity, err := managedIntegrity(store, segs, pkg, version, func() (string, error) {
+		return s.releaseDigests(ctx, version)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	treeIntegrity, err := store.HashDir(segs...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to compute integrity hash: %w", err)
 	}
 
 	return &ResolvedSource{
-		Dir:       store.Path(segs...),
-		Integrity: integrity,
+		Dir:           store.Path(segs...),
+		Integrity:     integrity,
+		Package:       pkg,
+		Version:       version,
+		TreeIntegrity: treeIntegrity,
 	}, nil
 }
 
-func (s *UVSource) resolveConcreteVersion(ctx context.Context) (string, error) {
-	// if the package spec contains ==, extract the pinned version directly
-	if idx := strings.Index(s.Package, "=="); idx >= 0 {
-		return s.Package[idx+2:], nil
-	}
-
-	// otherwise query PyPI JSON API for the latest version
-	url := fmt.Sprintf("https://pypi.org/pypi/%s/json", s.packageName())
+// releaseDigests returns the sha256 digests PyPI publishes for every file
+// of the release (sdist and all wheels), one "<filename> <sha256>" line per
+// file in sorted order, so the result doesn't depend on which wheel the
+// platform installed.
+func (s *UVSource) releaseDigests(ctx context.Context, version string) (string, error) {
+	url := fmt.Sprintf("%s/pypi/%s/%s/json", pypiBaseURL, s.packageName(), version)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
@@ -81,7 +111,56 @@ func (s *UVSource) resolveConcreteVersion(ctx context.Context) (string, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("pypi returned status %d for %s", resp.StatusCode, s.packageName())
+		return "", fmt.Errorf("pypi returned status %d for %s==%s", resp.StatusCode, s.packageName(), version)
+	}
+
+	var result struct {
+		URLs []struct {
+			Filename string `json:"filename"`
+			Digests  struct {
+				SHA256 string `json:"sha256"`
+			} `json:"digests"`
+		} `json:"urls"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("decoding pypi response for %s: %w", s.packageName(), err)
+	}
+
+	var lines []string
+	for _, file := range result.URLs {
+		lines = append(lines, file.Filename+" "+file.Digests.SHA256)
+	}
+	if len(lines) == 0 {
+		return "", fmt.Errorf("no release files for %s==%s on pypi", s.packageName(), version)
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n"), nil
+}
+
+func (s *UVSource) resolveConcreteVersion(ctx context.Context) (string, error) {
+	if s.Version != "" {
+		return s.Version, nil
+	}
+
+	// if the package spec contains ==, extract the pinned version directly
+	if idx := strings.Index(s.Package, "=="); idx >= 0 {
+		return s.Package[idx+2:], nil
+	}
+
+	// otherwise query PyPI JSON API for the latest version
+	return pypiLatest(ctx, nil, s.packageName())
+}
+
+// pypiLatest returns the latest version of name on PyPI.
+func pypiLatest(ctx context.Context, m *Metadata, name string) (string, error) {
+	url := fmt.Sprintf("%s/pypi/%s/json", pypiBaseURL, name)
+
+	status, body, err := m.get(ctx, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("querying pypi for %s: %w", name, err)
+	}
+	if status != http.StatusOK {
+		return "", fmt.Errorf("pypi returned status %d for %s", status, name)
 	}
 
 	var result struct {
@@ -89,19 +168,32 @@ func (s *UVSource) resolveConcreteVersion(ctx context.Context) (string, error) {
 			Version string `json:"version"`
 		} `json:"info"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("decoding pypi response for %s: %w", s.packageName(), err)
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("decoding pypi response for %s: %w", name, err)
 	}
 
 	if result.Info.Version == "" {
-		return "", fmt.Errorf("no version found for %s on pypi", s.packageName())
+		return "", fmt.Errorf("no version found for %s on pypi", name)
 	}
 
 	return result.Info.Version, nil
 }
 
 func (s *UVSource) getStoreSegments(resolvedVersion string) []string {
-	return []string{"uv", s.packageName(), resolvedVersion}
+	// A virtualenv is bound to the interpreter it was created with.
+	if s.Python != nil {
+		resolvedVersion += "-python" + s.Python.Version
+	}
+	return []string{s.kind(), s.packageName(), resolvedVersion}
+}
+
+// kind is the prefix of the package's specs, which also names its store
+// directory.
+func (s *UVSource) kind() string {
+	if s.Tool {
+		return "uv-tool"
+	}
+	return "uv"
 }
 
 func (s *UVSource) packageName() string {
@@ -111,23 +203,51 @@ func (s *UVSource) packageName() string {
 	return s.Package
 }
 
-func (s *UVSource) install(ctx context.Context, dest string, version string) error {
+func (s *UVSource) install(ctx context.Context, st store.Store, dest string, version string) error {
+	if s.Tool {
+		return s.installTool(ctx, st, dest, version)
+	}
+
 	venvPath := dest + "/.venv"
 
-	cmd := exec.CommandContext(ctx, "uv", "venv", venvPath)
-	if _, err := cmd.Output(); err != nil {
-		return fmt.Errorf("creating venv: %w", execError(err))
+	args := []string{"venv", venvPath}
+	if s.Python != nil {
+		args = append(args, "--python", s.Python.Bin)
 	}
-
 	pkg := fmt.Sprintf("%s==%s", s.packageName(), version)
+	cmd := exec.CommandContext(ctx, "uv", args...)
+	if err := runTool(cmd, st, "uv-"+pkg, s.Output); err != nil {
+		return fmt.Errorf("creating venv: %w", err)
+	}
+
 	cmd = exec.CommandContext(ctx, "uv", "pip", "install", "--python", venvPath+"/bin/python", pkg)
-	if _, err := cmd.Output(); err != nil {
-		return fmt.Errorf("installing package: %w", execError(err))
+	if err := runTool(cmd, st, "uv-"+pkg, s.Output); err != nil {
+		return fmt.Errorf("installing package: %w", err)
 	}
 
 	return nil
 }
 
+// installTool installs the package as a uv tool under dest: its
+// environment and receipt in tools/ and its console scripts in bin/, so
+// nothing is written to the user's own uv tool directories.
+func (s *UVSource) installTool(ctx context.Context, st store.Store, dest string, version string) error {
+	pkg := fmt.Sprintf("%s==%s", s.packageName(), version)
+	args := []string{"tool", "install", pkg}
+	if s.Python != nil {
+		args = append(args, "--python", s.Python.Bin)
+	}
+	cmd := exec.CommandContext(ctx, "uv", args...)
+	cmd.Env = append(cmd.Environ(),
+		"UV_TOOL_DIR="+filepath.Join(dest, "tools"),
+		"UV_TOOL_BIN_DIR="+filepath.Join(dest, "bin"),
+	)
+	if err := runTool(cmd, st, "uv-tool-"+pkg, s.Output); err != nil {
+		return fmt.Errorf("installing tool: %w", err)
+	}
+	return nil
+}
+
 func (s *UVSource) writeMCPConfig(store store.Store, segs []string) error {
 	data, err := toml.Marshal(s.MCPConfig)
 	if err != nil {
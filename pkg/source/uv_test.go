@@ -12,7 +12,9 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/agentpkg/agentpkg/pkg/apkgtest"
 	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/runtimes"
 	"github.com/agentpkg/agentpkg/pkg/store"
 )
 
@@ -54,6 +56,7 @@ func TestUVGetStoreSegments(t *testing.T) {
 	tests := map[string]struct {
 		pkg     string
 		version string
+		python  *runtimes.Runtime
 		want    []string
 	}{
 		"plain package": {
@@ -66,11 +69,17 @@ func TestUVGetStoreSegments(t *testing.T) {
 			version: "1.2.3",
 			want:    []string{"uv", "mcp-server-git", "1.2.3"},
 		},
+		"managed python": {
+			pkg:     "mcp-server-git",
+			version: "1.2.3",
+			python:  &runtimes.Runtime{Name: runtimes.Python, Version: "3.12.7"},
+			want:    []string{"uv", "mcp-server-git", "1.2.3-python3.12.7"},
+		},
 	}
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			s := &UVSource{Package: tc.pkg}
+			s := &UVSource{Package: tc.pkg, Python: tc.python}
 			got := s.getStoreSegments(tc.version)
 			if len(got) != len(tc.want) {
 				t.Fatalf("getStoreSegments() = %v, want %v", got, tc.want)
@@ -165,6 +174,61 @@ func TestUVResolveConcreteVersionPyPI(t *testing.T) {
 	}
 }
 
+func TestUVReleaseDigestsFromRegistry(t *testing.T) {
+	registry := apkgtest.NewPyPIRegistry(t)
+	oldWheel := registry.Publish("mcp-server-git", "2.0.0", "mcp_server_git-2.0.0-py3-none-any.whl", []byte("old wheel"))
+	wheel := registry.Publish("mcp-server-git", "3.0.0", "mcp_server_git-3.0.0-py3-none-any.whl", []byte("wheel"))
+	sdist := registry.Publish("mcp-server-git", "3.0.0", "mcp_server_git-3.0.0.tar.gz", []byte("sdist"))
+	orig := pypiBaseURL
+	pypiBaseURL = registry.URL
+	t.Cleanup(func() { pypiBaseURL = orig })
+
+	tests := map[string]struct {
+		pkg         string
+		wantVersion string
+		wantDigests string
+		wantErr     bool
+	}{
+		"latest release": {
+			pkg:         "mcp-server-git",
+			wantVersion: "3.0.0",
+			wantDigests: "mcp_server_git-3.0.0-py3-none-any.whl " + wheel + "\nmcp_server_git-3.0.0.tar.gz " + sdist,
+		},
+		"pinned release": {
+			pkg:         "mcp-server-git==2.0.0",
+			wantVersion: "2.0.0",
+			wantDigests: "mcp_server_git-2.0.0-py3-none-any.whl " + oldWheel,
+		},
+		"unknown package": {
+			pkg:     "mcp-server-missing",
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			src := &UVSource{Package: tc.pkg}
+			version, err := src.resolveConcreteVersion(context.Background())
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("resolveConcreteVersion() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if version != tc.wantVersion {
+				t.Errorf("resolveConcreteVersion() = %q, want %q", version, tc.wantVersion)
+			}
+			got, err := src.releaseDigests(context.Background(), version)
+			if err != nil {
+				t.Fatalf("releaseDigests() error = %v", err)
+			}
+			if got != tc.wantDigests {
+				t.Errorf("releaseDigests() = %q, want %q", got, tc.wantDigests)
+			}
+		})
+	}
+}
+
 // uvSourceWithCustomURL wraps UVSource to allow testing with a custom PyPI URL.
 type uvSourceWithCustomURL struct {
 	UVSource
@@ -381,3 +445,58 @@ func TestUVFetchContextCanceled(t *testing.T) {
 		t.Fatal("expected error with canceled context, got nil")
 	}
 }
+
+func TestUVReleaseDigests(t *testing.T) {
+	tests := map[string]struct {
+		files      []string // "<filename> <sha256>"
+		statusCode int
+		want       string
+		wantErr    bool
+	}{
+		"all files sorted": {
+			files:      []string{"pkg-1.0-py3-none-manylinux.whl bbb", "pkg-1.0.tar.gz ccc", "pkg-1.0-py3-none-macosx.whl aaa"},
+			statusCode: http.StatusOK,
+			want:       "pkg-1.0-py3-none-macosx.whl aaa\npkg-1.0-py3-none-manylinux.whl bbb\npkg-1.0.tar.gz ccc",
+		},
+		"no files": {
+			statusCode: http.StatusOK,
+			wantErr:    true,
+		},
+		"unknown release": {
+			statusCode: http.StatusNotFound,
+			wantErr:    true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/pypi/pkg/1.0/json" || tc.statusCode != http.StatusOK {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				var resp struct {
+					URLs []map[string]any `json:"urls"`
+				}
+				for _, f := range tc.files {
+					filename, sha, _ := strings.Cut(f, " ")
+					resp.URLs = append(resp.URLs, map[string]any{"filename": filename, "digests": map[string]string{"sha256": sha}})
+				}
+				json.NewEncoder(w).Encode(resp)
+			}))
+			defer server.Close()
+
+			orig := pypiBaseURL
+			pypiBaseURL = server.URL
+			t.Cleanup(func() { pypiBaseURL = orig })
+
+			got, err := (&UVSource{Package: "pkg"}).releaseDigests(context.Background(), "1.0")
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("releaseDigests() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("releaseDigests() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package source
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+)
+
+// npmRangeVersion returns the newest release of the npm package name
+// matching the version range rng (see matchNPMRange), from the versions
+// the registry lists.
+func npmRangeVersion(ctx context.Context, name, rng string) (string, error) {
+	var result struct {
+		Versions map[string]any `json:"versions"`
+	}
+	if err := getJSON(ctx, fmt.Sprintf("%s/%s", npmRegistryBaseURL, url.PathEscape(name)), &result); err != nil {
+		return "", err
+	}
+	versions := make([]string, 0, len(result.Versions))
+	for v := range result.Versions {
+		versions = append(versions, v)
+	}
+	version, err := matchNPMRange(versions, rng)
+	if err != nil {
+		return "", fmt.Errorf("resolving %s@%s: %w", name, rng, err)
+	}
+	return version, nil
+}
+
+// pypiPrefixVersion returns the newest release of the PyPI package name
+// matching the wildcard version pattern, e.g. "1.2.*".
+func pypiPrefixVersion(ctx context.Context, name, pattern string) (string, error) {
+	prefix, ok := strings.CutSuffix(pattern, ".*")
+	if !ok || strings.Contains(prefix, "*") {
+		return "", fmt.Errorf("unsupported version %q of %s; pin an exact version or a trailing .* wildcard", pattern, name)
+	}
+	var result struct {
+		Releases map[string]any `json:"releases"`
+	}
+	if err := getJSON(ctx, fmt.Sprintf("%s/pypi/%s/json", pypiBaseURL, name), &result); err != nil {
+		return "", err
+	}
+	best := ""
+	for v := range result.Releases {
+		if _, ok := parseRelease(v); !ok || !strings.HasPrefix(v, prefix+".") {
+			continue
+		}
+		if cmp, _ := config.CompareVersions(v, best); best == "" || cmp > 0 {
+			best = v
+		}
+	}
+	if best == "" {
+		return "", fmt.Errorf("no release of %s matches %s on pypi", name, pattern)
+	}
+	return best, nil
+}
+
+// matchNPMRange returns the newest of versions in the npm version range
+// rng: comparator sets joined by "||", each a space-separated list of
+// comparators like ">=1.2.0", "^1.2.3", "~1.2", "1.x", or "*". Pre-releases
+// and hyphen ranges aren't supported.
+func matchNPMRange(versions []string, rng string) (string, error) {
+	var sets [][]func(release) bool
+	for _, set := range strings.Split(rng, "||") {
+		var comparators []func(release) bool
+		for _, c := range strings.Fields(set) {
+			cmp, err := parseComparator(c)
+			if err != nil {
+				return "", err
+			}
+			comparators = append(comparators, cmp)
+		}
+		sets = append(sets, comparators)
+	}
+
+	var best release
+	found := ""
+	for _, v := range versions {
+		r, ok := parseRelease(v)
+		if !ok || !matchesAny(r, sets) {
+			continue
+		}
+		if found == "" || r.compare(best) > 0 {
+			best, found = r, v
+		}
+	}
+	if found == "" {
+		return "", fmt.Errorf("no version matches %q", rng)
+	}
+	return found, nil
+}
+
+func matchesAny(r release, sets [][]func(release) bool) bool {
+	for _, set := range sets {
+		matched := true
+		for _, cmp := range set {
+			if !cmp(r) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// release is a major.minor.patch version.
+type release [3]int
+
+// parseRelease parses an exact release version, rejecting pre-releases.
+func parseRelease(v string) (release, bool) {
+	v, _, _ = strings.Cut(strings.TrimPrefix(v, "v"), "+")
+	parts := strings.Split(v, ".")
+	if len(parts) != 3 {
+		return release{}, false
+	}
+	var r release
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return release{}, false
+		}
+		r[i] = n
+	}
+	return r, true
+}
+
+func (r release) compare(o release) int {
+	for i := range r {
+		switch {
+		case r[i] < o[i]:
+			return -1
+		case r[i] > o[i]:
+			return 1
+		}
+	}
+	return 0
+}
+
+// parseComparator parses one comparator of an npm range.
+func parseComparator(c string) (func(release) bool, error) {
+	op := ""
+	for _, prefix := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		if rest, ok := strings.CutPrefix(c, prefix); ok {
+			op, c = prefix, rest
+			break
+		}
+	}
+
+	// A partial version like "1.2" or "1.x" fixes its first n parts.
+	var v release
+	n := 0
+	if c != "" && c != "*" && c != "x" && c != "X" {
+		parts := strings.Split(strings.TrimPrefix(c, "v"), ".")
+		if len(parts) > 3 {
+			return nil, fmt.Errorf("unsupported version range comparator %q", op+c)
+		}
+		for _, p := range parts {
+			if p == "x" || p == "X" || p == "*" {
+				break
+			}
+			num, err := strconv.Atoi(p)
+			if err != nil || num < 0 {
+				return nil, fmt.Errorf("unsupported version range comparator %q", op+c)
+			}
+			v[n] = num
+			n++
+		}
+	}
+
+	// next is the first release past every one the partial version
+	// covers.
+	next := v
+	if n > 0 {
+		next[n-1]++
+		for i := n; i < 3; i++ {
+			next[i] = 0
+		}
+	}
+	between := func(lo, hi release) func(release) bool {
+		return func(r release) bool { return r.compare(lo) >= 0 && r.compare(hi) < 0 }
+	}
+	every := func(release) bool { return true }
+
+	switch op {
+	case "", "=":
+		if n == 0 {
+			return every, nil
+		}
+		return between(v, next), nil
+	case "^":
+		if n == 0 {
+			return every, nil
+		}
+		// Changes left of the first non-zero part of the version are
+		// breaking.
+		upper := release{v[0] + 1}
+		switch {
+		case v[0] == 0 && v[1] == 0 && n == 3:
+			upper = release{0, 0, v[2] + 1}
+		case v[0] == 0 && n >= 2:
+			upper = release{0, v[1] + 1}
+		}
+		return between(v, upper), nil
+	case "~":
+		if n == 0 {
+			return every, nil
+		}
+		upper := release{v[0] + 1}
+		if n >= 2 {
+			upper = release{v[0], v[1] + 1}
+		}
+		return between(v, upper), nil
+	case ">=":
+		return func(r release) bool { return r.compare(v) >= 0 }, nil
+	case ">":
+		if n == 0 {
+			return func(release) bool { return false }, nil
+		}
+		return func(r release) bool { return r.compare(next) >= 0 }, nil
+	case "<":
+		if n == 0 {
+			return func(release) bool { return false }, nil
+		}
+		return func(r release) bool { return r.compare(v) < 0 }, nil
+	default: // "<="
+		if n == 0 {
+			return every, nil
+		}
+		return func(r release) bool { return r.compare(next) < 0 }, nil
+	}
+}
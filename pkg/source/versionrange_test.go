@@ -0,0 +1,39 @@
+package source
+
+import "testing"
+
+func TestMatchNPMRange(t *testing.T) {
+	versions := []string{"0.0.3", "0.2.1", "0.2.5", "1.0.0", "1.2.0", "1.2.7", "1.3.0", "2.0.0-beta.1", "2.0.0", "2.1.0"}
+
+	tests := map[string]struct {
+		rng     string
+		want    string
+		wantErr bool
+	}{
+		"caret":              {rng: "^1.2.0", want: "1.3.0"},
+		"caret below 1":      {rng: "^0.2.1", want: "0.2.5"},
+		"caret below 0.1":    {rng: "^0.0.3", want: "0.0.3"},
+		"tilde":              {rng: "~1.2.0", want: "1.2.7"},
+		"x-range":            {rng: "1.x", want: "1.3.0"},
+		"partial":            {rng: "1.2", want: "1.2.7"},
+		"any":                {rng: "*", want: "2.1.0"},
+		"bounded":            {rng: ">=1.0.0 <2.0.0", want: "1.3.0"},
+		"greater than minor": {rng: ">1.2", want: "2.1.0"},
+		"at most":            {rng: "<=1.2", want: "1.2.7"},
+		"alternatives":       {rng: "^0.2.0 || ~1.2.0", want: "1.2.7"},
+		"no match":           {rng: "^3.0.0", wantErr: true},
+		"invalid":            {rng: "^a.b", wantErr: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := matchNPMRange(versions, tc.rng)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("matchNPMRange(%q) error = %v, wantErr %v", tc.rng, err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("matchNPMRange(%q) = %q, want %q", tc.rng, got, tc.want)
+			}
+		})
+	}
+}
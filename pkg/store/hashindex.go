@@ -0,0 +1,227 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agentpkg/agentpkg/pkg/fsutil"
+)
+
+// hashIndexFile, in the store root, caches the hashes HashDir computed so
+// unchanged directories (e.g. large node_modules trees) aren't read again
+// on every install.
+const hashIndexFile = "hash-index.json"
+
+// hashIndexEntry is the cached hash of a store directory, with the
+// fingerprint of the tree it was computed over.
+type hashIndexEntry struct {
+	// ModTime is the latest modification time, in Unix nanoseconds, of
+	// the files and directories hashed.
+	ModTime int64  `json:"mtime"`
+	Files   int    `json:"files"`
+	Hash    string `json:"hash"`
+	// Used is when the directory was last hashed, in Unix nanoseconds.
+	Used int64 `json:"used,omitempty"`
+}
+
+// treeFingerprint identifies a version of a directory tree without
+// reading its files: adding, removing, renaming, or writing a file
+// changes the file count or a modification time.
+type treeFingerprint struct {
+	modTime int64
+	files   int
+}
+
+// hashDir returns the hash of the directory at segments, from the index
+// if the directory is unchanged since it was recorded and rehash isn't
+// set. The hash is recorded with the time it was used.
+func (s *store) hashDir(rehash bool, segments []string) (string, error) {
+	dir := s.Path(segments...)
+	files, fp, err := walkTree(dir, fsutil.SkipJunk)
+	if err != nil {
+		return "", err
+	}
+
+	key := filepath.ToSlash(filepath.Join(segments...))
+	s.mu.Lock()
+	s.loadIndex()
+	cached, ok := s.index[key]
+	s.mu.Unlock()
+
+	hash := cached.Hash
+	if !ok || rehash || cached.ModTime != fp.modTime || cached.Files != fp.files {
+		hash, err = hashFiles(dir, files)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.updateIndex(func(index map[string]hashIndexEntry) bool {
+		index[key] = hashIndexEntry{ModTime: fp.modTime, Files: fp.files, Hash: hash, Used: time.Now().UnixNano()}
+		return true
+	})
+	return hash, nil
+}
+
+func (s *store) LastUsed(segments ...string) time.Time {
+	key := filepath.ToSlash(filepath.Join(segments...))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// Read the index rather than the copy loaded before, which other apkg
+	// processes may have updated since.
+	s.index = s.readIndex()
+	var used int64
+	for k, entry := range s.index {
+		if withinKey(k, key) {
+			used = max(used, entry.Used)
+		}
+	}
+	if used == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, used)
+}
+
+// forgetHashes drops the index entries of the directory at segments and
+// the directories under it.
+func (s *store) forgetHashes(segments []string) {
+	key := filepath.ToSlash(filepath.Join(segments...))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.updateIndex(func(index map[string]hashIndexEntry) bool {
+		changed := false
+		for k := range index {
+			if withinKey(k, key) {
+				delete(index, k)
+				changed = true
+			}
+		}
+		return changed
+	})
+}
+
+// withinKey reports whether the index key k is dir or a directory under it.
+func withinKey(k, dir string) bool {
+	return k == dir || dir == "." || strings.HasPrefix(k, dir+"/")
+}
+
+// loadIndex reads the index on first use. A missing or unreadable index
+// is empty. s.mu must be held.
+func (s *store) loadIndex() {
+	if s.index == nil {
+		s.index = s.readIndex()
+	}
+}
+
+// updateIndex applies update to the index on disk, merged with the
+// entries other apkg processes have recorded since it was loaded, and
+// writes it back if update reports a change. The index is only a cache,
+// so failing to write it is ignored. s.mu must be held.
+func (s *store) updateIndex(update func(index map[string]hashIndexEntry) bool) {
+	index := s.readIndex()
+	if !update(index) {
+		s.index = index
+		return
+	}
+	s.index = index
+
+	data, err := json.Marshal(index)
+	if err != nil {
+		return
+	}
+	// Write to a temporary file and rename it into place, so concurrent
+	// processes never read a partial index.
+	tmp, err := os.CreateTemp(s.root, hashIndexFile+".*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.Path(hashIndexFile))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// readIndex reads the index from disk.
+func (s *store) readIndex() map[string]hashIndexEntry {
+	index := make(map[string]hashIndexEntry)
+	if data, err := os.ReadFile(s.Path(hashIndexFile)); err == nil {
+		json.Unmarshal(data, &index)
+	}
+	return index
+}
+
+// walkTree returns the files under dir relative to it, sorted, with the
+// tree's fingerprint, leaving out the entries skip reports (see HashTree).
+func walkTree(dir string, skip fsutil.SkipFunc) ([]string, treeFingerprint, error) {
+	var files []string
+	var fp treeFingerprint
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			if skip(rel, d) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		fp.modTime = max(fp.modTime, info.ModTime().UnixNano())
+		if !d.IsDir() {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, treeFingerprint{}, err
+	}
+
+	sort.Strings(files)
+	fp.files = len(files)
+	return files, fp, nil
+}
+
+// hashFiles returns the "sha256:<hex>" hash over the relative path and
+// contents of each of files under dir, in order.
+func hashFiles(dir string, files []string) (string, error) {
+	h := sha256.New()
+	for _, f := range files {
+		data, err := os.ReadFile(filepath.Join(dir, f))
+		if err != nil {
+			return "", err
+		}
+		h.Write([]byte(f))
+		h.Write(data)
+	}
+	return hashPrefix + hex.EncodeToString(h.Sum(nil)), nil
+}
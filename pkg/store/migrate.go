@@ -0,0 +1,265 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// SchemaVersion is the version of the store layout this apkg reads and
+// writes. Stores from older versions are upgraded by Migrate.
+const SchemaVersion = 3
+
+// schemaFile, in the store root, holds the store's layout version. Stores
+// without one predate versioning and are at version 1.
+const schemaFile = "schema-version"
+
+// Migration upgrades a store from layout version From to From+1.
+type Migration struct {
+	From        int
+	Description string
+	// Apply upgrades the store rooted at root and returns notes for the
+	// user about what changed. It finds old structures by looking for
+	// them, so it is safe to run again after an interrupted upgrade.
+	Apply func(root string) (notes []string, err error)
+}
+
+// Migrations are the store upgrades, in order.
+var Migrations = []Migration{
+	{
+		From:        1,
+		Description: "move OCI servers installed before image digests were recorded under their digest",
+		Apply:       migratePreDigestOCI,
+	},
+	{
+		From:        2,
+		Description: "drop flat skill directories, replaced by commit-addressed repos",
+		Apply:       migrateFlatSkills,
+	},
+}
+
+// Migrate upgrades the store to SchemaVersion, recording the version after
+// each step so an interrupted upgrade resumes where it stopped, and returns
+// notes for the user about what changed. A store that doesn't exist yet is
+// left alone. A store written by a newer apkg is an error rather than being
+// mixed with this version's layout.
+func Migrate(st Store) ([]string, error) {
+	root := st.Path()
+	if _, err := os.Stat(root); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	version, err := readSchemaVersion(root)
+	if err != nil {
+		return nil, err
+	}
+	if version > SchemaVersion {
+		return nil, fmt.Errorf("store %s has layout version %d, newer than this apkg supports (%d); upgrade apkg", root, version, SchemaVersion)
+	}
+
+	var notes []string
+	for _, m := range Migrations {
+		if m.From < version {
+			continue
+		}
+		stepNotes, err := m.Apply(root)
+		if err != nil {
+			return notes, fmt.Errorf("upgrading store to version %d (%s): %w", m.From+1, m.Description, err)
+		}
+		notes = append(notes, stepNotes...)
+		if err := writeSchemaVersion(root, m.From+1); err != nil {
+			return notes, err
+		}
+		version = m.From + 1
+	}
+
+	if version < SchemaVersion {
+		if err := writeSchemaVersion(root, SchemaVersion); err != nil {
+			return notes, err
+		}
+	}
+	return notes, nil
+}
+
+func readSchemaVersion(root string) (int, error) {
+	data, err := os.ReadFile(filepath.Join(root, schemaFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return 1, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("reading store layout version: %w", err)
+	}
+	version, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("invalid store layout version %q in %s", strings.TrimSpace(string(data)), filepath.Join(root, schemaFile))
+	}
+	return version, nil
+}
+
+func writeSchemaVersion(root string, version int) error {
+	path := filepath.Join(root, schemaFile)
+	if err := os.WriteFile(path, []byte(strconv.Itoa(version)+"\n"), 0o644); err != nil {
+		return fmt.Errorf("writing store layout version: %w", err)
+	}
+	return nil
+}
+
+// migratePreDigestOCI moves oci/<name>/mcp.toml, written before installs
+// were keyed by image digest, to oci/<name>/<digest>/mcp.toml. Configs
+// without a recorded digest can't be placed and are removed; the server is
+// reinstalled by the next apkg install.
+func migratePreDigestOCI(root string) ([]string, error) {
+	ociDir := filepath.Join(root, "oci")
+	entries, err := os.ReadDir(ociDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var notes []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		legacy := filepath.Join(ociDir, name, "mcp.toml")
+		data, err := os.ReadFile(legacy)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return notes, err
+		}
+
+		var cfg struct {
+			Digest string `toml:"digest"`
+		}
+		if err := toml.Unmarshal(data, &cfg); err != nil || cfg.Digest == "" || strings.ContainsAny(cfg.Digest, `/\`) {
+			if err := os.Remove(legacy); err != nil {
+				return notes, err
+			}
+			notes = append(notes, fmt.Sprintf("removed OCI server %q, installed without an image digest; run apkg install to reinstall it", name))
+			continue
+		}
+
+		dir := filepath.Join(ociDir, name, cfg.Digest)
+		if err := os.MkdirAll(dir, dirPerm); err != nil {
+			return notes, err
+		}
+		if err := os.Rename(legacy, filepath.Join(dir, "mcp.toml")); err != nil {
+			return notes, err
+		}
+		notes = append(notes, fmt.Sprintf("moved OCI server %q under its image digest", name))
+	}
+	return notes, nil
+}
+
+// flatSkillsMarker, in the store root, records that migrateFlatSkills ran.
+// A store created after Migrate last ran has no layout version until the
+// next run, which then starts from version 1, so migrations must not trust
+// the version alone to tell an old layout.
+const flatSkillsMarker = ".flat-skills-migrated"
+
+// flatSkillsSources are the store directories skills are fetched into now.
+var flatSkillsSources = []string{"repos", "local", "snapshots", "archives"}
+
+// migrateFlatSkills removes the skills in skills/, where they were once
+// copied by name, that commit-addressed entries like repos/ now hold: the
+// flat copies are never read. Copies nothing else holds are kept, for the
+// user to reinstall and delete, and skills/ is removed once it is empty.
+// It runs once per store, which the marker file records.
+func migrateFlatSkills(root string) ([]string, error) {
+	marker := filepath.Join(root, flatSkillsMarker)
+	if _, err := os.Stat(marker); err == nil {
+		return nil, nil
+	}
+
+	dir := filepath.Join(root, "skills")
+	info, err := os.Stat(dir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	var notes []string
+	if err == nil && info.IsDir() {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		moved, err := skillHashes(root)
+		if err != nil {
+			return nil, err
+		}
+		removed := 0
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			if entry.IsDir() {
+				hash, err := HashTree(path)
+				if err != nil {
+					return notes, err
+				}
+				if moved[hash] {
+					if err := os.RemoveAll(path); err != nil {
+						return notes, err
+					}
+					removed++
+					continue
+				}
+			}
+			notes = append(notes, fmt.Sprintf("kept %s, which no other store entry holds; run apkg install in the projects using it, then delete it", path))
+		}
+		if removed > 0 {
+			notes = append(notes, fmt.Sprintf("removed %d flat skill copies in skills/, now kept in commit-addressed entries", removed))
+		}
+		if removed == len(entries) {
+			if err := os.Remove(dir); err != nil {
+				return notes, err
+			}
+		}
+	}
+
+	if err := os.WriteFile(marker, nil, 0o644); err != nil {
+		return notes, fmt.Errorf("writing %s: %w", marker, err)
+	}
+	return notes, nil
+}
+
+// skillHashes returns the hashes (see HashTree) of the skill directories,
+// those with a SKILL.md, in flatSkillsSources.
+func skillHashes(root string) (map[string]bool, error) {
+	hashes := make(map[string]bool)
+	for _, name := range flatSkillsSources {
+		err := filepath.WalkDir(filepath.Join(root, name), func(path string, d fs.DirEntry, err error) error {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if d.IsDir() && d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			if d.IsDir() || d.Name() != "SKILL.md" {
+				return nil
+			}
+			hash, err := HashTree(filepath.Dir(path))
+			if err != nil {
+				return err
+			}
+			hashes[hash] = true
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return hashes, nil
+}
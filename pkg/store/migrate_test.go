@@ -0,0 +1,159 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	tests := map[string]struct {
+		files       map[string]string
+		wantFiles   []string
+		wantMissing []string
+		wantNotes   int
+		wantErr     bool
+	}{
+		"empty store": {
+			files: map[string]string{},
+		},
+		"current layout": {
+			files: map[string]string{
+				schemaFile:                 "3\n",
+				"oci/pg/abc/mcp.toml":      "digest = 'abc'",
+				"repos/github.com/o/r/c/x": "",
+			},
+			wantFiles: []string{"oci/pg/abc/mcp.toml", "repos/github.com/o/r/c/x"},
+		},
+		"pre-digest oci entry": {
+			files: map[string]string{
+				"oci/pg/mcp.toml": "image = 'pg:16'\ndigest = 'abc'",
+			},
+			wantFiles:   []string{"oci/pg/abc/mcp.toml"},
+			wantMissing: []string{"oci/pg/mcp.toml"},
+			wantNotes:   1,
+		},
+		"pre-digest oci entry without digest": {
+			files: map[string]string{
+				"oci/pg/mcp.toml": "image = 'pg:16'",
+			},
+			wantMissing: []string{"oci/pg/mcp.toml"},
+			wantNotes:   1,
+		},
+		"flat skills": {
+			files: map[string]string{
+				"skills/review/SKILL.md":                 "---\nname: review\n---",
+				"repos/github.com/o/r/c/review/SKILL.md": "---\nname: review\n---",
+			},
+			wantFiles:   []string{"repos/github.com/o/r/c/review/SKILL.md"},
+			wantMissing: []string{"skills"},
+			wantNotes:   1,
+		},
+		"flat skills held nowhere else": {
+			files: map[string]string{
+				"skills/review/SKILL.md":                 "---\nname: review\n---",
+				"skills/edited/SKILL.md":                 "---\nname: edited\n---\nlocal changes",
+				"repos/github.com/o/r/c/edited/SKILL.md": "---\nname: edited\n---",
+				"local/abc/SKILL.md":                     "---\nname: review\n---",
+			},
+			wantFiles:   []string{"skills/edited/SKILL.md"},
+			wantMissing: []string{"skills/review"},
+			wantNotes:   2,
+		},
+		"flat skills migrated before": {
+			files: map[string]string{
+				flatSkillsMarker:                         "",
+				"skills/review/SKILL.md":                 "---\nname: review\n---",
+				"repos/github.com/o/r/c/review/SKILL.md": "---\nname: review\n---",
+			},
+			wantFiles: []string{"skills/review/SKILL.md"},
+		},
+		"newer layout": {
+			files: map[string]string{
+				schemaFile: "99\n",
+			},
+			wantErr: true,
+		},
+		"invalid version": {
+			files: map[string]string{
+				schemaFile: "two\n",
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			root := t.TempDir()
+			for path, content := range tc.files {
+				full := filepath.Join(root, filepath.FromSlash(path))
+				if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			notes, err := Migrate(New(root))
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Migrate() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if len(notes) != tc.wantNotes {
+				t.Errorf("Migrate() notes = %q, want %d", notes, tc.wantNotes)
+			}
+
+			for _, path := range tc.wantFiles {
+				if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(path))); err != nil {
+					t.Errorf("%s missing after migration: %v", path, err)
+				}
+			}
+			for _, path := range tc.wantMissing {
+				if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(path))); err == nil {
+					t.Errorf("%s still exists after migration", path)
+				}
+			}
+
+			version, err := readSchemaVersion(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if version != SchemaVersion {
+				t.Errorf("layout version = %d, want %d", version, SchemaVersion)
+			}
+
+			// Migrating again finds nothing to do.
+			if notes, err := Migrate(New(root)); err != nil || len(notes) != 0 {
+				t.Errorf("second Migrate() = %q, %v; want no notes", notes, err)
+			}
+		})
+	}
+}
+
+func TestMigrateMissingStore(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "store")
+	if _, err := Migrate(New(root)); err != nil {
+		t.Fatalf("Migrate() error: %v", err)
+	}
+	if _, err := os.Stat(root); err == nil {
+		t.Error("Migrate() created a store that didn't exist")
+	}
+}
+
+func TestMigrationsInOrder(t *testing.T) {
+	var from []int
+	for _, m := range Migrations {
+		from = append(from, m.From)
+	}
+	want := make([]int, SchemaVersion-1)
+	for i := range want {
+		want[i] = i + 1
+	}
+	if !slices.Equal(from, want) {
+		t.Errorf("migrations go from versions %v, want %v", from, want)
+	}
+}
@@ -1,13 +1,13 @@
 package store
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
 	"fmt"
-	"io/fs"
 	"os"
 	"path/filepath"
-	"sort"
+	"sync"
+	"time"
+
+	"github.com/agentpkg/agentpkg/pkg/fsutil"
 )
 
 const (
@@ -30,20 +30,45 @@ type Store interface {
 	Remove(segments ...string)
 	// HashDir computes a "sha256:<hex>" integrity hash over all file
 	// contents in the directory at segments, walking recursively in sorted
-	// order for determinism.
+	// order for determinism. The hash may come from a cache kept while the
+	// directory's files and modification times stay the same.
 	HashDir(segments ...string) (string, error)
+	// RehashDir computes the hash HashDir returns from the file contents,
+	// bypassing any cache, e.g. to verify the store hasn't been tampered
+	// with.
+	RehashDir(segments ...string) (string, error)
+	// LastUsed returns the last time HashDir or RehashDir hashed the
+	// directory at segments or one under it, which installs do for every
+	// package they use, or the zero time if none was hashed.
+	LastUsed(segments ...string) time.Time
 	// WriteFile writes data to the file at segments.
 	// Parent directories must already exist.
 	WriteFile(data []byte, perm os.FileMode, segments ...string) error
 	// ReadFile reads the file at segments.
 	ReadFile(segments ...string) ([]byte, error)
+	// Lock takes the store lock, which keeps apkg processes from deleting
+	// entries another is fetching, waiting up to timeout for the process
+	// holding it. The returned func releases it.
+	Lock(timeout time.Duration) (func(), error)
 }
 
 func New(root string) Store {
 	return &store{root: root}
 }
 
+// EnvRoot is the environment variable that relocates the default store.
+const EnvRoot = "APKG_STORE_DIR"
+
+// Default returns the store at $APKG_STORE_DIR, or ~/.apkg.
 func Default() (Store, error) {
+	if root := os.Getenv(EnvRoot); root != "" {
+		abs, err := filepath.Abs(root)
+		if err != nil {
+			return nil, fmt.Errorf("resolving %s: %w", EnvRoot, err)
+		}
+		return &store{root: abs}, nil
+	}
+
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return nil, fmt.Errorf("determining home directory: %w", err)
@@ -53,6 +78,11 @@ func Default() (Store, error) {
 
 type store struct {
 	root string
+
+	// mu guards index, the hash cache loaded from hashIndexFile (nil
+	// until first used).
+	mu    sync.Mutex
+	index map[string]hashIndexEntry
 }
 
 var _ Store = &store{}
@@ -78,42 +108,37 @@ func (s *store) EnsureDir(segments ...string) {
 
 func (s *store) Remove(segments ...string) {
 	os.RemoveAll(s.Path(segments...))
+	s.forgetHashes(segments)
 }
 
 func (s *store) HashDir(segments ...string) (string, error) {
-	dir := s.Path(segments...)
-	h := sha256.New()
+	return s.hashDir(false, segments)
+}
 
-	var files []string
-	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-		if !d.IsDir() {
-			rel, err := filepath.Rel(dir, path)
-			if err != nil {
-				return err
-			}
-			files = append(files, rel)
-		}
-		return nil
-	})
-	if err != nil {
-		return "", err
-	}
+func (s *store) RehashDir(segments ...string) (string, error) {
+	return s.hashDir(true, segments)
+}
 
-	sort.Strings(files)
+// HashTree computes a "sha256:<hex>" integrity hash over all file contents
+// in dir, walking recursively in sorted order for determinism. It is the
+// hash used by Store.HashDir, exposed for content that lives outside the
+// store (e.g. vendored packages). VCS metadata and junk files are left out
+// (see fsutil.IsJunk): a clone's .git differs between clones of the same
+// commit, and neither is part of the package.
+func HashTree(dir string) (string, error) {
+	return HashTreeFunc(dir, fsutil.SkipJunk)
+}
 
-	for _, f := range files {
-		data, err := os.ReadFile(filepath.Join(dir, f))
-		if err != nil {
-			return "", err
-		}
-		h.Write([]byte(f))
-		h.Write(data)
+// HashTreeFunc is HashTree leaving out the entries skip reports instead of
+// junk, e.g. those of a local package's fsutil.IgnoreFile (see
+// fsutil.Ignore.Skip). Skipping what a copy left out, the copy hashes the
+// same as its source.
+func HashTreeFunc(dir string, skip fsutil.SkipFunc) (string, error) {
+	files, _, err := walkTree(dir, skip)
+	if err != nil {
+		return "", err
 	}
-
-	return hashPrefix + hex.EncodeToString(h.Sum(nil)), nil
+	return hashFiles(dir, files)
 }
 
 func (s *store) WriteFile(data []byte, perm os.FileMode, segments ...string) error {
@@ -123,3 +148,14 @@ func (s *store) WriteFile(data []byte, perm os.FileMode, segments ...string) err
 func (s *store) ReadFile(segments ...string) ([]byte, error) {
 	return os.ReadFile(s.Path(segments...))
 }
+
+// lockFile is the file under the store root that exists while a process
+// holds the store lock (see fsutil.Lock).
+const lockFile = "store.lock"
+
+func (s *store) Lock(timeout time.Duration) (func(), error) {
+	if err := os.MkdirAll(s.root, dirPerm); err != nil {
+		return nil, fmt.Errorf("creating store: %w", err)
+	}
+	return fsutil.Lock(s.Path(lockFile), timeout)
+}
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestPath(t *testing.T) {
@@ -41,6 +42,33 @@ func TestPath(t *testing.T) {
 	}
 }
 
+func TestDefault(t *testing.T) {
+	tests := map[string]struct {
+		envRoot string
+		want    string
+	}{
+		"home directory": {want: filepath.Join("{home}", DefaultRoot)},
+		"env override":   {envRoot: "{tmp}", want: "{tmp}"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			home, tmp := t.TempDir(), t.TempDir()
+			replacer := strings.NewReplacer("{home}", home, "{tmp}", tmp)
+			t.Setenv("HOME", home)
+			t.Setenv(EnvRoot, replacer.Replace(tc.envRoot))
+
+			s, err := Default()
+			if err != nil {
+				t.Fatalf("Default() error = %v", err)
+			}
+			if want := replacer.Replace(tc.want); s.Path() != want {
+				t.Errorf("Path() = %q, want %q", s.Path(), want)
+			}
+		})
+	}
+}
+
 func TestExists(t *testing.T) {
 	root := t.TempDir()
 	s := New(root)
@@ -255,6 +283,17 @@ func TestHashDir(t *testing.T) {
 				{"c.txt", "charlie"},
 			},
 		},
+		"git metadata and junk skipped": {
+			files: map[string]string{
+				"SKILL.md":                            "skill",
+				filepath.Join(".git", "HEAD"):         "ref: refs/heads/main",
+				filepath.Join("sub", ".git", "index"): "index",
+				filepath.Join("sub", ".DS_Store"):     "junk",
+			},
+			pairs: [][2]string{
+				{"SKILL.md", "skill"},
+			},
+		},
 		"nested files": {
 			files: map[string]string{
 				filepath.Join("sub", "z.txt"): "zulu",
@@ -332,3 +371,111 @@ func TestHashDirNonExistent(t *testing.T) {
 		t.Fatal("expected error hashing nonexistent directory, got nil")
 	}
 }
+
+func TestHashDirCache(t *testing.T) {
+	root := t.TempDir()
+	s := New(root)
+
+	base := filepath.Join(root, "pkg")
+	file := filepath.Join(base, "a.txt")
+	os.MkdirAll(base, 0o755)
+	os.WriteFile(file, []byte("original"), 0o644)
+	mtime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	os.Chtimes(file, mtime, mtime)
+	os.Chtimes(base, mtime, mtime)
+
+	original, err := s.HashDir("pkg")
+	if err != nil {
+		t.Fatalf("HashDir() error: %v", err)
+	}
+
+	// Same size and modification time: only a rehash reads the change.
+	os.WriteFile(file, []byte("modified"), 0o644)
+	os.Chtimes(file, mtime, mtime)
+
+	// A fresh store reads the index another process wrote.
+	s = New(root)
+	if got, _ := s.HashDir("pkg"); got != original {
+		t.Errorf("HashDir() of unchanged fingerprint = %q, want cached %q", got, original)
+	}
+	modified, err := s.RehashDir("pkg")
+	if err != nil {
+		t.Fatalf("RehashDir() error: %v", err)
+	}
+	if modified == original {
+		t.Error("RehashDir() returned the cached hash of modified content")
+	}
+	if got, _ := s.HashDir("pkg"); got != modified {
+		t.Errorf("HashDir() after RehashDir() = %q, want %q", got, modified)
+	}
+
+	// A changed modification time invalidates the cached hash.
+	os.WriteFile(file, []byte("original"), 0o644)
+	if got, _ := s.HashDir("pkg"); got != original {
+		t.Errorf("HashDir() after write = %q, want %q", got, original)
+	}
+
+	// Removing a directory forgets its hash.
+	os.Chtimes(file, mtime, mtime)
+	s.HashDir("pkg")
+	s.Remove("pkg")
+	os.MkdirAll(base, 0o755)
+	os.WriteFile(file, []byte("modified"), 0o644)
+	os.Chtimes(file, mtime, mtime)
+	os.Chtimes(base, mtime, mtime)
+	if got, _ := s.HashDir("pkg"); got != modified {
+		t.Errorf("HashDir() after Remove() = %q, want %q", got, modified)
+	}
+}
+
+func TestLastUsed(t *testing.T) {
+	root := t.TempDir()
+	s := New(root)
+	for _, dir := range []string{"npm/a/1.0.0", "npm/b/1.0.0"} {
+		os.MkdirAll(filepath.Join(root, dir), 0o755)
+		os.WriteFile(filepath.Join(root, dir, "mcp.toml"), []byte(dir), 0o644)
+	}
+
+	if got := s.LastUsed("npm"); !got.IsZero() {
+		t.Errorf("LastUsed() before hashing = %v, want zero", got)
+	}
+
+	before := time.Now()
+	s.HashDir("npm", "a", "1.0.0")
+	// Cached hashes count as uses too.
+	s.HashDir("npm", "a", "1.0.0")
+	after := time.Now()
+
+	for _, segs := range [][]string{{"npm", "a", "1.0.0"}, {"npm", "a"}, {"npm"}} {
+		if got := s.LastUsed(segs...); got.Before(before) || got.After(after) {
+			t.Errorf("LastUsed(%v) = %v, want between %v and %v", segs, got, before, after)
+		}
+	}
+	if got := s.LastUsed("npm", "b"); !got.IsZero() {
+		t.Errorf("LastUsed() of unhashed directory = %v, want zero", got)
+	}
+
+	s.Remove("npm", "a")
+	if got := s.LastUsed("npm"); !got.IsZero() {
+		t.Errorf("LastUsed() after Remove() = %v, want zero", got)
+	}
+}
+
+func TestLock(t *testing.T) {
+	s := New(filepath.Join(t.TempDir(), "store"))
+
+	unlock, err := s.Lock(time.Second)
+	if err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+	if _, err := s.Lock(100 * time.Millisecond); err == nil {
+		t.Fatal("Lock() of a held lock succeeded, want timeout")
+	}
+
+	unlock()
+	unlock, err = s.Lock(time.Second)
+	if err != nil {
+		t.Fatalf("Lock() after release error = %v", err)
+	}
+	unlock()
+}
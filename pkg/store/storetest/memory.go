@@ -0,0 +1,189 @@
+// Package storetest provides store.Store implementations for tests: an
+// in-memory store and a wrapper recording the calls made to a store.
+package storetest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+// MemoryRoot is the root of the paths returned by Memory.Path. Nothing is
+// stored there; code that hands Path to external tools (e.g. git clone)
+// needs a store on disk.
+var MemoryRoot = filepath.Join(string(filepath.Separator), "storetest")
+
+// Memory is a store.Store holding files in memory. It behaves like the
+// filesystem store: WriteFile needs the parent directory to exist, reads
+// of missing files return errors matching fs.ErrNotExist, and HashDir
+// returns the same hash as store.HashTree over the same files. It is safe
+// for concurrent use.
+type Memory struct {
+	mu    sync.Mutex
+	dirs  map[string]bool
+	files map[string][]byte
+	used  map[string]time.Time // when HashDir last hashed each directory
+	lock  chan struct{}        // holds a token while the store is locked
+}
+
+var _ store.Store = &Memory{}
+
+// NewMemory returns an empty in-memory store.
+func NewMemory() *Memory {
+	return &Memory{
+		dirs:  map[string]bool{".": true},
+		files: make(map[string][]byte),
+		used:  make(map[string]time.Time),
+		lock:  make(chan struct{}, 1),
+	}
+}
+
+func (m *Memory) Path(segments ...string) string {
+	return filepath.Join(append([]string{MemoryRoot}, segments...)...)
+}
+
+func (m *Memory) Exists(segments ...string) (bool, error) {
+	key := memKey(segments)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, isFile := m.files[key]
+	return isFile || m.dirs[key], nil
+}
+
+func (m *Memory) EnsureDir(segments ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key := memKey(segments); !m.dirs[key]; key = path.Dir(key) {
+		m.dirs[key] = true
+	}
+}
+
+func (m *Memory) Remove(segments ...string) {
+	key := memKey(segments)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.files, key)
+	delete(m.dirs, key)
+	for f := range m.files {
+		if under(f, key) {
+			delete(m.files, f)
+		}
+	}
+	for d := range m.dirs {
+		if under(d, key) {
+			delete(m.dirs, d)
+		}
+	}
+	for d := range m.used {
+		if d == key || under(d, key) {
+			delete(m.used, d)
+		}
+	}
+	m.dirs["."] = true
+}
+
+// HashDir hashes the files under segments the way store.HashTree does:
+// sha256 over each file's relative path and contents, in sorted order.
+func (m *Memory) HashDir(segments ...string) (string, error) {
+	key := memKey(segments)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.dirs[key] {
+		return "", &fs.PathError{Op: "lstat", Path: m.Path(segments...), Err: fs.ErrNotExist}
+	}
+
+	var names []string
+	for f := range m.files {
+		if under(f, key) {
+			names = append(names, filepath.FromSlash(strings.TrimPrefix(f, key+"/")))
+		}
+	}
+	sort.Strings(names)
+	m.used[key] = time.Now()
+
+	h := sha256.New()
+	for _, name := range names {
+		h.Write([]byte(name))
+		h.Write(m.files[path.Join(key, filepath.ToSlash(name))])
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// RehashDir is HashDir: Memory keeps no hash cache.
+func (m *Memory) RehashDir(segments ...string) (string, error) {
+	return m.HashDir(segments...)
+}
+
+func (m *Memory) LastUsed(segments ...string) time.Time {
+	key := memKey(segments)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var last time.Time
+	for dir, used := range m.used {
+		if (dir == key || under(dir, key)) && used.After(last) {
+			last = used
+		}
+	}
+	return last
+}
+
+func (m *Memory) WriteFile(data []byte, perm os.FileMode, segments ...string) error {
+	key := memKey(segments)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.dirs[path.Dir(key)] {
+		return &fs.PathError{Op: "open", Path: m.Path(segments...), Err: fs.ErrNotExist}
+	}
+	if m.dirs[key] {
+		return &fs.PathError{Op: "open", Path: m.Path(segments...), Err: fmt.Errorf("is a directory")}
+	}
+	m.files[key] = append([]byte(nil), data...)
+	return nil
+}
+
+func (m *Memory) ReadFile(segments ...string) ([]byte, error) {
+	key := memKey(segments)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.files[key]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: m.Path(segments...), Err: fs.ErrNotExist}
+	}
+	return append([]byte(nil), data...), nil
+}
+
+func (m *Memory) Lock(timeout time.Duration) (func(), error) {
+	select {
+	case m.lock <- struct{}{}:
+		return func() { <-m.lock }, nil
+	case <-time.After(timeout):
+		return nil, fmt.Errorf("%s is locked", MemoryRoot)
+	}
+}
+
+// memKey joins segments into a slash-separated key relative to the root,
+// "." for the root itself.
+func memKey(segments []string) string {
+	return path.Join(append([]string{"."}, filepath.ToSlash(filepath.Join(segments...)))...)
+}
+
+// under reports whether key is strictly inside dir.
+func under(key, dir string) bool {
+	return dir == "." || strings.HasPrefix(key, dir+"/")
+}
@@ -0,0 +1,108 @@
+package storetest
+
+import (
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+// Call is a call made to a Recorder.
+type Call struct {
+	// Method is the store.Store method called, e.g. "WriteFile".
+	Method   string
+	Segments []string
+}
+
+// Recorder wraps a store.Store and records every call made to it, so
+// tests can assert what a Source or installer touched. It is safe for
+// concurrent use if the wrapped store is.
+type Recorder struct {
+	store.Store
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+var _ store.Store = &Recorder{}
+
+// NewRecorder returns a Recorder wrapping s.
+func NewRecorder(s store.Store) *Recorder {
+	return &Recorder{Store: s}
+}
+
+// Calls returns the calls recorded so far, in order.
+func (r *Recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.calls)
+}
+
+// Writes returns the segments of every WriteFile call, in order.
+func (r *Recorder) Writes() [][]string {
+	var writes [][]string
+	for _, c := range r.Calls() {
+		if c.Method == "WriteFile" {
+			writes = append(writes, c.Segments)
+		}
+	}
+	return writes
+}
+
+func (r *Recorder) record(method string, segments []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, Call{Method: method, Segments: slices.Clone(segments)})
+}
+
+func (r *Recorder) Path(segments ...string) string {
+	r.record("Path", segments)
+	return r.Store.Path(segments...)
+}
+
+func (r *Recorder) Exists(segments ...string) (bool, error) {
+	r.record("Exists", segments)
+	return r.Store.Exists(segments...)
+}
+
+func (r *Recorder) EnsureDir(segments ...string) {
+	r.record("EnsureDir", segments)
+	r.Store.EnsureDir(segments...)
+}
+
+func (r *Recorder) Remove(segments ...string) {
+	r.record("Remove", segments)
+	r.Store.Remove(segments...)
+}
+
+func (r *Recorder) HashDir(segments ...string) (string, error) {
+	r.record("HashDir", segments)
+	return r.Store.HashDir(segments...)
+}
+
+func (r *Recorder) RehashDir(segments ...string) (string, error) {
+	r.record("RehashDir", segments)
+	return r.Store.RehashDir(segments...)
+}
+
+func (r *Recorder) LastUsed(segments ...string) time.Time {
+	r.record("LastUsed", segments)
+	return r.Store.LastUsed(segments...)
+}
+
+func (r *Recorder) WriteFile(data []byte, perm os.FileMode, segments ...string) error {
+	r.record("WriteFile", segments)
+	return r.Store.WriteFile(data, perm, segments...)
+}
+
+func (r *Recorder) ReadFile(segments ...string) ([]byte, error) {
+	r.record("ReadFile", segments)
+	return r.Store.ReadFile(segments...)
+}
+
+func (r *Recorder) Lock(timeout time.Duration) (func(), error) {
+	r.record("Lock", nil)
+	return r.Store.Lock(timeout)
+}
@@ -0,0 +1,128 @@
+package storetest
+
+import (
+	"errors"
+	"io/fs"
+	"slices"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+// TestMemoryMatchesDisk runs the same operations against Memory and the
+// filesystem store and compares the results.
+func TestMemoryMatchesDisk(t *testing.T) {
+	tests := map[string]struct {
+		setup func(s store.Store)
+		check func(t *testing.T, s store.Store)
+	}{
+		"write needs parent dir": {
+			check: func(t *testing.T, s store.Store) {
+				err := s.WriteFile([]byte("x"), 0o644, "missing", "file")
+				if !errors.Is(err, fs.ErrNotExist) {
+					t.Errorf("WriteFile() error = %v, want fs.ErrNotExist", err)
+				}
+			},
+		},
+		"read missing file": {
+			check: func(t *testing.T, s store.Store) {
+				if _, err := s.ReadFile("missing"); !errors.Is(err, fs.ErrNotExist) {
+					t.Errorf("ReadFile() error = %v, want fs.ErrNotExist", err)
+				}
+			},
+		},
+		"write and read": {
+			setup: func(s store.Store) {
+				s.EnsureDir("skills", "greet")
+				s.WriteFile([]byte("hello"), 0o644, "skills", "greet", "SKILL.md")
+			},
+			check: func(t *testing.T, s store.Store) {
+				data, err := s.ReadFile("skills", "greet", "SKILL.md")
+				if err != nil || string(data) != "hello" {
+					t.Errorf("ReadFile() = %q, %v, want hello", data, err)
+				}
+				for _, segs := range [][]string{{"skills"}, {"skills", "greet"}, {"skills", "greet", "SKILL.md"}} {
+					if ok, _ := s.Exists(segs...); !ok {
+						t.Errorf("Exists(%v) = false, want true", segs)
+					}
+				}
+			},
+		},
+		"remove tree": {
+			setup: func(s store.Store) {
+				s.EnsureDir("skills", "greet", "scripts")
+				s.WriteFile([]byte("echo"), 0o755, "skills", "greet", "scripts", "run.sh")
+				s.EnsureDir("skills", "greeter")
+				s.Remove("skills", "greet")
+			},
+			check: func(t *testing.T, s store.Store) {
+				if ok, _ := s.Exists("skills", "greet", "scripts", "run.sh"); ok {
+					t.Error("removed file still exists")
+				}
+				if ok, _ := s.Exists("skills", "greeter"); !ok {
+					t.Error("sibling with a common prefix was removed")
+				}
+			},
+		},
+		"hash dir": {
+			setup: func(s store.Store) {
+				s.EnsureDir("pkg", "b")
+				s.WriteFile([]byte("one"), 0o644, "pkg", "z.txt")
+				s.WriteFile([]byte("two"), 0o644, "pkg", "b", "a.txt")
+				s.EnsureDir("other")
+				s.WriteFile([]byte("three"), 0o644, "other", "c.txt")
+			},
+		},
+		"hash missing dir": {
+			check: func(t *testing.T, s store.Store) {
+				if _, err := s.HashDir("missing"); !errors.Is(err, fs.ErrNotExist) {
+					t.Errorf("HashDir() error = %v, want fs.ErrNotExist", err)
+				}
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			disk, mem := store.New(t.TempDir()), NewMemory()
+			for _, s := range []store.Store{disk, mem} {
+				if tc.setup != nil {
+					tc.setup(s)
+				}
+				if tc.check != nil {
+					tc.check(t, s)
+				}
+			}
+
+			for _, segs := range [][]string{{"pkg"}, {"other"}} {
+				diskHash, diskErr := disk.HashDir(segs...)
+				memHash, memErr := mem.HashDir(segs...)
+				if diskHash != memHash || (diskErr == nil) != (memErr == nil) {
+					t.Errorf("HashDir(%v) = %q, %v on disk, %q, %v in memory", segs, diskHash, diskErr, memHash, memErr)
+				}
+			}
+		})
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	r := NewRecorder(NewMemory())
+	r.EnsureDir("a")
+	r.WriteFile([]byte("x"), 0o644, "a", "b")
+	r.ReadFile("a", "b")
+
+	want := []Call{
+		{Method: "EnsureDir", Segments: []string{"a"}},
+		{Method: "WriteFile", Segments: []string{"a", "b"}},
+		{Method: "ReadFile", Segments: []string{"a", "b"}},
+	}
+	got := r.Calls()
+	if !slices.EqualFunc(got, want, func(a, b Call) bool {
+		return a.Method == b.Method && slices.Equal(a.Segments, b.Segments)
+	}) {
+		t.Errorf("Calls() = %v, want %v", got, want)
+	}
+	if writes := r.Writes(); len(writes) != 1 || !slices.Equal(writes[0], []string{"a", "b"}) {
+		t.Errorf("Writes() = %v, want [[a b]]", writes)
+	}
+}
@@ -0,0 +1,116 @@
+// Package sumdb keeps a local checksum database: the integrity first seen
+// for each package version, shared by every project using the store. A
+// version whose content later hashes differently, such as a re-pointed
+// release tag or a tampered registry, is reported instead of trusted.
+package sumdb
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+// FileName is the store file holding the database, one
+// "<module> <version> <integrity>" line per package version.
+const FileName = "sumdb"
+
+// mu serializes read-modify-write cycles of the database file within the
+// process.
+var mu sync.Mutex
+
+// MismatchError reports a package version whose content differs from the
+// content recorded when it was first installed.
+type MismatchError struct {
+	Module   string
+	Version  string
+	Recorded string
+	Got      string
+}
+
+func (e *MismatchError) Error() string {
+	return fmt.Sprintf("checksum mismatch for %s@%s: got %s, but %s was recorded when this version was first installed; "+
+		"its content changed upstream, which may mean it was tampered with (remove the line from the %s file in the store to trust the new content)",
+		e.Module, e.Version, e.Got, e.Recorded, FileName)
+}
+
+// Check records integrity as the content of module at version if the
+// version isn't in the database yet (trust on first use). If a different
+// integrity was recorded, Check returns a *MismatchError and keeps the
+// recorded one.
+func Check(s store.Store, module, version, integrity string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	sums, err := read(s)
+	if err != nil {
+		return err
+	}
+
+	key := module + " " + version
+	if recorded, ok := sums[key]; ok {
+		if recorded != integrity {
+			return &MismatchError{Module: module, Version: version, Recorded: recorded, Got: integrity}
+		}
+		return nil
+	}
+
+	sums[key] = integrity
+	return write(s, sums)
+}
+
+// Lookup returns the integrity recorded for module at version.
+func Lookup(s store.Store, module, version string) (string, bool, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	sums, err := read(s)
+	if err != nil {
+		return "", false, err
+	}
+	integrity, ok := sums[module+" "+version]
+	return integrity, ok, nil
+}
+
+// read parses the database into integrities keyed by "<module> <version>".
+func read(s store.Store) (map[string]string, error) {
+	sums := make(map[string]string)
+	data, err := s.ReadFile(FileName)
+	if os.IsNotExist(err) {
+		return sums, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading checksum database: %w", err)
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		sums[fields[0]+" "+fields[1]] = fields[2]
+	}
+	return sums, nil
+}
+
+func write(s store.Store, sums map[string]string) error {
+	keys := make([]string, 0, len(sums))
+	for key := range sums {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		b.WriteString(key + " " + sums[key] + "\n")
+	}
+
+	s.EnsureDir()
+	if err := s.WriteFile([]byte(b.String()), 0o644, FileName); err != nil {
+		return fmt.Errorf("writing checksum database: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package sumdb
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/store/storetest"
+)
+
+func TestCheck(t *testing.T) {
+	const module = "https://github.com/org/skills.git//greet"
+
+	tests := map[string]struct {
+		recorded     map[string]string // version → integrity checked first
+		version      string
+		integrity    string
+		wantMismatch bool
+	}{
+		"first use is recorded": {
+			version:   "v1.0.0",
+			integrity: "sha256:aaa",
+		},
+		"same content": {
+			recorded:  map[string]string{"v1.0.0": "sha256:aaa"},
+			version:   "v1.0.0",
+			integrity: "sha256:aaa",
+		},
+		"changed content": {
+			recorded:     map[string]string{"v1.0.0": "sha256:aaa"},
+			version:      "v1.0.0",
+			integrity:    "sha256:bbb",
+			wantMismatch: true,
+		},
+		"other version": {
+			recorded:  map[string]string{"v1.0.0": "sha256:aaa"},
+			version:   "v1.1.0",
+			integrity: "sha256:bbb",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := storetest.NewMemory()
+			for version, integrity := range tc.recorded {
+				if err := Check(s, module, version, integrity); err != nil {
+					t.Fatalf("recording %s: %v", version, err)
+				}
+			}
+
+			err := Check(s, module, tc.version, tc.integrity)
+			var mismatch *MismatchError
+			if errors.As(err, &mismatch) != tc.wantMismatch {
+				t.Fatalf("Check() error = %v, wantMismatch %v", err, tc.wantMismatch)
+			}
+			if !tc.wantMismatch && err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+
+			want := tc.integrity
+			if tc.wantMismatch {
+				want = tc.recorded[tc.version]
+			}
+			got, ok, err := Lookup(s, module, tc.version)
+			if err != nil || !ok || got != want {
+				t.Errorf("Lookup() = %q, %v, %v, want %q", got, ok, err, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,296 @@
+package workspace
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/agentpkg/agentpkg/pkg/attest"
+	"github.com/agentpkg/agentpkg/pkg/completion"
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/installer"
+	"github.com/agentpkg/agentpkg/pkg/project"
+	"github.com/agentpkg/agentpkg/pkg/projector"
+	"github.com/agentpkg/agentpkg/pkg/source"
+)
+
+// LockTrust names whose signature of the lockfile to trust: a team public
+// key, or a sigstore identity and issuer.
+type LockTrust struct {
+	Key      string
+	Identity string
+	Issuer   string
+}
+
+// Empty reports whether no signer is trusted.
+func (t LockTrust) Empty() bool {
+	return t.Key == "" && t.Identity == ""
+}
+
+// Verify checks the signature of the lockfile at lockPath.
+func (t LockTrust) Verify(ctx context.Context, lockPath string) error {
+	var err error
+	if t.Key != "" {
+		err = attest.VerifyFile(lockPath, t.Key)
+	} else {
+		err = attest.VerifyKeyless(ctx, lockPath, t.Identity, t.Issuer)
+	}
+	if errors.Is(err, attest.ErrUnsigned) {
+		return fmt.Errorf("%s is not signed; run apkg lock sign after reviewing it", lockPath)
+	}
+	return err
+}
+
+// InstallOptions configures InstallAll.
+type InstallOptions struct {
+	// Frozen installs exactly what the lockfile locks, and fails instead
+	// of changing it or the manifest.
+	Frozen bool
+	// Trust, unless empty, is whose signature a frozen install requires
+	// of the lockfile before anything is installed.
+	Trust LockTrust
+
+	// Force, Concurrency, DeferredServers, Conflict, ToolOutput, and
+	// Report configure the installer (see installer.Installer).
+	Force           bool
+	Concurrency     int
+	DeferredServers []string
+	Conflict        func(projector.Conflict) (string, error)
+	ToolOutput      io.Writer
+	Report          func(installer.PackageResult)
+}
+
+// InstallAll installs every package in the manifest at its locked version
+// and writes the lockfile, and the manifest if its refs get pinned (see
+// installer.PinRefs). A frozen install writes neither, and fails if it
+// would have to change them.
+func (ws *Workspace) InstallAll(ctx context.Context, opts InstallOptions) (*config.LockFile, error) {
+	cfg, existing, err := ws.Load()
+	if err != nil {
+		return nil, err
+	}
+	if opts.Frozen && !opts.Trust.Empty() {
+		if err := opts.Trust.Verify(ctx, ws.LockPath); err != nil {
+			return nil, err
+		}
+	}
+
+	inst := ws.Installer()
+	inst.Force = opts.Force
+	inst.Concurrency = opts.Concurrency
+	inst.DeferredServers = opts.DeferredServers
+	inst.Conflict = opts.Conflict
+	inst.ToolOutput = opts.ToolOutput
+	inst.Report = opts.Report
+
+	if opts.Frozen {
+		if err := checkFrozen(inst.Status(cfg, existing), ws.LockPath); err != nil {
+			return nil, err
+		}
+	}
+
+	lf, err := inst.InstallAll(ctx, cfg, existing)
+	if err != nil {
+		return nil, err
+	}
+
+	changed, err := lockChanged(existing, lf)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Frozen {
+		if changed {
+			return nil, fmt.Errorf("%s is out of date with apkg.toml; run apkg install without --frozen and commit the lockfile", ws.LockPath)
+		}
+		return lf, nil
+	}
+
+	if err := ws.pinRefs(cfg, lf); err != nil {
+		return nil, err
+	}
+	if err := ws.SaveLock(lf); err != nil {
+		return nil, err
+	}
+	if changed && attest.Signed(ws.LockPath) && ws.Warn != nil {
+		ws.Warn(fmt.Errorf("%s changed, so its signature no longer verifies; review it and run apkg lock sign", ws.LockPath))
+	}
+	return lf, nil
+}
+
+// checkFrozen returns an error if a frozen install would have to change
+// the lockfile: a package in the manifest isn't locked, or the lockfile
+// locks one the manifest no longer declares.
+func checkFrozen(status *installer.Status, lockPath string) error {
+	var problems []string
+	for _, pkg := range status.Packages {
+		switch pkg.State {
+		case installer.StateNotInstalled:
+			problems = append(problems, fmt.Sprintf("%s %q is not locked", pkg.Kind, pkg.Name))
+		case installer.StateChanged:
+			problems = append(problems, fmt.Sprintf("%s %q changed since it was locked", pkg.Kind, pkg.Name))
+		}
+	}
+	for _, orphan := range status.Orphaned {
+		problems = append(problems, fmt.Sprintf("%s is locked but not in apkg.toml", orphan))
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s is out of date with apkg.toml:\n  %s\nRun apkg install without --frozen and commit the lockfile", lockPath, strings.Join(problems, "\n  "))
+}
+
+// lockChanged reports whether installing changed the lockfile's content.
+// Config digests added to MCP entries locked before they were recorded
+// don't count as a change.
+func lockChanged(before, after *config.LockFile) (bool, error) {
+	old, err := before.Marshal()
+	if err != nil {
+		return false, err
+	}
+	undigested := make(map[string]bool)
+	for _, entry := range before.MCPServers {
+		if entry.ConfigDigest == "" {
+			undigested[entry.Name] = true
+		}
+	}
+	cmp := *after
+	cmp.MCPServers = slices.Clone(after.MCPServers)
+	for i, entry := range cmp.MCPServers {
+		if undigested[entry.Name] {
+			cmp.MCPServers[i].ConfigDigest = ""
+		}
+	}
+	updated, err := cmp.Marshal()
+	if err != nil {
+		return false, err
+	}
+	return !bytes.Equal(old, updated), nil
+}
+
+// pinRefs pins the manifest's git skills to their locked commits and
+// saves it, if the manifest sets pin_refs (see installer.PinRefs).
+func (ws *Workspace) pinRefs(cfg *config.Config, lf *config.LockFile) error {
+	if !cfg.Project.PinRefs || !installer.PinRefs(cfg, lf) {
+		return nil
+	}
+	if err := config.SaveFile(ws.ManifestPath, cfg); err != nil {
+		return fmt.Errorf("saving %s: %w", ws.ManifestPath, err)
+	}
+	return nil
+}
+
+// SkillOptions configures InstallSkill.
+type SkillOptions struct {
+	// Scope is where to project the skill: config.SkillScopeProject (the
+	// default) or config.SkillScopeUser.
+	Scope string
+	// Snapshot projects a copy of a local skill from the store instead of
+	// its directory.
+	Snapshot bool
+	// SHA256 is the digest the archive of a skill installed from a URL
+	// must have.
+	SHA256 string
+	// Dir is the directory relative local paths are resolved against, the
+	// workspace directory if empty.
+	Dir string
+}
+
+// InstallSkill installs the skill at ref (see source.ParseRef), adds it to
+// the manifest and lockfile, and returns its name. Git refs are recorded
+// for completion.
+func (ws *Workspace) InstallSkill(ctx context.Context, ref string, opts SkillOptions) (string, error) {
+	src, ss, err := source.ParseRef(ref)
+	if err != nil {
+		return "", err
+	}
+	if ss.Remote() == "" && !filepath.IsAbs(ref) {
+		dir := opts.Dir
+		if dir == "" {
+			dir = ws.Dir
+		}
+		if src, ss, err = source.ParseRef(filepath.Join(dir, ref)); err != nil {
+			return "", err
+		}
+	}
+	if opts.Scope != "" && opts.Scope != config.SkillScopeProject {
+		ss.Scope = opts.Scope
+	}
+	if opts.Snapshot {
+		local, ok := src.(*source.LocalSource)
+		if !ok {
+			return "", fmt.Errorf("--snapshot only applies to local skills")
+		}
+		local.Snapshot, ss.Snapshot = true, true
+	}
+	if opts.SHA256 != "" {
+		archive, ok := src.(*source.ArchiveSource)
+		if !ok {
+			return "", fmt.Errorf("--sha256 only applies to skills installed from a URL")
+		}
+		archive.SHA256, ss.SHA256 = opts.SHA256, opts.SHA256
+	}
+	if ss.Remote() == "" {
+		if ss.Path, err = project.ManifestPath(ws.Dir, ss.Path, ws.Global); err != nil {
+			return "", err
+		}
+	}
+
+	inst := ws.Installer()
+	// The manifest may not exist yet, e.g. before the first global install.
+	declared, _ := config.LoadFile(ws.ManifestPath)
+	check := &config.Config{Skills: map[string]config.SkillSource{installer.SkillName(declared, ss): ss}}
+	if err := inst.CheckTools(check, nil); err != nil {
+		return "", err
+	}
+	sk, resolved, err := inst.InstallSkill(ctx, src, ss.Scope)
+	if err != nil {
+		return "", err
+	}
+
+	if ws.Global {
+		if err := project.InitGlobal(); err != nil {
+			return "", err
+		}
+	}
+
+	cfg, lf, err := ws.Load()
+	if err != nil {
+		return "", err
+	}
+	if cfg.Skills == nil {
+		cfg.Skills = make(map[string]config.SkillSource)
+	}
+	cfg.Skills[sk.Name()] = ss
+	if err := config.SaveFile(ws.ManifestPath, cfg); err != nil {
+		return "", fmt.Errorf("saving %s: %w", ws.ManifestPath, err)
+	}
+
+	lf.Skills = installer.UpsertSkillLockEntry(lf.Skills, config.SkillLockEntry{
+		Name:      sk.Name(),
+		Git:       ss.Git,
+		Path:      ss.Path,
+		Ref:       resolved.Ref,
+		Commit:    resolved.Commit,
+		Integrity: resolved.Integrity,
+		URL:       ss.URL,
+		SHA256:    resolved.SHA256,
+	})
+	if err := ws.pinRefs(cfg, lf); err != nil {
+		return "", err
+	}
+	if err := ws.SaveLock(lf); err != nil {
+		return "", err
+	}
+
+	if ss.Git != "" {
+		if err := completion.RecordRef(ws.Store, ref); err != nil && ws.Warn != nil {
+			ws.Warn(fmt.Errorf("recording %s for completion: %w", ref, err))
+		}
+	}
+	return sk.Name(), nil
+}
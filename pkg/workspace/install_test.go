@@ -0,0 +1,166 @@
+package workspace
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/journal"
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+// testWorkspace returns a workspace for a project with a local skill at
+// skills/pdf and an empty manifest.
+func testWorkspace(t *testing.T) *Workspace {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	if err := config.SaveFile(filepath.Join(dir, config.ManifestFileName), &config.Config{}); err != nil {
+		t.Fatal(err)
+	}
+	skillDir := filepath.Join(dir, "skills", "pdf")
+	if err := os.MkdirAll(skillDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	content := "---\nname: pdf\ndescription: test skill\n---\n# pdf\n"
+	if err := os.WriteFile(filepath.Join(skillDir, "SKILL.md"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return &Workspace{
+		Dir:          dir,
+		ManifestPath: filepath.Join(dir, config.ManifestFileName),
+		LockPath:     filepath.Join(dir, config.LockFileName),
+		Store:        store.New(filepath.Join(dir, "store")),
+		Journal:      &journal.Journal{Path: filepath.Join(dir, "journal.log")},
+	}
+}
+
+func TestInstallSkill(t *testing.T) {
+	tests := map[string]struct {
+		ref  string
+		opts SkillOptions
+		// inSkills resolves relative refs against the skills directory.
+		inSkills     bool
+		wantPath     string
+		wantSnapshot bool
+		wantErr      string
+	}{
+		"relative to the workspace": {
+			ref:      "./skills/pdf",
+			wantPath: "./skills/pdf",
+		},
+		"relative to dir": {
+			ref:      "./pdf",
+			inSkills: true,
+			wantPath: "./skills/pdf",
+		},
+		"snapshot": {
+			ref:          "./skills/pdf",
+			opts:         SkillOptions{Snapshot: true},
+			wantPath:     "./skills/pdf",
+			wantSnapshot: true,
+		},
+		"snapshot of a URL": {
+			ref:     "https://example.com/pdf.tar.gz",
+			opts:    SkillOptions{Snapshot: true},
+			wantErr: "--snapshot only applies to local skills",
+		},
+		"sha256 of a local skill": {
+			ref:     "./skills/pdf",
+			opts:    SkillOptions{SHA256: "abc"},
+			wantErr: "--sha256 only applies to skills installed from a URL",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ws := testWorkspace(t)
+			if tc.inSkills {
+				tc.opts.Dir = filepath.Join(ws.Dir, "skills")
+			}
+
+			got, err := ws.InstallSkill(context.Background(), tc.ref, tc.opts)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("InstallSkill() error = %v, want %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("InstallSkill() error = %v", err)
+			}
+			if got != "pdf" {
+				t.Errorf("InstallSkill() = %q, want pdf", got)
+			}
+
+			cfg, lf, err := ws.Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			ss := cfg.Skills["pdf"]
+			if ss.Path != tc.wantPath || ss.Snapshot != tc.wantSnapshot {
+				t.Errorf("manifest entry = %+v, want path %s, snapshot %v", ss, tc.wantPath, tc.wantSnapshot)
+			}
+			if len(lf.Skills) != 1 || lf.Skills[0].Name != "pdf" {
+				t.Errorf("lockfile skills = %+v, want pdf", lf.Skills)
+			}
+		})
+	}
+}
+
+func TestInstallAllFrozen(t *testing.T) {
+	tests := map[string]struct {
+		// unlocked is a skill declared after the lockfile was written.
+		unlocked string
+		wantErr  string
+	}{
+		"up to date": {},
+		"skill not locked": {
+			unlocked: "docs",
+			wantErr:  `skill "docs" is not locked`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ws := testWorkspace(t)
+			if _, err := ws.InstallSkill(context.Background(), "./skills/pdf", SkillOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			if tc.unlocked != "" {
+				cfg, _, err := ws.Load()
+				if err != nil {
+					t.Fatal(err)
+				}
+				cfg.Skills[tc.unlocked] = config.SkillSource{Path: "./skills/pdf"}
+				if err := config.SaveFile(ws.ManifestPath, cfg); err != nil {
+					t.Fatal(err)
+				}
+			}
+			before, err := os.ReadFile(ws.LockPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = ws.InstallAll(context.Background(), InstallOptions{Frozen: true})
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("InstallAll() error = %v, want %q", err, tc.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("InstallAll() error = %v", err)
+			}
+
+			after, err := os.ReadFile(ws.LockPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(after) != string(before) {
+				t.Errorf("frozen install changed the lockfile:\n%s\nwas:\n%s", after, before)
+			}
+		})
+	}
+}
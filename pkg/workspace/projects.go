@@ -0,0 +1,193 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/installer"
+	"github.com/agentpkg/agentpkg/pkg/journal"
+	"github.com/agentpkg/agentpkg/pkg/project"
+	"github.com/agentpkg/agentpkg/pkg/serve"
+)
+
+// ProjectSummary is a project apkg installed packages into, or the global
+// scope, with the packages it installs.
+type ProjectSummary struct {
+	Dir    string `json:"dir"`
+	Global bool   `json:"global,omitempty"`
+	// LastInstall is when the project's lockfile was last written, if
+	// apkg recorded it.
+	LastInstall time.Time `json:"lastInstall,omitzero"`
+	// Missing is set for known projects without a manifest anymore, e.g.
+	// because they were deleted or moved.
+	Missing bool `json:"missing,omitempty"`
+	// Agents are the agents the project's developer config projects into.
+	Agents   []string             `json:"agents"`
+	Packages []installer.TreeNode `json:"packages"`
+	// Error says why the project's packages couldn't be listed.
+	Error string `json:"error,omitempty"`
+}
+
+// AllProjects summarizes the global scope and every project apkg recorded
+// installing into (see project.RecordKnownProject), in that order.
+func AllProjects() ([]ProjectSummary, error) {
+	path, err := project.KnownProjectsPath()
+	if err != nil {
+		return nil, err
+	}
+	known, err := project.LoadKnownProjects(path)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := []ProjectSummary{summarize("", true)}
+	for _, p := range known {
+		s := summarize(p.Dir, false)
+		s.LastInstall = p.LastInstall
+		summaries = append(summaries, s)
+	}
+	return summaries, nil
+}
+
+// PruneProjects forgets the known projects without a manifest anymore
+// (see project.PruneKnownProjects) and returns them.
+func PruneProjects() ([]project.KnownProject, error) {
+	path, err := project.KnownProjectsPath()
+	if err != nil {
+		return nil, err
+	}
+	return project.PruneKnownProjects(path)
+}
+
+// summarize lists the packages of the project at dir, or of the global
+// scope.
+func summarize(dir string, global bool) ProjectSummary {
+	s := ProjectSummary{Dir: dir, Global: global, Agents: []string{}, Packages: []installer.TreeNode{}}
+	if !global {
+		if _, err := os.Stat(filepath.Join(dir, project.ManifestFile)); os.IsNotExist(err) {
+			s.Missing = true
+			return s
+		}
+	}
+	ws, err := Open(dir, global)
+	if err != nil {
+		s.Error = err.Error()
+		return s
+	}
+	s.Dir = ws.Dir
+	if _, err := os.Stat(ws.ManifestPath); os.IsNotExist(err) {
+		// Nothing installed globally yet.
+		return s
+	}
+	if ws.Agents != nil {
+		s.Agents = ws.Agents
+	}
+	nodes, err := ws.Packages()
+	if err != nil {
+		s.Error = err.Error()
+		return s
+	}
+	if nodes != nil {
+		s.Packages = nodes
+	}
+	return s
+}
+
+// KnownLockFiles loads the global lockfile, the lockfile of the project at
+// dir, and those of the projects in the journal or the known projects that
+// still exist: every lockfile that may reference store entries.
+func KnownLockFiles(dir string) ([]*config.LockFile, error) {
+	globalLock, err := config.GlobalLockFilePath()
+	if err != nil {
+		return nil, err
+	}
+	paths := []string{globalLock}
+
+	j, err := journal.Default()
+	if err != nil {
+		return nil, err
+	}
+	projects, err := j.Projects()
+	if err != nil {
+		return nil, err
+	}
+	knownPath, err := project.KnownProjectsPath()
+	if err != nil {
+		return nil, err
+	}
+	known, err := project.LoadKnownProjects(knownPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range known {
+		if !slices.Contains(projects, p.Dir) {
+			projects = append(projects, p.Dir)
+		}
+	}
+	if !slices.Contains(projects, dir) {
+		projects = append(projects, dir)
+	}
+	for _, p := range projects {
+		if _, err := os.Stat(p); os.IsNotExist(err) {
+			continue
+		}
+		paths = append(paths, filepath.Join(p, config.LockFileName))
+	}
+
+	var lockfiles []*config.LockFile
+	for _, path := range paths {
+		lf, err := config.LoadLockFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("loading %s: %w", path, err)
+		}
+		lockfiles = append(lockfiles, lf)
+	}
+	return lockfiles, nil
+}
+
+// ContainerInstall returns the install of the named container server that
+// the lockfile of the project at dir, or the global lockfile for "",
+// records (see serve.Server.Installs). dir comes from a request to the
+// proxy, so only known projects (see project.RecordKnownProject) are
+// looked up: a request mustn't get the proxy to mount the volumes of a
+// lockfile anywhere else.
+func ContainerInstall(dir, name string) (serve.Install, bool, error) {
+	path, err := config.GlobalLockFilePath()
+	if err != nil {
+		return serve.Install{}, false, err
+	}
+	if dir != "" {
+		known, err := isKnownProject(dir)
+		if err != nil || !known {
+			return serve.Install{}, false, err
+		}
+		path = filepath.Join(dir, config.LockFileName)
+	}
+	lf, err := config.LoadLockFile(path)
+	if err != nil {
+		return serve.Install{}, false, err
+	}
+	for _, entry := range lf.MCPServers {
+		if entry.Name == name && entry.Digest != "" {
+			return serve.Install{Digest: entry.Digest, Volumes: entry.Volumes}, true, nil
+		}
+	}
+	return serve.Install{}, false, nil
+}
+
+// isKnownProject reports whether dir is one of the known projects.
+func isKnownProject(dir string) (bool, error) {
+	path, err := project.KnownProjectsPath()
+	if err != nil {
+		return false, err
+	}
+	known, err := project.LoadKnownProjects(path)
+	if err != nil {
+		return false, err
+	}
+	return slices.ContainsFunc(known, func(p project.KnownProject) bool { return p.Dir == dir }), nil
+}
@@ -0,0 +1,156 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/project"
+	"github.com/agentpkg/agentpkg/pkg/serve"
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+func TestAllProjects(t *testing.T) {
+	const repo = "https://github.com/org/skills"
+	tests := map[string]struct {
+		manifest     string // of the known project; none if empty
+		wantMissing  bool
+		wantPackages []string
+	}{
+		"project with packages": {
+			manifest:     "[skills.pdf]\ngit = \"" + repo + "\"\npath = \"pdf\"\nref = \"main\"\n",
+			wantPackages: []string{"pdf"},
+		},
+		"project without packages": {
+			manifest: "\n",
+		},
+		"deleted project": {
+			wantMissing: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			t.Setenv(store.EnvRoot, t.TempDir())
+			dir := filepath.Join(t.TempDir(), "project")
+			if tc.manifest != "" {
+				if err := os.MkdirAll(dir, 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(dir, config.ManifestFileName), []byte(tc.manifest), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			knownPath, err := project.KnownProjectsPath()
+			if err != nil {
+				t.Fatal(err)
+			}
+			installed := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+			if err := project.RecordKnownProject(knownPath, dir, installed); err != nil {
+				t.Fatal(err)
+			}
+
+			summaries, err := AllProjects()
+			if err != nil {
+				t.Fatalf("AllProjects() error = %v", err)
+			}
+			if len(summaries) != 2 {
+				t.Fatalf("AllProjects() = %+v, want the global scope and the project", summaries)
+			}
+			if !summaries[0].Global {
+				t.Errorf("first summary = %+v, want the global scope", summaries[0])
+			}
+
+			got := summaries[1]
+			if got.Dir != dir || !got.LastInstall.Equal(installed) {
+				t.Errorf("project = %s installed %v, want %s installed %v", got.Dir, got.LastInstall, dir, installed)
+			}
+			if got.Missing != tc.wantMissing {
+				t.Errorf("Missing = %v, want %v", got.Missing, tc.wantMissing)
+			}
+			if got.Error != "" {
+				t.Errorf("Error = %q", got.Error)
+			}
+			var names []string
+			for _, node := range got.Packages {
+				names = append(names, node.Name)
+			}
+			if !slices.Equal(names, tc.wantPackages) {
+				t.Errorf("Packages = %v, want %v", names, tc.wantPackages)
+			}
+		})
+	}
+}
+
+func TestContainerInstall(t *testing.T) {
+	tests := map[string]struct {
+		global bool // look up the global install
+		known  bool // record the project as known
+		server string
+		want   serve.Install
+		wantOK bool
+	}{
+		"known project": {
+			known:  true,
+			server: "postgres",
+			want:   serve.Install{Digest: "sha256:abc", Volumes: []string{"/data:/data"}},
+			wantOK: true,
+		},
+		"unknown project": {
+			server: "postgres",
+		},
+		"server the project doesn't install": {
+			known:  true,
+			server: "redis",
+		},
+		"global install": {
+			global: true,
+			server: "postgres",
+			want:   serve.Install{Digest: "sha256:abc", Volumes: []string{"/data:/data"}},
+			wantOK: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			lf := &config.LockFile{MCPServers: []config.MCPLockEntry{
+				{Name: "postgres", Digest: "sha256:abc", Volumes: []string{"/data:/data"}},
+			}}
+
+			dir := t.TempDir()
+			path := filepath.Join(dir, config.LockFileName)
+			if tc.global {
+				dir = ""
+				var err error
+				if path, err = config.GlobalLockFilePath(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := config.SaveLockFile(path, lf); err != nil {
+				t.Fatal(err)
+			}
+			if tc.known {
+				knownPath, err := project.KnownProjectsPath()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := project.RecordKnownProject(knownPath, dir, time.Now()); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, ok, err := ContainerInstall(dir, tc.server)
+			if err != nil {
+				t.Fatalf("ContainerInstall() error = %v", err)
+			}
+			if ok != tc.wantOK || got.Digest != tc.want.Digest || !slices.Equal(got.Volumes, tc.want.Volumes) {
+				t.Errorf("ContainerInstall() = %+v, %v, want %+v, %v", got, ok, tc.want, tc.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,368 @@
+// Package workspace is the library facade over a project (or the global
+// scope): it ties together the manifest, lockfile, store, and installer so
+// the CLI and the API servers perform the same operations.
+package workspace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/agentpkg/agentpkg/pkg/config"
+	"github.com/agentpkg/agentpkg/pkg/container"
+	"github.com/agentpkg/agentpkg/pkg/installer"
+	"github.com/agentpkg/agentpkg/pkg/journal"
+	"github.com/agentpkg/agentpkg/pkg/policy"
+	"github.com/agentpkg/agentpkg/pkg/project"
+	"github.com/agentpkg/agentpkg/pkg/projector"
+	"github.com/agentpkg/agentpkg/pkg/serve"
+	"github.com/agentpkg/agentpkg/pkg/store"
+)
+
+// ErrNotFound is returned when removing a package that is not in the
+// manifest.
+var ErrNotFound = errors.New("package not found")
+
+// Workspace is a project directory, or the home directory for the global
+// scope, together with the configuration needed to install into it.
+type Workspace struct {
+	// Dir is the project root, or the home directory when Global is set.
+	Dir          string
+	ManifestPath string
+	LockPath     string
+	Global       bool
+
+	Store  store.Store
+	Agents []string
+	EnvSet string
+	// RequireEnvSet makes installs fail if no MCP server defines EnvSet
+	// (see installer.Installer.RequireEnvSet).
+	RequireEnvSet   bool
+	FetchTimeout    time.Duration
+	Projection      string
+	ProjectionMode  string
+	ProjectionModes map[string]string
+	MCPScopes       map[string]string
+	MCPTypes        map[string]map[string]string
+	MCPCommands     map[string]string
+	SocketAgents    []string
+	// Policy, if set, approves the packages installs resolve.
+	Policy policy.Hook
+
+	// Journal records lockfile changes; journal.Default() when nil.
+	Journal *journal.Journal
+	// Command is recorded in journal events as the cause of the change.
+	Command string
+	// Warn, if set, receives errors that don't fail the operation, such
+	// as failing to write the journal.
+	Warn func(error)
+}
+
+// Open returns the workspace of the project at dir, or the global scope
+// when global is set, with the agents and env set of the developer config.
+func Open(dir string, global bool) (*Workspace, error) {
+	if global {
+		home, err := config.HomeDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = home
+	}
+	devCfg, err := config.LoadDevConfig(nil, global, dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := projector.RegisterCustomProjectors(devCfg.Projectors); err != nil {
+		return nil, err
+	}
+	return New(dir, global, devCfg)
+}
+
+// New returns the workspace of the project at dir, or the global scope
+// when global is set, configured by devCfg, the developer config loaded
+// for it. The store is the one at $APKG_STORE_DIR, at store_path from
+// devCfg, or the default store.
+func New(dir string, global bool, devCfg *config.DevConfig) (*Workspace, error) {
+	ws := &Workspace{
+		Dir:          dir,
+		ManifestPath: filepath.Join(dir, project.ManifestFile),
+		LockPath:     filepath.Join(dir, config.LockFileName),
+		Global:       global,
+	}
+
+	if global {
+		home, err := config.HomeDir()
+		if err != nil {
+			return nil, err
+		}
+		ws.Dir = home
+		if ws.ManifestPath, err = config.GlobalManifestPath(); err != nil {
+			return nil, err
+		}
+		if ws.LockPath, err = config.GlobalLockFilePath(); err != nil {
+			return nil, err
+		}
+	}
+
+	ws.Agents = devCfg.Agents
+	ws.EnvSet = devCfg.EnvSet
+	ws.FetchTimeout = devCfg.FetchTimeoutDuration()
+	ws.Projection = devCfg.Projection
+	ws.ProjectionMode = devCfg.ProjectionMode
+	ws.ProjectionModes = devCfg.AgentProjectionModes
+	ws.MCPScopes = devCfg.MCPScopes
+	ws.MCPTypes = devCfg.MCPTypes
+	ws.MCPCommands = devCfg.MCPCommands
+	ws.SocketAgents = devCfg.ServeSocketAgents
+	var err error
+	if devCfg.PolicyHook != "" {
+		if ws.Policy, err = policy.New(devCfg.PolicyHook); err != nil {
+			return nil, err
+		}
+	}
+
+	if os.Getenv(store.EnvRoot) == "" && devCfg.StorePath != "" {
+		ws.Store = store.New(devCfg.StorePath)
+	} else if ws.Store, err = store.Default(); err != nil {
+		return nil, err
+	}
+	return ws, nil
+}
+
+// Load reads the manifest and lockfile.
+func (ws *Workspace) Load() (*config.Config, *config.LockFile, error) {
+	cfg, err := config.LoadFile(ws.ManifestPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("loading %s: %w", ws.ManifestPath, err)
+	}
+	lf, err := config.LoadLockFile(ws.LockPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("loading lockfile: %w", err)
+	}
+	return cfg, lf, nil
+}
+
+// SaveLock writes lf to the lockfile, journals the packages it installs,
+// updates, or removes relative to the lockfile on disk, and adds the
+// project to the known projects (see project.RecordKnownProject).
+func (ws *Workspace) SaveLock(lf *config.LockFile) error {
+	// An unreadable previous lockfile journals every entry as installed.
+	old, _ := config.LoadLockFile(ws.LockPath)
+
+	if err := config.SaveLockFile(ws.LockPath, lf); err != nil {
+		return fmt.Errorf("writing lockfile: %w", err)
+	}
+
+	now := time.Now()
+	ws.Record(journal.LockChanges(old, lf, now))
+	ws.registerProject(now)
+	return nil
+}
+
+// registerProject records the workspace's project as known. Like journal
+// failures, failures are passed to Warn.
+func (ws *Workspace) registerProject(now time.Time) {
+	if ws.Global {
+		return
+	}
+	path, err := project.KnownProjectsPath()
+	if err == nil {
+		err = project.RecordKnownProject(path, ws.Dir, now)
+	}
+	if err != nil && ws.Warn != nil {
+		ws.Warn(fmt.Errorf("recording known project: %w", err))
+	}
+}
+
+// Record appends events to the journal, stamped with the workspace
+// directory and Command. Journal failures are passed to Warn: they never
+// fail the operation that made the change.
+func (ws *Workspace) Record(events []journal.Event) {
+	if len(events) == 0 {
+		return
+	}
+
+	for i := range events {
+		events[i].Project = ws.Dir
+		events[i].Command = ws.Command
+	}
+
+	j := ws.Journal
+	var err error
+	if j == nil {
+		j, err = journal.Default()
+	}
+	if err == nil {
+		err = j.Append(events...)
+	}
+	if err != nil && ws.Warn != nil {
+		ws.Warn(fmt.Errorf("recording history: %w", err))
+	}
+}
+
+// Installer returns an installer projecting into the workspace's agents.
+func (ws *Workspace) Installer() *installer.Installer {
+	// Like `apkg install`, only use the vendor dir once something has
+	// been vendored.
+	vendorDir := ""
+	if !ws.Global {
+		dir := filepath.Join(ws.Dir, installer.VendorDirName)
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			vendorDir = dir
+		}
+	}
+	// Without a global config dir, ownership and projections just aren't
+	// recorded.
+	statePath := ""
+	if dir, err := config.GlobalConfigDir(); err == nil {
+		statePath = filepath.Join(dir, projector.StateFileName)
+	}
+	return &installer.Installer{
+		Store:           ws.Store,
+		ProjectDir:      ws.Dir,
+		Agents:          ws.Agents,
+		Global:          ws.Global,
+		VendorDir:       vendorDir,
+		EnvSet:          ws.EnvSet,
+		RequireEnvSet:   ws.RequireEnvSet,
+		FetchTimeout:    ws.FetchTimeout,
+		Projection:      ws.Projection,
+		ProjectionMode:  ws.ProjectionMode,
+		ProjectionModes: ws.ProjectionModes,
+		MCPScopes:       ws.MCPScopes,
+		MCPTypes:        ws.MCPTypes,
+		MCPCommands:     ws.MCPCommands,
+		SocketAgents:    ws.SocketAgents,
+		StatePath:       statePath,
+		Policy:          ws.Policy,
+		Warn:            ws.Warn,
+	}
+}
+
+// Packages returns every manifest entry with its resolved version, store
+// entry, and agents (see installer.Installer.Tree).
+func (ws *Workspace) Packages() ([]installer.TreeNode, error) {
+	cfg, lf, err := ws.Load()
+	if err != nil {
+		return nil, err
+	}
+	return ws.Installer().Tree(cfg, lf), nil
+}
+
+// Status reports the install state of every manifest entry.
+func (ws *Workspace) Status() (*installer.Status, error) {
+	cfg, lf, err := ws.Load()
+	if err != nil {
+		return nil, err
+	}
+	return ws.Installer().Status(cfg, lf), nil
+}
+
+// Drift returns the manifest entries missing from the configuration of a
+// configured agent (see installer.Installer.Drift).
+func (ws *Workspace) Drift() ([]installer.Drift, error) {
+	cfg, err := config.LoadFile(ws.ManifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("loading %s: %w", ws.ManifestPath, err)
+	}
+	return ws.Installer().Drift(cfg)
+}
+
+// Removal is what Remove took out besides manifest, lockfile, and agent
+// config entries.
+type Removal struct {
+	// Purged are the store entries deleted.
+	Purged []string
+	// Stopped are the container MCP servers removed from apkg serve.
+	Stopped []string
+}
+
+// Remove removes the named skills and MCP servers from the agent
+// configurations, the manifest, and the lockfile. Store entries are kept
+// unless purge is set: then those no known lockfile references any more
+// (see KnownLockFiles) are deleted, and the removed container servers are
+// removed from apkg serve, or their containers stopped if it isn't
+// running.
+func (ws *Workspace) Remove(ctx context.Context, skills, mcpServers []string, purge bool) (*Removal, error) {
+	cfg, lf, err := ws.Load()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range skills {
+		if _, ok := cfg.Skills[name]; !ok {
+			return nil, fmt.Errorf("%w: skill %q is not in %s", ErrNotFound, name, ws.ManifestPath)
+		}
+	}
+	for _, name := range mcpServers {
+		if _, ok := cfg.MCPServers[name]; !ok {
+			return nil, fmt.Errorf("%w: MCP server %q is not in %s", ErrNotFound, name, ws.ManifestPath)
+		}
+	}
+
+	inst := ws.Installer()
+	removedSkills := make(map[string]config.SkillSource)
+	for _, name := range skills {
+		ss := cfg.Skills[name]
+		if err := inst.RemoveSkill(name, ss.Scope); err != nil {
+			return nil, err
+		}
+		removedSkills[name] = ss
+		delete(cfg.Skills, name)
+	}
+	if err := inst.RemoveMCPServers(mcpServers); err != nil {
+		return nil, err
+	}
+	var containers []string
+	for _, name := range mcpServers {
+		if cfg.MCPServers[name].ContainerMCPConfig != nil {
+			containers = append(containers, name)
+		}
+		delete(cfg.MCPServers, name)
+	}
+
+	if err := config.SaveFile(ws.ManifestPath, cfg); err != nil {
+		return nil, fmt.Errorf("saving %s: %w", ws.ManifestPath, err)
+	}
+	removedLock, remaining := installer.SplitLockFile(lf, removedSkills, mcpServers)
+	if err := ws.SaveLock(remaining); err != nil {
+		return nil, err
+	}
+
+	removal := &Removal{}
+	if !purge {
+		return removal, nil
+	}
+
+	// The lockfile was saved above, so KnownLockFiles sees what remains.
+	referenced, err := KnownLockFiles(ws.Dir)
+	if err != nil {
+		return removal, err
+	}
+	if removal.Purged, err = inst.PurgeStore(removedLock, referenced...); err != nil {
+		return removal, err
+	}
+	var events []journal.Event
+	for _, path := range removal.Purged {
+		name, err := filepath.Rel(ws.Store.Path(), path)
+		if err != nil {
+			name = path
+		}
+		events = append(events, journal.Event{Time: time.Now(), Action: journal.ActionPurge, Name: name, Source: path})
+	}
+	ws.Record(events)
+
+	if len(containers) == 0 {
+		return removal, nil
+	}
+	engine, _ := container.DetectEngine()
+	for _, name := range containers {
+		if err := serve.RemoveServer(ctx, serve.Advertised(ws.Store), engine, name); err != nil {
+			return removal, fmt.Errorf("stopping MCP server %q: %w", name, err)
+		}
+		removal.Stopped = append(removal.Stopped, name)
+	}
+	return removal, nil
+}